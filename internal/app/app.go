@@ -4,25 +4,166 @@ import (
 	"context"
 	"encoding/json"
 	"github.com/jaredpetersen/go-health/health"
+	"io"
+	"net"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/jaredpetersen/go-rest-template/api"
+	"github.com/jaredpetersen/go-rest-template/internal/auth"
+	"github.com/jaredpetersen/go-rest-template/internal/idempotency"
+	"github.com/jaredpetersen/go-rest-template/internal/outbox"
+	"github.com/jaredpetersen/go-rest-template/internal/policy"
+	"github.com/jaredpetersen/go-rest-template/internal/project"
+	"github.com/jaredpetersen/go-rest-template/internal/ratelimit"
 	"github.com/jaredpetersen/go-rest-template/internal/task"
+	"github.com/jaredpetersen/go-rest-template/internal/webhook"
 	"github.com/rs/zerolog/log"
 )
 
 // Define interfaces where they are used
 
 type TaskManager interface {
-	Get(ctx context.Context, id string) (*task.Task, error)
+	Get(ctx context.Context, tenantID string, id string) (*task.Task, error)
+	GetBatch(ctx context.Context, tenantID string, ids []string) ([]task.Task, error)
+	List(ctx context.Context, tenantID string, f task.Filter) ([]task.Task, int, error)
+	ListSubtasks(ctx context.Context, tenantID string, id string, f task.SubtaskFilter) ([]task.Task, int, error)
 	Save(ctx context.Context, t task.Task) error
+	SaveBatch(ctx context.Context, ts []task.Task) error
+	Search(ctx context.Context, tenantID string, query string, limit int) ([]task.SearchResult, error)
+	Update(ctx context.Context, t task.Task) (*task.Task, error)
+	Delete(ctx context.Context, t task.Task) error
+}
+
+type TagManager interface {
+	GetTag(ctx context.Context, tenantID string, id string) (*task.Tag, error)
+	GetTagsByName(ctx context.Context, tenantID string, names []string) ([]task.Tag, error)
+	ListTags(ctx context.Context, tenantID string) ([]task.Tag, error)
+	SaveTag(ctx context.Context, t task.Tag) error
+	RenameTag(ctx context.Context, t task.Tag, name string) (*task.Tag, error)
+	DeleteTag(ctx context.Context, t task.Tag) error
+}
+
+type DependencyManager interface {
+	SaveDependency(ctx context.Context, d task.Dependency) error
+	DeleteDependency(ctx context.Context, d task.Dependency) error
+	SortTasks(ctx context.Context, tenantID string, ids []string) ([]string, error)
+}
+
+type HistoryManager interface {
+	GetRevision(ctx context.Context, tenantID string, id string, version int) (*task.Revision, error)
+	GetRevisionAsOf(ctx context.Context, tenantID string, id string, asOf time.Time) (*task.Revision, error)
+	ListHistory(ctx context.Context, tenantID string, id string, limit int, offset int) ([]task.Revision, error)
+}
+
+type CommentManager interface {
+	GetComment(ctx context.Context, tenantID string, taskID string, id string) (*task.Comment, error)
+	ListComments(ctx context.Context, tenantID string, taskID string, limit int, offset int) (*task.CommentPage, error)
+	SaveComment(ctx context.Context, c task.Comment) error
+	UpdateComment(ctx context.Context, c task.Comment, body string) (*task.Comment, error)
+	DeleteComment(ctx context.Context, c task.Comment) error
+}
+
+type AttachmentManager interface {
+	GetAttachment(ctx context.Context, tenantID string, taskID string, id string) (*task.Attachment, error)
+	ListAttachments(ctx context.Context, tenantID string, taskID string) ([]task.Attachment, error)
+	SaveAttachment(ctx context.Context, a task.Attachment, r io.Reader) error
+	OpenAttachment(ctx context.Context, a task.Attachment) io.ReadSeekCloser
+	DeleteAttachment(ctx context.Context, a task.Attachment) error
+}
+
+type ProjectManager interface {
+	Get(ctx context.Context, tenantID string, id string) (*project.Project, error)
+	List(ctx context.Context, tenantID string, includeArchived bool) ([]project.Project, error)
+	Save(ctx context.Context, p project.Project) error
+	Update(ctx context.Context, p project.Project) error
+}
+
+type WebhookManager interface {
+	GetSubscription(ctx context.Context, tenantID string, id string) (*webhook.Subscription, error)
+	ListSubscriptions(ctx context.Context, tenantID string) ([]webhook.Subscription, error)
+	SaveSubscription(ctx context.Context, s webhook.Subscription) error
+	UpdateSubscription(ctx context.Context, s webhook.Subscription) error
+	DeleteSubscription(ctx context.Context, tenantID string, id string) error
+	GetDelivery(ctx context.Context, tenantID string, id string) (*webhook.Delivery, error)
+	ListDeliveries(ctx context.Context, tenantID string, subscriptionID string, f webhook.DeliveryFilter) ([]webhook.Delivery, error)
+	UpdateDelivery(ctx context.Context, d webhook.Delivery) error
+}
+
+type TaskEventFeed interface {
+	Since(ctx context.Context, lastID string, limit int64) ([]outbox.Entry, bool, error)
+	Subscribe() (<-chan outbox.Entry, func())
+}
+
+type TaskPresence interface {
+	Join(ctx context.Context, tenantID string, taskID string, subject string, sessionID string) error
+	Leave(ctx context.Context, tenantID string, taskID string, subject string, sessionID string) error
+	List(ctx context.Context, tenantID string, taskID string) ([]string, error)
+	Watch(tenantID string, taskID string) (<-chan struct{}, func())
+}
+
+type Authenticator interface {
+	Authenticate(req *http.Request) (*auth.Principal, error)
+}
+
+type Authorizer interface {
+	Authorize(p auth.Principal, action policy.Action, t *task.Task) bool
+	AuthorizeEach(p auth.Principal, action policy.Action, ts []task.Task) []bool
+	AuthorizeList(p auth.Principal, action policy.Action) *task.Visibility
+	AllowedActions(p auth.Principal, t *task.Task) []policy.Action
+}
+
+type IdempotencyStore interface {
+	Reserve(ctx context.Context, key string, fingerprint string, ttl time.Duration) (*idempotency.Record, error)
+	Complete(ctx context.Context, key string, rec idempotency.Record, ttl time.Duration) error
+	Release(ctx context.Context, key string) error
+}
+
+type RateLimiter interface {
+	Allow(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error)
+}
+
+type StartupGate interface {
+	Complete() bool
 }
 
 type app struct {
-	router        *chi.Mux
-	HealthMonitor *health.Monitor
-	TaskManager   TaskManager
+	router            *chi.Mux
+	collabConns       collabConnections
+	AttachmentManager AttachmentManager
+	// AttachmentMaxSize is the largest file in bytes that may be attached to a task. Defaults to 25 MiB.
+	AttachmentMaxSize int64
+	Authenticator     Authenticator
+	Authorizer        Authorizer
+	// CollabMaxConnections is the most collaboration connections that the replica holds open at once. Defaults to
+	// 1000.
+	CollabMaxConnections int
+	// CollabMaxConnectionsPerPrincipal is the most collaboration connections that the replica holds open at once for
+	// each principal. Defaults to 5.
+	CollabMaxConnectionsPerPrincipal int
+	// CollabPingInterval is how often collaboration connections are pinged to check that the client is still there,
+	// which also keeps proxies from closing them. Defaults to 30 seconds.
+	CollabPingInterval time.Duration
+	CommentManager     CommentManager
+	DependencyManager  DependencyManager
+	HealthMonitor      *health.Monitor
+	HistoryManager     HistoryManager
+	IdempotencyStore   IdempotencyStore
+	IdempotencyWindow  time.Duration
+	LivenessChecks     []health.Check
+	ProjectManager     ProjectManager
+	RateLimiter        RateLimiter
+	RateLimits         map[string]ratelimit.Limit
+	StartupGate        StartupGate
+	TagManager         TagManager
+	TaskEventFeed      TaskEventFeed
+	// TaskEventHeartbeat is how often a comment is sent on task event streams that have no events to send, so that
+	// proxies do not close them. Defaults to 15 seconds.
+	TaskEventHeartbeat time.Duration
+	TaskManager        TaskManager
+	TaskPresence       TaskPresence
+	WebhookManager     WebhookManager
 }
 
 type AppError struct {
@@ -30,6 +171,12 @@ type AppError struct {
 	Internal error
 }
 
+// statusError is an application error along with the status code that it should be responded with
+type statusError struct {
+	AppError
+	StatusCode int
+}
+
 func New() *app {
 	a := &app{}
 	a.routes()
@@ -41,6 +188,26 @@ func (a *app) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	a.router.ServeHTTP(w, req)
 }
 
+// connContextKey is the context key for the connection that a request was received on
+type connContextKey struct{}
+
+// ConnContext keeps the connection that a request was received on in the request's context so that handlers of
+// long-lived responses can extend the server's WriteTimeout. Used as the ConnContext of the http.Server.
+func ConnContext(ctx context.Context, c net.Conn) context.Context {
+	return context.WithValue(ctx, connContextKey{}, c)
+}
+
+// extendWriteDeadline gives the handler until the deadline to write the rest of the response, replacing the deadline
+// set by the server's WriteTimeout. Nothing is extended when the server was not set up with ConnContext.
+func extendWriteDeadline(req *http.Request, deadline time.Time) error {
+	c, ok := req.Context().Value(connContextKey{}).(net.Conn)
+	if !ok {
+		return nil
+	}
+
+	return c.SetWriteDeadline(deadline)
+}
+
 func receive(req *http.Request, data interface{}) error {
 	// raw, _ := io.ReadAll(req.Body)
 	// log.Debug().Str("raw", string(raw)).Send()
@@ -1,18 +1,56 @@
 package app
 
 import (
+	"context"
 	"github.com/jaredpetersen/go-health/health"
 	"github.com/jaredpetersen/go-rest-template/api"
 	"net/http"
+
+	"github.com/rs/zerolog/hlog"
 )
 
+// handleStartup creates a HTTP handler that indicates when the application has finished initializing
+func (a *app) handleStartup() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if !a.started() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
 // handleLiveness creates a HTTP handler that indicates when the application is alive or dead
+//
+// Liveness checks are executed on every request rather than being monitored in the background. They must only evaluate
+// the internal state of the process -- never external dependencies -- so that a dependency outage does not result in
+// the application being restarted.
 func (a *app) handleLiveness() http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
-		w.WriteHeader(200)
+		for _, check := range a.LivenessChecks {
+			if checkLiveness(req.Context(), check) == health.StateDown {
+				hlog.FromRequest(req).Error().Str("check", check.Name).Msg("Liveness check failed")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+		}
+
+		w.WriteHeader(http.StatusOK)
 	}
 }
 
+// checkLiveness executes a liveness check, respecting the check's timeout.
+func checkLiveness(ctx context.Context, check health.Check) health.State {
+	if check.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, check.Timeout)
+		defer cancel()
+	}
+
+	return check.Func(ctx).State
+}
+
 // handleReadiness creates a HTTP handler that indicates when the application is ready to serve traffic
 func (a *app) handleReadiness() http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
@@ -35,8 +73,13 @@ func (a *app) handleReadiness() http.HandlerFunc {
 			},
 		}
 
+		// Dependencies may look healthy before initialization has completed, so don't accept traffic until it has
+		if !a.started() {
+			res.State = api.HealthStateSTARTING
+		}
+
 		var statusCode int
-		if res.State == api.HealthStateDOWN {
+		if res.State == api.HealthStateDOWN || res.State == api.HealthStateSTARTING {
 			statusCode = http.StatusServiceUnavailable
 		} else {
 			statusCode = http.StatusOK
@@ -46,6 +89,12 @@ func (a *app) handleReadiness() http.HandlerFunc {
 	}
 }
 
+// started indicates whether or not the application has finished initializing. Applications without a startup gate are
+// always considered started.
+func (a *app) started() bool {
+	return a.StartupGate == nil || a.StartupGate.Complete()
+}
+
 func transformState(monitorState health.State) api.HealthState {
 	var state api.HealthState
 	if monitorState == health.StateUp {
@@ -6,6 +6,7 @@ import (
 	"github.com/jaredpetersen/go-health/health"
 	"github.com/jaredpetersen/go-rest-template/api"
 	"github.com/jaredpetersen/go-rest-template/internal/app"
+	"github.com/jaredpetersen/go-rest-template/internal/app/mocks"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -21,6 +22,44 @@ func buildHealthCheckFunc(status health.Status) health.CheckFunc {
 	}
 }
 
+func TestHandleStartup(t *testing.T) {
+	// Set up relevant server dependencies
+	startupGate := mocks.StartupGate{}
+	startupGate.On("Complete").Return(true)
+
+	// Set up server
+	a := app.New()
+	a.StartupGate = &startupGate
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/startup", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	assert.Empty(t, res.Body)
+}
+
+func TestHandleStartupStarting(t *testing.T) {
+	// Set up relevant server dependencies
+	startupGate := mocks.StartupGate{}
+	startupGate.On("Complete").Return(false)
+
+	// Set up server
+	a := app.New()
+	a.StartupGate = &startupGate
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/startup", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, res.Result().StatusCode)
+	assert.Empty(t, res.Body)
+}
+
 func TestHandleLiveness(t *testing.T) {
 	// Set up server
 	a := app.New()
@@ -35,6 +74,42 @@ func TestHandleLiveness(t *testing.T) {
 	assert.Empty(t, res.Body)
 }
 
+func TestHandleLivenessChecksUp(t *testing.T) {
+	// Set up server
+	a := app.New()
+	a.LivenessChecks = []health.Check{
+		health.NewCheck("heartbeat", buildHealthCheckFunc(health.Status{State: health.StateUp})),
+		health.NewCheck("goroutines", buildHealthCheckFunc(health.Status{State: health.StateWarn})),
+	}
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/liveness", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	assert.Empty(t, res.Body)
+}
+
+func TestHandleLivenessChecksDown(t *testing.T) {
+	// Set up server
+	a := app.New()
+	a.LivenessChecks = []health.Check{
+		health.NewCheck("heartbeat", buildHealthCheckFunc(health.Status{State: health.StateUp})),
+		health.NewCheck("goroutines", buildHealthCheckFunc(health.Status{State: health.StateDown})),
+	}
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/liveness", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, res.Result().StatusCode)
+	assert.Empty(t, res.Body)
+}
+
 func TestHandleReadinessStateUp(t *testing.T) {
 	ctx := context.Background()
 
@@ -136,3 +211,40 @@ func TestHandleReadinessStateDown(t *testing.T) {
 	assert.Equal(t, api.HealthStateUP, resBody.Components.Redis.State)
 	assert.NotNil(t, resBody.Components.Redis.Timestamp)
 }
+
+func TestHandleReadinessStateStarting(t *testing.T) {
+	ctx := context.Background()
+
+	// Set up health monitor and wait for it to kick off monitoring goroutines
+	dbHealthCheck := health.NewCheck("database", buildHealthCheckFunc(health.Status{State: health.StateUp}))
+	redisHealthCheck := health.NewCheck("redis", buildHealthCheckFunc(health.Status{State: health.StateUp}))
+
+	healthMonitor := health.New()
+	healthMonitor.Monitor(ctx, redisHealthCheck, dbHealthCheck)
+	time.Sleep(time.Millisecond * 200)
+
+	// Set up relevant server dependencies
+	startupGate := mocks.StartupGate{}
+	startupGate.On("Complete").Return(false)
+
+	// Set up server
+	a := app.New()
+	a.HealthMonitor = healthMonitor
+	a.StartupGate = &startupGate
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/readiness", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	// Decode response body to struct so that we can pick out pieces
+	resBody := api.Health{}
+	err = json.NewDecoder(res.Body).Decode(&resBody)
+	require.NoError(t, err, "Failed to convert response body")
+
+	assert.Equal(t, http.StatusServiceUnavailable, res.Result().StatusCode)
+	assert.Equal(t, api.HealthStateSTARTING, resBody.State)
+	assert.Equal(t, api.HealthStateUP, resBody.Components.CockroachDb.State)
+	assert.Equal(t, api.HealthStateUP, resBody.Components.Redis.State)
+}
@@ -0,0 +1,316 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi"
+	"github.com/jaredpetersen/go-rest-template/api"
+	"github.com/jaredpetersen/go-rest-template/internal/auth"
+	"github.com/jaredpetersen/go-rest-template/internal/policy"
+	"github.com/jaredpetersen/go-rest-template/internal/project"
+)
+
+// maxProjectNameLength is the maximum number of characters in a project name.
+const maxProjectNameLength = 255
+
+// errProjectArchived is returned when changing an archived project or adding tasks to it.
+var errProjectArchived = errors.New("project is archived")
+
+func (a *app) handleProjectList() http.HandlerFunc {
+	// Set up dependencies specific to the handler here
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		var includeArchived bool
+		switch req.URL.Query().Get("archived") {
+		case "", "false":
+		case "true":
+			includeArchived = true
+		default:
+			err := errors.New("query parameter 'archived' must be 'true' or 'false'")
+			respondError(w, AppError{External: err}, http.StatusUnprocessableEntity)
+			return
+		}
+
+		principal := auth.FromContext(req.Context())
+
+		if !a.authorize(principal, policy.ActionProjectRead, nil) {
+			respondError(w, AppError{External: errors.New("forbidden")}, http.StatusForbidden)
+			return
+		}
+
+		ps, err := a.ProjectManager.List(req.Context(), principal.TenantID, includeArchived)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		res := api.ProjectList{Projects: make([]api.Project, 0, len(ps))}
+		for _, p := range ps {
+			res.Projects = append(res.Projects, toAPIProject(p))
+		}
+
+		respond(w, res, http.StatusOK)
+	}
+}
+
+func (a *app) handleProjectGet() http.HandlerFunc {
+	// Set up dependencies specific to the handler here
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		p, ok := a.readableProject(w, req)
+		if !ok {
+			return
+		}
+
+		respond(w, toAPIProject(*p), http.StatusOK)
+	}
+}
+
+func (a *app) handleProjectSave() http.HandlerFunc {
+	// Set up dependencies specific to the handler here
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		val := new(api.NewProject)
+		err := receive(req, val)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusBadRequest)
+			return
+		}
+
+		// Validate request body manually
+		name, err := validateProjectName(val.Name)
+		if err != nil {
+			respondError(w, AppError{External: err}, http.StatusUnprocessableEntity)
+			return
+		}
+
+		principal := auth.FromContext(req.Context())
+
+		if !a.authorize(principal, policy.ActionProjectCreate, nil) {
+			respondError(w, AppError{External: errors.New("forbidden")}, http.StatusForbidden)
+			return
+		}
+
+		p := project.New()
+		p.TenantID = principal.TenantID
+		p.OwnerID = principal.Subject
+		p.Name = name
+		if val.Description != nil {
+			p.Description = *val.Description
+		}
+
+		err = a.ProjectManager.Save(req.Context(), *p)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, api.Identifier{Id: p.ID}, http.StatusCreated)
+	}
+}
+
+func (a *app) handleProjectUpdate() http.HandlerFunc {
+	// Set up dependencies specific to the handler here
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		val := new(api.NewProject)
+		err := receive(req, val)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusBadRequest)
+			return
+		}
+
+		// Validate request body manually
+		name, err := validateProjectName(val.Name)
+		if err != nil {
+			respondError(w, AppError{External: err}, http.StatusUnprocessableEntity)
+			return
+		}
+
+		id := chi.URLParam(req, "id")
+		principal := auth.FromContext(req.Context())
+
+		if !a.authorize(principal, policy.ActionProjectUpdate, nil) {
+			respondError(w, AppError{External: errors.New("forbidden")}, http.StatusForbidden)
+			return
+		}
+
+		p, err := a.ProjectManager.Get(req.Context(), principal.TenantID, id)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		if p == nil {
+			respond(w, nil, http.StatusNotFound)
+			return
+		}
+
+		if p.Archived() {
+			respondError(w, AppError{External: errProjectArchived}, http.StatusConflict)
+			return
+		}
+
+		p.Name = name
+		if val.Description != nil {
+			p.Description = *val.Description
+		}
+		p.DateUpdated = time.Now()
+
+		err = a.ProjectManager.Update(req.Context(), *p)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, toAPIProject(*p), http.StatusOK)
+	}
+}
+
+func (a *app) handleProjectArchive() http.HandlerFunc {
+	// Set up dependencies specific to the handler here
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		a.setProjectArchived(w, req, true)
+	}
+}
+
+func (a *app) handleProjectUnarchive() http.HandlerFunc {
+	// Set up dependencies specific to the handler here
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		a.setProjectArchived(w, req, false)
+	}
+}
+
+func (a *app) handleProjectTaskList() http.HandlerFunc {
+	// Set up dependencies specific to the handler here
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		p, ok := a.readableProject(w, req)
+		if !ok {
+			return
+		}
+
+		// Tasks in archived projects are hidden from other task lists but are still listed within their project
+		a.listTasks(w, req, p.ID)
+	}
+}
+
+func (a *app) handleProjectTaskSave() http.HandlerFunc {
+	// Set up dependencies specific to the handler here
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		p, ok := a.readableProject(w, req)
+		if !ok {
+			return
+		}
+
+		if p.Archived() {
+			respondError(w, AppError{External: errProjectArchived}, http.StatusConflict)
+			return
+		}
+
+		a.saveTask(w, req, p.ID)
+	}
+}
+
+// readableProject retrieves the project identified by the request path and makes sure that the principal may read it.
+// A response is written and false is returned if the project cannot be read.
+func (a *app) readableProject(w http.ResponseWriter, req *http.Request) (*project.Project, bool) {
+	id := chi.URLParam(req, "id")
+	principal := auth.FromContext(req.Context())
+
+	if !a.authorize(principal, policy.ActionProjectRead, nil) {
+		respondError(w, AppError{External: errors.New("forbidden")}, http.StatusForbidden)
+		return nil, false
+	}
+
+	// Projects that belong to other tenants are indistinguishable from projects that do not exist
+	p, err := a.ProjectManager.Get(req.Context(), principal.TenantID, id)
+	if err != nil {
+		respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+		return nil, false
+	}
+
+	if p == nil {
+		respond(w, nil, http.StatusNotFound)
+		return nil, false
+	}
+
+	return p, true
+}
+
+// setProjectArchived archives or restores the project identified by the request path. Projects that are already in the
+// requested state are left alone.
+func (a *app) setProjectArchived(w http.ResponseWriter, req *http.Request, archived bool) {
+	id := chi.URLParam(req, "id")
+	principal := auth.FromContext(req.Context())
+
+	if !a.authorize(principal, policy.ActionProjectArchive, nil) {
+		respondError(w, AppError{External: errors.New("forbidden")}, http.StatusForbidden)
+		return
+	}
+
+	p, err := a.ProjectManager.Get(req.Context(), principal.TenantID, id)
+	if err != nil {
+		respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+		return
+	}
+
+	if p == nil {
+		respond(w, nil, http.StatusNotFound)
+		return
+	}
+
+	if p.Archived() == archived {
+		respond(w, toAPIProject(*p), http.StatusOK)
+		return
+	}
+
+	now := time.Now()
+	p.DateArchived = nil
+	if archived {
+		p.DateArchived = &now
+	}
+	p.DateUpdated = now
+
+	err = a.ProjectManager.Update(req.Context(), *p)
+	if err != nil {
+		respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, toAPIProject(*p), http.StatusOK)
+}
+
+// toAPIProject converts the project to its API representation
+func toAPIProject(p project.Project) api.Project {
+	return api.Project{
+		Id:           p.ID,
+		OwnerId:      p.OwnerID,
+		Name:         p.Name,
+		Description:  p.Description,
+		TaskCount:    p.TaskCount,
+		Archived:     p.Archived(),
+		DateArchived: p.DateArchived,
+	}
+}
+
+// validateProjectName validates and normalizes the name of a project
+func validateProjectName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", errors.New("field 'name' is required")
+	}
+	if utf8.RuneCountInString(name) > maxProjectNameLength {
+		return "", fmt.Errorf("field 'name' must not be longer than %d characters", maxProjectNameLength)
+	}
+
+	return name, nil
+}
@@ -0,0 +1,447 @@
+package app_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaredpetersen/go-rest-template/internal/app"
+	"github.com/jaredpetersen/go-rest-template/internal/app/mocks"
+	"github.com/jaredpetersen/go-rest-template/internal/project"
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func buildProject(name string) *project.Project {
+	p := project.New()
+	p.TenantID = "tenant-1"
+	p.OwnerID = "user-1"
+	p.Name = name
+	return p
+}
+
+func TestHandleProjectList(t *testing.T) {
+	home := buildProject("Home")
+	home.TaskCount = 2
+
+	// Set up relevant server dependencies
+	projMgr := mocks.ProjectManager{}
+	projMgr.On("List", mock.Anything, "tenant-1", true).Return([]project.Project{*home}, nil)
+
+	// Set up server
+	a := app.New()
+	a.ProjectManager = &projMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/projects?archived=true", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	expectedJSON := fmt.Sprintf(`{"projects": [{
+		"id": "%s", "ownerId": "user-1", "name": "Home", "description": "", "taskCount": 2, "archived": false, "dateArchived": null
+	}]}`, home.ID)
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	assert.JSONEq(t, expectedJSON, res.Body.String())
+
+	projMgr.AssertExpectations(t)
+}
+
+func TestHandleProjectListInvalidArchived(t *testing.T) {
+	// Set up relevant server dependencies
+	projMgr := mocks.ProjectManager{}
+
+	// Set up server
+	a := app.New()
+	a.ProjectManager = &projMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/projects?archived=maybe", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Result().StatusCode)
+	assert.JSONEq(t, `{"message": "query parameter 'archived' must be 'true' or 'false'"}`, res.Body.String())
+}
+
+func TestHandleProjectGetNotFound(t *testing.T) {
+	// Set up relevant server dependencies
+	projMgr := mocks.ProjectManager{}
+	projMgr.On("Get", mock.Anything, "tenant-1", "nonexistent").Return(nil, nil)
+
+	// Set up server
+	a := app.New()
+	a.ProjectManager = &projMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/projects/nonexistent", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusNotFound, res.Result().StatusCode)
+	assert.Empty(t, res.Body)
+}
+
+func TestHandleProjectGetForbidden(t *testing.T) {
+	// Set up relevant server dependencies
+	projMgr := mocks.ProjectManager{}
+
+	// Set up server
+	a := app.New()
+	a.ProjectManager = &projMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(false)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/projects/someid", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusForbidden, res.Result().StatusCode)
+	projMgr.AssertNotCalled(t, "Get", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleProjectSave(t *testing.T) {
+	// Set up relevant server dependencies
+	projMgr := mocks.ProjectManager{}
+	projMgr.On("Save", mock.Anything, mock.MatchedBy(func(p project.Project) bool {
+		return p.TenantID == "tenant-1" && p.OwnerID == "user-1" && p.Name == "Home" && p.Description == "Chores"
+	})).Return(nil)
+
+	// Set up server
+	a := app.New()
+	a.ProjectManager = &projMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodPost, "/projects", strings.NewReader(`{"name": " Home ", "description": "Chores"}`))
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusCreated, res.Result().StatusCode)
+
+	projMgr.AssertExpectations(t)
+}
+
+func TestHandleProjectSaveMissingName(t *testing.T) {
+	// Set up relevant server dependencies
+	projMgr := mocks.ProjectManager{}
+
+	// Set up server
+	a := app.New()
+	a.ProjectManager = &projMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodPost, "/projects", strings.NewReader(`{"description": "Chores"}`))
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Result().StatusCode)
+	assert.JSONEq(t, `{"message": "field 'name' is required"}`, res.Body.String())
+	projMgr.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
+
+func TestHandleProjectUpdate(t *testing.T) {
+	p := buildProject("Home")
+	p.Description = "Chores"
+
+	// Set up relevant server dependencies
+	projMgr := mocks.ProjectManager{}
+	projMgr.On("Get", mock.Anything, "tenant-1", p.ID).Return(p, nil)
+	projMgr.On("Update", mock.Anything, mock.MatchedBy(func(updated project.Project) bool {
+		// The description is left alone when it is not provided
+		return updated.ID == p.ID && updated.Name == "House" && updated.Description == "Chores"
+	})).Return(nil)
+
+	// Set up server
+	a := app.New()
+	a.ProjectManager = &projMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodPut, "/projects/"+p.ID, strings.NewReader(`{"name": "House"}`))
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+
+	projMgr.AssertExpectations(t)
+}
+
+func TestHandleProjectUpdateArchived(t *testing.T) {
+	p := buildProject("Home")
+	archived := time.Now()
+	p.DateArchived = &archived
+
+	// Set up relevant server dependencies
+	projMgr := mocks.ProjectManager{}
+	projMgr.On("Get", mock.Anything, "tenant-1", p.ID).Return(p, nil)
+
+	// Set up server
+	a := app.New()
+	a.ProjectManager = &projMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodPut, "/projects/"+p.ID, strings.NewReader(`{"name": "House"}`))
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusConflict, res.Result().StatusCode)
+	assert.JSONEq(t, `{"message": "project is archived"}`, res.Body.String())
+	projMgr.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestHandleProjectArchive(t *testing.T) {
+	p := buildProject("Home")
+
+	// Set up relevant server dependencies
+	projMgr := mocks.ProjectManager{}
+	projMgr.On("Get", mock.Anything, "tenant-1", p.ID).Return(p, nil)
+	projMgr.On("Update", mock.Anything, mock.MatchedBy(func(updated project.Project) bool {
+		return updated.ID == p.ID && updated.Archived()
+	})).Return(nil)
+
+	// Set up server
+	a := app.New()
+	a.ProjectManager = &projMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodPost, "/projects/"+p.ID+":archive", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	assert.Contains(t, res.Body.String(), `"archived":true`)
+
+	projMgr.AssertExpectations(t)
+}
+
+func TestHandleProjectUnarchive(t *testing.T) {
+	p := buildProject("Home")
+	archived := time.Now()
+	p.DateArchived = &archived
+
+	// Set up relevant server dependencies
+	projMgr := mocks.ProjectManager{}
+	projMgr.On("Get", mock.Anything, "tenant-1", p.ID).Return(p, nil)
+	projMgr.On("Update", mock.Anything, mock.MatchedBy(func(updated project.Project) bool {
+		return updated.ID == p.ID && !updated.Archived()
+	})).Return(nil)
+
+	// Set up server
+	a := app.New()
+	a.ProjectManager = &projMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodPost, "/projects/"+p.ID+":unarchive", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	assert.Contains(t, res.Body.String(), `"archived":false`)
+
+	projMgr.AssertExpectations(t)
+}
+
+func TestHandleProjectArchiveForbidden(t *testing.T) {
+	// Set up relevant server dependencies
+	projMgr := mocks.ProjectManager{}
+
+	// Set up server
+	a := app.New()
+	a.ProjectManager = &projMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(false)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodPost, "/projects/someid:archive", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusForbidden, res.Result().StatusCode)
+	projMgr.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestHandleProjectTaskList(t *testing.T) {
+	p := buildProject("Home")
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+	tsk.ProjectID = p.ID
+	tsk.Description = "Paint fence"
+
+	// Set up relevant server dependencies
+	projMgr := mocks.ProjectManager{}
+	projMgr.On("Get", mock.Anything, "tenant-1", p.ID).Return(p, nil)
+
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("List", mock.Anything, "tenant-1", task.Filter{ProjectID: p.ID, Limit: 20}).Return([]task.Task{*tsk}, 1, nil)
+
+	// Set up server
+	a := app.New()
+	a.ProjectManager = &projMgr
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/projects/"+p.ID+"/tasks", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	expectedJSON := fmt.Sprintf(`{
+		"tasks": [{"id": "%s", "ownerId": "user-1", "projectId": "%s", "description": "Paint fence", "dateDue": null,
+			"parentId": null, "completed": false, "dateCompleted": null, "subtasks": {"total": 0, "completed": 0, "percentComplete": 0},
+			"blocked": false, "blockedBy": [], "recurrence": null, "reminders": [],
+			"shares": [], "tags": [], "commentCount": 0, "version": 1}],
+		"total": 1,
+		"limit": 20,
+		"offset": 0
+	}`, tsk.ID, p.ID)
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	assert.JSONEq(t, expectedJSON, res.Body.String())
+
+	tskMgr.AssertExpectations(t)
+}
+
+func TestHandleProjectTaskSave(t *testing.T) {
+	p := buildProject("Home")
+
+	// Set up relevant server dependencies
+	projMgr := mocks.ProjectManager{}
+	projMgr.On("Get", mock.Anything, "tenant-1", p.ID).Return(p, nil)
+
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Save", mock.Anything, mock.MatchedBy(func(tsk task.Task) bool {
+		return tsk.ProjectID == p.ID && tsk.Description == "Paint fence"
+	})).Return(nil)
+
+	// Set up server
+	a := app.New()
+	a.ProjectManager = &projMgr
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodPost, "/projects/"+p.ID+"/tasks", strings.NewReader(`{"description": "Paint fence"}`))
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusCreated, res.Result().StatusCode)
+
+	tskMgr.AssertExpectations(t)
+}
+
+func TestHandleProjectTaskSaveArchived(t *testing.T) {
+	p := buildProject("Home")
+	archived := time.Now()
+	p.DateArchived = &archived
+
+	// Set up relevant server dependencies
+	projMgr := mocks.ProjectManager{}
+	projMgr.On("Get", mock.Anything, "tenant-1", p.ID).Return(p, nil)
+
+	tskMgr := mocks.TaskManager{}
+
+	// Set up server
+	a := app.New()
+	a.ProjectManager = &projMgr
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodPost, "/projects/"+p.ID+"/tasks", strings.NewReader(`{"description": "Paint fence"}`))
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusConflict, res.Result().StatusCode)
+	assert.JSONEq(t, `{"message": "project is archived"}`, res.Body.String())
+	tskMgr.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
+
+func TestHandleProjectTaskSaveArchivedConcurrently(t *testing.T) {
+	p := buildProject("Home")
+
+	// Set up relevant server dependencies
+	projMgr := mocks.ProjectManager{}
+	projMgr.On("Get", mock.Anything, "tenant-1", p.ID).Return(p, nil)
+
+	// The project was archived after it was retrieved
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Save", mock.Anything, mock.Anything).Return(task.ErrProjectUnavailable)
+
+	// Set up server
+	a := app.New()
+	a.ProjectManager = &projMgr
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodPost, "/projects/"+p.ID+"/tasks", strings.NewReader(`{"description": "Paint fence"}`))
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusConflict, res.Result().StatusCode)
+}
+
+func TestHandleProjectGetError(t *testing.T) {
+	// Set up relevant server dependencies
+	projMgr := mocks.ProjectManager{}
+	projMgr.On("Get", mock.Anything, "tenant-1", "someid").Return(nil, errors.New("failure to get project"))
+
+	// Set up server
+	a := app.New()
+	a.ProjectManager = &projMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/projects/someid", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusInternalServerError, res.Result().StatusCode)
+	assert.Empty(t, res.Body)
+}
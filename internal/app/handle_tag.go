@@ -0,0 +1,281 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi"
+	"github.com/jaredpetersen/go-rest-template/api"
+	"github.com/jaredpetersen/go-rest-template/internal/auth"
+	"github.com/jaredpetersen/go-rest-template/internal/policy"
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+)
+
+// maxTagNameLength is the maximum number of characters in a tag name.
+const maxTagNameLength = 64
+
+func (a *app) handleTagList() http.HandlerFunc {
+	// Set up dependencies specific to the handler here
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		principal := auth.FromContext(req.Context())
+
+		tgs, err := a.TagManager.ListTags(req.Context(), principal.TenantID)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		res := api.TagList{Tags: make([]api.Tag, 0, len(tgs))}
+		for _, tg := range tgs {
+			res.Tags = append(res.Tags, toAPITag(tg))
+		}
+
+		respond(w, res, http.StatusOK)
+	}
+}
+
+func (a *app) handleTagGet() http.HandlerFunc {
+	// Set up dependencies specific to the handler here
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		id := chi.URLParam(req, "id")
+		principal := auth.FromContext(req.Context())
+
+		tg, err := a.TagManager.GetTag(req.Context(), principal.TenantID, id)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		if tg == nil {
+			respond(w, nil, http.StatusNotFound)
+			return
+		}
+
+		respond(w, toAPITag(*tg), http.StatusOK)
+	}
+}
+
+func (a *app) handleTagSave() http.HandlerFunc {
+	// Set up dependencies specific to the handler here
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		val := new(api.NewTag)
+		err := receive(req, val)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusBadRequest)
+			return
+		}
+
+		// Validate request body manually
+		name, err := validateTagName(val.Name)
+		if err != nil {
+			respondError(w, AppError{External: err}, http.StatusUnprocessableEntity)
+			return
+		}
+
+		principal := auth.FromContext(req.Context())
+
+		if !a.authorize(principal, policy.ActionTagManage, nil) {
+			respondError(w, AppError{External: errors.New("forbidden")}, http.StatusForbidden)
+			return
+		}
+
+		tg := task.NewTag()
+		tg.TenantID = principal.TenantID
+		tg.Name = name
+
+		err = a.TagManager.SaveTag(req.Context(), *tg)
+		if errors.Is(err, task.ErrTagExists) {
+			respondError(w, AppError{External: fmt.Errorf("tag '%s' already exists", name)}, http.StatusConflict)
+			return
+		}
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, api.Identifier{Id: tg.ID}, http.StatusCreated)
+	}
+}
+
+func (a *app) handleTagUpdate() http.HandlerFunc {
+	// Set up dependencies specific to the handler here
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		val := new(api.NewTag)
+		err := receive(req, val)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusBadRequest)
+			return
+		}
+
+		// Validate request body manually
+		name, err := validateTagName(val.Name)
+		if err != nil {
+			respondError(w, AppError{External: err}, http.StatusUnprocessableEntity)
+			return
+		}
+
+		id := chi.URLParam(req, "id")
+		principal := auth.FromContext(req.Context())
+
+		if !a.authorize(principal, policy.ActionTagManage, nil) {
+			respondError(w, AppError{External: errors.New("forbidden")}, http.StatusForbidden)
+			return
+		}
+
+		tg, err := a.TagManager.GetTag(req.Context(), principal.TenantID, id)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		if tg == nil {
+			respond(w, nil, http.StatusNotFound)
+			return
+		}
+
+		if tg.Name == name {
+			respond(w, toAPITag(*tg), http.StatusOK)
+			return
+		}
+
+		// Renaming the tag also renames it on every task that has it
+		renamed, err := a.TagManager.RenameTag(req.Context(), *tg, name)
+		if errors.Is(err, task.ErrTagExists) {
+			respondError(w, AppError{External: fmt.Errorf("tag '%s' already exists", name)}, http.StatusConflict)
+			return
+		}
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, toAPITag(*renamed), http.StatusOK)
+	}
+}
+
+func (a *app) handleTagDelete() http.HandlerFunc {
+	// Set up dependencies specific to the handler here
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		id := chi.URLParam(req, "id")
+		principal := auth.FromContext(req.Context())
+
+		if !a.authorize(principal, policy.ActionTagManage, nil) {
+			respondError(w, AppError{External: errors.New("forbidden")}, http.StatusForbidden)
+			return
+		}
+
+		tg, err := a.TagManager.GetTag(req.Context(), principal.TenantID, id)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		if tg == nil {
+			respond(w, nil, http.StatusNotFound)
+			return
+		}
+
+		// Deleting the tag also removes it from every task that has it
+		err = a.TagManager.DeleteTag(req.Context(), *tg)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// toAPITag converts the tag to its API representation
+func toAPITag(tg task.Tag) api.Tag {
+	return api.Tag{Id: tg.ID, Name: tg.Name}
+}
+
+// validateTagName validates and normalizes the name of a tag
+func validateTagName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", errors.New("field 'name' is required")
+	}
+	if utf8.RuneCountInString(name) > maxTagNameLength {
+		return "", fmt.Errorf("field 'name' must not be longer than %d characters", maxTagNameLength)
+	}
+
+	return name, nil
+}
+
+// fromAPITags validates and converts the API representation of a task's tags. The returned names are unique and
+// sorted.
+func fromAPITags(tags *[]string) ([]string, error) {
+	if tags == nil || len(*tags) == 0 {
+		return nil, nil
+	}
+
+	seen := make(map[string]bool, len(*tags))
+	res := make([]string, 0, len(*tags))
+	for _, name := range *tags {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, errors.New("field 'tags' must not contain empty tags")
+		}
+
+		if !seen[name] {
+			seen[name] = true
+			res = append(res, name)
+		}
+	}
+
+	sort.Strings(res)
+	return res, nil
+}
+
+// unknownTag finds the first of the tag names that the tenant does not have. An empty string is returned if the tenant
+// has every tag.
+func (a *app) unknownTag(ctx context.Context, tenantID string, names []string) (string, error) {
+	known, err := a.knownTags(ctx, tenantID, names)
+	if err != nil {
+		return "", err
+	}
+
+	return firstUnknown(names, known), nil
+}
+
+// knownTags determines which of the tag names the tenant has. Every tag is unknown when tags are not configured.
+func (a *app) knownTags(ctx context.Context, tenantID string, names []string) (map[string]bool, error) {
+	known := make(map[string]bool)
+	if len(names) == 0 || a.TagManager == nil {
+		return known, nil
+	}
+
+	tgs, err := a.TagManager.GetTagsByName(ctx, tenantID, names)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tg := range tgs {
+		known[tg.Name] = true
+	}
+
+	return known, nil
+}
+
+// firstUnknown finds the first of the tag names that is not known. An empty string is returned if every tag is known.
+func firstUnknown(names []string, known map[string]bool) string {
+	for _, name := range names {
+		if !known[name] {
+			return name
+		}
+	}
+
+	return ""
+}
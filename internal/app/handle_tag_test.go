@@ -0,0 +1,324 @@
+package app_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jaredpetersen/go-rest-template/internal/app"
+	"github.com/jaredpetersen/go-rest-template/internal/app/mocks"
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func buildTag(name string) *task.Tag {
+	tg := task.NewTag()
+	tg.TenantID = "tenant-1"
+	tg.Name = name
+	return tg
+}
+
+func TestHandleTagList(t *testing.T) {
+	errand := buildTag("errand")
+	home := buildTag("home")
+
+	// Set up relevant server dependencies
+	tagMgr := mocks.TagManager{}
+	tagMgr.On("ListTags", mock.Anything, "tenant-1").Return([]task.Tag{*errand, *home}, nil)
+
+	// Set up server
+	a := app.New()
+	a.TagManager = &tagMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/tags", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	expectedJSON := `{"tags": [{"id": "` + errand.ID + `", "name": "errand"}, {"id": "` + home.ID + `", "name": "home"}]}`
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	assert.JSONEq(t, expectedJSON, res.Body.String())
+
+	tagMgr.AssertExpectations(t)
+}
+
+func TestHandleTagGet(t *testing.T) {
+	tg := buildTag("errand")
+
+	// Set up relevant server dependencies
+	tagMgr := mocks.TagManager{}
+	tagMgr.On("GetTag", mock.Anything, "tenant-1", tg.ID).Return(tg, nil)
+
+	// Set up server
+	a := app.New()
+	a.TagManager = &tagMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/tags/"+tg.ID, nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	assert.JSONEq(t, `{"id": "`+tg.ID+`", "name": "errand"}`, res.Body.String())
+
+	tagMgr.AssertExpectations(t)
+}
+
+func TestHandleTagGetNotFound(t *testing.T) {
+	// Set up relevant server dependencies
+	tagMgr := mocks.TagManager{}
+	tagMgr.On("GetTag", mock.Anything, "tenant-1", "nonexistent").Return(nil, nil)
+
+	// Set up server
+	a := app.New()
+	a.TagManager = &tagMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/tags/nonexistent", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusNotFound, res.Result().StatusCode)
+	assert.Empty(t, res.Body)
+}
+
+func TestHandleTagSave(t *testing.T) {
+	// Set up relevant server dependencies
+	tagMgr := mocks.TagManager{}
+	tagMgr.On("SaveTag", mock.Anything, mock.MatchedBy(func(tg task.Tag) bool {
+		return tg.TenantID == "tenant-1" && tg.Name == "errand" && tg.ID != ""
+	})).Return(nil)
+
+	// Set up server
+	a := app.New()
+	a.TagManager = &tagMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodPost, "/tags", strings.NewReader(`{"name": "  errand "}`))
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusCreated, res.Result().StatusCode)
+	assert.Contains(t, res.Body.String(), `"id"`)
+
+	tagMgr.AssertExpectations(t)
+}
+
+func TestHandleTagSaveConflict(t *testing.T) {
+	// Set up relevant server dependencies
+	tagMgr := mocks.TagManager{}
+	tagMgr.On("SaveTag", mock.Anything, mock.Anything).Return(task.ErrTagExists)
+
+	// Set up server
+	a := app.New()
+	a.TagManager = &tagMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodPost, "/tags", strings.NewReader(`{"name": "errand"}`))
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusConflict, res.Result().StatusCode)
+	assert.JSONEq(t, `{"message": "tag 'errand' already exists"}`, res.Body.String())
+}
+
+func TestHandleTagSaveInvalidName(t *testing.T) {
+	testCases := []struct {
+		name    string
+		body    string
+		message string
+	}{
+		{name: "Missing", body: `{}`, message: "field 'name' is required"},
+		{name: "Blank", body: `{"name": "   "}`, message: "field 'name' is required"},
+		{
+			name:    "TooLong",
+			body:    `{"name": "` + strings.Repeat("a", 65) + `"}`,
+			message: "field 'name' must not be longer than 64 characters",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Set up relevant server dependencies
+			tagMgr := mocks.TagManager{}
+
+			// Set up server
+			a := app.New()
+			a.TagManager = &tagMgr
+			a.Authenticator = buildAuthenticator("tasks:write")
+			a.Authorizer = buildAuthorizer(true)
+
+			// Make request
+			req, err := http.NewRequest(http.MethodPost, "/tags", strings.NewReader(tc.body))
+			require.NoError(t, err)
+			res := httptest.NewRecorder()
+			a.ServeHTTP(res, req)
+
+			assert.Equal(t, http.StatusUnprocessableEntity, res.Result().StatusCode)
+			assert.JSONEq(t, `{"message": "`+tc.message+`"}`, res.Body.String())
+			tagMgr.AssertNotCalled(t, "SaveTag", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestHandleTagSaveForbidden(t *testing.T) {
+	// Set up relevant server dependencies
+	tagMgr := mocks.TagManager{}
+
+	// Set up server
+	a := app.New()
+	a.TagManager = &tagMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(false)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodPost, "/tags", strings.NewReader(`{"name": "errand"}`))
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusForbidden, res.Result().StatusCode)
+	tagMgr.AssertNotCalled(t, "SaveTag", mock.Anything, mock.Anything)
+}
+
+func TestHandleTagUpdate(t *testing.T) {
+	tg := buildTag("errand")
+	renamed := *tg
+	renamed.Name = "chore"
+
+	// Set up relevant server dependencies
+	tagMgr := mocks.TagManager{}
+	tagMgr.On("GetTag", mock.Anything, "tenant-1", tg.ID).Return(tg, nil)
+	tagMgr.On("RenameTag", mock.Anything, *tg, "chore").Return(&renamed, nil)
+
+	// Set up server
+	a := app.New()
+	a.TagManager = &tagMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodPut, "/tags/"+tg.ID, strings.NewReader(`{"name": "chore"}`))
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	assert.JSONEq(t, `{"id": "`+tg.ID+`", "name": "chore"}`, res.Body.String())
+
+	tagMgr.AssertExpectations(t)
+}
+
+func TestHandleTagUpdateSameName(t *testing.T) {
+	tg := buildTag("errand")
+
+	// Set up relevant server dependencies
+	tagMgr := mocks.TagManager{}
+	tagMgr.On("GetTag", mock.Anything, "tenant-1", tg.ID).Return(tg, nil)
+
+	// Set up server
+	a := app.New()
+	a.TagManager = &tagMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodPut, "/tags/"+tg.ID, strings.NewReader(`{"name": "errand"}`))
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	tagMgr.AssertNotCalled(t, "RenameTag", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleTagUpdateConflict(t *testing.T) {
+	tg := buildTag("errand")
+
+	// Set up relevant server dependencies
+	tagMgr := mocks.TagManager{}
+	tagMgr.On("GetTag", mock.Anything, "tenant-1", tg.ID).Return(tg, nil)
+	tagMgr.On("RenameTag", mock.Anything, mock.Anything, mock.Anything).Return(nil, task.ErrTagExists)
+
+	// Set up server
+	a := app.New()
+	a.TagManager = &tagMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodPut, "/tags/"+tg.ID, strings.NewReader(`{"name": "home"}`))
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusConflict, res.Result().StatusCode)
+	assert.JSONEq(t, `{"message": "tag 'home' already exists"}`, res.Body.String())
+}
+
+func TestHandleTagDelete(t *testing.T) {
+	tg := buildTag("errand")
+
+	// Set up relevant server dependencies
+	tagMgr := mocks.TagManager{}
+	tagMgr.On("GetTag", mock.Anything, "tenant-1", tg.ID).Return(tg, nil)
+	tagMgr.On("DeleteTag", mock.Anything, *tg).Return(nil)
+
+	// Set up server
+	a := app.New()
+	a.TagManager = &tagMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodDelete, "/tags/"+tg.ID, nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusNoContent, res.Result().StatusCode)
+	assert.Empty(t, res.Body)
+
+	tagMgr.AssertExpectations(t)
+}
+
+func TestHandleTagDeleteError(t *testing.T) {
+	tg := buildTag("errand")
+
+	// Set up relevant server dependencies
+	tagMgr := mocks.TagManager{}
+	tagMgr.On("GetTag", mock.Anything, "tenant-1", tg.ID).Return(tg, nil)
+	tagMgr.On("DeleteTag", mock.Anything, mock.Anything).Return(errors.New("failure to delete tag"))
+
+	// Set up server
+	a := app.New()
+	a.TagManager = &tagMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodDelete, "/tags/"+tg.ID, nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusInternalServerError, res.Result().StatusCode)
+	assert.Empty(t, res.Body)
+}
@@ -1,11 +1,19 @@
 package app
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/jaredpetersen/go-rest-template/api"
+	"github.com/jaredpetersen/go-rest-template/internal/auth"
+	"github.com/jaredpetersen/go-rest-template/internal/policy"
 	"github.com/jaredpetersen/go-rest-template/internal/task"
 )
 
@@ -13,9 +21,22 @@ func (a *app) handleTaskGet() http.HandlerFunc {
 	// Set up any dependencies specific to the handler here
 
 	return func(w http.ResponseWriter, req *http.Request) {
+		var asOf *time.Time
+		if param := req.URL.Query().Get("asOf"); param != "" {
+			parsed, err := time.Parse(time.RFC3339, param)
+			if err != nil {
+				err = errors.New("query parameter 'asOf' must be an RFC 3339 date-time")
+				respondError(w, AppError{External: err}, http.StatusUnprocessableEntity)
+				return
+			}
+			asOf = &parsed
+		}
+
 		id := chi.URLParam(req, "id")
+		principal := auth.FromContext(req.Context())
 
-		val, err := a.TaskManager.Get(req.Context(), id)
+		// Tasks that belong to other tenants are indistinguishable from tasks that do not exist
+		val, err := a.TaskManager.Get(req.Context(), principal.TenantID, id)
 		if err != nil {
 			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
 			return
@@ -26,42 +47,608 @@ func (a *app) handleTaskGet() http.HandlerFunc {
 			return
 		}
 
-		res := api.Task{
-			Id:          val.ID,
-			Description: val.Description,
-			DateDue:     val.DateDue,
+		if !a.authorize(principal, policy.ActionTaskRead, val) {
+			respondError(w, AppError{External: errors.New("forbidden")}, http.StatusForbidden)
+			return
 		}
-		respond(w, res, http.StatusOK)
+
+		if asOf != nil {
+			r, err := a.HistoryManager.GetRevisionAsOf(req.Context(), principal.TenantID, id, *asOf)
+			if err != nil {
+				respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+				return
+			}
+
+			if r == nil || r.Action == task.ActionDeleted {
+				respond(w, nil, http.StatusNotFound)
+				return
+			}
+
+			val = pastTask(*val, r.Task)
+		}
+
+		tag := etag(val.Version)
+		w.Header().Set("ETag", tag)
+
+		if ifNoneMatch := req.Header.Get("If-None-Match"); ifNoneMatch != "" && etagMatches(ifNoneMatch, tag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		respond(w, toAPITask(*val), http.StatusOK)
+	}
+}
+
+func (a *app) handleTaskList() http.HandlerFunc {
+	// Set up dependencies specific to the handler here
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		a.listTasks(w, req, "")
 	}
 }
 
+// listTasks responds with a page of the tasks that the principal can read, optionally limited to the tasks in a project
+func (a *app) listTasks(w http.ResponseWriter, req *http.Request, projectID string) {
+	params := req.URL.Query()
+
+	values := params["tag"]
+	tags, err := fromAPITags(&values)
+	if err != nil {
+		respondError(w, AppError{External: errors.New("query parameter 'tag' must not be empty")}, http.StatusUnprocessableEntity)
+		return
+	}
+
+	var anyTag bool
+	switch api.TagMatch(params.Get("tagMatch")) {
+	case "", api.TagMatchAll:
+	case api.TagMatchAny:
+		anyTag = true
+	default:
+		respondError(w, AppError{External: errors.New("query parameter 'tagMatch' must be 'all' or 'any'")}, http.StatusUnprocessableEntity)
+		return
+	}
+
+	limit, offset, err := pageParams(params)
+	if err != nil {
+		respondError(w, AppError{External: err}, http.StatusUnprocessableEntity)
+		return
+	}
+
+	principal := auth.FromContext(req.Context())
+
+	filter := task.Filter{
+		Tags:       tags,
+		AnyTag:     anyTag,
+		ProjectID:  projectID,
+		Visibility: a.authorizeList(principal, policy.ActionTaskRead),
+		Limit:      limit,
+		Offset:     offset,
+	}
+	ts, total, err := a.TaskManager.List(req.Context(), principal.TenantID, filter)
+	if err != nil {
+		respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+		return
+	}
+
+	res := api.TaskList{Tasks: make([]api.Task, len(ts)), Limit: limit, Offset: offset, Total: total}
+	for i := range ts {
+		res.Tasks[i] = toAPITask(ts[i])
+	}
+
+	respond(w, res, http.StatusOK)
+}
+
 func (a *app) handleTaskSave() http.HandlerFunc {
 	// Set up dependencies specific to the handler here
 
 	return func(w http.ResponseWriter, req *http.Request) {
-		val := new(api.NewTask)
+		a.saveTask(w, req, "")
+	}
+}
+
+// saveTask creates a task from the request body, optionally within a project
+func (a *app) saveTask(w http.ResponseWriter, req *http.Request, projectID string) {
+	val := new(api.NewTask)
+	err := receive(req, val)
+	if err != nil {
+		respondError(w, AppError{Internal: err}, http.StatusBadRequest)
+		return
+	}
+
+	principal := auth.FromContext(req.Context())
+
+	// Validate request body manually
+	t, err := fromAPINewTask(principal, *val)
+	if err != nil {
+		respondError(w, AppError{External: err}, http.StatusUnprocessableEntity)
+		return
+	}
+
+	if !a.authorize(principal, policy.ActionTaskCreate, nil) {
+		respondError(w, AppError{External: errors.New("forbidden")}, http.StatusForbidden)
+		return
+	}
+
+	if len(t.Shares) > 0 && !a.authorize(principal, policy.ActionTaskShare, t) {
+		respondError(w, AppError{External: errors.New("forbidden")}, http.StatusForbidden)
+		return
+	}
+
+	unknown, err := a.unknownTag(req.Context(), principal.TenantID, t.Tags)
+	if err != nil {
+		respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+		return
+	}
+	if unknown != "" {
+		respondError(w, AppError{External: unknownTagError(unknown)}, http.StatusUnprocessableEntity)
+		return
+	}
+
+	if t.ParentID != "" && !a.checkParent(w, req, principal, t.ParentID) {
+		return
+	}
+
+	// The project and parent are checked again atomically in case they changed after they were retrieved
+	t.ProjectID = projectID
+	err = a.TaskManager.Save(req.Context(), *t)
+	if errors.Is(err, task.ErrProjectUnavailable) {
+		respondError(w, AppError{External: errProjectArchived}, http.StatusConflict)
+		return
+	}
+	if hierarchyErr := hierarchyError(err); hierarchyErr != nil {
+		respondError(w, AppError{External: hierarchyErr}, http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, api.Identifier{Id: t.ID}, http.StatusCreated)
+}
+
+func (a *app) handleTaskUpdate() http.HandlerFunc {
+	// Set up dependencies specific to the handler here
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		// Require clients to prove that they have seen the latest version so that concurrent updates are not lost
+		ifMatch := req.Header.Get("If-Match")
+		if ifMatch == "" {
+			respondError(w, AppError{External: errors.New("header 'If-Match' is required")}, http.StatusPreconditionRequired)
+			return
+		}
+
+		val := new(api.UpdateTask)
 		err := receive(req, val)
 		if err != nil {
 			respondError(w, AppError{Internal: err}, http.StatusBadRequest)
 			return
 		}
 
-		// Validate request body manually
-		if val.Description == "" {
-			respondError(w, AppError{External: errors.New("field 'description' is required")}, http.StatusUnprocessableEntity)
+		id := chi.URLParam(req, "id")
+		principal := auth.FromContext(req.Context())
+
+		updated, statusErr := a.updateTask(req.Context(), principal, id, ifMatch, *val)
+		if statusErr != nil && statusErr.StatusCode == http.StatusNotFound {
+			respond(w, nil, http.StatusNotFound)
 			return
 		}
+		if statusErr != nil {
+			respondError(w, statusErr.AppError, statusErr.StatusCode)
+			return
+		}
+
+		w.Header().Set("ETag", etag(updated.Version))
+		respond(w, toAPITask(*updated), http.StatusOK)
+	}
+}
+
+// updateTask validates and applies an update to a task on behalf of the principal, provided that the If-Match value
+// names the latest version of the task. It is shared by every way that clients can edit tasks so that they all follow
+// the same rules.
+func (a *app) updateTask(ctx context.Context, principal *auth.Principal, id string, ifMatch string, val api.UpdateTask) (*task.Task, *statusError) {
+	// Validate request body manually
+	if val.Description == "" {
+		return nil, &statusError{AppError{External: errors.New("field 'description' is required")}, http.StatusUnprocessableEntity}
+	}
+
+	shares, err := fromAPIShares(val.Shares)
+	if err != nil {
+		return nil, &statusError{AppError{External: err}, http.StatusUnprocessableEntity}
+	}
 
-		t := task.New()
-		t.Description = val.Description
-		t.DateDue = val.DateDue
+	tags, err := fromAPITags(val.Tags)
+	if err != nil {
+		return nil, &statusError{AppError{External: err}, http.StatusUnprocessableEntity}
+	}
 
-		err = a.TaskManager.Save(req.Context(), *t)
+	var r *task.Recurrence
+	if val.Recurrence != nil {
+		r, err = fromAPIRecurrence(*val.Recurrence, val.DateDue)
+		if err != nil {
+			return nil, &statusError{AppError{External: err}, http.StatusUnprocessableEntity}
+		}
+	}
+
+	reminderOffsets, err := fromAPIReminders(val.Reminders)
+	if err != nil {
+		return nil, &statusError{AppError{External: err}, http.StatusUnprocessableEntity}
+	}
+
+	t, err := a.TaskManager.Get(ctx, principal.TenantID, id)
+	if err != nil {
+		return nil, &statusError{AppError{Internal: err}, http.StatusInternalServerError}
+	}
+
+	if t == nil {
+		return nil, &statusError{AppError{External: errors.New("task not found")}, http.StatusNotFound}
+	}
+
+	if !a.authorize(principal, policy.ActionTaskUpdate, t) {
+		return nil, &statusError{AppError{External: errors.New("forbidden")}, http.StatusForbidden}
+	}
+
+	// Changing who the task is shared with is only permitted for principals that can share the task
+	if val.Shares != nil && !sharesEqual(t.Shares, shares) && !a.authorize(principal, policy.ActionTaskShare, t) {
+		return nil, &statusError{AppError{External: errors.New("forbidden")}, http.StatusForbidden}
+	}
+
+	if !etagMatches(ifMatch, etag(t.Version)) {
+		return nil, &statusError{AppError{External: errors.New("task has been modified")}, http.StatusPreconditionFailed}
+	}
+
+	parentID := t.ParentID
+	if val.ParentId != nil {
+		parentID = strings.TrimSpace(*val.ParentId)
+	}
+
+	unknown, err := a.unknownTag(ctx, principal.TenantID, tags)
+	if err != nil {
+		return nil, &statusError{AppError{Internal: err}, http.StatusInternalServerError}
+	}
+	if unknown != "" {
+		return nil, &statusError{AppError{External: unknownTagError(unknown)}, http.StatusUnprocessableEntity}
+	}
+
+	// Moving the task underneath another task changes that task's subtasks
+	if parentID != t.ParentID && parentID != "" {
+		statusErr := a.parentError(ctx, principal, parentID)
+		if statusErr != nil {
+			return nil, statusErr
+		}
+	}
+
+	t.ParentID = parentID
+	now := time.Now()
+	t.Description = val.Description
+	t.DateDue = val.DateDue
+	t.DateUpdated = now
+	if val.Shares != nil {
+		t.Shares = shares
+	}
+	if val.Tags != nil {
+		t.Tags = tags
+	}
+	if val.Recurrence != nil {
+		// Keep counting occurrences from the original start unless the schedule itself changed
+		if r != nil && t.Recurrence != nil && r.Rule == t.Recurrence.Rule && r.TimeZone == t.Recurrence.TimeZone {
+			r.DateStart = t.Recurrence.DateStart
+		}
+		t.Recurrence = r
+	}
+	if val.Reminders != nil {
+		t.ReminderOffsets = reminderOffsets
+	}
+	if val.Completed != nil && *val.Completed != t.Completed() {
+		t.DateCompleted = nil
+		if *val.Completed {
+			t.DateCompleted = &now
+		}
+	}
+
+	// The version is checked again atomically in case the task was modified after it was retrieved
+	updated, err := a.TaskManager.Update(ctx, *t)
+	if errors.Is(err, task.ErrVersionConflict) {
+		return nil, &statusError{AppError{External: errors.New("task has been modified")}, http.StatusPreconditionFailed}
+	}
+	if hierarchyErr := hierarchyError(err); hierarchyErr != nil {
+		return nil, &statusError{AppError{External: hierarchyErr}, http.StatusUnprocessableEntity}
+	}
+	if err != nil {
+		return nil, &statusError{AppError{Internal: err}, http.StatusInternalServerError}
+	}
+
+	return updated, nil
+}
+
+func (a *app) handleTaskDelete() http.HandlerFunc {
+	// Set up dependencies specific to the handler here
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		// Require clients to prove that they have seen the latest version so that they do not delete changes unseen
+		ifMatch := req.Header.Get("If-Match")
+		if ifMatch == "" {
+			respondError(w, AppError{External: errors.New("header 'If-Match' is required")}, http.StatusPreconditionRequired)
+			return
+		}
+
+		id := chi.URLParam(req, "id")
+		principal := auth.FromContext(req.Context())
+
+		t, err := a.TaskManager.Get(req.Context(), principal.TenantID, id)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		if t == nil {
+			respond(w, nil, http.StatusNotFound)
+			return
+		}
+
+		if !a.authorize(principal, policy.ActionTaskDelete, t) {
+			respondError(w, AppError{External: errors.New("forbidden")}, http.StatusForbidden)
+			return
+		}
+
+		if !etagMatches(ifMatch, etag(t.Version)) {
+			respondError(w, AppError{External: errors.New("task has been modified")}, http.StatusPreconditionFailed)
+			return
+		}
+
+		// The version is checked again atomically in case the task was modified after it was retrieved
+		err = a.TaskManager.Delete(req.Context(), *t)
+		if errors.Is(err, task.ErrVersionConflict) {
+			respondError(w, AppError{External: errors.New("task has been modified")}, http.StatusPreconditionFailed)
+			return
+		}
+		if errors.Is(err, task.ErrHasSubtasks) {
+			respondError(w, AppError{External: errors.New("task has subtasks that must be deleted first")}, http.StatusConflict)
+			return
+		}
 		if err != nil {
 			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
 			return
 		}
 
-		respond(w, api.Identifier{Id: t.ID}, http.StatusCreated)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (a *app) handleTaskPermissions() http.HandlerFunc {
+	// Set up any dependencies specific to the handler here
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		id := chi.URLParam(req, "id")
+		principal := auth.FromContext(req.Context())
+
+		val, err := a.TaskManager.Get(req.Context(), principal.TenantID, id)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		if val == nil {
+			respond(w, nil, http.StatusNotFound)
+			return
+		}
+
+		res := api.Permissions{Actions: []string{}}
+		if a.Authorizer != nil {
+			for _, action := range a.Authorizer.AllowedActions(*principal, val) {
+				res.Actions = append(res.Actions, string(action))
+			}
+		}
+		respond(w, res, http.StatusOK)
+	}
+}
+
+// toAPITask converts the task to its API representation
+func toAPITask(t task.Task) api.Task {
+	return api.Task{
+		Id:            t.ID,
+		OwnerId:       t.OwnerID,
+		ProjectId:     toAPIProjectID(t.ProjectID),
+		ParentId:      toAPIParentID(t.ParentID),
+		Description:   t.Description,
+		DateDue:       t.DateDue,
+		Recurrence:    toAPIRecurrence(t.Recurrence),
+		Reminders:     toAPIReminders(t.ReminderOffsets),
+		Completed:     t.Completed(),
+		DateCompleted: t.DateCompleted,
+		Subtasks:      toAPISubtaskRollup(t.Rollup),
+		Blocked:       t.Blocked(),
+		BlockedBy:     toAPIBlockedBy(t.BlockedBy),
+		Shares:        toAPIShares(t.Shares),
+		Tags:          toAPITags(t.Tags),
+		CommentCount:  t.CommentCount,
+		Version:       t.Version,
+	}
+}
+
+// pastTask combines a task as it was in its history with the parts of it that are not kept in the history, which are
+// as they are now
+func pastTask(current task.Task, past task.Task) *task.Task {
+	past.Rollup = current.Rollup
+	past.BlockedBy = current.BlockedBy
+	past.CommentCount = current.CommentCount
+	return &past
+}
+
+// toAPIProjectID converts the ID of the task's project to its API representation, which is null for tasks that are not
+// in a project
+func toAPIProjectID(projectID string) *string {
+	if projectID == "" {
+		return nil
+	}
+
+	return &projectID
+}
+
+// toAPITags converts the task tags to their API representation
+func toAPITags(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+
+	return tags
+}
+
+// fromAPINewTask validates and converts the API representation of a new task to a task owned by the principal
+func fromAPINewTask(principal *auth.Principal, val api.NewTask) (*task.Task, error) {
+	if val.Description == "" {
+		return nil, errors.New("field 'description' is required")
 	}
+
+	shares, err := fromAPIShares(val.Shares)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := fromAPITags(val.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	var r *task.Recurrence
+	if val.Recurrence != nil {
+		r, err = fromAPIRecurrence(*val.Recurrence, val.DateDue)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	reminderOffsets, err := fromAPIReminders(val.Reminders)
+	if err != nil {
+		return nil, err
+	}
+
+	t := task.New()
+	t.TenantID = principal.TenantID
+	t.OwnerID = principal.Subject
+	if val.ParentId != nil {
+		t.ParentID = strings.TrimSpace(*val.ParentId)
+	}
+	t.Description = val.Description
+	t.DateDue = val.DateDue
+	t.Recurrence = r
+	t.ReminderOffsets = reminderOffsets
+	t.Shares = shares
+	t.Tags = tags
+
+	return t, nil
+}
+
+// toAPIShares converts the task shares to their API representation
+func toAPIShares(shares map[string]string) []api.Share {
+	res := make([]api.Share, 0, len(shares))
+	for principalID, role := range shares {
+		res = append(res, api.Share{PrincipalId: principalID, Role: api.Role(role)})
+	}
+
+	// Maps are unordered so sort for a stable response
+	sort.Slice(res, func(i, j int) bool { return res[i].PrincipalId < res[j].PrincipalId })
+	return res
+}
+
+// fromAPIShares validates and converts the API representation of task shares
+func fromAPIShares(shares *[]api.Share) (map[string]string, error) {
+	if shares == nil || len(*shares) == 0 {
+		return nil, nil
+	}
+
+	res := make(map[string]string, len(*shares))
+	for _, share := range *shares {
+		if share.PrincipalId == "" {
+			return nil, errors.New("field 'shares.principalId' is required")
+		}
+		if !policy.Role(share.Role).Valid() {
+			return nil, fmt.Errorf("field 'shares.role' has unknown role '%s'", share.Role)
+		}
+		if _, ok := res[share.PrincipalId]; ok {
+			return nil, fmt.Errorf("field 'shares' has duplicate principal '%s'", share.PrincipalId)
+		}
+
+		res[share.PrincipalId] = string(share.Role)
+	}
+
+	return res, nil
+}
+
+// toAPIReminders converts the task's reminder offsets to their API representation
+func toAPIReminders(offsets []time.Duration) []api.Reminder {
+	res := make([]api.Reminder, 0, len(offsets))
+	for _, offset := range offsets {
+		res = append(res, api.Reminder{MinutesBefore: int(offset / time.Minute)})
+	}
+
+	return res
+}
+
+// fromAPIReminders validates and converts the API representation of task reminders to reminder offsets, earliest
+// reminder first
+func fromAPIReminders(reminders *[]api.Reminder) ([]time.Duration, error) {
+	if reminders == nil || len(*reminders) == 0 {
+		return nil, nil
+	}
+
+	if len(*reminders) > task.MaxReminders {
+		return nil, fmt.Errorf("field 'reminders' must not have more than %d reminders", task.MaxReminders)
+	}
+
+	maxMinutes := int(task.MaxReminderOffset / time.Minute)
+	res := make([]time.Duration, 0, len(*reminders))
+	seen := make(map[int]bool, len(*reminders))
+	for _, reminder := range *reminders {
+		if reminder.MinutesBefore < 0 || reminder.MinutesBefore > maxMinutes {
+			return nil, fmt.Errorf("field 'reminders.minutesBefore' must be between 0 and %d", maxMinutes)
+		}
+		if seen[reminder.MinutesBefore] {
+			return nil, fmt.Errorf("field 'reminders' has duplicate reminder '%d'", reminder.MinutesBefore)
+		}
+
+		seen[reminder.MinutesBefore] = true
+		res = append(res, time.Duration(reminder.MinutesBefore)*time.Minute)
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i] > res[j] })
+	return res, nil
+}
+
+// unknownTagError builds the error returned when a task refers to a tag that the tenant does not have
+func unknownTagError(name string) error {
+	return fmt.Errorf("field 'tags' has unknown tag '%s'", name)
+}
+
+// sharesEqual determines whether or not the shares grant the same roles to the same principals
+func sharesEqual(a map[string]string, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for principalID, role := range a {
+		if otherRole, ok := b[principalID]; !ok || role != otherRole {
+			return false
+		}
+	}
+
+	return true
+}
+
+// etag builds the entity tag for a version of a task
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// etagMatches determines whether or not an If-Match or If-None-Match header value matches the entity tag. Weak entity
+// tags are compared as if they were strong since task versions are never reused.
+func etagMatches(header string, tag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == tag {
+			return true
+		}
+	}
+
+	return false
 }
@@ -0,0 +1,368 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi"
+	"github.com/jaredpetersen/go-rest-template/api"
+	"github.com/jaredpetersen/go-rest-template/internal/auth"
+	"github.com/jaredpetersen/go-rest-template/internal/policy"
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+	"github.com/rs/zerolog/hlog"
+)
+
+const (
+	// defaultAttachmentMaxSize is the largest file in bytes that may be attached when the limit is not configured
+	defaultAttachmentMaxSize = 25 << 20
+	// multipartOverhead is how many bytes of the request body are allowed for the multipart boundaries and headers
+	// around the file
+	multipartOverhead = 1 << 20
+	// maxAttachmentNameLength is the most characters that the file name of an attachment may have
+	maxAttachmentNameLength = 255
+	// sniffLength is how much of the content is looked at to detect its content type
+	sniffLength = 512
+)
+
+func (a *app) handleTaskAttachmentList() http.HandlerFunc {
+	// Set up dependencies specific to the handler here
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		id := chi.URLParam(req, "id")
+		principal := auth.FromContext(req.Context())
+
+		// Access to the attachments follows access to the task
+		t, err := a.TaskManager.Get(req.Context(), principal.TenantID, id)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		if t == nil {
+			respond(w, nil, http.StatusNotFound)
+			return
+		}
+
+		if !a.authorize(principal, policy.ActionTaskRead, t) {
+			respondError(w, AppError{External: errors.New("forbidden")}, http.StatusForbidden)
+			return
+		}
+
+		as, err := a.AttachmentManager.ListAttachments(req.Context(), principal.TenantID, id)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		res := api.AttachmentList{Attachments: make([]api.Attachment, 0, len(as))}
+		for _, att := range as {
+			res.Attachments = append(res.Attachments, toAPIAttachment(att))
+		}
+
+		respond(w, res, http.StatusOK)
+	}
+}
+
+func (a *app) handleTaskAttachmentGet() http.HandlerFunc {
+	// Set up dependencies specific to the handler here
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		att, ok := a.readableAttachment(w, req)
+		if !ok {
+			return
+		}
+
+		respond(w, toAPIAttachment(*att), http.StatusOK)
+	}
+}
+
+func (a *app) handleTaskAttachmentDownload() http.HandlerFunc {
+	// Set up dependencies specific to the handler here
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		att, ok := a.readableAttachment(w, req)
+		if !ok {
+			return
+		}
+
+		content := &recordingReadSeeker{ReadSeekCloser: a.AttachmentManager.OpenAttachment(req.Context(), *att)}
+		defer content.Close()
+
+		// Browsers must not render uploaded files as part of the site
+		disposition := mime.FormatMediaType("attachment", map[string]string{"filename": att.Name})
+		if disposition == "" {
+			disposition = "attachment"
+		}
+		w.Header().Set("Content-Disposition", disposition)
+		w.Header().Set("Content-Type", att.ContentType)
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("ETag", `"`+att.Checksum+`"`)
+
+		// Handles Range, If-Range, and the other conditional request headers
+		http.ServeContent(w, req, att.Name, att.DateCreated, content)
+
+		if content.err != nil {
+			hlog.FromRequest(req).Error().Err(content.err).Str("attachment", att.ID).Msg("Failed to send attachment content")
+		}
+	}
+}
+
+func (a *app) handleTaskAttachmentSave() http.HandlerFunc {
+	// Set up dependencies specific to the handler here
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		id := chi.URLParam(req, "id")
+		principal := auth.FromContext(req.Context())
+
+		// Permission is checked before the file is received so that it is not received for nothing
+		t, err := a.TaskManager.Get(req.Context(), principal.TenantID, id)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		if t == nil {
+			respond(w, nil, http.StatusNotFound)
+			return
+		}
+
+		if !a.authorize(principal, policy.ActionTaskUpdate, t) {
+			respondError(w, AppError{External: errors.New("forbidden")}, http.StatusForbidden)
+			return
+		}
+
+		maxSize := a.AttachmentMaxSize
+		if maxSize <= 0 {
+			maxSize = defaultAttachmentMaxSize
+		}
+
+		req.Body = http.MaxBytesReader(w, req.Body, maxSize+multipartOverhead)
+		mr, err := req.MultipartReader()
+		if err != nil {
+			respondError(w, AppError{External: errors.New("request body must be multipart/form-data")}, http.StatusUnsupportedMediaType)
+			return
+		}
+
+		var part io.Reader
+		var name string
+		for part == nil {
+			p, err := mr.NextPart()
+			if err == io.EOF {
+				respondError(w, AppError{External: errors.New("field 'file' is required")}, http.StatusUnprocessableEntity)
+				return
+			}
+			if err != nil {
+				respondError(w, AppError{Internal: err}, http.StatusBadRequest)
+				return
+			}
+
+			if p.FormName() == "file" {
+				part = p
+				name = p.FileName()
+			}
+		}
+
+		// Validate file name manually
+		err = validateAttachmentName(name)
+		if err != nil {
+			respondError(w, AppError{External: err}, http.StatusUnprocessableEntity)
+			return
+		}
+
+		// The file is kept on disk until it is stored so that its size and checksum are known up front and large files
+		// are not held in memory
+		f, err := os.CreateTemp("", "attachment-*")
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+		defer os.Remove(f.Name())
+		defer f.Close()
+
+		hash := sha256.New()
+		size, err := io.Copy(io.MultiWriter(f, hash), io.LimitReader(part, maxSize+1))
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusBadRequest)
+			return
+		}
+
+		if size > maxSize {
+			err = fmt.Errorf("field 'file' must not be larger than %d bytes", maxSize)
+			respondError(w, AppError{External: err}, http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		// The content type that the client claims is not trusted
+		sniff := make([]byte, sniffLength)
+		n, err := f.ReadAt(sniff, 0)
+		if err != nil && err != io.EOF {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		_, err = f.Seek(0, io.SeekStart)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		att := task.NewAttachment()
+		att.TenantID = principal.TenantID
+		att.TaskID = id
+		att.Name = name
+		att.ContentType = http.DetectContentType(sniff[:n])
+		att.Size = size
+		att.Checksum = hex.EncodeToString(hash.Sum(nil))
+		att.UploaderID = principal.Subject
+
+		// The task may have been deleted after it was retrieved
+		err = a.AttachmentManager.SaveAttachment(req.Context(), *att, f)
+		if errors.Is(err, task.ErrTaskUnavailable) {
+			respond(w, nil, http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, task.ErrTooManyAttachments) {
+			err = fmt.Errorf("task must not have more than %d attachments", task.MaxAttachments)
+			respondError(w, AppError{External: err}, http.StatusConflict)
+			return
+		}
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, toAPIAttachment(*att), http.StatusCreated)
+	}
+}
+
+func (a *app) handleTaskAttachmentDelete() http.HandlerFunc {
+	// Set up dependencies specific to the handler here
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		id := chi.URLParam(req, "id")
+		attachmentID := chi.URLParam(req, "attachmentId")
+		principal := auth.FromContext(req.Context())
+
+		t, err := a.TaskManager.Get(req.Context(), principal.TenantID, id)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		if t == nil {
+			respond(w, nil, http.StatusNotFound)
+			return
+		}
+
+		if !a.authorize(principal, policy.ActionTaskUpdate, t) {
+			respondError(w, AppError{External: errors.New("forbidden")}, http.StatusForbidden)
+			return
+		}
+
+		att, err := a.AttachmentManager.GetAttachment(req.Context(), principal.TenantID, id, attachmentID)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		if att == nil {
+			respond(w, nil, http.StatusNotFound)
+			return
+		}
+
+		err = a.AttachmentManager.DeleteAttachment(req.Context(), *att)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// readableAttachment retrieves the attachment in the request's path, provided that the principal may read its task.
+// Otherwise, the response is written and false is returned.
+func (a *app) readableAttachment(w http.ResponseWriter, req *http.Request) (*task.Attachment, bool) {
+	id := chi.URLParam(req, "id")
+	attachmentID := chi.URLParam(req, "attachmentId")
+	principal := auth.FromContext(req.Context())
+
+	t, err := a.TaskManager.Get(req.Context(), principal.TenantID, id)
+	if err != nil {
+		respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+		return nil, false
+	}
+
+	if t == nil {
+		respond(w, nil, http.StatusNotFound)
+		return nil, false
+	}
+
+	if !a.authorize(principal, policy.ActionTaskRead, t) {
+		respondError(w, AppError{External: errors.New("forbidden")}, http.StatusForbidden)
+		return nil, false
+	}
+
+	att, err := a.AttachmentManager.GetAttachment(req.Context(), principal.TenantID, id, attachmentID)
+	if err != nil {
+		respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+		return nil, false
+	}
+
+	if att == nil {
+		respond(w, nil, http.StatusNotFound)
+		return nil, false
+	}
+
+	return att, true
+}
+
+// recordingReadSeeker remembers the first error that reading fails with, since http.ServeContent does not report it
+type recordingReadSeeker struct {
+	io.ReadSeekCloser
+	err error
+}
+
+func (r *recordingReadSeeker) Read(p []byte) (int, error) {
+	n, err := r.ReadSeekCloser.Read(p)
+	if err != nil && err != io.EOF && r.err == nil {
+		r.err = err
+	}
+	return n, err
+}
+
+// validateAttachmentName validates the file name of an attachment. Multipart parts only provide the last element of the
+// file name, so it never contains directories.
+func validateAttachmentName(name string) error {
+	if name == "" || name == "." || name == "/" {
+		return errors.New("field 'file' must have a file name")
+	}
+	if !utf8.ValidString(name) {
+		return errors.New("field 'file' must have a UTF-8 file name")
+	}
+	if utf8.RuneCountInString(name) > maxAttachmentNameLength {
+		return fmt.Errorf("field 'file' must not have a file name longer than %d characters", maxAttachmentNameLength)
+	}
+
+	return nil
+}
+
+// toAPIAttachment converts the attachment to its API representation
+func toAPIAttachment(att task.Attachment) api.Attachment {
+	return api.Attachment{
+		Id:          att.ID,
+		Name:        att.Name,
+		ContentType: att.ContentType,
+		Size:        att.Size,
+		Checksum:    att.Checksum,
+		UploaderId:  att.UploaderID,
+		DateCreated: att.DateCreated,
+	}
+}
@@ -0,0 +1,554 @@
+package app_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaredpetersen/go-rest-template/internal/app"
+	"github.com/jaredpetersen/go-rest-template/internal/app/mocks"
+	"github.com/jaredpetersen/go-rest-template/internal/policy"
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// buildAttachment creates an attachment on the task for tests
+func buildAttachment(tsk *task.Task, content string) *task.Attachment {
+	sum := sha256.Sum256([]byte(content))
+	return &task.Attachment{
+		ID:          "attachment-1",
+		TenantID:    tsk.TenantID,
+		TaskID:      tsk.ID,
+		Name:        "menu.txt",
+		ContentType: "text/plain; charset=utf-8",
+		Size:        int64(len(content)),
+		Checksum:    hex.EncodeToString(sum[:]),
+		UploaderID:  "user-2",
+		DateCreated: time.Date(2021, time.October, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+// buildUpload creates a multipart request body with the file in the field for tests
+func buildUpload(t *testing.T, field string, fileName string, content string) (*bytes.Buffer, string) {
+	body := new(bytes.Buffer)
+	mw := multipart.NewWriter(body)
+
+	err := mw.WriteField("note", "ignored")
+	require.NoError(t, err)
+
+	fw, err := mw.CreateFormFile(field, fileName)
+	require.NoError(t, err)
+	_, err = io.WriteString(fw, content)
+	require.NoError(t, err)
+
+	err = mw.Close()
+	require.NoError(t, err)
+
+	return body, mw.FormDataContentType()
+}
+
+func TestHandleTaskAttachmentList(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+
+	att := buildAttachment(tsk, "Sandwiches")
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	attachmentMgr := mocks.AttachmentManager{}
+	attachmentMgr.On("ListAttachments", mock.Anything, "tenant-1", tsk.ID).Return([]task.Attachment{*att}, nil)
+
+	authorizer := mocks.Authorizer{}
+	authorizer.On("Authorize", mock.Anything, policy.ActionTaskRead, tsk).Return(true)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.AttachmentManager = &attachmentMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = &authorizer
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/tasks/"+tsk.ID+"/attachments", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	expectedJSON := `{"attachments": [
+		{"id": "attachment-1", "name": "menu.txt", "contentType": "text/plain; charset=utf-8", "size": 10,
+			"checksum": "` + att.Checksum + `", "uploaderId": "user-2", "dateCreated": "2021-10-01T12:00:00Z"}
+	]}`
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	assert.JSONEq(t, expectedJSON, res.Body.String())
+	attachmentMgr.AssertExpectations(t)
+	authorizer.AssertExpectations(t)
+}
+
+func TestHandleTaskAttachmentListForbidden(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-2"
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	attachmentMgr := mocks.AttachmentManager{}
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.AttachmentManager = &attachmentMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(false)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/tasks/"+tsk.ID+"/attachments", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusForbidden, res.Result().StatusCode)
+	attachmentMgr.AssertNotCalled(t, "ListAttachments", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleTaskAttachmentSave(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+
+	content := "%PDF-1.4 picnic plans"
+	sum := sha256.Sum256([]byte(content))
+	checksum := hex.EncodeToString(sum[:])
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	var stored string
+	attachmentMgr := mocks.AttachmentManager{}
+	attachmentMgr.On("SaveAttachment", mock.Anything, mock.MatchedBy(func(att task.Attachment) bool {
+		return att.TenantID == "tenant-1" && att.TaskID == tsk.ID && att.UploaderID == "user-1"
+	}), mock.Anything).
+		Run(func(args mock.Arguments) {
+			raw, _ := io.ReadAll(args.Get(2).(io.Reader))
+			stored = string(raw)
+		}).
+		Return(nil)
+
+	authorizer := mocks.Authorizer{}
+	authorizer.On("Authorize", mock.Anything, policy.ActionTaskUpdate, tsk).Return(true)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.AttachmentManager = &attachmentMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = &authorizer
+
+	// Make request
+	// The claimed content type and any directories in the file name are not trusted
+	body, contentType := buildUpload(t, "file", "../../plans.pdf", content)
+	req, err := http.NewRequest(http.MethodPost, "/tasks/"+tsk.ID+"/attachments", body)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", contentType)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	require.Equal(t, http.StatusCreated, res.Result().StatusCode, res.Body.String())
+	assert.Contains(t, res.Body.String(), `"name":"plans.pdf"`)
+	assert.Contains(t, res.Body.String(), `"contentType":"application/pdf"`)
+	assert.Contains(t, res.Body.String(), `"size":21`)
+	assert.Contains(t, res.Body.String(), `"checksum":"`+checksum+`"`)
+	assert.Contains(t, res.Body.String(), `"uploaderId":"user-1"`)
+	assert.Equal(t, content, stored, "Did not store content")
+	attachmentMgr.AssertExpectations(t)
+}
+
+func TestHandleTaskAttachmentSaveForbidden(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-2"
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	attachmentMgr := mocks.AttachmentManager{}
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.AttachmentManager = &attachmentMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(false)
+
+	// Make request
+	body, contentType := buildUpload(t, "file", "menu.txt", "Sandwiches")
+	req, err := http.NewRequest(http.MethodPost, "/tasks/"+tsk.ID+"/attachments", body)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", contentType)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusForbidden, res.Result().StatusCode)
+	attachmentMgr.AssertNotCalled(t, "SaveAttachment", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleTaskAttachmentSaveInvalid(t *testing.T) {
+	testCases := []struct {
+		name           string
+		field          string
+		fileName       string
+		content        string
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:           "missing file",
+			field:          "attachment",
+			fileName:       "menu.txt",
+			content:        "Sandwiches",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedError:  "field 'file' is required",
+		},
+		{
+			name:           "missing file name",
+			field:          "file",
+			fileName:       "/",
+			content:        "Sandwiches",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedError:  "field 'file' must have a file name",
+		},
+		{
+			name:           "long file name",
+			field:          "file",
+			fileName:       strings.Repeat("a", 252) + ".txt",
+			content:        "Sandwiches",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedError:  "field 'file' must not have a file name longer than 255 characters",
+		},
+		{
+			name:           "too large",
+			field:          "file",
+			fileName:       "menu.txt",
+			content:        strings.Repeat("a", 17),
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedError:  "field 'file' must not be larger than 16 bytes",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tsk := task.New()
+			tsk.TenantID = "tenant-1"
+			tsk.OwnerID = "user-1"
+
+			// Set up relevant server dependencies
+			tskMgr := mocks.TaskManager{}
+			tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+			attachmentMgr := mocks.AttachmentManager{}
+
+			// Set up server
+			a := app.New()
+			a.TaskManager = &tskMgr
+			a.AttachmentManager = &attachmentMgr
+			a.AttachmentMaxSize = 16
+			a.Authenticator = buildAuthenticator("tasks:write")
+			a.Authorizer = buildAuthorizer(true)
+
+			// Make request
+			body, contentType := buildUpload(t, tc.field, tc.fileName, tc.content)
+			req, err := http.NewRequest(http.MethodPost, "/tasks/"+tsk.ID+"/attachments", body)
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", contentType)
+			res := httptest.NewRecorder()
+			a.ServeHTTP(res, req)
+
+			assert.Equal(t, tc.expectedStatus, res.Result().StatusCode)
+			assert.JSONEq(t, `{"message": "`+tc.expectedError+`"}`, res.Body.String())
+			attachmentMgr.AssertNotCalled(t, "SaveAttachment", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestHandleTaskAttachmentSaveNotMultipart(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodPost, "/tasks/"+tsk.ID+"/attachments", strings.NewReader(`{"file": "menu.txt"}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusUnsupportedMediaType, res.Result().StatusCode)
+	assert.JSONEq(t, `{"message": "request body must be multipart/form-data"}`, res.Body.String())
+}
+
+func TestHandleTaskAttachmentSaveTooManyAttachments(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	attachmentMgr := mocks.AttachmentManager{}
+	attachmentMgr.On("SaveAttachment", mock.Anything, mock.Anything, mock.Anything).Return(task.ErrTooManyAttachments)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.AttachmentManager = &attachmentMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	body, contentType := buildUpload(t, "file", "menu.txt", "Sandwiches")
+	req, err := http.NewRequest(http.MethodPost, "/tasks/"+tsk.ID+"/attachments", body)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", contentType)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusConflict, res.Result().StatusCode)
+	assert.JSONEq(t, `{"message": "task must not have more than 100 attachments"}`, res.Body.String())
+}
+
+func TestHandleTaskAttachmentDownload(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+
+	content := "Sandwiches and lemonade"
+	att := buildAttachment(tsk, content)
+	att.Name = "picnic menu.txt"
+
+	testCases := []struct {
+		name            string
+		header          http.Header
+		expectedStatus  int
+		expectedBody    string
+		expectedHeaders map[string]string
+	}{
+		{
+			name:           "whole",
+			header:         http.Header{},
+			expectedStatus: http.StatusOK,
+			expectedBody:   content,
+			expectedHeaders: map[string]string{
+				"Content-Type":           "text/plain; charset=utf-8",
+				"Content-Length":         "23",
+				"Content-Disposition":    `attachment; filename="picnic menu.txt"`,
+				"X-Content-Type-Options": "nosniff",
+				"ETag":                   `"` + att.Checksum + `"`,
+				"Accept-Ranges":          "bytes",
+			},
+		},
+		{
+			name:           "range",
+			header:         http.Header{"Range": {"bytes=15-22"}},
+			expectedStatus: http.StatusPartialContent,
+			expectedBody:   "lemonade",
+			expectedHeaders: map[string]string{
+				"Content-Range":  "bytes 15-22/23",
+				"Content-Length": "8",
+			},
+		},
+		{
+			name:           "range from unchanged content",
+			header:         http.Header{"Range": {"bytes=15-"}, "If-Range": {`"` + att.Checksum + `"`}},
+			expectedStatus: http.StatusPartialContent,
+			expectedBody:   "lemonade",
+		},
+		{
+			name:           "range from changed content",
+			header:         http.Header{"Range": {"bytes=15-"}, "If-Range": {`"outdated"`}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   content,
+		},
+		{
+			name:           "unsatisfiable range",
+			header:         http.Header{"Range": {"bytes=100-"}},
+			expectedStatus: http.StatusRequestedRangeNotSatisfiable,
+			expectedHeaders: map[string]string{
+				"Content-Range": "bytes */23",
+			},
+		},
+		{
+			name:           "not modified",
+			header:         http.Header{"If-None-Match": {`"` + att.Checksum + `"`}},
+			expectedStatus: http.StatusNotModified,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Set up relevant server dependencies
+			tskMgr := mocks.TaskManager{}
+			tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+			attachmentMgr := mocks.AttachmentManager{}
+			attachmentMgr.On("GetAttachment", mock.Anything, "tenant-1", tsk.ID, "attachment-1").Return(att, nil)
+			attachmentMgr.On("OpenAttachment", mock.Anything, *att).Return(nopCloser{strings.NewReader(content)})
+
+			authorizer := mocks.Authorizer{}
+			authorizer.On("Authorize", mock.Anything, policy.ActionTaskRead, tsk).Return(true)
+
+			// Set up server
+			a := app.New()
+			a.TaskManager = &tskMgr
+			a.AttachmentManager = &attachmentMgr
+			a.Authenticator = buildAuthenticator("tasks:read")
+			a.Authorizer = &authorizer
+
+			// Make request
+			req, err := http.NewRequest(http.MethodGet, "/tasks/"+tsk.ID+"/attachments/attachment-1/content", nil)
+			require.NoError(t, err)
+			for name, values := range tc.header {
+				req.Header[name] = values
+			}
+			res := httptest.NewRecorder()
+			a.ServeHTTP(res, req)
+
+			assert.Equal(t, tc.expectedStatus, res.Result().StatusCode)
+			if tc.expectedBody != "" {
+				assert.Equal(t, tc.expectedBody, res.Body.String())
+			}
+			for name, value := range tc.expectedHeaders {
+				assert.Equal(t, value, res.Header().Get(name), "Incorrect %s header", name)
+			}
+			authorizer.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandleTaskAttachmentDownloadNotFound(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	attachmentMgr := mocks.AttachmentManager{}
+	attachmentMgr.On("GetAttachment", mock.Anything, "tenant-1", tsk.ID, "attachment-1").Return(nil, nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.AttachmentManager = &attachmentMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/tasks/"+tsk.ID+"/attachments/attachment-1/content", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusNotFound, res.Result().StatusCode)
+	attachmentMgr.AssertNotCalled(t, "OpenAttachment", mock.Anything, mock.Anything)
+}
+
+func TestHandleTaskAttachmentDelete(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+
+	att := buildAttachment(tsk, "Sandwiches")
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	attachmentMgr := mocks.AttachmentManager{}
+	attachmentMgr.On("GetAttachment", mock.Anything, "tenant-1", tsk.ID, "attachment-1").Return(att, nil)
+	attachmentMgr.On("DeleteAttachment", mock.Anything, *att).Return(nil)
+
+	authorizer := mocks.Authorizer{}
+	authorizer.On("Authorize", mock.Anything, policy.ActionTaskUpdate, tsk).Return(true)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.AttachmentManager = &attachmentMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = &authorizer
+
+	// Make request
+	req, err := http.NewRequest(http.MethodDelete, "/tasks/"+tsk.ID+"/attachments/attachment-1", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusNoContent, res.Result().StatusCode)
+	attachmentMgr.AssertExpectations(t)
+	authorizer.AssertExpectations(t)
+}
+
+func TestHandleTaskAttachmentDeleteForbidden(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-2"
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	attachmentMgr := mocks.AttachmentManager{}
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.AttachmentManager = &attachmentMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(false)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodDelete, "/tasks/"+tsk.ID+"/attachments/attachment-1", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusForbidden, res.Result().StatusCode)
+	attachmentMgr.AssertNotCalled(t, "DeleteAttachment", mock.Anything, mock.Anything)
+}
+
+// nopCloser adds a no-op Close method to a seekable reader
+type nopCloser struct {
+	io.ReadSeeker
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
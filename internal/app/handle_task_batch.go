@@ -0,0 +1,308 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jaredpetersen/go-rest-template/api"
+	"github.com/jaredpetersen/go-rest-template/internal/auth"
+	"github.com/jaredpetersen/go-rest-template/internal/policy"
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+)
+
+// maxBatchSize is the maximum number of tasks that may be created or retrieved in a single request.
+const maxBatchSize = 100
+
+func (a *app) handleTaskBatchCreate() http.HandlerFunc {
+	// Set up dependencies specific to the handler here
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		val := new(api.BatchCreateTasks)
+		err := receive(req, val)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusBadRequest)
+			return
+		}
+
+		if len(val.Tasks) == 0 {
+			respondError(w, AppError{External: errors.New("field 'tasks' is required")}, http.StatusUnprocessableEntity)
+			return
+		}
+
+		if len(val.Tasks) > maxBatchSize {
+			err = fmt.Errorf("field 'tasks' must not contain more than %d tasks", maxBatchSize)
+			respondError(w, AppError{External: err}, http.StatusUnprocessableEntity)
+			return
+		}
+
+		principal := auth.FromContext(req.Context())
+
+		if !a.authorize(principal, policy.ActionTaskCreate, nil) {
+			respondError(w, AppError{External: errors.New("forbidden")}, http.StatusForbidden)
+			return
+		}
+
+		// Batches are all-or-nothing unless the client opts into partial success
+		atomic := val.Atomic == nil || *val.Atomic
+
+		results := make([]api.BatchCreateResult, len(val.Tasks))
+		ts := make([]task.Task, 0, len(val.Tasks))
+		indexes := make([]int, 0, len(val.Tasks))
+		failed := false
+
+		for i, newTask := range val.Tasks {
+			results[i].Index = i
+
+			t, err := fromAPINewTask(principal, newTask)
+			if err != nil {
+				results[i].Status = http.StatusUnprocessableEntity
+				results[i].Message = stringPtr(err.Error())
+				failed = true
+				continue
+			}
+
+			if len(t.Shares) > 0 && !a.authorize(principal, policy.ActionTaskShare, t) {
+				results[i].Status = http.StatusForbidden
+				results[i].Message = stringPtr("forbidden")
+				failed = true
+				continue
+			}
+
+			ts = append(ts, *t)
+			indexes = append(indexes, i)
+		}
+
+		// Look up the tags of every task in the batch at once rather than one task at a time
+		var names []string
+		for _, t := range ts {
+			names = append(names, t.Tags...)
+		}
+
+		known, err := a.knownTags(req.Context(), principal.TenantID, names)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		tagged := ts[:0]
+		taggedIndexes := indexes[:0]
+		for j, t := range ts {
+			i := indexes[j]
+
+			if unknown := firstUnknown(t.Tags, known); unknown != "" {
+				results[i].Status = http.StatusUnprocessableEntity
+				results[i].Message = stringPtr(unknownTagError(unknown).Error())
+				failed = true
+				continue
+			}
+
+			tagged = append(tagged, t)
+			taggedIndexes = append(taggedIndexes, i)
+		}
+		ts = tagged
+		indexes = taggedIndexes
+
+		// Look up the parents of every subtask in the batch at once too
+		var parentIDs []string
+		for _, t := range ts {
+			if t.ParentID != "" {
+				parentIDs = append(parentIDs, t.ParentID)
+			}
+		}
+
+		parents := make(map[string]task.Task)
+		if len(parentIDs) > 0 {
+			found, err := a.TaskManager.GetBatch(req.Context(), principal.TenantID, parentIDs)
+			if err != nil {
+				respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+				return
+			}
+
+			for _, parent := range found {
+				parents[parent.ID] = parent
+			}
+		}
+
+		parented := ts[:0]
+		parentedIndexes := indexes[:0]
+		for j, t := range ts {
+			i := indexes[j]
+
+			if t.ParentID != "" {
+				parent, ok := parents[t.ParentID]
+				if !ok {
+					results[i].Status = http.StatusUnprocessableEntity
+					results[i].Message = stringPtr(errParentNotFound.Error())
+					failed = true
+					continue
+				}
+
+				if !a.authorize(principal, policy.ActionTaskUpdate, &parent) {
+					results[i].Status = http.StatusForbidden
+					results[i].Message = stringPtr("forbidden")
+					failed = true
+					continue
+				}
+			}
+
+			parented = append(parented, t)
+			parentedIndexes = append(parentedIndexes, i)
+		}
+		ts = parented
+		indexes = parentedIndexes
+
+		if atomic && failed {
+			rejectBatch(w, results, indexes)
+			return
+		}
+
+		// Parents are checked again atomically in case they changed after they were retrieved. Nothing is stored if a
+		// task can no longer be a subtask of its parent, so that task fails on its own and the rest of the batch is
+		// tried again without it.
+		for len(ts) > 0 {
+			err = a.TaskManager.SaveBatch(req.Context(), ts)
+			if err == nil {
+				break
+			}
+
+			var batchErr *task.BatchError
+			hierarchyErr := hierarchyError(err)
+			if hierarchyErr == nil || !errors.As(err, &batchErr) {
+				respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+				return
+			}
+
+			j := batchErr.Index
+			results[indexes[j]].Status = http.StatusUnprocessableEntity
+			results[indexes[j]].Message = stringPtr(hierarchyErr.Error())
+			failed = true
+			ts = append(ts[:j], ts[j+1:]...)
+			indexes = append(indexes[:j], indexes[j+1:]...)
+
+			if atomic {
+				rejectBatch(w, results, indexes)
+				return
+			}
+		}
+
+		for j, i := range indexes {
+			results[i].Status = http.StatusCreated
+			results[i].Id = stringPtr(ts[j].ID)
+		}
+
+		statusCode := http.StatusCreated
+		if failed {
+			statusCode = http.StatusOK
+		}
+
+		respond(w, api.BatchCreateResults{Results: results}, statusCode)
+	}
+}
+
+// rejectBatch responds to an atomic batch that failed, marking the tasks at the indexes, which did not fail themselves,
+// as not created because of the others
+func rejectBatch(w http.ResponseWriter, results []api.BatchCreateResult, indexes []int) {
+	for _, i := range indexes {
+		results[i].Status = http.StatusFailedDependency
+		results[i].Message = stringPtr("task was not created because another task in the batch failed")
+	}
+
+	respond(w, api.BatchCreateResults{Results: results}, http.StatusUnprocessableEntity)
+}
+
+func (a *app) handleTaskBatchGet() http.HandlerFunc {
+	// Set up dependencies specific to the handler here
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		ids := parseIDs(req.URL.Query()["ids"])
+
+		if len(ids) == 0 {
+			respondError(w, AppError{External: errors.New("query parameter 'ids' is required")}, http.StatusUnprocessableEntity)
+			return
+		}
+
+		if len(ids) > maxBatchSize {
+			err := fmt.Errorf("query parameter 'ids' must not contain more than %d IDs", maxBatchSize)
+			respondError(w, AppError{External: err}, http.StatusUnprocessableEntity)
+			return
+		}
+
+		principal := auth.FromContext(req.Context())
+
+		// Malformed IDs can never match a task so there is no point in looking them up
+		lookup := make([]string, 0, len(ids))
+		for _, id := range ids {
+			if _, err := uuid.Parse(id); err == nil {
+				lookup = append(lookup, id)
+			}
+		}
+
+		found := make(map[string]task.Task, len(lookup))
+		if len(lookup) > 0 {
+			ts, err := a.TaskManager.GetBatch(req.Context(), principal.TenantID, lookup)
+			if err != nil {
+				respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+				return
+			}
+
+			for _, t := range ts {
+				found[t.ID] = t
+			}
+		}
+
+		// Authorize every task that was found at once so that only a summary of the decisions is audited
+		candidates := make([]task.Task, 0, len(found))
+		for _, id := range ids {
+			if t, ok := found[id]; ok {
+				candidates = append(candidates, t)
+			}
+		}
+		permitted := make(map[string]bool, len(candidates))
+		for i, allowed := range a.authorizeEach(principal, policy.ActionTaskRead, candidates) {
+			permitted[candidates[i].ID] = allowed
+		}
+
+		res := api.BatchTasks{Tasks: []api.Task{}, NotFound: []string{}, Forbidden: []string{}}
+		for _, id := range ids {
+			t, ok := found[id]
+			switch {
+			case !ok:
+				res.NotFound = append(res.NotFound, id)
+			case !permitted[id]:
+				res.Forbidden = append(res.Forbidden, id)
+			default:
+				res.Tasks = append(res.Tasks, toAPITask(t))
+			}
+		}
+
+		respond(w, res, http.StatusOK)
+	}
+}
+
+// parseIDs flattens repeated and comma-separated ID query parameter values, dropping blanks and duplicates
+func parseIDs(values []string) []string {
+	var ids []string
+	seen := make(map[string]bool)
+
+	for _, value := range values {
+		for _, id := range strings.Split(value, ",") {
+			id = strings.TrimSpace(id)
+			if id == "" || seen[id] {
+				continue
+			}
+
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
+
+// stringPtr returns a pointer to the string
+func stringPtr(s string) *string {
+	return &s
+}
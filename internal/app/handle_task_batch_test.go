@@ -0,0 +1,436 @@
+package app_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jaredpetersen/go-rest-template/api"
+	"github.com/jaredpetersen/go-rest-template/internal/app"
+	"github.com/jaredpetersen/go-rest-template/internal/app/mocks"
+	"github.com/jaredpetersen/go-rest-template/internal/policy"
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleTaskBatchCreate(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("SaveBatch", mock.Anything, mock.MatchedBy(func(tsks []task.Task) bool {
+		return len(tsks) == 2 &&
+			tsks[0].Description == "Buy milk" && tsks[0].TenantID == "tenant-1" && tsks[0].OwnerID == "user-1" &&
+			tsks[1].Description == "Buy eggs"
+	})).Return(nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	body := `{"tasks": [{"description": "Buy milk"}, {"description": "Buy eggs"}]}`
+	req, err := http.NewRequest(http.MethodPost, "/tasks:batchCreate", strings.NewReader(body))
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusCreated, res.Result().StatusCode)
+
+	resBody := api.BatchCreateResults{}
+	err = json.NewDecoder(res.Body).Decode(&resBody)
+	require.NoError(t, err, "Failed to convert response body")
+	require.Len(t, resBody.Results, 2, "Incorrect number of results")
+
+	for i, result := range resBody.Results {
+		assert.Equal(t, i, result.Index)
+		assert.Equal(t, http.StatusCreated, result.Status)
+		assert.Nil(t, result.Message)
+		require.NotNil(t, result.Id)
+		_, err = uuid.Parse(*result.Id)
+		assert.NoError(t, err, "Returned an invalid UUID")
+	}
+
+	tskMgr.AssertExpectations(t)
+}
+
+func TestHandleTaskBatchCreateAtomicFailure(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	body := `{"tasks": [{"description": "Buy milk"}, {"description": ""}]}`
+	req, err := http.NewRequest(http.MethodPost, "/tasks:batchCreate", strings.NewReader(body))
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	expectedJSON := `{"results": [
+		{"index": 0, "status": 424, "message": "task was not created because another task in the batch failed"},
+		{"index": 1, "status": 422, "message": "field 'description' is required"}
+	]}`
+
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Result().StatusCode)
+	assert.JSONEq(t, expectedJSON, res.Body.String())
+
+	// Nothing may be stored when any task in an atomic batch fails
+	tskMgr.AssertNotCalled(t, "SaveBatch", mock.Anything, mock.Anything)
+}
+
+func TestHandleTaskBatchCreatePartialSuccess(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("SaveBatch", mock.Anything, mock.MatchedBy(func(tsks []task.Task) bool {
+		return len(tsks) == 1 && tsks[0].Description == "Buy eggs"
+	})).Return(nil)
+
+	authorizer := mocks.Authorizer{}
+	authorizer.On("Authorize", mock.Anything, policy.ActionTaskCreate, mock.Anything).Return(true)
+	authorizer.On("Authorize", mock.Anything, policy.ActionTaskShare, mock.Anything).Return(false)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = &authorizer
+
+	// Make request
+	body := `{"atomic": false, "tasks": [
+		{"description": ""},
+		{"description": "Buy eggs"},
+		{"description": "Buy bread", "shares": [{"principalId": "user-2", "role": "viewer"}]}
+	]}`
+	req, err := http.NewRequest(http.MethodPost, "/tasks:batchCreate", strings.NewReader(body))
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+
+	resBody := api.BatchCreateResults{}
+	err = json.NewDecoder(res.Body).Decode(&resBody)
+	require.NoError(t, err, "Failed to convert response body")
+	require.Len(t, resBody.Results, 3, "Incorrect number of results")
+
+	assert.Equal(t, http.StatusUnprocessableEntity, resBody.Results[0].Status)
+	assert.Nil(t, resBody.Results[0].Id)
+	assert.Equal(t, http.StatusCreated, resBody.Results[1].Status)
+	assert.NotNil(t, resBody.Results[1].Id)
+	assert.Equal(t, http.StatusForbidden, resBody.Results[2].Status)
+	assert.Nil(t, resBody.Results[2].Id)
+
+	tskMgr.AssertExpectations(t)
+}
+
+func TestHandleTaskBatchCreatePartialSuccessHierarchyError(t *testing.T) {
+	parent := task.New()
+	parent.TenantID = "tenant-1"
+	parent.OwnerID = "user-1"
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("GetBatch", mock.Anything, "tenant-1", []string{parent.ID, parent.ID}).Return([]task.Task{*parent}, nil)
+	tskMgr.On("SaveBatch", mock.Anything, mock.MatchedBy(func(tsks []task.Task) bool {
+		return len(tsks) == 3
+	})).Return(&task.BatchError{Index: 1, Err: task.ErrDepthExceeded}).Once()
+	tskMgr.On("SaveBatch", mock.Anything, mock.MatchedBy(func(tsks []task.Task) bool {
+		return len(tsks) == 2 && tsks[0].Description == "Paint fence" && tsks[1].Description == "Paint gate"
+	})).Return(nil).Once()
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	body := fmt.Sprintf(`{"atomic": false, "tasks": [
+		{"description": "Paint fence"},
+		{"description": "Sand fence", "parentId": "%s"},
+		{"description": "Paint gate", "parentId": "%s"}
+	]}`, parent.ID, parent.ID)
+	req, err := http.NewRequest(http.MethodPost, "/tasks:batchCreate", strings.NewReader(body))
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+
+	resBody := api.BatchCreateResults{}
+	err = json.NewDecoder(res.Body).Decode(&resBody)
+	require.NoError(t, err, "Failed to convert response body")
+	require.Len(t, resBody.Results, 3, "Incorrect number of results")
+
+	assert.Equal(t, http.StatusCreated, resBody.Results[0].Status)
+	assert.NotNil(t, resBody.Results[0].Id)
+	assert.Equal(t, http.StatusUnprocessableEntity, resBody.Results[1].Status)
+	require.NotNil(t, resBody.Results[1].Message)
+	assert.Equal(t, "field 'parentId' must not nest subtasks more than 5 levels deep", *resBody.Results[1].Message)
+	assert.Nil(t, resBody.Results[1].Id)
+	assert.Equal(t, http.StatusCreated, resBody.Results[2].Status)
+	assert.NotNil(t, resBody.Results[2].Id)
+
+	tskMgr.AssertExpectations(t)
+}
+
+func TestHandleTaskBatchCreateAtomicHierarchyError(t *testing.T) {
+	parent := task.New()
+	parent.TenantID = "tenant-1"
+	parent.OwnerID = "user-1"
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("GetBatch", mock.Anything, "tenant-1", []string{parent.ID}).Return([]task.Task{*parent}, nil)
+	tskMgr.On("SaveBatch", mock.Anything, mock.Anything).
+		Return(&task.BatchError{Index: 0, Err: task.ErrParentUnavailable}).Once()
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	body := fmt.Sprintf(`{"tasks": [{"description": "Sand fence", "parentId": "%s"}, {"description": "Paint gate"}]}`,
+		parent.ID)
+	req, err := http.NewRequest(http.MethodPost, "/tasks:batchCreate", strings.NewReader(body))
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	expectedJSON := `{"results": [
+		{"index": 0, "status": 422, "message": "field 'parentId' refers to a task that does not exist"},
+		{"index": 1, "status": 424, "message": "task was not created because another task in the batch failed"}
+	]}`
+
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Result().StatusCode)
+	assert.JSONEq(t, expectedJSON, res.Body.String())
+
+	tskMgr.AssertExpectations(t)
+}
+
+func TestHandleTaskBatchCreateUnknownTag(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("SaveBatch", mock.Anything, mock.MatchedBy(func(tsks []task.Task) bool {
+		return len(tsks) == 1 && tsks[0].Description == "Buy milk"
+	})).Return(nil)
+
+	// The tags of every task are looked up together
+	tagMgr := mocks.TagManager{}
+	tagMgr.On("GetTagsByName", mock.Anything, "tenant-1", []string{"errand", "unknown"}).
+		Return([]task.Tag{{Name: "errand"}}, nil).Once()
+
+	// Set up server
+	a := app.New()
+	a.TagManager = &tagMgr
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	body := `{"atomic": false, "tasks": [
+		{"description": "Buy milk", "tags": ["errand"]},
+		{"description": "Buy eggs", "tags": ["unknown"]}
+	]}`
+	req, err := http.NewRequest(http.MethodPost, "/tasks:batchCreate", strings.NewReader(body))
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+
+	resBody := api.BatchCreateResults{}
+	err = json.NewDecoder(res.Body).Decode(&resBody)
+	require.NoError(t, err, "Failed to convert response body")
+	require.Len(t, resBody.Results, 2, "Incorrect number of results")
+
+	assert.Equal(t, http.StatusCreated, resBody.Results[0].Status)
+	assert.Equal(t, http.StatusUnprocessableEntity, resBody.Results[1].Status)
+	require.NotNil(t, resBody.Results[1].Message)
+	assert.Equal(t, "field 'tags' has unknown tag 'unknown'", *resBody.Results[1].Message)
+
+	tskMgr.AssertExpectations(t)
+	tagMgr.AssertExpectations(t)
+}
+
+func TestHandleTaskBatchCreateInvalidSize(t *testing.T) {
+	tooMany := make([]string, 101)
+	for i := range tooMany {
+		tooMany[i] = `{"description": "Buy milk"}`
+	}
+
+	testCases := []struct {
+		name string
+		body string
+	}{
+		{name: "Empty", body: `{"tasks": []}`},
+		{name: "TooMany", body: `{"tasks": [` + strings.Join(tooMany, ",") + `]}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Set up relevant server dependencies
+			tskMgr := mocks.TaskManager{}
+
+			// Set up server
+			a := app.New()
+			a.TaskManager = &tskMgr
+			a.Authenticator = buildAuthenticator("tasks:write")
+			a.Authorizer = buildAuthorizer(true)
+
+			// Make request
+			req, err := http.NewRequest(http.MethodPost, "/tasks:batchCreate", strings.NewReader(tc.body))
+			require.NoError(t, err)
+			res := httptest.NewRecorder()
+			a.ServeHTTP(res, req)
+
+			assert.Equal(t, http.StatusUnprocessableEntity, res.Result().StatusCode)
+			tskMgr.AssertNotCalled(t, "SaveBatch", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestHandleTaskBatchCreateForbidden(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(false)
+
+	// Make request
+	body := `{"tasks": [{"description": "Buy milk"}]}`
+	req, err := http.NewRequest(http.MethodPost, "/tasks:batchCreate", strings.NewReader(body))
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusForbidden, res.Result().StatusCode)
+	tskMgr.AssertNotCalled(t, "SaveBatch", mock.Anything, mock.Anything)
+}
+
+func TestHandleTaskBatchCreateError(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("SaveBatch", mock.Anything, mock.Anything).Return(errors.New("failure to save tasks"))
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	body := `{"tasks": [{"description": "Buy milk"}]}`
+	req, err := http.NewRequest(http.MethodPost, "/tasks:batchCreate", strings.NewReader(body))
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusInternalServerError, res.Result().StatusCode)
+	assert.Empty(t, res.Body)
+}
+
+func TestHandleTaskBatchGet(t *testing.T) {
+	readable := task.New()
+	readable.TenantID = "tenant-1"
+	readable.OwnerID = "user-1"
+	readable.Description = "Buy butter"
+	unreadable := task.New()
+	unreadable.TenantID = "tenant-1"
+	unreadable.OwnerID = "user-2"
+	missingID := uuid.NewString()
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("GetBatch", mock.Anything, "tenant-1", []string{readable.ID, unreadable.ID, missingID}).
+		Return([]task.Task{*readable, *unreadable}, nil)
+
+	authorizer := mocks.Authorizer{}
+	authorizer.On("AuthorizeEach", mock.Anything, policy.ActionTaskRead, []task.Task{*readable, *unreadable}).
+		Return([]bool{true, false})
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = &authorizer
+
+	// Make request, with the IDs split across repeated parameters and including duplicates and malformed IDs
+	url := fmt.Sprintf("/tasks:batchGet?ids=%s,%s&ids=%s,notanid&ids=%s", readable.ID, unreadable.ID, missingID, readable.ID)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	expectedJSON := fmt.Sprintf(`{
+		"tasks": [{"id": "%s", "ownerId": "user-1", "projectId": null, "description": "Buy butter", "dateDue": null,
+			"parentId": null, "completed": false, "dateCompleted": null, "subtasks": {"total": 0, "completed": 0, "percentComplete": 0},
+			"blocked": false, "blockedBy": [], "recurrence": null, "reminders": [],
+			"shares": [], "tags": [], "commentCount": 0, "version": 1}],
+		"notFound": ["%s", "notanid"],
+		"forbidden": ["%s"]
+	}`, readable.ID, missingID, unreadable.ID)
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	assert.JSONEq(t, expectedJSON, res.Body.String())
+
+	tskMgr.AssertExpectations(t)
+}
+
+func TestHandleTaskBatchGetMissingIDs(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/tasks:batchGet", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Result().StatusCode)
+	assert.JSONEq(t, `{"message": "query parameter 'ids' is required"}`, res.Body.String())
+}
+
+func TestHandleTaskBatchGetError(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("GetBatch", mock.Anything, "tenant-1", mock.Anything).Return(nil, errors.New("failure to get tasks"))
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/tasks:batchGet?ids="+uuid.NewString(), nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusInternalServerError, res.Result().StatusCode)
+	assert.Empty(t, res.Body)
+}
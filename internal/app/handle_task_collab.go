@@ -0,0 +1,542 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/jaredpetersen/go-rest-template/api"
+	"github.com/jaredpetersen/go-rest-template/internal/auth"
+	"github.com/jaredpetersen/go-rest-template/internal/outbox"
+	"github.com/jaredpetersen/go-rest-template/internal/policy"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/hlog"
+)
+
+const (
+	// defaultCollabMaxConnections is the most collaboration connections held open when the limit is not configured
+	defaultCollabMaxConnections = 1000
+	// defaultCollabMaxConnectionsPerPrincipal is the most collaboration connections held open for each principal when
+	// the limit is not configured
+	defaultCollabMaxConnectionsPerPrincipal = 5
+	// defaultCollabPingInterval is how often collaboration connections are pinged when the interval is not configured
+	defaultCollabPingInterval = 30 * time.Second
+	// collabMaxSubscriptions is the most tasks that a collaboration connection may subscribe to at once
+	collabMaxSubscriptions = 100
+	// collabMaxMessageSize is the largest message that clients may send, in bytes
+	collabMaxMessageSize = 64 * 1024
+	// collabSendBuffer is how many notifications a client may fall behind by before it is disconnected
+	collabSendBuffer = 64
+	// collabWriteTimeout is how long each write to a collaboration connection may take
+	collabWriteTimeout = 10 * time.Second
+)
+
+// closeTryAgainLater is the WebSocket close code for when the server is overloaded, which gorilla/websocket does not
+// define
+const closeTryAgainLater = 1013
+
+// errCollabFull is returned when the replica holds as many collaboration connections open as it can.
+var errCollabFull = errors.New("too many open collaboration connections")
+
+// errCollabPrincipalFull is returned when the principal has as many collaboration connections open as it may.
+var errCollabPrincipalFull = errors.New("too many open collaboration connections for the principal")
+
+// collabConnections counts the open collaboration connections, in total and for each principal. The zero value is
+// ready to use.
+type collabConnections struct {
+	mu         sync.Mutex
+	total      int
+	principals map[string]int
+}
+
+// acquire counts a new connection for the principal unless it would exceed the limits, returning a function to stop
+// counting it with
+func (c *collabConnections) acquire(principal *auth.Principal, max int, maxPerPrincipal int) (func(), error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := principal.TenantID + "." + principal.Subject
+
+	if c.total >= max {
+		return nil, errCollabFull
+	}
+	if c.principals[key] >= maxPerPrincipal {
+		return nil, errCollabPrincipalFull
+	}
+
+	if c.principals == nil {
+		c.principals = make(map[string]int)
+	}
+	c.total++
+	c.principals[key]++
+
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+
+			c.total--
+			c.principals[key]--
+			if c.principals[key] == 0 {
+				delete(c.principals, key)
+			}
+		})
+	}
+
+	return release, nil
+}
+
+func (a *app) handleTaskCollaboration() http.HandlerFunc {
+	// Set up dependencies specific to the handler here
+	// Origins are checked against the host by default so that other sites cannot connect with their visitors' cookies
+	upgrader := websocket.Upgrader{}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		maxConns := a.CollabMaxConnections
+		if maxConns <= 0 {
+			maxConns = defaultCollabMaxConnections
+		}
+		maxConnsPerPrincipal := a.CollabMaxConnectionsPerPrincipal
+		if maxConnsPerPrincipal <= 0 {
+			maxConnsPerPrincipal = defaultCollabMaxConnectionsPerPrincipal
+		}
+		pingInterval := a.CollabPingInterval
+		if pingInterval <= 0 {
+			pingInterval = defaultCollabPingInterval
+		}
+
+		principal := auth.FromContext(req.Context())
+
+		release, err := a.collabConns.acquire(principal, maxConns, maxConnsPerPrincipal)
+		if errors.Is(err, errCollabFull) {
+			respondError(w, AppError{External: err}, http.StatusServiceUnavailable)
+			return
+		}
+		if err != nil {
+			respondError(w, AppError{External: err}, http.StatusTooManyRequests)
+			return
+		}
+		defer release()
+
+		conn, err := upgrader.Upgrade(w, req, nil)
+		if err != nil {
+			// The upgrader has already responded
+			hlog.FromRequest(req).Warn().Err(err).Msg("Failed to open collaboration connection")
+			return
+		}
+		defer conn.Close()
+
+		s := collabSession{
+			app:             a,
+			conn:            conn,
+			req:             req,
+			principal:       principal,
+			logger:          hlog.FromRequest(req),
+			id:              uuid.NewString(),
+			pingInterval:    pingInterval,
+			send:            make(chan api.CollaborationNotification, collabSendBuffer),
+			presenceChanged: make(chan string, collabMaxSubscriptions),
+			subscriptions:   make(map[string]func()),
+		}
+		s.run()
+	}
+}
+
+// collabSession is a collaboration connection with a client.
+//
+// A single goroutine handles requests and notifications and is the only one to touch the subscriptions. Messages are
+// read and written in goroutines of their own so that a slow client cannot hold the session up.
+type collabSession struct {
+	app          *app
+	conn         *websocket.Conn
+	req          *http.Request
+	principal    *auth.Principal
+	logger       *zerolog.Logger
+	id           string
+	pingInterval time.Duration
+	// send is the notifications waiting to be written
+	send chan api.CollaborationNotification
+	// presenceChanged is the IDs of the subscribed tasks that who is present on has changed
+	presenceChanged chan string
+	// subscriptions maps the IDs of the subscribed tasks to functions that stop watching who is present on them
+	subscriptions map[string]func()
+}
+
+// run handles the session until the client goes away or the session must be closed
+func (s *collabSession) run() {
+	// Clients that do not answer pings within two intervals are gone
+	pongWait := 2 * s.pingInterval
+	s.conn.SetReadLimit(collabMaxMessageSize)
+	s.conn.SetReadDeadline(time.Now().Add(pongWait))
+	s.conn.SetPongHandler(func(string) error {
+		return s.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	entries, unsubscribe := s.app.TaskEventFeed.Subscribe()
+	defer unsubscribe()
+	defer s.leaveAll()
+
+	done := make(chan struct{})
+	messages := make(chan []byte)
+	readDone := make(chan struct{})
+	go s.read(messages, readDone, done)
+	writeDone := make(chan struct{})
+	go s.write(writeDone, done)
+
+	closeCode, closeReason := s.loop(entries, messages, readDone, writeDone)
+
+	close(done)
+	<-writeDone
+
+	if closeCode != 0 {
+		msg := websocket.FormatCloseMessage(closeCode, closeReason)
+		s.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(collabWriteTimeout))
+	}
+}
+
+// loop handles requests and notifications until the session ends, returning the code and reason to close the
+// connection with, if any
+func (s *collabSession) loop(entries <-chan outbox.Entry, messages <-chan []byte, readDone <-chan struct{}, writeDone <-chan struct{}) (int, string) {
+	// Presence expires unless it is refreshed, so that sessions that are never closed do not linger
+	ticker := time.NewTicker(s.pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		// The client has closed the connection, which has already been acknowledged, or it has failed
+		case <-readDone:
+			return 0, ""
+		case <-writeDone:
+			return 0, ""
+		case msg := <-messages:
+			if !s.handle(msg) {
+				return closeTryAgainLater, "client fell behind"
+			}
+		case e, ok := <-entries:
+			// Changes may have been missed, so the client must subscribe again to see the latest version
+			if !ok {
+				return closeTryAgainLater, "client fell behind"
+			}
+
+			if !s.changed(e) {
+				return closeTryAgainLater, "client fell behind"
+			}
+		case taskID := <-s.presenceChanged:
+			if !s.sendPresence(taskID) {
+				return closeTryAgainLater, "client fell behind"
+			}
+		case <-ticker.C:
+			for taskID := range s.subscriptions {
+				err := s.app.TaskPresence.Join(s.req.Context(), s.principal.TenantID, taskID, s.principal.Subject, s.id)
+				if err != nil {
+					s.logger.Warn().Err(err).Str("task", taskID).Msg("Failed to refresh presence")
+				}
+			}
+		}
+	}
+}
+
+// read hands the messages from the client to the session until the connection fails or the session ends
+func (s *collabSession) read(messages chan<- []byte, readDone chan<- struct{}, done <-chan struct{}) {
+	defer close(readDone)
+
+	for {
+		msgType, msg, err := s.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				s.logger.Warn().Err(err).Msg("Collaboration connection failed")
+			}
+			return
+		}
+
+		if msgType != websocket.TextMessage {
+			continue
+		}
+
+		select {
+		case messages <- msg:
+		case <-done:
+			return
+		}
+	}
+}
+
+// write writes notifications to the client and pings it until the connection fails or the session ends
+func (s *collabSession) write(writeDone chan<- struct{}, done <-chan struct{}) {
+	defer close(writeDone)
+
+	ticker := time.NewTicker(s.pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case n := <-s.send:
+			s.conn.SetWriteDeadline(time.Now().Add(collabWriteTimeout))
+			err := s.conn.WriteJSON(n)
+			if err != nil {
+				return
+			}
+		case <-ticker.C:
+			err := s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(collabWriteTimeout))
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+// notify queues the notification to be written, reporting false if the client has fallen too far behind to take it
+func (s *collabSession) notify(n api.CollaborationNotification) bool {
+	select {
+	case s.send <- n:
+		return true
+	default:
+		return false
+	}
+}
+
+// notifyError queues a notification that the request failed
+func (s *collabSession) notifyError(r api.CollaborationRequest, statusErr statusError) bool {
+	if statusErr.Internal != nil {
+		s.logger.Error().AnErr("external", statusErr.External).AnErr("internal", statusErr.Internal).Send()
+	}
+
+	message := http.StatusText(statusErr.StatusCode)
+	if statusErr.External != nil {
+		message = statusErr.External.Error()
+	}
+
+	n := api.CollaborationNotification{
+		Type:      api.CollaborationNotificationTypeError,
+		RequestId: r.RequestId,
+		Status:    &statusErr.StatusCode,
+		Error:     &api.Error{Message: message},
+	}
+	if r.TaskId != "" {
+		n.TaskId = &r.TaskId
+	}
+
+	return s.notify(n)
+}
+
+// handle handles a request from the client, reporting false if the client has fallen too far behind to respond to
+func (s *collabSession) handle(msg []byte) bool {
+	var r api.CollaborationRequest
+	err := json.Unmarshal(msg, &r)
+	if err != nil {
+		err = errors.New("message must be a collaboration request")
+		return s.notifyError(r, statusError{AppError{External: err}, http.StatusBadRequest})
+	}
+
+	r.TaskId = strings.TrimSpace(r.TaskId)
+	if r.TaskId == "" {
+		err = errors.New("field 'taskId' is required")
+		return s.notifyError(r, statusError{AppError{External: err}, http.StatusUnprocessableEntity})
+	}
+
+	switch r.Type {
+	case api.CollaborationRequestTypeSubscribe:
+		return s.subscribe(r)
+	case api.CollaborationRequestTypeUnsubscribe:
+		return s.unsubscribe(r)
+	case api.CollaborationRequestTypeEdit:
+		return s.edit(r)
+	default:
+		err = errors.New("field 'type' must be 'subscribe', 'unsubscribe', or 'edit'")
+		return s.notifyError(r, statusError{AppError{External: err}, http.StatusUnprocessableEntity})
+	}
+}
+
+// subscribe starts sending the client changes to the task and who is present on it
+func (s *collabSession) subscribe(r api.CollaborationRequest) bool {
+	ctx := s.req.Context()
+
+	_, subscribed := s.subscriptions[r.TaskId]
+	if !subscribed && len(s.subscriptions) >= collabMaxSubscriptions {
+		err := fmt.Errorf("a connection may not subscribe to more than %d tasks", collabMaxSubscriptions)
+		return s.notifyError(r, statusError{AppError{External: err}, http.StatusUnprocessableEntity})
+	}
+
+	t, err := s.app.TaskManager.Get(ctx, s.principal.TenantID, r.TaskId)
+	if err != nil {
+		return s.notifyError(r, statusError{AppError{Internal: err}, http.StatusInternalServerError})
+	}
+
+	if t == nil {
+		return s.notifyError(r, statusError{AppError{External: errors.New("task not found")}, http.StatusNotFound})
+	}
+
+	if !s.app.authorize(s.principal, policy.ActionTaskRead, t) {
+		return s.notifyError(r, statusError{AppError{External: errors.New("forbidden")}, http.StatusForbidden})
+	}
+
+	if !subscribed {
+		err = s.app.TaskPresence.Join(ctx, s.principal.TenantID, t.ID, s.principal.Subject, s.id)
+		if err != nil {
+			return s.notifyError(r, statusError{AppError{Internal: err}, http.StatusInternalServerError})
+		}
+
+		s.subscriptions[t.ID] = s.watchPresence(t.ID)
+	}
+
+	subjects, err := s.app.TaskPresence.List(ctx, s.principal.TenantID, t.ID)
+	if err != nil {
+		return s.notifyError(r, statusError{AppError{Internal: err}, http.StatusInternalServerError})
+	}
+
+	apiTask := toAPITask(*t)
+	return s.notify(api.CollaborationNotification{
+		Type:      api.CollaborationNotificationTypeSubscribed,
+		RequestId: r.RequestId,
+		TaskId:    &t.ID,
+		Task:      &apiTask,
+		Subjects:  &subjects,
+	})
+}
+
+// watchPresence hands the task to the session whenever who is present on it changes, returning a function to stop
+// watching with
+func (s *collabSession) watchPresence(taskID string) func() {
+	changes, unwatch := s.app.TaskPresence.Watch(s.principal.TenantID, taskID)
+	stop := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			case <-changes:
+				select {
+				case s.presenceChanged <- taskID:
+				case <-stop:
+					return
+				}
+			}
+		}
+	}()
+
+	return func() {
+		unwatch()
+		close(stop)
+	}
+}
+
+// unsubscribe stops sending the client changes to the task
+func (s *collabSession) unsubscribe(r api.CollaborationRequest) bool {
+	s.leave(r.TaskId)
+
+	return s.notify(api.CollaborationNotification{
+		Type:      api.CollaborationNotificationTypeUnsubscribed,
+		RequestId: r.RequestId,
+		TaskId:    &r.TaskId,
+	})
+}
+
+// leave stops watching the task and marks the session as no longer present on it, if it was subscribed to it
+func (s *collabSession) leave(taskID string) {
+	unwatch, ok := s.subscriptions[taskID]
+	if !ok {
+		return
+	}
+
+	unwatch()
+	delete(s.subscriptions, taskID)
+
+	err := s.app.TaskPresence.Leave(s.req.Context(), s.principal.TenantID, taskID, s.principal.Subject, s.id)
+	if err != nil {
+		s.logger.Warn().Err(err).Str("task", taskID).Msg("Failed to leave task")
+	}
+}
+
+// leaveAll leaves every subscribed task
+func (s *collabSession) leaveAll() {
+	for taskID := range s.subscriptions {
+		s.leave(taskID)
+	}
+}
+
+// edit updates the task following the same rules as updating it over REST, with the version in place of If-Match
+func (s *collabSession) edit(r api.CollaborationRequest) bool {
+	if !s.principal.HasScope(scopeTasksWrite) {
+		return s.notifyError(r, statusError{AppError{External: errors.New("insufficient scope")}, http.StatusForbidden})
+	}
+
+	if r.Version == nil {
+		err := errors.New("field 'version' is required")
+		return s.notifyError(r, statusError{AppError{External: err}, http.StatusUnprocessableEntity})
+	}
+
+	if r.Task == nil {
+		err := errors.New("field 'task' is required")
+		return s.notifyError(r, statusError{AppError{External: err}, http.StatusUnprocessableEntity})
+	}
+
+	updated, statusErr := s.app.updateTask(s.req.Context(), s.principal, r.TaskId, etag(*r.Version), *r.Task)
+	if statusErr != nil {
+		return s.notifyError(r, *statusErr)
+	}
+
+	apiTask := toAPITask(*updated)
+	return s.notify(api.CollaborationNotification{
+		Type:      api.CollaborationNotificationTypeEdited,
+		RequestId: r.RequestId,
+		TaskId:    &updated.ID,
+		Task:      &apiTask,
+	})
+}
+
+// changed sends the change to the client if it is subscribed to the task and still allowed to read it
+func (s *collabSession) changed(e outbox.Entry) bool {
+	if e.TenantID != s.principal.TenantID {
+		return true
+	}
+
+	t, err := fromTaskEventEntry(e)
+	if err != nil {
+		s.logger.Warn().Err(err).Str("event", e.ID).Msg("Failed to decode task event")
+		return true
+	}
+
+	if t == nil {
+		return true
+	}
+
+	if _, ok := s.subscriptions[t.ID]; !ok || !s.app.authorize(s.principal, policy.ActionTaskRead, t) {
+		return true
+	}
+
+	event := toAPITaskEvent(e, *t)
+	return s.notify(api.CollaborationNotification{
+		Type:   api.CollaborationNotificationTypeChanged,
+		TaskId: &t.ID,
+		Event:  &event,
+	})
+}
+
+// sendPresence sends the client who is present on the task, if it is still subscribed to it
+func (s *collabSession) sendPresence(taskID string) bool {
+	if _, ok := s.subscriptions[taskID]; !ok {
+		return true
+	}
+
+	subjects, err := s.app.TaskPresence.List(s.req.Context(), s.principal.TenantID, taskID)
+	if err != nil {
+		s.logger.Warn().Err(err).Str("task", taskID).Msg("Failed to list presence")
+		return true
+	}
+
+	return s.notify(api.CollaborationNotification{
+		Type:     api.CollaborationNotificationTypePresence,
+		TaskId:   &taskID,
+		Subjects: &subjects,
+	})
+}
@@ -0,0 +1,634 @@
+package app_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/jaredpetersen/go-rest-template/api"
+	"github.com/jaredpetersen/go-rest-template/internal/app"
+	"github.com/jaredpetersen/go-rest-template/internal/app/mocks"
+	"github.com/jaredpetersen/go-rest-template/internal/outbox"
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// buildLiveTaskEventFeed creates a feed that streams the entries sent on the returned channel
+func buildLiveTaskEventFeed() (*mocks.TaskEventFeed, chan outbox.Entry) {
+	ch := make(chan outbox.Entry, 10)
+
+	feed := mocks.TaskEventFeed{}
+	feed.On("Subscribe").Return((<-chan outbox.Entry)(ch), func() {})
+	return &feed, ch
+}
+
+// buildTaskPresence creates a presence tracker where the subjects are present on every task, notifying of changes
+// sent on the returned channel
+func buildTaskPresence(subjects ...string) (*mocks.TaskPresence, chan struct{}) {
+	ch := make(chan struct{}, 1)
+
+	presence := mocks.TaskPresence{}
+	presence.On("Join", mock.Anything, "tenant-1", mock.Anything, "user-1", mock.Anything).Return(nil).Maybe()
+	presence.On("Leave", mock.Anything, "tenant-1", mock.Anything, "user-1", mock.Anything).Return(nil).Maybe()
+	presence.On("List", mock.Anything, "tenant-1", mock.Anything).Return(subjects, nil).Maybe()
+	presence.On("Watch", "tenant-1", mock.Anything).Return((<-chan struct{})(ch), func() {}).Maybe()
+	return &presence, ch
+}
+
+// dialCollaboration opens a collaboration connection to the server
+func dialCollaboration(t *testing.T, server *httptest.Server) *websocket.Conn {
+	conn, res, err := websocket.DefaultDialer.Dial(collaborationURL(server), nil)
+	require.NoError(t, err)
+	res.Body.Close()
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// collaborationURL generates the WebSocket URL of the server's collaboration endpoint
+func collaborationURL(server *httptest.Server) string {
+	return "ws" + strings.TrimPrefix(server.URL, "http") + "/tasks/collaboration"
+}
+
+// receiveNotification waits for the next notification on the connection
+func receiveNotification(t *testing.T, conn *websocket.Conn) api.CollaborationNotification {
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+
+	var n api.CollaborationNotification
+	err := conn.ReadJSON(&n)
+	require.NoError(t, err)
+	return n
+}
+
+func TestHandleTaskCollaborationSubscribe(t *testing.T) {
+	tsk := buildEventTask("task-1")
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", "task-1").Return(&tsk, nil)
+
+	feed, entries := buildLiveTaskEventFeed()
+	presence, _ := buildTaskPresence("user-1", "user-2")
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.TaskEventFeed = feed
+	a.TaskPresence = presence
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+	server := httptest.NewServer(a)
+	defer server.Close()
+
+	// Make request
+	conn := dialCollaboration(t, server)
+	err := conn.WriteJSON(map[string]string{"type": "subscribe", "requestId": "request-1", "taskId": "task-1"})
+	require.NoError(t, err)
+
+	n := receiveNotification(t, conn)
+	assert.Equal(t, api.CollaborationNotificationTypeSubscribed, n.Type)
+	require.NotNil(t, n.RequestId)
+	assert.Equal(t, "request-1", *n.RequestId)
+	require.NotNil(t, n.Task)
+	assert.Equal(t, "Task task-1", n.Task.Description)
+	require.NotNil(t, n.Subjects)
+	assert.Equal(t, []string{"user-1", "user-2"}, *n.Subjects)
+
+	// Only changes to subscribed tasks are sent
+	otherTsk := buildEventTask("task-2")
+	entries <- buildTaskEvent(t, "1-0", task.EventUpdated, otherTsk)
+	entries <- buildTaskEvent(t, "2-0", task.EventUpdated, tsk)
+
+	n = receiveNotification(t, conn)
+	assert.Equal(t, api.CollaborationNotificationTypeChanged, n.Type)
+	require.NotNil(t, n.TaskId)
+	assert.Equal(t, "task-1", *n.TaskId)
+	require.NotNil(t, n.Event)
+	assert.Equal(t, api.TaskEventTypeTaskUpdated, n.Event.Type)
+	assert.Equal(t, "task-1", n.Event.Task.Id)
+
+	presence.AssertCalled(t, "Join", mock.Anything, "tenant-1", "task-1", "user-1", mock.Anything)
+}
+
+func TestHandleTaskCollaborationSubscribeNotFound(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", "task-1").Return(nil, nil)
+
+	feed, _ := buildLiveTaskEventFeed()
+	presence, _ := buildTaskPresence("user-1", "user-2")
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.TaskEventFeed = feed
+	a.TaskPresence = presence
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+	server := httptest.NewServer(a)
+	defer server.Close()
+
+	// Make request
+	conn := dialCollaboration(t, server)
+	err := conn.WriteJSON(map[string]string{"type": "subscribe", "taskId": "task-1"})
+	require.NoError(t, err)
+
+	n := receiveNotification(t, conn)
+	assert.Equal(t, api.CollaborationNotificationTypeError, n.Type)
+	require.NotNil(t, n.Status)
+	assert.Equal(t, http.StatusNotFound, *n.Status)
+	require.NotNil(t, n.Error)
+	assert.Equal(t, "task not found", n.Error.Message)
+
+	presence.AssertNotCalled(t, "Join", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleTaskCollaborationSubscribeForbidden(t *testing.T) {
+	tsk := buildEventTask("task-1")
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", "task-1").Return(&tsk, nil)
+
+	feed, _ := buildLiveTaskEventFeed()
+	presence, _ := buildTaskPresence()
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.TaskEventFeed = feed
+	a.TaskPresence = presence
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(false)
+	server := httptest.NewServer(a)
+	defer server.Close()
+
+	// Make request
+	conn := dialCollaboration(t, server)
+	err := conn.WriteJSON(map[string]string{"type": "subscribe", "taskId": "task-1"})
+	require.NoError(t, err)
+
+	n := receiveNotification(t, conn)
+	assert.Equal(t, api.CollaborationNotificationTypeError, n.Type)
+	require.NotNil(t, n.Status)
+	assert.Equal(t, http.StatusForbidden, *n.Status)
+}
+
+func TestHandleTaskCollaborationPresence(t *testing.T) {
+	tsk := buildEventTask("task-1")
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", "task-1").Return(&tsk, nil)
+
+	feed, _ := buildLiveTaskEventFeed()
+	presence, changes := buildTaskPresence("user-1", "user-2")
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.TaskEventFeed = feed
+	a.TaskPresence = presence
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+	server := httptest.NewServer(a)
+	defer server.Close()
+
+	// Make request
+	conn := dialCollaboration(t, server)
+	err := conn.WriteJSON(map[string]string{"type": "subscribe", "taskId": "task-1"})
+	require.NoError(t, err)
+	receiveNotification(t, conn)
+
+	changes <- struct{}{}
+
+	n := receiveNotification(t, conn)
+	assert.Equal(t, api.CollaborationNotificationTypePresence, n.Type)
+	require.NotNil(t, n.TaskId)
+	assert.Equal(t, "task-1", *n.TaskId)
+	require.NotNil(t, n.Subjects)
+	assert.Equal(t, []string{"user-1", "user-2"}, *n.Subjects)
+}
+
+func TestHandleTaskCollaborationUnsubscribe(t *testing.T) {
+	tsk := buildEventTask("task-1")
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", "task-1").Return(&tsk, nil)
+
+	feed, entries := buildLiveTaskEventFeed()
+	presence, _ := buildTaskPresence("user-1", "user-2")
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.TaskEventFeed = feed
+	a.TaskPresence = presence
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+	server := httptest.NewServer(a)
+	defer server.Close()
+
+	// Make request
+	conn := dialCollaboration(t, server)
+	err := conn.WriteJSON(map[string]string{"type": "subscribe", "taskId": "task-1"})
+	require.NoError(t, err)
+	receiveNotification(t, conn)
+
+	err = conn.WriteJSON(map[string]string{"type": "unsubscribe", "requestId": "request-2", "taskId": "task-1"})
+	require.NoError(t, err)
+
+	n := receiveNotification(t, conn)
+	assert.Equal(t, api.CollaborationNotificationTypeUnsubscribed, n.Type)
+	require.NotNil(t, n.RequestId)
+	assert.Equal(t, "request-2", *n.RequestId)
+
+	presence.AssertCalled(t, "Leave", mock.Anything, "tenant-1", "task-1", "user-1", mock.Anything)
+
+	// Changes to the task are no longer sent
+	entries <- buildTaskEvent(t, "1-0", task.EventUpdated, tsk)
+	err = conn.WriteJSON(map[string]string{"type": "unsubscribe", "taskId": "task-1"})
+	require.NoError(t, err)
+
+	n = receiveNotification(t, conn)
+	assert.Equal(t, api.CollaborationNotificationTypeUnsubscribed, n.Type)
+}
+
+func TestHandleTaskCollaborationEdit(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+	tsk.Description = "Buy milk"
+
+	updatedTsk := *tsk
+	updatedTsk.Description = "Buy oat milk"
+	updatedTsk.Version = 2
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+	tskMgr.On("Update", mock.Anything, mock.MatchedBy(func(t task.Task) bool {
+		return t.ID == tsk.ID && t.Description == "Buy oat milk" && t.Version == 1
+	})).Return(&updatedTsk, nil)
+
+	feed, _ := buildLiveTaskEventFeed()
+	presence, _ := buildTaskPresence("user-1", "user-2")
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.TaskEventFeed = feed
+	a.TaskPresence = presence
+	a.Authenticator = buildAuthenticator("tasks:read", "tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+	server := httptest.NewServer(a)
+	defer server.Close()
+
+	// Make request
+	conn := dialCollaboration(t, server)
+	err := conn.WriteJSON(map[string]interface{}{
+		"type":      "edit",
+		"requestId": "request-1",
+		"taskId":    tsk.ID,
+		"version":   1,
+		"task":      map[string]interface{}{"description": "Buy oat milk"},
+	})
+	require.NoError(t, err)
+
+	n := receiveNotification(t, conn)
+	assert.Equal(t, api.CollaborationNotificationTypeEdited, n.Type)
+	require.NotNil(t, n.RequestId)
+	assert.Equal(t, "request-1", *n.RequestId)
+	require.NotNil(t, n.Task)
+	assert.Equal(t, "Buy oat milk", n.Task.Description)
+	assert.Equal(t, 2, n.Task.Version)
+
+	tskMgr.AssertExpectations(t)
+}
+
+func TestHandleTaskCollaborationEditConflict(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+	tsk.Description = "Buy milk"
+	tsk.Version = 3
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	feed, _ := buildLiveTaskEventFeed()
+	presence, _ := buildTaskPresence("user-1", "user-2")
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.TaskEventFeed = feed
+	a.TaskPresence = presence
+	a.Authenticator = buildAuthenticator("tasks:read", "tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+	server := httptest.NewServer(a)
+	defer server.Close()
+
+	// Make request
+	conn := dialCollaboration(t, server)
+	err := conn.WriteJSON(map[string]interface{}{
+		"type":    "edit",
+		"taskId":  tsk.ID,
+		"version": 2,
+		"task":    map[string]interface{}{"description": "Buy oat milk"},
+	})
+	require.NoError(t, err)
+
+	n := receiveNotification(t, conn)
+	assert.Equal(t, api.CollaborationNotificationTypeError, n.Type)
+	require.NotNil(t, n.Status)
+	assert.Equal(t, http.StatusPreconditionFailed, *n.Status)
+	require.NotNil(t, n.Error)
+	assert.Equal(t, "task has been modified", n.Error.Message)
+
+	tskMgr.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestHandleTaskCollaborationEditInvalid(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+
+	feed, _ := buildLiveTaskEventFeed()
+	presence, _ := buildTaskPresence("user-1", "user-2")
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.TaskEventFeed = feed
+	a.TaskPresence = presence
+	a.Authenticator = buildAuthenticator("tasks:read", "tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+	server := httptest.NewServer(a)
+	defer server.Close()
+
+	// Make request
+	conn := dialCollaboration(t, server)
+	err := conn.WriteJSON(map[string]interface{}{
+		"type":    "edit",
+		"taskId":  "task-1",
+		"version": 1,
+		"task":    map[string]interface{}{"description": ""},
+	})
+	require.NoError(t, err)
+
+	n := receiveNotification(t, conn)
+	assert.Equal(t, api.CollaborationNotificationTypeError, n.Type)
+	require.NotNil(t, n.Status)
+	assert.Equal(t, http.StatusUnprocessableEntity, *n.Status)
+	require.NotNil(t, n.Error)
+	assert.Equal(t, "field 'description' is required", n.Error.Message)
+
+	// The connection stays open after errors
+	err = conn.WriteJSON(map[string]interface{}{"type": "edit", "taskId": "task-1", "version": 1})
+	require.NoError(t, err)
+
+	n = receiveNotification(t, conn)
+	require.NotNil(t, n.Error)
+	assert.Equal(t, "field 'task' is required", n.Error.Message)
+
+	tskMgr.AssertNotCalled(t, "Get", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleTaskCollaborationEditInsufficientScope(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+
+	feed, _ := buildLiveTaskEventFeed()
+	presence, _ := buildTaskPresence("user-1", "user-2")
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.TaskEventFeed = feed
+	a.TaskPresence = presence
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+	server := httptest.NewServer(a)
+	defer server.Close()
+
+	// Make request
+	conn := dialCollaboration(t, server)
+	err := conn.WriteJSON(map[string]interface{}{
+		"type":    "edit",
+		"taskId":  "task-1",
+		"version": 1,
+		"task":    map[string]interface{}{"description": "Buy oat milk"},
+	})
+	require.NoError(t, err)
+
+	n := receiveNotification(t, conn)
+	assert.Equal(t, api.CollaborationNotificationTypeError, n.Type)
+	require.NotNil(t, n.Status)
+	assert.Equal(t, http.StatusForbidden, *n.Status)
+
+	tskMgr.AssertNotCalled(t, "Get", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleTaskCollaborationInvalidMessage(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+
+	feed, _ := buildLiveTaskEventFeed()
+	presence, _ := buildTaskPresence("user-1", "user-2")
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.TaskEventFeed = feed
+	a.TaskPresence = presence
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+	server := httptest.NewServer(a)
+	defer server.Close()
+
+	// Make request
+	conn := dialCollaboration(t, server)
+	err := conn.WriteMessage(websocket.TextMessage, []byte("not json"))
+	require.NoError(t, err)
+
+	n := receiveNotification(t, conn)
+	assert.Equal(t, api.CollaborationNotificationTypeError, n.Type)
+	require.NotNil(t, n.Status)
+	assert.Equal(t, http.StatusBadRequest, *n.Status)
+
+	err = conn.WriteJSON(map[string]string{"type": "delete", "taskId": "task-1"})
+	require.NoError(t, err)
+
+	n = receiveNotification(t, conn)
+	require.NotNil(t, n.Status)
+	assert.Equal(t, http.StatusUnprocessableEntity, *n.Status)
+}
+
+func TestHandleTaskCollaborationMessageTooLarge(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+
+	feed, _ := buildLiveTaskEventFeed()
+	presence, _ := buildTaskPresence("user-1", "user-2")
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.TaskEventFeed = feed
+	a.TaskPresence = presence
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+	server := httptest.NewServer(a)
+	defer server.Close()
+
+	// Make request
+	conn := dialCollaboration(t, server)
+	err := conn.WriteMessage(websocket.TextMessage, []byte(strings.Repeat("a", 128*1024)))
+	require.NoError(t, err)
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, _, err = conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseMessageTooBig), "Connection was not closed: %v", err)
+}
+
+func TestHandleTaskCollaborationFellBehind(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	presence, _ := buildTaskPresence()
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	// The subscriber is dropped right away
+	a.TaskEventFeed = buildTaskEventFeed()
+	a.TaskPresence = presence
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+	server := httptest.NewServer(a)
+	defer server.Close()
+
+	// Make request
+	conn := dialCollaboration(t, server)
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, _, err := conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, 1013), "Connection was not closed: %v", err)
+}
+
+func TestHandleTaskCollaborationPing(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	feed, _ := buildLiveTaskEventFeed()
+	presence, _ := buildTaskPresence()
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.TaskEventFeed = feed
+	a.TaskPresence = presence
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+	a.CollabPingInterval = 50 * time.Millisecond
+	server := httptest.NewServer(a)
+	defer server.Close()
+
+	// Make request
+	conn := dialCollaboration(t, server)
+
+	// Clients that answer pings, which happens while reading, stay connected
+	notifications := make(chan api.CollaborationNotification)
+	go func() {
+		var n api.CollaborationNotification
+		err := conn.ReadJSON(&n)
+		if err == nil {
+			notifications <- n
+		}
+	}()
+
+	time.Sleep(300 * time.Millisecond)
+	err := conn.WriteMessage(websocket.TextMessage, []byte("not json"))
+	require.NoError(t, err)
+
+	select {
+	case n := <-notifications:
+		assert.Equal(t, api.CollaborationNotificationTypeError, n.Type)
+	case <-time.After(time.Second):
+		t.Fatal("Connection was closed")
+	}
+
+	// Pings are not answered
+	pinged := make(chan struct{}, 1)
+	conn.SetPingHandler(func(string) error {
+		select {
+		case pinged <- struct{}{}:
+		default:
+		}
+		return nil
+	})
+
+	// Pings are only handled while reading
+	readErr := make(chan error, 1)
+	go func() {
+		for {
+			_, _, err := conn.ReadMessage()
+			if err != nil {
+				readErr <- err
+				return
+			}
+		}
+	}()
+
+	select {
+	case <-pinged:
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for a ping")
+	}
+
+	// Clients that do not answer are gone
+	select {
+	case err := <-readErr:
+		assert.Error(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Connection was not closed")
+	}
+}
+
+func TestHandleTaskCollaborationConnectionLimits(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	feed, _ := buildLiveTaskEventFeed()
+	presence, _ := buildTaskPresence()
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.TaskEventFeed = feed
+	a.TaskPresence = presence
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+	a.CollabMaxConnectionsPerPrincipal = 1
+	server := httptest.NewServer(a)
+	defer server.Close()
+
+	// Make request
+	dialCollaboration(t, server)
+
+	_, res, err := websocket.DefaultDialer.Dial(collaborationURL(server), nil)
+	assert.True(t, errors.Is(err, websocket.ErrBadHandshake), "Connection was opened")
+	require.NotNil(t, res)
+	assert.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+
+	a.CollabMaxConnections = 1
+
+	_, res, err = websocket.DefaultDialer.Dial(collaborationURL(server), nil)
+	assert.True(t, errors.Is(err, websocket.ErrBadHandshake), "Connection was opened")
+	require.NotNil(t, res)
+	assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+}
@@ -0,0 +1,317 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi"
+	"github.com/jaredpetersen/go-rest-template/api"
+	"github.com/jaredpetersen/go-rest-template/internal/auth"
+	"github.com/jaredpetersen/go-rest-template/internal/policy"
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+)
+
+func (a *app) handleTaskCommentList() http.HandlerFunc {
+	// Set up dependencies specific to the handler here
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		limit, offset, err := pageParams(req.URL.Query())
+		if err != nil {
+			respondError(w, AppError{External: err}, http.StatusUnprocessableEntity)
+			return
+		}
+
+		id := chi.URLParam(req, "id")
+		principal := auth.FromContext(req.Context())
+
+		// Access to the comments follows access to the task
+		t, err := a.TaskManager.Get(req.Context(), principal.TenantID, id)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		if t == nil {
+			respond(w, nil, http.StatusNotFound)
+			return
+		}
+
+		if !a.authorize(principal, policy.ActionTaskRead, t) {
+			respondError(w, AppError{External: errors.New("forbidden")}, http.StatusForbidden)
+			return
+		}
+
+		page, err := a.CommentManager.ListComments(req.Context(), principal.TenantID, id, limit, offset)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		res := api.CommentList{Comments: make([]api.Comment, 0, len(page.Comments)), Limit: limit, Offset: offset, Total: t.CommentCount}
+		for _, c := range page.Comments {
+			res.Comments = append(res.Comments, toAPIComment(c))
+		}
+
+		respond(w, res, http.StatusOK)
+	}
+}
+
+func (a *app) handleTaskCommentGet() http.HandlerFunc {
+	// Set up dependencies specific to the handler here
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		id := chi.URLParam(req, "id")
+		commentID := chi.URLParam(req, "commentId")
+		principal := auth.FromContext(req.Context())
+
+		t, err := a.TaskManager.Get(req.Context(), principal.TenantID, id)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		if t == nil {
+			respond(w, nil, http.StatusNotFound)
+			return
+		}
+
+		if !a.authorize(principal, policy.ActionTaskRead, t) {
+			respondError(w, AppError{External: errors.New("forbidden")}, http.StatusForbidden)
+			return
+		}
+
+		c, err := a.CommentManager.GetComment(req.Context(), principal.TenantID, id, commentID)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		if c == nil {
+			respond(w, nil, http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("ETag", etag(c.Version))
+		respond(w, toAPIComment(*c), http.StatusOK)
+	}
+}
+
+func (a *app) handleTaskCommentSave() http.HandlerFunc {
+	// Set up dependencies specific to the handler here
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		val := new(api.NewComment)
+		err := receive(req, val)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusBadRequest)
+			return
+		}
+
+		// Validate request body manually
+		err = validateCommentBody(val.Body)
+		if err != nil {
+			respondError(w, AppError{External: err}, http.StatusUnprocessableEntity)
+			return
+		}
+
+		id := chi.URLParam(req, "id")
+		principal := auth.FromContext(req.Context())
+
+		t, err := a.TaskManager.Get(req.Context(), principal.TenantID, id)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		if t == nil {
+			respond(w, nil, http.StatusNotFound)
+			return
+		}
+
+		if !a.authorize(principal, policy.ActionCommentCreate, t) {
+			respondError(w, AppError{External: errors.New("forbidden")}, http.StatusForbidden)
+			return
+		}
+
+		c := task.NewComment()
+		c.TenantID = principal.TenantID
+		c.TaskID = id
+		c.AuthorID = principal.Subject
+		c.Body = val.Body
+
+		// The task may have been deleted after it was retrieved
+		err = a.CommentManager.SaveComment(req.Context(), *c)
+		if errors.Is(err, task.ErrTaskUnavailable) {
+			respond(w, nil, http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, api.Identifier{Id: c.ID}, http.StatusCreated)
+	}
+}
+
+func (a *app) handleTaskCommentUpdate() http.HandlerFunc {
+	// Set up dependencies specific to the handler here
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		// Require clients to prove that they have seen the latest version so that they do not overwrite edits unseen
+		ifMatch := req.Header.Get("If-Match")
+		if ifMatch == "" {
+			respondError(w, AppError{External: errors.New("header 'If-Match' is required")}, http.StatusPreconditionRequired)
+			return
+		}
+
+		val := new(api.NewComment)
+		err := receive(req, val)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusBadRequest)
+			return
+		}
+
+		// Validate request body manually
+		err = validateCommentBody(val.Body)
+		if err != nil {
+			respondError(w, AppError{External: err}, http.StatusUnprocessableEntity)
+			return
+		}
+
+		id := chi.URLParam(req, "id")
+		commentID := chi.URLParam(req, "commentId")
+		principal := auth.FromContext(req.Context())
+
+		t, err := a.TaskManager.Get(req.Context(), principal.TenantID, id)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		if t == nil {
+			respond(w, nil, http.StatusNotFound)
+			return
+		}
+
+		if !a.authorize(principal, policy.ActionTaskRead, t) {
+			respondError(w, AppError{External: errors.New("forbidden")}, http.StatusForbidden)
+			return
+		}
+
+		c, err := a.CommentManager.GetComment(req.Context(), principal.TenantID, id, commentID)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		if c == nil {
+			respond(w, nil, http.StatusNotFound)
+			return
+		}
+
+		// Comments are attributed to their author, so not even moderators may edit them
+		if c.AuthorID != principal.Subject {
+			respondError(w, AppError{External: errors.New("only the author may edit the comment")}, http.StatusForbidden)
+			return
+		}
+
+		if !etagMatches(ifMatch, etag(c.Version)) {
+			respondError(w, AppError{External: errors.New("comment has been modified")}, http.StatusPreconditionFailed)
+			return
+		}
+
+		// The version is checked again atomically in case the comment was modified after it was retrieved
+		updated, err := a.CommentManager.UpdateComment(req.Context(), *c, val.Body)
+		if errors.Is(err, task.ErrCommentVersionConflict) {
+			respondError(w, AppError{External: errors.New("comment has been modified")}, http.StatusPreconditionFailed)
+			return
+		}
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("ETag", etag(updated.Version))
+		respond(w, toAPIComment(*updated), http.StatusOK)
+	}
+}
+
+func (a *app) handleTaskCommentDelete() http.HandlerFunc {
+	// Set up dependencies specific to the handler here
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		id := chi.URLParam(req, "id")
+		commentID := chi.URLParam(req, "commentId")
+		principal := auth.FromContext(req.Context())
+
+		t, err := a.TaskManager.Get(req.Context(), principal.TenantID, id)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		if t == nil {
+			respond(w, nil, http.StatusNotFound)
+			return
+		}
+
+		if !a.authorize(principal, policy.ActionTaskRead, t) {
+			respondError(w, AppError{External: errors.New("forbidden")}, http.StatusForbidden)
+			return
+		}
+
+		c, err := a.CommentManager.GetComment(req.Context(), principal.TenantID, id, commentID)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		if c == nil {
+			respond(w, nil, http.StatusNotFound)
+			return
+		}
+
+		// Authors may always take back what they said
+		if c.AuthorID != principal.Subject && !a.authorize(principal, policy.ActionCommentModerate, t) {
+			respondError(w, AppError{External: errors.New("forbidden")}, http.StatusForbidden)
+			return
+		}
+
+		err = a.CommentManager.DeleteComment(req.Context(), *c)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// validateCommentBody validates the body of a comment. Bodies are Markdown, so they are stored as they were written
+// rather than trimmed.
+func validateCommentBody(body string) error {
+	if strings.TrimSpace(body) == "" {
+		return errors.New("field 'body' is required")
+	}
+	if utf8.RuneCountInString(body) > task.MaxCommentLength {
+		return fmt.Errorf("field 'body' must not be longer than %d characters", task.MaxCommentLength)
+	}
+
+	return nil
+}
+
+// toAPIComment converts the comment to its API representation
+func toAPIComment(c task.Comment) api.Comment {
+	return api.Comment{
+		Id:          c.ID,
+		AuthorId:    c.AuthorID,
+		Body:        c.Body,
+		DateCreated: c.DateCreated,
+		DateUpdated: c.DateUpdated,
+		Version:     c.Version,
+	}
+}
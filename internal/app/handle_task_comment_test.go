@@ -0,0 +1,618 @@
+package app_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaredpetersen/go-rest-template/internal/app"
+	"github.com/jaredpetersen/go-rest-template/internal/app/mocks"
+	"github.com/jaredpetersen/go-rest-template/internal/policy"
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// buildComment creates a comment on the task for tests
+func buildComment(tsk *task.Task, authorID string) *task.Comment {
+	date := time.Date(2021, time.October, 1, 12, 0, 0, 0, time.UTC)
+	return &task.Comment{
+		ID:          "comment-1",
+		TenantID:    tsk.TenantID,
+		TaskID:      tsk.ID,
+		AuthorID:    authorID,
+		Body:        "Bring **snacks**",
+		DateCreated: date,
+		DateUpdated: date,
+		Version:     1,
+	}
+}
+
+func TestHandleTaskCommentList(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+	tsk.CommentCount = 5
+
+	c := buildComment(tsk, "user-2")
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	commentMgr := mocks.CommentManager{}
+	commentMgr.On("ListComments", mock.Anything, "tenant-1", tsk.ID, 2, 4).
+		Return(&task.CommentPage{Comments: []task.Comment{*c}, Limit: 2, Offset: 4}, nil)
+
+	authorizer := mocks.Authorizer{}
+	authorizer.On("Authorize", mock.Anything, policy.ActionTaskRead, tsk).Return(true)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.CommentManager = &commentMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = &authorizer
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/tasks/"+tsk.ID+"/comments?limit=2&offset=4", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	// The total comes from the count on the task
+	expectedJSON := `{"comments": [
+		{"id": "comment-1", "authorId": "user-2", "body": "Bring **snacks**", "dateCreated": "2021-10-01T12:00:00Z",
+			"dateUpdated": "2021-10-01T12:00:00Z", "version": 1}
+	], "limit": 2, "offset": 4, "total": 5}`
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	assert.JSONEq(t, expectedJSON, res.Body.String())
+	commentMgr.AssertExpectations(t)
+	authorizer.AssertExpectations(t)
+}
+
+func TestHandleTaskCommentListForbidden(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-2"
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	commentMgr := mocks.CommentManager{}
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.CommentManager = &commentMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(false)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/tasks/"+tsk.ID+"/comments", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusForbidden, res.Result().StatusCode)
+	commentMgr.AssertNotCalled(t, "ListComments", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleTaskCommentListNotFound(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", "someid").Return(nil, nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/tasks/someid/comments", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusNotFound, res.Result().StatusCode)
+}
+
+func TestHandleTaskCommentGet(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+
+	c := buildComment(tsk, "user-2")
+	c.Version = 3
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	commentMgr := mocks.CommentManager{}
+	commentMgr.On("GetComment", mock.Anything, "tenant-1", tsk.ID, c.ID).Return(c, nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.CommentManager = &commentMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/tasks/"+tsk.ID+"/comments/"+c.ID, nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	expectedJSON := `{"id": "comment-1", "authorId": "user-2", "body": "Bring **snacks**", "dateCreated": "2021-10-01T12:00:00Z",
+		"dateUpdated": "2021-10-01T12:00:00Z", "version": 3}`
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	assert.Equal(t, "\"3\"", res.Result().Header.Get("ETag"))
+	assert.JSONEq(t, expectedJSON, res.Body.String())
+}
+
+func TestHandleTaskCommentGetNotFound(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	commentMgr := mocks.CommentManager{}
+	commentMgr.On("GetComment", mock.Anything, "tenant-1", tsk.ID, "missing").Return(nil, nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.CommentManager = &commentMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/tasks/"+tsk.ID+"/comments/missing", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusNotFound, res.Result().StatusCode)
+}
+
+func TestHandleTaskCommentSave(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-2"
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	// Markdown is stored as it was written
+	commentMgr := mocks.CommentManager{}
+	commentMgr.On("SaveComment", mock.Anything, mock.MatchedBy(func(c task.Comment) bool {
+		return c.TenantID == "tenant-1" && c.TaskID == tsk.ID && c.AuthorID == "user-1" && c.Body == "  Bring **snacks**\n" &&
+			c.Version == 1
+	})).Return(nil)
+
+	authorizer := mocks.Authorizer{}
+	authorizer.On("Authorize", mock.Anything, policy.ActionCommentCreate, tsk).Return(true)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.CommentManager = &commentMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = &authorizer
+
+	// Make request
+	body := `{"body": "  Bring **snacks**\n"}`
+	req, err := http.NewRequest(http.MethodPost, "/tasks/"+tsk.ID+"/comments", strings.NewReader(body))
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusCreated, res.Result().StatusCode)
+	assert.Contains(t, res.Body.String(), `"id":`)
+	commentMgr.AssertExpectations(t)
+	authorizer.AssertExpectations(t)
+}
+
+func TestHandleTaskCommentSaveInvalidBody(t *testing.T) {
+	var tests = []struct {
+		body    string
+		message string
+	}{
+		{`{"body": ""}`, "field 'body' is required"},
+		{`{"body": " \n "}`, "field 'body' is required"},
+		{fmt.Sprintf(`{"body": "%s"}`, strings.Repeat("é", task.MaxCommentLength+1)), "field 'body' must not be longer than 10000 characters"},
+	}
+
+	for _, tt := range tests {
+		// Set up relevant server dependencies
+		tskMgr := mocks.TaskManager{}
+		commentMgr := mocks.CommentManager{}
+
+		// Set up server
+		a := app.New()
+		a.TaskManager = &tskMgr
+		a.CommentManager = &commentMgr
+		a.Authenticator = buildAuthenticator("tasks:write")
+		a.Authorizer = buildAuthorizer(true)
+
+		// Make request
+		req, err := http.NewRequest(http.MethodPost, "/tasks/someid/comments", strings.NewReader(tt.body))
+		require.NoError(t, err)
+		res := httptest.NewRecorder()
+		a.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, res.Result().StatusCode)
+		assert.JSONEq(t, fmt.Sprintf(`{"message": "%s"}`, tt.message), res.Body.String())
+		commentMgr.AssertNotCalled(t, "SaveComment", mock.Anything, mock.Anything)
+	}
+}
+
+func TestHandleTaskCommentSaveMaxLength(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	commentMgr := mocks.CommentManager{}
+	commentMgr.On("SaveComment", mock.Anything, mock.Anything).Return(nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.CommentManager = &commentMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request. Length is counted in characters rather than bytes.
+	body := fmt.Sprintf(`{"body": "%s"}`, strings.Repeat("é", task.MaxCommentLength))
+	req, err := http.NewRequest(http.MethodPost, "/tasks/"+tsk.ID+"/comments", strings.NewReader(body))
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusCreated, res.Result().StatusCode)
+}
+
+func TestHandleTaskCommentSaveForbidden(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-2"
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	commentMgr := mocks.CommentManager{}
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.CommentManager = &commentMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(false)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodPost, "/tasks/"+tsk.ID+"/comments", strings.NewReader(`{"body": "Hello"}`))
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusForbidden, res.Result().StatusCode)
+	commentMgr.AssertNotCalled(t, "SaveComment", mock.Anything, mock.Anything)
+}
+
+func TestHandleTaskCommentSaveTaskDeleted(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	// The task was deleted after it was retrieved
+	commentMgr := mocks.CommentManager{}
+	commentMgr.On("SaveComment", mock.Anything, mock.Anything).Return(task.ErrTaskUnavailable)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.CommentManager = &commentMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodPost, "/tasks/"+tsk.ID+"/comments", strings.NewReader(`{"body": "Hello"}`))
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusNotFound, res.Result().StatusCode)
+}
+
+func buildUpdateCommentRequest(t *testing.T, taskID string, id string, ifMatch string, body string) *http.Request {
+	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf("/tasks/%s/comments/%s", taskID, id), strings.NewReader(body))
+	require.NoError(t, err)
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+	return req
+}
+
+func TestHandleTaskCommentUpdate(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-2"
+
+	c := buildComment(tsk, "user-1")
+	c.Version = 2
+
+	updated := *c
+	updated.Body = "Bring snacks and drinks"
+	updated.Version = 3
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	commentMgr := mocks.CommentManager{}
+	commentMgr.On("GetComment", mock.Anything, "tenant-1", tsk.ID, c.ID).Return(c, nil)
+	commentMgr.On("UpdateComment", mock.Anything, *c, "Bring snacks and drinks").Return(&updated, nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.CommentManager = &commentMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req := buildUpdateCommentRequest(t, tsk.ID, c.ID, "\"2\"", `{"body": "Bring snacks and drinks"}`)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	assert.Equal(t, "\"3\"", res.Result().Header.Get("ETag"))
+	assert.Contains(t, res.Body.String(), `"body":"Bring snacks and drinks"`)
+	commentMgr.AssertExpectations(t)
+}
+
+func TestHandleTaskCommentUpdateMissingIfMatch(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req := buildUpdateCommentRequest(t, "someid", "comment-1", "", `{"body": "Hello"}`)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusPreconditionRequired, res.Result().StatusCode)
+	tskMgr.AssertNotCalled(t, "Get", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleTaskCommentUpdateNotAuthor(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+
+	// Moderators may not edit the comments of others either
+	c := buildComment(tsk, "user-2")
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	commentMgr := mocks.CommentManager{}
+	commentMgr.On("GetComment", mock.Anything, "tenant-1", tsk.ID, c.ID).Return(c, nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.CommentManager = &commentMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req := buildUpdateCommentRequest(t, tsk.ID, c.ID, "\"1\"", `{"body": "Hello"}`)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusForbidden, res.Result().StatusCode)
+	assert.JSONEq(t, `{"message": "only the author may edit the comment"}`, res.Body.String())
+	commentMgr.AssertNotCalled(t, "UpdateComment", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleTaskCommentUpdateOutdatedVersion(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+
+	c := buildComment(tsk, "user-1")
+	c.Version = 2
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	commentMgr := mocks.CommentManager{}
+	commentMgr.On("GetComment", mock.Anything, "tenant-1", tsk.ID, c.ID).Return(c, nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.CommentManager = &commentMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req := buildUpdateCommentRequest(t, tsk.ID, c.ID, "\"1\"", `{"body": "Hello"}`)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusPreconditionFailed, res.Result().StatusCode)
+	commentMgr.AssertNotCalled(t, "UpdateComment", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleTaskCommentUpdateVersionConflict(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+
+	c := buildComment(tsk, "user-1")
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	// The comment was edited after it was retrieved
+	commentMgr := mocks.CommentManager{}
+	commentMgr.On("GetComment", mock.Anything, "tenant-1", tsk.ID, c.ID).Return(c, nil)
+	commentMgr.On("UpdateComment", mock.Anything, *c, "Hello").Return(nil, task.ErrCommentVersionConflict)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.CommentManager = &commentMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req := buildUpdateCommentRequest(t, tsk.ID, c.ID, "\"1\"", `{"body": "Hello"}`)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusPreconditionFailed, res.Result().StatusCode)
+	assert.JSONEq(t, `{"message": "comment has been modified"}`, res.Body.String())
+}
+
+func TestHandleTaskCommentDeleteByAuthor(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-2"
+
+	c := buildComment(tsk, "user-1")
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	commentMgr := mocks.CommentManager{}
+	commentMgr.On("GetComment", mock.Anything, "tenant-1", tsk.ID, c.ID).Return(c, nil)
+	commentMgr.On("DeleteComment", mock.Anything, *c).Return(nil)
+
+	// Authors do not need to be able to moderate
+	authorizer := mocks.Authorizer{}
+	authorizer.On("Authorize", mock.Anything, policy.ActionTaskRead, tsk).Return(true)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.CommentManager = &commentMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = &authorizer
+
+	// Make request
+	req, err := http.NewRequest(http.MethodDelete, "/tasks/"+tsk.ID+"/comments/"+c.ID, nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusNoContent, res.Result().StatusCode)
+	commentMgr.AssertExpectations(t)
+	authorizer.AssertNotCalled(t, "Authorize", mock.Anything, policy.ActionCommentModerate, mock.Anything)
+}
+
+func TestHandleTaskCommentDeleteByModerator(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+
+	c := buildComment(tsk, "user-2")
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	commentMgr := mocks.CommentManager{}
+	commentMgr.On("GetComment", mock.Anything, "tenant-1", tsk.ID, c.ID).Return(c, nil)
+	commentMgr.On("DeleteComment", mock.Anything, *c).Return(nil)
+
+	authorizer := mocks.Authorizer{}
+	authorizer.On("Authorize", mock.Anything, policy.ActionTaskRead, tsk).Return(true)
+	authorizer.On("Authorize", mock.Anything, policy.ActionCommentModerate, tsk).Return(true)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.CommentManager = &commentMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = &authorizer
+
+	// Make request
+	req, err := http.NewRequest(http.MethodDelete, "/tasks/"+tsk.ID+"/comments/"+c.ID, nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusNoContent, res.Result().StatusCode)
+	commentMgr.AssertExpectations(t)
+	authorizer.AssertExpectations(t)
+}
+
+func TestHandleTaskCommentDeleteForbidden(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+
+	c := buildComment(tsk, "user-2")
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	commentMgr := mocks.CommentManager{}
+	commentMgr.On("GetComment", mock.Anything, "tenant-1", tsk.ID, c.ID).Return(c, nil)
+
+	authorizer := mocks.Authorizer{}
+	authorizer.On("Authorize", mock.Anything, policy.ActionTaskRead, tsk).Return(true)
+	authorizer.On("Authorize", mock.Anything, policy.ActionCommentModerate, tsk).Return(false)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.CommentManager = &commentMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = &authorizer
+
+	// Make request
+	req, err := http.NewRequest(http.MethodDelete, "/tasks/"+tsk.ID+"/comments/"+c.ID, nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusForbidden, res.Result().StatusCode)
+	commentMgr.AssertNotCalled(t, "DeleteComment", mock.Anything, mock.Anything)
+}
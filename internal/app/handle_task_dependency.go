@@ -0,0 +1,210 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+	"github.com/jaredpetersen/go-rest-template/api"
+	"github.com/jaredpetersen/go-rest-template/internal/auth"
+	"github.com/jaredpetersen/go-rest-template/internal/policy"
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+)
+
+// errBlockerNotFound is returned when a task would be blocked by a task that does not exist.
+var errBlockerNotFound = errors.New("blocking task does not exist")
+
+// errDependencyCycle is returned when a task would be blocked by itself or by a task that it already blocks.
+var errDependencyCycle = errors.New("task must not be blocked by itself or by a task that it blocks")
+
+func (a *app) handleTaskBlockerSave() http.HandlerFunc {
+	// Set up dependencies specific to the handler here
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		id := chi.URLParam(req, "id")
+		blockerID := chi.URLParam(req, "blockerId")
+		principal := auth.FromContext(req.Context())
+
+		t, ok := a.blockedTask(w, req, principal, id)
+		if !ok {
+			return
+		}
+
+		if blockerID == t.ID {
+			respondError(w, AppError{External: errDependencyCycle}, http.StatusConflict)
+			return
+		}
+
+		blocker, err := a.TaskManager.Get(req.Context(), principal.TenantID, blockerID)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		if blocker == nil {
+			respondError(w, AppError{External: errBlockerNotFound}, http.StatusNotFound)
+			return
+		}
+
+		if !a.authorize(principal, policy.ActionTaskRead, blocker) {
+			respondError(w, AppError{External: errors.New("forbidden")}, http.StatusForbidden)
+			return
+		}
+
+		d := task.NewDependency(principal.TenantID, blocker.ID, t.ID)
+		err = a.DependencyManager.SaveDependency(req.Context(), *d)
+		if errors.Is(err, task.ErrDependencyCycle) {
+			respondError(w, AppError{External: errDependencyCycle, Internal: err}, http.StatusConflict)
+			return
+		}
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, nil, http.StatusNoContent)
+	}
+}
+
+func (a *app) handleTaskBlockerDelete() http.HandlerFunc {
+	// Set up dependencies specific to the handler here
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		id := chi.URLParam(req, "id")
+		blockerID := chi.URLParam(req, "blockerId")
+		principal := auth.FromContext(req.Context())
+
+		t, ok := a.blockedTask(w, req, principal, id)
+		if !ok {
+			return
+		}
+
+		// Malformed IDs can never have been stored as a blocker
+		if _, err := uuid.Parse(blockerID); err != nil {
+			respond(w, nil, http.StatusNoContent)
+			return
+		}
+
+		d := task.Dependency{TenantID: principal.TenantID, BlockerID: blockerID, BlockedID: t.ID}
+		err := a.DependencyManager.DeleteDependency(req.Context(), d)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, nil, http.StatusNoContent)
+	}
+}
+
+func (a *app) handleTaskSort() http.HandlerFunc {
+	// Set up dependencies specific to the handler here
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		ids := parseIDs(req.URL.Query()["ids"])
+
+		if len(ids) == 0 {
+			respondError(w, AppError{External: errors.New("query parameter 'ids' is required")}, http.StatusUnprocessableEntity)
+			return
+		}
+
+		if len(ids) > maxBatchSize {
+			err := fmt.Errorf("query parameter 'ids' must not contain more than %d IDs", maxBatchSize)
+			respondError(w, AppError{External: err}, http.StatusUnprocessableEntity)
+			return
+		}
+
+		principal := auth.FromContext(req.Context())
+
+		// Malformed IDs can never match a task so there is no point in looking them up
+		lookup := make([]string, 0, len(ids))
+		for _, id := range ids {
+			if _, err := uuid.Parse(id); err == nil {
+				lookup = append(lookup, id)
+			}
+		}
+
+		found := make(map[string]task.Task, len(lookup))
+		if len(lookup) > 0 {
+			ts, err := a.TaskManager.GetBatch(req.Context(), principal.TenantID, lookup)
+			if err != nil {
+				respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+				return
+			}
+
+			for _, t := range ts {
+				found[t.ID] = t
+			}
+		}
+
+		// Authorize every task that was found at once so that only a summary of the decisions is audited
+		candidates := make([]task.Task, 0, len(found))
+		for _, id := range ids {
+			if t, ok := found[id]; ok {
+				candidates = append(candidates, t)
+			}
+		}
+		permitted := make(map[string]bool, len(candidates))
+		for i, allowed := range a.authorizeEach(principal, policy.ActionTaskRead, candidates) {
+			permitted[candidates[i].ID] = allowed
+		}
+
+		res := api.BatchTasks{Tasks: []api.Task{}, NotFound: []string{}, Forbidden: []string{}}
+		readable := make([]string, 0, len(found))
+		for _, id := range ids {
+			_, ok := found[id]
+			switch {
+			case !ok:
+				res.NotFound = append(res.NotFound, id)
+			case !permitted[id]:
+				res.Forbidden = append(res.Forbidden, id)
+			default:
+				readable = append(readable, id)
+			}
+		}
+
+		sorted, err := a.DependencyManager.SortTasks(req.Context(), principal.TenantID, readable)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		for _, id := range sorted {
+			res.Tasks = append(res.Tasks, toAPITask(found[id]))
+		}
+
+		respond(w, res, http.StatusOK)
+	}
+}
+
+// blockedTask retrieves the task whose blockers are being changed and ensures that the principal may change it,
+// responding with an error if not
+func (a *app) blockedTask(w http.ResponseWriter, req *http.Request, principal *auth.Principal, id string) (*task.Task, bool) {
+	t, err := a.TaskManager.Get(req.Context(), principal.TenantID, id)
+	if err != nil {
+		respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+		return nil, false
+	}
+
+	if t == nil {
+		respond(w, nil, http.StatusNotFound)
+		return nil, false
+	}
+
+	if !a.authorize(principal, policy.ActionTaskUpdate, t) {
+		respondError(w, AppError{External: errors.New("forbidden")}, http.StatusForbidden)
+		return nil, false
+	}
+
+	return t, true
+}
+
+// toAPIBlockedBy converts the IDs of the tasks blocking a task to their API representation
+func toAPIBlockedBy(blockedBy []string) []string {
+	if blockedBy == nil {
+		return []string{}
+	}
+
+	return blockedBy
+}
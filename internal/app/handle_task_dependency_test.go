@@ -0,0 +1,423 @@
+package app_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jaredpetersen/go-rest-template/api"
+	"github.com/jaredpetersen/go-rest-template/internal/app"
+	"github.com/jaredpetersen/go-rest-template/internal/app/mocks"
+	"github.com/jaredpetersen/go-rest-template/internal/policy"
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleTaskGetBlocked(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+	tsk.Description = "Paint fence"
+	tsk.BlockedBy = []string{"blocker-1", "blocker-2"}
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/tasks/"+tsk.ID, nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	var val api.Task
+	err = json.Unmarshal(res.Body.Bytes(), &val)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	assert.True(t, val.Blocked, "Task is not blocked")
+	assert.Equal(t, []string{"blocker-1", "blocker-2"}, val.BlockedBy, "Incorrect blockers")
+}
+
+func TestHandleTaskBlockerSave(t *testing.T) {
+	blocker := task.New()
+	blocker.TenantID = "tenant-1"
+
+	blocked := task.New()
+	blocked.TenantID = "tenant-1"
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", blocked.ID).Return(blocked, nil)
+	tskMgr.On("Get", mock.Anything, "tenant-1", blocker.ID).Return(blocker, nil)
+
+	depMgr := mocks.DependencyManager{}
+	depMgr.On("SaveDependency", mock.Anything, mock.MatchedBy(func(d task.Dependency) bool {
+		return d.TenantID == "tenant-1" && d.BlockerID == blocker.ID && d.BlockedID == blocked.ID &&
+			!d.DateCreated.IsZero()
+	})).Return(nil)
+
+	authorizer := mocks.Authorizer{}
+	authorizer.On("Authorize", mock.Anything, policy.ActionTaskUpdate, blocked).Return(true)
+	authorizer.On("Authorize", mock.Anything, policy.ActionTaskRead, blocker).Return(true)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.DependencyManager = &depMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = &authorizer
+
+	// Make request
+	req, err := http.NewRequest(http.MethodPut, "/tasks/"+blocked.ID+"/blockers/"+blocker.ID, nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusNoContent, res.Result().StatusCode)
+	assert.Empty(t, res.Body.String())
+
+	depMgr.AssertExpectations(t)
+	authorizer.AssertExpectations(t)
+}
+
+func TestHandleTaskBlockerSaveCycle(t *testing.T) {
+	blocker := task.New()
+	blocker.TenantID = "tenant-1"
+
+	blocked := task.New()
+	blocked.TenantID = "tenant-1"
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", blocked.ID).Return(blocked, nil)
+	tskMgr.On("Get", mock.Anything, "tenant-1", blocker.ID).Return(blocker, nil)
+
+	depMgr := mocks.DependencyManager{}
+	depMgr.On("SaveDependency", mock.Anything, mock.Anything).Return(task.ErrDependencyCycle)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.DependencyManager = &depMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodPut, "/tasks/"+blocked.ID+"/blockers/"+blocker.ID, nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusConflict, res.Result().StatusCode)
+	assert.JSONEq(t, `{"message": "task must not be blocked by itself or by a task that it blocks"}`, res.Body.String())
+}
+
+func TestHandleTaskBlockerSaveSelf(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	depMgr := mocks.DependencyManager{}
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.DependencyManager = &depMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodPut, "/tasks/"+tsk.ID+"/blockers/"+tsk.ID, nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusConflict, res.Result().StatusCode)
+	assert.JSONEq(t, `{"message": "task must not be blocked by itself or by a task that it blocks"}`, res.Body.String())
+	depMgr.AssertNotCalled(t, "SaveDependency", mock.Anything, mock.Anything)
+}
+
+func TestHandleTaskBlockerSaveNotFound(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", "someid").Return(nil, nil)
+
+	depMgr := mocks.DependencyManager{}
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.DependencyManager = &depMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodPut, "/tasks/someid/blockers/otherid", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusNotFound, res.Result().StatusCode)
+	depMgr.AssertNotCalled(t, "SaveDependency", mock.Anything, mock.Anything)
+}
+
+func TestHandleTaskBlockerSaveBlockerNotFound(t *testing.T) {
+	blocked := task.New()
+	blocked.TenantID = "tenant-1"
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", blocked.ID).Return(blocked, nil)
+	tskMgr.On("Get", mock.Anything, "tenant-1", "otherid").Return(nil, nil)
+
+	depMgr := mocks.DependencyManager{}
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.DependencyManager = &depMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodPut, "/tasks/"+blocked.ID+"/blockers/otherid", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusNotFound, res.Result().StatusCode)
+	assert.JSONEq(t, `{"message": "blocking task does not exist"}`, res.Body.String())
+	depMgr.AssertNotCalled(t, "SaveDependency", mock.Anything, mock.Anything)
+}
+
+func TestHandleTaskBlockerSaveForbidden(t *testing.T) {
+	blocker := task.New()
+	blocker.TenantID = "tenant-1"
+
+	blocked := task.New()
+	blocked.TenantID = "tenant-1"
+
+	testCases := []struct {
+		name           string
+		canUpdate      bool
+		canReadBlocker bool
+	}{
+		{name: "blocked task", canUpdate: false, canReadBlocker: true},
+		{name: "blocking task", canUpdate: true, canReadBlocker: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Set up relevant server dependencies
+			tskMgr := mocks.TaskManager{}
+			tskMgr.On("Get", mock.Anything, "tenant-1", blocked.ID).Return(blocked, nil)
+			tskMgr.On("Get", mock.Anything, "tenant-1", blocker.ID).Return(blocker, nil)
+
+			depMgr := mocks.DependencyManager{}
+
+			authorizer := mocks.Authorizer{}
+			authorizer.On("Authorize", mock.Anything, policy.ActionTaskUpdate, blocked).Return(tc.canUpdate)
+			authorizer.On("Authorize", mock.Anything, policy.ActionTaskRead, blocker).Return(tc.canReadBlocker)
+
+			// Set up server
+			a := app.New()
+			a.TaskManager = &tskMgr
+			a.DependencyManager = &depMgr
+			a.Authenticator = buildAuthenticator("tasks:write")
+			a.Authorizer = &authorizer
+
+			// Make request
+			req, err := http.NewRequest(http.MethodPut, "/tasks/"+blocked.ID+"/blockers/"+blocker.ID, nil)
+			require.NoError(t, err)
+			res := httptest.NewRecorder()
+			a.ServeHTTP(res, req)
+
+			assert.Equal(t, http.StatusForbidden, res.Result().StatusCode)
+			depMgr.AssertNotCalled(t, "SaveDependency", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestHandleTaskBlockerDelete(t *testing.T) {
+	blocked := task.New()
+	blocked.TenantID = "tenant-1"
+
+	blockerID := task.New().ID
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", blocked.ID).Return(blocked, nil)
+
+	depMgr := mocks.DependencyManager{}
+	d := task.Dependency{TenantID: "tenant-1", BlockerID: blockerID, BlockedID: blocked.ID}
+	depMgr.On("DeleteDependency", mock.Anything, d).Return(nil)
+
+	authorizer := mocks.Authorizer{}
+	authorizer.On("Authorize", mock.Anything, policy.ActionTaskUpdate, blocked).Return(true)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.DependencyManager = &depMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = &authorizer
+
+	// Make request
+	req, err := http.NewRequest(http.MethodDelete, "/tasks/"+blocked.ID+"/blockers/"+blockerID, nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusNoContent, res.Result().StatusCode)
+
+	depMgr.AssertExpectations(t)
+}
+
+func TestHandleTaskBlockerDeleteInvalidBlockerID(t *testing.T) {
+	blocked := task.New()
+	blocked.TenantID = "tenant-1"
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", blocked.ID).Return(blocked, nil)
+
+	depMgr := mocks.DependencyManager{}
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.DependencyManager = &depMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodDelete, "/tasks/"+blocked.ID+"/blockers/notanid", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusNoContent, res.Result().StatusCode)
+	depMgr.AssertNotCalled(t, "DeleteDependency", mock.Anything, mock.Anything)
+}
+
+func TestHandleTaskBlockerDeleteForbidden(t *testing.T) {
+	blocked := task.New()
+	blocked.TenantID = "tenant-1"
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", blocked.ID).Return(blocked, nil)
+
+	depMgr := mocks.DependencyManager{}
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.DependencyManager = &depMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(false)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodDelete, "/tasks/"+blocked.ID+"/blockers/"+task.New().ID, nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusForbidden, res.Result().StatusCode)
+	depMgr.AssertNotCalled(t, "DeleteDependency", mock.Anything, mock.Anything)
+}
+
+func TestHandleTaskSort(t *testing.T) {
+	first := task.New()
+	first.TenantID = "tenant-1"
+	first.OwnerID = "user-1"
+
+	second := task.New()
+	second.TenantID = "tenant-1"
+	second.OwnerID = "user-1"
+	second.BlockedBy = []string{first.ID}
+
+	hidden := task.New()
+	hidden.TenantID = "tenant-1"
+	hidden.OwnerID = "user-2"
+
+	missingID := task.New().ID
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("GetBatch", mock.Anything, "tenant-1", []string{second.ID, hidden.ID, missingID, first.ID}).
+		Return([]task.Task{*first, *second, *hidden}, nil)
+
+	depMgr := mocks.DependencyManager{}
+	depMgr.On("SortTasks", mock.Anything, "tenant-1", []string{second.ID, first.ID}).
+		Return([]string{first.ID, second.ID}, nil)
+
+	authorizer := mocks.Authorizer{}
+	authorizer.On("AuthorizeEach", mock.Anything, policy.ActionTaskRead, []task.Task{*second, *hidden, *first}).
+		Return([]bool{true, false, true})
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.DependencyManager = &depMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = &authorizer
+
+	// Make request
+	url := fmt.Sprintf("/tasks:sort?ids=%s,%s&ids=%s,notanid,%s", second.ID, hidden.ID, missingID, first.ID)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	var val api.BatchTasks
+	err = json.Unmarshal(res.Body.Bytes(), &val)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	require.Len(t, val.Tasks, 2, "Returned incorrect number of tasks")
+	assert.Equal(t, first.ID, val.Tasks[0].Id, "Returned tasks out of order")
+	assert.Equal(t, second.ID, val.Tasks[1].Id, "Returned tasks out of order")
+	assert.True(t, val.Tasks[1].Blocked, "Task is not blocked")
+	assert.Equal(t, []string{missingID, "notanid"}, val.NotFound, "Incorrect tasks not found")
+	assert.Equal(t, []string{hidden.ID}, val.Forbidden, "Incorrect forbidden tasks")
+
+	depMgr.AssertExpectations(t)
+}
+
+func TestHandleTaskSortMissingIDs(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	depMgr := mocks.DependencyManager{}
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.DependencyManager = &depMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/tasks:sort", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Result().StatusCode)
+	assert.JSONEq(t, `{"message": "query parameter 'ids' is required"}`, res.Body.String())
+	depMgr.AssertNotCalled(t, "SortTasks", mock.Anything, mock.Anything, mock.Anything)
+}
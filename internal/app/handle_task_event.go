@@ -0,0 +1,303 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jaredpetersen/go-rest-template/api"
+	"github.com/jaredpetersen/go-rest-template/internal/auth"
+	"github.com/jaredpetersen/go-rest-template/internal/outbox"
+	"github.com/jaredpetersen/go-rest-template/internal/policy"
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+	"github.com/rs/zerolog/hlog"
+)
+
+const (
+	// defaultTaskEventHeartbeat is how often heartbeats are sent when the interval is not configured
+	defaultTaskEventHeartbeat = 15 * time.Second
+	// taskEventWriteTimeout is how long each write to a task event stream may take, in place of the server's
+	// WriteTimeout, so that streams to clients that have gone away are still closed
+	taskEventWriteTimeout = 10 * time.Second
+	// taskEventCatchUpBatchSize is the number of missed events retrieved at a time when resuming a stream
+	taskEventCatchUpBatchSize = 100
+)
+
+// taskEventFilter selects the task events that a client wants
+type taskEventFilter struct {
+	projectID string
+	taskIDs   map[string]bool
+	tags      []string
+	anyTag    bool
+}
+
+// matches determines whether or not the client wants events about the task
+func (f taskEventFilter) matches(t task.Task) bool {
+	if f.projectID != "" && t.ProjectID != f.projectID {
+		return false
+	}
+
+	if len(f.taskIDs) > 0 && !f.taskIDs[t.ID] {
+		return false
+	}
+
+	if len(f.tags) == 0 {
+		return true
+	}
+
+	has := make(map[string]bool, len(t.Tags))
+	for _, tag := range t.Tags {
+		has[tag] = true
+	}
+
+	matched := 0
+	for _, tag := range f.tags {
+		if has[tag] {
+			matched++
+		}
+	}
+
+	if f.anyTag {
+		return matched > 0
+	}
+	return matched == len(f.tags)
+}
+
+func (a *app) handleTaskEventStream() http.HandlerFunc {
+	// Set up dependencies specific to the handler here
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		filter, err := fromAPITaskEventFilter(req.URL.Query())
+		if err != nil {
+			respondError(w, AppError{External: err}, http.StatusUnprocessableEntity)
+			return
+		}
+
+		// Browsers send the header when they reconnect but cannot set it on the first connection
+		lastEventID := req.Header.Get("Last-Event-ID")
+		if lastEventID == "" {
+			lastEventID = req.URL.Query().Get("lastEventId")
+		}
+		if lastEventID != "" && !outbox.ValidStreamID(lastEventID) {
+			respondError(w, AppError{External: errors.New("last event ID must be the ID of an event")}, http.StatusUnprocessableEntity)
+			return
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			respondError(w, AppError{Internal: errors.New("response writer does not support streaming")}, http.StatusInternalServerError)
+			return
+		}
+
+		heartbeat := a.TaskEventHeartbeat
+		if heartbeat <= 0 {
+			heartbeat = defaultTaskEventHeartbeat
+		}
+
+		principal := auth.FromContext(req.Context())
+		logger := hlog.FromRequest(req)
+
+		// Subscribe before catching up so that nothing is missed in between, skipping what was already caught up on
+		entries, unsubscribe := a.TaskEventFeed.Subscribe()
+		defer unsubscribe()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		// Keep proxies such as nginx from buffering the stream
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		stream := taskEventStream{w: w, flusher: flusher, req: req, principal: principal, filter: filter, app: a}
+
+		// Send the headers right away so that the client knows that the stream is open
+		err = stream.write(": connected\n\n")
+		if err != nil {
+			return
+		}
+
+		if lastEventID != "" {
+			err = stream.catchUp(lastEventID)
+			if err != nil {
+				logger.Warn().Err(err).Msg("Task event stream ended while catching up")
+				return
+			}
+		}
+
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-req.Context().Done():
+				return
+			case <-ticker.C:
+				err = stream.write(": heartbeat\n\n")
+				if err != nil {
+					return
+				}
+			case e, ok := <-entries:
+				// The client was dropped for falling behind and will catch up when it reconnects
+				if !ok {
+					return
+				}
+
+				err = stream.send(e)
+				if err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+// taskEventStream writes task events to a client
+type taskEventStream struct {
+	w         http.ResponseWriter
+	flusher   http.Flusher
+	req       *http.Request
+	principal *auth.Principal
+	filter    taskEventFilter
+	app       *app
+	// lastID is the ID of the last event that was sent or skipped
+	lastID string
+}
+
+// catchUp sends the events after the event that the client last received
+func (s *taskEventStream) catchUp(lastEventID string) error {
+	s.lastID = lastEventID
+
+	for first := true; ; first = false {
+		es, found, err := s.app.TaskEventFeed.Since(s.req.Context(), s.lastID, taskEventCatchUpBatchSize)
+		if err != nil {
+			return err
+		}
+
+		// The client has missed events that are no longer kept and must start over
+		if first && !found {
+			err = s.write("event: reset\ndata: {}\n\n")
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, e := range es {
+			err = s.send(e)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(es) < taskEventCatchUpBatchSize {
+			return nil
+		}
+	}
+}
+
+// send sends the event if the client has not seen it yet, is allowed to, and wants to
+func (s *taskEventStream) send(e outbox.Entry) error {
+	if s.lastID != "" && outbox.CompareStreamIDs(e.StreamID, s.lastID) <= 0 {
+		return nil
+	}
+	s.lastID = e.StreamID
+
+	if e.TenantID != s.principal.TenantID {
+		return nil
+	}
+
+	t, err := fromTaskEventEntry(e)
+	if err != nil {
+		hlog.FromRequest(s.req).Warn().Err(err).Str("event", e.ID).Msg("Failed to decode task event")
+		return nil
+	}
+
+	if t == nil || !s.app.authorize(s.principal, policy.ActionTaskRead, t) || !s.filter.matches(*t) {
+		return nil
+	}
+
+	data, err := json.Marshal(toAPITaskEvent(e, *t))
+	if err != nil {
+		return err
+	}
+
+	return s.write(fmt.Sprintf("id: %s\nevent: %s\ndata: %s\n\n", e.StreamID, e.Type, data))
+}
+
+// write writes to the stream and sends it to the client right away
+func (s *taskEventStream) write(chunk string) error {
+	err := extendWriteDeadline(s.req, time.Now().Add(taskEventWriteTimeout))
+	if err != nil {
+		return err
+	}
+
+	_, err = s.w.Write([]byte(chunk))
+	if err != nil {
+		return err
+	}
+
+	s.flusher.Flush()
+	return nil
+}
+
+// fromTaskEventEntry decodes the task that an entry from the task event feed is about. Nil is returned for entries that
+// are not task events.
+func fromTaskEventEntry(e outbox.Entry) (*task.Task, error) {
+	if !task.ValidEventType(e.Type) {
+		return nil, nil
+	}
+
+	var t task.Task
+	err := json.Unmarshal(e.Payload, &t)
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+// toAPITaskEvent converts an entry from the task event feed about the task to its API representation
+func toAPITaskEvent(e outbox.Entry, t task.Task) api.TaskEvent {
+	return api.TaskEvent{
+		Id:   e.ID,
+		Type: api.TaskEventType(e.Type),
+		Date: e.DateCreated,
+		Task: toAPITask(t),
+	}
+}
+
+// fromAPITaskEventFilter parses the query parameters that select which task events to stream
+func fromAPITaskEventFilter(params url.Values) (taskEventFilter, error) {
+	f := taskEventFilter{projectID: strings.TrimSpace(params.Get("projectId"))}
+
+	for _, id := range params["taskId"] {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			return f, errors.New("query parameter 'taskId' must not be empty")
+		}
+
+		if f.taskIDs == nil {
+			f.taskIDs = make(map[string]bool)
+		}
+		f.taskIDs[id] = true
+	}
+
+	values := params["tag"]
+	tags, err := fromAPITags(&values)
+	if err != nil {
+		return f, errors.New("query parameter 'tag' must not be empty")
+	}
+	f.tags = tags
+
+	switch api.TagMatch(params.Get("tagMatch")) {
+	case "", api.TagMatchAll:
+	case api.TagMatchAny:
+		f.anyTag = true
+	default:
+		return f, errors.New("query parameter 'tagMatch' must be 'all' or 'any'")
+	}
+
+	return f, nil
+}
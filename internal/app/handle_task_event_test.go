@@ -0,0 +1,456 @@
+package app_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaredpetersen/go-rest-template/api"
+	"github.com/jaredpetersen/go-rest-template/internal/app"
+	"github.com/jaredpetersen/go-rest-template/internal/app/mocks"
+	"github.com/jaredpetersen/go-rest-template/internal/auth"
+	"github.com/jaredpetersen/go-rest-template/internal/outbox"
+	"github.com/jaredpetersen/go-rest-template/internal/policy"
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func buildTaskEvent(t *testing.T, streamID string, eventType string, tsk task.Task) outbox.Entry {
+	payload, err := json.Marshal(tsk)
+	require.NoError(t, err)
+
+	m := outbox.NewMessage(tsk.TenantID, tsk.ID, int64(tsk.Version), eventType, payload)
+	return outbox.Entry{StreamID: streamID, Message: *m}
+}
+
+func buildEventTask(id string) task.Task {
+	tsk := task.New()
+	tsk.ID = id
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+	tsk.Description = "Task " + id
+	return *tsk
+}
+
+// buildTaskEventFeed creates a feed that streams the entries and then drops the subscriber
+func buildTaskEventFeed(entries ...outbox.Entry) *mocks.TaskEventFeed {
+	ch := make(chan outbox.Entry, len(entries))
+	for _, e := range entries {
+		ch <- e
+	}
+	close(ch)
+
+	feed := mocks.TaskEventFeed{}
+	feed.On("Subscribe").Return((<-chan outbox.Entry)(ch), func() {})
+	return &feed
+}
+
+// parseTaskEvents reads the IDs and data of the events in a stream, ignoring comments
+func parseTaskEvents(t *testing.T, body string) ([]string, []api.TaskEvent) {
+	var ids []string
+	var events []api.TaskEvent
+	for _, block := range strings.Split(body, "\n\n") {
+		for _, line := range strings.Split(block, "\n") {
+			switch {
+			case strings.HasPrefix(line, "id: "):
+				ids = append(ids, strings.TrimPrefix(line, "id: "))
+			case strings.HasPrefix(line, "data: ") && line != "data: {}":
+				var e api.TaskEvent
+				err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &e)
+				require.NoError(t, err)
+				events = append(events, e)
+			}
+		}
+	}
+	return ids, events
+}
+
+func TestHandleTaskEventStream(t *testing.T) {
+	first := buildEventTask("task-1")
+	second := buildEventTask("task-2")
+	otherTenant := buildEventTask("task-3")
+	otherTenant.TenantID = "tenant-2"
+
+	// Set up relevant server dependencies
+	feed := buildTaskEventFeed(
+		buildTaskEvent(t, "1-0", task.EventCreated, first),
+		buildTaskEvent(t, "2-0", task.EventCreated, otherTenant),
+		buildTaskEvent(t, "3-0", "project.created", first),
+		buildTaskEvent(t, "4-0", task.EventDeleted, second),
+	)
+
+	// Set up server
+	a := app.New()
+	a.TaskEventFeed = feed
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/tasks/events", nil)
+	require.NoError(t, err)
+	rr := httptest.NewRecorder()
+	a.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect status code")
+	assert.Equal(t, "text/event-stream", rr.Header().Get("Content-Type"))
+	assert.Equal(t, "no-cache", rr.Header().Get("Cache-Control"))
+
+	body := rr.Body.String()
+	assert.True(t, strings.HasPrefix(body, ": connected\n\n"), "Stream was not opened with a comment")
+	assert.Contains(t, body, "id: 1-0\nevent: task.created\ndata: ")
+	assert.Contains(t, body, "id: 4-0\nevent: task.deleted\ndata: ")
+
+	ids, events := parseTaskEvents(t, body)
+	assert.Equal(t, []string{"1-0", "4-0"}, ids)
+	require.Len(t, events, 2)
+	assert.Equal(t, api.TaskEventTypeTaskCreated, events[0].Type)
+	assert.Equal(t, "task-1", events[0].Task.Id)
+	assert.Equal(t, "Task task-1", events[0].Task.Description)
+	assert.NotEmpty(t, events[0].Id)
+	assert.Equal(t, api.TaskEventTypeTaskDeleted, events[1].Type)
+	assert.Equal(t, "task-2", events[1].Task.Id)
+}
+
+func TestHandleTaskEventStreamFiltersUnreadableTasks(t *testing.T) {
+	readable := buildEventTask("task-1")
+	unreadable := buildEventTask("task-2")
+	unreadable.OwnerID = "user-2"
+
+	// Set up relevant server dependencies
+	feed := buildTaskEventFeed(
+		buildTaskEvent(t, "1-0", task.EventCreated, readable),
+		buildTaskEvent(t, "2-0", task.EventCreated, unreadable),
+	)
+
+	authorizer := mocks.Authorizer{}
+	authorizer.On("Authorize", mock.Anything, policy.ActionTaskRead, mock.Anything).
+		Return(func(p auth.Principal, action policy.Action, tsk *task.Task) bool {
+			return tsk.OwnerID == p.Subject
+		})
+
+	// Set up server
+	a := app.New()
+	a.TaskEventFeed = feed
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = &authorizer
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/tasks/events", nil)
+	require.NoError(t, err)
+	rr := httptest.NewRecorder()
+	a.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect status code")
+
+	ids, _ := parseTaskEvents(t, rr.Body.String())
+	assert.Equal(t, []string{"1-0"}, ids)
+}
+
+func TestHandleTaskEventStreamFilters(t *testing.T) {
+	inProject := buildEventTask("task-1")
+	inProject.ProjectID = "project-1"
+	inProject.Tags = []string{"chores", "home"}
+	otherProject := buildEventTask("task-2")
+	otherProject.ProjectID = "project-2"
+	otherProject.Tags = []string{"chores", "home"}
+	untagged := buildEventTask("task-3")
+	untagged.ProjectID = "project-1"
+	partlyTagged := buildEventTask("task-4")
+	partlyTagged.ProjectID = "project-1"
+	partlyTagged.Tags = []string{"chores"}
+
+	entries := []outbox.Entry{
+		buildTaskEvent(t, "1-0", task.EventUpdated, inProject),
+		buildTaskEvent(t, "2-0", task.EventUpdated, otherProject),
+		buildTaskEvent(t, "3-0", task.EventUpdated, untagged),
+		buildTaskEvent(t, "4-0", task.EventUpdated, partlyTagged),
+	}
+
+	tests := []struct {
+		query       string
+		expectedIDs []string
+	}{
+		{query: "", expectedIDs: []string{"1-0", "2-0", "3-0", "4-0"}},
+		{query: "?projectId=project-1", expectedIDs: []string{"1-0", "3-0", "4-0"}},
+		{query: "?taskId=task-2&taskId=task-3", expectedIDs: []string{"2-0", "3-0"}},
+		{query: "?tag=chores&tag=home", expectedIDs: []string{"1-0", "2-0"}},
+		{query: "?tag=chores&tag=home&tagMatch=any", expectedIDs: []string{"1-0", "2-0", "4-0"}},
+		{query: "?projectId=project-1&tag=home", expectedIDs: []string{"1-0"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			// Set up server
+			a := app.New()
+			a.TaskEventFeed = buildTaskEventFeed(entries...)
+			a.Authenticator = buildAuthenticator("tasks:read")
+			a.Authorizer = buildAuthorizer(true)
+
+			// Make request
+			req, err := http.NewRequest(http.MethodGet, "/tasks/events"+test.query, nil)
+			require.NoError(t, err)
+			rr := httptest.NewRecorder()
+			a.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code, "Incorrect status code")
+
+			ids, _ := parseTaskEvents(t, rr.Body.String())
+			assert.Equal(t, test.expectedIDs, ids)
+		})
+	}
+}
+
+func TestHandleTaskEventStreamResumes(t *testing.T) {
+	tsk := buildEventTask("task-1")
+
+	// Set up relevant server dependencies
+	// The live events overlap with the missed events since the client subscribes before catching up
+	feed := buildTaskEventFeed(
+		buildTaskEvent(t, "3-0", task.EventUpdated, tsk),
+		buildTaskEvent(t, "4-0", task.EventUpdated, tsk),
+	)
+	feed.On("Since", mock.Anything, "1-0", int64(100)).
+		Return([]outbox.Entry{
+			buildTaskEvent(t, "2-0", task.EventUpdated, tsk),
+			buildTaskEvent(t, "3-0", task.EventUpdated, tsk),
+		}, true, nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskEventFeed = feed
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/tasks/events", nil)
+	require.NoError(t, err)
+	req.Header.Set("Last-Event-ID", "1-0")
+	rr := httptest.NewRecorder()
+	a.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect status code")
+
+	body := rr.Body.String()
+	assert.NotContains(t, body, "event: reset")
+
+	ids, _ := parseTaskEvents(t, body)
+	assert.Equal(t, []string{"2-0", "3-0", "4-0"}, ids)
+
+	feed.AssertExpectations(t)
+}
+
+func TestHandleTaskEventStreamResumesInBatches(t *testing.T) {
+	tsk := buildEventTask("task-1")
+
+	batch := make([]outbox.Entry, 100)
+	for i := range batch {
+		batch[i] = buildTaskEvent(t, "1-"+strconv.Itoa(i+1), task.EventUpdated, tsk)
+	}
+
+	// Set up relevant server dependencies
+	feed := buildTaskEventFeed()
+	feed.On("Since", mock.Anything, "1-0", int64(100)).Return(batch, true, nil)
+	feed.On("Since", mock.Anything, "1-100", int64(100)).
+		Return([]outbox.Entry{buildTaskEvent(t, "1-101", task.EventUpdated, tsk)}, true, nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskEventFeed = feed
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/tasks/events?lastEventId=1-0", nil)
+	require.NoError(t, err)
+	rr := httptest.NewRecorder()
+	a.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect status code")
+
+	ids, _ := parseTaskEvents(t, rr.Body.String())
+	require.Len(t, ids, 101)
+	assert.Equal(t, "1-101", ids[100])
+
+	feed.AssertExpectations(t)
+}
+
+func TestHandleTaskEventStreamResets(t *testing.T) {
+	tsk := buildEventTask("task-1")
+
+	// Set up relevant server dependencies
+	feed := buildTaskEventFeed()
+	feed.On("Since", mock.Anything, "1-0", int64(100)).
+		Return([]outbox.Entry{buildTaskEvent(t, "5-0", task.EventUpdated, tsk)}, false, nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskEventFeed = feed
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/tasks/events", nil)
+	require.NoError(t, err)
+	req.Header.Set("Last-Event-ID", "1-0")
+	rr := httptest.NewRecorder()
+	a.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect status code")
+
+	body := rr.Body.String()
+	assert.Contains(t, body, "event: reset\ndata: {}\n\n")
+	assert.Less(t, strings.Index(body, "event: reset"), strings.Index(body, "id: 5-0"), "Reset was not sent first")
+}
+
+func TestHandleTaskEventStreamHeartbeat(t *testing.T) {
+	// Set up relevant server dependencies
+	ch := make(chan outbox.Entry)
+	feed := mocks.TaskEventFeed{}
+	feed.On("Subscribe").Return((<-chan outbox.Entry)(ch), func() {})
+
+	// Set up server
+	a := app.New()
+	a.TaskEventFeed = &feed
+	a.TaskEventHeartbeat = time.Millisecond
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Drop the client once it has had time for a few heartbeats
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		close(ch)
+	}()
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/tasks/events", nil)
+	require.NoError(t, err)
+	rr := httptest.NewRecorder()
+	a.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Incorrect status code")
+	assert.Contains(t, rr.Body.String(), ": heartbeat\n\n")
+}
+
+func TestHandleTaskEventStreamInvalidLastEventID(t *testing.T) {
+	// Set up relevant server dependencies
+	feed := mocks.TaskEventFeed{}
+
+	// Set up server
+	a := app.New()
+	a.TaskEventFeed = &feed
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/tasks/events", nil)
+	require.NoError(t, err)
+	req.Header.Set("Last-Event-ID", "abc")
+	rr := httptest.NewRecorder()
+	a.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code, "Incorrect status code")
+	assert.JSONEq(t, `{"message":"last event ID must be the ID of an event"}`, rr.Body.String())
+
+	feed.AssertNotCalled(t, "Subscribe")
+}
+
+func TestHandleTaskEventStreamInvalidFilter(t *testing.T) {
+	tests := []struct {
+		query           string
+		expectedMessage string
+	}{
+		{query: "?taskId=", expectedMessage: "query parameter 'taskId' must not be empty"},
+		{query: "?tag=", expectedMessage: "query parameter 'tag' must not be empty"},
+		{query: "?tag=home&tagMatch=some", expectedMessage: "query parameter 'tagMatch' must be 'all' or 'any'"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			// Set up relevant server dependencies
+			feed := mocks.TaskEventFeed{}
+
+			// Set up server
+			a := app.New()
+			a.TaskEventFeed = &feed
+			a.Authenticator = buildAuthenticator("tasks:read")
+			a.Authorizer = buildAuthorizer(true)
+
+			// Make request
+			req, err := http.NewRequest(http.MethodGet, "/tasks/events"+test.query, nil)
+			require.NoError(t, err)
+			rr := httptest.NewRecorder()
+			a.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusUnprocessableEntity, rr.Code, "Incorrect status code")
+			assert.JSONEq(t, `{"message":"`+test.expectedMessage+`"}`, rr.Body.String())
+		})
+	}
+}
+
+func TestHandleTaskEventStreamRequiresScope(t *testing.T) {
+	// Set up relevant server dependencies
+	feed := mocks.TaskEventFeed{}
+
+	// Set up server
+	a := app.New()
+	a.TaskEventFeed = &feed
+	a.Authenticator = buildAuthenticator()
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/tasks/events", nil)
+	require.NoError(t, err)
+	rr := httptest.NewRecorder()
+	a.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code, "Incorrect status code")
+
+	feed.AssertNotCalled(t, "Subscribe")
+}
+
+func TestHandleTaskEventStreamOutlivesWriteTimeout(t *testing.T) {
+	// Set up relevant server dependencies
+	ch := make(chan outbox.Entry)
+	feed := mocks.TaskEventFeed{}
+	feed.On("Subscribe").Return((<-chan outbox.Entry)(ch), func() {})
+
+	// Set up server
+	a := app.New()
+	a.TaskEventFeed = &feed
+	a.TaskEventHeartbeat = 10 * time.Millisecond
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+
+	srv := httptest.NewUnstartedServer(a)
+	srv.Config.WriteTimeout = 50 * time.Millisecond
+	srv.Config.ConnContext = app.ConnContext
+	srv.Start()
+	defer srv.Close()
+
+	// Send an event well after the server's write timeout
+	tsk := buildEventTask("task-1")
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		ch <- buildTaskEvent(t, "1-0", task.EventCreated, tsk)
+		close(ch)
+	}()
+
+	// Make request
+	res, err := http.Get(srv.URL + "/tasks/events")
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err, "Stream was cut off")
+
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Incorrect status code")
+	ids, _ := parseTaskEvents(t, string(body))
+	assert.Equal(t, []string{"1-0"}, ids)
+}
@@ -0,0 +1,175 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/jaredpetersen/go-rest-template/api"
+	"github.com/jaredpetersen/go-rest-template/internal/auth"
+	"github.com/jaredpetersen/go-rest-template/internal/policy"
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+)
+
+func (a *app) handleTaskHistoryList() http.HandlerFunc {
+	// Set up dependencies specific to the handler here
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		limit, offset, err := pageParams(req.URL.Query())
+		if err != nil {
+			respondError(w, AppError{External: err}, http.StatusUnprocessableEntity)
+			return
+		}
+
+		id := chi.URLParam(req, "id")
+		principal := auth.FromContext(req.Context())
+
+		// Access to the history follows access to the task, so the history of deleted tasks is not available
+		t, err := a.TaskManager.Get(req.Context(), principal.TenantID, id)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		if t == nil {
+			respond(w, nil, http.StatusNotFound)
+			return
+		}
+
+		if !a.authorize(principal, policy.ActionTaskRead, t) {
+			respondError(w, AppError{External: errors.New("forbidden")}, http.StatusForbidden)
+			return
+		}
+
+		rs, err := a.HistoryManager.ListHistory(req.Context(), principal.TenantID, id, limit, offset)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		res := api.TaskHistory{Revisions: make([]api.TaskRevision, 0, len(rs)), Limit: limit, Offset: offset}
+		for _, r := range rs {
+			res.Revisions = append(res.Revisions, toAPITaskRevision(r))
+		}
+
+		respond(w, res, http.StatusOK)
+	}
+}
+
+func (a *app) handleTaskRevert() http.HandlerFunc {
+	// Set up dependencies specific to the handler here
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		// Require clients to prove that they have seen the latest version so that they do not revert changes unseen
+		ifMatch := req.Header.Get("If-Match")
+		if ifMatch == "" {
+			respondError(w, AppError{External: errors.New("header 'If-Match' is required")}, http.StatusPreconditionRequired)
+			return
+		}
+
+		val := new(api.RevertTask)
+		err := receive(req, val)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusBadRequest)
+			return
+		}
+
+		// Validate request body manually
+		if val.Version < 1 {
+			respondError(w, AppError{External: errors.New("field 'version' must be positive")}, http.StatusUnprocessableEntity)
+			return
+		}
+
+		id := chi.URLParam(req, "id")
+		principal := auth.FromContext(req.Context())
+
+		// The history is only revealed to principals that could revert the task
+		t, err := a.TaskManager.Get(req.Context(), principal.TenantID, id)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		if t == nil {
+			respond(w, nil, http.StatusNotFound)
+			return
+		}
+
+		if !a.authorize(principal, policy.ActionTaskUpdate, t) {
+			respondError(w, AppError{External: errors.New("forbidden")}, http.StatusForbidden)
+			return
+		}
+
+		r, err := a.HistoryManager.GetRevision(req.Context(), principal.TenantID, id, val.Version)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		if r == nil || r.Action == task.ActionDeleted {
+			err = fmt.Errorf("field 'version' has version %d, which is not in the task's history", val.Version)
+			respondError(w, AppError{External: err}, http.StatusUnprocessableEntity)
+			return
+		}
+
+		// Reverting is an update like any other so that it follows the same rules and is recorded in the history
+		updated, statusErr := a.updateTask(req.Context(), principal, id, ifMatch, revertUpdate(r.Task))
+		if statusErr != nil && statusErr.StatusCode == http.StatusNotFound {
+			respond(w, nil, http.StatusNotFound)
+			return
+		}
+		if statusErr != nil {
+			respondError(w, statusErr.AppError, statusErr.StatusCode)
+			return
+		}
+
+		w.Header().Set("ETag", etag(updated.Version))
+		respond(w, toAPITask(*updated), http.StatusOK)
+	}
+}
+
+// revertUpdate builds the update that restores a task to how it was in its history. Fields that updates cannot change,
+// such as the owner and project, are left out.
+func revertUpdate(t task.Task) api.UpdateTask {
+	completed := t.Completed()
+	parentID := t.ParentID
+	reminders := toAPIReminders(t.ReminderOffsets)
+	shares := toAPIShares(t.Shares)
+	tags := toAPITags(t.Tags)
+
+	// An empty rule stops the task from recurring
+	recurrence := toAPIRecurrence(t.Recurrence)
+	if recurrence == nil {
+		recurrence = &api.Recurrence{}
+	}
+
+	return api.UpdateTask{
+		Description: t.Description,
+		DateDue:     t.DateDue,
+		Completed:   &completed,
+		ParentId:    &parentID,
+		Recurrence:  recurrence,
+		Reminders:   &reminders,
+		Shares:      &shares,
+		Tags:        &tags,
+	}
+}
+
+// toAPITaskRevision converts the task revision to its API representation
+func toAPITaskRevision(r task.Revision) api.TaskRevision {
+	changes := make([]api.TaskChange, 0, len(r.Changes))
+	for _, c := range r.Changes {
+		// Values are already JSON encoded, so they are passed through as they are
+		changes = append(changes, api.TaskChange{Field: c.Field, Before: c.Before, After: c.After})
+	}
+
+	return api.TaskRevision{
+		Id:      r.ID,
+		Version: r.Version,
+		Action:  api.TaskRevisionAction(r.Action),
+		Actor:   api.Actor{Subject: r.Actor.Subject, RequestId: r.Actor.RequestID},
+		Changes: changes,
+		Date:    r.DateCreated,
+	}
+}
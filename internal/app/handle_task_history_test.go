@@ -0,0 +1,536 @@
+package app_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaredpetersen/go-rest-template/internal/app"
+	"github.com/jaredpetersen/go-rest-template/internal/app/mocks"
+	"github.com/jaredpetersen/go-rest-template/internal/policy"
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleTaskHistoryList(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+
+	date := time.Date(2021, time.October, 1, 12, 0, 0, 0, time.UTC)
+	rs := []task.Revision{
+		{
+			ID:      "revision-2",
+			Version: 2,
+			Action:  task.ActionUpdated,
+			Actor:   task.Actor{Subject: "user-2", RequestID: "request-2"},
+			Changes: []task.Change{
+				{Field: "description", Before: json.RawMessage(`"Buy milk"`), After: json.RawMessage(`"Buy oat milk"`)},
+				{Field: "dateDue", Before: json.RawMessage(`null`), After: json.RawMessage(`"2021-10-02T12:00:00Z"`)},
+			},
+			DateCreated: date.Add(time.Hour),
+		},
+		{
+			ID:          "revision-1",
+			Version:     1,
+			Action:      task.ActionCreated,
+			Actor:       task.Actor{Subject: "user-1", RequestID: "request-1"},
+			Changes:     []task.Change{{Field: "description", Before: json.RawMessage(`null`), After: json.RawMessage(`"Buy milk"`)}},
+			DateCreated: date,
+		},
+	}
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	historyMgr := mocks.HistoryManager{}
+	historyMgr.On("ListHistory", mock.Anything, "tenant-1", tsk.ID, 2, 4).Return(rs, nil)
+
+	authorizer := mocks.Authorizer{}
+	authorizer.On("Authorize", mock.Anything, policy.ActionTaskRead, tsk).Return(true)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.HistoryManager = &historyMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = &authorizer
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/tasks/"+tsk.ID+"/history?limit=2&offset=4", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	expectedJSON := `{"revisions": [
+		{"id": "revision-2", "version": 2, "action": "updated", "actor": {"subject": "user-2", "requestId": "request-2"},
+			"changes": [
+				{"field": "description", "before": "Buy milk", "after": "Buy oat milk"},
+				{"field": "dateDue", "before": null, "after": "2021-10-02T12:00:00Z"}
+			],
+			"date": "2021-10-01T13:00:00Z"},
+		{"id": "revision-1", "version": 1, "action": "created", "actor": {"subject": "user-1", "requestId": "request-1"},
+			"changes": [{"field": "description", "before": null, "after": "Buy milk"}],
+			"date": "2021-10-01T12:00:00Z"}
+	], "limit": 2, "offset": 4}`
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	assert.JSONEq(t, expectedJSON, res.Body.String())
+	historyMgr.AssertExpectations(t)
+	authorizer.AssertExpectations(t)
+}
+
+func TestHandleTaskHistoryListInvalidLimit(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/tasks/someid/history?limit=0", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Result().StatusCode)
+	assert.JSONEq(t, `{"message": "query parameter 'limit' must be between 1 and 100"}`, res.Body.String())
+	tskMgr.AssertNotCalled(t, "Get", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleTaskHistoryListNotFound(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", "someid").Return(nil, nil)
+
+	historyMgr := mocks.HistoryManager{}
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.HistoryManager = &historyMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/tasks/someid/history", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusNotFound, res.Result().StatusCode)
+	historyMgr.AssertNotCalled(t, "ListHistory", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleTaskHistoryListForbidden(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	historyMgr := mocks.HistoryManager{}
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.HistoryManager = &historyMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(false)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/tasks/"+tsk.ID+"/history", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusForbidden, res.Result().StatusCode)
+	historyMgr.AssertNotCalled(t, "ListHistory", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleTaskHistoryListError(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	historyMgr := mocks.HistoryManager{}
+	historyMgr.On("ListHistory", mock.Anything, "tenant-1", tsk.ID, 20, 0).Return(nil, errors.New("failure to list history"))
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.HistoryManager = &historyMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/tasks/"+tsk.ID+"/history", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusInternalServerError, res.Result().StatusCode)
+}
+
+func TestHandleTaskUpdateRecordsActor(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+
+	updatedTsk := *tsk
+	updatedTsk.Version = 2
+
+	// Set up relevant server dependencies
+	var actor task.Actor
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+	tskMgr.On("Update", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { actor = task.ActorFromContext(args.Get(0).(context.Context)) }).
+		Return(&updatedTsk, nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req := buildUpdateTaskRequest(t, tsk.ID, "\"1\"", "{\"description\": \"Buy oat milk\"}")
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	// The change is tied to the request that the client was told about
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	assert.NotEmpty(t, res.Result().Header.Get("X-Request-Id"), "Did not identify request")
+	assert.Equal(t, task.Actor{Subject: "user-1", RequestID: res.Result().Header.Get("X-Request-Id")}, actor)
+}
+
+func TestHandleTaskGetAsOf(t *testing.T) {
+	asOf := time.Date(2021, time.October, 1, 12, 0, 0, 0, time.UTC)
+
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+	tsk.Description = "Buy oat milk"
+	tsk.Version = 3
+	tsk.Rollup = task.Rollup{Subtasks: 2, Completed: 1}
+	tsk.CommentCount = 4
+
+	past := *tsk
+	past.Description = "Buy milk"
+	past.Version = 2
+	past.Rollup = task.Rollup{}
+	past.CommentCount = 1
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	historyMgr := mocks.HistoryManager{}
+	historyMgr.On("GetRevisionAsOf", mock.Anything, "tenant-1", tsk.ID, mock.MatchedBy(asOf.Equal)).
+		Return(&task.Revision{Version: 2, Action: task.ActionUpdated, Task: past}, nil)
+
+	authorizer := mocks.Authorizer{}
+	authorizer.On("Authorize", mock.Anything, policy.ActionTaskRead, tsk).Return(true)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.HistoryManager = &historyMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = &authorizer
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/tasks/"+tsk.ID+"?asOf=2021-10-01T08:00:00-04:00", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	// Subtask rollups and comment counts are not part of the history
+	expectedJSON := fmt.Sprintf("{\"id\": \"%s\", \"ownerId\": \"user-1\", \"projectId\": null, \"description\": \"Buy milk\", \"dateDue\": null, "+
+		"\"parentId\": null, \"completed\": false, \"dateCompleted\": null, \"subtasks\": {\"total\": 2, \"completed\": 1, \"percentComplete\": 50}, "+
+		"\"blocked\": false, \"blockedBy\": [], \"recurrence\": null, \"reminders\": [], \"shares\": [], \"tags\": [], \"commentCount\": 4, \"version\": 2}",
+		tsk.ID)
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	assert.Equal(t, "\"2\"", res.Result().Header.Get("ETag"))
+	assert.JSONEq(t, expectedJSON, res.Body.String())
+	historyMgr.AssertExpectations(t)
+	authorizer.AssertExpectations(t)
+}
+
+func TestHandleTaskGetAsOfNotFound(t *testing.T) {
+	testCases := []struct {
+		name     string
+		revision *task.Revision
+	}{
+		{name: "BeforeCreated"},
+		{name: "Deleted", revision: &task.Revision{Version: 2, Action: task.ActionDeleted}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tsk := task.New()
+			tsk.TenantID = "tenant-1"
+
+			// Set up relevant server dependencies
+			tskMgr := mocks.TaskManager{}
+			tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+			historyMgr := mocks.HistoryManager{}
+			historyMgr.On("GetRevisionAsOf", mock.Anything, "tenant-1", tsk.ID, mock.Anything).Return(tc.revision, nil)
+
+			// Set up server
+			a := app.New()
+			a.TaskManager = &tskMgr
+			a.HistoryManager = &historyMgr
+			a.Authenticator = buildAuthenticator("tasks:read")
+			a.Authorizer = buildAuthorizer(true)
+
+			// Make request
+			req, err := http.NewRequest(http.MethodGet, "/tasks/"+tsk.ID+"?asOf=2021-10-01T12:00:00Z", nil)
+			require.NoError(t, err)
+			res := httptest.NewRecorder()
+			a.ServeHTTP(res, req)
+
+			assert.Equal(t, http.StatusNotFound, res.Result().StatusCode)
+		})
+	}
+}
+
+func TestHandleTaskGetAsOfInvalid(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/tasks/someid?asOf=yesterday", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Result().StatusCode)
+	assert.JSONEq(t, `{"message": "query parameter 'asOf' must be an RFC 3339 date-time"}`, res.Body.String())
+	tskMgr.AssertNotCalled(t, "Get", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// buildRevertTaskRequest builds a request to revert the task to the version
+func buildRevertTaskRequest(t *testing.T, id string, ifMatch string, body string) *http.Request {
+	req, err := http.NewRequest(http.MethodPost, "/tasks/"+id+"/revert", strings.NewReader(body))
+	require.NoError(t, err)
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+	return req
+}
+
+func TestHandleTaskRevert(t *testing.T) {
+	dateDue := time.Date(2021, time.October, 1, 12, 0, 0, 0, time.UTC)
+
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+	tsk.Description = "Buy oat milk"
+	tsk.Tags = []string{"groceries"}
+	tsk.Version = 3
+
+	past := *tsk
+	past.Description = "Buy milk"
+	past.DateDue = &dateDue
+	past.Tags = []string{"errands", "groceries"}
+	past.Version = 1
+
+	reverted := past
+	reverted.Version = 4
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+	tskMgr.On("Update", mock.Anything, mock.MatchedBy(func(t task.Task) bool {
+		return t.ID == tsk.ID && t.Version == 3 && t.Description == "Buy milk" && t.DateDue.Equal(dateDue) &&
+			len(t.Tags) == 2 && t.Recurrence == nil
+	})).Return(&reverted, nil)
+
+	tagMgr := mocks.TagManager{}
+	tagMgr.On("GetTagsByName", mock.Anything, "tenant-1", []string{"errands", "groceries"}).
+		Return([]task.Tag{{Name: "errands"}, {Name: "groceries"}}, nil)
+
+	historyMgr := mocks.HistoryManager{}
+	historyMgr.On("GetRevision", mock.Anything, "tenant-1", tsk.ID, 1).
+		Return(&task.Revision{Version: 1, Action: task.ActionCreated, Task: past}, nil)
+
+	authorizer := mocks.Authorizer{}
+	authorizer.On("Authorize", mock.Anything, policy.ActionTaskUpdate, tsk).Return(true)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.TagManager = &tagMgr
+	a.HistoryManager = &historyMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = &authorizer
+
+	// Make request
+	req := buildRevertTaskRequest(t, tsk.ID, "\"3\"", `{"version": 1}`)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	assert.Equal(t, "\"4\"", res.Result().Header.Get("ETag"))
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &body))
+	assert.Equal(t, "Buy milk", body["description"])
+	assert.Equal(t, 4.0, body["version"])
+
+	tskMgr.AssertExpectations(t)
+	authorizer.AssertExpectations(t)
+}
+
+func TestHandleTaskRevertMissingIfMatch(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req := buildRevertTaskRequest(t, "someid", "", `{"version": 1}`)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusPreconditionRequired, res.Result().StatusCode)
+	tskMgr.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestHandleTaskRevertInvalidVersion(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req := buildRevertTaskRequest(t, "someid", "\"3\"", `{"version": 0}`)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Result().StatusCode)
+	assert.JSONEq(t, `{"message": "field 'version' must be positive"}`, res.Body.String())
+}
+
+func TestHandleTaskRevertUnknownVersion(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.Version = 3
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	historyMgr := mocks.HistoryManager{}
+	historyMgr.On("GetRevision", mock.Anything, "tenant-1", tsk.ID, 7).Return(nil, nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.HistoryManager = &historyMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req := buildRevertTaskRequest(t, tsk.ID, "\"3\"", `{"version": 7}`)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Result().StatusCode)
+	assert.JSONEq(t, `{"message": "field 'version' has version 7, which is not in the task's history"}`, res.Body.String())
+	tskMgr.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestHandleTaskRevertForbidden(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	historyMgr := mocks.HistoryManager{}
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.HistoryManager = &historyMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(false)
+
+	// Make request
+	req := buildRevertTaskRequest(t, tsk.ID, "\"1\"", `{"version": 1}`)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusForbidden, res.Result().StatusCode)
+	historyMgr.AssertNotCalled(t, "GetRevision", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleTaskRevertModified(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.Description = "Buy oat milk"
+	tsk.Version = 3
+
+	past := *tsk
+	past.Description = "Buy milk"
+	past.Version = 1
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	historyMgr := mocks.HistoryManager{}
+	historyMgr.On("GetRevision", mock.Anything, "tenant-1", tsk.ID, 1).
+		Return(&task.Revision{Version: 1, Action: task.ActionCreated, Task: past}, nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.HistoryManager = &historyMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req := buildRevertTaskRequest(t, tsk.ID, "\"2\"", `{"version": 1}`)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusPreconditionFailed, res.Result().StatusCode)
+	tskMgr.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,121 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/jaredpetersen/go-rest-template/api"
+	"github.com/jaredpetersen/go-rest-template/internal/auth"
+	"github.com/jaredpetersen/go-rest-template/internal/policy"
+	"github.com/jaredpetersen/go-rest-template/internal/recurrence"
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+)
+
+// defaultOccurrenceLimit is the number of occurrences previewed when the client does not specify a limit.
+const defaultOccurrenceLimit = 10
+
+func (a *app) handleTaskOccurrenceList() http.HandlerFunc {
+	// Set up dependencies specific to the handler here
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		limit, err := intParam(req.URL.Query().Get("limit"), defaultOccurrenceLimit)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			err = fmt.Errorf("query parameter 'limit' must be between 1 and %d", maxPageLimit)
+			respondError(w, AppError{External: err}, http.StatusUnprocessableEntity)
+			return
+		}
+
+		id := chi.URLParam(req, "id")
+		principal := auth.FromContext(req.Context())
+
+		t, err := a.TaskManager.Get(req.Context(), principal.TenantID, id)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		if t == nil {
+			respond(w, nil, http.StatusNotFound)
+			return
+		}
+
+		if !a.authorize(principal, policy.ActionTaskRead, t) {
+			respondError(w, AppError{External: errors.New("forbidden")}, http.StatusForbidden)
+			return
+		}
+
+		res := api.OccurrenceList{Occurrences: []time.Time{}}
+		switch {
+		case t.Recurrence != nil:
+			s, err := t.Recurrence.Set()
+			if err != nil {
+				respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+				return
+			}
+
+			from := t.Recurrence.DateStart
+			if t.DateDue != nil {
+				from = *t.DateDue
+			}
+			res.Occurrences = s.Occurrences(from, limit)
+		case t.DateDue != nil:
+			res.Occurrences = append(res.Occurrences, *t.DateDue)
+		}
+
+		respond(w, res, http.StatusOK)
+	}
+}
+
+// toAPIRecurrence converts the task recurrence to its API representation, which is null for tasks that do not recur
+func toAPIRecurrence(r *task.Recurrence) *api.Recurrence {
+	if r == nil {
+		return nil
+	}
+
+	exDates := r.ExDates
+	if exDates == nil {
+		exDates = []time.Time{}
+	}
+
+	timeZone := r.TimeZone
+	dateStart := r.DateStart
+	return &api.Recurrence{Rule: r.Rule, TimeZone: &timeZone, DateStart: &dateStart, ExDates: &exDates}
+}
+
+// fromAPIRecurrence validates and converts the API representation of a task recurrence. The recurrence starts on the
+// task's due date. Nil is returned for an empty rule, which does not recur.
+func fromAPIRecurrence(val api.Recurrence, dateDue *time.Time) (*task.Recurrence, error) {
+	if strings.TrimSpace(val.Rule) == "" {
+		return nil, nil
+	}
+
+	rule, err := recurrence.Parse(val.Rule)
+	if err != nil {
+		return nil, fmt.Errorf("field 'recurrence.rule' has an %v", err)
+	}
+
+	timeZone := "UTC"
+	if val.TimeZone != nil && *val.TimeZone != "" {
+		timeZone = *val.TimeZone
+	}
+
+	// The local time zone depends on where the server happens to run
+	if _, err := time.LoadLocation(timeZone); err != nil || timeZone == "Local" {
+		return nil, errors.New("field 'recurrence.timeZone' must be an IANA time zone")
+	}
+
+	if dateDue == nil {
+		return nil, errors.New("field 'dateDue' is required for recurring tasks")
+	}
+
+	r := task.Recurrence{Rule: rule.String(), TimeZone: timeZone, DateStart: *dateDue}
+	if val.ExDates != nil && len(*val.ExDates) > 0 {
+		r.ExDates = append([]time.Time{}, *val.ExDates...)
+	}
+
+	return &r, nil
+}
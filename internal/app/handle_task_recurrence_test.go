@@ -0,0 +1,379 @@
+package app_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/jaredpetersen/go-rest-template/internal/app"
+	"github.com/jaredpetersen/go-rest-template/internal/app/mocks"
+	"github.com/jaredpetersen/go-rest-template/internal/policy"
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleTaskSaveRecurring(t *testing.T) {
+	dateDue := time.Date(2026, time.March, 2, 14, 0, 0, 0, time.UTC)
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Save", mock.Anything, mock.MatchedBy(func(tsk task.Task) bool {
+		r := tsk.Recurrence
+		return r != nil && r.Rule == "FREQ=WEEKLY;BYDAY=MO,WE" && r.TimeZone == "America/New_York" &&
+			r.DateStart.Equal(dateDue) && len(r.ExDates) == 1
+	})).Return(nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	body := `{"description": "Water the plants", "dateDue": "2026-03-02T14:00:00Z",
+		"recurrence": {"rule": "rrule:freq=weekly;byday=mo,we", "timeZone": "America/New_York",
+			"exDates": ["2026-03-04T14:00:00Z"]}}`
+	req, err := http.NewRequest(http.MethodPost, "/tasks", strings.NewReader(body))
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusCreated, res.Result().StatusCode)
+	tskMgr.AssertExpectations(t)
+}
+
+func TestHandleTaskSaveRecurringInvalid(t *testing.T) {
+	testCases := []struct {
+		name    string
+		body    string
+		message string
+	}{
+		{
+			name:    "InvalidRule",
+			body:    `{"description": "Water the plants", "dateDue": "2026-03-02T14:00:00Z", "recurrence": {"rule": "FREQ=HOURLY"}}`,
+			message: "field 'recurrence.rule' has an invalid recurrence rule: frequency HOURLY is not supported",
+		},
+		{
+			name: "UnknownTimeZone",
+			body: `{"description": "Water the plants", "dateDue": "2026-03-02T14:00:00Z",
+				"recurrence": {"rule": "FREQ=DAILY", "timeZone": "Mars/Olympus_Mons"}}`,
+			message: "field 'recurrence.timeZone' must be an IANA time zone",
+		},
+		{
+			name:    "MissingDueDate",
+			body:    `{"description": "Water the plants", "recurrence": {"rule": "FREQ=DAILY"}}`,
+			message: "field 'dateDue' is required for recurring tasks",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Set up relevant server dependencies
+			tskMgr := mocks.TaskManager{}
+
+			// Set up server
+			a := app.New()
+			a.TaskManager = &tskMgr
+			a.Authenticator = buildAuthenticator("tasks:write")
+			a.Authorizer = buildAuthorizer(true)
+
+			// Make request
+			req, err := http.NewRequest(http.MethodPost, "/tasks", strings.NewReader(tc.body))
+			require.NoError(t, err)
+			res := httptest.NewRecorder()
+			a.ServeHTTP(res, req)
+
+			assert.Equal(t, http.StatusUnprocessableEntity, res.Result().StatusCode)
+			assert.JSONEq(t, fmt.Sprintf(`{"message": %q}`, tc.message), res.Body.String())
+			tskMgr.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestHandleTaskUpdateKeepsRecurrenceStart(t *testing.T) {
+	dateStart := time.Date(2026, time.March, 2, 14, 0, 0, 0, time.UTC)
+	dateDue := dateStart.AddDate(0, 0, 7)
+
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+	tsk.Description = "Water the plants"
+	tsk.DateDue = &dateDue
+	tsk.Recurrence = &task.Recurrence{Rule: "FREQ=WEEKLY;COUNT=4", TimeZone: "UTC", DateStart: dateStart}
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+	tskMgr.On("Update", mock.Anything, mock.MatchedBy(func(t task.Task) bool {
+		return t.Recurrence != nil && t.Recurrence.DateStart.Equal(dateStart) && len(t.Recurrence.ExDates) == 1
+	})).Return(tsk, nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	body := `{"description": "Water the plants", "dateDue": "2026-03-09T14:00:00Z",
+		"recurrence": {"rule": "FREQ=WEEKLY;COUNT=4", "exDates": ["2026-03-16T14:00:00Z"]}}`
+	req := buildUpdateTaskRequest(t, tsk.ID, "\"1\"", body)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	tskMgr.AssertExpectations(t)
+}
+
+func TestHandleTaskUpdateChangesRecurrence(t *testing.T) {
+	dateStart := time.Date(2026, time.March, 2, 14, 0, 0, 0, time.UTC)
+	dateDue := dateStart.AddDate(0, 0, 7)
+
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+	tsk.Description = "Water the plants"
+	tsk.DateDue = &dateDue
+	tsk.Recurrence = &task.Recurrence{Rule: "FREQ=WEEKLY", TimeZone: "UTC", DateStart: dateStart}
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+	tskMgr.On("Update", mock.Anything, mock.MatchedBy(func(t task.Task) bool {
+		return t.Recurrence != nil && t.Recurrence.Rule == "FREQ=DAILY" && t.Recurrence.DateStart.Equal(dateDue)
+	})).Return(tsk, nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	body := `{"description": "Water the plants", "dateDue": "2026-03-09T14:00:00Z", "recurrence": {"rule": "FREQ=DAILY"}}`
+	req := buildUpdateTaskRequest(t, tsk.ID, "\"1\"", body)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	tskMgr.AssertExpectations(t)
+}
+
+func TestHandleTaskUpdateRemovesRecurrence(t *testing.T) {
+	dateDue := time.Date(2026, time.March, 2, 14, 0, 0, 0, time.UTC)
+
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+	tsk.Description = "Water the plants"
+	tsk.DateDue = &dateDue
+	tsk.Recurrence = &task.Recurrence{Rule: "FREQ=WEEKLY", TimeZone: "UTC", DateStart: dateDue}
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+	tskMgr.On("Update", mock.Anything, mock.MatchedBy(func(t task.Task) bool {
+		return t.Recurrence == nil
+	})).Return(tsk, nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	body := `{"description": "Water the plants", "dateDue": "2026-03-02T14:00:00Z", "recurrence": {"rule": ""}}`
+	req := buildUpdateTaskRequest(t, tsk.ID, "\"1\"", body)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	tskMgr.AssertExpectations(t)
+}
+
+func TestHandleTaskGetRecurring(t *testing.T) {
+	dateDue := time.Date(2026, time.March, 2, 14, 0, 0, 0, time.UTC)
+
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+	tsk.Description = "Water the plants"
+	tsk.DateDue = &dateDue
+	tsk.Recurrence = &task.Recurrence{Rule: "FREQ=WEEKLY", TimeZone: "America/New_York", DateStart: dateDue}
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/tasks/"+tsk.ID, nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	expectedJSON := fmt.Sprintf(`{"id": "%s", "ownerId": "user-1", "projectId": null, "description": "Water the plants",
+		"dateDue": "2026-03-02T14:00:00Z", "parentId": null, "completed": false, "dateCompleted": null,
+		"subtasks": {"total": 0, "completed": 0, "percentComplete": 0}, "blocked": false, "blockedBy": [],
+		"recurrence": {"rule": "FREQ=WEEKLY", "timeZone": "America/New_York", "dateStart": "2026-03-02T14:00:00Z", "exDates": []},
+		"reminders": [], "shares": [], "tags": [], "commentCount": 0, "version": 1}`, tsk.ID)
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	assert.JSONEq(t, expectedJSON, res.Body.String())
+}
+
+func TestHandleTaskOccurrenceList(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	// The clocks spring forward on March 8th, 2026 in New York
+	dateStart := time.Date(2026, time.March, 2, 9, 0, 0, 0, loc)
+	dateDue := time.Date(2026, time.March, 4, 9, 0, 0, 0, loc)
+
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+	tsk.DateDue = &dateDue
+	tsk.Recurrence = &task.Recurrence{Rule: "FREQ=WEEKLY;BYDAY=MO,WE", TimeZone: "America/New_York", DateStart: dateStart}
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	authorizer := mocks.Authorizer{}
+	authorizer.On("Authorize", mock.Anything, policy.ActionTaskRead, tsk).Return(true)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = &authorizer
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/tasks/"+tsk.ID+"/occurrences?limit=3", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	expectedJSON := `{"occurrences": ["2026-03-04T09:00:00-05:00", "2026-03-09T09:00:00-04:00", "2026-03-11T09:00:00-04:00"]}`
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	assert.JSONEq(t, expectedJSON, res.Body.String())
+	authorizer.AssertExpectations(t)
+}
+
+func TestHandleTaskOccurrenceListNotRecurring(t *testing.T) {
+	dateDue := time.Date(2026, time.March, 2, 14, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name     string
+		dateDue  *time.Time
+		expected string
+	}{
+		{name: "DueDate", dateDue: &dateDue, expected: `{"occurrences": ["2026-03-02T14:00:00Z"]}`},
+		{name: "NoDueDate", expected: `{"occurrences": []}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tsk := task.New()
+			tsk.TenantID = "tenant-1"
+			tsk.DateDue = tc.dateDue
+
+			// Set up relevant server dependencies
+			tskMgr := mocks.TaskManager{}
+			tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+			// Set up server
+			a := app.New()
+			a.TaskManager = &tskMgr
+			a.Authenticator = buildAuthenticator("tasks:read")
+			a.Authorizer = buildAuthorizer(true)
+
+			// Make request
+			req, err := http.NewRequest(http.MethodGet, "/tasks/"+tsk.ID+"/occurrences", nil)
+			require.NoError(t, err)
+			res := httptest.NewRecorder()
+			a.ServeHTTP(res, req)
+
+			assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+			assert.JSONEq(t, tc.expected, res.Body.String())
+		})
+	}
+}
+
+func TestHandleTaskOccurrenceListInvalidLimit(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/tasks/someid/occurrences?limit=101", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Result().StatusCode)
+	assert.JSONEq(t, `{"message": "query parameter 'limit' must be between 1 and 100"}`, res.Body.String())
+	tskMgr.AssertNotCalled(t, "Get", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleTaskOccurrenceListNotFound(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", "someid").Return(nil, nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/tasks/someid/occurrences", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusNotFound, res.Result().StatusCode)
+}
+
+func TestHandleTaskOccurrenceListForbidden(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(false)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/tasks/"+tsk.ID+"/occurrences", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusForbidden, res.Result().StatusCode)
+}
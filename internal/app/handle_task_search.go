@@ -0,0 +1,66 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/jaredpetersen/go-rest-template/api"
+	"github.com/jaredpetersen/go-rest-template/internal/auth"
+	"github.com/jaredpetersen/go-rest-template/internal/policy"
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+)
+
+func (a *app) handleTaskSearch() http.HandlerFunc {
+	// Set up dependencies specific to the handler here
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		params := req.URL.Query()
+
+		q := strings.TrimSpace(params.Get("q"))
+		if q == "" {
+			respondError(w, AppError{External: errors.New("query parameter 'q' is required")}, http.StatusUnprocessableEntity)
+			return
+		}
+
+		limit, offset, err := pageParams(params)
+		if err != nil {
+			respondError(w, AppError{External: err}, http.StatusUnprocessableEntity)
+			return
+		}
+
+		principal := auth.FromContext(req.Context())
+
+		// Every result is retrieved and filtered down to what the principal can read before paginating so that pages
+		// are full and stable
+		results, err := a.TaskManager.Search(req.Context(), principal.TenantID, q, maxPagedResults)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		ts := make([]task.Task, len(results))
+		for i, result := range results {
+			ts[i] = result.Task
+		}
+		readable := a.authorizeEach(principal, policy.ActionTaskRead, ts)
+
+		res := api.TaskSearchResults{Results: []api.TaskSearchResult{}, Limit: limit, Offset: offset}
+		for i, result := range results {
+			if !readable[i] {
+				continue
+			}
+
+			if res.Total >= offset && len(res.Results) < limit {
+				res.Results = append(res.Results, api.TaskSearchResult{
+					Task:      toAPITask(result.Task),
+					Score:     result.Score,
+					Highlight: result.Highlight,
+				})
+			}
+			res.Total++
+		}
+
+		respond(w, res, http.StatusOK)
+	}
+}
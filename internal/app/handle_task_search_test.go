@@ -0,0 +1,159 @@
+package app_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jaredpetersen/go-rest-template/internal/app"
+	"github.com/jaredpetersen/go-rest-template/internal/app/mocks"
+	"github.com/jaredpetersen/go-rest-template/internal/policy"
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func buildSearchResults(descriptions ...string) []task.SearchResult {
+	results := make([]task.SearchResult, len(descriptions))
+	for i, description := range descriptions {
+		tsk := task.New()
+		tsk.TenantID = "tenant-1"
+		tsk.OwnerID = "user-1"
+		tsk.Description = description
+		results[i] = task.SearchResult{Task: *tsk, Score: float64(len(descriptions) - i), Highlight: description}
+	}
+	return results
+}
+
+func TestHandleTaskSearch(t *testing.T) {
+	results := buildSearchResults("Buy <mark>socks</mark>")
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Search", mock.Anything, "tenant-1", "socks", 1000).Return(results, nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/tasks/search?q=socks", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	expectedJSON := fmt.Sprintf(`{
+		"results": [{
+			"task": {"id": "%s", "ownerId": "user-1", "projectId": null, "description": "Buy <mark>socks</mark>", "dateDue": null,
+			"parentId": null, "completed": false, "dateCompleted": null, "subtasks": {"total": 0, "completed": 0, "percentComplete": 0},
+			"blocked": false, "blockedBy": [], "recurrence": null, "reminders": [],
+			"shares": [], "tags": [], "commentCount": 0, "version": 1},
+			"score": 1,
+			"highlight": "Buy <mark>socks</mark>"
+		}],
+		"total": 1,
+		"limit": 20,
+		"offset": 0
+	}`, results[0].Task.ID)
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	assert.JSONEq(t, expectedJSON, res.Body.String())
+}
+
+func TestHandleTaskSearchPagination(t *testing.T) {
+	results := buildSearchResults("first", "second", "third", "fourth", "fifth")
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Search", mock.Anything, "tenant-1", "socks", mock.Anything).Return(results, nil)
+
+	authorizer := mocks.Authorizer{}
+	authorizer.On("AuthorizeEach", mock.Anything, policy.ActionTaskRead, mock.Anything).
+		Return([]bool{true, false, true, true, true})
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = &authorizer
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/tasks/search?q=socks&limit=2&offset=1", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+
+	// Tasks that cannot be read are left out before paginating
+	assert.Contains(t, res.Body.String(), `"total":4`)
+	assert.Contains(t, res.Body.String(), `"highlight":"third"`)
+	assert.Contains(t, res.Body.String(), `"highlight":"fourth"`)
+	assert.NotContains(t, res.Body.String(), `"highlight":"first"`)
+	assert.NotContains(t, res.Body.String(), `"highlight":"second"`)
+	assert.NotContains(t, res.Body.String(), `"highlight":"fifth"`)
+}
+
+func TestHandleTaskSearchInvalidParameters(t *testing.T) {
+	testCases := []struct {
+		name            string
+		query           string
+		expectedMessage string
+	}{
+		{name: "MissingQuery", query: "", expectedMessage: "query parameter 'q' is required"},
+		{name: "BlankQuery", query: "q=%20", expectedMessage: "query parameter 'q' is required"},
+		{name: "NonNumericLimit", query: "q=socks&limit=ten", expectedMessage: "query parameter 'limit' must be between 1 and 100"},
+		{name: "ZeroLimit", query: "q=socks&limit=0", expectedMessage: "query parameter 'limit' must be between 1 and 100"},
+		{name: "LargeLimit", query: "q=socks&limit=101", expectedMessage: "query parameter 'limit' must be between 1 and 100"},
+		{name: "NegativeOffset", query: "q=socks&offset=-1", expectedMessage: "query parameter 'offset' must not be negative"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Set up relevant server dependencies
+			tskMgr := mocks.TaskManager{}
+
+			// Set up server
+			a := app.New()
+			a.TaskManager = &tskMgr
+			a.Authenticator = buildAuthenticator("tasks:read")
+			a.Authorizer = buildAuthorizer(true)
+
+			// Make request
+			req, err := http.NewRequest(http.MethodGet, "/tasks/search?"+tc.query, nil)
+			require.NoError(t, err)
+			res := httptest.NewRecorder()
+			a.ServeHTTP(res, req)
+
+			assert.Equal(t, http.StatusUnprocessableEntity, res.Result().StatusCode)
+			assert.JSONEq(t, fmt.Sprintf(`{"message": "%s"}`, tc.expectedMessage), res.Body.String())
+			tskMgr.AssertNotCalled(t, "Search", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestHandleTaskSearchError(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Search", mock.Anything, "tenant-1", "socks", mock.Anything).Return(nil, errors.New("failure to search tasks"))
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/tasks/search?q=socks", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusInternalServerError, res.Result().StatusCode)
+	assert.Empty(t, res.Body)
+}
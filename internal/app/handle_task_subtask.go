@@ -0,0 +1,142 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/jaredpetersen/go-rest-template/api"
+	"github.com/jaredpetersen/go-rest-template/internal/auth"
+	"github.com/jaredpetersen/go-rest-template/internal/policy"
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+)
+
+// errParentNotFound is returned when a task refers to a parent task that does not exist.
+var errParentNotFound = errors.New("field 'parentId' refers to a task that does not exist")
+
+// errParentCycle is returned when a task would become a subtask of itself or one of its subtasks.
+var errParentCycle = errors.New("field 'parentId' must not refer to the task or one of its subtasks")
+
+func (a *app) handleTaskSubtaskList() http.HandlerFunc {
+	// Set up dependencies specific to the handler here
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		params := req.URL.Query()
+
+		var recursive bool
+		switch params.Get("recursive") {
+		case "", "false":
+		case "true":
+			recursive = true
+		default:
+			err := errors.New("query parameter 'recursive' must be 'true' or 'false'")
+			respondError(w, AppError{External: err}, http.StatusUnprocessableEntity)
+			return
+		}
+
+		limit, offset, err := pageParams(params)
+		if err != nil {
+			respondError(w, AppError{External: err}, http.StatusUnprocessableEntity)
+			return
+		}
+
+		id := chi.URLParam(req, "id")
+		principal := auth.FromContext(req.Context())
+
+		t, err := a.TaskManager.Get(req.Context(), principal.TenantID, id)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		if t == nil {
+			respond(w, nil, http.StatusNotFound)
+			return
+		}
+
+		if !a.authorize(principal, policy.ActionTaskRead, t) {
+			respondError(w, AppError{External: errors.New("forbidden")}, http.StatusForbidden)
+			return
+		}
+
+		filter := task.SubtaskFilter{
+			Recursive:  recursive,
+			Visibility: a.authorizeList(principal, policy.ActionTaskRead),
+			Limit:      limit,
+			Offset:     offset,
+		}
+		ts, total, err := a.TaskManager.ListSubtasks(req.Context(), principal.TenantID, id, filter)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		res := api.TaskList{Tasks: make([]api.Task, len(ts)), Limit: limit, Offset: offset, Total: total}
+		for i := range ts {
+			res.Tasks[i] = toAPITask(ts[i])
+		}
+
+		respond(w, res, http.StatusOK)
+	}
+}
+
+// checkParent ensures that the parent task exists and that the principal may add subtasks to it, responding with an
+// error if not
+func (a *app) checkParent(w http.ResponseWriter, req *http.Request, principal *auth.Principal, parentID string) bool {
+	statusErr := a.parentError(req.Context(), principal, parentID)
+	if statusErr != nil {
+		respondError(w, statusErr.AppError, statusErr.StatusCode)
+		return false
+	}
+
+	return true
+}
+
+// parentError determines why the principal may not add subtasks to the parent task, if they may not
+func (a *app) parentError(ctx context.Context, principal *auth.Principal, parentID string) *statusError {
+	parent, err := a.TaskManager.Get(ctx, principal.TenantID, parentID)
+	if err != nil {
+		return &statusError{AppError{Internal: err}, http.StatusInternalServerError}
+	}
+
+	if parent == nil {
+		return &statusError{AppError{External: errParentNotFound}, http.StatusUnprocessableEntity}
+	}
+
+	if !a.authorize(principal, policy.ActionTaskUpdate, parent) {
+		return &statusError{AppError{External: errors.New("forbidden")}, http.StatusForbidden}
+	}
+
+	return nil
+}
+
+// hierarchyError converts an error about where a task sits in the task hierarchy to an error for the client. Nil is
+// returned for any other error.
+func hierarchyError(err error) error {
+	switch {
+	case errors.Is(err, task.ErrParentUnavailable):
+		return errParentNotFound
+	case errors.Is(err, task.ErrHierarchyCycle):
+		return errParentCycle
+	case errors.Is(err, task.ErrDepthExceeded):
+		return fmt.Errorf("field 'parentId' must not nest subtasks more than %d levels deep", task.MaxDepth)
+	default:
+		return nil
+	}
+}
+
+// toAPIParentID converts the ID of the task's parent to its API representation, which is null for top-level tasks
+func toAPIParentID(parentID string) *string {
+	if parentID == "" {
+		return nil
+	}
+
+	return &parentID
+}
+
+// toAPISubtaskRollup converts the summary of a task's subtasks to its API representation
+func toAPISubtaskRollup(r task.Rollup) api.SubtaskRollup {
+	return api.SubtaskRollup{Total: r.Subtasks, Completed: r.Completed, PercentComplete: r.PercentComplete()}
+}
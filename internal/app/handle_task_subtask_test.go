@@ -0,0 +1,392 @@
+package app_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jaredpetersen/go-rest-template/api"
+	"github.com/jaredpetersen/go-rest-template/internal/app"
+	"github.com/jaredpetersen/go-rest-template/internal/app/mocks"
+	"github.com/jaredpetersen/go-rest-template/internal/policy"
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleTaskSubtaskList(t *testing.T) {
+	parent := task.New()
+	parent.TenantID = "tenant-1"
+	parent.OwnerID = "user-1"
+
+	child := task.New()
+	child.TenantID = "tenant-1"
+	child.OwnerID = "user-1"
+	child.ParentID = parent.ID
+	child.Description = "Sand fence"
+	child.Rollup = task.Rollup{Subtasks: 3, Completed: 1}
+
+	visibility := &task.Visibility{Subject: "user-1", Owned: true}
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", parent.ID).Return(parent, nil)
+	filter := task.SubtaskFilter{Recursive: true, Visibility: visibility, Limit: 1, Offset: 1}
+	tskMgr.On("ListSubtasks", mock.Anything, "tenant-1", parent.ID, filter).Return([]task.Task{*child}, 2, nil)
+
+	authorizer := mocks.Authorizer{}
+	authorizer.On("Authorize", mock.Anything, policy.ActionTaskRead, parent).Return(true)
+	authorizer.On("AuthorizeList", mock.Anything, policy.ActionTaskRead).Return(visibility)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = &authorizer
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/tasks/"+parent.ID+"/subtasks?recursive=true&limit=1&offset=1", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	expectedJSON := fmt.Sprintf(`{
+		"tasks": [{"id": "%s", "ownerId": "user-1", "projectId": null, "description": "Sand fence", "dateDue": null,
+			"parentId": "%s", "completed": false, "dateCompleted": null, "subtasks": {"total": 3, "completed": 1, "percentComplete": 33},
+			"blocked": false, "blockedBy": [], "recurrence": null, "reminders": [],
+			"shares": [], "tags": [], "commentCount": 0, "version": 1}],
+		"total": 2,
+		"limit": 1,
+		"offset": 1
+	}`, child.ID, parent.ID)
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	assert.JSONEq(t, expectedJSON, res.Body.String())
+
+	tskMgr.AssertExpectations(t)
+}
+
+func TestHandleTaskSubtaskListInvalidRecursive(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/tasks/someid/subtasks?recursive=yes", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Result().StatusCode)
+	assert.JSONEq(t, `{"message": "query parameter 'recursive' must be 'true' or 'false'"}`, res.Body.String())
+}
+
+func TestHandleTaskSubtaskListNotFound(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", "someid").Return(nil, nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/tasks/someid/subtasks", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusNotFound, res.Result().StatusCode)
+	tskMgr.AssertNotCalled(t, "ListSubtasks", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleTaskSubtaskListForbidden(t *testing.T) {
+	parent := task.New()
+	parent.TenantID = "tenant-1"
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", parent.ID).Return(parent, nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(false)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/tasks/"+parent.ID+"/subtasks", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusForbidden, res.Result().StatusCode)
+	tskMgr.AssertNotCalled(t, "ListSubtasks", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleTaskSaveSubtask(t *testing.T) {
+	parent := task.New()
+	parent.TenantID = "tenant-1"
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", parent.ID).Return(parent, nil)
+	tskMgr.On("Save", mock.Anything, mock.MatchedBy(func(t task.Task) bool {
+		return t.ParentID == parent.ID && t.Description == "Sand fence"
+	})).Return(nil)
+
+	authorizer := mocks.Authorizer{}
+	authorizer.On("Authorize", mock.Anything, policy.ActionTaskCreate, (*task.Task)(nil)).Return(true)
+	authorizer.On("Authorize", mock.Anything, policy.ActionTaskUpdate, parent).Return(true)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = &authorizer
+
+	// Make request
+	body := fmt.Sprintf(`{"description": "Sand fence", "parentId": "%s"}`, parent.ID)
+	req, err := http.NewRequest(http.MethodPost, "/tasks", strings.NewReader(body))
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusCreated, res.Result().StatusCode)
+
+	tskMgr.AssertExpectations(t)
+	authorizer.AssertExpectations(t)
+}
+
+func TestHandleTaskSaveSubtaskParentNotFound(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", "someid").Return(nil, nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodPost, "/tasks", strings.NewReader(`{"description": "Sand fence", "parentId": "someid"}`))
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Result().StatusCode)
+	assert.JSONEq(t, `{"message": "field 'parentId' refers to a task that does not exist"}`, res.Body.String())
+	tskMgr.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
+
+func TestHandleTaskSaveSubtaskParentForbidden(t *testing.T) {
+	parent := task.New()
+	parent.TenantID = "tenant-1"
+	parent.OwnerID = "user-2"
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", parent.ID).Return(parent, nil)
+
+	// The principal can create tasks but cannot change the parent
+	authorizer := mocks.Authorizer{}
+	authorizer.On("Authorize", mock.Anything, policy.ActionTaskCreate, (*task.Task)(nil)).Return(true)
+	authorizer.On("Authorize", mock.Anything, policy.ActionTaskUpdate, parent).Return(false)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = &authorizer
+
+	// Make request
+	body := fmt.Sprintf(`{"description": "Sand fence", "parentId": "%s"}`, parent.ID)
+	req, err := http.NewRequest(http.MethodPost, "/tasks", strings.NewReader(body))
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusForbidden, res.Result().StatusCode)
+	tskMgr.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
+
+func TestHandleTaskSaveSubtaskTooDeep(t *testing.T) {
+	parent := task.New()
+	parent.TenantID = "tenant-1"
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", parent.ID).Return(parent, nil)
+	tskMgr.On("Save", mock.Anything, mock.Anything).Return(task.ErrDepthExceeded)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	body := fmt.Sprintf(`{"description": "Sand fence", "parentId": "%s"}`, parent.ID)
+	req, err := http.NewRequest(http.MethodPost, "/tasks", strings.NewReader(body))
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Result().StatusCode)
+	assert.JSONEq(t, `{"message": "field 'parentId' must not nest subtasks more than 5 levels deep"}`, res.Body.String())
+}
+
+func TestHandleTaskUpdateComplete(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+	tsk.Description = "Sand fence"
+
+	updatedTsk := *tsk
+	updatedTsk.Version = 2
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+	tskMgr.On("Update", mock.Anything, mock.MatchedBy(func(t task.Task) bool {
+		return t.ID == tsk.ID && t.Completed() && t.DateCompleted.Equal(t.DateUpdated)
+	})).Return(&updatedTsk, nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req := buildUpdateTaskRequest(t, tsk.ID, "\"1\"", `{"description": "Sand fence", "completed": true}`)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+
+	tskMgr.AssertExpectations(t)
+}
+
+func TestHandleTaskUpdateMoveUnderSubtask(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+	tsk.Description = "Paint fence"
+
+	subtask := task.New()
+	subtask.TenantID = "tenant-1"
+	subtask.OwnerID = "user-1"
+	subtask.ParentID = tsk.ID
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+	tskMgr.On("Get", mock.Anything, "tenant-1", subtask.ID).Return(subtask, nil)
+	tskMgr.On("Update", mock.Anything, mock.MatchedBy(func(t task.Task) bool {
+		return t.ID == tsk.ID && t.ParentID == subtask.ID
+	})).Return(nil, task.ErrHierarchyCycle)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	body := fmt.Sprintf(`{"description": "Paint fence", "parentId": "%s"}`, subtask.ID)
+	req := buildUpdateTaskRequest(t, tsk.ID, "\"1\"", body)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Result().StatusCode)
+	assert.JSONEq(t, `{"message": "field 'parentId' must not refer to the task or one of its subtasks"}`, res.Body.String())
+}
+
+func TestHandleTaskUpdateMoveToTopLevel(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+	tsk.Description = "Sand fence"
+	tsk.ParentID = "someparent"
+
+	updatedTsk := *tsk
+	updatedTsk.ParentID = ""
+	updatedTsk.Version = 2
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+	tskMgr.On("Update", mock.Anything, mock.MatchedBy(func(t task.Task) bool {
+		return t.ID == tsk.ID && t.ParentID == ""
+	})).Return(&updatedTsk, nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req := buildUpdateTaskRequest(t, tsk.ID, "\"1\"", `{"description": "Sand fence", "parentId": ""}`)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+
+	tskMgr.AssertExpectations(t)
+}
+
+func TestHandleTaskBatchCreateSubtasks(t *testing.T) {
+	parent := task.New()
+	parent.TenantID = "tenant-1"
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("GetBatch", mock.Anything, "tenant-1", []string{parent.ID, "missing"}).Return([]task.Task{*parent}, nil)
+	tskMgr.On("SaveBatch", mock.Anything, mock.MatchedBy(func(tsks []task.Task) bool {
+		return len(tsks) == 1 && tsks[0].ParentID == parent.ID
+	})).Return(nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	body := fmt.Sprintf(`{"atomic": false, "tasks": [
+		{"description": "Sand fence", "parentId": "%s"},
+		{"description": "Buy paint", "parentId": "missing"}
+	]}`, parent.ID)
+	req, err := http.NewRequest(http.MethodPost, "/tasks:batchCreate", strings.NewReader(body))
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+
+	resBody := api.BatchCreateResults{}
+	err = json.NewDecoder(res.Body).Decode(&resBody)
+	require.NoError(t, err, "Failed to convert response body")
+	require.Len(t, resBody.Results, 2, "Incorrect number of results")
+
+	assert.Equal(t, http.StatusCreated, resBody.Results[0].Status)
+	assert.Equal(t, http.StatusUnprocessableEntity, resBody.Results[1].Status)
+	require.NotNil(t, resBody.Results[1].Message)
+	assert.Equal(t, "field 'parentId' refers to a task that does not exist", *resBody.Results[1].Message)
+
+	tskMgr.AssertExpectations(t)
+}
@@ -10,10 +10,12 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	_ "github.com/jackc/pgx/v4/stdlib"
 	"github.com/jaredpetersen/go-rest-template/internal/app/mocks"
+	"github.com/jaredpetersen/go-rest-template/internal/policy"
 	"github.com/jaredpetersen/go-rest-template/internal/task"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -22,15 +24,19 @@ import (
 
 func TestHandleTaskGet(t *testing.T) {
 	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
 	tsk.Description = "Buy butter"
 
 	// Set up relevant server dependencies
 	tskMgr := mocks.TaskManager{}
-	tskMgr.On("Get", mock.Anything, tsk.ID).Return(tsk, nil)
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
 
 	// Set up server
 	a := app.New()
 	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
 
 	// Make request
 	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/tasks/%s", tsk.ID), nil)
@@ -38,20 +44,26 @@ func TestHandleTaskGet(t *testing.T) {
 	res := httptest.NewRecorder()
 	a.ServeHTTP(res, req)
 
-	expectedJSON := fmt.Sprintf("{\"id\": \"%s\", \"description\": \"%s\", \"dateDue\": null}", tsk.ID, tsk.Description)
+	expectedJSON := fmt.Sprintf("{\"id\": \"%s\", \"ownerId\": \"user-1\", \"projectId\": null, \"description\": \"%s\", \"dateDue\": null, "+
+		"\"parentId\": null, \"completed\": false, \"dateCompleted\": null, \"subtasks\": {\"total\": 0, \"completed\": 0, \"percentComplete\": 0}, "+
+		"\"blocked\": false, \"blockedBy\": [], \"recurrence\": null, \"reminders\": [], \"shares\": [], \"tags\": [], \"commentCount\": 0, \"version\": 1}",
+		tsk.ID,
+		tsk.Description)
 
 	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	assert.Equal(t, "\"1\"", res.Result().Header.Get("ETag"))
 	assert.JSONEq(t, expectedJSON, res.Body.String())
 }
 
 func TestHandleTaskGetError(t *testing.T) {
 	// Set up relevant server dependencies
 	tskMgr := mocks.TaskManager{}
-	tskMgr.On("Get", mock.Anything, mock.AnythingOfType("string")).Return(nil, errors.New("failure to get task"))
+	tskMgr.On("Get", mock.Anything, "tenant-1", mock.AnythingOfType("string")).Return(nil, errors.New("failure to get task"))
 
 	// Set up server
 	a := app.New()
 	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
 
 	// Make request
 	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/tasks/%s", uuid.New()), nil)
@@ -66,11 +78,12 @@ func TestHandleTaskGetError(t *testing.T) {
 func TestHandleTaskGetNotFound(t *testing.T) {
 	// Set up relevant server dependencies
 	tskMgr := mocks.TaskManager{}
-	tskMgr.On("Get", mock.Anything, mock.AnythingOfType("string")).Return(nil, nil)
+	tskMgr.On("Get", mock.Anything, "tenant-1", mock.AnythingOfType("string")).Return(nil, nil)
 
 	// Set up server
 	a := app.New()
 	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
 
 	// Make request
 	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/tasks/%s", uuid.New().String()), nil)
@@ -85,11 +98,15 @@ func TestHandleTaskGetNotFound(t *testing.T) {
 func TestHandleTaskSave(t *testing.T) {
 	// Set up relevant server dependencies
 	tskMgr := mocks.TaskManager{}
-	tskMgr.On("Save", mock.Anything, mock.Anything).Return(nil)
+	tskMgr.On("Save", mock.Anything, mock.MatchedBy(func(tsk task.Task) bool {
+		return tsk.TenantID == "tenant-1" && tsk.OwnerID == "user-1" && tsk.Description == "Buy milk"
+	})).Return(nil)
 
 	// Set up server
 	a := app.New()
 	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
 
 	// Set up request body
 	tsk := struct {
@@ -127,6 +144,7 @@ func TestHandleTaskSaveBadBody(t *testing.T) {
 	// Set up server
 	a := app.New()
 	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
 
 	// Set up request body without valid JSON
 	reqBody := strings.NewReader("<task />")
@@ -149,6 +167,7 @@ func TestHandleTaskSaveMissingBodyFields(t *testing.T) {
 	// Set up server
 	a := app.New()
 	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
 
 	// Set up invalid request body
 	tsk := struct {
@@ -177,6 +196,8 @@ func TestHandleTaskSaveError(t *testing.T) {
 	// Set up server
 	a := app.New()
 	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
 
 	// Set up request body
 	tsk := struct {
@@ -196,3 +217,1064 @@ func TestHandleTaskSaveError(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, res.Result().StatusCode)
 	assert.Empty(t, res.Body)
 }
+
+func TestHandleTaskGetForbidden(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-2"
+	tsk.Description = "Buy butter"
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	authorizer := mocks.Authorizer{}
+	authorizer.On("Authorize", mock.Anything, policy.ActionTaskRead, tsk).Return(false)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = &authorizer
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/tasks/%s", tsk.ID), nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusForbidden, res.Result().StatusCode)
+	assert.JSONEq(t, "{\"message\": \"forbidden\"}", res.Body.String())
+
+	authorizer.AssertExpectations(t)
+}
+
+func TestHandleTaskGetShares(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+	tsk.Description = "Buy butter"
+	tsk.Shares = map[string]string{"user-3": "editor", "user-2": "viewer"}
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/tasks/%s", tsk.ID), nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	expectedJSON := fmt.Sprintf("{\"id\": \"%s\", \"ownerId\": \"user-1\", \"projectId\": null, \"description\": \"%s\", \"dateDue\": null, "+
+		"\"parentId\": null, \"completed\": false, \"dateCompleted\": null, \"subtasks\": {\"total\": 0, \"completed\": 0, \"percentComplete\": 0}, "+
+		"\"blocked\": false, \"blockedBy\": [], \"recurrence\": null, \"reminders\": [], \"shares\": [{\"principalId\": \"user-2\", \"role\": \"viewer\"}, {\"principalId\": \"user-3\", \"role\": \"editor\"}], \"tags\": [], \"commentCount\": 0, \"version\": 1}",
+		tsk.ID,
+		tsk.Description)
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	assert.JSONEq(t, expectedJSON, res.Body.String())
+}
+
+func TestHandleTaskSaveForbidden(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+
+	authorizer := mocks.Authorizer{}
+	authorizer.On("Authorize", mock.Anything, policy.ActionTaskCreate, (*task.Task)(nil)).Return(false)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = &authorizer
+
+	// Make request
+	req, err := http.NewRequest(http.MethodPost, "/tasks", strings.NewReader("{\"description\": \"Buy milk\"}"))
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusForbidden, res.Result().StatusCode)
+	assert.JSONEq(t, "{\"message\": \"forbidden\"}", res.Body.String())
+
+	authorizer.AssertExpectations(t)
+	tskMgr.AssertExpectations(t)
+}
+
+func TestHandleTaskSaveNotConfigured(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+
+	// Set up server without an authorizer
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+
+	// Make request
+	req, err := http.NewRequest(http.MethodPost, "/tasks", strings.NewReader("{\"description\": \"Buy milk\"}"))
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusForbidden, res.Result().StatusCode)
+
+	tskMgr.AssertExpectations(t)
+}
+
+func TestHandleTaskSaveShares(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Save", mock.Anything, mock.MatchedBy(func(tsk task.Task) bool {
+		return assert.ObjectsAreEqual(map[string]string{"user-2": "viewer"}, tsk.Shares)
+	})).Return(nil)
+
+	authorizer := mocks.Authorizer{}
+	authorizer.On("Authorize", mock.Anything, policy.ActionTaskCreate, (*task.Task)(nil)).Return(true)
+	authorizer.On("Authorize", mock.Anything, policy.ActionTaskShare, mock.AnythingOfType("*task.Task")).Return(true)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = &authorizer
+
+	// Make request
+	reqBody := strings.NewReader("{\"description\": \"Buy milk\", \"shares\": [{\"principalId\": \"user-2\", \"role\": \"viewer\"}]}")
+	req, err := http.NewRequest(http.MethodPost, "/tasks", reqBody)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusCreated, res.Result().StatusCode)
+
+	authorizer.AssertExpectations(t)
+	tskMgr.AssertExpectations(t)
+}
+
+func TestHandleTaskSaveSharesForbidden(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+
+	authorizer := mocks.Authorizer{}
+	authorizer.On("Authorize", mock.Anything, policy.ActionTaskCreate, (*task.Task)(nil)).Return(true)
+	authorizer.On("Authorize", mock.Anything, policy.ActionTaskShare, mock.AnythingOfType("*task.Task")).Return(false)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = &authorizer
+
+	// Make request
+	reqBody := strings.NewReader("{\"description\": \"Buy milk\", \"shares\": [{\"principalId\": \"user-2\", \"role\": \"viewer\"}]}")
+	req, err := http.NewRequest(http.MethodPost, "/tasks", reqBody)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusForbidden, res.Result().StatusCode)
+
+	authorizer.AssertExpectations(t)
+	tskMgr.AssertExpectations(t)
+}
+
+func TestHandleTaskSaveInvalidShares(t *testing.T) {
+	var tests = []struct {
+		shares  string
+		message string
+	}{
+		{
+			shares:  "[{\"principalId\": \"user-2\", \"role\": \"superuser\"}]",
+			message: "field 'shares.role' has unknown role 'superuser'",
+		},
+		{
+			shares:  "[{\"role\": \"viewer\"}]",
+			message: "field 'shares.principalId' is required",
+		},
+		{
+			shares:  "[{\"principalId\": \"user-2\", \"role\": \"viewer\"}, {\"principalId\": \"user-2\", \"role\": \"admin\"}]",
+			message: "field 'shares' has duplicate principal 'user-2'",
+		},
+	}
+
+	for _, tt := range tests {
+		// Set up relevant server dependencies
+		tskMgr := mocks.TaskManager{}
+
+		// Set up server
+		a := app.New()
+		a.TaskManager = &tskMgr
+		a.Authenticator = buildAuthenticator("tasks:write")
+		a.Authorizer = buildAuthorizer(true)
+
+		// Make request
+		reqBody := strings.NewReader(fmt.Sprintf("{\"description\": \"Buy milk\", \"shares\": %s}", tt.shares))
+		req, err := http.NewRequest(http.MethodPost, "/tasks", reqBody)
+		require.NoError(t, err)
+		res := httptest.NewRecorder()
+		a.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, res.Result().StatusCode)
+		assert.JSONEq(t, fmt.Sprintf("{\"message\": \"%s\"}", tt.message), res.Body.String())
+
+		tskMgr.AssertExpectations(t)
+	}
+}
+
+func TestHandleTaskPermissions(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-2"
+	tsk.Description = "Buy butter"
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	authorizer := mocks.Authorizer{}
+	authorizer.On("AllowedActions", mock.Anything, tsk).Return([]policy.Action{policy.ActionTaskRead, policy.ActionTaskUpdate})
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = &authorizer
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/tasks/%s/permissions", tsk.ID), nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	assert.JSONEq(t, "{\"actions\": [\"task:read\", \"task:update\"]}", res.Body.String())
+}
+
+func TestHandleTaskPermissionsNotFound(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", mock.AnythingOfType("string")).Return(nil, nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/tasks/%s/permissions", uuid.New()), nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusNotFound, res.Result().StatusCode)
+	assert.Empty(t, res.Body)
+}
+
+func TestHandleTaskGetNotModified(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.Version = 3
+
+	var tests = []struct {
+		ifNoneMatch string
+		statusCode  int
+	}{
+		{ifNoneMatch: "\"3\"", statusCode: http.StatusNotModified},
+		{ifNoneMatch: "W/\"3\"", statusCode: http.StatusNotModified},
+		{ifNoneMatch: "\"1\", \"3\"", statusCode: http.StatusNotModified},
+		{ifNoneMatch: "*", statusCode: http.StatusNotModified},
+		{ifNoneMatch: "\"2\"", statusCode: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		// Set up relevant server dependencies
+		tskMgr := mocks.TaskManager{}
+		tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+		// Set up server
+		a := app.New()
+		a.TaskManager = &tskMgr
+		a.Authenticator = buildAuthenticator("tasks:read")
+		a.Authorizer = buildAuthorizer(true)
+
+		// Make request
+		req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/tasks/%s", tsk.ID), nil)
+		require.NoError(t, err)
+		req.Header.Set("If-None-Match", tt.ifNoneMatch)
+		res := httptest.NewRecorder()
+		a.ServeHTTP(res, req)
+
+		assert.Equal(t, tt.statusCode, res.Result().StatusCode, "Incorrect status for %s", tt.ifNoneMatch)
+		assert.Equal(t, "\"3\"", res.Result().Header.Get("ETag"))
+		if tt.statusCode == http.StatusNotModified {
+			assert.Empty(t, res.Body, "Returned body for %s", tt.ifNoneMatch)
+		}
+	}
+}
+
+// buildUpdateTaskRequest creates a request to update the task
+func buildUpdateTaskRequest(t *testing.T, id string, ifMatch string, body string) *http.Request {
+	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf("/tasks/%s", id), strings.NewReader(body))
+	require.NoError(t, err)
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+	return req
+}
+
+func TestHandleTaskUpdate(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+	tsk.Description = "Buy milk"
+	tsk.Shares = map[string]string{"user-2": "viewer"}
+
+	updatedTsk := *tsk
+	updatedTsk.Description = "Buy oat milk"
+	updatedTsk.Version = 2
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+	tskMgr.On("Update", mock.Anything, mock.MatchedBy(func(t task.Task) bool {
+		return t.ID == tsk.ID && t.Description == "Buy oat milk" && t.Version == 1 && t.Shares["user-2"] == "viewer"
+	})).Return(&updatedTsk, nil)
+
+	authorizer := mocks.Authorizer{}
+	authorizer.On("Authorize", mock.Anything, policy.ActionTaskUpdate, tsk).Return(true)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = &authorizer
+
+	// Make request
+	req := buildUpdateTaskRequest(t, tsk.ID, "\"1\"", "{\"description\": \"Buy oat milk\"}")
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	expectedJSON := fmt.Sprintf("{\"id\": \"%s\", \"ownerId\": \"user-1\", \"projectId\": null, \"description\": \"Buy oat milk\", \"dateDue\": null, "+
+		"\"parentId\": null, \"completed\": false, \"dateCompleted\": null, \"subtasks\": {\"total\": 0, \"completed\": 0, \"percentComplete\": 0}, "+
+		"\"blocked\": false, \"blockedBy\": [], \"recurrence\": null, \"reminders\": [], \"shares\": [{\"principalId\": \"user-2\", \"role\": \"viewer\"}], \"tags\": [], \"commentCount\": 0, \"version\": 2}",
+		tsk.ID)
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	assert.Equal(t, "\"2\"", res.Result().Header.Get("ETag"))
+	assert.JSONEq(t, expectedJSON, res.Body.String())
+
+	tskMgr.AssertExpectations(t)
+	authorizer.AssertExpectations(t)
+}
+
+func TestHandleTaskUpdateMissingIfMatch(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req := buildUpdateTaskRequest(t, uuid.NewString(), "", "{\"description\": \"Buy oat milk\"}")
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusPreconditionRequired, res.Result().StatusCode)
+	assert.JSONEq(t, "{\"message\": \"header 'If-Match' is required\"}", res.Body.String())
+
+	tskMgr.AssertExpectations(t)
+}
+
+func TestHandleTaskUpdateStaleIfMatch(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.Version = 2
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req := buildUpdateTaskRequest(t, tsk.ID, "\"1\"", "{\"description\": \"Buy oat milk\"}")
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusPreconditionFailed, res.Result().StatusCode)
+	assert.JSONEq(t, "{\"message\": \"task has been modified\"}", res.Body.String())
+
+	tskMgr.AssertExpectations(t)
+}
+
+func TestHandleTaskUpdateVersionConflict(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+	tskMgr.On("Update", mock.Anything, mock.Anything).Return(nil, task.ErrVersionConflict)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req := buildUpdateTaskRequest(t, tsk.ID, "\"1\"", "{\"description\": \"Buy oat milk\"}")
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusPreconditionFailed, res.Result().StatusCode)
+
+	tskMgr.AssertExpectations(t)
+}
+
+func TestHandleTaskUpdateError(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+	tskMgr.On("Update", mock.Anything, mock.Anything).Return(nil, errors.New("failure to update task"))
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req := buildUpdateTaskRequest(t, tsk.ID, "\"1\"", "{\"description\": \"Buy oat milk\"}")
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusInternalServerError, res.Result().StatusCode)
+	assert.Empty(t, res.Body)
+}
+
+func TestHandleTaskUpdateNotFound(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", mock.AnythingOfType("string")).Return(nil, nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req := buildUpdateTaskRequest(t, uuid.NewString(), "\"1\"", "{\"description\": \"Buy oat milk\"}")
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusNotFound, res.Result().StatusCode)
+}
+
+func TestHandleTaskUpdateMissingBodyFields(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req := buildUpdateTaskRequest(t, uuid.NewString(), "\"1\"", "{\"dateDue\": null}")
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Result().StatusCode)
+	assert.JSONEq(t, "{\"message\": \"field 'description' is required\"}", res.Body.String())
+
+	tskMgr.AssertExpectations(t)
+}
+
+func TestHandleTaskUpdateForbidden(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	authorizer := mocks.Authorizer{}
+	authorizer.On("Authorize", mock.Anything, policy.ActionTaskUpdate, tsk).Return(false)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = &authorizer
+
+	// Make request
+	req := buildUpdateTaskRequest(t, tsk.ID, "\"1\"", "{\"description\": \"Buy oat milk\"}")
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusForbidden, res.Result().StatusCode)
+
+	tskMgr.AssertExpectations(t)
+}
+
+func TestHandleTaskUpdateSharesForbidden(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	authorizer := mocks.Authorizer{}
+	authorizer.On("Authorize", mock.Anything, policy.ActionTaskUpdate, tsk).Return(true)
+	authorizer.On("Authorize", mock.Anything, policy.ActionTaskShare, tsk).Return(false)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = &authorizer
+
+	// Make request
+	body := "{\"description\": \"Buy oat milk\", \"shares\": [{\"principalId\": \"user-2\", \"role\": \"admin\"}]}"
+	req := buildUpdateTaskRequest(t, tsk.ID, "\"1\"", body)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusForbidden, res.Result().StatusCode)
+
+	tskMgr.AssertExpectations(t)
+	authorizer.AssertExpectations(t)
+}
+
+func TestHandleTaskList(t *testing.T) {
+	readable := task.New()
+	readable.TenantID = "tenant-1"
+	readable.OwnerID = "user-1"
+	readable.Description = "Buy milk"
+	readable.Tags = []string{"errand", "urgent"}
+
+	visibility := &task.Visibility{Subject: "user-1", Owned: true, SharedAs: []string{"viewer"}}
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	filter := task.Filter{Tags: []string{"errand", "urgent"}, AnyTag: true, Visibility: visibility, Limit: 1, Offset: 2}
+	tskMgr.On("List", mock.Anything, "tenant-1", filter).Return([]task.Task{*readable}, 3, nil)
+
+	authorizer := mocks.Authorizer{}
+	authorizer.On("AuthorizeList", mock.Anything, policy.ActionTaskRead).Return(visibility)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = &authorizer
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/tasks?tag=urgent&tag=errand&tagMatch=any&limit=1&offset=2", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	expectedJSON := fmt.Sprintf(`{
+		"tasks": [{"id": "%s", "ownerId": "user-1", "projectId": null, "description": "Buy milk", "dateDue": null,
+			"parentId": null, "completed": false, "dateCompleted": null, "subtasks": {"total": 0, "completed": 0, "percentComplete": 0},
+			"blocked": false, "blockedBy": [], "recurrence": null, "reminders": [],
+			"shares": [], "tags": ["errand", "urgent"], "commentCount": 0, "version": 1}],
+		"total": 3,
+		"limit": 1,
+		"offset": 2
+	}`, readable.ID)
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	assert.JSONEq(t, expectedJSON, res.Body.String())
+
+	tskMgr.AssertExpectations(t)
+}
+
+func TestHandleTaskListInvalidParams(t *testing.T) {
+	testCases := []struct {
+		name    string
+		query   string
+		message string
+	}{
+		{name: "EmptyTag", query: "tag=", message: "query parameter 'tag' must not be empty"},
+		{name: "InvalidTagMatch", query: "tag=errand&tagMatch=some", message: "query parameter 'tagMatch' must be 'all' or 'any'"},
+		{name: "InvalidLimit", query: "limit=0", message: "query parameter 'limit' must be between 1 and 100"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Set up relevant server dependencies
+			tskMgr := mocks.TaskManager{}
+
+			// Set up server
+			a := app.New()
+			a.TaskManager = &tskMgr
+			a.Authenticator = buildAuthenticator("tasks:read")
+			a.Authorizer = buildAuthorizer(true)
+
+			// Make request
+			req, err := http.NewRequest(http.MethodGet, "/tasks?"+tc.query, nil)
+			require.NoError(t, err)
+			res := httptest.NewRecorder()
+			a.ServeHTTP(res, req)
+
+			assert.Equal(t, http.StatusUnprocessableEntity, res.Result().StatusCode)
+			assert.JSONEq(t, `{"message": "`+tc.message+`"}`, res.Body.String())
+			tskMgr.AssertNotCalled(t, "List", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestHandleTaskSaveTags(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Save", mock.Anything, mock.MatchedBy(func(tsk task.Task) bool {
+		return assert.ObjectsAreEqual([]string{"errand", "urgent"}, tsk.Tags)
+	})).Return(nil)
+
+	tagMgr := mocks.TagManager{}
+	tagMgr.On("GetTagsByName", mock.Anything, "tenant-1", []string{"errand", "urgent"}).
+		Return([]task.Tag{{Name: "errand"}, {Name: "urgent"}}, nil)
+
+	// Set up server
+	a := app.New()
+	a.TagManager = &tagMgr
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request, with duplicate tags that are collapsed
+	body := `{"description": "Buy milk", "tags": ["urgent", "errand", "urgent"]}`
+	req, err := http.NewRequest(http.MethodPost, "/tasks", strings.NewReader(body))
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusCreated, res.Result().StatusCode)
+
+	tskMgr.AssertExpectations(t)
+	tagMgr.AssertExpectations(t)
+}
+
+func TestHandleTaskSaveUnknownTag(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+
+	tagMgr := mocks.TagManager{}
+	tagMgr.On("GetTagsByName", mock.Anything, "tenant-1", []string{"errand", "urgent"}).
+		Return([]task.Tag{{Name: "errand"}}, nil)
+
+	// Set up server
+	a := app.New()
+	a.TagManager = &tagMgr
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	body := `{"description": "Buy milk", "tags": ["errand", "urgent"]}`
+	req, err := http.NewRequest(http.MethodPost, "/tasks", strings.NewReader(body))
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Result().StatusCode)
+	assert.JSONEq(t, `{"message": "field 'tags' has unknown tag 'urgent'"}`, res.Body.String())
+	tskMgr.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
+
+func TestHandleTaskUpdateTags(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+	tsk.Description = "Buy milk"
+	tsk.Tags = []string{"errand"}
+
+	updatedTsk := *tsk
+	updatedTsk.Tags = nil
+	updatedTsk.Version = 2
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+	tskMgr.On("Update", mock.Anything, mock.MatchedBy(func(t task.Task) bool {
+		return t.ID == tsk.ID && len(t.Tags) == 0
+	})).Return(&updatedTsk, nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request, clearing the tags
+	req := buildUpdateTaskRequest(t, tsk.ID, "\"1\"", `{"description": "Buy milk", "tags": []}`)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	assert.Contains(t, res.Body.String(), `"tags":[]`)
+
+	tskMgr.AssertExpectations(t)
+}
+
+func TestHandleTaskSaveReminders(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Save", mock.Anything, mock.MatchedBy(func(tsk task.Task) bool {
+		return assert.ObjectsAreEqual([]time.Duration{24 * time.Hour, time.Hour, 0}, tsk.ReminderOffsets)
+	})).Return(nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request, with reminders out of order that are sorted earliest first
+	body := `{"description": "Buy milk", "dateDue": "2026-03-02T14:00:00Z",
+		"reminders": [{"minutesBefore": 60}, {"minutesBefore": 0}, {"minutesBefore": 1440}]}`
+	req, err := http.NewRequest(http.MethodPost, "/tasks", strings.NewReader(body))
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusCreated, res.Result().StatusCode)
+	tskMgr.AssertExpectations(t)
+}
+
+func TestHandleTaskSaveRemindersInvalid(t *testing.T) {
+	testCases := []struct {
+		name    string
+		body    string
+		message string
+	}{
+		{
+			name:    "Negative",
+			body:    `{"description": "Buy milk", "reminders": [{"minutesBefore": -5}]}`,
+			message: "field 'reminders.minutesBefore' must be between 0 and 40320",
+		},
+		{
+			name:    "TooEarly",
+			body:    `{"description": "Buy milk", "reminders": [{"minutesBefore": 40321}]}`,
+			message: "field 'reminders.minutesBefore' must be between 0 and 40320",
+		},
+		{
+			name:    "Duplicate",
+			body:    `{"description": "Buy milk", "reminders": [{"minutesBefore": 60}, {"minutesBefore": 60}]}`,
+			message: "field 'reminders' has duplicate reminder '60'",
+		},
+		{
+			name: "TooMany",
+			body: `{"description": "Buy milk", "reminders": [{"minutesBefore": 0}, {"minutesBefore": 1},
+				{"minutesBefore": 2}, {"minutesBefore": 3}, {"minutesBefore": 4}, {"minutesBefore": 5}]}`,
+			message: "field 'reminders' must not have more than 5 reminders",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Set up relevant server dependencies
+			tskMgr := mocks.TaskManager{}
+
+			// Set up server
+			a := app.New()
+			a.TaskManager = &tskMgr
+			a.Authenticator = buildAuthenticator("tasks:write")
+			a.Authorizer = buildAuthorizer(true)
+
+			// Make request
+			req, err := http.NewRequest(http.MethodPost, "/tasks", strings.NewReader(tc.body))
+			require.NoError(t, err)
+			res := httptest.NewRecorder()
+			a.ServeHTTP(res, req)
+
+			assert.Equal(t, http.StatusUnprocessableEntity, res.Result().StatusCode)
+			assert.JSONEq(t, fmt.Sprintf(`{"message": %q}`, tc.message), res.Body.String())
+			tskMgr.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestHandleTaskUpdateReminders(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+	tsk.Description = "Buy milk"
+	tsk.ReminderOffsets = []time.Duration{time.Hour}
+
+	testCases := []struct {
+		name            string
+		body            string
+		reminderOffsets []time.Duration
+	}{
+		{
+			name:            "Unchanged",
+			body:            `{"description": "Buy milk"}`,
+			reminderOffsets: []time.Duration{time.Hour},
+		},
+		{
+			name:            "Changed",
+			body:            `{"description": "Buy milk", "reminders": [{"minutesBefore": 30}]}`,
+			reminderOffsets: []time.Duration{30 * time.Minute},
+		},
+		{
+			name:            "Cleared",
+			body:            `{"description": "Buy milk", "reminders": []}`,
+			reminderOffsets: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Set up relevant server dependencies
+			original := *tsk
+			tskMgr := mocks.TaskManager{}
+			tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(&original, nil)
+			tskMgr.On("Update", mock.Anything, mock.MatchedBy(func(t task.Task) bool {
+				return assert.ObjectsAreEqual(tc.reminderOffsets, t.ReminderOffsets)
+			})).Return(&original, nil)
+
+			// Set up server
+			a := app.New()
+			a.TaskManager = &tskMgr
+			a.Authenticator = buildAuthenticator("tasks:write")
+			a.Authorizer = buildAuthorizer(true)
+
+			// Make request
+			req := buildUpdateTaskRequest(t, tsk.ID, "\"1\"", tc.body)
+			res := httptest.NewRecorder()
+			a.ServeHTTP(res, req)
+
+			assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+			tskMgr.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandleTaskGetReminders(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+	tsk.Description = "Buy milk"
+	tsk.ReminderOffsets = []time.Duration{24 * time.Hour, 0}
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/tasks/"+tsk.ID, nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	assert.Contains(t, res.Body.String(), `"reminders":[{"minutesBefore":1440},{"minutesBefore":0}]`)
+}
+
+func buildDeleteTaskRequest(t *testing.T, id string, ifMatch string) *http.Request {
+	req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("/tasks/%s", id), nil)
+	require.NoError(t, err)
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+	return req
+}
+
+func TestHandleTaskDelete(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+	tskMgr.On("Delete", mock.Anything, *tsk).Return(nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req := buildDeleteTaskRequest(t, tsk.ID, "\"1\"")
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusNoContent, res.Result().StatusCode)
+	assert.Empty(t, res.Body)
+
+	tskMgr.AssertExpectations(t)
+}
+
+func TestHandleTaskDeleteMissingIfMatch(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req := buildDeleteTaskRequest(t, uuid.NewString(), "")
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusPreconditionRequired, res.Result().StatusCode)
+	assert.JSONEq(t, "{\"message\": \"header 'If-Match' is required\"}", res.Body.String())
+	tskMgr.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
+
+func TestHandleTaskDeleteStaleIfMatch(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.Version = 2
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req := buildDeleteTaskRequest(t, tsk.ID, "\"1\"")
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusPreconditionFailed, res.Result().StatusCode)
+	assert.JSONEq(t, "{\"message\": \"task has been modified\"}", res.Body.String())
+	tskMgr.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
+
+func TestHandleTaskDeleteVersionConflict(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+	tskMgr.On("Delete", mock.Anything, mock.Anything).Return(task.ErrVersionConflict)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req := buildDeleteTaskRequest(t, tsk.ID, "\"1\"")
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusPreconditionFailed, res.Result().StatusCode)
+
+	tskMgr.AssertExpectations(t)
+}
+
+func TestHandleTaskDeleteHasSubtasks(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+	tskMgr.On("Delete", mock.Anything, mock.Anything).Return(task.ErrHasSubtasks)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req := buildDeleteTaskRequest(t, tsk.ID, "\"1\"")
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusConflict, res.Result().StatusCode)
+	assert.JSONEq(t, "{\"message\": \"task has subtasks that must be deleted first\"}", res.Body.String())
+
+	tskMgr.AssertExpectations(t)
+}
+
+func TestHandleTaskDeleteNotFound(t *testing.T) {
+	id := uuid.NewString()
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", id).Return(nil, nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req := buildDeleteTaskRequest(t, id, "\"1\"")
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusNotFound, res.Result().StatusCode)
+	tskMgr.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
+
+func TestHandleTaskDeleteForbidden(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(false)
+
+	// Make request
+	req := buildDeleteTaskRequest(t, tsk.ID, "\"1\"")
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusForbidden, res.Result().StatusCode)
+	tskMgr.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
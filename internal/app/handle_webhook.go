@@ -0,0 +1,359 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/jaredpetersen/go-rest-template/api"
+	"github.com/jaredpetersen/go-rest-template/internal/auth"
+	"github.com/jaredpetersen/go-rest-template/internal/policy"
+	"github.com/jaredpetersen/go-rest-template/internal/webhook"
+)
+
+// maxWebhookURLLength is the maximum number of characters in a webhook subscription's URL.
+const maxWebhookURLLength = 2048
+
+func (a *app) handleWebhookList() http.HandlerFunc {
+	// Set up dependencies specific to the handler here
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		principal := auth.FromContext(req.Context())
+
+		if !a.authorize(principal, policy.ActionWebhookManage, nil) {
+			respondError(w, AppError{External: errors.New("forbidden")}, http.StatusForbidden)
+			return
+		}
+
+		ss, err := a.WebhookManager.ListSubscriptions(req.Context(), principal.TenantID)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		res := api.WebhookSubscriptionList{Subscriptions: make([]api.WebhookSubscription, 0, len(ss))}
+		for _, s := range ss {
+			res.Subscriptions = append(res.Subscriptions, toAPIWebhookSubscription(s))
+		}
+
+		respond(w, res, http.StatusOK)
+	}
+}
+
+func (a *app) handleWebhookGet() http.HandlerFunc {
+	// Set up dependencies specific to the handler here
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		s, ok := a.managedWebhook(w, req)
+		if !ok {
+			return
+		}
+
+		respond(w, toAPIWebhookSubscription(*s), http.StatusOK)
+	}
+}
+
+func (a *app) handleWebhookSave() http.HandlerFunc {
+	// Set up dependencies specific to the handler here
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		val := new(api.NewWebhookSubscription)
+		err := receive(req, val)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusBadRequest)
+			return
+		}
+
+		// Validate request body manually
+		endpoint, events, err := fromAPINewWebhookSubscription(*val)
+		if err != nil {
+			respondError(w, AppError{External: err}, http.StatusUnprocessableEntity)
+			return
+		}
+
+		principal := auth.FromContext(req.Context())
+
+		if !a.authorize(principal, policy.ActionWebhookManage, nil) {
+			respondError(w, AppError{External: errors.New("forbidden")}, http.StatusForbidden)
+			return
+		}
+
+		s, err := webhook.NewSubscription()
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+		s.TenantID = principal.TenantID
+		s.OwnerID = principal.Subject
+		s.URL = endpoint
+		s.Events = events
+
+		err = a.WebhookManager.SaveSubscription(req.Context(), *s)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		// The secret is only ever revealed here so that it cannot leak through later reads
+		res := toAPIWebhookSubscription(*s)
+		res.Secret = &s.Secret
+
+		respond(w, res, http.StatusCreated)
+	}
+}
+
+func (a *app) handleWebhookUpdate() http.HandlerFunc {
+	// Set up dependencies specific to the handler here
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		val := new(api.NewWebhookSubscription)
+		err := receive(req, val)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusBadRequest)
+			return
+		}
+
+		// Validate request body manually
+		endpoint, events, err := fromAPINewWebhookSubscription(*val)
+		if err != nil {
+			respondError(w, AppError{External: err}, http.StatusUnprocessableEntity)
+			return
+		}
+
+		s, ok := a.managedWebhook(w, req)
+		if !ok {
+			return
+		}
+
+		s.URL = endpoint
+		s.Events = events
+		s.DateUpdated = time.Now()
+
+		err = a.WebhookManager.UpdateSubscription(req.Context(), *s)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, toAPIWebhookSubscription(*s), http.StatusOK)
+	}
+}
+
+func (a *app) handleWebhookDelete() http.HandlerFunc {
+	// Set up dependencies specific to the handler here
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		s, ok := a.managedWebhook(w, req)
+		if !ok {
+			return
+		}
+
+		// Deleting the subscription also deletes its delivery log
+		err := a.WebhookManager.DeleteSubscription(req.Context(), s.TenantID, s.ID)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (a *app) handleWebhookDeliveryList() http.HandlerFunc {
+	// Set up dependencies specific to the handler here
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		params := req.URL.Query()
+
+		status := params.Get("status")
+		switch status {
+		case "", webhook.StatusPending, webhook.StatusSucceeded, webhook.StatusDead:
+		default:
+			err := errors.New("query parameter 'status' must be 'pending', 'succeeded', or 'dead'")
+			respondError(w, AppError{External: err}, http.StatusUnprocessableEntity)
+			return
+		}
+
+		limit, offset, err := pageParams(params)
+		if err != nil {
+			respondError(w, AppError{External: err}, http.StatusUnprocessableEntity)
+			return
+		}
+
+		s, ok := a.managedWebhook(w, req)
+		if !ok {
+			return
+		}
+
+		filter := webhook.DeliveryFilter{Status: status, Limit: limit, Offset: offset}
+		ds, err := a.WebhookManager.ListDeliveries(req.Context(), s.TenantID, s.ID, filter)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		res := api.WebhookDeliveryList{Deliveries: make([]api.WebhookDelivery, 0, len(ds)), Limit: limit, Offset: offset}
+		for _, d := range ds {
+			res.Deliveries = append(res.Deliveries, toAPIWebhookDelivery(d))
+		}
+
+		respond(w, res, http.StatusOK)
+	}
+}
+
+func (a *app) handleWebhookRedeliver() http.HandlerFunc {
+	// Set up dependencies specific to the handler here
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		s, ok := a.managedWebhook(w, req)
+		if !ok {
+			return
+		}
+
+		deliveryID := chi.URLParam(req, "deliveryId")
+
+		d, err := a.WebhookManager.GetDelivery(req.Context(), s.TenantID, deliveryID)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		if d == nil || d.SubscriptionID != s.ID {
+			respond(w, nil, http.StatusNotFound)
+			return
+		}
+
+		// Pending deliveries may be in the middle of an attempt, which would overwrite the reset
+		if d.Status == webhook.StatusPending {
+			respondError(w, AppError{External: errors.New("delivery is still pending")}, http.StatusConflict)
+			return
+		}
+
+		now := time.Now()
+		d.Status = webhook.StatusPending
+		d.Attempts = 0
+		d.DateNextAttempt = now
+		d.DateUpdated = now
+
+		err = a.WebhookManager.UpdateDelivery(req.Context(), *d)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, toAPIWebhookDelivery(*d), http.StatusAccepted)
+	}
+}
+
+// managedWebhook retrieves the webhook subscription identified by the request path and makes sure that the principal
+// may manage it. A response is written and false is returned if the subscription cannot be managed.
+func (a *app) managedWebhook(w http.ResponseWriter, req *http.Request) (*webhook.Subscription, bool) {
+	id := chi.URLParam(req, "id")
+	principal := auth.FromContext(req.Context())
+
+	if !a.authorize(principal, policy.ActionWebhookManage, nil) {
+		respondError(w, AppError{External: errors.New("forbidden")}, http.StatusForbidden)
+		return nil, false
+	}
+
+	// Subscriptions that belong to other tenants are indistinguishable from subscriptions that do not exist
+	s, err := a.WebhookManager.GetSubscription(req.Context(), principal.TenantID, id)
+	if err != nil {
+		respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+		return nil, false
+	}
+
+	if s == nil {
+		respond(w, nil, http.StatusNotFound)
+		return nil, false
+	}
+
+	return s, true
+}
+
+// toAPIWebhookSubscription converts the subscription to its API representation, leaving out its secret
+func toAPIWebhookSubscription(s webhook.Subscription) api.WebhookSubscription {
+	events := make([]api.WebhookEventType, 0, len(s.Events))
+	for _, e := range s.Events {
+		events = append(events, api.WebhookEventType(e))
+	}
+
+	return api.WebhookSubscription{
+		Id:          s.ID,
+		OwnerId:     s.OwnerID,
+		Url:         s.URL,
+		Events:      events,
+		DateCreated: s.DateCreated,
+		DateUpdated: s.DateUpdated,
+	}
+}
+
+// toAPIWebhookDelivery converts the delivery to its API representation
+func toAPIWebhookDelivery(d webhook.Delivery) api.WebhookDelivery {
+	res := api.WebhookDelivery{
+		Id:             d.ID,
+		SubscriptionId: d.SubscriptionID,
+		EventId:        d.EventID,
+		EventType:      api.WebhookEventType(d.EventType),
+		Status:         api.WebhookDeliveryStatus(d.Status),
+		Attempts:       d.Attempts,
+		Payload:        string(d.Payload),
+		DateCreated:    d.DateCreated,
+		DateUpdated:    d.DateUpdated,
+	}
+	if d.Status == webhook.StatusPending {
+		dateNextAttempt := d.DateNextAttempt
+		res.DateNextAttempt = &dateNextAttempt
+	}
+	if d.ResponseStatus != 0 {
+		responseStatus := d.ResponseStatus
+		res.ResponseStatus = &responseStatus
+	}
+	if d.Error != "" {
+		deliveryErr := d.Error
+		res.Error = &deliveryErr
+	}
+
+	return res
+}
+
+// fromAPINewWebhookSubscription validates the API representation of a subscription, returning its URL and its unique,
+// sorted event types
+func fromAPINewWebhookSubscription(val api.NewWebhookSubscription) (string, []string, error) {
+	if val.Url == "" {
+		return "", nil, errors.New("field 'url' is required")
+	}
+	if len(val.Url) > maxWebhookURLLength {
+		return "", nil, fmt.Errorf("field 'url' must not be longer than %d characters", maxWebhookURLLength)
+	}
+
+	u, err := url.Parse(val.Url)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", nil, errors.New("field 'url' must be an absolute http or https URL")
+	}
+
+	if len(val.Events) == 0 {
+		return "", nil, errors.New("field 'events' must not be empty")
+	}
+
+	seen := make(map[string]bool, len(val.Events))
+	events := make([]string, 0, len(val.Events))
+	for _, e := range val.Events {
+		eventType := string(e)
+		if !webhook.ValidEventType(eventType) {
+			return "", nil, fmt.Errorf("field 'events' has unknown event type '%s'", eventType)
+		}
+
+		if !seen[eventType] {
+			seen[eventType] = true
+			events = append(events, eventType)
+		}
+	}
+
+	sort.Strings(events)
+	return val.Url, events, nil
+}
@@ -0,0 +1,435 @@
+package app_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jaredpetersen/go-rest-template/api"
+	"github.com/jaredpetersen/go-rest-template/internal/app"
+	"github.com/jaredpetersen/go-rest-template/internal/app/mocks"
+	"github.com/jaredpetersen/go-rest-template/internal/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func buildSubscription(t *testing.T, events ...string) *webhook.Subscription {
+	s, err := webhook.NewSubscription()
+	require.NoError(t, err)
+	s.TenantID = "tenant-1"
+	s.OwnerID = "user-1"
+	s.URL = "https://example.com/webhook"
+	s.Events = events
+	return s
+}
+
+func buildDelivery(s webhook.Subscription, status string) *webhook.Delivery {
+	return &webhook.Delivery{
+		ID:             "delivery-1",
+		TenantID:       s.TenantID,
+		SubscriptionID: s.ID,
+		EventID:        "event-1",
+		EventType:      webhook.EventTaskCreated,
+		Payload:        []byte(`{"id":"event-1"}`),
+		Status:         status,
+		Attempts:       8,
+		ResponseStatus: 500,
+		Error:          "subscription responded with status 500",
+	}
+}
+
+func TestHandleWebhookList(t *testing.T) {
+	s := buildSubscription(t, webhook.EventTaskCreated)
+
+	// Set up relevant server dependencies
+	webhookMgr := mocks.WebhookManager{}
+	webhookMgr.On("ListSubscriptions", mock.Anything, "tenant-1").Return([]webhook.Subscription{*s}, nil)
+
+	// Set up server
+	a := app.New()
+	a.WebhookManager = &webhookMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/webhooks", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+
+	var list api.WebhookSubscriptionList
+	err = json.Unmarshal(res.Body.Bytes(), &list)
+	require.NoError(t, err)
+	require.Len(t, list.Subscriptions, 1)
+	assert.Equal(t, s.ID, list.Subscriptions[0].Id)
+	assert.Equal(t, []api.WebhookEventType{api.WebhookEventTypeTaskCreated}, list.Subscriptions[0].Events)
+	assert.NotContains(t, res.Body.String(), s.Secret, "Secret was revealed")
+
+	webhookMgr.AssertExpectations(t)
+}
+
+func TestHandleWebhookGetNotFound(t *testing.T) {
+	// Set up relevant server dependencies
+	webhookMgr := mocks.WebhookManager{}
+	webhookMgr.On("GetSubscription", mock.Anything, "tenant-1", "webhook-1").Return(nil, nil)
+
+	// Set up server
+	a := app.New()
+	a.WebhookManager = &webhookMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/webhooks/webhook-1", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusNotFound, res.Result().StatusCode)
+
+	webhookMgr.AssertExpectations(t)
+}
+
+func TestHandleWebhookSave(t *testing.T) {
+	// Set up relevant server dependencies
+	var saved webhook.Subscription
+	webhookMgr := mocks.WebhookManager{}
+	webhookMgr.On("SaveSubscription", mock.Anything, mock.MatchedBy(func(s webhook.Subscription) bool {
+		saved = s
+		return s.TenantID == "tenant-1" && s.OwnerID == "user-1" && s.URL == "https://example.com/webhook"
+	})).Return(nil)
+
+	// Set up server
+	a := app.New()
+	a.WebhookManager = &webhookMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	body := `{"url": "https://example.com/webhook", "events": ["task.updated", "task.created", "task.updated"]}`
+	req, err := http.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(body))
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusCreated, res.Result().StatusCode)
+	assert.Equal(t, []string{webhook.EventTaskCreated, webhook.EventTaskUpdated}, saved.Events)
+
+	// The secret is only revealed on creation
+	var created api.WebhookSubscription
+	err = json.Unmarshal(res.Body.Bytes(), &created)
+	require.NoError(t, err)
+	require.NotNil(t, created.Secret)
+	assert.Equal(t, saved.Secret, *created.Secret)
+
+	webhookMgr.AssertExpectations(t)
+}
+
+func TestHandleWebhookSaveInvalid(t *testing.T) {
+	testCases := []struct {
+		name    string
+		body    string
+		message string
+	}{
+		{name: "MissingURL", body: `{"events": ["task.created"]}`, message: "field 'url' is required"},
+		{
+			name:    "RelativeURL",
+			body:    `{"url": "/webhook", "events": ["task.created"]}`,
+			message: "field 'url' must be an absolute http or https URL",
+		},
+		{
+			name:    "UnsupportedScheme",
+			body:    `{"url": "ftp://example.com/webhook", "events": ["task.created"]}`,
+			message: "field 'url' must be an absolute http or https URL",
+		},
+		{
+			name:    "TooLongURL",
+			body:    `{"url": "https://example.com/` + strings.Repeat("a", 2048) + `", "events": ["task.created"]}`,
+			message: "field 'url' must not be longer than 2048 characters",
+		},
+		{
+			name:    "MissingEvents",
+			body:    `{"url": "https://example.com/webhook", "events": []}`,
+			message: "field 'events' must not be empty",
+		},
+		{
+			name:    "UnknownEvent",
+			body:    `{"url": "https://example.com/webhook", "events": ["task.archived"]}`,
+			message: "field 'events' has unknown event type 'task.archived'",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Set up relevant server dependencies
+			webhookMgr := mocks.WebhookManager{}
+
+			// Set up server
+			a := app.New()
+			a.WebhookManager = &webhookMgr
+			a.Authenticator = buildAuthenticator("tasks:write")
+			a.Authorizer = buildAuthorizer(true)
+
+			// Make request
+			req, err := http.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(tc.body))
+			require.NoError(t, err)
+			res := httptest.NewRecorder()
+			a.ServeHTTP(res, req)
+
+			assert.Equal(t, http.StatusUnprocessableEntity, res.Result().StatusCode)
+			assert.JSONEq(t, `{"message": "`+tc.message+`"}`, res.Body.String())
+			webhookMgr.AssertNotCalled(t, "SaveSubscription", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestHandleWebhookSaveForbidden(t *testing.T) {
+	// Set up relevant server dependencies
+	webhookMgr := mocks.WebhookManager{}
+
+	// Set up server
+	a := app.New()
+	a.WebhookManager = &webhookMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(false)
+
+	// Make request
+	body := `{"url": "https://example.com/webhook", "events": ["task.created"]}`
+	req, err := http.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(body))
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusForbidden, res.Result().StatusCode)
+	webhookMgr.AssertNotCalled(t, "SaveSubscription", mock.Anything, mock.Anything)
+}
+
+func TestHandleWebhookUpdate(t *testing.T) {
+	s := buildSubscription(t, webhook.EventTaskCreated)
+
+	// Set up relevant server dependencies
+	webhookMgr := mocks.WebhookManager{}
+	webhookMgr.On("GetSubscription", mock.Anything, "tenant-1", s.ID).Return(s, nil)
+	webhookMgr.On("UpdateSubscription", mock.Anything, mock.MatchedBy(func(updated webhook.Subscription) bool {
+		return updated.ID == s.ID && updated.URL == "http://example.com/other" && updated.Secret == s.Secret &&
+			len(updated.Events) == 1 && updated.Events[0] == webhook.EventTaskDeleted
+	})).Return(nil)
+
+	// Set up server
+	a := app.New()
+	a.WebhookManager = &webhookMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	body := `{"url": "http://example.com/other", "events": ["task.deleted"]}`
+	req, err := http.NewRequest(http.MethodPut, "/webhooks/"+s.ID, strings.NewReader(body))
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	assert.NotContains(t, res.Body.String(), "secret", "Secret was revealed")
+
+	webhookMgr.AssertExpectations(t)
+}
+
+func TestHandleWebhookDelete(t *testing.T) {
+	s := buildSubscription(t, webhook.EventTaskCreated)
+
+	// Set up relevant server dependencies
+	webhookMgr := mocks.WebhookManager{}
+	webhookMgr.On("GetSubscription", mock.Anything, "tenant-1", s.ID).Return(s, nil)
+	webhookMgr.On("DeleteSubscription", mock.Anything, "tenant-1", s.ID).Return(nil)
+
+	// Set up server
+	a := app.New()
+	a.WebhookManager = &webhookMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodDelete, "/webhooks/"+s.ID, nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusNoContent, res.Result().StatusCode)
+	assert.Empty(t, res.Body)
+
+	webhookMgr.AssertExpectations(t)
+}
+
+func TestHandleWebhookDeliveryList(t *testing.T) {
+	s := buildSubscription(t, webhook.EventTaskCreated)
+	d := buildDelivery(*s, webhook.StatusDead)
+
+	// Set up relevant server dependencies
+	webhookMgr := mocks.WebhookManager{}
+	webhookMgr.On("GetSubscription", mock.Anything, "tenant-1", s.ID).Return(s, nil)
+	webhookMgr.On("ListDeliveries", mock.Anything, "tenant-1", s.ID, webhook.DeliveryFilter{
+		Status: webhook.StatusDead,
+		Limit:  10,
+		Offset: 5,
+	}).Return([]webhook.Delivery{*d}, nil)
+
+	// Set up server
+	a := app.New()
+	a.WebhookManager = &webhookMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/webhooks/"+s.ID+"/deliveries?status=dead&limit=10&offset=5", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+
+	var list api.WebhookDeliveryList
+	err = json.Unmarshal(res.Body.Bytes(), &list)
+	require.NoError(t, err)
+	require.Len(t, list.Deliveries, 1)
+	assert.Equal(t, api.WebhookDeliveryStatusDead, list.Deliveries[0].Status)
+	assert.Equal(t, `{"id":"event-1"}`, list.Deliveries[0].Payload)
+	assert.Nil(t, list.Deliveries[0].DateNextAttempt, "Dead delivery has a next attempt")
+	require.NotNil(t, list.Deliveries[0].ResponseStatus)
+	assert.Equal(t, 500, *list.Deliveries[0].ResponseStatus)
+	assert.Equal(t, 10, list.Limit)
+	assert.Equal(t, 5, list.Offset)
+
+	webhookMgr.AssertExpectations(t)
+}
+
+func TestHandleWebhookDeliveryListInvalidStatus(t *testing.T) {
+	// Set up relevant server dependencies
+	webhookMgr := mocks.WebhookManager{}
+
+	// Set up server
+	a := app.New()
+	a.WebhookManager = &webhookMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/webhooks/webhook-1/deliveries?status=failed", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Result().StatusCode)
+	assert.JSONEq(t, `{"message": "query parameter 'status' must be 'pending', 'succeeded', or 'dead'"}`, res.Body.String())
+	webhookMgr.AssertNotCalled(t, "ListDeliveries", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleWebhookRedeliver(t *testing.T) {
+	s := buildSubscription(t, webhook.EventTaskCreated)
+	d := buildDelivery(*s, webhook.StatusDead)
+
+	// Set up relevant server dependencies
+	webhookMgr := mocks.WebhookManager{}
+	webhookMgr.On("GetSubscription", mock.Anything, "tenant-1", s.ID).Return(s, nil)
+	webhookMgr.On("GetDelivery", mock.Anything, "tenant-1", d.ID).Return(d, nil)
+	webhookMgr.On("UpdateDelivery", mock.Anything, mock.MatchedBy(func(updated webhook.Delivery) bool {
+		return updated.ID == d.ID && updated.Status == webhook.StatusPending && updated.Attempts == 0 &&
+			!updated.DateNextAttempt.IsZero()
+	})).Return(nil)
+
+	// Set up server
+	a := app.New()
+	a.WebhookManager = &webhookMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodPost, "/webhooks/"+s.ID+"/deliveries/"+d.ID+":redeliver", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusAccepted, res.Result().StatusCode)
+	assert.Contains(t, res.Body.String(), `"status":"pending"`)
+
+	webhookMgr.AssertExpectations(t)
+}
+
+func TestHandleWebhookRedeliverPending(t *testing.T) {
+	s := buildSubscription(t, webhook.EventTaskCreated)
+	d := buildDelivery(*s, webhook.StatusPending)
+
+	// Set up relevant server dependencies
+	webhookMgr := mocks.WebhookManager{}
+	webhookMgr.On("GetSubscription", mock.Anything, "tenant-1", s.ID).Return(s, nil)
+	webhookMgr.On("GetDelivery", mock.Anything, "tenant-1", d.ID).Return(d, nil)
+
+	// Set up server
+	a := app.New()
+	a.WebhookManager = &webhookMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodPost, "/webhooks/"+s.ID+"/deliveries/"+d.ID+":redeliver", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusConflict, res.Result().StatusCode)
+	assert.JSONEq(t, `{"message": "delivery is still pending"}`, res.Body.String())
+	webhookMgr.AssertNotCalled(t, "UpdateDelivery", mock.Anything, mock.Anything)
+}
+
+func TestHandleWebhookRedeliverOtherSubscription(t *testing.T) {
+	s := buildSubscription(t, webhook.EventTaskCreated)
+	other := buildSubscription(t, webhook.EventTaskCreated)
+	d := buildDelivery(*other, webhook.StatusDead)
+
+	// Set up relevant server dependencies
+	webhookMgr := mocks.WebhookManager{}
+	webhookMgr.On("GetSubscription", mock.Anything, "tenant-1", s.ID).Return(s, nil)
+	webhookMgr.On("GetDelivery", mock.Anything, "tenant-1", d.ID).Return(d, nil)
+
+	// Set up server
+	a := app.New()
+	a.WebhookManager = &webhookMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodPost, "/webhooks/"+s.ID+"/deliveries/"+d.ID+":redeliver", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusNotFound, res.Result().StatusCode)
+	webhookMgr.AssertNotCalled(t, "UpdateDelivery", mock.Anything, mock.Anything)
+}
+
+func TestHandleWebhookError(t *testing.T) {
+	// Set up relevant server dependencies
+	webhookMgr := mocks.WebhookManager{}
+	webhookMgr.On("ListSubscriptions", mock.Anything, "tenant-1").Return(nil, errors.New("failure to list subscriptions"))
+
+	// Set up server
+	a := app.New()
+	a.WebhookManager = &webhookMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, "/webhooks", nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusInternalServerError, res.Result().StatusCode)
+
+	webhookMgr.AssertExpectations(t)
+}
@@ -0,0 +1,99 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/jaredpetersen/go-rest-template/internal/auth"
+	"github.com/jaredpetersen/go-rest-template/internal/policy"
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/hlog"
+)
+
+// Scopes that may be required by routes
+const (
+	scopeTasksRead  = "tasks:read"
+	scopeTasksWrite = "tasks:write"
+)
+
+// authenticate creates middleware that rejects requests that are not authenticated and stores the authenticated
+// principal in the request context
+func (a *app) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if a.Authenticator == nil {
+			respondError(w, AppError{External: errors.New("authentication is not configured")}, http.StatusUnauthorized)
+			return
+		}
+
+		principal, err := a.Authenticator.Authenticate(req)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="tasks"`)
+			respondError(w, AppError{External: errors.New("unauthorized"), Internal: err}, http.StatusUnauthorized)
+			return
+		}
+
+		// Add the principal to the request logger so that access logs can be attributed
+		hlog.FromRequest(req).UpdateContext(func(c zerolog.Context) zerolog.Context {
+			return c.Str("principal", principal.Subject)
+		})
+
+		// Record the principal and the request with every change that is made to tasks
+		actor := task.Actor{Subject: principal.Subject}
+		if id, ok := hlog.IDFromRequest(req); ok {
+			actor.RequestID = id.String()
+		}
+
+		ctx := auth.NewContext(req.Context(), principal)
+		ctx = task.NewActorContext(ctx, actor)
+
+		next.ServeHTTP(w, req.WithContext(ctx))
+	})
+}
+
+// requireScope creates middleware that rejects requests from principals that have not been granted the scope. Must be
+// used after authenticate.
+func (a *app) requireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			principal := auth.FromContext(req.Context())
+			if principal == nil || !principal.HasScope(scope) {
+				respondError(w, AppError{External: errors.New("insufficient scope")}, http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// authorize determines whether or not the principal may perform the action on the task. The task should be nil when the
+// action is not performed on an existing task. Everything is denied when authorization is not configured.
+func (a *app) authorize(p *auth.Principal, action policy.Action, t *task.Task) bool {
+	if a.Authorizer == nil || p == nil {
+		return false
+	}
+
+	return a.Authorizer.Authorize(*p, action, t)
+}
+
+// authorizeEach determines whether or not the principal may perform the action on each of the tasks, in the same order
+// as the tasks. Only a summary of the decisions is logged, so it is meant for filtering lists of tasks. Everything is
+// denied when authorization is not configured.
+func (a *app) authorizeEach(p *auth.Principal, action policy.Action, ts []task.Task) []bool {
+	if a.Authorizer == nil || p == nil {
+		return make([]bool, len(ts))
+	}
+
+	return a.Authorizer.AuthorizeEach(*p, action, ts)
+}
+
+// authorizeList determines which of the tasks the principal may perform the action on so that lists of tasks can be
+// filtered by the database. No tasks are visible when authorization is not configured.
+func (a *app) authorizeList(p *auth.Principal, action policy.Action) *task.Visibility {
+	if a.Authorizer == nil || p == nil {
+		return &task.Visibility{}
+	}
+
+	return a.Authorizer.AuthorizeList(*p, action)
+}
@@ -0,0 +1,135 @@
+package app_test
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jaredpetersen/go-rest-template/internal/app"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jaredpetersen/go-rest-template/internal/app/mocks"
+	"github.com/jaredpetersen/go-rest-template/internal/auth"
+	"github.com/jaredpetersen/go-rest-template/internal/policy"
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// buildAuthenticator creates an authenticator that authenticates every request as a principal with the scopes
+func buildAuthenticator(scopes ...string) *mocks.Authenticator {
+	authenticator := mocks.Authenticator{}
+	authenticator.On("Authenticate", mock.Anything).Return(&auth.Principal{Subject: "user-1", TenantID: "tenant-1", Scopes: scopes}, nil)
+	return &authenticator
+}
+
+// buildAuthorizer creates an authorizer that makes the same decision for every action
+func buildAuthorizer(allowed bool) *mocks.Authorizer {
+	authorizer := mocks.Authorizer{}
+	authorizer.On("Authorize", mock.Anything, mock.Anything, mock.Anything).Return(allowed)
+	authorizer.On("AuthorizeEach", mock.Anything, mock.Anything, mock.Anything).Return(
+		func(p auth.Principal, action policy.Action, ts []task.Task) []bool {
+			decisions := make([]bool, len(ts))
+			for i := range decisions {
+				decisions[i] = allowed
+			}
+			return decisions
+		})
+	authorizer.On("AuthorizeList", mock.Anything, mock.Anything).Return(
+		func(p auth.Principal, action policy.Action) *task.Visibility {
+			if allowed {
+				return nil
+			}
+			return &task.Visibility{Subject: p.Subject}
+		})
+	return &authorizer
+}
+
+func TestAuthenticateUnauthorized(t *testing.T) {
+	// Set up relevant server dependencies
+	authenticator := mocks.Authenticator{}
+	authenticator.On("Authenticate", mock.Anything).Return(nil, auth.ErrMissingCredentials)
+
+	tskMgr := mocks.TaskManager{}
+
+	// Set up server
+	a := app.New()
+	a.Authenticator = &authenticator
+	a.TaskManager = &tskMgr
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/tasks/%s", uuid.New()), nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusUnauthorized, res.Result().StatusCode)
+	assert.Equal(t, `Bearer realm="tasks"`, res.Result().Header.Get("WWW-Authenticate"))
+	assert.JSONEq(t, "{\"message\": \"unauthorized\"}", res.Body.String())
+
+	tskMgr.AssertExpectations(t)
+}
+
+func TestAuthenticateNotConfigured(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+
+	// Set up server without an authenticator
+	a := app.New()
+	a.TaskManager = &tskMgr
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/tasks/%s", uuid.New()), nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusUnauthorized, res.Result().StatusCode)
+
+	tskMgr.AssertExpectations(t)
+}
+
+func TestRequireScopeForbidden(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+
+	// Set up server
+	a := app.New()
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.TaskManager = &tskMgr
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/tasks/%s", uuid.New()), nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusForbidden, res.Result().StatusCode)
+	assert.JSONEq(t, "{\"message\": \"insufficient scope\"}", res.Body.String())
+
+	tskMgr.AssertExpectations(t)
+}
+
+func TestHealthRoutesArePublic(t *testing.T) {
+	// Set up relevant server dependencies
+	authenticator := mocks.Authenticator{}
+	authenticator.On("Authenticate", mock.Anything).Return(nil, errors.New("should not be called"))
+
+	// Set up server
+	a := app.New()
+	a.Authenticator = &authenticator
+
+	for _, path := range []string{"/startup", "/liveness"} {
+		// Make request
+		req, err := http.NewRequest(http.MethodGet, path, nil)
+		require.NoError(t, err)
+		res := httptest.NewRecorder()
+		a.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	}
+
+	authenticator.AssertNotCalled(t, "Authenticate", mock.Anything)
+}
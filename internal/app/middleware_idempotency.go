@@ -0,0 +1,162 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/jaredpetersen/go-rest-template/internal/auth"
+	"github.com/jaredpetersen/go-rest-template/internal/idempotency"
+	"github.com/rs/zerolog/hlog"
+)
+
+// IdempotencyKeyHeader is the request header used to provide an idempotency key
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+const (
+	// defaultIdempotencyWindow is how long responses are replayed for when the window is not configured
+	defaultIdempotencyWindow = 24 * time.Hour
+	// idempotencyLockTTL is how long a request may be in flight before its key may be used again, in case the request
+	// never completes
+	idempotencyLockTTL = time.Minute
+	// maxIdempotencyKeyLength limits the size of stored keys
+	maxIdempotencyKeyLength = 255
+	// maxIdempotentBodySize is the largest request body in bytes that is read into memory to fingerprint the request
+	maxIdempotentBodySize = 1 << 20
+)
+
+// replayedHeaders are the response headers that are stored along with the response and replayed, since they describe
+// the outcome of the request
+var replayedHeaders = []string{"Content-Type", "ETag", "Last-Modified", "Location"}
+
+// idempotent creates middleware that replays the stored response when a request is retried with the same idempotency
+// key. Requests without an idempotency key are performed every time. Must be used after authenticate.
+//
+// Responses are stored per principal for the idempotency window, which defaults to 24 hours. Server errors are not
+// stored so that the request may be retried.
+func (a *app) idempotent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		idempotencyKey := req.Header.Get(IdempotencyKeyHeader)
+		if idempotencyKey == "" || a.IdempotencyStore == nil {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		if len(idempotencyKey) > maxIdempotencyKeyLength {
+			respondError(w, AppError{External: errors.New("header 'Idempotency-Key' must be at most 255 characters")}, http.StatusUnprocessableEntity)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, req.Body, maxIdempotentBodySize))
+		if err != nil && len(body) == maxIdempotentBodySize {
+			err = fmt.Errorf("request body must not be larger than %d bytes", maxIdempotentBodySize)
+			respondError(w, AppError{External: err}, http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusBadRequest)
+			return
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+
+		principal := auth.FromContext(req.Context())
+		key := idempotency.Key(principal.TenantID, principal.Subject, idempotencyKey)
+		fingerprint := idempotency.Fingerprint(req.Method, req.URL.Path, body)
+
+		rec, err := a.IdempotencyStore.Reserve(req.Context(), key, fingerprint, idempotencyLockTTL)
+		if err != nil {
+			respondError(w, AppError{Internal: err}, http.StatusInternalServerError)
+			return
+		}
+
+		if rec != nil {
+			switch {
+			case rec.Fingerprint != fingerprint:
+				respondError(w, AppError{External: errors.New("idempotency key has already been used for a different request")}, http.StatusUnprocessableEntity)
+			case rec.InFlight():
+				respondError(w, AppError{External: errors.New("request with the same idempotency key is in progress")}, http.StatusConflict)
+			default:
+				w.Header().Set("Idempotent-Replayed", "true")
+				replay(w, *rec)
+			}
+			return
+		}
+
+		rw := &recordingResponseWriter{ResponseWriter: w}
+		next.ServeHTTP(rw, req)
+
+		// The client may have given up on the request but its retry still needs to see the outcome
+		ctx := context.Background()
+
+		if rw.statusCode >= http.StatusInternalServerError {
+			err = a.IdempotencyStore.Release(ctx, key)
+			if err != nil {
+				hlog.FromRequest(req).Error().Err(err).Msg("Failed to release idempotency key")
+			}
+			return
+		}
+
+		window := a.IdempotencyWindow
+		if window <= 0 {
+			window = defaultIdempotencyWindow
+		}
+
+		rec = &idempotency.Record{Fingerprint: fingerprint, StatusCode: rw.statusCode, Header: rw.header, Body: rw.body.Bytes()}
+		err = a.IdempotencyStore.Complete(ctx, key, *rec, window)
+		if err != nil {
+			hlog.FromRequest(req).Error().Err(err).Msg("Failed to store idempotent response")
+		}
+	})
+}
+
+// replay writes a stored response
+func replay(w http.ResponseWriter, rec idempotency.Record) {
+	for name, values := range rec.Header {
+		w.Header()[name] = values
+	}
+	// Responses stored before headers were stored are all JSON
+	if rec.Header == nil && len(rec.Body) > 0 {
+		w.Header().Add("Content-Type", "application/json")
+	}
+	w.WriteHeader(rec.StatusCode)
+	w.Write(rec.Body)
+}
+
+// recordingResponseWriter writes the response while keeping a copy of it
+type recordingResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+	header     http.Header
+	body       bytes.Buffer
+}
+
+func (rw *recordingResponseWriter) WriteHeader(statusCode int) {
+	if rw.statusCode == 0 {
+		rw.statusCode = statusCode
+		rw.recordHeader()
+	}
+	rw.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (rw *recordingResponseWriter) Write(b []byte) (int, error) {
+	if rw.statusCode == 0 {
+		rw.statusCode = http.StatusOK
+		rw.recordHeader()
+	}
+	rw.body.Write(b)
+	return rw.ResponseWriter.Write(b)
+}
+
+// recordHeader keeps a copy of the headers that are replayed as they are when the response is written
+func (rw *recordingResponseWriter) recordHeader() {
+	rw.header = make(http.Header)
+	for _, name := range replayedHeaders {
+		if values := rw.Header().Values(name); len(values) > 0 {
+			rw.header[name] = append([]string(nil), values...)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package app_test
+
+import (
+	"errors"
+	"github.com/jaredpetersen/go-rest-template/internal/app"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaredpetersen/go-rest-template/internal/app/mocks"
+	"github.com/jaredpetersen/go-rest-template/internal/idempotency"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const idempotentTaskBody = "{\"description\": \"Buy milk\"}"
+
+var idempotentTaskKey = idempotency.Key("tenant-1", "user-1", "retry-1")
+var idempotentTaskFingerprint = idempotency.Fingerprint(http.MethodPost, "/tasks", []byte(idempotentTaskBody))
+
+// makeIdempotentTaskRequest makes a request to create a task with an idempotency key
+func makeIdempotentTaskRequest(t *testing.T, handler http.Handler, idempotencyKey string) *httptest.ResponseRecorder {
+	req, err := http.NewRequest(http.MethodPost, "/tasks", strings.NewReader(idempotentTaskBody))
+	require.NoError(t, err)
+	req.Header.Set(app.IdempotencyKeyHeader, idempotencyKey)
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	return res
+}
+
+func TestIdempotentFirstRequest(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Save", mock.Anything, mock.Anything).Return(nil)
+
+	store := mocks.IdempotencyStore{}
+	store.On("Reserve", mock.Anything, idempotentTaskKey, idempotentTaskFingerprint, time.Minute).Return(nil, nil)
+	store.On("Complete", mock.Anything, idempotentTaskKey, mock.MatchedBy(func(rec idempotency.Record) bool {
+		return rec.Fingerprint == idempotentTaskFingerprint &&
+			rec.StatusCode == http.StatusCreated &&
+			rec.Header.Get("Content-Type") == "application/json" &&
+			strings.Contains(string(rec.Body), "\"id\"")
+	}), 2*time.Hour).Return(nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+	a.IdempotencyStore = &store
+	a.IdempotencyWindow = 2 * time.Hour
+
+	// Make request
+	res := makeIdempotentTaskRequest(t, a, "retry-1")
+
+	assert.Equal(t, http.StatusCreated, res.Result().StatusCode)
+	assert.Empty(t, res.Result().Header.Get("Idempotent-Replayed"))
+
+	store.AssertExpectations(t)
+	tskMgr.AssertExpectations(t)
+}
+
+func TestIdempotentReplay(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+
+	store := mocks.IdempotencyStore{}
+	store.On("Reserve", mock.Anything, idempotentTaskKey, idempotentTaskFingerprint, time.Minute).Return(&idempotency.Record{
+		Fingerprint: idempotentTaskFingerprint,
+		StatusCode:  http.StatusCreated,
+		Body:        []byte("{\"id\": \"c7b3ec1c-7a57-4e2e-a3c3-dfa2b3e1d8a2\"}"),
+	}, nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+	a.IdempotencyStore = &store
+
+	// Make request
+	res := makeIdempotentTaskRequest(t, a, "retry-1")
+
+	assert.Equal(t, http.StatusCreated, res.Result().StatusCode)
+	assert.Equal(t, "true", res.Result().Header.Get("Idempotent-Replayed"))
+	assert.JSONEq(t, "{\"id\": \"c7b3ec1c-7a57-4e2e-a3c3-dfa2b3e1d8a2\"}", res.Body.String())
+
+	store.AssertExpectations(t)
+	tskMgr.AssertExpectations(t)
+}
+
+func TestIdempotentInFlight(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+
+	store := mocks.IdempotencyStore{}
+	store.On("Reserve", mock.Anything, idempotentTaskKey, idempotentTaskFingerprint, time.Minute).
+		Return(&idempotency.Record{Fingerprint: idempotentTaskFingerprint}, nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+	a.IdempotencyStore = &store
+
+	// Make request
+	res := makeIdempotentTaskRequest(t, a, "retry-1")
+
+	assert.Equal(t, http.StatusConflict, res.Result().StatusCode)
+	assert.JSONEq(t, "{\"message\": \"request with the same idempotency key is in progress\"}", res.Body.String())
+
+	tskMgr.AssertExpectations(t)
+}
+
+func TestIdempotentDifferentRequest(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+
+	store := mocks.IdempotencyStore{}
+	store.On("Reserve", mock.Anything, idempotentTaskKey, idempotentTaskFingerprint, time.Minute).
+		Return(&idempotency.Record{Fingerprint: "other", StatusCode: http.StatusCreated}, nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+	a.IdempotencyStore = &store
+
+	// Make request
+	res := makeIdempotentTaskRequest(t, a, "retry-1")
+
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Result().StatusCode)
+	assert.JSONEq(t, "{\"message\": \"idempotency key has already been used for a different request\"}", res.Body.String())
+
+	tskMgr.AssertExpectations(t)
+}
+
+func TestIdempotentServerErrorReleased(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Save", mock.Anything, mock.Anything).Return(errors.New("failure to save task"))
+
+	store := mocks.IdempotencyStore{}
+	store.On("Reserve", mock.Anything, idempotentTaskKey, idempotentTaskFingerprint, time.Minute).Return(nil, nil)
+	store.On("Release", mock.Anything, idempotentTaskKey).Return(nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+	a.IdempotencyStore = &store
+
+	// Make request
+	res := makeIdempotentTaskRequest(t, a, "retry-1")
+
+	assert.Equal(t, http.StatusInternalServerError, res.Result().StatusCode)
+
+	store.AssertExpectations(t)
+}
+
+func TestIdempotentStoreError(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+
+	store := mocks.IdempotencyStore{}
+	store.On("Reserve", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("failed"))
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+	a.IdempotencyStore = &store
+
+	// Make request
+	res := makeIdempotentTaskRequest(t, a, "retry-1")
+
+	assert.Equal(t, http.StatusInternalServerError, res.Result().StatusCode)
+
+	tskMgr.AssertExpectations(t)
+}
+
+func TestIdempotentKeyTooLong(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	store := mocks.IdempotencyStore{}
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+	a.IdempotencyStore = &store
+
+	// Make request
+	res := makeIdempotentTaskRequest(t, a, strings.Repeat("a", 256))
+
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Result().StatusCode)
+
+	store.AssertExpectations(t)
+	tskMgr.AssertExpectations(t)
+}
+
+func TestIdempotentWithoutKey(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Save", mock.Anything, mock.Anything).Return(nil)
+
+	store := mocks.IdempotencyStore{}
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+	a.IdempotencyStore = &store
+
+	// Make request
+	res := makeIdempotentTaskRequest(t, a, "")
+
+	assert.Equal(t, http.StatusCreated, res.Result().StatusCode)
+
+	store.AssertExpectations(t)
+}
+
+func TestIdempotentReplayHeaders(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+
+	store := mocks.IdempotencyStore{}
+	store.On("Reserve", mock.Anything, idempotentTaskKey, idempotentTaskFingerprint, time.Minute).Return(&idempotency.Record{
+		Fingerprint: idempotentTaskFingerprint,
+		StatusCode:  http.StatusCreated,
+		Header: http.Header{
+			"Content-Type": {"application/json"},
+			"Etag":         {"\"1\""},
+			"Location":     {"/tasks/c7b3ec1c-7a57-4e2e-a3c3-dfa2b3e1d8a2"},
+		},
+		Body: []byte("{\"id\": \"c7b3ec1c-7a57-4e2e-a3c3-dfa2b3e1d8a2\"}"),
+	}, nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+	a.IdempotencyStore = &store
+
+	// Make request
+	res := makeIdempotentTaskRequest(t, a, "retry-1")
+
+	assert.Equal(t, http.StatusCreated, res.Result().StatusCode)
+	assert.Equal(t, "application/json", res.Result().Header.Get("Content-Type"))
+	assert.Equal(t, "\"1\"", res.Result().Header.Get("ETag"))
+	assert.Equal(t, "/tasks/c7b3ec1c-7a57-4e2e-a3c3-dfa2b3e1d8a2", res.Result().Header.Get("Location"))
+
+	store.AssertExpectations(t)
+}
+
+func TestIdempotentBodyTooLarge(t *testing.T) {
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	store := mocks.IdempotencyStore{}
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+	a.IdempotencyStore = &store
+
+	// Make request
+	body := "{\"description\": \"" + strings.Repeat("a", 1<<20) + "\"}"
+	req, err := http.NewRequest(http.MethodPost, "/tasks", strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set(app.IdempotencyKeyHeader, "retry-1")
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, res.Result().StatusCode)
+	assert.JSONEq(t, "{\"message\": \"request body must not be larger than 1048576 bytes\"}", res.Body.String())
+
+	store.AssertExpectations(t)
+	tskMgr.AssertExpectations(t)
+}
@@ -0,0 +1,145 @@
+package app
+
+import (
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jaredpetersen/go-rest-template/internal/auth"
+	"github.com/rs/zerolog/hlog"
+)
+
+// Routes that may be rate limited. Used as the keys of RateLimits.
+const (
+	// RouteAuthenticate covers every route that requires authentication and is limited per remote address
+	RouteAuthenticate       = "authenticate"
+	RouteTasksGet           = "tasks.get"
+	RouteTasksSave          = "tasks.save"
+	RouteTasksUpdate        = "tasks.update"
+	RouteTasksDelete        = "tasks.delete"
+	RouteTasksPermissions   = "tasks.permissions"
+	RouteTasksBatchGet      = "tasks.batchGet"
+	RouteTasksBatchCreate   = "tasks.batchCreate"
+	RouteTasksSearch        = "tasks.search"
+	RouteTasksList          = "tasks.list"
+	RouteTasksSubtasks      = "tasks.subtasks"
+	RouteTasksOccurrences   = "tasks.occurrences"
+	RouteTasksHistory       = "tasks.history"
+	RouteTasksRevert        = "tasks.revert"
+	RouteTasksBlockers      = "tasks.blockers"
+	RouteTasksSort          = "tasks.sort"
+	RouteTasksEvents        = "tasks.events"
+	RouteTasksCollaboration = "tasks.collaboration"
+	RouteCommentsGet        = "comments.get"
+	RouteCommentsList       = "comments.list"
+	RouteCommentsSave       = "comments.save"
+	RouteCommentsUpdate     = "comments.update"
+	RouteCommentsDelete     = "comments.delete"
+	RouteAttachmentsGet     = "attachments.get"
+	RouteAttachmentsList    = "attachments.list"
+	RouteAttachmentsContent = "attachments.content"
+	RouteAttachmentsSave    = "attachments.save"
+	RouteAttachmentsDelete  = "attachments.delete"
+	RouteTagsGet            = "tags.get"
+	RouteTagsList           = "tags.list"
+	RouteTagsSave           = "tags.save"
+	RouteTagsUpdate         = "tags.update"
+	RouteTagsDelete         = "tags.delete"
+	RouteProjectsGet        = "projects.get"
+	RouteProjectsList       = "projects.list"
+	RouteProjectsSave       = "projects.save"
+	RouteProjectsUpdate     = "projects.update"
+	RouteProjectsArchive    = "projects.archive"
+	RouteProjectTasksList   = "projects.tasks.list"
+	RouteProjectTasksSave   = "projects.tasks.save"
+	RouteWebhooksGet        = "webhooks.get"
+	RouteWebhooksList       = "webhooks.list"
+	RouteWebhooksSave       = "webhooks.save"
+	RouteWebhooksUpdate     = "webhooks.update"
+	RouteWebhooksDelete     = "webhooks.delete"
+	RouteWebhooksDeliveries = "webhooks.deliveries"
+)
+
+// rateLimit creates middleware that rejects requests once the client has exceeded the route's limit. Requests are not
+// limited if rate limiting is not configured or the route does not have a limit.
+//
+// Clients are identified by their API key, their authenticated principal, or their remote address, in that order, so it
+// should be used after authenticate.
+func (a *app) rateLimit(route string) func(http.Handler) http.Handler {
+	return a.rateLimitBy(route, rateLimitClient)
+}
+
+// rateLimitRemoteAddr creates middleware that rejects requests once the remote address has exceeded the route's limit,
+// regardless of who the client claims to be. Meant to be used before authenticate so that clients are limited even if
+// they never authenticate.
+func (a *app) rateLimitRemoteAddr(route string) func(http.Handler) http.Handler {
+	return a.rateLimitBy(route, rateLimitRemoteAddrClient)
+}
+
+// rateLimitBy creates middleware that rejects requests once the client, as identified by the client function, has
+// exceeded the route's limit
+func (a *app) rateLimitBy(route string, client func(req *http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			limit, ok := a.RateLimits[route]
+			if a.RateLimiter == nil || !ok {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			key := "ratelimit." + route + "." + client(req)
+			res, err := a.RateLimiter.Allow(req.Context(), key, limit)
+			if err != nil {
+				// Availability is more important than enforcing the limit
+				hlog.FromRequest(req).Error().Err(err).Str("route", route).Msg("Failed to rate limit request")
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.ResetAfter)))
+
+			if !res.Allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+				respondError(w, AppError{External: errors.New("rate limit exceeded")}, http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// rateLimitClient identifies the client making the request for rate limiting purposes
+func rateLimitClient(req *http.Request) string {
+	// Keys are hashed so that they are not exposed in Redis
+	if apiKey := req.Header.Get(auth.APIKeyHeader); apiKey != "" {
+		return "key." + auth.HashAPIKey(apiKey)
+	}
+
+	if principal := auth.FromContext(req.Context()); principal != nil {
+		return "principal." + principal.TenantID + "." + principal.Subject
+	}
+
+	return rateLimitRemoteAddrClient(req)
+}
+
+// rateLimitRemoteAddrClient identifies the client making the request by the host of the remote address that was logged
+// for it
+func rateLimitRemoteAddrClient(req *http.Request) string {
+	addr := remoteAddr(req)
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	return "ip." + host
+}
+
+// ceilSeconds converts the duration to whole seconds, rounding up so that clients never retry too early
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,221 @@
+package app_test
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jaredpetersen/go-rest-template/internal/app"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaredpetersen/go-rest-template/internal/app/mocks"
+	"github.com/jaredpetersen/go-rest-template/internal/auth"
+	"github.com/jaredpetersen/go-rest-template/internal/ratelimit"
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitAllowed(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+
+	limit := ratelimit.PerSecond(10)
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	rateLimiter := mocks.RateLimiter{}
+	rateLimiter.On("Allow", mock.Anything, "ratelimit.tasks.get.principal.tenant-1.user-1", limit).
+		Return(ratelimit.Result{Allowed: true, Limit: 10, Remaining: 9, ResetAfter: 100 * time.Millisecond}, nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+	a.RateLimiter = &rateLimiter
+	a.RateLimits = map[string]ratelimit.Limit{app.RouteTasksGet: limit}
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/tasks/%s", tsk.ID), nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	assert.Equal(t, "10", res.Result().Header.Get("RateLimit-Limit"))
+	assert.Equal(t, "9", res.Result().Header.Get("RateLimit-Remaining"))
+	assert.Equal(t, "1", res.Result().Header.Get("RateLimit-Reset"))
+	assert.Empty(t, res.Result().Header.Get("Retry-After"))
+
+	rateLimiter.AssertExpectations(t)
+}
+
+func TestRateLimitExceeded(t *testing.T) {
+	limit := ratelimit.PerSecond(10)
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+
+	rateLimiter := mocks.RateLimiter{}
+	rateLimiter.On("Allow", mock.Anything, "ratelimit.tasks.save.key."+auth.HashAPIKey("secret"), limit).
+		Return(ratelimit.Result{Allowed: false, Limit: 10, RetryAfter: 1500 * time.Millisecond, ResetAfter: 2 * time.Second}, nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:write")
+	a.Authorizer = buildAuthorizer(true)
+	a.RateLimiter = &rateLimiter
+	a.RateLimits = map[string]ratelimit.Limit{app.RouteTasksSave: limit}
+
+	// Make request
+	req, err := http.NewRequest(http.MethodPost, "/tasks", strings.NewReader("{\"description\": \"Buy milk\"}"))
+	require.NoError(t, err)
+	req.Header.Set(auth.APIKeyHeader, "secret")
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusTooManyRequests, res.Result().StatusCode)
+	assert.Equal(t, "10", res.Result().Header.Get("RateLimit-Limit"))
+	assert.Equal(t, "0", res.Result().Header.Get("RateLimit-Remaining"))
+	assert.Equal(t, "2", res.Result().Header.Get("RateLimit-Reset"))
+	assert.Equal(t, "2", res.Result().Header.Get("Retry-After"))
+	assert.JSONEq(t, "{\"message\": \"rate limit exceeded\"}", res.Body.String())
+
+	rateLimiter.AssertExpectations(t)
+	tskMgr.AssertExpectations(t)
+}
+
+func TestRateLimitError(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	rateLimiter := mocks.RateLimiter{}
+	rateLimiter.On("Allow", mock.Anything, mock.Anything, mock.Anything).Return(ratelimit.Result{}, errors.New("failed"))
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+	a.RateLimiter = &rateLimiter
+	a.RateLimits = map[string]ratelimit.Limit{app.RouteTasksGet: ratelimit.PerSecond(10)}
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/tasks/%s", tsk.ID), nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	assert.Empty(t, res.Result().Header.Get("RateLimit-Limit"))
+}
+
+func TestRateLimitRouteWithoutLimit(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	rateLimiter := mocks.RateLimiter{}
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+	a.RateLimiter = &rateLimiter
+	a.RateLimits = map[string]ratelimit.Limit{app.RouteTasksSave: ratelimit.PerSecond(10)}
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/tasks/%s", tsk.ID), nil)
+	require.NoError(t, err)
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	assert.Empty(t, res.Result().Header.Get("RateLimit-Limit"))
+
+	rateLimiter.AssertExpectations(t)
+}
+
+func TestRateLimitUnauthenticatedExceeded(t *testing.T) {
+	limit := ratelimit.PerSecond(100)
+
+	// Set up relevant server dependencies
+	authenticator := mocks.Authenticator{}
+
+	rateLimiter := mocks.RateLimiter{}
+	rateLimiter.On("Allow", mock.Anything, "ratelimit.authenticate.ip.192.0.2.1", limit).
+		Return(ratelimit.Result{Allowed: false, Limit: 100, RetryAfter: 10 * time.Millisecond, ResetAfter: time.Second}, nil)
+
+	// Set up server
+	a := app.New()
+	a.Authenticator = &authenticator
+	a.RateLimiter = &rateLimiter
+	a.RateLimits = map[string]ratelimit.Limit{app.RouteAuthenticate: limit}
+
+	// Make request without credentials
+	req, err := http.NewRequest(http.MethodGet, "/tasks", nil)
+	require.NoError(t, err)
+	req.RemoteAddr = "192.0.2.1:1234"
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusTooManyRequests, res.Result().StatusCode)
+	assert.Equal(t, "1", res.Result().Header.Get("Retry-After"))
+	assert.JSONEq(t, "{\"message\": \"rate limit exceeded\"}", res.Body.String())
+
+	rateLimiter.AssertExpectations(t)
+	authenticator.AssertNotCalled(t, "Authenticate", mock.Anything)
+}
+
+func TestRateLimitRemoteAddrBeforeRoute(t *testing.T) {
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+
+	addrLimit := ratelimit.PerSecond(100)
+	routeLimit := ratelimit.PerSecond(10)
+
+	// Set up relevant server dependencies
+	tskMgr := mocks.TaskManager{}
+	tskMgr.On("Get", mock.Anything, "tenant-1", tsk.ID).Return(tsk, nil)
+
+	rateLimiter := mocks.RateLimiter{}
+	rateLimiter.On("Allow", mock.Anything, "ratelimit.authenticate.ip.2001:db8::1", addrLimit).
+		Return(ratelimit.Result{Allowed: true, Limit: 100, Remaining: 99}, nil)
+	rateLimiter.On("Allow", mock.Anything, "ratelimit.tasks.get.principal.tenant-1.user-1", routeLimit).
+		Return(ratelimit.Result{Allowed: true, Limit: 10, Remaining: 9}, nil)
+
+	// Set up server
+	a := app.New()
+	a.TaskManager = &tskMgr
+	a.Authenticator = buildAuthenticator("tasks:read")
+	a.Authorizer = buildAuthorizer(true)
+	a.RateLimiter = &rateLimiter
+	a.RateLimits = map[string]ratelimit.Limit{app.RouteAuthenticate: addrLimit, app.RouteTasksGet: routeLimit}
+
+	// Make request
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/tasks/%s", tsk.ID), nil)
+	require.NoError(t, err)
+	req.RemoteAddr = "[2001:db8::1]:1234"
+	res := httptest.NewRecorder()
+	a.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	assert.Equal(t, "10", res.Result().Header.Get("RateLimit-Limit"))
+
+	rateLimiter.AssertExpectations(t)
+}
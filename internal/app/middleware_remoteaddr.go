@@ -0,0 +1,36 @@
+package app
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/rs/zerolog"
+)
+
+type remoteAddrContextKey struct{}
+
+// remoteAddrHandler creates middleware that adds the request's remote address to the request logger as fieldKey, the
+// same as hlog.RemoteAddrHandler, and also keeps it in the request context so that the address that is logged can be
+// used to identify the client. Must be used after hlog.NewHandler.
+func remoteAddrHandler(fieldKey string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.RemoteAddr != "" {
+				log := zerolog.Ctx(req.Context())
+				log.UpdateContext(func(c zerolog.Context) zerolog.Context {
+					return c.Str(fieldKey, req.RemoteAddr)
+				})
+
+				req = req.WithContext(context.WithValue(req.Context(), remoteAddrContextKey{}, req.RemoteAddr))
+			}
+
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// remoteAddr retrieves the remote address that was logged for the request. Empty if there was not one.
+func remoteAddr(req *http.Request) string {
+	addr, _ := req.Context().Value(remoteAddrContextKey{}).(string)
+	return addr
+}
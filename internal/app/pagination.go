@@ -0,0 +1,41 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+const (
+	// defaultPageLimit is the number of items returned per page when the client does not specify a limit.
+	defaultPageLimit = 20
+	// maxPageLimit is the maximum number of items that may be returned per page.
+	maxPageLimit = 100
+	// maxPagedResults is the maximum number of items that may be paged through for a single query.
+	maxPagedResults = 1000
+)
+
+// pageParams parses the limit and offset query parameters used to paginate through results.
+func pageParams(params url.Values) (limit int, offset int, err error) {
+	limit, err = intParam(params.Get("limit"), defaultPageLimit)
+	if err != nil || limit < 1 || limit > maxPageLimit {
+		return 0, 0, fmt.Errorf("query parameter 'limit' must be between 1 and %d", maxPageLimit)
+	}
+
+	offset, err = intParam(params.Get("offset"), 0)
+	if err != nil || offset < 0 {
+		return 0, 0, errors.New("query parameter 'offset' must not be negative")
+	}
+
+	return limit, offset, nil
+}
+
+// intParam parses an integer query parameter, falling back on the default value when the parameter is not provided
+func intParam(value string, defaultValue int) (int, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	return strconv.Atoi(value)
+}
@@ -15,6 +15,8 @@ func (a *app) routes() {
 
 	// Set up logging middleware
 	a.router.Use(hlog.NewHandler(log.Logger))
+	// Identify every request so that logs and the changes made in it can be tied together
+	a.router.Use(hlog.RequestIDHandler("request_id", "X-Request-Id"))
 	a.router.Use(hlog.AccessHandler(func(r *http.Request, status, size int, duration time.Duration) {
 		hlog.FromRequest(r).
 			Info().
@@ -25,15 +27,121 @@ func (a *app) routes() {
 			Dur("duration", duration).
 			Msg("Access")
 	}))
-	a.router.Use(hlog.RemoteAddrHandler("ip"))
+	a.router.Use(remoteAddrHandler("ip"))
 	a.router.Use(hlog.UserAgentHandler("user_agent"))
 	a.router.Use(hlog.RefererHandler("referer"))
 
+	a.router.Get("/startup", a.handleStartup())
 	a.router.Get("/liveness", a.handleLiveness())
 	a.router.Get("/readiness", a.handleReadiness())
 
-	a.router.Get("/tasks/{id}", a.handleTaskGet())
-	a.router.Post("/tasks", a.handleTaskSave())
+	a.router.Group(func(r chi.Router) {
+		// Limit clients by address before authenticating them so that floods of requests with bad credentials are
+		// turned away without verifying each one
+		r.Use(a.rateLimitRemoteAddr(RouteAuthenticate))
+		r.Use(a.authenticate)
+
+		r.With(a.rateLimit(RouteTasksBatchGet), a.requireScope(scopeTasksRead)).
+			Get("/tasks:batchGet", a.handleTaskBatchGet())
+		r.With(a.rateLimit(RouteTasksBatchCreate), a.requireScope(scopeTasksWrite), a.idempotent).
+			Post("/tasks:batchCreate", a.handleTaskBatchCreate())
+		r.With(a.rateLimit(RouteTasksSort), a.requireScope(scopeTasksRead)).
+			Get("/tasks:sort", a.handleTaskSort())
+		r.With(a.rateLimit(RouteTasksList), a.requireScope(scopeTasksRead)).
+			Get("/tasks", a.handleTaskList())
+		r.With(a.rateLimit(RouteTasksSearch), a.requireScope(scopeTasksRead)).
+			Get("/tasks/search", a.handleTaskSearch())
+		r.With(a.rateLimit(RouteTasksEvents), a.requireScope(scopeTasksRead)).
+			Get("/tasks/events", a.handleTaskEventStream())
+		r.With(a.rateLimit(RouteTasksCollaboration), a.requireScope(scopeTasksRead)).
+			Get("/tasks/collaboration", a.handleTaskCollaboration())
+		r.With(a.rateLimit(RouteTasksGet), a.requireScope(scopeTasksRead)).
+			Get("/tasks/{id}", a.handleTaskGet())
+		r.With(a.rateLimit(RouteTasksPermissions), a.requireScope(scopeTasksRead)).
+			Get("/tasks/{id}/permissions", a.handleTaskPermissions())
+		r.With(a.rateLimit(RouteTasksSubtasks), a.requireScope(scopeTasksRead)).
+			Get("/tasks/{id}/subtasks", a.handleTaskSubtaskList())
+		r.With(a.rateLimit(RouteTasksOccurrences), a.requireScope(scopeTasksRead)).
+			Get("/tasks/{id}/occurrences", a.handleTaskOccurrenceList())
+		r.With(a.rateLimit(RouteTasksHistory), a.requireScope(scopeTasksRead)).
+			Get("/tasks/{id}/history", a.handleTaskHistoryList())
+		r.With(a.rateLimit(RouteTasksRevert), a.requireScope(scopeTasksWrite)).
+			Post("/tasks/{id}/revert", a.handleTaskRevert())
+		r.With(a.rateLimit(RouteCommentsList), a.requireScope(scopeTasksRead)).
+			Get("/tasks/{id}/comments", a.handleTaskCommentList())
+		r.With(a.rateLimit(RouteCommentsGet), a.requireScope(scopeTasksRead)).
+			Get("/tasks/{id}/comments/{commentId}", a.handleTaskCommentGet())
+		r.With(a.rateLimit(RouteCommentsSave), a.requireScope(scopeTasksWrite), a.idempotent).
+			Post("/tasks/{id}/comments", a.handleTaskCommentSave())
+		r.With(a.rateLimit(RouteCommentsUpdate), a.requireScope(scopeTasksWrite)).
+			Put("/tasks/{id}/comments/{commentId}", a.handleTaskCommentUpdate())
+		r.With(a.rateLimit(RouteCommentsDelete), a.requireScope(scopeTasksWrite)).
+			Delete("/tasks/{id}/comments/{commentId}", a.handleTaskCommentDelete())
+		r.With(a.rateLimit(RouteAttachmentsList), a.requireScope(scopeTasksRead)).
+			Get("/tasks/{id}/attachments", a.handleTaskAttachmentList())
+		r.With(a.rateLimit(RouteAttachmentsGet), a.requireScope(scopeTasksRead)).
+			Get("/tasks/{id}/attachments/{attachmentId}", a.handleTaskAttachmentGet())
+		r.With(a.rateLimit(RouteAttachmentsContent), a.requireScope(scopeTasksRead)).
+			Get("/tasks/{id}/attachments/{attachmentId}/content", a.handleTaskAttachmentDownload())
+		// Not idempotent since idempotency buffers the whole request body in memory
+		r.With(a.rateLimit(RouteAttachmentsSave), a.requireScope(scopeTasksWrite)).
+			Post("/tasks/{id}/attachments", a.handleTaskAttachmentSave())
+		r.With(a.rateLimit(RouteAttachmentsDelete), a.requireScope(scopeTasksWrite)).
+			Delete("/tasks/{id}/attachments/{attachmentId}", a.handleTaskAttachmentDelete())
+		r.With(a.rateLimit(RouteTasksBlockers), a.requireScope(scopeTasksWrite)).
+			Put("/tasks/{id}/blockers/{blockerId}", a.handleTaskBlockerSave())
+		r.With(a.rateLimit(RouteTasksBlockers), a.requireScope(scopeTasksWrite)).
+			Delete("/tasks/{id}/blockers/{blockerId}", a.handleTaskBlockerDelete())
+		r.With(a.rateLimit(RouteTasksSave), a.requireScope(scopeTasksWrite), a.idempotent).
+			Post("/tasks", a.handleTaskSave())
+		r.With(a.rateLimit(RouteTasksUpdate), a.requireScope(scopeTasksWrite)).
+			Put("/tasks/{id}", a.handleTaskUpdate())
+		r.With(a.rateLimit(RouteTasksDelete), a.requireScope(scopeTasksWrite)).
+			Delete("/tasks/{id}", a.handleTaskDelete())
+
+		r.With(a.rateLimit(RouteTagsList), a.requireScope(scopeTasksRead)).
+			Get("/tags", a.handleTagList())
+		r.With(a.rateLimit(RouteTagsGet), a.requireScope(scopeTasksRead)).
+			Get("/tags/{id}", a.handleTagGet())
+		r.With(a.rateLimit(RouteTagsSave), a.requireScope(scopeTasksWrite), a.idempotent).
+			Post("/tags", a.handleTagSave())
+		r.With(a.rateLimit(RouteTagsUpdate), a.requireScope(scopeTasksWrite)).
+			Put("/tags/{id}", a.handleTagUpdate())
+		r.With(a.rateLimit(RouteTagsDelete), a.requireScope(scopeTasksWrite)).
+			Delete("/tags/{id}", a.handleTagDelete())
+
+		r.With(a.rateLimit(RouteProjectsList), a.requireScope(scopeTasksRead)).
+			Get("/projects", a.handleProjectList())
+		r.With(a.rateLimit(RouteProjectsGet), a.requireScope(scopeTasksRead)).
+			Get("/projects/{id}", a.handleProjectGet())
+		r.With(a.rateLimit(RouteProjectsSave), a.requireScope(scopeTasksWrite), a.idempotent).
+			Post("/projects", a.handleProjectSave())
+		r.With(a.rateLimit(RouteProjectsUpdate), a.requireScope(scopeTasksWrite)).
+			Put("/projects/{id}", a.handleProjectUpdate())
+		r.With(a.rateLimit(RouteProjectsArchive), a.requireScope(scopeTasksWrite)).
+			Post("/projects/{id}:archive", a.handleProjectArchive())
+		r.With(a.rateLimit(RouteProjectsArchive), a.requireScope(scopeTasksWrite)).
+			Post("/projects/{id}:unarchive", a.handleProjectUnarchive())
+		r.With(a.rateLimit(RouteProjectTasksList), a.requireScope(scopeTasksRead)).
+			Get("/projects/{id}/tasks", a.handleProjectTaskList())
+		r.With(a.rateLimit(RouteProjectTasksSave), a.requireScope(scopeTasksWrite), a.idempotent).
+			Post("/projects/{id}/tasks", a.handleProjectTaskSave())
+
+		r.With(a.rateLimit(RouteWebhooksList), a.requireScope(scopeTasksRead)).
+			Get("/webhooks", a.handleWebhookList())
+		r.With(a.rateLimit(RouteWebhooksGet), a.requireScope(scopeTasksRead)).
+			Get("/webhooks/{id}", a.handleWebhookGet())
+		r.With(a.rateLimit(RouteWebhooksSave), a.requireScope(scopeTasksWrite), a.idempotent).
+			Post("/webhooks", a.handleWebhookSave())
+		r.With(a.rateLimit(RouteWebhooksUpdate), a.requireScope(scopeTasksWrite)).
+			Put("/webhooks/{id}", a.handleWebhookUpdate())
+		r.With(a.rateLimit(RouteWebhooksDelete), a.requireScope(scopeTasksWrite)).
+			Delete("/webhooks/{id}", a.handleWebhookDelete())
+		r.With(a.rateLimit(RouteWebhooksDeliveries), a.requireScope(scopeTasksRead)).
+			Get("/webhooks/{id}/deliveries", a.handleWebhookDeliveryList())
+		r.With(a.rateLimit(RouteWebhooksDeliveries), a.requireScope(scopeTasksWrite)).
+			Post("/webhooks/{id}/deliveries/{deliveryId}:redeliver", a.handleWebhookRedeliver())
+	})
 
 	a.router.NotFound(a.handleNotFound())
 	a.router.MethodNotAllowed(a.handleMethodNotAllowed())
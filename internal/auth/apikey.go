@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// APIKey is a static credential. Only the hash of the key is stored so that a leaked configuration file does not leak
+// usable credentials.
+type APIKey struct {
+	// ID of the key. Used to refer to the key without revealing it.
+	ID string `json:"id"`
+	// Hash is the hex-encoded SHA-256 hash of the key, as produced by HashAPIKey.
+	Hash string `json:"hash"`
+	// Subject is the principal that the key belongs to.
+	Subject string `json:"subject"`
+	// TenantID is the tenant that the principal belongs to.
+	TenantID string `json:"tenantId"`
+	// Scopes are the permissions granted to the key.
+	Scopes []string `json:"scopes"`
+	// Roles are the roles granted to the principal within its tenant.
+	Roles []string `json:"roles"`
+}
+
+// APIKeyStore verifies API keys.
+type APIKeyStore struct {
+	keys []APIKey
+}
+
+// HashAPIKey hashes an API key for storage.
+func HashAPIKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+// NewAPIKeyStore creates a store containing the API keys. The returned pointer will never be nil.
+func NewAPIKeyStore(keys ...APIKey) *APIKeyStore {
+	return &APIKeyStore{keys: keys}
+}
+
+// LoadAPIKeyStore reads a JSON file containing an array of API keys. The returned pointer will never be nil if the
+// error is nil.
+func LoadAPIKeyStore(path string) (*APIKeyStore, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []APIKey
+	err = json.Unmarshal(raw, &keys)
+	if err != nil {
+		return nil, fmt.Errorf("invalid API key file %s: %w", path, err)
+	}
+
+	for _, key := range keys {
+		if key.Hash == "" || key.Subject == "" || key.TenantID == "" {
+			return nil, fmt.Errorf("invalid API key file %s: key %s must have a hash, subject, and tenantId", path, key.ID)
+		}
+	}
+
+	return NewAPIKeyStore(keys...), nil
+}
+
+// Validate verifies the API key and returns the principal that it belongs to.
+func (s *APIKeyStore) Validate(key string) (*Principal, error) {
+	hash := []byte(HashAPIKey(key))
+
+	// Compare against every key in constant time so that timing does not reveal anything about the stored hashes
+	var match *APIKey
+	for i := range s.keys {
+		if subtle.ConstantTimeCompare(hash, []byte(s.keys[i].Hash)) == 1 {
+			match = &s.keys[i]
+		}
+	}
+
+	if match == nil {
+		return nil, fmt.Errorf("%w: unknown API key", ErrInvalidCredentials)
+	}
+
+	return &Principal{Subject: match.Subject, TenantID: match.TenantID, Scopes: match.Scopes, Roles: match.Roles}, nil
+}
@@ -0,0 +1,79 @@
+package auth_test
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/jaredpetersen/go-rest-template/internal/auth"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashAPIKey(t *testing.T) {
+	assert.Equal(t, "2bb80d537b1da3e38bd30361aa855686bde0eacd7162fef6a25fe97bf527a25b", auth.HashAPIKey("secret"))
+}
+
+func TestAPIKeyStoreValidate(t *testing.T) {
+	store := auth.NewAPIKeyStore(
+		auth.APIKey{ID: "importer", Hash: auth.HashAPIKey("importer-secret"), Subject: "importer", TenantID: "tenant-1"},
+		auth.APIKey{
+			ID:       "reporter",
+			Hash:     auth.HashAPIKey("reporter-secret"),
+			Subject:  "reporter",
+			TenantID: "tenant-2",
+			Scopes:   []string{"tasks:read"},
+			Roles:    []string{"viewer"},
+		},
+	)
+
+	p, err := store.Validate("reporter-secret")
+	require.NoError(t, err, "Returned error")
+	assert.Equal(t, &auth.Principal{Subject: "reporter", TenantID: "tenant-2", Scopes: []string{"tasks:read"}, Roles: []string{"viewer"}}, p)
+
+	p, err = store.Validate("unknown-secret")
+	assert.True(t, errors.Is(err, auth.ErrInvalidCredentials), "Incorrect error")
+	assert.Nil(t, p, "Returned principal")
+}
+
+func TestLoadAPIKeyStore(t *testing.T) {
+	keys := []auth.APIKey{
+		{ID: "importer", Hash: auth.HashAPIKey("importer-secret"), Subject: "importer", TenantID: "tenant-1", Scopes: []string{"tasks:write"}},
+	}
+	raw, err := json.Marshal(keys)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "apikeys.json")
+	require.NoError(t, os.WriteFile(path, raw, 0600))
+
+	store, err := auth.LoadAPIKeyStore(path)
+	require.NoError(t, err, "Returned error")
+
+	p, err := store.Validate("importer-secret")
+	require.NoError(t, err, "Returned error")
+	assert.Equal(t, &auth.Principal{Subject: "importer", TenantID: "tenant-1", Scopes: []string{"tasks:write"}}, p)
+}
+
+func TestLoadAPIKeyStoreInvalid(t *testing.T) {
+	var tests = []string{
+		`not json`,
+		`[{"id": "importer", "subject": "importer", "tenantId": "tenant-1"}]`,
+		`[{"id": "importer", "hash": "abc", "tenantId": "tenant-1"}]`,
+		`[{"id": "importer", "hash": "abc", "subject": "importer"}]`,
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "apikeys.json")
+		require.NoError(t, os.WriteFile(path, []byte(tt), 0600))
+
+		store, err := auth.LoadAPIKeyStore(path)
+		assert.Error(t, err, "Did not return error for %s", tt)
+		assert.Nil(t, store, "Returned store")
+	}
+
+	store, err := auth.LoadAPIKeyStore(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err, "Did not return error")
+	assert.Nil(t, store, "Returned store")
+}
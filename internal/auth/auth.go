@@ -0,0 +1,91 @@
+// Package auth authenticates requests using JWT bearer tokens or static API keys.
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// ErrMissingCredentials indicates that the request did not provide any credentials.
+var ErrMissingCredentials = errors.New("missing credentials")
+
+// ErrInvalidCredentials indicates that the request provided credentials but they could not be verified.
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// APIKeyHeader is the request header used to provide an API key.
+const APIKeyHeader = "X-API-Key"
+
+// Principal is an authenticated user or service.
+type Principal struct {
+	// Subject uniquely identifies the principal within its tenant.
+	Subject string
+	// TenantID identifies the tenant that the principal belongs to. Principals may only access their tenant's data.
+	TenantID string
+	// Scopes are the permissions that have been granted to the principal.
+	Scopes []string
+	// Roles are the roles that have been granted to the principal within its tenant.
+	Roles []string
+}
+
+// HasScope indicates whether or not the principal has been granted the scope.
+func (p Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of the context that carries the principal.
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// FromContext retrieves the principal from the context. If the context does not have a principal, nil is returned.
+func FromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(contextKey{}).(*Principal)
+	return p
+}
+
+// Authenticator determines who is making a request.
+//
+// Requests may authenticate with a JWT in the Authorization header as a bearer token or with an API key in the
+// X-API-Key header. A mechanism is disabled when it is not configured.
+type Authenticator struct {
+	JWT     *JWTValidator
+	APIKeys *APIKeyStore
+}
+
+// Authenticate verifies the credentials provided by the request and returns the principal that they belong to.
+//
+// ErrMissingCredentials is returned when the request does not provide any credentials. An error wrapping
+// ErrInvalidCredentials is returned when the credentials cannot be verified.
+func (a Authenticator) Authenticate(req *http.Request) (*Principal, error) {
+	if authorization := req.Header.Get("Authorization"); authorization != "" {
+		const prefix = "bearer "
+		if len(authorization) <= len(prefix) || !strings.EqualFold(authorization[:len(prefix)], prefix) {
+			return nil, ErrInvalidCredentials
+		}
+		if a.JWT == nil {
+			return nil, ErrInvalidCredentials
+		}
+
+		return a.JWT.Validate(authorization[len(prefix):])
+	}
+
+	if apiKey := req.Header.Get(APIKeyHeader); apiKey != "" {
+		if a.APIKeys == nil {
+			return nil, ErrInvalidCredentials
+		}
+
+		return a.APIKeys.Validate(apiKey)
+	}
+
+	return nil, ErrMissingCredentials
+}
@@ -0,0 +1,229 @@
+package auth_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/jaredpetersen/go-rest-template/internal/auth"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// signingKey is a locally generated key used to sign test tokens
+type signingKey struct {
+	id     string
+	method jwt.SigningMethod
+	key    interface{}
+	jwk    map[string]string
+}
+
+func encodeSegment(raw []byte) string {
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func generateHMACKey(t *testing.T, id string) signingKey {
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	require.NoError(t, err, "Failed to generate HMAC secret")
+
+	return signingKey{
+		id:     id,
+		method: jwt.SigningMethodHS256,
+		key:    secret,
+		jwk:    map[string]string{"kty": "oct", "kid": id, "alg": "HS256", "k": encodeSegment(secret)},
+	}
+}
+
+func generateRSAKey(t *testing.T, id string) signingKey {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err, "Failed to generate RSA key")
+
+	return signingKey{
+		id:     id,
+		method: jwt.SigningMethodRS256,
+		key:    privateKey,
+		jwk: map[string]string{
+			"kty": "RSA",
+			"kid": id,
+			"alg": "RS256",
+			"n":   encodeSegment(privateKey.N.Bytes()),
+			"e":   encodeSegment(big.NewInt(int64(privateKey.E)).Bytes()),
+		},
+	}
+}
+
+func generateECKey(t *testing.T, id string) signingKey {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err, "Failed to generate EC key")
+
+	return signingKey{
+		id:     id,
+		method: jwt.SigningMethodES256,
+		key:    privateKey,
+		jwk: map[string]string{
+			"kty": "EC",
+			"kid": id,
+			"alg": "ES256",
+			"crv": "P-256",
+			"x":   encodeSegment(privateKey.X.FillBytes(make([]byte, 32))),
+			"y":   encodeSegment(privateKey.Y.FillBytes(make([]byte, 32))),
+		},
+	}
+}
+
+// writeJWKS writes the public portion of the signing keys to a JWKS file
+func writeJWKS(t *testing.T, path string, keys ...signingKey) {
+	jwks := struct {
+		Keys []map[string]string `json:"keys"`
+	}{}
+	for _, key := range keys {
+		jwks.Keys = append(jwks.Keys, key.jwk)
+	}
+
+	raw, err := json.Marshal(jwks)
+	require.NoError(t, err, "Failed to marshal JWKS")
+
+	err = os.WriteFile(path, raw, 0600)
+	require.NoError(t, err, "Failed to write JWKS file")
+}
+
+func signToken(t *testing.T, key signingKey, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.id
+
+	signed, err := token.SignedString(key.key)
+	require.NoError(t, err, "Failed to sign token")
+
+	return signed
+}
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub":       "user-1",
+		"tenant_id": "tenant-1",
+		"scope":     "tasks:read tasks:write",
+		"exp":       time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func TestPrincipalHasScope(t *testing.T) {
+	p := auth.Principal{Subject: "user-1", Scopes: []string{"tasks:read"}}
+
+	assert.True(t, p.HasScope("tasks:read"))
+	assert.False(t, p.HasScope("tasks:write"))
+}
+
+func TestContext(t *testing.T) {
+	ctx := context.Background()
+
+	assert.Nil(t, auth.FromContext(ctx), "Principal exists before being set")
+
+	p := auth.Principal{Subject: "user-1"}
+	ctx = auth.NewContext(ctx, &p)
+
+	assert.Equal(t, &p, auth.FromContext(ctx), "Incorrect principal")
+}
+
+func TestAuthenticateJWT(t *testing.T) {
+	key := generateHMACKey(t, "key-1")
+	jwksPath := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, jwksPath, key)
+
+	ks, err := auth.LoadKeySet(jwksPath)
+	require.NoError(t, err, "Failed to load key set")
+
+	authenticator := auth.Authenticator{JWT: &auth.JWTValidator{Keys: ks}}
+
+	req, err := http.NewRequest(http.MethodGet, "/tasks", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+signToken(t, key, validClaims()))
+
+	p, err := authenticator.Authenticate(req)
+	require.NoError(t, err, "Returned error")
+	assert.Equal(t, &auth.Principal{Subject: "user-1", TenantID: "tenant-1", Scopes: []string{"tasks:read", "tasks:write"}}, p)
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	apiKeys := auth.NewAPIKeyStore(auth.APIKey{
+		ID:       "importer",
+		Hash:     auth.HashAPIKey("secret"),
+		Subject:  "importer",
+		TenantID: "tenant-1",
+		Scopes:   []string{"tasks:write"},
+	})
+
+	authenticator := auth.Authenticator{APIKeys: apiKeys}
+
+	req, err := http.NewRequest(http.MethodGet, "/tasks", nil)
+	require.NoError(t, err)
+	req.Header.Set(auth.APIKeyHeader, "secret")
+
+	p, err := authenticator.Authenticate(req)
+	require.NoError(t, err, "Returned error")
+	assert.Equal(t, &auth.Principal{Subject: "importer", TenantID: "tenant-1", Scopes: []string{"tasks:write"}}, p)
+}
+
+func TestAuthenticateErrors(t *testing.T) {
+	apiKeys := auth.NewAPIKeyStore(auth.APIKey{ID: "importer", Hash: auth.HashAPIKey("secret"), Subject: "importer"})
+
+	var tests = []struct {
+		authenticator auth.Authenticator
+		header        string
+		value         string
+		expected      error
+	}{
+		{
+			authenticator: auth.Authenticator{APIKeys: apiKeys},
+			expected:      auth.ErrMissingCredentials,
+		},
+		{
+			authenticator: auth.Authenticator{APIKeys: apiKeys},
+			header:        "Authorization",
+			value:         "Basic dXNlcjpwYXNz",
+			expected:      auth.ErrInvalidCredentials,
+		},
+		{
+			authenticator: auth.Authenticator{APIKeys: apiKeys},
+			header:        "Authorization",
+			value:         "Bearer token",
+			expected:      auth.ErrInvalidCredentials,
+		},
+		{
+			authenticator: auth.Authenticator{APIKeys: apiKeys},
+			header:        auth.APIKeyHeader,
+			value:         "wrong",
+			expected:      auth.ErrInvalidCredentials,
+		},
+		{
+			authenticator: auth.Authenticator{},
+			header:        auth.APIKeyHeader,
+			value:         "secret",
+			expected:      auth.ErrInvalidCredentials,
+		},
+	}
+
+	for _, tt := range tests {
+		req, err := http.NewRequest(http.MethodGet, "/tasks", nil)
+		require.NoError(t, err)
+		if tt.header != "" {
+			req.Header.Set(tt.header, tt.value)
+		}
+
+		p, err := tt.authenticator.Authenticate(req)
+		assert.True(t, errors.Is(err, tt.expected), "Incorrect error %v", err)
+		assert.Nil(t, p, "Returned principal")
+	}
+}
@@ -0,0 +1,249 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Supported JWT signing algorithms.
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmES256 = "ES256"
+)
+
+// Key is a key used to verify JWT signatures.
+type Key struct {
+	// ID of the key, matched against the kid header of the JWT.
+	ID string
+	// Algorithm that the key is used with.
+	Algorithm string
+	// Key is a []byte for HS256, *rsa.PublicKey for RS256, and *ecdsa.PublicKey for ES256.
+	Key interface{}
+}
+
+// jwk is a JSON Web Key as defined by RFC 7517.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	// oct
+	K string `json:"k"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// jwks is a JSON Web Key Set as defined by RFC 7517.
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+// KeySet is a set of JWT verification keys loaded from a local JSON Web Key Set (JWKS) file.
+//
+// Keys are rotated by adding the new key to the file, signing new tokens with it, and removing the old key from the
+// file once tokens signed with it have expired. The file is re-read when it changes while the key set is being watched.
+type KeySet struct {
+	path    string
+	mtx     sync.RWMutex
+	keys    map[string]Key
+	modTime time.Time
+}
+
+// LoadKeySet reads the JWKS file at the path. The returned pointer will never be nil if the error is nil.
+func LoadKeySet(path string) (*KeySet, error) {
+	ks := &KeySet{path: path}
+	err := ks.Reload()
+	if err != nil {
+		return nil, err
+	}
+
+	return ks, nil
+}
+
+// Key retrieves a key by ID.
+func (ks *KeySet) Key(id string) (Key, bool) {
+	ks.mtx.RLock()
+	defer ks.mtx.RUnlock()
+
+	key, ok := ks.keys[id]
+	return key, ok
+}
+
+// Reload re-reads the JWKS file if it has been modified since it was last read. The existing keys are kept if the file
+// cannot be read or is invalid.
+func (ks *KeySet) Reload() error {
+	info, err := os.Stat(ks.path)
+	if err != nil {
+		return err
+	}
+
+	ks.mtx.RLock()
+	unchanged := info.ModTime().Equal(ks.modTime)
+	ks.mtx.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	raw, err := os.ReadFile(ks.path)
+	if err != nil {
+		return err
+	}
+
+	keys, err := parseJWKS(raw)
+	if err != nil {
+		return fmt.Errorf("invalid JWKS file %s: %w", ks.path, err)
+	}
+
+	ks.mtx.Lock()
+	ks.keys = keys
+	ks.modTime = info.ModTime()
+	ks.mtx.Unlock()
+
+	return nil
+}
+
+// Watch reloads the JWKS file on an interval in a separate goroutine until the context is done.
+func (ks *KeySet) Watch(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				err := ks.Reload()
+				if err != nil {
+					log.Error().Err(err).Str("path", ks.path).Msg("Failed to reload JWKS file")
+				}
+			}
+		}
+	}()
+}
+
+// parseJWKS parses a JSON Web Key Set into verification keys organized by key ID.
+func parseJWKS(raw []byte) (map[string]Key, error) {
+	var set jwks
+	err := json.Unmarshal(raw, &set)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]Key, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kid == "" {
+			return nil, errors.New("key is missing kid")
+		}
+		if _, ok := keys[k.Kid]; ok {
+			return nil, fmt.Errorf("duplicate kid %s", k.Kid)
+		}
+
+		key, err := parseJWK(k)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", k.Kid, err)
+		}
+
+		keys[k.Kid] = key
+	}
+
+	return keys, nil
+}
+
+// parseJWK converts a JSON Web Key into a verification key.
+func parseJWK(k jwk) (Key, error) {
+	key := Key{ID: k.Kid, Algorithm: k.Alg}
+
+	switch k.Kty {
+	case "oct":
+		if key.Algorithm == "" {
+			key.Algorithm = AlgorithmHS256
+		}
+		if key.Algorithm != AlgorithmHS256 {
+			return Key{}, fmt.Errorf("unsupported algorithm %s for key type oct", key.Algorithm)
+		}
+
+		secret, err := decodeSegment(k.K)
+		if err != nil {
+			return Key{}, err
+		}
+		key.Key = secret
+	case "RSA":
+		if key.Algorithm == "" {
+			key.Algorithm = AlgorithmRS256
+		}
+		if key.Algorithm != AlgorithmRS256 {
+			return Key{}, fmt.Errorf("unsupported algorithm %s for key type RSA", key.Algorithm)
+		}
+
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return Key{}, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return Key{}, err
+		}
+		key.Key = &rsa.PublicKey{N: n, E: int(e.Int64())}
+	case "EC":
+		if key.Algorithm == "" {
+			key.Algorithm = AlgorithmES256
+		}
+		if key.Algorithm != AlgorithmES256 || k.Crv != "P-256" {
+			return Key{}, fmt.Errorf("unsupported algorithm %s with curve %s for key type EC", key.Algorithm, k.Crv)
+		}
+
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return Key{}, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return Key{}, err
+		}
+		if !elliptic.P256().IsOnCurve(x, y) {
+			return Key{}, errors.New("point is not on curve P-256")
+		}
+		key.Key = &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+	default:
+		return Key{}, fmt.Errorf("unsupported key type %s", k.Kty)
+	}
+
+	return key, nil
+}
+
+// decodeSegment decodes a base64url encoded value without padding.
+func decodeSegment(value string) ([]byte, error) {
+	if value == "" {
+		return nil, errors.New("missing key material")
+	}
+
+	return base64.RawURLEncoding.DecodeString(value)
+}
+
+// decodeBigInt decodes a base64url encoded, big-endian unsigned integer.
+func decodeBigInt(value string) (*big.Int, error) {
+	raw, err := decodeSegment(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(raw), nil
+}
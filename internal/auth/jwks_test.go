@@ -0,0 +1,121 @@
+package auth_test
+
+import (
+	"context"
+	"github.com/jaredpetersen/go-rest-template/internal/auth"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadKeySet(t *testing.T) {
+	hmacKey := generateHMACKey(t, "hmac")
+	rsaKey := generateRSAKey(t, "rsa")
+	ecKey := generateECKey(t, "ec")
+
+	jwksPath := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, jwksPath, hmacKey, rsaKey, ecKey)
+
+	ks, err := auth.LoadKeySet(jwksPath)
+	require.NoError(t, err, "Returned error")
+	require.NotNil(t, ks, "Key set is nil")
+
+	var tests = []struct {
+		id        string
+		algorithm string
+	}{
+		{id: "hmac", algorithm: auth.AlgorithmHS256},
+		{id: "rsa", algorithm: auth.AlgorithmRS256},
+		{id: "ec", algorithm: auth.AlgorithmES256},
+	}
+
+	for _, tt := range tests {
+		key, ok := ks.Key(tt.id)
+		assert.True(t, ok, "Key %s not found", tt.id)
+		assert.Equal(t, tt.id, key.ID)
+		assert.Equal(t, tt.algorithm, key.Algorithm)
+		assert.NotNil(t, key.Key)
+	}
+
+	_, ok := ks.Key("unknown")
+	assert.False(t, ok, "Found unknown key")
+}
+
+func TestLoadKeySetInvalid(t *testing.T) {
+	var tests = []string{
+		`not json`,
+		`{"keys": [{"kty": "oct", "k": "c2VjcmV0"}]}`,
+		`{"keys": [{"kty": "oct", "kid": "a", "k": "c2VjcmV0"}, {"kty": "oct", "kid": "a", "k": "c2VjcmV0"}]}`,
+		`{"keys": [{"kty": "oct", "kid": "a", "alg": "HS512", "k": "c2VjcmV0"}]}`,
+		`{"keys": [{"kty": "oct", "kid": "a"}]}`,
+		`{"keys": [{"kty": "EC", "kid": "a", "crv": "P-384", "x": "AQ", "y": "AQ"}]}`,
+		`{"keys": [{"kty": "EC", "kid": "a", "crv": "P-256", "x": "AQ", "y": "AQ"}]}`,
+		`{"keys": [{"kty": "OKP", "kid": "a"}]}`,
+	}
+
+	for _, tt := range tests {
+		jwksPath := filepath.Join(t.TempDir(), "jwks.json")
+		err := os.WriteFile(jwksPath, []byte(tt), 0600)
+		require.NoError(t, err)
+
+		ks, err := auth.LoadKeySet(jwksPath)
+		assert.Error(t, err, "Did not return error for %s", tt)
+		assert.Nil(t, ks, "Returned key set")
+	}
+}
+
+func TestLoadKeySetMissingFile(t *testing.T) {
+	ks, err := auth.LoadKeySet(filepath.Join(t.TempDir(), "jwks.json"))
+	assert.Error(t, err, "Did not return error")
+	assert.Nil(t, ks, "Returned key set")
+}
+
+func TestKeySetWatchRotatesKeys(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	oldKey := generateHMACKey(t, "old")
+	newKey := generateHMACKey(t, "new")
+
+	jwksPath := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, jwksPath, oldKey)
+
+	ks, err := auth.LoadKeySet(jwksPath)
+	require.NoError(t, err, "Failed to load key set")
+	ks.Watch(ctx, time.Millisecond*10)
+
+	// Rotate the keys, making sure that the modification time changes
+	writeJWKS(t, jwksPath, newKey)
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(jwksPath, future, future))
+
+	assert.Eventually(t, func() bool {
+		_, hasNew := ks.Key("new")
+		_, hasOld := ks.Key("old")
+		return hasNew && !hasOld
+	}, time.Second, time.Millisecond*10, "Keys were not rotated")
+}
+
+func TestKeySetReloadKeepsKeysOnInvalidFile(t *testing.T) {
+	key := generateHMACKey(t, "key-1")
+
+	jwksPath := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, jwksPath, key)
+
+	ks, err := auth.LoadKeySet(jwksPath)
+	require.NoError(t, err, "Failed to load key set")
+
+	require.NoError(t, os.WriteFile(jwksPath, []byte("not json"), 0600))
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(jwksPath, future, future))
+
+	err = ks.Reload()
+	assert.Error(t, err, "Did not return error")
+
+	_, ok := ks.Key("key-1")
+	assert.True(t, ok, "Existing key was dropped")
+}
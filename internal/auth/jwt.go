@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// claims are the JWT claims used to build a principal.
+type claims struct {
+	jwt.RegisteredClaims
+	// Scope is a space-delimited list of scopes as defined by RFC 8693.
+	Scope string `json:"scope"`
+	// TenantID is the tenant that the subject belongs to.
+	TenantID string `json:"tenant_id"`
+	// Roles are the roles granted to the subject within its tenant.
+	Roles []string `json:"roles"`
+}
+
+// JWTValidator verifies JWT bearer tokens.
+type JWTValidator struct {
+	// Keys used to verify token signatures.
+	Keys *KeySet
+	// Issuer that tokens must be issued by. Not verified if empty.
+	Issuer string
+	// Audience that tokens must be intended for. Not verified if empty.
+	Audience string
+}
+
+// Validate verifies the token and returns the principal that it was issued to. Tokens must identify their signing key
+// with the kid header, be signed with HS256, RS256, or ES256, and have an expiration, subject, and tenant_id.
+func (v JWTValidator) Validate(token string) (*Principal, error) {
+	var c claims
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{AlgorithmHS256, AlgorithmRS256, AlgorithmES256}))
+	_, err := parser.ParseWithClaims(token, &c, v.keyFunc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+
+	now := time.Now()
+	if !c.VerifyExpiresAt(now, true) {
+		return nil, fmt.Errorf("%w: token is missing expiration", ErrInvalidCredentials)
+	}
+	if v.Issuer != "" && !c.VerifyIssuer(v.Issuer, true) {
+		return nil, fmt.Errorf("%w: token has incorrect issuer", ErrInvalidCredentials)
+	}
+	if v.Audience != "" && !c.VerifyAudience(v.Audience, true) {
+		return nil, fmt.Errorf("%w: token has incorrect audience", ErrInvalidCredentials)
+	}
+	if c.Subject == "" {
+		return nil, fmt.Errorf("%w: token is missing subject", ErrInvalidCredentials)
+	}
+	if c.TenantID == "" {
+		return nil, fmt.Errorf("%w: token is missing tenant", ErrInvalidCredentials)
+	}
+
+	return &Principal{Subject: c.Subject, TenantID: c.TenantID, Scopes: strings.Fields(c.Scope), Roles: c.Roles}, nil
+}
+
+// keyFunc looks up the key that the token claims to be signed with. The key's algorithm must match the token's
+// algorithm so that a public key cannot be used as an HMAC secret.
+func (v JWTValidator) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, errors.New("token is missing kid")
+	}
+
+	key, ok := v.Keys.Key(kid)
+	if !ok {
+		return nil, fmt.Errorf("unknown kid %s", kid)
+	}
+
+	if key.Algorithm != token.Method.Alg() {
+		return nil, fmt.Errorf("key %s cannot be used with algorithm %s", kid, token.Method.Alg())
+	}
+
+	return key.Key, nil
+}
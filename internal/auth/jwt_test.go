@@ -0,0 +1,118 @@
+package auth_test
+
+import (
+	"errors"
+	"github.com/jaredpetersen/go-rest-template/internal/auth"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWTValidatorValidate(t *testing.T) {
+	keys := []signingKey{
+		generateHMACKey(t, "hmac"),
+		generateRSAKey(t, "rsa"),
+		generateECKey(t, "ec"),
+	}
+
+	jwksPath := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, jwksPath, keys...)
+
+	ks, err := auth.LoadKeySet(jwksPath)
+	require.NoError(t, err, "Failed to load key set")
+
+	validator := auth.JWTValidator{Keys: ks, Issuer: "https://issuer.example.com", Audience: "tasks"}
+
+	for _, key := range keys {
+		claims := validClaims()
+		claims["iss"] = "https://issuer.example.com"
+		claims["aud"] = "tasks"
+		claims["roles"] = []string{"editor"}
+
+		p, err := validator.Validate(signToken(t, key, claims))
+		require.NoError(t, err, "Returned error for %s", key.id)
+		assert.Equal(t, &auth.Principal{
+			Subject:  "user-1",
+			TenantID: "tenant-1",
+			Scopes:   []string{"tasks:read", "tasks:write"},
+			Roles:    []string{"editor"},
+		}, p)
+	}
+}
+
+func TestJWTValidatorValidateInvalid(t *testing.T) {
+	key := generateRSAKey(t, "rsa")
+	unknownKey := generateRSAKey(t, "unknown")
+	impostorKey := generateRSAKey(t, "rsa")
+
+	jwksPath := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, jwksPath, key)
+
+	ks, err := auth.LoadKeySet(jwksPath)
+	require.NoError(t, err, "Failed to load key set")
+
+	validator := auth.JWTValidator{Keys: ks, Issuer: "https://issuer.example.com", Audience: "tasks"}
+
+	withClaims := func(modify func(claims jwt.MapClaims)) jwt.MapClaims {
+		claims := validClaims()
+		claims["iss"] = "https://issuer.example.com"
+		claims["aud"] = "tasks"
+		modify(claims)
+		return claims
+	}
+
+	var tests = map[string]string{
+		"malformed": "not.a.token",
+		"expired": signToken(t, key, withClaims(func(claims jwt.MapClaims) {
+			claims["exp"] = time.Now().Add(-time.Minute).Unix()
+		})),
+		"missing expiration": signToken(t, key, withClaims(func(claims jwt.MapClaims) {
+			delete(claims, "exp")
+		})),
+		"wrong issuer": signToken(t, key, withClaims(func(claims jwt.MapClaims) {
+			claims["iss"] = "https://evil.example.com"
+		})),
+		"wrong audience": signToken(t, key, withClaims(func(claims jwt.MapClaims) {
+			claims["aud"] = "billing"
+		})),
+		"missing subject": signToken(t, key, withClaims(func(claims jwt.MapClaims) {
+			delete(claims, "sub")
+		})),
+		"missing tenant": signToken(t, key, withClaims(func(claims jwt.MapClaims) {
+			delete(claims, "tenant_id")
+		})),
+		"unknown key":     signToken(t, unknownKey, withClaims(func(claims jwt.MapClaims) {})),
+		"wrong signature": signToken(t, impostorKey, withClaims(func(claims jwt.MapClaims) {})),
+		"missing kid": func() string {
+			token := jwt.NewWithClaims(jwt.SigningMethodRS256, withClaims(func(claims jwt.MapClaims) {}))
+			signed, err := token.SignedString(key.key)
+			require.NoError(t, err)
+			return signed
+		}(),
+		"algorithm confusion": func() string {
+			// Sign with HMAC using the public key material as the secret
+			token := jwt.NewWithClaims(jwt.SigningMethodHS256, withClaims(func(claims jwt.MapClaims) {}))
+			token.Header["kid"] = "rsa"
+			signed, err := token.SignedString([]byte(key.jwk["n"]))
+			require.NoError(t, err)
+			return signed
+		}(),
+		"unsupported algorithm": func() string {
+			token := jwt.NewWithClaims(jwt.SigningMethodNone, withClaims(func(claims jwt.MapClaims) {}))
+			token.Header["kid"] = "rsa"
+			signed, err := token.SignedString(jwt.UnsafeAllowNoneSignatureType)
+			require.NoError(t, err)
+			return signed
+		}(),
+	}
+
+	for name, token := range tests {
+		p, err := validator.Validate(token)
+		assert.True(t, errors.Is(err, auth.ErrInvalidCredentials), "Incorrect error for %s: %v", name, err)
+		assert.Nil(t, p, "Returned principal for %s", name)
+	}
+}
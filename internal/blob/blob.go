@@ -0,0 +1,129 @@
+// Package blob stores large binary objects, such as the files attached to tasks, outside of the database.
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrNotFound indicates that there is no blob stored under the key.
+var ErrNotFound = errors.New("blob not found")
+
+// ErrChecksumMismatch indicates that a blob was not stored because its content does not match its checksum.
+var ErrChecksumMismatch = errors.New("blob checksum mismatch")
+
+// Store is a place to keep blobs. Keys are slash-separated paths made up of letters, digits, and the characters in
+// "-_.", such as "tenant-1/task-1/attachment-1".
+type Store interface {
+	// Put stores the blob under the key, replacing any blob that is already stored under it. The size is the number of
+	// bytes in the blob and the checksum is the hex encoded SHA-256 digest of its content, which must match.
+	Put(ctx context.Context, key string, r io.Reader, size int64, checksum string) error
+	// Get retrieves length bytes of the blob stored under the key, starting at the offset. A negative length retrieves
+	// the rest of the blob. ErrNotFound is returned if there is no blob stored under the key.
+	Get(ctx context.Context, key string, offset int64, length int64) (io.ReadCloser, error)
+	// Delete removes the blob stored under the key. Deleting a blob that does not exist does nothing.
+	Delete(ctx context.Context, key string) error
+}
+
+// reader reads a blob of a known size on demand, retrieving it again from the store from wherever it is seeked to.
+type reader struct {
+	ctx    context.Context
+	store  Store
+	key    string
+	size   int64
+	offset int64
+	body   io.ReadCloser
+}
+
+// NewReader creates a reader for the blob stored under the key that can seek, such as for serving ranges of it with
+// http.ServeContent. Nothing is retrieved from the store until the blob is read, and seeking only retrieves the blob
+// again if it is read afterwards. The size must be the number of bytes in the blob.
+func NewReader(ctx context.Context, s Store, key string, size int64) io.ReadSeekCloser {
+	return &reader{ctx: ctx, store: s, key: key, size: size}
+}
+
+// Read reads from the blob at the current offset.
+func (r *reader) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+
+	if r.body == nil {
+		body, err := r.store.Get(r.ctx, r.key, r.offset, -1)
+		if err != nil {
+			return 0, err
+		}
+		r.body = body
+	}
+
+	n, err := r.body.Read(p)
+	r.offset += int64(n)
+	return n, err
+}
+
+// Seek changes the offset that the blob is read from next.
+func (r *reader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.size
+	default:
+		return 0, fmt.Errorf("invalid whence %d", whence)
+	}
+
+	if offset < 0 {
+		return 0, errors.New("negative offset")
+	}
+
+	if offset != r.offset {
+		err := r.Close()
+		if err != nil {
+			return 0, err
+		}
+		r.offset = offset
+	}
+
+	return r.offset, nil
+}
+
+// Close stops reading the blob.
+func (r *reader) Close() error {
+	if r.body == nil {
+		return nil
+	}
+
+	err := r.body.Close()
+	r.body = nil
+	return err
+}
+
+// validKey determines whether or not the key is a valid blob key.
+func validKey(key string) bool {
+	if key == "" {
+		return false
+	}
+
+	segmentStart := 0
+	for i := 0; i <= len(key); i++ {
+		if i == len(key) || key[i] == '/' {
+			segment := key[segmentStart:i]
+			if segment == "" || segment == "." || segment == ".." {
+				return false
+			}
+			segmentStart = i + 1
+			continue
+		}
+
+		c := key[i]
+		valid := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '-' || c == '_' || c == '.'
+		if !valid {
+			return false
+		}
+	}
+
+	return true
+}
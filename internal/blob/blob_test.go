@@ -0,0 +1,76 @@
+package blob_test
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/jaredpetersen/go-rest-template/internal/blob"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingStore counts the number of times that blobs are retrieved from the store
+type countingStore struct {
+	blob.FSStore
+	gets int
+}
+
+func (s *countingStore) Get(ctx context.Context, key string, offset int64, length int64) (io.ReadCloser, error) {
+	s.gets++
+	return s.FSStore.Get(ctx, key, offset, length)
+}
+
+func TestReader(t *testing.T) {
+	ctx := context.Background()
+
+	s := &countingStore{FSStore: blob.FSStore{Dir: t.TempDir()}}
+
+	content := "The quick brown fox"
+	err := s.Put(ctx, "fox.txt", strings.NewReader(content), int64(len(content)), checksum(content))
+	require.NoError(t, err, "Put returned error")
+
+	r := blob.NewReader(ctx, s, "fox.txt", int64(len(content)))
+	defer r.Close()
+
+	// Finding the size does not retrieve the blob
+	size, err := r.Seek(0, io.SeekEnd)
+	require.NoError(t, err, "Seek returned error")
+	assert.Equal(t, int64(len(content)), size, "Returned incorrect size")
+	assert.Equal(t, 0, s.gets, "Retrieved blob to find its size")
+
+	offset, err := r.Seek(10, io.SeekStart)
+	require.NoError(t, err, "Seek returned error")
+	assert.Equal(t, int64(10), offset, "Returned incorrect offset")
+
+	buf := make([]byte, 5)
+	_, err = io.ReadFull(r, buf)
+	require.NoError(t, err, "Read returned error")
+	assert.Equal(t, "brown", string(buf), "Read from incorrect offset")
+
+	// Seeking relative to the current offset picks up from where the last read ended
+	offset, err = r.Seek(1, io.SeekCurrent)
+	require.NoError(t, err, "Seek returned error")
+	assert.Equal(t, int64(16), offset, "Returned incorrect offset")
+
+	rest, err := io.ReadAll(r)
+	require.NoError(t, err, "Read returned error")
+	assert.Equal(t, "fox", string(rest), "Read from incorrect offset")
+	assert.Equal(t, 2, s.gets, "Did not retrieve blob again after seeking")
+
+	_, err = r.Seek(-1, io.SeekStart)
+	assert.Error(t, err, "Did not return error for negative offset")
+}
+
+func TestReaderReturnsStoreError(t *testing.T) {
+	ctx := context.Background()
+
+	s := blob.FSStore{Dir: t.TempDir()}
+
+	r := blob.NewReader(ctx, s, "missing.txt", 10)
+	defer r.Close()
+
+	_, err := io.ReadAll(r)
+	assert.ErrorIs(t, err, blob.ErrNotFound)
+}
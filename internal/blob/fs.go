@@ -0,0 +1,120 @@
+package blob
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// FSStore is a blob store that keeps each blob in a file on the local filesystem. Useful in development or when every
+// replica shares the same volume.
+type FSStore struct {
+	// Dir is the directory that the blobs are kept in. Created if it does not exist.
+	Dir string
+}
+
+// Put writes the blob to a temporary file and then moves it into place, so that a blob is never partially stored.
+// ErrChecksumMismatch is returned if the content does not match the size or checksum.
+func (s FSStore) Put(ctx context.Context, key string, r io.Reader, size int64, checksum string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	// Removing the temporary file fails harmlessly once it has been moved into place
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	hash := sha256.New()
+	n, err := io.Copy(io.MultiWriter(f, hash), r)
+	if err != nil {
+		return err
+	}
+	if n != size || hex.EncodeToString(hash.Sum(nil)) != checksum {
+		return ErrChecksumMismatch
+	}
+
+	err = f.Sync()
+	if err != nil {
+		return err
+	}
+
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
+
+// Get opens the blob's file. ErrNotFound is returned if the file does not exist.
+func (s FSStore) Get(ctx context.Context, key string, offset int64, length int64) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = f.Seek(offset, io.SeekStart)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if length < 0 {
+		return f, nil
+	}
+
+	return limitedReadCloser{Reader: io.LimitReader(f, length), Closer: f}, nil
+}
+
+// Delete removes the blob's file.
+func (s FSStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
+
+// path builds the path of the file that the blob is kept in
+func (s FSStore) path(key string) (string, error) {
+	if !validKey(key) {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+
+	return filepath.Join(s.Dir, filepath.FromSlash(key)), nil
+}
+
+// limitedReadCloser reads part of a file and closes the whole file.
+type limitedReadCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,96 @@
+package blob_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jaredpetersen/go-rest-template/internal/blob"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// checksum computes the hex encoded SHA-256 digest of the content
+func checksum(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// readAll reads the rest of the blob and closes it
+func readAll(t *testing.T, rc io.ReadCloser) string {
+	defer rc.Close()
+	content, err := io.ReadAll(rc)
+	require.NoError(t, err, "Failed to read blob")
+	return string(content)
+}
+
+func TestFSStore(t *testing.T) {
+	ctx := context.Background()
+
+	s := blob.FSStore{Dir: t.TempDir()}
+
+	content := "The quick brown fox"
+	err := s.Put(ctx, "attachments/fox.txt", strings.NewReader(content), int64(len(content)), checksum(content))
+	require.NoError(t, err, "Put returned error")
+
+	rc, err := s.Get(ctx, "attachments/fox.txt", 0, -1)
+	require.NoError(t, err, "Get returned error")
+	assert.Equal(t, content, readAll(t, rc), "Returned incorrect content")
+
+	rc, err = s.Get(ctx, "attachments/fox.txt", 4, 5)
+	require.NoError(t, err, "Get returned error")
+	assert.Equal(t, "quick", readAll(t, rc), "Returned incorrect range")
+
+	rc, err = s.Get(ctx, "attachments/fox.txt", 10, -1)
+	require.NoError(t, err, "Get returned error")
+	assert.Equal(t, "brown fox", readAll(t, rc), "Returned incorrect remainder")
+
+	err = s.Delete(ctx, "attachments/fox.txt")
+	require.NoError(t, err, "Delete returned error")
+
+	_, err = s.Get(ctx, "attachments/fox.txt", 0, -1)
+	assert.ErrorIs(t, err, blob.ErrNotFound)
+
+	// Deleting a blob that does not exist does nothing
+	err = s.Delete(ctx, "attachments/fox.txt")
+	assert.NoError(t, err, "Delete returned error for missing blob")
+}
+
+func TestFSStorePutChecksumMismatch(t *testing.T) {
+	ctx := context.Background()
+
+	dir := t.TempDir()
+	s := blob.FSStore{Dir: dir}
+
+	content := "The quick brown fox"
+	err := s.Put(ctx, "attachments/fox.txt", strings.NewReader(content), int64(len(content)), checksum("The lazy dog"))
+	assert.ErrorIs(t, err, blob.ErrChecksumMismatch)
+
+	err = s.Put(ctx, "attachments/fox.txt", strings.NewReader(content), 3, checksum(content))
+	assert.ErrorIs(t, err, blob.ErrChecksumMismatch)
+
+	// Neither the blob nor the temporary file are left behind
+	entries, err := os.ReadDir(filepath.Join(dir, "attachments"))
+	require.NoError(t, err)
+	assert.Empty(t, entries, "Left files behind")
+}
+
+func TestFSStoreInvalidKey(t *testing.T) {
+	ctx := context.Background()
+
+	s := blob.FSStore{Dir: t.TempDir()}
+
+	keys := []string{"", "../escape", "attachments/../../escape", "/absolute", "attachments//fox", "attachments/fox\x00"}
+	for _, key := range keys {
+		err := s.Put(ctx, key, strings.NewReader(""), 0, checksum(""))
+		assert.Error(t, err, "Did not return error for key %q", key)
+
+		_, err = s.Get(ctx, key, 0, -1)
+		assert.Error(t, err, "Did not return error for key %q", key)
+	}
+}
@@ -0,0 +1,231 @@
+package blob
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// emptyPayloadHash is the hex encoded SHA-256 digest of an empty request body.
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// S3Store is a blob store that keeps each blob as an object in a bucket of an S3-compatible object storage service,
+// such as Amazon S3 or MinIO. Requests are signed with AWS Signature Version 4 and address the bucket in the path, so
+// any endpoint works without DNS being set up for the bucket.
+type S3Store struct {
+	// Endpoint is the base URL of the service, such as https://s3.us-east-1.amazonaws.com or http://localhost:9000.
+	Endpoint        string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// Region that requests are signed for. Defaults to us-east-1.
+	Region string
+	// Client sends the requests. Defaults to http.DefaultClient.
+	Client *http.Client
+	// Now returns the time that requests are signed at. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// s3Error is the body of an error response from the service.
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+// Put uploads the blob as an object. The checksum is sent as the payload hash, so the service refuses to store content
+// that does not match it and ErrChecksumMismatch is returned.
+func (s S3Store) Put(ctx context.Context, key string, r io.Reader, size int64, checksum string) error {
+	req, err := s.newRequest(ctx, http.MethodPut, key, r)
+	if err != nil {
+		return err
+	}
+
+	// An empty body must not be sent, otherwise the request is sent with chunked encoding
+	req.ContentLength = size
+	if size == 0 {
+		req.Body = http.NoBody
+	}
+
+	res, err := s.do(req, checksum)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return responseError(req, res)
+	}
+
+	return nil
+}
+
+// Get downloads the object. ErrNotFound is returned if the object does not exist.
+func (s S3Store) Get(ctx context.Context, key string, offset int64, length int64) (io.ReadCloser, error) {
+	if length == 0 {
+		return io.NopCloser(strings.NewReader("")), nil
+	}
+
+	req, err := s.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if length > 0 {
+		req.Header.Set("Range", "bytes="+strconv.FormatInt(offset, 10)+"-"+strconv.FormatInt(offset+length-1, 10))
+	} else if offset > 0 {
+		req.Header.Set("Range", "bytes="+strconv.FormatInt(offset, 10)+"-")
+	}
+
+	res, err := s.do(req, emptyPayloadHash)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusPartialContent {
+		defer res.Body.Close()
+		return nil, responseError(req, res)
+	}
+
+	return res.Body, nil
+}
+
+// Delete removes the object.
+func (s S3Store) Delete(ctx context.Context, key string) error {
+	req, err := s.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.do(req, emptyPayloadHash)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	// Deleting an object that does not exist succeeds, though not every service agrees on the status
+	if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK && res.StatusCode != http.StatusNotFound {
+		return responseError(req, res)
+	}
+
+	return nil
+}
+
+// newRequest builds a request for the object stored under the key
+func (s S3Store) newRequest(ctx context.Context, method string, key string, body io.Reader) (*http.Request, error) {
+	if !validKey(key) {
+		return nil, fmt.Errorf("invalid blob key %q", key)
+	}
+
+	// Valid keys do not have any characters that need to be escaped
+	url := strings.TrimSuffix(s.Endpoint, "/") + "/" + s.Bucket + "/" + key
+	return http.NewRequestWithContext(ctx, method, url, body)
+}
+
+// do signs and sends the request
+func (s S3Store) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	now := time.Now
+	if s.Now != nil {
+		now = s.Now
+	}
+
+	region := s.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	signV4(req, payloadHash, now().UTC(), region, s.AccessKeyID, s.SecretAccessKey)
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return client.Do(req)
+}
+
+// signV4 signs the request for the S3 service with AWS Signature Version 4, using the payload hash in place of hashing
+// the body. The host, range, and x-amz-* headers are signed.
+func signV4(req *http.Request, payloadHash string, now time.Time, region string, accessKeyID string, secretAccessKey string) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	scope := date + "/" + region + "/s3/aws4_request"
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if name == "range" || strings.HasPrefix(name, "x-amz-") {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	canonicalRequestHash := sha256.Sum256([]byte(canonicalRequest))
+
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalRequestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+secretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+accessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+// hmacSHA256 computes the HMAC-SHA256 of the data with the key
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// responseError builds the error for an unsuccessful response from the service, using the error code in the body where
+// there is one.
+func responseError(req *http.Request, res *http.Response) error {
+	var body s3Error
+	raw, _ := io.ReadAll(io.LimitReader(res.Body, 4096))
+	_ = xml.Unmarshal(raw, &body)
+
+	switch {
+	case res.StatusCode == http.StatusNotFound || body.Code == "NoSuchKey":
+		return ErrNotFound
+	case body.Code == "XAmzContentSHA256Mismatch" || body.Code == "BadDigest":
+		return ErrChecksumMismatch
+	case body.Code != "":
+		return fmt.Errorf("s3 %s %s returned status %d: %s: %s", req.Method, req.URL.Path, res.StatusCode, body.Code, body.Message)
+	default:
+		return fmt.Errorf("s3 %s %s returned status %d", req.Method, req.URL.Path, res.StatusCode)
+	}
+}
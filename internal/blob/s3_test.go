@@ -0,0 +1,253 @@
+package blob_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jaredpetersen/go-rest-template/internal/blob"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// s3StandIn is an in-memory stand-in for an S3-compatible service that supports just enough of the API for S3Store.
+type s3StandIn struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	// requests records the authorization of every request
+	requests []*http.Request
+}
+
+func newS3StandIn() *s3StandIn {
+	return &s3StandIn{objects: make(map[string][]byte)}
+}
+
+func (s *s3StandIn) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = append(s.requests, req)
+
+	switch req.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(req.Body)
+		sum := sha256.Sum256(body)
+		if req.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(sum[:]) {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, "<Error><Code>XAmzContentSHA256Mismatch</Code><Message>mismatch</Message></Error>")
+			return
+		}
+		s.objects[req.URL.Path] = body
+	case http.MethodGet:
+		object, ok := s.objects[req.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, "<Error><Code>NoSuchKey</Code><Message>missing</Message></Error>")
+			return
+		}
+
+		rng := strings.TrimPrefix(req.Header.Get("Range"), "bytes=")
+		if rng == "" {
+			w.Write(object)
+			return
+		}
+
+		bounds := strings.SplitN(rng, "-", 2)
+		start, _ := strconv.Atoi(bounds[0])
+		end := len(object) - 1
+		if bounds[1] != "" {
+			end, _ = strconv.Atoi(bounds[1])
+		}
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(object[start : end+1])
+	case http.MethodDelete:
+		delete(s.objects, req.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestS3Store(t *testing.T) {
+	ctx := context.Background()
+
+	standIn := newS3StandIn()
+	server := httptest.NewServer(standIn)
+	defer server.Close()
+
+	s := blob.S3Store{
+		Endpoint:        server.URL,
+		Bucket:          "attachments",
+		AccessKeyID:     "access-key",
+		SecretAccessKey: "secret-key",
+		Now:             func() time.Time { return time.Date(2021, time.October, 1, 12, 0, 0, 0, time.UTC) },
+	}
+
+	content := "The quick brown fox"
+	err := s.Put(ctx, "tenant-1/fox.txt", strings.NewReader(content), int64(len(content)), checksum(content))
+	require.NoError(t, err, "Put returned error")
+	assert.Equal(t, []byte(content), standIn.objects["/attachments/tenant-1/fox.txt"], "Did not store object in bucket")
+
+	rc, err := s.Get(ctx, "tenant-1/fox.txt", 0, -1)
+	require.NoError(t, err, "Get returned error")
+	assert.Equal(t, content, readAll(t, rc), "Returned incorrect content")
+
+	rc, err = s.Get(ctx, "tenant-1/fox.txt", 4, 5)
+	require.NoError(t, err, "Get returned error")
+	assert.Equal(t, "quick", readAll(t, rc), "Returned incorrect range")
+
+	rc, err = s.Get(ctx, "tenant-1/fox.txt", 10, -1)
+	require.NoError(t, err, "Get returned error")
+	assert.Equal(t, "brown fox", readAll(t, rc), "Returned incorrect remainder")
+
+	err = s.Delete(ctx, "tenant-1/fox.txt")
+	require.NoError(t, err, "Delete returned error")
+
+	_, err = s.Get(ctx, "tenant-1/fox.txt", 0, -1)
+	assert.ErrorIs(t, err, blob.ErrNotFound)
+
+	// Every request is signed for the default region
+	for _, req := range standIn.requests {
+		authorization := req.Header.Get("Authorization")
+		assert.True(t, strings.HasPrefix(authorization, "AWS4-HMAC-SHA256 Credential=access-key/20211001/us-east-1/s3/aws4_request, "),
+			"Incorrect credential in %s", authorization)
+		assert.Contains(t, authorization, "SignedHeaders=host;", "Did not sign host")
+		assert.Equal(t, "20211001T120000Z", req.Header.Get("X-Amz-Date"), "Incorrect date")
+	}
+}
+
+func TestS3StorePutEmpty(t *testing.T) {
+	ctx := context.Background()
+
+	standIn := newS3StandIn()
+	server := httptest.NewServer(standIn)
+	defer server.Close()
+
+	s := blob.S3Store{Endpoint: server.URL, Bucket: "attachments", AccessKeyID: "access-key", SecretAccessKey: "secret-key"}
+
+	err := s.Put(ctx, "empty.txt", strings.NewReader(""), 0, checksum(""))
+	require.NoError(t, err, "Put returned error")
+
+	require.Len(t, standIn.requests, 1)
+	assert.Equal(t, int64(0), standIn.requests[0].ContentLength, "Did not send empty body")
+	assert.Empty(t, standIn.requests[0].TransferEncoding, "Sent chunked body")
+}
+
+func TestS3StorePutChecksumMismatch(t *testing.T) {
+	ctx := context.Background()
+
+	standIn := newS3StandIn()
+	server := httptest.NewServer(standIn)
+	defer server.Close()
+
+	s := blob.S3Store{Endpoint: server.URL, Bucket: "attachments", AccessKeyID: "access-key", SecretAccessKey: "secret-key"}
+
+	content := "The quick brown fox"
+	err := s.Put(ctx, "fox.txt", strings.NewReader(content), int64(len(content)), checksum("The lazy dog"))
+	assert.ErrorIs(t, err, blob.ErrChecksumMismatch)
+	assert.Empty(t, standIn.objects, "Stored object")
+}
+
+func TestS3StoreReturnsServiceError(t *testing.T) {
+	ctx := context.Background()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, "<Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>")
+	}))
+	defer server.Close()
+
+	s := blob.S3Store{Endpoint: server.URL, Bucket: "attachments", AccessKeyID: "access-key", SecretAccessKey: "wrong"}
+
+	_, err := s.Get(ctx, "fox.txt", 0, -1)
+	assert.EqualError(t, err, "s3 GET /attachments/fox.txt returned status 403: AccessDenied: Access Denied")
+}
+
+type minioContainer struct {
+	testcontainers.Container
+	URI string
+}
+
+// setupMinio starts up a MinIO container with an empty bucket named attachments
+//
+// Returned MinIO container must be explicitly terminated
+func setupMinio(ctx context.Context) (*minioContainer, error) {
+	req := testcontainers.ContainerRequest{
+		// Directories at the top of the data directory are served as buckets
+		Image:        "minio/minio:RELEASE.2021-10-27T16-29-42Z",
+		Entrypoint:   []string{"sh", "-c", "mkdir -p /data/attachments && minio server /data"},
+		Env:          map[string]string{"MINIO_ROOT_USER": "access-key", "MINIO_ROOT_PASSWORD": "secret-key"},
+		ExposedPorts: []string{"9000/tcp"},
+		WaitingFor:   wait.ForHTTP("/minio/health/live").WithPort("9000/tcp"),
+		SkipReaper:   true,
+	}
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	mappedPort, err := container.MappedPort(ctx, "9000")
+	if err != nil {
+		return nil, err
+	}
+
+	hostIP, err := container.Host(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	uri := fmt.Sprintf("http://%s:%s", hostIP, mappedPort.Port())
+
+	return &minioContainer{Container: container, URI: uri}, nil
+}
+
+func TestIntegrationS3Store(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+	minioContainer, err := setupMinio(ctx)
+	require.NoError(t, err, "Failed to start up MinIO container")
+	defer minioContainer.Terminate(ctx)
+
+	s := blob.S3Store{Endpoint: minioContainer.URI, Bucket: "attachments", AccessKeyID: "access-key", SecretAccessKey: "secret-key"}
+
+	content := "The quick brown fox"
+	err = s.Put(ctx, "tenant-1/fox.txt", strings.NewReader(content), int64(len(content)), checksum(content))
+	require.NoError(t, err, "Put returned error")
+
+	rc, err := s.Get(ctx, "tenant-1/fox.txt", 4, 5)
+	require.NoError(t, err, "Get returned error")
+	assert.Equal(t, "quick", readAll(t, rc), "Returned incorrect range")
+
+	// The service checks the content against the checksum
+	err = s.Put(ctx, "tenant-1/dog.txt", strings.NewReader(content), int64(len(content)), checksum("The lazy dog"))
+	assert.ErrorIs(t, err, blob.ErrChecksumMismatch)
+
+	err = s.Delete(ctx, "tenant-1/fox.txt")
+	require.NoError(t, err, "Delete returned error")
+
+	_, err = s.Get(ctx, "tenant-1/fox.txt", 0, -1)
+	assert.ErrorIs(t, err, blob.ErrNotFound)
+
+	// Requests signed with the wrong secret are refused
+	s.SecretAccessKey = "wrong"
+	_, err = s.Get(ctx, "tenant-1/dog.txt", 0, -1)
+	assert.Error(t, err, "Did not refuse request")
+	assert.NotErrorIs(t, err, blob.ErrNotFound)
+}
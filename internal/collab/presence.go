@@ -0,0 +1,302 @@
+// Package collab keeps track of who is collaborating on which tasks, for every replica of the service, so that
+// collaborators can see each other.
+package collab
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jaredpetersen/go-rest-template/internal/redis"
+	"github.com/rs/zerolog/log"
+)
+
+// joinScript adds or refreshes a session in the set of sessions that are present on a task, pruning the sessions that
+// have expired along the way.
+//
+// Sessions are kept in a sorted set scored by when they expire so that sessions that were never closed, such as those
+// of a replica that crashed, go away on their own. Redis' clock is used rather than the caller's so that clock skew
+// between replicas does not affect expiry.
+//
+// KEYS[1] - key of the sorted set
+// ARGV[1] - session member
+// ARGV[2] - TTL in milliseconds
+//
+// Returns the number of sessions that were added or pruned
+const joinScript = `
+redis.replicate_commands()
+
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local ttl = tonumber(ARGV[2])
+
+local pruned = redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now)
+local added = redis.call('ZADD', KEYS[1], now + ttl, ARGV[1])
+redis.call('PEXPIRE', KEYS[1], ttl)
+return pruned + added
+`
+
+// leaveScript removes a session from the set of sessions that are present on a task.
+//
+// KEYS[1] - key of the sorted set
+// ARGV[1] - session member
+//
+// Returns the number of sessions that were removed
+const leaveScript = `
+return redis.call('ZREM', KEYS[1], ARGV[1])
+`
+
+// listScript lists the sessions that are present on a task and have not expired.
+//
+// KEYS[1] - key of the sorted set
+//
+// Returns the session members
+const listScript = `
+redis.replicate_commands()
+
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+return redis.call('ZRANGEBYSCORE', KEYS[1], '(' .. now, '+inf')
+`
+
+// announcement tells every replica that who is present on a task has changed.
+type announcement struct {
+	TenantID string `json:"tenantId"`
+	TaskID   string `json:"taskId"`
+}
+
+// Presence keeps track of the sessions that are present on tasks in Redis and lets each replica watch for changes.
+//
+// Each replica holds a single subscription to the pub/sub channel that changes are announced on and notifies its own
+// watchers. Sessions must join again before their TTL runs out to stay present.
+type Presence struct {
+	Redis redis.Client
+	// Channel is the pub/sub channel that changes are announced on.
+	Channel string
+	// TTL is how long a session stays present after it last joined. Defaults to a minute.
+	TTL time.Duration
+	// RetryInterval is how long to wait before subscribing again after the subscription is lost. Defaults to a second.
+	RetryInterval time.Duration
+	mu            sync.Mutex
+	watchers      map[announcement]map[chan struct{}]bool
+}
+
+// NewPresence creates a presence tracker with default values. The returned pointer will never be nil.
+func NewPresence() *Presence {
+	return &Presence{
+		TTL:           time.Minute,
+		RetryInterval: time.Second,
+		watchers:      make(map[announcement]map[chan struct{}]bool),
+	}
+}
+
+// Join marks the subject's session as present on the task, or keeps it present if it already is.
+func (p *Presence) Join(ctx context.Context, tenantID string, taskID string, subject string, sessionID string) error {
+	key := presenceKey(tenantID, taskID)
+
+	raw, err := p.Redis.Eval(ctx, joinScript, []string{key}, member(subject, sessionID), p.TTL.Milliseconds())
+	if err != nil {
+		return err
+	}
+
+	changed, ok := raw.(int64)
+	if !ok {
+		return fmt.Errorf("unexpected presence join script result %v", raw)
+	}
+
+	if changed == 0 {
+		return nil
+	}
+
+	return p.announce(ctx, tenantID, taskID)
+}
+
+// Leave marks the subject's session as no longer present on the task.
+func (p *Presence) Leave(ctx context.Context, tenantID string, taskID string, subject string, sessionID string) error {
+	key := presenceKey(tenantID, taskID)
+
+	raw, err := p.Redis.Eval(ctx, leaveScript, []string{key}, member(subject, sessionID))
+	if err != nil {
+		return err
+	}
+
+	changed, ok := raw.(int64)
+	if !ok {
+		return fmt.Errorf("unexpected presence leave script result %v", raw)
+	}
+
+	if changed == 0 {
+		return nil
+	}
+
+	return p.announce(ctx, tenantID, taskID)
+}
+
+// List retrieves the subjects that are present on the task, in order. Subjects with several sessions are only listed
+// once.
+func (p *Presence) List(ctx context.Context, tenantID string, taskID string) ([]string, error) {
+	raw, err := p.Redis.Eval(ctx, listScript, []string{presenceKey(tenantID, taskID)})
+	if err != nil {
+		return nil, err
+	}
+
+	members, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected presence list script result %v", raw)
+	}
+
+	seen := make(map[string]bool, len(members))
+	subjects := []string{}
+	for _, m := range members {
+		s, ok := m.(string)
+		if !ok {
+			return nil, fmt.Errorf("unexpected presence list script result %v", raw)
+		}
+
+		// Members are the session ID followed by the subject, which may contain anything
+		parts := strings.SplitN(s, ":", 2)
+		if len(parts) != 2 || seen[parts[1]] {
+			continue
+		}
+
+		seen[parts[1]] = true
+		subjects = append(subjects, parts[1])
+	}
+
+	sort.Strings(subjects)
+	return subjects, nil
+}
+
+// announce tells every replica that who is present on the task has changed.
+func (p *Presence) announce(ctx context.Context, tenantID string, taskID string) error {
+	raw, err := json.Marshal(announcement{TenantID: tenantID, TaskID: taskID})
+	if err != nil {
+		return err
+	}
+
+	return p.Redis.Publish(ctx, p.Channel, string(raw))
+}
+
+// Start follows the channel in a separate goroutine until the context is done.
+func (p *Presence) Start(ctx context.Context) {
+	go func() {
+		for {
+			err := p.follow(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+
+			log.Error().Err(err).Msg("Lost subscription to presence announcements")
+
+			// Watchers may have missed changes, so they are all notified to check again
+			p.notifyAll()
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(p.RetryInterval):
+			}
+		}
+	}()
+}
+
+// follow subscribes to the channel and notifies the watchers of every change until the subscription is lost or the
+// context is done.
+func (p *Presence) follow(ctx context.Context) error {
+	sub, err := p.Redis.Subscribe(ctx, p.Channel)
+	if err != nil {
+		return err
+	}
+	defer sub.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-sub.Messages():
+			if !ok {
+				return errors.New("presence subscription closed")
+			}
+
+			var a announcement
+			err = json.Unmarshal([]byte(msg), &a)
+			if err != nil {
+				log.Warn().Err(err).Msg("Failed to decode presence announcement")
+				continue
+			}
+
+			p.notify(a)
+		}
+	}
+}
+
+// notify notifies the watchers of the task. Watchers that have not caught up on an earlier notification are not
+// notified again since they will see the change anyway.
+func (p *Presence) notify(a announcement) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for ch := range p.watchers[a] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// notifyAll notifies every watcher.
+func (p *Presence) notifyAll() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, chs := range p.watchers {
+		for ch := range chs {
+			select {
+			case ch <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// Watch receives a notification whenever who is present on the task changes, along with a function to stop watching
+// with. Notifications that arrive before the last one was received are combined.
+func (p *Presence) Watch(tenantID string, taskID string) (<-chan struct{}, func()) {
+	a := announcement{TenantID: tenantID, TaskID: taskID}
+	ch := make(chan struct{}, 1)
+
+	p.mu.Lock()
+	if p.watchers[a] == nil {
+		p.watchers[a] = make(map[chan struct{}]bool)
+	}
+	p.watchers[a][ch] = true
+	p.mu.Unlock()
+
+	unwatch := func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		delete(p.watchers[a], ch)
+		if len(p.watchers[a]) == 0 {
+			delete(p.watchers, a)
+		}
+	}
+
+	return ch, unwatch
+}
+
+// presenceKey generates the key of the sorted set of sessions that are present on the task.
+func presenceKey(tenantID string, taskID string) string {
+	return "presence." + tenantID + "." + taskID
+}
+
+// member generates the sorted set member for the subject's session. The session ID comes first since the subject may
+// contain anything.
+func member(subject string, sessionID string) string {
+	return sessionID + ":" + subject
+}
@@ -0,0 +1,270 @@
+package collab_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jaredpetersen/go-rest-template/internal/collab"
+	"github.com/jaredpetersen/go-rest-template/internal/redis"
+	redismock "github.com/jaredpetersen/go-rest-template/internal/redis/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+type redisContainer struct {
+	testcontainers.Container
+	URI string
+}
+
+// setupRedis starts up a Redis container
+//
+// Returned Redis container must be explicitly terminated
+func setupRedis(ctx context.Context) (*redisContainer, error) {
+	req := testcontainers.ContainerRequest{
+		Image:        "redis:6",
+		ExposedPorts: []string{"6379/tcp"},
+		WaitingFor:   wait.ForLog("* Ready to accept connections"),
+		SkipReaper:   true,
+	}
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	mappedPort, err := container.MappedPort(ctx, "6379")
+	if err != nil {
+		return nil, err
+	}
+
+	hostIP, err := container.Host(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	uri := fmt.Sprintf("redis://%s:%s", hostIP, mappedPort.Port())
+
+	return &redisContainer{Container: container, URI: uri}, nil
+}
+
+// buildSubscription creates a subscription that receives the messages sent on msgs
+func buildSubscription(msgs chan string) *redismock.Subscription {
+	sub := redismock.Subscription{}
+	sub.On("Messages").Return((<-chan string)(msgs))
+	sub.On("Close").Return(nil)
+	return &sub
+}
+
+// buildPresence creates a presence tracker that uses the Redis client
+func buildPresence(rdb redis.Client) *collab.Presence {
+	presence := collab.NewPresence()
+	presence.Redis = rdb
+	presence.Channel = "presence"
+	presence.TTL = 30 * time.Second
+	return presence
+}
+
+// notified waits for a notification, reporting whether or not one arrived
+func notified(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	case <-time.After(100 * time.Millisecond):
+		return false
+	}
+}
+
+func TestPresenceJoin(t *testing.T) {
+	ctx := context.Background()
+
+	rdb := redismock.Client{}
+	rdb.On("Eval", ctx, mock.AnythingOfType("string"), []string{"presence.tenant-1.task-1"}, "session-1:user-1", int64(30000)).
+		Return(int64(1), nil)
+	rdb.On("Publish", ctx, "presence", `{"tenantId":"tenant-1","taskId":"task-1"}`).Return(nil)
+
+	err := buildPresence(&rdb).Join(ctx, "tenant-1", "task-1", "user-1", "session-1")
+	require.NoError(t, err)
+
+	rdb.AssertExpectations(t)
+}
+
+func TestPresenceJoinUnchanged(t *testing.T) {
+	ctx := context.Background()
+
+	rdb := redismock.Client{}
+	rdb.On("Eval", ctx, mock.AnythingOfType("string"), []string{"presence.tenant-1.task-1"}, "session-1:user-1", int64(30000)).
+		Return(int64(0), nil)
+
+	// Refreshing a session that is already present is not announced
+	err := buildPresence(&rdb).Join(ctx, "tenant-1", "task-1", "user-1", "session-1")
+	require.NoError(t, err)
+
+	rdb.AssertExpectations(t)
+	rdb.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPresenceJoinError(t *testing.T) {
+	ctx := context.Background()
+
+	rdb := redismock.Client{}
+	rdb.On("Eval", ctx, mock.AnythingOfType("string"), mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, errors.New("failed"))
+
+	err := buildPresence(&rdb).Join(ctx, "tenant-1", "task-1", "user-1", "session-1")
+	assert.EqualError(t, err, "failed")
+}
+
+func TestPresenceLeave(t *testing.T) {
+	ctx := context.Background()
+
+	rdb := redismock.Client{}
+	rdb.On("Eval", ctx, mock.AnythingOfType("string"), []string{"presence.tenant-1.task-1"}, "session-1:user-1").
+		Return(int64(1), nil)
+	rdb.On("Publish", ctx, "presence", `{"tenantId":"tenant-1","taskId":"task-1"}`).Return(nil)
+
+	err := buildPresence(&rdb).Leave(ctx, "tenant-1", "task-1", "user-1", "session-1")
+	require.NoError(t, err)
+
+	rdb.AssertExpectations(t)
+}
+
+func TestPresenceList(t *testing.T) {
+	ctx := context.Background()
+
+	rdb := redismock.Client{}
+	rdb.On("Eval", ctx, mock.AnythingOfType("string"), []string{"presence.tenant-1.task-1"}).
+		Return([]interface{}{"session-1:user-2", "session-2:user-1", "session-3:user-2", "session-4:user:with:colons"}, nil)
+
+	subjects, err := buildPresence(&rdb).List(ctx, "tenant-1", "task-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user-1", "user-2", "user:with:colons"}, subjects)
+}
+
+func TestPresenceListUnexpectedResult(t *testing.T) {
+	ctx := context.Background()
+
+	rdb := redismock.Client{}
+	rdb.On("Eval", ctx, mock.AnythingOfType("string"), []string{"presence.tenant-1.task-1"}).Return(int64(1), nil)
+
+	_, err := buildPresence(&rdb).List(ctx, "tenant-1", "task-1")
+	assert.Error(t, err)
+}
+
+func TestPresenceWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	msgs := make(chan string, 4)
+	rdb := redismock.Client{}
+	rdb.On("Subscribe", mock.Anything, "presence").Return(buildSubscription(msgs), nil)
+
+	presence := buildPresence(&rdb)
+	task1, unwatchTask1 := presence.Watch("tenant-1", "task-1")
+	defer unwatchTask1()
+	task2, unwatchTask2 := presence.Watch("tenant-1", "task-2")
+	defer unwatchTask2()
+
+	msgs <- `{"tenantId":"tenant-1","taskId":"task-1"}`
+	msgs <- `{"tenantId":"tenant-1","taskId":"task-1"}`
+	msgs <- `{"tenantId":"tenant-2","taskId":"task-1"}`
+	msgs <- `{"tenantId":"tenant-1","taskId":"task-2"}`
+	presence.Start(ctx)
+
+	// Announcements are handled in order, so the ones before the last have been handled once it has
+	assert.True(t, notified(task2), "Watcher was not notified")
+	assert.True(t, notified(task1), "Watcher was not notified")
+	assert.False(t, notified(task1), "Notifications were not combined")
+}
+
+func TestPresenceWatchSubscriptionLost(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	msgs := make(chan string)
+	rdb := redismock.Client{}
+	rdb.On("Subscribe", mock.Anything, "presence").Return(buildSubscription(msgs), nil).Once()
+	rdb.On("Subscribe", mock.Anything, "presence").Return(nil, errors.New("failed"))
+
+	presence := buildPresence(&rdb)
+	presence.RetryInterval = time.Hour
+	ch, unwatch := presence.Watch("tenant-1", "task-1")
+	defer unwatch()
+	presence.Start(ctx)
+
+	// Changes may have been missed, so the watcher checks again
+	close(msgs)
+	assert.True(t, notified(ch), "Watcher was not notified")
+}
+
+func TestPresenceUnwatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	msgs := make(chan string, 1)
+	rdb := redismock.Client{}
+	rdb.On("Subscribe", mock.Anything, "presence").Return(buildSubscription(msgs), nil)
+
+	presence := buildPresence(&rdb)
+	ch, unwatch := presence.Watch("tenant-1", "task-1")
+	unwatch()
+	presence.Start(ctx)
+
+	msgs <- `{"tenantId":"tenant-1","taskId":"task-1"}`
+	assert.False(t, notified(ch), "Watcher was notified after it stopped watching")
+}
+
+func TestIntegrationPresence(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+	redisContainer, err := setupRedis(ctx)
+	require.NoError(t, err, "Failed to start up Redis container")
+	defer redisContainer.Terminate(ctx)
+
+	config := redis.Config{URI: redisContainer.URI}
+	rdb, err := redis.New(config)
+	require.NoError(t, err, "Client instantiation error")
+	defer rdb.Close()
+
+	presence := buildPresence(rdb)
+	presence.TTL = 500 * time.Millisecond
+
+	followCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ch, unwatch := presence.Watch("tenant-1", "task-1")
+	defer unwatch()
+	presence.Start(followCtx)
+
+	// Give the subscription a moment to be established
+	time.Sleep(100 * time.Millisecond)
+
+	require.NoError(t, presence.Join(ctx, "tenant-1", "task-1", "user-1", "session-1"))
+	require.NoError(t, presence.Join(ctx, "tenant-1", "task-1", "user-1", "session-2"))
+	require.NoError(t, presence.Join(ctx, "tenant-1", "task-1", "user-2", "session-3"))
+	assert.True(t, notified(ch), "Watcher was not notified")
+
+	subjects, err := presence.List(ctx, "tenant-1", "task-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user-1", "user-2"}, subjects)
+
+	require.NoError(t, presence.Leave(ctx, "tenant-1", "task-1", "user-2", "session-3"))
+	subjects, err = presence.List(ctx, "tenant-1", "task-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user-1"}, subjects)
+
+	// Sessions that are not refreshed expire
+	time.Sleep(600 * time.Millisecond)
+	subjects, err = presence.List(ctx, "tenant-1", "task-1")
+	require.NoError(t, err)
+	assert.Empty(t, subjects)
+}
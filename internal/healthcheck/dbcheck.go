@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"github.com/jaredpetersen/go-health/health"
+	"time"
 )
 
 type DBDetails struct {
@@ -24,3 +25,99 @@ func BuildDBHealthCheckFunc(db *sql.DB) health.CheckFunc {
 		return health.Status{State: health.StateUp, Details: dbDetails}
 	}
 }
+
+type DBLatencyDetails struct {
+	Latency time.Duration
+}
+
+// BuildDBLatencyHealthCheckFunc creates a health check that warns when the database takes longer than warnThreshold to
+// respond to a ping.
+func BuildDBLatencyHealthCheckFunc(db *sql.DB, warnThreshold time.Duration) health.CheckFunc {
+	return func(ctx context.Context) health.Status {
+		start := time.Now()
+		err := db.PingContext(ctx)
+		latency := time.Since(start)
+		if err != nil {
+			return health.Status{State: health.StateDown}
+		}
+
+		details := DBLatencyDetails{Latency: latency}
+
+		if latency > warnThreshold {
+			return health.Status{State: health.StateWarn, Details: details}
+		}
+
+		return health.Status{State: health.StateUp, Details: details}
+	}
+}
+
+// DBStatsProvider provides database connection pool statistics. Satisfied by *sql.DB.
+type DBStatsProvider interface {
+	Stats() sql.DBStats
+}
+
+type DBPoolDetails struct {
+	ConnectionsInUse   int
+	ConnectionsIdle    int
+	MaxOpenConnections int
+	WaitCount          int64
+	Saturation         float64
+}
+
+// BuildDBPoolHealthCheckFunc creates a health check that warns when the ratio of in-use connections to the maximum
+// number of open connections exceeds warnSaturation, e.g. 0.9 for 90%. Connection pools without a maximum are never
+// considered saturated.
+func BuildDBPoolHealthCheckFunc(db DBStatsProvider, warnSaturation float64) health.CheckFunc {
+	return func(ctx context.Context) health.Status {
+		dbStats := db.Stats()
+		details := DBPoolDetails{
+			ConnectionsInUse:   dbStats.InUse,
+			ConnectionsIdle:    dbStats.Idle,
+			MaxOpenConnections: dbStats.MaxOpenConnections,
+			WaitCount:          dbStats.WaitCount,
+		}
+
+		if dbStats.MaxOpenConnections > 0 {
+			details.Saturation = float64(dbStats.InUse) / float64(dbStats.MaxOpenConnections)
+		}
+
+		if details.Saturation > warnSaturation {
+			return health.Status{State: health.StateWarn, Details: details}
+		}
+
+		return health.Status{State: health.StateUp, Details: details}
+	}
+}
+
+type DBClockSkewDetails struct {
+	Skew time.Duration
+}
+
+// BuildDBClockSkewHealthCheckFunc creates a health check that warns when the application clock and the database clock
+// differ by more than warnThreshold. The round trip time of the query is accounted for by comparing the database time
+// against the midpoint of the request.
+func BuildDBClockSkewHealthCheckFunc(db *sql.DB, warnThreshold time.Duration) health.CheckFunc {
+	return func(ctx context.Context) health.Status {
+		before := time.Now()
+		var dbNow time.Time
+		err := db.QueryRowContext(ctx, "select now()").Scan(&dbNow)
+		after := time.Now()
+		if err != nil {
+			return health.Status{State: health.StateDown}
+		}
+
+		localNow := before.Add(after.Sub(before) / 2)
+		skew := dbNow.Sub(localNow)
+		if skew < 0 {
+			skew = -skew
+		}
+
+		details := DBClockSkewDetails{Skew: skew}
+
+		if skew > warnThreshold {
+			return health.Status{State: health.StateWarn, Details: details}
+		}
+
+		return health.Status{State: health.StateUp, Details: details}
+	}
+}
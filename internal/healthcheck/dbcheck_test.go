@@ -12,6 +12,7 @@ import (
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/wait"
 	"testing"
+	"time"
 )
 
 type cockroachDBContainer struct {
@@ -98,3 +99,137 @@ func TestBuildDBHealthCheckFuncStateUp(t *testing.T) {
 	assert.Equal(t, health.StateUp, healthStatus.State)
 	assert.Equal(t, healthcheck.DBDetails{ConnectionsInUse: 0, ConnectionsIdle: 1}, healthStatus.Details)
 }
+
+// dbStats is a fake connection pool statistics provider
+type dbStats sql.DBStats
+
+func (s dbStats) Stats() sql.DBStats {
+	return sql.DBStats(s)
+}
+
+func TestIntegrationBuildDBLatencyHealthCheckFunc(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+
+	cdbContainer, err := setupCockroachDB(ctx)
+	require.NoError(t, err, "Failed to start up CockroachDB container")
+	defer cdbContainer.Terminate(ctx)
+
+	db, err := sql.Open("pgx", cdbContainer.URI+"/projectmanagement")
+	require.NoError(t, err, "Failed to open connection to CockroachDB")
+	defer db.Close()
+
+	var tests = []struct {
+		warnThreshold time.Duration
+		expected      health.State
+	}{
+		{
+			warnThreshold: time.Minute,
+			expected:      health.StateUp,
+		},
+		{
+			warnThreshold: 0,
+			expected:      health.StateWarn,
+		},
+	}
+
+	for _, tt := range tests {
+		dbLatencyHealthCheckFunc := healthcheck.BuildDBLatencyHealthCheckFunc(db, tt.warnThreshold)
+		require.NotNil(t, dbLatencyHealthCheckFunc)
+
+		healthStatus := dbLatencyHealthCheckFunc(ctx)
+		assert.Equal(t, tt.expected, healthStatus.State)
+		assert.Greater(t, healthStatus.Details.(healthcheck.DBLatencyDetails).Latency, time.Duration(0))
+	}
+
+	// Simulate a database outage
+	cdbContainer.Terminate(ctx)
+
+	healthStatus := healthcheck.BuildDBLatencyHealthCheckFunc(db, time.Minute)(ctx)
+	assert.Equal(t, health.StateDown, healthStatus.State)
+	assert.Nil(t, healthStatus.Details)
+}
+
+func TestBuildDBPoolHealthCheckFunc(t *testing.T) {
+	ctx := context.Background()
+
+	var tests = []struct {
+		stats    dbStats
+		expected health.Status
+	}{
+		{
+			stats: dbStats{MaxOpenConnections: 10, InUse: 5, Idle: 5},
+			expected: health.Status{
+				State: health.StateUp,
+				Details: healthcheck.DBPoolDetails{
+					ConnectionsInUse:   5,
+					ConnectionsIdle:    5,
+					MaxOpenConnections: 10,
+					Saturation:         0.5,
+				},
+			},
+		},
+		{
+			stats: dbStats{MaxOpenConnections: 10, InUse: 10, WaitCount: 3},
+			expected: health.Status{
+				State: health.StateWarn,
+				Details: healthcheck.DBPoolDetails{
+					ConnectionsInUse:   10,
+					MaxOpenConnections: 10,
+					WaitCount:          3,
+					Saturation:         1,
+				},
+			},
+		},
+		{
+			stats: dbStats{MaxOpenConnections: 0, InUse: 1000},
+			expected: health.Status{
+				State: health.StateUp,
+				Details: healthcheck.DBPoolDetails{
+					ConnectionsInUse: 1000,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		dbPoolHealthCheckFunc := healthcheck.BuildDBPoolHealthCheckFunc(tt.stats, 0.9)
+		require.NotNil(t, dbPoolHealthCheckFunc)
+
+		healthStatus := dbPoolHealthCheckFunc(ctx)
+		assert.Equal(t, tt.expected, healthStatus)
+	}
+}
+
+func TestIntegrationBuildDBClockSkewHealthCheckFunc(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+
+	cdbContainer, err := setupCockroachDB(ctx)
+	require.NoError(t, err, "Failed to start up CockroachDB container")
+	defer cdbContainer.Terminate(ctx)
+
+	db, err := sql.Open("pgx", cdbContainer.URI+"/projectmanagement")
+	require.NoError(t, err, "Failed to open connection to CockroachDB")
+	defer db.Close()
+
+	dbClockSkewHealthCheckFunc := healthcheck.BuildDBClockSkewHealthCheckFunc(db, time.Second*5)
+	require.NotNil(t, dbClockSkewHealthCheckFunc)
+
+	healthStatus := dbClockSkewHealthCheckFunc(ctx)
+	assert.Equal(t, health.StateUp, healthStatus.State)
+	assert.Less(t, healthStatus.Details.(healthcheck.DBClockSkewDetails).Skew, time.Second*5)
+
+	// Simulate a database outage
+	cdbContainer.Terminate(ctx)
+
+	healthStatus = dbClockSkewHealthCheckFunc(ctx)
+	assert.Equal(t, health.StateDown, healthStatus.State)
+	assert.Nil(t, healthStatus.Details)
+}
@@ -0,0 +1,39 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package healthcheck
+
+import (
+	"context"
+	"github.com/jaredpetersen/go-health/health"
+	"syscall"
+)
+
+type DiskDetails struct {
+	FreeBytes uint64
+}
+
+// BuildDiskHealthCheckFunc creates a health check for the free disk space available to the application on the
+// filesystem containing path. The check warns when free space drops below warnFreeBytes and is down when free space
+// drops below downFreeBytes.
+func BuildDiskHealthCheckFunc(path string, warnFreeBytes uint64, downFreeBytes uint64) health.CheckFunc {
+	return func(ctx context.Context) health.Status {
+		var stat syscall.Statfs_t
+		err := syscall.Statfs(path, &stat)
+		if err != nil {
+			return health.Status{State: health.StateDown}
+		}
+
+		// Bavail is the number of blocks available to unprivileged users, as opposed to Bfree
+		details := DiskDetails{FreeBytes: uint64(stat.Bavail) * uint64(stat.Bsize)}
+
+		if details.FreeBytes < downFreeBytes {
+			return health.Status{State: health.StateDown, Details: details}
+		}
+		if details.FreeBytes < warnFreeBytes {
+			return health.Status{State: health.StateWarn, Details: details}
+		}
+
+		return health.Status{State: health.StateUp, Details: details}
+	}
+}
@@ -0,0 +1,25 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package healthcheck
+
+import (
+	"context"
+	"github.com/jaredpetersen/go-health/health"
+	"runtime"
+)
+
+type DiskUnsupportedDetails struct {
+	Reason string
+}
+
+// BuildDiskHealthCheckFunc creates a health check for the free disk space available to the application on the
+// filesystem containing path. Free disk space cannot be looked up on this platform, so the check is always up and only
+// reports that it is unsupported.
+func BuildDiskHealthCheckFunc(path string, warnFreeBytes uint64, downFreeBytes uint64) health.CheckFunc {
+	details := DiskUnsupportedDetails{Reason: "free disk space is not available on " + runtime.GOOS}
+
+	return func(ctx context.Context) health.Status {
+		return health.Status{State: health.StateUp, Details: details}
+	}
+}
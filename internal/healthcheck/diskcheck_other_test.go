@@ -0,0 +1,24 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package healthcheck_test
+
+import (
+	"context"
+	"github.com/jaredpetersen/go-health/health"
+	"github.com/jaredpetersen/go-rest-template/internal/healthcheck"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestBuildDiskHealthCheckFuncUnsupported(t *testing.T) {
+	ctx := context.Background()
+
+	diskHealthCheckFunc := healthcheck.BuildDiskHealthCheckFunc(t.TempDir(), 0, 0)
+	require.NotNil(t, diskHealthCheckFunc)
+
+	healthStatus := diskHealthCheckFunc(ctx)
+	assert.Equal(t, health.StateUp, healthStatus.State)
+	assert.IsType(t, healthcheck.DiskUnsupportedDetails{}, healthStatus.Details)
+}
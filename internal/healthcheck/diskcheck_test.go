@@ -0,0 +1,61 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package healthcheck_test
+
+import (
+	"context"
+	"github.com/jaredpetersen/go-health/health"
+	"github.com/jaredpetersen/go-rest-template/internal/healthcheck"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuildDiskHealthCheckFunc(t *testing.T) {
+	ctx := context.Background()
+
+	var tests = []struct {
+		warnFreeBytes uint64
+		downFreeBytes uint64
+		expected      health.State
+	}{
+		{
+			warnFreeBytes: 0,
+			downFreeBytes: 0,
+			expected:      health.StateUp,
+		},
+		{
+			warnFreeBytes: math.MaxUint64,
+			downFreeBytes: 0,
+			expected:      health.StateWarn,
+		},
+		{
+			warnFreeBytes: math.MaxUint64,
+			downFreeBytes: math.MaxUint64,
+			expected:      health.StateDown,
+		},
+	}
+
+	for _, tt := range tests {
+		diskHealthCheckFunc := healthcheck.BuildDiskHealthCheckFunc(t.TempDir(), tt.warnFreeBytes, tt.downFreeBytes)
+		require.NotNil(t, diskHealthCheckFunc)
+
+		healthStatus := diskHealthCheckFunc(ctx)
+		assert.Equal(t, tt.expected, healthStatus.State)
+		assert.IsType(t, healthcheck.DiskDetails{}, healthStatus.Details)
+	}
+}
+
+func TestBuildDiskHealthCheckFuncStateDownOnMissingPath(t *testing.T) {
+	ctx := context.Background()
+
+	diskHealthCheckFunc := healthcheck.BuildDiskHealthCheckFunc(filepath.Join(t.TempDir(), "nonexistent"), 0, 0)
+	require.NotNil(t, diskHealthCheckFunc)
+
+	healthStatus := diskHealthCheckFunc(ctx)
+	assert.Equal(t, health.StateDown, healthStatus.State)
+	assert.Nil(t, healthStatus.Details)
+}
@@ -0,0 +1,67 @@
+package healthcheck
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/jaredpetersen/go-health/health"
+)
+
+type HeartbeatDetails struct {
+	LastBeat time.Time
+}
+
+// Heartbeat is a goroutine that beats on a regular interval. If the Go scheduler is wedged or the process is
+// deadlocked, the heartbeat will stop and its last beat will grow stale.
+type Heartbeat struct {
+	// lastBeat is the last beat in Unix nanoseconds. Accessed atomically.
+	lastBeat int64
+}
+
+// NewHeartbeat creates a heartbeat that has just beat. The returned pointer will never be nil.
+func NewHeartbeat() *Heartbeat {
+	hb := &Heartbeat{}
+	hb.beat()
+	return hb
+}
+
+// Start begins beating in a separate goroutine until the context is done.
+func (hb *Heartbeat) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				hb.beat()
+			}
+		}
+	}()
+}
+
+// LastBeat returns the time of the most recent beat.
+func (hb *Heartbeat) LastBeat() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&hb.lastBeat))
+}
+
+func (hb *Heartbeat) beat() {
+	atomic.StoreInt64(&hb.lastBeat, time.Now().UnixNano())
+}
+
+// BuildHeartbeatHealthCheckFunc creates a health check that is down when the heartbeat has not beat within maxAge.
+func BuildHeartbeatHealthCheckFunc(hb *Heartbeat, maxAge time.Duration) health.CheckFunc {
+	return func(ctx context.Context) health.Status {
+		lastBeat := hb.LastBeat()
+		details := HeartbeatDetails{LastBeat: lastBeat}
+
+		if time.Since(lastBeat) > maxAge {
+			return health.Status{State: health.StateDown, Details: details}
+		}
+
+		return health.Status{State: health.StateUp, Details: details}
+	}
+}
@@ -0,0 +1,52 @@
+package healthcheck_test
+
+import (
+	"context"
+	"github.com/jaredpetersen/go-health/health"
+	"github.com/jaredpetersen/go-rest-template/internal/healthcheck"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestHeartbeatStart(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	hb := healthcheck.NewHeartbeat()
+	firstBeat := hb.LastBeat()
+
+	hb.Start(ctx, time.Millisecond*10)
+	time.Sleep(time.Millisecond * 50)
+
+	assert.True(t, hb.LastBeat().After(firstBeat), "Heartbeat did not beat")
+}
+
+func TestBuildHeartbeatHealthCheckFuncStateUp(t *testing.T) {
+	ctx := context.Background()
+
+	hb := healthcheck.NewHeartbeat()
+
+	heartbeatHealthCheckFunc := healthcheck.BuildHeartbeatHealthCheckFunc(hb, time.Minute)
+	require.NotNil(t, heartbeatHealthCheckFunc)
+
+	healthStatus := heartbeatHealthCheckFunc(ctx)
+	assert.Equal(t, health.StateUp, healthStatus.State)
+	assert.Equal(t, healthcheck.HeartbeatDetails{LastBeat: hb.LastBeat()}, healthStatus.Details)
+}
+
+func TestBuildHeartbeatHealthCheckFuncStateDown(t *testing.T) {
+	ctx := context.Background()
+
+	// Never start the heartbeat to simulate a wedged goroutine
+	hb := healthcheck.NewHeartbeat()
+	time.Sleep(time.Millisecond * 20)
+
+	heartbeatHealthCheckFunc := healthcheck.BuildHeartbeatHealthCheckFunc(hb, time.Millisecond*10)
+	require.NotNil(t, heartbeatHealthCheckFunc)
+
+	healthStatus := heartbeatHealthCheckFunc(ctx)
+	assert.Equal(t, health.StateDown, healthStatus.State)
+	assert.Equal(t, healthcheck.HeartbeatDetails{LastBeat: hb.LastBeat()}, healthStatus.Details)
+}
@@ -0,0 +1,35 @@
+package healthcheck
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/jaredpetersen/go-health/health"
+	"github.com/jaredpetersen/go-rest-template/internal/migration"
+)
+
+type MigrationDetails struct {
+	Pending []string
+}
+
+// BuildMigrationHealthCheckFunc creates a health check that warns when there are database migrations that have not
+// been applied yet.
+func BuildMigrationHealthCheckFunc(db *sql.DB) health.CheckFunc {
+	return func(ctx context.Context) health.Status {
+		pending, err := migration.Pending(ctx, db)
+		if err != nil {
+			return health.Status{State: health.StateDown}
+		}
+
+		if len(pending) > 0 {
+			details := MigrationDetails{Pending: make([]string, len(pending))}
+			for i, m := range pending {
+				details.Pending[i] = fmt.Sprintf("%04d_%s", m.Version, m.Name)
+			}
+
+			return health.Status{State: health.StateWarn, Details: details}
+		}
+
+		return health.Status{State: health.StateUp}
+	}
+}
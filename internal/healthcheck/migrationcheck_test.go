@@ -0,0 +1,49 @@
+package healthcheck_test
+
+import (
+	"context"
+	"database/sql"
+	"github.com/jaredpetersen/go-health/health"
+	"github.com/jaredpetersen/go-rest-template/internal/healthcheck"
+	"github.com/jaredpetersen/go-rest-template/internal/migration"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestIntegrationBuildMigrationHealthCheckFunc(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+
+	cdbContainer, err := setupCockroachDB(ctx)
+	require.NoError(t, err, "Failed to start up CockroachDB container")
+	defer cdbContainer.Terminate(ctx)
+
+	db, err := sql.Open("pgx", cdbContainer.URI+"/defaultdb")
+	require.NoError(t, err, "Failed to open connection to CockroachDB")
+	defer db.Close()
+
+	migrationHealthCheckFunc := healthcheck.BuildMigrationHealthCheckFunc(db)
+	require.NotNil(t, migrationHealthCheckFunc)
+
+	healthStatus := migrationHealthCheckFunc(ctx)
+	assert.Equal(t, health.StateWarn, healthStatus.State)
+	assert.Contains(t, healthStatus.Details.(healthcheck.MigrationDetails).Pending, "0001_create_task")
+
+	// Checking does not change the schema
+	var tableCount int
+	err = db.QueryRowContext(ctx, `select count(*) from information_schema.tables where table_name = 'schema_migration'`).
+		Scan(&tableCount)
+	require.NoError(t, err, "Failed to look up migration table")
+	assert.Zero(t, tableCount, "Created migration table")
+
+	err = migration.Migrate(ctx, db)
+	require.NoError(t, err, "Failed to migrate CockroachDB")
+
+	healthStatus = migrationHealthCheckFunc(ctx)
+	assert.Equal(t, health.StateUp, healthStatus.State)
+	assert.Nil(t, healthStatus.Details)
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"github.com/jaredpetersen/go-health/health"
 	"github.com/jaredpetersen/go-rest-template/internal/redis"
+	"strconv"
+	"strings"
+	"time"
 )
 
 func BuildRedisHealthCheckFunc(rdb redis.Client) health.CheckFunc {
@@ -19,3 +22,128 @@ func BuildRedisHealthCheckFunc(rdb redis.Client) health.CheckFunc {
 		return health.Status{State: health.StateUp}
 	}
 }
+
+type RedisMemoryDetails struct {
+	UsedBytes uint64
+	MaxBytes  uint64
+	Usage     float64
+}
+
+// BuildRedisMemoryHealthCheckFunc creates a health check that warns when Redis memory usage exceeds warnUsage of the
+// configured maxmemory, e.g. 0.9 for 90%. Redis servers without a maxmemory are never considered full.
+func BuildRedisMemoryHealthCheckFunc(rdb redis.Client, warnUsage float64) health.CheckFunc {
+	return func(ctx context.Context) health.Status {
+		rawInfo, err := rdb.Info(ctx, "memory")
+		if err != nil {
+			return health.Status{State: health.StateWarn}
+		}
+		info := parseRedisInfo(rawInfo)
+
+		usedBytes, err := strconv.ParseUint(info["used_memory"], 10, 64)
+		if err != nil {
+			return health.Status{State: health.StateWarn}
+		}
+
+		// maxmemory is not reported by older versions of Redis, treat it as unlimited
+		maxBytes, _ := strconv.ParseUint(info["maxmemory"], 10, 64)
+
+		details := RedisMemoryDetails{UsedBytes: usedBytes, MaxBytes: maxBytes}
+		if maxBytes > 0 {
+			details.Usage = float64(usedBytes) / float64(maxBytes)
+		}
+
+		if details.Usage > warnUsage {
+			return health.Status{State: health.StateWarn, Details: details}
+		}
+
+		return health.Status{State: health.StateUp, Details: details}
+	}
+}
+
+type RedisReplicationDetails struct {
+	Role string
+	Lag  time.Duration
+}
+
+// BuildRedisReplicationHealthCheckFunc creates a health check that warns when replication lag exceeds warnLag.
+//
+// On a replica, the lag is the time since the last interaction with the primary and the check also warns when the
+// link to the primary is down. On a primary, the lag is the largest lag reported for any of the connected replicas.
+func BuildRedisReplicationHealthCheckFunc(rdb redis.Client, warnLag time.Duration) health.CheckFunc {
+	return func(ctx context.Context) health.Status {
+		rawInfo, err := rdb.Info(ctx, "replication")
+		if err != nil {
+			return health.Status{State: health.StateWarn}
+		}
+		info := parseRedisInfo(rawInfo)
+
+		details := RedisReplicationDetails{Role: info["role"]}
+
+		if details.Role == "slave" {
+			if info["master_link_status"] != "up" {
+				return health.Status{State: health.StateWarn, Details: details}
+			}
+
+			lagSeconds, err := strconv.Atoi(info["master_last_io_seconds_ago"])
+			if err != nil {
+				return health.Status{State: health.StateWarn, Details: details}
+			}
+			details.Lag = time.Duration(lagSeconds) * time.Second
+		} else {
+			for key, value := range info {
+				if !strings.HasPrefix(key, "slave") || !strings.Contains(value, "lag=") {
+					continue
+				}
+
+				lagSeconds, err := strconv.Atoi(parseRedisInfoFields(value)["lag"])
+				if err != nil {
+					return health.Status{State: health.StateWarn, Details: details}
+				}
+
+				lag := time.Duration(lagSeconds) * time.Second
+				if lag > details.Lag {
+					details.Lag = lag
+				}
+			}
+		}
+
+		if details.Lag > warnLag {
+			return health.Status{State: health.StateWarn, Details: details}
+		}
+
+		return health.Status{State: health.StateUp, Details: details}
+	}
+}
+
+// parseRedisInfo parses the raw output of the INFO command into its key-value pairs. Section headers and blank lines
+// are ignored.
+func parseRedisInfo(info string) map[string]string {
+	values := make(map[string]string)
+	for _, line := range strings.Split(info, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) == 2 {
+			values[parts[0]] = parts[1]
+		}
+	}
+
+	return values
+}
+
+// parseRedisInfoFields parses INFO values that contain multiple comma-separated fields, e.g.
+// "ip=127.0.0.1,port=6380,state=online,offset=42,lag=0".
+func parseRedisInfoFields(value string) map[string]string {
+	fields := make(map[string]string)
+	for _, field := range strings.Split(value, ",") {
+		parts := strings.SplitN(field, "=", 2)
+		if len(parts) == 2 {
+			fields[parts[0]] = parts[1]
+		}
+	}
+
+	return fields
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"testing"
+	"time"
 )
 
 func TestBuildRedisHealthCheckFuncStateWarn(t *testing.T) {
@@ -38,3 +39,143 @@ func TestBuildRedisHealthCheckFuncStateUp(t *testing.T) {
 	assert.Equal(t, health.StateUp, healthStatus.State)
 	assert.Nil(t, healthStatus.Details)
 }
+
+func TestBuildRedisMemoryHealthCheckFunc(t *testing.T) {
+	ctx := context.Background()
+
+	var tests = []struct {
+		info     string
+		expected health.Status
+	}{
+		{
+			info: "# Memory\r\nused_memory:500\r\nused_memory_human:500B\r\nmaxmemory:1000\r\n",
+			expected: health.Status{
+				State:   health.StateUp,
+				Details: healthcheck.RedisMemoryDetails{UsedBytes: 500, MaxBytes: 1000, Usage: 0.5},
+			},
+		},
+		{
+			info: "# Memory\r\nused_memory:950\r\nmaxmemory:1000\r\n",
+			expected: health.Status{
+				State:   health.StateWarn,
+				Details: healthcheck.RedisMemoryDetails{UsedBytes: 950, MaxBytes: 1000, Usage: 0.95},
+			},
+		},
+		{
+			info: "# Memory\r\nused_memory:950\r\nmaxmemory:0\r\n",
+			expected: health.Status{
+				State:   health.StateUp,
+				Details: healthcheck.RedisMemoryDetails{UsedBytes: 950},
+			},
+		},
+		{
+			info:     "# Memory\r\n",
+			expected: health.Status{State: health.StateWarn},
+		},
+	}
+
+	for _, tt := range tests {
+		rdb := redismock.Client{}
+		rdb.On("Info", ctx, "memory").Return(tt.info, nil)
+
+		redisMemoryHealthCheckFunc := healthcheck.BuildRedisMemoryHealthCheckFunc(&rdb, 0.9)
+		require.NotNil(t, redisMemoryHealthCheckFunc)
+
+		healthStatus := redisMemoryHealthCheckFunc(ctx)
+		assert.Equal(t, tt.expected, healthStatus)
+	}
+}
+
+func TestBuildRedisMemoryHealthCheckFuncStateWarnOnError(t *testing.T) {
+	ctx := context.Background()
+
+	rdb := redismock.Client{}
+	rdb.On("Info", ctx, "memory").Return("", errors.New("bad info"))
+
+	redisMemoryHealthCheckFunc := healthcheck.BuildRedisMemoryHealthCheckFunc(&rdb, 0.9)
+	require.NotNil(t, redisMemoryHealthCheckFunc)
+
+	healthStatus := redisMemoryHealthCheckFunc(ctx)
+	assert.Equal(t, health.StateWarn, healthStatus.State)
+	assert.Nil(t, healthStatus.Details)
+}
+
+func TestBuildRedisReplicationHealthCheckFunc(t *testing.T) {
+	ctx := context.Background()
+
+	var tests = []struct {
+		info     string
+		expected health.Status
+	}{
+		{
+			info: "# Replication\r\nrole:master\r\nconnected_slaves:0\r\n",
+			expected: health.Status{
+				State:   health.StateUp,
+				Details: healthcheck.RedisReplicationDetails{Role: "master"},
+			},
+		},
+		{
+			info: "# Replication\r\nrole:master\r\nconnected_slaves:2\r\n" +
+				"slave0:ip=10.0.0.1,port=6379,state=online,offset=42,lag=1\r\n" +
+				"slave1:ip=10.0.0.2,port=6379,state=online,offset=40,lag=3\r\n",
+			expected: health.Status{
+				State:   health.StateUp,
+				Details: healthcheck.RedisReplicationDetails{Role: "master", Lag: time.Second * 3},
+			},
+		},
+		{
+			info: "# Replication\r\nrole:master\r\nconnected_slaves:1\r\n" +
+				"slave0:ip=10.0.0.1,port=6379,state=online,offset=42,lag=30\r\n",
+			expected: health.Status{
+				State:   health.StateWarn,
+				Details: healthcheck.RedisReplicationDetails{Role: "master", Lag: time.Second * 30},
+			},
+		},
+		{
+			info: "# Replication\r\nrole:slave\r\nmaster_link_status:up\r\nmaster_last_io_seconds_ago:2\r\n",
+			expected: health.Status{
+				State:   health.StateUp,
+				Details: healthcheck.RedisReplicationDetails{Role: "slave", Lag: time.Second * 2},
+			},
+		},
+		{
+			info: "# Replication\r\nrole:slave\r\nmaster_link_status:up\r\nmaster_last_io_seconds_ago:60\r\n",
+			expected: health.Status{
+				State:   health.StateWarn,
+				Details: healthcheck.RedisReplicationDetails{Role: "slave", Lag: time.Minute},
+			},
+		},
+		{
+			info: "# Replication\r\nrole:slave\r\nmaster_link_status:down\r\nmaster_last_io_seconds_ago:-1\r\n",
+			expected: health.Status{
+				State:   health.StateWarn,
+				Details: healthcheck.RedisReplicationDetails{Role: "slave"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		rdb := redismock.Client{}
+		rdb.On("Info", ctx, "replication").Return(tt.info, nil)
+
+		redisReplicationHealthCheckFunc := healthcheck.BuildRedisReplicationHealthCheckFunc(&rdb, time.Second*10)
+		require.NotNil(t, redisReplicationHealthCheckFunc)
+
+		healthStatus := redisReplicationHealthCheckFunc(ctx)
+		assert.Equal(t, tt.expected, healthStatus)
+	}
+}
+
+func TestBuildRedisReplicationHealthCheckFuncStateWarnOnError(t *testing.T) {
+	ctx := context.Background()
+
+	rdb := redismock.Client{}
+	rdb.On("Info", ctx, "replication").Return("", errors.New("bad info"))
+
+	redisReplicationHealthCheckFunc := healthcheck.BuildRedisReplicationHealthCheckFunc(&rdb, time.Second*10)
+	require.NotNil(t, redisReplicationHealthCheckFunc)
+
+	healthStatus := redisReplicationHealthCheckFunc(ctx)
+	assert.Equal(t, health.StateWarn, healthStatus.State)
+	assert.Nil(t, healthStatus.Details)
+}
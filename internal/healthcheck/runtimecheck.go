@@ -0,0 +1,46 @@
+package healthcheck
+
+import (
+	"context"
+	"runtime"
+
+	"github.com/jaredpetersen/go-health/health"
+)
+
+type GoroutineDetails struct {
+	Goroutines int
+}
+
+type MemoryDetails struct {
+	HeapAllocBytes uint64
+}
+
+// BuildGoroutineHealthCheckFunc creates a health check that is down when the number of goroutines exceeds max. A
+// runaway goroutine count usually means that goroutines are stuck and leaking.
+func BuildGoroutineHealthCheckFunc(max int) health.CheckFunc {
+	return func(ctx context.Context) health.Status {
+		goroutines := runtime.NumGoroutine()
+		details := GoroutineDetails{Goroutines: goroutines}
+
+		if goroutines > max {
+			return health.Status{State: health.StateDown, Details: details}
+		}
+
+		return health.Status{State: health.StateUp, Details: details}
+	}
+}
+
+// BuildMemoryHealthCheckFunc creates a health check that is down when the allocated heap exceeds maxBytes.
+func BuildMemoryHealthCheckFunc(maxBytes uint64) health.CheckFunc {
+	return func(ctx context.Context) health.Status {
+		var memStats runtime.MemStats
+		runtime.ReadMemStats(&memStats)
+		details := MemoryDetails{HeapAllocBytes: memStats.HeapAlloc}
+
+		if memStats.HeapAlloc > maxBytes {
+			return health.Status{State: health.StateDown, Details: details}
+		}
+
+		return health.Status{State: health.StateUp, Details: details}
+	}
+}
@@ -0,0 +1,55 @@
+package healthcheck_test
+
+import (
+	"context"
+	"github.com/jaredpetersen/go-health/health"
+	"github.com/jaredpetersen/go-rest-template/internal/healthcheck"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math"
+	"testing"
+)
+
+func TestBuildGoroutineHealthCheckFuncStateUp(t *testing.T) {
+	ctx := context.Background()
+
+	goroutineHealthCheckFunc := healthcheck.BuildGoroutineHealthCheckFunc(math.MaxInt32)
+	require.NotNil(t, goroutineHealthCheckFunc)
+
+	healthStatus := goroutineHealthCheckFunc(ctx)
+	assert.Equal(t, health.StateUp, healthStatus.State)
+	assert.Greater(t, healthStatus.Details.(healthcheck.GoroutineDetails).Goroutines, 0)
+}
+
+func TestBuildGoroutineHealthCheckFuncStateDown(t *testing.T) {
+	ctx := context.Background()
+
+	goroutineHealthCheckFunc := healthcheck.BuildGoroutineHealthCheckFunc(0)
+	require.NotNil(t, goroutineHealthCheckFunc)
+
+	healthStatus := goroutineHealthCheckFunc(ctx)
+	assert.Equal(t, health.StateDown, healthStatus.State)
+	assert.Greater(t, healthStatus.Details.(healthcheck.GoroutineDetails).Goroutines, 0)
+}
+
+func TestBuildMemoryHealthCheckFuncStateUp(t *testing.T) {
+	ctx := context.Background()
+
+	memoryHealthCheckFunc := healthcheck.BuildMemoryHealthCheckFunc(math.MaxUint64)
+	require.NotNil(t, memoryHealthCheckFunc)
+
+	healthStatus := memoryHealthCheckFunc(ctx)
+	assert.Equal(t, health.StateUp, healthStatus.State)
+	assert.Greater(t, healthStatus.Details.(healthcheck.MemoryDetails).HeapAllocBytes, uint64(0))
+}
+
+func TestBuildMemoryHealthCheckFuncStateDown(t *testing.T) {
+	ctx := context.Background()
+
+	memoryHealthCheckFunc := healthcheck.BuildMemoryHealthCheckFunc(1)
+	require.NotNil(t, memoryHealthCheckFunc)
+
+	healthStatus := memoryHealthCheckFunc(ctx)
+	assert.Equal(t, health.StateDown, healthStatus.State)
+	assert.Greater(t, healthStatus.Details.(healthcheck.MemoryDetails).HeapAllocBytes, uint64(0))
+}
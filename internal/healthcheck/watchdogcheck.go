@@ -0,0 +1,41 @@
+package healthcheck
+
+import (
+	"context"
+	"time"
+
+	"github.com/jaredpetersen/go-health/health"
+)
+
+type WatchdogDetails struct {
+	StaleChecks []string
+}
+
+// BuildMonitorWatchdogHealthCheckFunc creates a health check that is down when any of the checks run by the monitor
+// have not reported a status within maxAge. This catches the monitor goroutines having stopped or hung, regardless of
+// what state the checks themselves report.
+//
+// Checks that have not reported a status yet are given maxAge from the time the health check function is built.
+func BuildMonitorWatchdogHealthCheckFunc(mtr *health.Monitor, maxAge time.Duration) health.CheckFunc {
+	created := time.Now()
+
+	return func(ctx context.Context) health.Status {
+		var staleChecks []string
+		for name, checkStatus := range mtr.Check().CheckStatuses {
+			lastUpdate := checkStatus.Timestamp
+			if lastUpdate.IsZero() {
+				lastUpdate = created
+			}
+
+			if time.Since(lastUpdate) > maxAge {
+				staleChecks = append(staleChecks, name)
+			}
+		}
+
+		if len(staleChecks) > 0 {
+			return health.Status{State: health.StateDown, Details: WatchdogDetails{StaleChecks: staleChecks}}
+		}
+
+		return health.Status{State: health.StateUp}
+	}
+}
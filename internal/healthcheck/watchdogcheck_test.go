@@ -0,0 +1,80 @@
+package healthcheck_test
+
+import (
+	"context"
+	"github.com/jaredpetersen/go-health/health"
+	"github.com/jaredpetersen/go-rest-template/internal/healthcheck"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestBuildMonitorWatchdogHealthCheckFuncStateUp(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	check := health.NewCheck("database", func(ctx context.Context) health.Status {
+		return health.Status{State: health.StateDown}
+	})
+	check.TTL = time.Millisecond * 10
+
+	healthMonitor := health.New()
+	healthMonitor.Monitor(ctx, check)
+	time.Sleep(time.Millisecond * 50)
+
+	watchdogHealthCheckFunc := healthcheck.BuildMonitorWatchdogHealthCheckFunc(healthMonitor, time.Second)
+	require.NotNil(t, watchdogHealthCheckFunc)
+
+	// Watchdog only cares that the checks are running, not that they are passing
+	healthStatus := watchdogHealthCheckFunc(ctx)
+	assert.Equal(t, health.StateUp, healthStatus.State)
+	assert.Nil(t, healthStatus.Details)
+}
+
+func TestBuildMonitorWatchdogHealthCheckFuncStateDown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	check := health.NewCheck("database", func(ctx context.Context) health.Status {
+		return health.Status{State: health.StateUp}
+	})
+	check.TTL = time.Millisecond * 10
+
+	healthMonitor := health.New()
+	healthMonitor.Monitor(ctx, check)
+	time.Sleep(time.Millisecond * 50)
+
+	// Stop the monitor goroutines so that the check statuses go stale
+	cancel()
+	time.Sleep(time.Millisecond * 50)
+
+	watchdogHealthCheckFunc := healthcheck.BuildMonitorWatchdogHealthCheckFunc(healthMonitor, time.Millisecond*20)
+	require.NotNil(t, watchdogHealthCheckFunc)
+
+	healthStatus := watchdogHealthCheckFunc(ctx)
+	assert.Equal(t, health.StateDown, healthStatus.State)
+	assert.Equal(t, healthcheck.WatchdogDetails{StaleChecks: []string{"database"}}, healthStatus.Details)
+}
+
+func TestBuildMonitorWatchdogHealthCheckFuncStateDownNeverRun(t *testing.T) {
+	ctx := context.Background()
+
+	// Checks that never complete their first execution never record a timestamp
+	healthMonitor := health.New()
+	healthMonitor.Monitor(ctx, health.NewCheck("database", func(ctx context.Context) health.Status {
+		<-ctx.Done()
+		return health.Status{State: health.StateDown}
+	}))
+
+	watchdogHealthCheckFunc := healthcheck.BuildMonitorWatchdogHealthCheckFunc(healthMonitor, time.Millisecond*20)
+	require.NotNil(t, watchdogHealthCheckFunc)
+
+	healthStatus := watchdogHealthCheckFunc(ctx)
+	assert.Equal(t, health.StateUp, healthStatus.State)
+
+	time.Sleep(time.Millisecond * 50)
+
+	healthStatus = watchdogHealthCheckFunc(ctx)
+	assert.Equal(t, health.StateDown, healthStatus.State)
+	assert.Equal(t, healthcheck.WatchdogDetails{StaleChecks: []string{"database"}}, healthStatus.Details)
+}
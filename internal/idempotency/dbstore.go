@@ -0,0 +1,113 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// DBStore stores idempotency records in a SQL database.
+type DBStore struct {
+	DB *sql.DB
+}
+
+// Reserve atomically creates an in flight record for the key if the key does not already have a record. The key's
+// record is removed first if it has expired. Other expired records are left for the Pruner.
+func (dbs DBStore) Reserve(ctx context.Context, key string, fingerprint string, ttl time.Duration) (*Record, error) {
+	now := time.Now()
+
+	tx, err := dbs.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	const deleteQuery = `delete from idempotency_key where id = $1 and date_expires < $2`
+	_, err = tx.ExecContext(ctx, deleteQuery, key, now)
+	if err != nil {
+		return nil, err
+	}
+
+	const insertQuery = `insert into idempotency_key (id, fingerprint, date_expires)
+		values ($1, $2, $3)
+		on conflict (id) do nothing`
+	res, err := tx.ExecContext(ctx, insertQuery, key, fingerprint, now.Add(ttl))
+	if err != nil {
+		return nil, err
+	}
+
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if inserted == 1 {
+		return nil, tx.Commit()
+	}
+
+	const selectQuery = `select fingerprint, status_code, header, body from idempotency_key where id = $1`
+	var rec Record
+	var statusCode sql.NullInt64
+	var header []byte
+	err = tx.QueryRowContext(ctx, selectQuery, key).Scan(&rec.Fingerprint, &statusCode, &header, &rec.Body)
+	if err != nil {
+		return nil, err
+	}
+	rec.StatusCode = int(statusCode.Int64)
+
+	// Records completed before headers were stored do not have any
+	if header != nil {
+		err = json.Unmarshal(header, &rec.Header)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &rec, tx.Commit()
+}
+
+// Complete stores the response for the key, replacing the reservation. The record is created if the key was reserved
+// in another store.
+func (dbs DBStore) Complete(ctx context.Context, key string, rec Record, ttl time.Duration) error {
+	header, err := json.Marshal(rec.Header)
+	if err != nil {
+		return err
+	}
+
+	const query = `upsert into idempotency_key (id, fingerprint, status_code, header, body, date_expires)
+		values ($1, $2, $3, $4, $5, $6)`
+	_, err = dbs.DB.ExecContext(ctx, query,
+		key,
+		rec.Fingerprint,
+		rec.StatusCode,
+		string(header),
+		rec.Body,
+		time.Now().Add(ttl))
+
+	return err
+}
+
+// Release removes the record for the key.
+func (dbs DBStore) Release(ctx context.Context, key string) error {
+	const query = `delete from idempotency_key where id = $1`
+	_, err := dbs.DB.ExecContext(ctx, query, key)
+
+	return err
+}
+
+// PruneExpired removes up to limit records that expired before the given time. The number of records that were removed
+// is returned.
+func (dbs DBStore) PruneExpired(ctx context.Context, before time.Time, limit int) (int, error) {
+	const query = `delete from idempotency_key where date_expires < $1 order by date_expires limit $2`
+	res, err := dbs.DB.ExecContext(ctx, query, before, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	pruned, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(pruned), nil
+}
@@ -0,0 +1,197 @@
+package idempotency_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/jaredpetersen/go-rest-template/internal/idempotency"
+	"github.com/jaredpetersen/go-rest-template/internal/migration"
+	"net/http"
+	"testing"
+	"time"
+
+	_ "github.com/jackc/pgx/v4/stdlib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+type cockroachDBContainer struct {
+	testcontainers.Container
+	URI string
+}
+
+func setupCockroachDB(ctx context.Context) (*cockroachDBContainer, error) {
+	req := testcontainers.ContainerRequest{
+		Image:        "cockroachdb/cockroach:latest-v21.1",
+		ExposedPorts: []string{"26257/tcp", "8080/tcp"},
+		WaitingFor:   wait.ForHTTP("/health").WithPort("8080"),
+		Cmd:          []string{"start-single-node", "--insecure"},
+		SkipReaper:   true,
+	}
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	mappedPort, err := container.MappedPort(ctx, "26257")
+	if err != nil {
+		return nil, err
+	}
+
+	hostIP, err := container.Host(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	uri := fmt.Sprintf("postgres://root@%s:%s", hostIP, mappedPort.Port())
+
+	return &cockroachDBContainer{Container: container, URI: uri}, nil
+}
+
+func initCockroachDB(ctx context.Context, db *sql.DB) error {
+	const query = `CREATE DATABASE projectmanagement`
+	_, err := db.ExecContext(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return migration.Migrate(ctx, db)
+}
+
+func TestIntegrationDBStore(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+
+	cdbContainer, err := setupCockroachDB(ctx)
+	require.NoError(t, err, "Failed to start up CockroachDB container")
+	defer cdbContainer.Terminate(ctx)
+
+	db, err := sql.Open("pgx", cdbContainer.URI+"/projectmanagement")
+	require.NoError(t, err, "Failed to open connection to CockroachDB")
+	defer db.Close()
+
+	err = initCockroachDB(ctx, db)
+	require.NoError(t, err, "Failed to initialize CockroachDB")
+
+	store := idempotency.DBStore{DB: db}
+
+	// Reserve
+	rec, err := store.Reserve(ctx, "key", "abc", time.Minute)
+	require.NoError(t, err, "Reserve returned error")
+	assert.Nil(t, rec, "Reserve returned record for new key")
+
+	// Reserve while in flight
+	rec, err = store.Reserve(ctx, "key", "abc", time.Minute)
+	require.NoError(t, err, "Reserve returned error")
+	assert.Equal(t, &idempotency.Record{Fingerprint: "abc"}, rec)
+
+	// Complete
+	completed := idempotency.Record{
+		Fingerprint: "abc",
+		StatusCode:  201,
+		Header:      http.Header{"Location": {"/tasks/1"}},
+		Body:        []byte(`{"id":"1"}`),
+	}
+	err = store.Complete(ctx, "key", completed, time.Hour)
+	require.NoError(t, err, "Complete returned error")
+
+	rec, err = store.Reserve(ctx, "key", "abc", time.Minute)
+	require.NoError(t, err, "Reserve returned error")
+	assert.Equal(t, &completed, rec)
+
+	// Release
+	err = store.Release(ctx, "key")
+	require.NoError(t, err, "Release returned error")
+
+	rec, err = store.Reserve(ctx, "key", "abc", time.Minute)
+	require.NoError(t, err, "Reserve returned error")
+	assert.Nil(t, rec, "Reserve returned record for released key")
+}
+
+func TestIntegrationDBStoreExpired(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+
+	cdbContainer, err := setupCockroachDB(ctx)
+	require.NoError(t, err, "Failed to start up CockroachDB container")
+	defer cdbContainer.Terminate(ctx)
+
+	db, err := sql.Open("pgx", cdbContainer.URI+"/projectmanagement")
+	require.NoError(t, err, "Failed to open connection to CockroachDB")
+	defer db.Close()
+
+	err = initCockroachDB(ctx, db)
+	require.NoError(t, err, "Failed to initialize CockroachDB")
+
+	store := idempotency.DBStore{DB: db}
+
+	err = store.Complete(ctx, "key", idempotency.Record{Fingerprint: "abc", StatusCode: 201}, -time.Minute)
+	require.NoError(t, err, "Complete returned error")
+
+	rec, err := store.Reserve(ctx, "key", "abc", time.Minute)
+	require.NoError(t, err, "Reserve returned error")
+	assert.Nil(t, rec, "Reserve returned expired record")
+}
+
+func TestIntegrationDBStorePruneExpired(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+
+	cdbContainer, err := setupCockroachDB(ctx)
+	require.NoError(t, err, "Failed to start up CockroachDB container")
+	defer cdbContainer.Terminate(ctx)
+
+	db, err := sql.Open("pgx", cdbContainer.URI+"/projectmanagement")
+	require.NoError(t, err, "Failed to open connection to CockroachDB")
+	defer db.Close()
+
+	err = initCockroachDB(ctx, db)
+	require.NoError(t, err, "Failed to initialize CockroachDB")
+
+	store := idempotency.DBStore{DB: db}
+
+	for _, key := range []string{"expired-1", "expired-2", "expired-3"} {
+		err = store.Complete(ctx, key, idempotency.Record{Fingerprint: "abc", StatusCode: 201}, -time.Minute)
+		require.NoError(t, err, "Complete returned error")
+	}
+	err = store.Complete(ctx, "current", idempotency.Record{Fingerprint: "abc", StatusCode: 201}, time.Hour)
+	require.NoError(t, err, "Complete returned error")
+
+	// Reserving a key only removes that key's expired record
+	rec, err := store.Reserve(ctx, "expired-1", "abc", time.Minute)
+	require.NoError(t, err, "Reserve returned error")
+	assert.Nil(t, rec, "Reserve returned expired record")
+
+	var count int
+	err = db.QueryRowContext(ctx, `select count(*) from idempotency_key`).Scan(&count)
+	require.NoError(t, err, "Failed to count records")
+	assert.Equal(t, 4, count, "Reserve removed other expired records")
+
+	// Pruning removes the rest, a batch at a time
+	pruned, err := store.PruneExpired(ctx, time.Now(), 1)
+	require.NoError(t, err, "PruneExpired returned error")
+	assert.Equal(t, 1, pruned)
+
+	pruned, err = store.PruneExpired(ctx, time.Now(), 10)
+	require.NoError(t, err, "PruneExpired returned error")
+	assert.Equal(t, 1, pruned)
+
+	rec, err = store.Reserve(ctx, "current", "abc", time.Minute)
+	require.NoError(t, err, "Reserve returned error")
+	require.NotNil(t, rec, "PruneExpired removed unexpired record")
+	assert.Equal(t, 201, rec.StatusCode)
+}
@@ -0,0 +1,98 @@
+// Package idempotency records the responses to requests so that retried requests can be replayed instead of being
+// performed again.
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Record is the state of a request made with an idempotency key.
+type Record struct {
+	// Fingerprint identifies the request so that a key cannot be reused for a different request.
+	Fingerprint string `json:"fingerprint"`
+	// StatusCode of the response. Zero while the request is in flight.
+	StatusCode int `json:"statusCode"`
+	// Header of the response, limited to the headers that describe the outcome such as ETag and Location.
+	Header http.Header `json:"header,omitempty"`
+	// Body of the response.
+	Body []byte `json:"body"`
+}
+
+// InFlight indicates whether or not the request is still being performed.
+func (r Record) InFlight() bool {
+	return r.StatusCode == 0
+}
+
+// Store stores idempotency records.
+type Store interface {
+	// Reserve atomically creates an in flight record for the key if the key does not already have a record. If the key
+	// already has a record, the existing record is returned. Otherwise nil will be returned for both the record and
+	// error. The reservation expires after the TTL so that a crashed request does not block the key forever.
+	Reserve(ctx context.Context, key string, fingerprint string, ttl time.Duration) (*Record, error)
+	// Complete stores the response for the key, replacing the reservation. The record expires after the TTL.
+	Complete(ctx context.Context, key string, rec Record, ttl time.Duration) error
+	// Release removes the record for the key so that the request may be performed again.
+	Release(ctx context.Context, key string) error
+}
+
+// Key builds the key that a principal's idempotency key is stored under. Keys are hashed so that the parts cannot run
+// together and collide with another principal's key.
+func Key(tenantID string, subject string, idempotencyKey string) string {
+	hash := sha256.Sum256([]byte(tenantID + "\x00" + subject + "\x00" + idempotencyKey))
+	return "idempotency." + hex.EncodeToString(hash[:])
+}
+
+// Fingerprint identifies a request by its method, path, and body.
+func Fingerprint(method string, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// FallbackStore is a store that uses a fallback store when its primary store fails, e.g. when Redis is unavailable.
+//
+// Each operation falls back independently so duplicate requests may be performed while the primary store is flapping.
+type FallbackStore struct {
+	Primary  Store
+	Fallback Store
+}
+
+// Reserve reserves the key using the primary store, falling back to the fallback store if the primary store fails.
+func (fs FallbackStore) Reserve(ctx context.Context, key string, fingerprint string, ttl time.Duration) (*Record, error) {
+	rec, err := fs.Primary.Reserve(ctx, key, fingerprint, ttl)
+	if err == nil {
+		return rec, nil
+	}
+
+	log.Warn().Err(err).Msg("Failed to reserve idempotency key in primary store, using fallback")
+	return fs.Fallback.Reserve(ctx, key, fingerprint, ttl)
+}
+
+// Complete stores the response using the primary store, falling back to the fallback store if the primary store fails.
+func (fs FallbackStore) Complete(ctx context.Context, key string, rec Record, ttl time.Duration) error {
+	err := fs.Primary.Complete(ctx, key, rec, ttl)
+	if err == nil {
+		return nil
+	}
+
+	log.Warn().Err(err).Msg("Failed to complete idempotency key in primary store, using fallback")
+	return fs.Fallback.Complete(ctx, key, rec, ttl)
+}
+
+// Release removes the record from both stores since either may have reserved the key.
+func (fs FallbackStore) Release(ctx context.Context, key string) error {
+	primaryErr := fs.Primary.Release(ctx, key)
+	fallbackErr := fs.Fallback.Release(ctx, key)
+	if primaryErr != nil {
+		return primaryErr
+	}
+
+	return fallbackErr
+}
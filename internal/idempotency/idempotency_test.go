@@ -0,0 +1,124 @@
+package idempotency_test
+
+import (
+	"context"
+	"errors"
+	"github.com/jaredpetersen/go-rest-template/internal/idempotency"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	redismock "github.com/jaredpetersen/go-rest-template/internal/redis/mocks"
+)
+
+func TestKey(t *testing.T) {
+	key := idempotency.Key("tenant-1", "user-1", "abc")
+	assert.Regexp(t, "^idempotency\\.[0-9a-f]{64}$", key)
+	assert.Equal(t, key, idempotency.Key("tenant-1", "user-1", "abc"), "Key is not stable")
+
+	// Parts must not be able to run together
+	assert.NotEqual(t, key, idempotency.Key("tenant-1", "user-1a", "bc"))
+	assert.NotEqual(t, key, idempotency.Key("tenant-2", "user-1", "abc"))
+	assert.NotEqual(t, key, idempotency.Key("tenant-1", "user-2", "abc"))
+}
+
+func TestFingerprint(t *testing.T) {
+	fingerprint := idempotency.Fingerprint("POST", "/tasks", []byte(`{"description":"Buy milk"}`))
+	assert.Len(t, fingerprint, 64)
+	assert.Equal(t, fingerprint, idempotency.Fingerprint("POST", "/tasks", []byte(`{"description":"Buy milk"}`)))
+
+	assert.NotEqual(t, fingerprint, idempotency.Fingerprint("POST", "/tasks", []byte(`{"description":"Buy eggs"}`)))
+	assert.NotEqual(t, fingerprint, idempotency.Fingerprint("PUT", "/tasks", []byte(`{"description":"Buy milk"}`)))
+	assert.NotEqual(t, fingerprint, idempotency.Fingerprint("POST", "/projects", []byte(`{"description":"Buy milk"}`)))
+}
+
+func TestRecordInFlight(t *testing.T) {
+	assert.True(t, idempotency.Record{Fingerprint: "abc"}.InFlight())
+	assert.False(t, idempotency.Record{Fingerprint: "abc", StatusCode: 201}.InFlight())
+}
+
+func TestFallbackStoreReserve(t *testing.T) {
+	ctx := context.Background()
+
+	primary := redismock.Client{}
+	primary.On("Eval", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
+
+	fallback := redismock.Client{}
+
+	store := idempotency.FallbackStore{
+		Primary:  idempotency.RedisStore{Redis: &primary},
+		Fallback: idempotency.RedisStore{Redis: &fallback},
+	}
+
+	rec, err := store.Reserve(ctx, "key", "abc", time.Minute)
+	assert.NoError(t, err, "Returned error")
+	assert.Nil(t, rec, "Returned record")
+
+	primary.AssertExpectations(t)
+	fallback.AssertExpectations(t)
+}
+
+func TestFallbackStoreReservePrimaryError(t *testing.T) {
+	ctx := context.Background()
+
+	primary := redismock.Client{}
+	primary.On("Eval", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("Failed"))
+
+	fallback := redismock.Client{}
+	fallback.On("Eval", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(`{"fingerprint":"abc","statusCode":0}`, nil)
+
+	store := idempotency.FallbackStore{
+		Primary:  idempotency.RedisStore{Redis: &primary},
+		Fallback: idempotency.RedisStore{Redis: &fallback},
+	}
+
+	rec, err := store.Reserve(ctx, "key", "abc", time.Minute)
+	assert.NoError(t, err, "Returned error")
+	assert.Equal(t, &idempotency.Record{Fingerprint: "abc"}, rec)
+}
+
+func TestFallbackStoreCompletePrimaryError(t *testing.T) {
+	ctx := context.Background()
+
+	primary := redismock.Client{}
+	primary.On("Set", mock.Anything, "key", mock.Anything, time.Hour).Return(errors.New("Failed"))
+
+	fallback := redismock.Client{}
+	fallback.On("Set", mock.Anything, "key", mock.Anything, time.Hour).Return(nil)
+
+	store := idempotency.FallbackStore{
+		Primary:  idempotency.RedisStore{Redis: &primary},
+		Fallback: idempotency.RedisStore{Redis: &fallback},
+	}
+
+	err := store.Complete(ctx, "key", idempotency.Record{Fingerprint: "abc", StatusCode: 201}, time.Hour)
+	assert.NoError(t, err, "Returned error")
+
+	fallback.AssertExpectations(t)
+}
+
+func TestFallbackStoreRelease(t *testing.T) {
+	ctx := context.Background()
+
+	expectedErr := errors.New("Failed")
+
+	primary := redismock.Client{}
+	primary.On("Del", mock.Anything, "key").Return(expectedErr)
+
+	fallback := redismock.Client{}
+	fallback.On("Del", mock.Anything, "key").Return(nil)
+
+	store := idempotency.FallbackStore{
+		Primary:  idempotency.RedisStore{Redis: &primary},
+		Fallback: idempotency.RedisStore{Redis: &fallback},
+	}
+
+	// Released from both stores even though the primary store failed
+	err := store.Release(ctx, "key")
+	assert.EqualError(t, err, expectedErr.Error(), "Did not return error")
+
+	fallback.AssertExpectations(t)
+}
@@ -0,0 +1,75 @@
+package idempotency
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ExpiredRecordPruner removes expired records from a store that does not expire them on its own.
+type ExpiredRecordPruner interface {
+	PruneExpired(ctx context.Context, before time.Time, limit int) (int, error)
+}
+
+// Pruner removes expired records from a store so that they do not pile up, keeping the cleanup off of the path of the
+// requests that reserve keys.
+//
+// Pruning is idempotent, so every replica may run a pruner without coordinating.
+type Pruner struct {
+	Store ExpiredRecordPruner
+	// BatchSize is the most records removed at a time, so that pruning does not hold up reservations. Defaults to 1000.
+	BatchSize int
+	// Now reports the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// NewPruner creates a pruner with default values. The returned pointer will never be nil.
+func NewPruner() *Pruner {
+	return &Pruner{
+		BatchSize: 1000,
+		Now:       time.Now,
+	}
+}
+
+// Start runs the pruner on an interval in a separate goroutine until the context is done.
+func (p *Pruner) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				pruned, err := p.Run(ctx)
+				if err != nil {
+					log.Error().Err(err).Msg("Failed to prune idempotency records")
+				}
+				if pruned > 0 {
+					log.Info().Int("records", pruned).Msg("Pruned idempotency records")
+				}
+			}
+		}
+	}()
+}
+
+// Run removes every record that has expired, a batch at a time. The number of records that were removed is returned,
+// even if some of them could not be.
+func (p *Pruner) Run(ctx context.Context) (int, error) {
+	before := p.Now()
+
+	total := 0
+	for {
+		pruned, err := p.Store.PruneExpired(ctx, before, p.BatchSize)
+		total += pruned
+		if err != nil {
+			return total, err
+		}
+
+		if pruned < p.BatchSize {
+			return total, nil
+		}
+	}
+}
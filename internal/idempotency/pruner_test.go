@@ -0,0 +1,53 @@
+package idempotency_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jaredpetersen/go-rest-template/internal/idempotency"
+	idempotencymock "github.com/jaredpetersen/go-rest-template/internal/idempotency/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPrunerRun(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Date(2021, time.October, 1, 12, 0, 0, 0, time.UTC)
+
+	// Batches continue until one comes back short
+	store := idempotencymock.ExpiredRecordPruner{}
+	store.On("PruneExpired", mock.Anything, now, 10).Return(10, nil).Twice()
+	store.On("PruneExpired", mock.Anything, now, 10).Return(3, nil).Once()
+
+	p := idempotency.NewPruner()
+	p.Store = &store
+	p.BatchSize = 10
+	p.Now = func() time.Time { return now }
+
+	pruned, err := p.Run(ctx)
+	assert.NoError(t, err, "Returned error")
+	assert.Equal(t, 23, pruned, "Returned incorrect number of pruned records")
+
+	store.AssertExpectations(t)
+}
+
+func TestPrunerRunReturnsErrorOnStoreError(t *testing.T) {
+	ctx := context.Background()
+
+	store := idempotencymock.ExpiredRecordPruner{}
+	store.On("PruneExpired", mock.Anything, mock.Anything, 10).Return(10, nil).Once()
+	store.On("PruneExpired", mock.Anything, mock.Anything, 10).Return(0, errors.New("failed")).Once()
+
+	p := idempotency.NewPruner()
+	p.Store = &store
+	p.BatchSize = 10
+
+	pruned, err := p.Run(ctx)
+	assert.Error(t, err, "Did not return error")
+	assert.Equal(t, 10, pruned, "Returned incorrect number of pruned records")
+
+	store.AssertExpectations(t)
+}
@@ -0,0 +1,73 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jaredpetersen/go-rest-template/internal/redis"
+)
+
+// reserveScript sets the key to the reservation unless it already exists, in which case the existing value is
+// returned.
+//
+// KEYS[1] - key to reserve
+// ARGV[1] - reservation record
+// ARGV[2] - reservation TTL in milliseconds
+const reserveScript = `
+if redis.call('SET', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2]) then
+  return false
+end
+
+return redis.call('GET', KEYS[1])
+`
+
+// RedisStore stores idempotency records in Redis.
+type RedisStore struct {
+	Redis redis.Client
+}
+
+// Reserve atomically creates an in flight record for the key if the key does not already have a record.
+func (rs RedisStore) Reserve(ctx context.Context, key string, fingerprint string, ttl time.Duration) (*Record, error) {
+	reservation, err := json.Marshal(Record{Fingerprint: fingerprint})
+	if err != nil {
+		return nil, err
+	}
+
+	val, err := rs.Redis.Eval(ctx, reserveScript, []string{key}, reservation, ttl.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	if val == nil {
+		return nil, nil
+	}
+
+	raw, ok := val.(string)
+	if !ok {
+		return nil, fmt.Errorf("unexpected idempotency record %v", val)
+	}
+
+	var rec Record
+	err = json.Unmarshal([]byte(raw), &rec)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rec, nil
+}
+
+// Complete stores the response for the key, replacing the reservation.
+func (rs RedisStore) Complete(ctx context.Context, key string, rec Record, ttl time.Duration) error {
+	value, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	return rs.Redis.Set(ctx, key, value, ttl)
+}
+
+// Release removes the record for the key.
+func (rs RedisStore) Release(ctx context.Context, key string) error {
+	return rs.Redis.Del(ctx, key)
+}
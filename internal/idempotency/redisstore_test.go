@@ -0,0 +1,97 @@
+package idempotency_test
+
+import (
+	"context"
+	"errors"
+	"github.com/jaredpetersen/go-rest-template/internal/idempotency"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	redismock "github.com/jaredpetersen/go-rest-template/internal/redis/mocks"
+)
+
+func TestRedisStoreReserve(t *testing.T) {
+	ctx := context.Background()
+
+	rdb := redismock.Client{}
+	rdb.On("Eval", mock.Anything, mock.AnythingOfType("string"), []string{"key"}, []byte(`{"fingerprint":"abc","statusCode":0,"body":null}`), int64(60000)).
+		Return(nil, nil)
+
+	store := idempotency.RedisStore{Redis: &rdb}
+
+	rec, err := store.Reserve(ctx, "key", "abc", time.Minute)
+	assert.NoError(t, err, "Returned error")
+	assert.Nil(t, rec, "Returned record")
+
+	rdb.AssertExpectations(t)
+}
+
+func TestRedisStoreReserveExisting(t *testing.T) {
+	ctx := context.Background()
+
+	rdb := redismock.Client{}
+	rdb.On("Eval", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(`{"fingerprint":"abc","statusCode":201,"body":"eyJpZCI6IjEifQ=="}`, nil)
+
+	store := idempotency.RedisStore{Redis: &rdb}
+
+	rec, err := store.Reserve(ctx, "key", "abc", time.Minute)
+	assert.NoError(t, err, "Returned error")
+	assert.Equal(t, &idempotency.Record{Fingerprint: "abc", StatusCode: 201, Body: []byte(`{"id":"1"}`)}, rec)
+}
+
+func TestRedisStoreReserveReturnsRedisError(t *testing.T) {
+	ctx := context.Background()
+
+	expectedErr := errors.New("Failed")
+
+	rdb := redismock.Client{}
+	rdb.On("Eval", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, expectedErr)
+
+	store := idempotency.RedisStore{Redis: &rdb}
+
+	rec, err := store.Reserve(ctx, "key", "abc", time.Minute)
+	assert.EqualError(t, err, expectedErr.Error(), "Did not return error")
+	assert.Nil(t, rec, "Returned record")
+}
+
+func TestRedisStoreComplete(t *testing.T) {
+	ctx := context.Background()
+
+	rdb := redismock.Client{}
+	rdb.On("Set", mock.Anything, "key",
+		[]byte(`{"fingerprint":"abc","statusCode":201,"header":{"Location":["/tasks/1"]},"body":"eyJpZCI6IjEifQ=="}`),
+		time.Hour).
+		Return(nil)
+
+	store := idempotency.RedisStore{Redis: &rdb}
+
+	rec := idempotency.Record{
+		Fingerprint: "abc",
+		StatusCode:  201,
+		Header:      http.Header{"Location": {"/tasks/1"}},
+		Body:        []byte(`{"id":"1"}`),
+	}
+	err := store.Complete(ctx, "key", rec, time.Hour)
+	assert.NoError(t, err, "Returned error")
+
+	rdb.AssertExpectations(t)
+}
+
+func TestRedisStoreRelease(t *testing.T) {
+	ctx := context.Background()
+
+	rdb := redismock.Client{}
+	rdb.On("Del", mock.Anything, "key").Return(nil)
+
+	store := idempotency.RedisStore{Redis: &rdb}
+
+	err := store.Release(ctx, "key")
+	assert.NoError(t, err, "Returned error")
+
+	rdb.AssertExpectations(t)
+}
@@ -0,0 +1,179 @@
+// Package migration manages the database schema through a set of ordered SQL migrations.
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed sql/*.sql
+var files embed.FS
+
+// Migration is a single, versioned change to the database schema.
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+// Load reads all of the embedded migrations, ordered by version.
+//
+// Migration files must be named <version>_<name>.sql, e.g. 0001_create_task.sql.
+func Load() ([]Migration, error) {
+	entries, err := files.ReadDir("sql")
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]Migration, 0, len(entries))
+	for _, entry := range entries {
+		fileName := entry.Name()
+
+		parts := strings.SplitN(strings.TrimSuffix(fileName, ".sql"), "_", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("migration file %s is not named <version>_<name>.sql", fileName)
+		}
+
+		version, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("migration file %s has an invalid version: %w", fileName, err)
+		}
+
+		query, err := files.ReadFile(path.Join("sql", fileName))
+		if err != nil {
+			return nil, err
+		}
+
+		migrations = append(migrations, Migration{Version: version, Name: parts[1], SQL: string(query)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return nil, fmt.Errorf("duplicate migration version %d", migrations[i].Version)
+		}
+	}
+
+	return migrations, nil
+}
+
+// Pending returns the migrations that have not yet been applied to the database, ordered by version. The database is
+// only read from, so it is safe to call from health checks; every migration is pending if none have ever been applied.
+func Pending(ctx context.Context, db *sql.DB) ([]Migration, error) {
+	applied, err := appliedVersions(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, m := range migrations {
+		if !applied[m.Version] {
+			pending = append(pending, m)
+		}
+	}
+
+	return pending, nil
+}
+
+// Migrate applies all pending migrations to the database. Each migration is applied in its own transaction alongside
+// the record of its version so that a failure part of the way through can be safely retried.
+func Migrate(ctx context.Context, db *sql.DB) error {
+	err := createVersionTable(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	pending, err := Pending(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range pending {
+		err = apply(ctx, db, m)
+		if err != nil {
+			return fmt.Errorf("failed to apply migration %d_%s: %w", m.Version, m.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// apply runs a single migration and records its version.
+func apply(ctx context.Context, db *sql.DB, m Migration) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, m.SQL)
+	if err != nil {
+		return err
+	}
+
+	const query = `insert into schema_migration (version, name) values ($1, $2)`
+	_, err = tx.ExecContext(ctx, query, m.Version, m.Name)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// createVersionTable creates the table used to keep track of applied migrations if it does not already exist.
+func createVersionTable(ctx context.Context, db *sql.DB) error {
+	const query = `create table if not exists schema_migration (
+		version int primary key not null,
+		name varchar(255) not null,
+		date_applied timestamp with time zone not null default now())`
+	_, err := db.ExecContext(ctx, query)
+
+	return err
+}
+
+// appliedVersions retrieves the set of migration versions that have already been applied. The set is empty if the
+// table used to keep track of applied migrations has not been created yet.
+func appliedVersions(ctx context.Context, db *sql.DB) (map[int]bool, error) {
+	const existsQuery = `select exists (select 1 from information_schema.tables
+		where table_schema = current_schema() and table_name = 'schema_migration')`
+	var exists bool
+	err := db.QueryRowContext(ctx, existsQuery).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int]bool)
+	if !exists {
+		return applied, nil
+	}
+
+	const query = `select version from schema_migration`
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var version int
+		err = rows.Scan(&version)
+		if err != nil {
+			return nil, err
+		}
+		applied[version] = true
+	}
+
+	return applied, rows.Err()
+}
@@ -0,0 +1,100 @@
+package migration_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/jaredpetersen/go-rest-template/internal/migration"
+	"testing"
+
+	_ "github.com/jackc/pgx/v4/stdlib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+type cockroachDBContainer struct {
+	testcontainers.Container
+	URI string
+}
+
+func setupCockroachDB(ctx context.Context) (*cockroachDBContainer, error) {
+	req := testcontainers.ContainerRequest{
+		Image:        "cockroachdb/cockroach:latest-v21.1",
+		ExposedPorts: []string{"26257/tcp", "8080/tcp"},
+		WaitingFor:   wait.ForHTTP("/health").WithPort("8080"),
+		Cmd:          []string{"start-single-node", "--insecure"},
+		SkipReaper:   true,
+	}
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	mappedPort, err := container.MappedPort(ctx, "26257")
+	if err != nil {
+		return nil, err
+	}
+
+	hostIP, err := container.Host(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	uri := fmt.Sprintf("postgres://root@%s:%s", hostIP, mappedPort.Port())
+
+	return &cockroachDBContainer{Container: container, URI: uri}, nil
+}
+
+func TestLoad(t *testing.T) {
+	migrations, err := migration.Load()
+	require.NoError(t, err, "Returned error")
+	require.NotEmpty(t, migrations, "Did not load any migrations")
+
+	for i, m := range migrations {
+		assert.NotEmpty(t, m.Name, "Migration is missing a name")
+		assert.NotEmpty(t, m.SQL, "Migration is missing SQL")
+
+		if i > 0 {
+			assert.Greater(t, m.Version, migrations[i-1].Version, "Migrations are not ordered by version")
+		}
+	}
+}
+
+func TestIntegrationMigrate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+
+	cdbContainer, err := setupCockroachDB(ctx)
+	require.NoError(t, err, "Failed to start up CockroachDB container")
+	defer cdbContainer.Terminate(ctx)
+
+	db, err := sql.Open("pgx", cdbContainer.URI+"/defaultdb")
+	require.NoError(t, err, "Failed to open connection to CockroachDB")
+	defer db.Close()
+
+	migrations, err := migration.Load()
+	require.NoError(t, err, "Failed to load migrations")
+
+	pending, err := migration.Pending(ctx, db)
+	require.NoError(t, err, "Pending returned error")
+	assert.Equal(t, migrations, pending, "Not all migrations are pending")
+
+	err = migration.Migrate(ctx, db)
+	require.NoError(t, err, "Migrate returned error")
+
+	pending, err = migration.Pending(ctx, db)
+	require.NoError(t, err, "Pending returned error")
+	assert.Empty(t, pending, "Migrations are still pending")
+
+	// Migrating again must be a no-op
+	err = migration.Migrate(ctx, db)
+	require.NoError(t, err, "Second Migrate returned error")
+}
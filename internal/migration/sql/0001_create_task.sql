@@ -0,0 +1,7 @@
+create table if not exists task (
+	id uuid primary key not null,
+	description varchar(255) not null,
+	date_due timestamp with time zone,
+	date_created timestamp with time zone not null,
+	date_updated timestamp with time zone not null
+);
@@ -0,0 +1,3 @@
+alter table task add column if not exists tenant_id varchar(255) not null default '';
+alter table task add column if not exists owner_id varchar(255) not null default '';
+create index if not exists task_tenant_id_owner_id_idx on task (tenant_id, owner_id);
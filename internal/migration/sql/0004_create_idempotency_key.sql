@@ -0,0 +1,8 @@
+create table if not exists idempotency_key (
+	id varchar(255) primary key not null,
+	fingerprint varchar(64) not null,
+	status_code int,
+	body bytea,
+	date_expires timestamp with time zone not null
+);
+create index if not exists idempotency_key_date_expires_idx on idempotency_key (date_expires);
@@ -0,0 +1 @@
+alter table task add column if not exists version int not null default 1;
@@ -0,0 +1,2 @@
+alter table task add column if not exists search_terms string[] not null default '{}';
+create inverted index if not exists task_tenant_id_search_terms_idx on task (tenant_id, search_terms);
@@ -0,0 +1,3 @@
+update task
+set search_terms = array_remove(regexp_split_to_array(lower(description), '[^\p{L}\p{Nd}]+'), '')
+where search_terms = '{}';
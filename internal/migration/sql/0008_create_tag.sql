@@ -0,0 +1,15 @@
+create table if not exists tag (
+	id uuid primary key not null,
+	tenant_id varchar(255) not null,
+	name varchar(255) not null,
+	date_created timestamp with time zone not null,
+	date_updated timestamp with time zone not null,
+	unique index tag_tenant_id_name_idx (tenant_id, name)
+);
+create table if not exists task_tag (
+	task_id uuid not null,
+	tag_id uuid not null,
+	tenant_id varchar(255) not null,
+	primary key (task_id, tag_id),
+	index task_tag_tenant_id_tag_id_idx (tenant_id, tag_id)
+);
@@ -0,0 +1,14 @@
+create table if not exists project (
+	id uuid primary key not null,
+	tenant_id varchar(255) not null,
+	owner_id varchar(255) not null,
+	name varchar(255) not null,
+	description string not null default '',
+	task_count int not null default 0,
+	date_archived timestamp with time zone,
+	date_created timestamp with time zone not null,
+	date_updated timestamp with time zone not null,
+	index project_tenant_id_name_idx (tenant_id, name)
+);
+alter table task add column if not exists project_id uuid;
+create index if not exists task_tenant_id_project_id_idx on task (tenant_id, project_id);
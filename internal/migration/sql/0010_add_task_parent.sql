@@ -0,0 +1,3 @@
+alter table task add column if not exists parent_id uuid;
+alter table task add column if not exists date_completed timestamp with time zone;
+create index if not exists task_tenant_id_parent_id_idx on task (tenant_id, parent_id);
@@ -0,0 +1,8 @@
+create table if not exists task_dependency (
+	blocked_id uuid not null,
+	blocker_id uuid not null,
+	tenant_id varchar(255) not null,
+	date_created timestamp with time zone not null,
+	primary key (blocked_id, blocker_id),
+	index task_dependency_tenant_id_blocker_id_idx (tenant_id, blocker_id)
+);
@@ -0,0 +1 @@
+alter table task add column if not exists recurrence jsonb;
@@ -0,0 +1,2 @@
+alter table task add column if not exists reminder_offsets jsonb;
+create index if not exists task_date_due_idx on task (date_due) where date_completed is null;
@@ -0,0 +1,28 @@
+create table if not exists webhook_subscription (
+	id uuid primary key not null,
+	tenant_id varchar(255) not null,
+	owner_id varchar(255) not null,
+	url string not null,
+	events jsonb not null,
+	secret varchar(255) not null,
+	date_created timestamp with time zone not null,
+	date_updated timestamp with time zone not null,
+	index webhook_subscription_tenant_id_idx (tenant_id)
+);
+create table if not exists webhook_delivery (
+	id uuid primary key not null,
+	tenant_id varchar(255) not null,
+	subscription_id uuid not null,
+	event_id uuid not null,
+	event_type varchar(64) not null,
+	payload bytes not null,
+	status varchar(16) not null,
+	attempts int not null default 0,
+	date_next_attempt timestamp with time zone not null,
+	response_status int not null default 0,
+	error string not null default '',
+	date_created timestamp with time zone not null,
+	date_updated timestamp with time zone not null,
+	index webhook_delivery_tenant_id_subscription_id_idx (tenant_id, subscription_id, date_created),
+	index webhook_delivery_status_date_next_attempt_idx (status, date_next_attempt)
+);
@@ -0,0 +1,15 @@
+create table if not exists outbox_message (
+	id uuid primary key not null,
+	tenant_id varchar(255) not null,
+	ordering_key varchar(255) not null,
+	sequence int8 not null,
+	type varchar(64) not null,
+	payload bytes not null,
+	sinks_published jsonb not null default '[]',
+	attempts int not null default 0,
+	date_next_attempt timestamp with time zone not null,
+	error string not null default '',
+	date_created timestamp with time zone not null,
+	index outbox_message_ordering_key_sequence_idx (ordering_key, sequence),
+	index outbox_message_date_next_attempt_idx (date_next_attempt)
+);
@@ -0,0 +1 @@
+create unique index if not exists webhook_delivery_subscription_id_event_id_idx on webhook_delivery (subscription_id, event_id);
@@ -0,0 +1,14 @@
+create table if not exists task_history (
+	id uuid primary key not null,
+	tenant_id varchar(255) not null,
+	task_id uuid not null,
+	version int8 not null,
+	action varchar(16) not null,
+	actor varchar(255) not null,
+	request_id varchar(64) not null,
+	changes jsonb not null,
+	task jsonb not null,
+	date_created timestamp with time zone not null,
+	index task_history_tenant_id_task_id_version_idx (tenant_id, task_id, version),
+	index task_history_date_created_idx (date_created)
+);
@@ -0,0 +1,11 @@
+create table if not exists task_comment (
+	id uuid primary key not null,
+	tenant_id varchar(255) not null,
+	task_id uuid not null,
+	author_id varchar(255) not null,
+	body string not null,
+	date_created timestamp with time zone not null,
+	date_updated timestamp with time zone not null,
+	version int8 not null,
+	index task_comment_tenant_id_task_id_date_created_idx (tenant_id, task_id, date_created, id)
+);
@@ -0,0 +1 @@
+alter table task add column if not exists comment_count int8 not null default 0;
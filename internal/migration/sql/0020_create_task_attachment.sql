@@ -0,0 +1,12 @@
+create table if not exists task_attachment (
+	id uuid primary key not null,
+	tenant_id varchar(255) not null,
+	task_id uuid not null,
+	name varchar(255) not null,
+	content_type varchar(255) not null,
+	size int8 not null,
+	checksum varchar(64) not null,
+	uploader_id varchar(255) not null,
+	date_created timestamp with time zone not null,
+	index task_attachment_tenant_id_task_id_date_created_idx (tenant_id, task_id, date_created, id)
+);
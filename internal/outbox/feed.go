@@ -0,0 +1,229 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jaredpetersen/go-rest-template/internal/redis"
+	"github.com/rs/zerolog/log"
+)
+
+// Entry is a message that has been appended to a Redis stream by RedisStreamSink.
+type Entry struct {
+	// StreamID is the ID of the stream entry. Stream IDs order the entries of every key.
+	StreamID string `json:"streamId"`
+	Message
+}
+
+// ValidStreamID determines whether or not the ID is a complete Redis stream ID, such as "1614682800000-0".
+func ValidStreamID(id string) bool {
+	_, _, ok := parseStreamID(id)
+	return ok
+}
+
+// CompareStreamIDs returns -1 if stream ID a comes before b, 1 if it comes after b, and 0 if they are the same. Invalid
+// IDs come before every valid ID.
+func CompareStreamIDs(a string, b string) int {
+	aMillis, aSeq, _ := parseStreamID(a)
+	bMillis, bSeq, _ := parseStreamID(b)
+
+	switch {
+	case aMillis < bMillis || (aMillis == bMillis && aSeq < bSeq):
+		return -1
+	case aMillis > bMillis || (aMillis == bMillis && aSeq > bSeq):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// parseStreamID splits a stream ID into its millisecond time and sequence number.
+func parseStreamID(id string) (uint64, uint64, bool) {
+	parts := strings.Split(id, "-")
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+
+	millis, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	seq, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return millis, seq, true
+}
+
+// Feed follows the entries that RedisStreamSink appends to a stream, for every replica of the service.
+//
+// Each replica holds a single subscription to the sink's pub/sub channel and fans the entries out to its own
+// subscribers. Pub/sub does not keep messages for subscribers that are not listening, so subscribers that fall behind
+// or that were subscribed while the subscription was lost are dropped, and catch up on what they missed from the stream
+// with Since.
+type Feed struct {
+	Redis redis.Client
+	// Stream is the key of the stream that the sink appends to.
+	Stream string
+	// Channel is the pub/sub channel that the sink publishes to.
+	Channel string
+	// Buffer is how many entries a subscriber may fall behind by before it is dropped. Defaults to 64.
+	Buffer int
+	// RetryInterval is how long to wait before subscribing again after the subscription is lost. Defaults to a second.
+	RetryInterval time.Duration
+
+	mu          sync.Mutex
+	subscribers map[chan Entry]bool
+}
+
+// NewFeed creates a feed with default values. The returned pointer will never be nil.
+func NewFeed() *Feed {
+	return &Feed{
+		Buffer:        64,
+		RetryInterval: time.Second,
+		subscribers:   make(map[chan Entry]bool),
+	}
+}
+
+// Start follows the channel in a separate goroutine until the context is done, at which point every subscriber is
+// dropped.
+func (f *Feed) Start(ctx context.Context) {
+	go func() {
+		defer f.dropAll()
+
+		for {
+			err := f.follow(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+
+			log.Error().Err(err).Msg("Lost subscription to outbox feed")
+
+			// Subscribers may have missed entries, so they are dropped to catch up on their own
+			f.dropAll()
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(f.RetryInterval):
+			}
+		}
+	}()
+}
+
+// follow subscribes to the channel and hands every entry to the subscribers until the subscription is lost or the
+// context is done.
+func (f *Feed) follow(ctx context.Context) error {
+	sub, err := f.Redis.Subscribe(ctx, f.Channel)
+	if err != nil {
+		return err
+	}
+	defer sub.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-sub.Messages():
+			if !ok {
+				return errors.New("outbox feed subscription closed")
+			}
+
+			var e Entry
+			err = json.Unmarshal([]byte(msg), &e)
+			if err != nil {
+				log.Warn().Err(err).Msg("Failed to decode outbox feed entry")
+				continue
+			}
+
+			f.broadcast(e)
+		}
+	}
+}
+
+// broadcast hands the entry to every subscriber, dropping the subscribers that have fallen too far behind to take it.
+func (f *Feed) broadcast(e Entry) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for ch := range f.subscribers {
+		select {
+		case ch <- e:
+		default:
+			delete(f.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// dropAll drops every subscriber.
+func (f *Feed) dropAll() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for ch := range f.subscribers {
+		delete(f.subscribers, ch)
+		close(ch)
+	}
+}
+
+// Subscribe receives the entries appended to the stream from now on, along with a function to unsubscribe with. The
+// channel is closed when the subscriber is dropped or unsubscribes.
+func (f *Feed) Subscribe() (<-chan Entry, func()) {
+	ch := make(chan Entry, f.Buffer)
+
+	f.mu.Lock()
+	f.subscribers[ch] = true
+	f.mu.Unlock()
+
+	unsubscribe := func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		if f.subscribers[ch] {
+			delete(f.subscribers, ch)
+			close(ch)
+		}
+	}
+
+	return ch, unsubscribe
+}
+
+// Since retrieves up to limit entries that were appended to the stream after the entry with the given ID, in order.
+//
+// The stream is capped, so the entry may have been trimmed along with entries after it. Whether or not the entry is
+// still in the stream is returned so that callers can tell when entries may have been missed.
+func (f *Feed) Since(ctx context.Context, lastID string, limit int64) ([]Entry, bool, error) {
+	// The range is inclusive, so one more is retrieved in case the first is the last entry that was seen
+	ses, err := f.Redis.XRange(ctx, f.Stream, lastID, "+", limit+1)
+	if err != nil {
+		return nil, false, err
+	}
+
+	found := len(ses) > 0 && ses[0].ID == lastID
+	if found {
+		ses = ses[1:]
+	}
+	if int64(len(ses)) > limit {
+		ses = ses[:limit]
+	}
+
+	es := make([]Entry, 0, len(ses))
+	for _, se := range ses {
+		m, err := fromStreamValues(se.Values)
+		if err != nil {
+			log.Warn().Err(err).Str("entry", se.ID).Msg("Failed to decode outbox stream entry")
+			continue
+		}
+
+		es = append(es, Entry{StreamID: se.ID, Message: *m})
+	}
+
+	return es, found, nil
+}
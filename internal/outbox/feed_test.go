@@ -0,0 +1,284 @@
+package outbox_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jaredpetersen/go-rest-template/internal/outbox"
+	"github.com/jaredpetersen/go-rest-template/internal/redis"
+	redismock "github.com/jaredpetersen/go-rest-template/internal/redis/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func buildStreamEntry(id string, key string) redis.StreamEntry {
+	return redis.StreamEntry{
+		ID: id,
+		Values: map[string]interface{}{
+			"id":          "message-" + id,
+			"tenantId":    "tenant-1",
+			"key":         key,
+			"sequence":    "1",
+			"type":        "task.created",
+			"payload":     `{"id":"` + key + `"}`,
+			"dateCreated": "2026-03-02T09:00:00Z",
+		},
+	}
+}
+
+func buildFeedMessage(t *testing.T, streamID string, key string) string {
+	m := outbox.NewMessage("tenant-1", key, 1, "task.created", []byte(`{"id":"`+key+`"}`))
+	raw, err := json.Marshal(outbox.Entry{StreamID: streamID, Message: *m})
+	require.NoError(t, err)
+	return string(raw)
+}
+
+// buildSubscription creates a subscription that receives the messages sent on msgs
+func buildSubscription(msgs chan string) *redismock.Subscription {
+	sub := redismock.Subscription{}
+	sub.On("Messages").Return((<-chan string)(msgs))
+	sub.On("Close").Return(nil)
+	return &sub
+}
+
+// receive waits for an entry from the subscriber, reporting whether or not the subscriber is still open
+func receive(t *testing.T, ch <-chan outbox.Entry) (outbox.Entry, bool) {
+	select {
+	case e, ok := <-ch:
+		return e, ok
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for the feed")
+		return outbox.Entry{}, false
+	}
+}
+
+func TestValidStreamID(t *testing.T) {
+	assert.True(t, outbox.ValidStreamID("1614682800000-0"))
+	assert.True(t, outbox.ValidStreamID("0-0"))
+	assert.False(t, outbox.ValidStreamID(""))
+	assert.False(t, outbox.ValidStreamID("1614682800000"))
+	assert.False(t, outbox.ValidStreamID("1614682800000-"))
+	assert.False(t, outbox.ValidStreamID("abc-0"))
+	assert.False(t, outbox.ValidStreamID("1-2-3"))
+}
+
+func TestCompareStreamIDs(t *testing.T) {
+	assert.Equal(t, 0, outbox.CompareStreamIDs("5-1", "5-1"))
+	assert.Equal(t, -1, outbox.CompareStreamIDs("5-1", "5-2"))
+	assert.Equal(t, 1, outbox.CompareStreamIDs("5-2", "5-1"))
+	assert.Equal(t, -1, outbox.CompareStreamIDs("9-9", "10-0"), "IDs were compared as strings")
+	assert.Equal(t, 1, outbox.CompareStreamIDs("10-0", "9-9"), "IDs were compared as strings")
+	assert.Equal(t, -1, outbox.CompareStreamIDs("invalid", "0-1"))
+}
+
+func TestFeedSince(t *testing.T) {
+	ctx := context.Background()
+
+	// Set up relevant dependencies
+	rdb := redismock.Client{}
+	rdb.On("XRange", ctx, "outbox.events", "1-0", "+", int64(3)).
+		Return([]redis.StreamEntry{
+			buildStreamEntry("1-0", "task-1"),
+			buildStreamEntry("2-0", "task-2"),
+			buildStreamEntry("3-0", "task-1"),
+		}, nil)
+
+	feed := outbox.NewFeed()
+	feed.Redis = &rdb
+	feed.Stream = "outbox.events"
+
+	es, found, err := feed.Since(ctx, "1-0", 2)
+	require.NoError(t, err)
+	assert.True(t, found)
+	require.Len(t, es, 2)
+	assert.Equal(t, "2-0", es[0].StreamID)
+	assert.Equal(t, "message-2-0", es[0].ID)
+	assert.Equal(t, "tenant-1", es[0].TenantID)
+	assert.Equal(t, "task-2", es[0].Key)
+	assert.Equal(t, int64(1), es[0].Sequence)
+	assert.Equal(t, "task.created", es[0].Type)
+	assert.JSONEq(t, `{"id":"task-2"}`, string(es[0].Payload))
+	assert.Equal(t, time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC), es[0].DateCreated.UTC())
+	assert.Equal(t, "3-0", es[1].StreamID)
+
+	rdb.AssertExpectations(t)
+}
+
+func TestFeedSinceTrimmed(t *testing.T) {
+	ctx := context.Background()
+
+	// Set up relevant dependencies
+	rdb := redismock.Client{}
+	rdb.On("XRange", ctx, "outbox.events", "1-0", "+", int64(3)).
+		Return([]redis.StreamEntry{
+			buildStreamEntry("5-0", "task-1"),
+			buildStreamEntry("6-0", "task-2"),
+			buildStreamEntry("7-0", "task-1"),
+		}, nil)
+
+	feed := outbox.NewFeed()
+	feed.Redis = &rdb
+	feed.Stream = "outbox.events"
+
+	es, found, err := feed.Since(ctx, "1-0", 2)
+	require.NoError(t, err)
+	assert.False(t, found, "Trimmed entry was reported as found")
+	require.Len(t, es, 2)
+	assert.Equal(t, "5-0", es[0].StreamID)
+	assert.Equal(t, "6-0", es[1].StreamID)
+}
+
+func TestFeedSinceSkipsInvalidEntries(t *testing.T) {
+	ctx := context.Background()
+
+	invalid := buildStreamEntry("2-0", "task-2")
+	invalid.Values["sequence"] = "abc"
+
+	// Set up relevant dependencies
+	rdb := redismock.Client{}
+	rdb.On("XRange", ctx, "outbox.events", "1-0", "+", int64(11)).
+		Return([]redis.StreamEntry{buildStreamEntry("1-0", "task-1"), invalid, buildStreamEntry("3-0", "task-1")}, nil)
+
+	feed := outbox.NewFeed()
+	feed.Redis = &rdb
+	feed.Stream = "outbox.events"
+
+	es, found, err := feed.Since(ctx, "1-0", 10)
+	require.NoError(t, err)
+	assert.True(t, found)
+	require.Len(t, es, 1)
+	assert.Equal(t, "3-0", es[0].StreamID)
+}
+
+func TestFeedSinceReturnsError(t *testing.T) {
+	ctx := context.Background()
+
+	// Set up relevant dependencies
+	rdb := redismock.Client{}
+	rdbErr := errors.New("failed")
+	rdb.On("XRange", ctx, "outbox.events", "1-0", "+", int64(11)).Return(nil, rdbErr)
+
+	feed := outbox.NewFeed()
+	feed.Redis = &rdb
+	feed.Stream = "outbox.events"
+
+	_, _, err := feed.Since(ctx, "1-0", 10)
+	assert.Equal(t, rdbErr, err)
+}
+
+func TestFeedBroadcasts(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Set up relevant dependencies
+	msgs := make(chan string)
+	rdb := redismock.Client{}
+	rdb.On("Subscribe", mock.Anything, "outbox.events").Return(buildSubscription(msgs), nil)
+
+	feed := outbox.NewFeed()
+	feed.Redis = &rdb
+	feed.Channel = "outbox.events"
+
+	first, _ := feed.Subscribe()
+	second, unsubscribe := feed.Subscribe()
+	feed.Start(ctx)
+
+	msgs <- buildFeedMessage(t, "1-0", "task-1")
+
+	e, ok := receive(t, first)
+	require.True(t, ok)
+	assert.Equal(t, "1-0", e.StreamID)
+	assert.Equal(t, "task-1", e.Key)
+	e, ok = receive(t, second)
+	require.True(t, ok)
+	assert.Equal(t, "1-0", e.StreamID)
+
+	// Unsubscribed subscribers are closed and no longer receive entries
+	unsubscribe()
+	_, ok = receive(t, second)
+	assert.False(t, ok, "Unsubscribed subscriber was not closed")
+
+	// Entries that cannot be decoded are skipped
+	msgs <- "invalid"
+	msgs <- buildFeedMessage(t, "2-0", "task-2")
+	e, ok = receive(t, first)
+	require.True(t, ok)
+	assert.Equal(t, "2-0", e.StreamID)
+
+	// Subscribers are dropped when the feed stops
+	cancel()
+	_, ok = receive(t, first)
+	assert.False(t, ok, "Subscriber was not dropped")
+}
+
+func TestFeedDropsSlowSubscribers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Set up relevant dependencies
+	msgs := make(chan string)
+	rdb := redismock.Client{}
+	rdb.On("Subscribe", mock.Anything, "outbox.events").Return(buildSubscription(msgs), nil)
+
+	feed := outbox.NewFeed()
+	feed.Redis = &rdb
+	feed.Channel = "outbox.events"
+	feed.Buffer = 1
+
+	slow, _ := feed.Subscribe()
+	feed.Start(ctx)
+
+	msgs <- buildFeedMessage(t, "1-0", "task-1")
+	msgs <- buildFeedMessage(t, "2-0", "task-1")
+	// The previous entry has been handed out once the feed takes another
+	msgs <- buildFeedMessage(t, "3-0", "task-1")
+
+	e, ok := receive(t, slow)
+	require.True(t, ok)
+	assert.Equal(t, "1-0", e.StreamID)
+	_, ok = receive(t, slow)
+	assert.False(t, ok, "Slow subscriber was not dropped")
+}
+
+func TestFeedResubscribes(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Set up relevant dependencies
+	lost := make(chan string)
+	close(lost)
+	msgs := make(chan string)
+	rdb := redismock.Client{}
+	rdb.On("Subscribe", mock.Anything, "outbox.events").Return(nil, errors.New("failed")).Once()
+	rdb.On("Subscribe", mock.Anything, "outbox.events").Return(buildSubscription(lost), nil).Once()
+	resubscribed := make(chan bool)
+	rdb.On("Subscribe", mock.Anything, "outbox.events").
+		Run(func(args mock.Arguments) { close(resubscribed) }).
+		Return(buildSubscription(msgs), nil).
+		Once()
+
+	feed := outbox.NewFeed()
+	feed.Redis = &rdb
+	feed.Channel = "outbox.events"
+	feed.RetryInterval = time.Millisecond
+
+	feed.Start(ctx)
+
+	// Subscribers are dropped when the subscription is lost since they may have missed entries, so wait until the
+	// feed has resubscribed for good
+	select {
+	case <-resubscribed:
+	case <-time.After(time.Second):
+		t.Fatal("Feed did not resubscribe")
+	}
+	sub, _ := feed.Subscribe()
+
+	msgs <- buildFeedMessage(t, "1-0", "task-1")
+
+	e, ok := receive(t, sub)
+	require.True(t, ok)
+	assert.Equal(t, "1-0", e.StreamID)
+}
@@ -0,0 +1,93 @@
+// Package outbox records domain events in the same database transaction as the changes that they describe and relays
+// them to sinks such as Redis Streams and webhooks, so that an event is published if and only if its change was stored.
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Message is a domain event waiting in the outbox to be published.
+type Message struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenantId"`
+	// Key groups the messages that must be published in order, such as the ID of the task that the event is about.
+	Key string `json:"key"`
+	// Sequence orders the messages with the same key, such as the version of the task after the event.
+	Sequence int64  `json:"sequence"`
+	Type     string `json:"type"`
+	// Payload is the JSON encoded event.
+	Payload json.RawMessage `json:"payload"`
+	// SinksPublished are the names of the sinks that the message has already been published to.
+	SinksPublished []string `json:"-"`
+	// Attempts is the number of times that publishing the message has failed.
+	Attempts int `json:"-"`
+	// DateNextAttempt is when the message will be published next.
+	DateNextAttempt time.Time `json:"-"`
+	// Error describes why the last attempt failed. Empty if it has not been attempted.
+	Error       string    `json:"-"`
+	DateCreated time.Time `json:"dateCreated"`
+}
+
+// NewMessage creates a new message that is due to be published right away. The returned pointer will never be nil.
+func NewMessage(tenantID string, key string, sequence int64, messageType string, payload []byte) *Message {
+	now := time.Now()
+	return &Message{
+		ID:              uuid.New().String(),
+		TenantID:        tenantID,
+		Key:             key,
+		Sequence:        sequence,
+		Type:            messageType,
+		Payload:         payload,
+		SinksPublished:  []string{},
+		DateNextAttempt: now,
+		DateCreated:     now,
+	}
+}
+
+// Execer executes statements, such as a *sql.Tx.
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// Write adds messages to the outbox. It should be called with the transaction that stores the changes that the messages
+// describe so that the messages are only recorded if the changes are.
+func Write(ctx context.Context, tx Execer, ms ...Message) error {
+	if len(ms) == 0 {
+		return nil
+	}
+
+	const columns = 9
+	args := make([]interface{}, 0, len(ms)*columns)
+	values := make([]string, len(ms))
+	for i, m := range ms {
+		args = append(args,
+			m.ID,
+			m.TenantID,
+			m.Key,
+			m.Sequence,
+			m.Type,
+			[]byte(m.Payload),
+			m.Attempts,
+			m.DateNextAttempt,
+			m.DateCreated)
+
+		placeholders := make([]string, columns)
+		for j := range placeholders {
+			placeholders[j] = "$" + strconv.Itoa(i*columns+j+1)
+		}
+		values[i] = "(" + strings.Join(placeholders, ", ") + ")"
+	}
+
+	query := `insert into outbox_message
+		(id, tenant_id, ordering_key, sequence, type, payload, attempts, date_next_attempt, date_created)
+		values ` + strings.Join(values, ", ")
+	_, err := tx.ExecContext(ctx, query, args...)
+	return err
+}
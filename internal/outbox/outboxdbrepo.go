@@ -0,0 +1,85 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// DBRepo is a database repository for the messages in the outbox.
+type DBRepo struct {
+	DB *sql.DB
+}
+
+// ListDue retrieves up to limit messages that are due to be published by now, oldest first. Only the first message of
+// each key is retrieved, so that a message is never published while a message ahead of it is still in the outbox.
+func (dbr DBRepo) ListDue(ctx context.Context, now time.Time, limit int) ([]Message, error) {
+	const query = `select id, tenant_id, ordering_key, sequence, type, payload, sinks_published, attempts,
+			date_next_attempt, error, date_created
+		from outbox_message m
+		where date_next_attempt <= $1
+			and not exists (
+				select 1 from outbox_message ahead
+				where ahead.ordering_key = m.ordering_key and ahead.sequence < m.sequence
+			)
+		order by date_created, id
+		limit $2`
+	rows, err := dbr.DB.QueryContext(ctx, query, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ms := []Message{}
+	for rows.Next() {
+		var m Message
+		var payload []byte
+		var sinksPublished []byte
+		err = rows.Scan(
+			&m.ID,
+			&m.TenantID,
+			&m.Key,
+			&m.Sequence,
+			&m.Type,
+			&payload,
+			&sinksPublished,
+			&m.Attempts,
+			&m.DateNextAttempt,
+			&m.Error,
+			&m.DateCreated)
+		if err != nil {
+			return nil, err
+		}
+
+		m.Payload = payload
+		err = json.Unmarshal(sinksPublished, &m.SinksPublished)
+		if err != nil {
+			return nil, err
+		}
+
+		ms = append(ms, m)
+	}
+
+	return ms, rows.Err()
+}
+
+// Update records a failed attempt to publish a message, along with the sinks that it has been published to so far.
+func (dbr DBRepo) Update(ctx context.Context, m Message) error {
+	sinksPublished, err := json.Marshal(m.SinksPublished)
+	if err != nil {
+		return err
+	}
+
+	const query = `update outbox_message
+		set sinks_published = $1, attempts = $2, date_next_attempt = $3, error = $4
+		where id = $5`
+	_, err = dbr.DB.ExecContext(ctx, query, string(sinksPublished), m.Attempts, m.DateNextAttempt, m.Error, m.ID)
+	return err
+}
+
+// Delete removes a message that has been published to every sink from the outbox.
+func (dbr DBRepo) Delete(ctx context.Context, id string) error {
+	_, err := dbr.DB.ExecContext(ctx, `delete from outbox_message where id = $1`, id)
+	return err
+}
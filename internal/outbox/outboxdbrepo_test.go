@@ -0,0 +1,149 @@
+package outbox_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	_ "github.com/jackc/pgx/v4/stdlib"
+	"github.com/jaredpetersen/go-rest-template/internal/migration"
+	"github.com/jaredpetersen/go-rest-template/internal/outbox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+type cockroachDBContainer struct {
+	testcontainers.Container
+	URI string
+}
+
+func setupCockroachDB(ctx context.Context) (*cockroachDBContainer, error) {
+	req := testcontainers.ContainerRequest{
+		Image:        "cockroachdb/cockroach:latest-v21.1",
+		ExposedPorts: []string{"26257/tcp", "8080/tcp"},
+		WaitingFor:   wait.ForHTTP("/health").WithPort("8080"),
+		Cmd:          []string{"start-single-node", "--insecure"},
+		SkipReaper:   true,
+	}
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	mappedPort, err := container.MappedPort(ctx, "26257")
+	if err != nil {
+		return nil, err
+	}
+
+	hostIP, err := container.Host(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	uri := fmt.Sprintf("postgres://root@%s:%s", hostIP, mappedPort.Port())
+
+	return &cockroachDBContainer{Container: container, URI: uri}, nil
+}
+
+func initCockroachDB(ctx context.Context, db *sql.DB) error {
+	const query = `CREATE DATABASE projectmanagement`
+	_, err := db.ExecContext(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return migration.Migrate(ctx, db)
+}
+
+func TestIntegrationDBRepo(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+
+	cdbContainer, err := setupCockroachDB(ctx)
+	require.NoError(t, err, "Failed to start up CockroachDB container")
+	defer cdbContainer.Terminate(ctx)
+
+	db, err := sql.Open("pgx", cdbContainer.URI+"/projectmanagement")
+	require.NoError(t, err, "Failed to open connection to CockroachDB")
+	defer db.Close()
+
+	err = initCockroachDB(ctx, db)
+	require.NoError(t, err, "Failed to initialize CockroachDB")
+
+	dbr := outbox.DBRepo{DB: db}
+	now := time.Now()
+
+	first := outbox.NewMessage("tenant-1", "task-1", 1, "task.created", []byte(`{"id":"task-1"}`))
+	second := outbox.NewMessage("tenant-1", "task-1", 2, "task.updated", []byte(`{"id":"task-1"}`))
+	other := outbox.NewMessage("tenant-1", "task-2", 1, "task.created", []byte(`{"id":"task-2"}`))
+	later := outbox.NewMessage("tenant-1", "task-3", 1, "task.created", []byte(`{"id":"task-3"}`))
+	later.DateNextAttempt = now.Add(time.Hour)
+
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	err = outbox.Write(ctx, tx, *second, *first, *other, *later)
+	require.NoError(t, err, "Write returned error")
+	err = tx.Commit()
+	require.NoError(t, err)
+
+	// Only the first message of each key that is due
+	due, err := dbr.ListDue(ctx, now, 10)
+	require.NoError(t, err, "ListDue returned error")
+	require.Len(t, due, 2)
+	ids := []string{due[0].ID, due[1].ID}
+	assert.ElementsMatch(t, []string{first.ID, other.ID}, ids)
+	for _, m := range due {
+		if m.ID == first.ID {
+			assert.Equal(t, "task.created", m.Type)
+			assert.JSONEq(t, `{"id":"task-1"}`, string(m.Payload))
+			assert.Equal(t, []string{}, m.SinksPublished)
+		}
+	}
+
+	// A failed attempt puts off the message along with the ones behind it
+	failed := *first
+	failed.SinksPublished = []string{"redis"}
+	failed.Attempts = 1
+	failed.Error = "webhook: failed"
+	failed.DateNextAttempt = now.Add(time.Minute)
+	err = dbr.Update(ctx, failed)
+	require.NoError(t, err, "Update returned error")
+
+	due, err = dbr.ListDue(ctx, now, 10)
+	require.NoError(t, err, "ListDue returned error")
+	require.Len(t, due, 1)
+	assert.Equal(t, other.ID, due[0].ID)
+
+	due, err = dbr.ListDue(ctx, now.Add(time.Minute), 10)
+	require.NoError(t, err, "ListDue returned error")
+	require.Len(t, due, 2)
+	for _, m := range due {
+		if m.ID == first.ID {
+			assert.Equal(t, []string{"redis"}, m.SinksPublished)
+			assert.Equal(t, 1, m.Attempts)
+			assert.Equal(t, "webhook: failed", m.Error)
+		}
+	}
+
+	// Once the first message is gone the second one is next
+	err = dbr.Delete(ctx, first.ID)
+	require.NoError(t, err, "Delete returned error")
+
+	due, err = dbr.ListDue(ctx, now, 10)
+	require.NoError(t, err, "ListDue returned error")
+	ids = make([]string, len(due))
+	for i, m := range due {
+		ids[i] = m.ID
+	}
+	assert.ElementsMatch(t, []string{second.ID, other.ID}, ids)
+}
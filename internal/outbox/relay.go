@@ -0,0 +1,201 @@
+package outbox
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Store is where the relay finds the messages that are due to be published and records how publishing them went.
+type Store interface {
+	ListDue(ctx context.Context, now time.Time, limit int) ([]Message, error)
+	Update(ctx context.Context, m Message) error
+	Delete(ctx context.Context, id string) error
+}
+
+// Lease makes sure that only one replica of the service relays messages at a time.
+type Lease interface {
+	Acquire(ctx context.Context) (bool, error)
+	Release(ctx context.Context) error
+}
+
+// Sink is somewhere that messages are published to.
+type Sink interface {
+	Publish(ctx context.Context, m Message) error
+}
+
+// Relay periodically publishes the messages in the outbox to every sink.
+//
+// Every replica of the service may run a relay, but only the replica that holds the lease publishes messages, so that
+// the messages with the same key are published to each sink in order. A message is removed from the outbox once it has
+// been published to every sink. A message that fails to publish to a sink is retried with exponential backoff, holding
+// up the messages behind it with the same key, and is not published to the sinks that it was already published to.
+//
+// Messages are published at least once, so a sink may receive the same message more than once, e.g. when the replica
+// goes away after publishing it but before recording that it did. Consumers can use the message ID to ignore
+// duplicates.
+type Relay struct {
+	Messages Store
+	Lease    Lease
+	// Sinks receive every message. The key names the sink in logs and in the record of where each message has been
+	// published, so it must not change between releases.
+	Sinks map[string]Sink
+	// Backoff is how long to wait before the first retry. The wait doubles with every retry after that. Defaults to a
+	// second.
+	Backoff time.Duration
+	// MaxBackoff is the longest to wait between retries. Defaults to five minutes.
+	MaxBackoff time.Duration
+	// Timeout is how long a sink may take to publish a message. Defaults to 10 seconds.
+	Timeout time.Duration
+	// BatchSize is the number of messages retrieved at a time. Defaults to 100.
+	BatchSize int
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// NewRelay creates a relay with default values. The returned pointer will never be nil.
+func NewRelay() *Relay {
+	return &Relay{
+		Sinks:      make(map[string]Sink),
+		Backoff:    time.Second,
+		MaxBackoff: 5 * time.Minute,
+		Timeout:    10 * time.Second,
+		BatchSize:  100,
+		Now:        time.Now,
+	}
+}
+
+// Start runs the relay on an interval in a separate goroutine until the context is done, at which point the lease is
+// released. The lease should last longer than the interval so that it does not lapse between runs.
+func (r *Relay) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				// The context is already done so the lease needs a context of its own to be released
+				releaseCtx, cancel := context.WithTimeout(context.Background(), r.Timeout)
+				err := r.Lease.Release(releaseCtx)
+				cancel()
+				if err != nil {
+					log.Error().Err(err).Msg("Failed to release outbox lease")
+				}
+				return
+			case <-ticker.C:
+				err := r.Run(ctx)
+				if err != nil {
+					log.Error().Err(err).Msg("Failed to relay outbox messages")
+				}
+			}
+		}
+	}()
+}
+
+// Run publishes every message that is due until the outbox has no more messages that are due. Nothing is published if
+// another replica holds the lease, which is renewed before every batch.
+//
+// Failures to publish are recorded rather than returned so that one failing sink does not hold up the messages with
+// other keys.
+func (r *Relay) Run(ctx context.Context) error {
+	for {
+		held, err := r.Lease.Acquire(ctx)
+		if err != nil {
+			return err
+		}
+		if !held {
+			return nil
+		}
+
+		ms, err := r.Messages.ListDue(ctx, r.Now(), r.BatchSize)
+		if err != nil {
+			return err
+		}
+
+		// Every message is either removed or put off, so the outbox runs out of messages that are due
+		if len(ms) == 0 {
+			return nil
+		}
+
+		for _, m := range ms {
+			err = r.relay(ctx, m)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// relay publishes the message to the sinks that it has not been published to yet and records how it went.
+func (r *Relay) relay(ctx context.Context, m Message) error {
+	published := make(map[string]bool, len(m.SinksPublished))
+	for _, name := range m.SinksPublished {
+		published[name] = true
+	}
+
+	var failures []string
+	for _, name := range r.sinkNames() {
+		if published[name] {
+			continue
+		}
+
+		err := r.publish(ctx, r.Sinks[name], m)
+		if err != nil {
+			log.Warn().
+				Err(err).
+				Str("sink", name).
+				Str("message", m.ID).
+				Int("attempts", m.Attempts+1).
+				Msg("Failed to publish outbox message")
+			failures = append(failures, name+": "+err.Error())
+			continue
+		}
+
+		m.SinksPublished = append(m.SinksPublished, name)
+	}
+
+	if len(failures) == 0 {
+		return r.Messages.Delete(ctx, m.ID)
+	}
+
+	m.Attempts++
+	m.Error = strings.Join(failures, "; ")
+	m.DateNextAttempt = r.Now().Add(r.backoff(m.Attempts))
+	return r.Messages.Update(ctx, m)
+}
+
+// publish publishes the message to the sink, giving up after the timeout.
+func (r *Relay) publish(ctx context.Context, sink Sink, m Message) error {
+	ctx, cancel := context.WithTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	return sink.Publish(ctx, m)
+}
+
+// sinkNames lists the names of the sinks in a consistent order.
+func (r *Relay) sinkNames() []string {
+	names := make([]string, 0, len(r.Sinks))
+	for name := range r.Sinks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// backoff determines how long to wait before the next attempt after the given number of failed attempts.
+func (r *Relay) backoff(attempts int) time.Duration {
+	wait := r.Backoff
+	for i := 1; i < attempts; i++ {
+		wait *= 2
+		if wait >= r.MaxBackoff {
+			return r.MaxBackoff
+		}
+	}
+
+	return wait
+}
@@ -0,0 +1,320 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaredpetersen/go-rest-template/internal/outbox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a clock that only moves when it is told to
+type fakeClock struct {
+	now time.Time
+}
+
+func (fc *fakeClock) Now() time.Time {
+	return fc.now
+}
+
+func (fc *fakeClock) Advance(d time.Duration) {
+	fc.now = fc.now.Add(d)
+}
+
+// fakeLease is a lease that is either held or not
+type fakeLease struct {
+	held     bool
+	err      error
+	acquired int
+}
+
+func (fl *fakeLease) Acquire(ctx context.Context) (bool, error) {
+	fl.acquired++
+	return fl.held, fl.err
+}
+
+func (fl *fakeLease) Release(ctx context.Context) error {
+	return nil
+}
+
+// fakeSink records the messages that are published to it, failing while it has failures left
+type fakeSink struct {
+	failures  int
+	published []outbox.Message
+}
+
+func (fs *fakeSink) Publish(ctx context.Context, m outbox.Message) error {
+	if fs.failures > 0 {
+		fs.failures--
+		return errors.New("publish failed")
+	}
+
+	fs.published = append(fs.published, m)
+	return nil
+}
+
+// sequences lists the keys and sequences of the published messages in the order that they were published
+func (fs *fakeSink) sequences() []string {
+	res := make([]string, len(fs.published))
+	for i, m := range fs.published {
+		res[i] = m.Key + "." + strconv.FormatInt(m.Sequence, 10)
+	}
+	return res
+}
+
+// memoryStore keeps messages in memory and lists them like outbox.DBRepo.ListDue
+type memoryStore struct {
+	messages map[string]outbox.Message
+	listErr  error
+}
+
+func newMemoryStore(ms ...outbox.Message) *memoryStore {
+	ms2 := make(map[string]outbox.Message, len(ms))
+	for _, m := range ms {
+		ms2[m.ID] = m
+	}
+	return &memoryStore{messages: ms2}
+}
+
+func (ms *memoryStore) ListDue(ctx context.Context, now time.Time, limit int) ([]outbox.Message, error) {
+	if ms.listErr != nil {
+		return nil, ms.listErr
+	}
+
+	heads := make(map[string]outbox.Message)
+	for _, m := range ms.messages {
+		head, ok := heads[m.Key]
+		if !ok || m.Sequence < head.Sequence {
+			heads[m.Key] = m
+		}
+	}
+
+	res := []outbox.Message{}
+	for _, m := range heads {
+		if !m.DateNextAttempt.After(now) {
+			res = append(res, m)
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].ID < res[j].ID })
+	if len(res) > limit {
+		res = res[:limit]
+	}
+
+	return res, nil
+}
+
+func (ms *memoryStore) Update(ctx context.Context, m outbox.Message) error {
+	ms.messages[m.ID] = m
+	return nil
+}
+
+func (ms *memoryStore) Delete(ctx context.Context, id string) error {
+	delete(ms.messages, id)
+	return nil
+}
+
+func buildMessage(id string, key string, sequence int64, now time.Time) outbox.Message {
+	m := outbox.NewMessage("tenant-1", key, sequence, "task.updated", []byte(`{"id":"`+key+`"}`))
+	m.ID = id
+	m.DateNextAttempt = now
+	return *m
+}
+
+func buildRelay(store outbox.Store, lease outbox.Lease, clock *fakeClock) *outbox.Relay {
+	relay := outbox.NewRelay()
+	relay.Messages = store
+	relay.Lease = lease
+	relay.Now = clock.Now
+	return relay
+}
+
+func TestRelayPublishesInOrderPerKey(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)}
+
+	// Listed by ID, so the later messages would come first if the relay did not keep them in order
+	store := newMemoryStore(
+		buildMessage("a", "task-1", 3, clock.now),
+		buildMessage("b", "task-1", 2, clock.now),
+		buildMessage("c", "task-2", 1, clock.now),
+		buildMessage("d", "task-1", 1, clock.now),
+	)
+	sink := &fakeSink{}
+
+	relay := buildRelay(store, &fakeLease{held: true}, clock)
+	relay.Sinks["fake"] = sink
+	relay.BatchSize = 1
+
+	err := relay.Run(ctx)
+	require.NoError(t, err)
+
+	var task1 []string
+	for _, s := range sink.sequences() {
+		if strings.HasPrefix(s, "task-1.") {
+			task1 = append(task1, s)
+		}
+	}
+	assert.Equal(t, []string{"task-1.1", "task-1.2", "task-1.3"}, task1)
+	assert.Len(t, sink.published, 4)
+	assert.Empty(t, store.messages, "Published messages were not removed")
+}
+
+func TestRelayRetriesWithBackoff(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)}
+
+	store := newMemoryStore(buildMessage("a", "task-1", 1, clock.now))
+	sink := &fakeSink{failures: 2}
+
+	relay := buildRelay(store, &fakeLease{held: true}, clock)
+	relay.Sinks["fake"] = sink
+
+	// First failure waits a second
+	err := relay.Run(ctx)
+	require.NoError(t, err)
+	require.Contains(t, store.messages, "a")
+	assert.Equal(t, 1, store.messages["a"].Attempts)
+	assert.Equal(t, "fake: publish failed", store.messages["a"].Error)
+	assert.Equal(t, clock.now.Add(time.Second), store.messages["a"].DateNextAttempt)
+
+	// Not retried before then
+	err = relay.Run(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, store.messages["a"].Attempts)
+
+	// Second failure waits twice as long
+	clock.Advance(time.Second)
+	err = relay.Run(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, store.messages["a"].Attempts)
+	assert.Equal(t, clock.now.Add(2*time.Second), store.messages["a"].DateNextAttempt)
+
+	clock.Advance(2 * time.Second)
+	err = relay.Run(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, store.messages, "Published message was not removed")
+	assert.Len(t, sink.published, 1)
+}
+
+func TestRelayFailureHoldsUpKey(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)}
+
+	store := newMemoryStore(
+		buildMessage("a", "task-1", 1, clock.now),
+		buildMessage("b", "task-1", 2, clock.now),
+		buildMessage("c", "task-2", 1, clock.now),
+	)
+	sink := &fakeSink{failures: 1}
+
+	relay := buildRelay(store, &fakeLease{held: true}, clock)
+	relay.Sinks["fake"] = sink
+
+	err := relay.Run(ctx)
+	require.NoError(t, err)
+
+	// The first message of task-1 failed so the second one waits behind it while task-2 carries on
+	assert.Equal(t, []string{"task-2.1"}, sink.sequences())
+	assert.Contains(t, store.messages, "a")
+	assert.Contains(t, store.messages, "b")
+
+	clock.Advance(time.Second)
+	err = relay.Run(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"task-2.1", "task-1.1", "task-1.2"}, sink.sequences())
+}
+
+func TestRelayDoesNotRepublishToSucceededSinks(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)}
+
+	store := newMemoryStore(buildMessage("a", "task-1", 1, clock.now))
+	healthy := &fakeSink{}
+	failing := &fakeSink{failures: 1}
+
+	relay := buildRelay(store, &fakeLease{held: true}, clock)
+	relay.Sinks["healthy"] = healthy
+	relay.Sinks["failing"] = failing
+
+	err := relay.Run(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"healthy"}, store.messages["a"].SinksPublished)
+
+	clock.Advance(time.Second)
+	err = relay.Run(ctx)
+	require.NoError(t, err)
+
+	assert.Len(t, healthy.published, 1, "Message was published to the healthy sink again")
+	assert.Len(t, failing.published, 1)
+	assert.Empty(t, store.messages)
+}
+
+func TestRelayWithoutLease(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)}
+
+	store := newMemoryStore(buildMessage("a", "task-1", 1, clock.now))
+	sink := &fakeSink{}
+
+	relay := buildRelay(store, &fakeLease{held: false}, clock)
+	relay.Sinks["fake"] = sink
+
+	err := relay.Run(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, sink.published, "Published without holding the lease")
+	assert.Len(t, store.messages, 1)
+}
+
+func TestRelayLeaseError(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)}
+
+	store := newMemoryStore(buildMessage("a", "task-1", 1, clock.now))
+
+	relay := buildRelay(store, &fakeLease{err: errors.New("lease failed")}, clock)
+	relay.Sinks["fake"] = &fakeSink{}
+
+	err := relay.Run(ctx)
+	assert.EqualError(t, err, "lease failed")
+}
+
+func TestRelayRenewsLeaseEveryBatch(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)}
+
+	store := newMemoryStore(
+		buildMessage("a", "task-1", 1, clock.now),
+		buildMessage("b", "task-2", 1, clock.now),
+	)
+	lease := &fakeLease{held: true}
+
+	relay := buildRelay(store, lease, clock)
+	relay.Sinks["fake"] = &fakeSink{}
+	relay.BatchSize = 1
+
+	err := relay.Run(ctx)
+	require.NoError(t, err)
+
+	// Once for each batch and once more to find that there is nothing left
+	assert.Equal(t, 3, lease.acquired)
+}
+
+func TestRelayListError(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)}
+
+	store := newMemoryStore()
+	store.listErr = errors.New("list failed")
+
+	relay := buildRelay(store, &fakeLease{held: true}, clock)
+
+	err := relay.Run(ctx)
+	assert.EqualError(t, err, "list failed")
+}
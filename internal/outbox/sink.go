@@ -0,0 +1,99 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/jaredpetersen/go-rest-template/internal/redis"
+)
+
+// WriterSink writes every message to a writer as a line of JSON, such as to stdout for log shipping or debugging.
+type WriterSink struct {
+	Writer io.Writer
+}
+
+// Publish writes the message.
+func (ws WriterSink) Publish(ctx context.Context, m Message) error {
+	line, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	_, err = ws.Writer.Write(append(line, '\n'))
+	return err
+}
+
+// RedisStreamSink appends every message to a Redis stream. Each entry has the message's id, tenantId, key, sequence,
+// type, payload, and dateCreated fields.
+type RedisStreamSink struct {
+	Redis redis.Client
+	// Stream is the key of the stream.
+	Stream string
+	// MaxLen caps the stream at approximately this many entries, trimming the oldest. The stream is not capped if zero.
+	MaxLen int64
+	// Channel is a pub/sub channel that every entry is also published to as a JSON encoded Entry, so that followers of
+	// the stream do not have to poll it. Entries are not published if empty.
+	Channel string
+}
+
+// Publish appends the message to the stream.
+func (rss RedisStreamSink) Publish(ctx context.Context, m Message) error {
+	values := map[string]interface{}{
+		"id":          m.ID,
+		"tenantId":    m.TenantID,
+		"key":         m.Key,
+		"sequence":    strconv.FormatInt(m.Sequence, 10),
+		"type":        m.Type,
+		"payload":     string(m.Payload),
+		"dateCreated": m.DateCreated.Format(time.RFC3339Nano),
+	}
+
+	id, err := rss.Redis.XAdd(ctx, rss.Stream, rss.MaxLen, values)
+	if err != nil {
+		return err
+	}
+
+	if rss.Channel == "" {
+		return nil
+	}
+
+	e, err := json.Marshal(Entry{StreamID: id, Message: m})
+	if err != nil {
+		return err
+	}
+
+	return rss.Redis.Publish(ctx, rss.Channel, string(e))
+}
+
+// fromStreamValues reads a message back from the values of a stream entry written by RedisStreamSink.
+func fromStreamValues(values map[string]interface{}) (*Message, error) {
+	str := func(key string) string {
+		s, _ := values[key].(string)
+		return s
+	}
+
+	sequence, err := strconv.ParseInt(str("sequence"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid stream entry sequence: %w", err)
+	}
+
+	dateCreated, err := time.Parse(time.RFC3339Nano, str("dateCreated"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid stream entry date: %w", err)
+	}
+
+	m := Message{
+		ID:          str("id"),
+		TenantID:    str("tenantId"),
+		Key:         str("key"),
+		Sequence:    sequence,
+		Type:        str("type"),
+		Payload:     json.RawMessage(str("payload")),
+		DateCreated: dateCreated,
+	}
+	return &m, nil
+}
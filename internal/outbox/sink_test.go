@@ -0,0 +1,120 @@
+package outbox_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jaredpetersen/go-rest-template/internal/outbox"
+	redismock "github.com/jaredpetersen/go-rest-template/internal/redis/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriterSinkPublish(t *testing.T) {
+	ctx := context.Background()
+
+	m := buildMessage("a", "task-1", 2, time.Now())
+	m.DateCreated = time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	sink := outbox.WriterSink{Writer: &buf}
+
+	err := sink.Publish(ctx, m)
+	require.NoError(t, err)
+	err = sink.Publish(ctx, m)
+	require.NoError(t, err)
+
+	lines := bytes.Split(bytes.TrimSuffix(buf.Bytes(), []byte("\n")), []byte("\n"))
+	require.Len(t, lines, 2, "Messages were not written one per line")
+
+	var written map[string]interface{}
+	err = json.Unmarshal(lines[0], &written)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"id":          "a",
+		"tenantId":    "tenant-1",
+		"key":         "task-1",
+		"sequence":    float64(2),
+		"type":        "task.updated",
+		"payload":     map[string]interface{}{"id": "task-1"},
+		"dateCreated": "2026-03-02T09:00:00Z",
+	}, written)
+}
+
+func TestRedisStreamSinkPublish(t *testing.T) {
+	ctx := context.Background()
+
+	m := buildMessage("a", "task-1", 2, time.Now())
+	m.DateCreated = time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+
+	// Set up relevant dependencies
+	rdb := redismock.Client{}
+	expectedValues := map[string]interface{}{
+		"id":          "a",
+		"tenantId":    "tenant-1",
+		"key":         "task-1",
+		"sequence":    "2",
+		"type":        "task.updated",
+		"payload":     `{"id":"task-1"}`,
+		"dateCreated": "2026-03-02T09:00:00Z",
+	}
+	rdb.On("XAdd", ctx, "outbox.events", int64(1000), expectedValues).Return("1-0", nil)
+
+	sink := outbox.RedisStreamSink{Redis: &rdb, Stream: "outbox.events", MaxLen: 1000}
+
+	err := sink.Publish(ctx, m)
+	require.NoError(t, err)
+
+	rdb.AssertExpectations(t)
+}
+
+func TestRedisStreamSinkPublishReturnsError(t *testing.T) {
+	ctx := context.Background()
+
+	m := buildMessage("a", "task-1", 2, time.Now())
+
+	// Set up relevant dependencies
+	rdb := redismock.Client{}
+	rdbErr := errors.New("failed")
+	rdb.On("XAdd", ctx, "outbox.events", int64(0), mock.Anything).Return("", rdbErr)
+
+	sink := outbox.RedisStreamSink{Redis: &rdb, Stream: "outbox.events"}
+
+	err := sink.Publish(ctx, m)
+	assert.Equal(t, rdbErr, err)
+
+	rdb.AssertExpectations(t)
+}
+
+func TestRedisStreamSinkPublishNotifiesChannel(t *testing.T) {
+	ctx := context.Background()
+
+	m := buildMessage("a", "task-1", 2, time.Now())
+	m.DateCreated = time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+
+	// Set up relevant dependencies
+	rdb := redismock.Client{}
+	rdb.On("XAdd", ctx, "outbox.events", int64(0), mock.Anything).Return("1-0", nil)
+	rdb.On("Publish", ctx, "outbox.events", mock.Anything).Return(nil)
+
+	sink := outbox.RedisStreamSink{Redis: &rdb, Stream: "outbox.events", Channel: "outbox.events"}
+
+	err := sink.Publish(ctx, m)
+	require.NoError(t, err)
+
+	rdb.AssertExpectations(t)
+
+	var published outbox.Entry
+	err = json.Unmarshal([]byte(rdb.Calls[1].Arguments.String(2)), &published)
+	require.NoError(t, err)
+	assert.Equal(t, "1-0", published.StreamID)
+	assert.Equal(t, "a", published.ID)
+	assert.Equal(t, "task-1", published.Key)
+	assert.Equal(t, int64(2), published.Sequence)
+	assert.JSONEq(t, `{"id":"task-1"}`, string(published.Payload))
+}
@@ -0,0 +1,342 @@
+// Package policy decides what authenticated principals are allowed to do using role-based access control.
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/jaredpetersen/go-rest-template/internal/auth"
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+	"github.com/rs/zerolog/log"
+)
+
+// Role is a named set of permissions.
+type Role string
+
+// Roles that may be granted to principals.
+const (
+	RoleViewer Role = "viewer"
+	RoleEditor Role = "editor"
+	RoleAdmin  Role = "admin"
+)
+
+// Roles lists every role, ordered from least to most privileged.
+var Roles = []Role{RoleViewer, RoleEditor, RoleAdmin}
+
+// Valid indicates whether or not the role is a known role.
+func (r Role) Valid() bool {
+	for _, role := range Roles {
+		if r == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Action is an operation that a principal may perform.
+type Action string
+
+// Actions that may be performed on tasks.
+const (
+	ActionTaskRead   Action = "task:read"
+	ActionTaskCreate Action = "task:create"
+	ActionTaskUpdate Action = "task:update"
+	ActionTaskDelete Action = "task:delete"
+	ActionTaskShare  Action = "task:share"
+)
+
+// Actions that may be performed on the comments on tasks. Authors may always edit and delete their own comments, so
+// moderating only covers deleting the comments of others.
+const (
+	ActionCommentCreate   Action = "comment:create"
+	ActionCommentModerate Action = "comment:moderate"
+)
+
+// Actions that may be performed on tags. Tags are shared by every task within a tenant so roles granted through a task,
+// such as the owner role, never permit them.
+const (
+	ActionTagManage Action = "tag:manage"
+)
+
+// Actions that may be performed on projects. Projects contain many tasks so roles granted through a task never permit
+// them either.
+const (
+	ActionProjectRead    Action = "project:read"
+	ActionProjectCreate  Action = "project:create"
+	ActionProjectUpdate  Action = "project:update"
+	ActionProjectArchive Action = "project:archive"
+)
+
+// Actions that may be performed on webhook subscriptions. Subscriptions receive events about every task within a tenant
+// so they are also only permitted by roles granted throughout the tenant.
+const (
+	ActionWebhookManage Action = "webhook:manage"
+)
+
+// Actions lists every action.
+var Actions = []Action{
+	ActionTaskRead,
+	ActionTaskCreate,
+	ActionTaskUpdate,
+	ActionTaskDelete,
+	ActionTaskShare,
+	ActionCommentCreate,
+	ActionCommentModerate,
+	ActionTagManage,
+	ActionProjectRead,
+	ActionProjectCreate,
+	ActionProjectUpdate,
+	ActionProjectArchive,
+	ActionWebhookManage,
+}
+
+// tenantActions are the actions that are not performed on a particular task, so only roles granted throughout the
+// tenant may permit them.
+var tenantActions = map[Action]bool{
+	ActionTagManage:      true,
+	ActionProjectRead:    true,
+	ActionProjectCreate:  true,
+	ActionProjectUpdate:  true,
+	ActionProjectArchive: true,
+	ActionWebhookManage:  true,
+}
+
+// Policy declares what each role is permitted to do.
+type Policy struct {
+	// Roles maps each role to the actions that it permits.
+	Roles map[Role][]Action `json:"roles"`
+	// DefaultRoles are granted to every principal within their tenant.
+	DefaultRoles []Role `json:"defaultRoles"`
+	// OwnerRole is granted to the owner of a task on that task. No role is granted if empty.
+	OwnerRole Role `json:"ownerRole"`
+}
+
+// Decision is the result of evaluating whether or not a principal may perform an action.
+type Decision struct {
+	Allowed bool
+	// Reason explains how the decision was reached.
+	Reason string
+}
+
+// Engine evaluates the policy.
+type Engine struct {
+	policy Policy
+}
+
+// New creates a policy engine. An error is returned if the policy refers to unknown roles or actions.
+func New(p Policy) (*Engine, error) {
+	for role, actions := range p.Roles {
+		if !role.Valid() {
+			return nil, fmt.Errorf("unknown role %s", role)
+		}
+		for _, action := range actions {
+			if !action.valid() {
+				return nil, fmt.Errorf("unknown action %s for role %s", action, role)
+			}
+		}
+	}
+
+	for _, role := range p.DefaultRoles {
+		if !role.Valid() {
+			return nil, fmt.Errorf("unknown default role %s", role)
+		}
+	}
+
+	if p.OwnerRole != "" && !p.OwnerRole.Valid() {
+		return nil, fmt.Errorf("unknown owner role %s", p.OwnerRole)
+	}
+
+	return &Engine{policy: p}, nil
+}
+
+// Load reads a JSON policy file and creates a policy engine for it.
+func Load(path string) (*Engine, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var p Policy
+	err = json.Unmarshal(raw, &p)
+	if err != nil {
+		return nil, fmt.Errorf("invalid policy file %s: %w", path, err)
+	}
+
+	return New(p)
+}
+
+// Evaluate decides whether or not the principal may perform the action on the task. The task should be nil when
+// the action is not performed on an existing task, e.g. creating a task.
+//
+// Principals are granted their own roles, the policy's default roles, the policy's owner role for tasks that they
+// own, and any role that the task has been shared with them as. Principals may never act on another tenant's tasks.
+func (e *Engine) Evaluate(p auth.Principal, action Action, t *task.Task) Decision {
+	if t != nil && t.TenantID != p.TenantID {
+		return Decision{Allowed: false, Reason: "task belongs to another tenant"}
+	}
+
+	if tenantActions[action] {
+		t = nil
+	}
+
+	for _, grant := range e.grants(p, t) {
+		if e.permits(grant.role, action) {
+			return Decision{Allowed: true, Reason: fmt.Sprintf("%s role granted %s", grant.role, grant.source)}
+		}
+	}
+
+	return Decision{Allowed: false, Reason: "no role permits the action"}
+}
+
+// Authorize decides whether or not the principal may perform the action on the task and logs the decision for
+// auditing purposes. See Evaluate.
+func (e *Engine) Authorize(p auth.Principal, action Action, t *task.Task) bool {
+	decision := e.Evaluate(p, action, t)
+
+	event := log.Info().
+		Bool("audit", true).
+		Str("tenant", p.TenantID).
+		Str("principal", p.Subject).
+		Str("action", string(action)).
+		Bool("allowed", decision.Allowed).
+		Str("reason", decision.Reason)
+	if t != nil {
+		event = event.Str("task", t.ID)
+	}
+	event.Msg("Authorization decision")
+
+	return decision.Allowed
+}
+
+// AuthorizeEach decides whether or not the principal may perform the action on each of the tasks, in the same order
+// as the tasks, and logs a single summary of the decisions for auditing purposes. Useful for filtering lists of tasks,
+// where logging every decision would flood the audit log. See Evaluate.
+func (e *Engine) AuthorizeEach(p auth.Principal, action Action, ts []task.Task) []bool {
+	allowed := make([]bool, len(ts))
+	denied := []string{}
+	for i := range ts {
+		allowed[i] = e.Evaluate(p, action, &ts[i]).Allowed
+		if !allowed[i] {
+			denied = append(denied, ts[i].ID)
+		}
+	}
+
+	log.Info().
+		Bool("audit", true).
+		Str("tenant", p.TenantID).
+		Str("principal", p.Subject).
+		Str("action", string(action)).
+		Int("tasks", len(ts)).
+		Strs("denied", denied).
+		Msg("Authorization decisions")
+
+	return allowed
+}
+
+// AuthorizeList determines which of the tasks within the principal's tenant they may perform the action on, so that
+// lists of tasks can be filtered by the database, and logs the decision for auditing purposes. Nil is returned when the
+// principal may perform the action on every task. See Evaluate.
+func (e *Engine) AuthorizeList(p auth.Principal, action Action) *task.Visibility {
+	visibility := e.visibility(p, action)
+
+	event := log.Info().
+		Bool("audit", true).
+		Str("tenant", p.TenantID).
+		Str("principal", p.Subject).
+		Str("action", string(action)).
+		Bool("allTasks", visibility == nil)
+	if visibility != nil {
+		event = event.Bool("owned", visibility.Owned).Strs("sharedAs", visibility.SharedAs)
+	}
+	event.Msg("List authorization decision")
+
+	return visibility
+}
+
+// AllowedActions lists all of the actions that the principal may perform on the task. Useful for determining what
+// functionality to expose to the principal. Decisions are not logged.
+func (e *Engine) AllowedActions(p auth.Principal, t *task.Task) []Action {
+	allowed := []Action{}
+	for _, action := range Actions {
+		if e.Evaluate(p, action, t).Allowed {
+			allowed = append(allowed, action)
+		}
+	}
+
+	return allowed
+}
+
+// grant is a role granted to a principal and how it was granted.
+type grant struct {
+	role   Role
+	source string
+}
+
+// grants determines all of the roles granted to the principal for the task.
+func (e *Engine) grants(p auth.Principal, t *task.Task) []grant {
+	var grants []grant
+	for _, role := range p.Roles {
+		grants = append(grants, grant{role: Role(role), source: "to principal"})
+	}
+	for _, role := range e.policy.DefaultRoles {
+		grants = append(grants, grant{role: role, source: "by default"})
+	}
+
+	if t != nil {
+		if e.policy.OwnerRole != "" && t.OwnerID == p.Subject {
+			grants = append(grants, grant{role: e.policy.OwnerRole, source: "as owner"})
+		}
+		if role, ok := t.Shares[p.Subject]; ok {
+			grants = append(grants, grant{role: Role(role), source: "by share"})
+		}
+	}
+
+	return grants
+}
+
+// visibility determines which tasks the principal may perform the action on. Roles granted to the principal throughout
+// the tenant cover every task, otherwise only the roles granted through a task may permit it.
+func (e *Engine) visibility(p auth.Principal, action Action) *task.Visibility {
+	for _, grant := range e.grants(p, nil) {
+		if e.permits(grant.role, action) {
+			return nil
+		}
+	}
+
+	v := task.Visibility{Subject: p.Subject, SharedAs: []string{}}
+	if tenantActions[action] {
+		return &v
+	}
+
+	v.Owned = e.policy.OwnerRole != "" && e.permits(e.policy.OwnerRole, action)
+	for _, role := range Roles {
+		if e.permits(role, action) {
+			v.SharedAs = append(v.SharedAs, string(role))
+		}
+	}
+
+	return &v
+}
+
+// permits indicates whether or not the role permits the action.
+func (e *Engine) permits(role Role, action Action) bool {
+	for _, permitted := range e.policy.Roles[role] {
+		if permitted == action {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (a Action) valid() bool {
+	for _, action := range Actions {
+		if a == action {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,296 @@
+package policy_test
+
+import (
+	"bytes"
+	"github.com/jaredpetersen/go-rest-template/internal/auth"
+	"github.com/jaredpetersen/go-rest-template/internal/policy"
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testPolicy = policy.Policy{
+	Roles: map[policy.Role][]policy.Action{
+		policy.RoleViewer: {policy.ActionTaskRead},
+		policy.RoleEditor: {policy.ActionTaskRead, policy.ActionTaskCreate, policy.ActionTaskUpdate},
+		policy.RoleAdmin:  policy.Actions,
+	},
+	OwnerRole: policy.RoleEditor,
+}
+
+func TestRoleValid(t *testing.T) {
+	for _, role := range policy.Roles {
+		assert.True(t, role.Valid(), "Role %s is not valid", role)
+	}
+
+	assert.False(t, policy.Role("superuser").Valid())
+	assert.False(t, policy.Role("").Valid())
+}
+
+func TestNewInvalid(t *testing.T) {
+	var tests = []policy.Policy{
+		{Roles: map[policy.Role][]policy.Action{"superuser": {policy.ActionTaskRead}}},
+		{Roles: map[policy.Role][]policy.Action{policy.RoleViewer: {"task:explode"}}},
+		{DefaultRoles: []policy.Role{"superuser"}},
+		{OwnerRole: "superuser"},
+	}
+
+	for _, tt := range tests {
+		engine, err := policy.New(tt)
+		assert.Error(t, err, "Did not return error for %v", tt)
+		assert.Nil(t, engine, "Returned engine")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	// Make sure that the policy that ships with the application is valid
+	engine, err := policy.Load(filepath.Join("..", "..", "config", "policy.json"))
+	require.NoError(t, err, "Returned error")
+	require.NotNil(t, engine, "Engine is nil")
+
+	principal := auth.Principal{Subject: "user-1", TenantID: "tenant-1"}
+	tsk := task.Task{ID: "task-1", TenantID: "tenant-1", OwnerID: "user-2"}
+
+	assert.Equal(t, []policy.Action{policy.ActionTaskRead, policy.ActionProjectRead}, engine.AllowedActions(principal, &tsk))
+}
+
+func TestLoadInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0600))
+
+	engine, err := policy.Load(path)
+	assert.Error(t, err, "Did not return error")
+	assert.Nil(t, engine, "Returned engine")
+
+	engine, err = policy.Load(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err, "Did not return error")
+	assert.Nil(t, engine, "Returned engine")
+}
+
+func TestEvaluateMatrix(t *testing.T) {
+	engine, err := policy.New(testPolicy)
+	require.NoError(t, err, "Failed to create engine")
+
+	otherTask := task.Task{ID: "task-1", TenantID: "tenant-1", OwnerID: "someone-else"}
+	ownTask := task.Task{ID: "task-2", TenantID: "tenant-1", OwnerID: "user-1"}
+	sharedTask := task.Task{ID: "task-3", TenantID: "tenant-1", OwnerID: "someone-else", Shares: map[string]string{"user-1": "viewer"}}
+	foreignTask := task.Task{ID: "task-4", TenantID: "tenant-2", OwnerID: "user-1"}
+
+	var tests = []struct {
+		name     string
+		roles    []string
+		task     *task.Task
+		expected []policy.Action
+	}{
+		{
+			name:     "no roles",
+			task:     &otherTask,
+			expected: []policy.Action{},
+		},
+		{
+			name:     "no roles creating",
+			expected: []policy.Action{},
+		},
+		{
+			name:     "viewer",
+			roles:    []string{"viewer"},
+			task:     &otherTask,
+			expected: []policy.Action{policy.ActionTaskRead},
+		},
+		{
+			name:     "viewer creating",
+			roles:    []string{"viewer"},
+			expected: []policy.Action{policy.ActionTaskRead},
+		},
+		{
+			name:     "editor",
+			roles:    []string{"editor"},
+			task:     &otherTask,
+			expected: []policy.Action{policy.ActionTaskRead, policy.ActionTaskCreate, policy.ActionTaskUpdate},
+		},
+		{
+			name:     "admin",
+			roles:    []string{"admin"},
+			task:     &otherTask,
+			expected: policy.Actions,
+		},
+		{
+			name:     "multiple roles",
+			roles:    []string{"viewer", "admin"},
+			task:     &otherTask,
+			expected: policy.Actions,
+		},
+		{
+			name:     "unknown role",
+			roles:    []string{"superuser"},
+			task:     &otherTask,
+			expected: []policy.Action{},
+		},
+		{
+			name:     "owner",
+			task:     &ownTask,
+			expected: []policy.Action{policy.ActionTaskRead, policy.ActionTaskCreate, policy.ActionTaskUpdate},
+		},
+		{
+			name:     "owner with admin role",
+			roles:    []string{"admin"},
+			task:     &ownTask,
+			expected: policy.Actions,
+		},
+		{
+			name:     "shared",
+			task:     &sharedTask,
+			expected: []policy.Action{policy.ActionTaskRead},
+		},
+		{
+			name:     "shared with editor role",
+			roles:    []string{"editor"},
+			task:     &sharedTask,
+			expected: []policy.Action{policy.ActionTaskRead, policy.ActionTaskCreate, policy.ActionTaskUpdate},
+		},
+		{
+			name:     "other tenant",
+			roles:    []string{"admin"},
+			task:     &foreignTask,
+			expected: []policy.Action{},
+		},
+	}
+
+	for _, tt := range tests {
+		principal := auth.Principal{Subject: "user-1", TenantID: "tenant-1", Roles: tt.roles}
+
+		assert.Equal(t, tt.expected, engine.AllowedActions(principal, tt.task), "Incorrect actions for %s", tt.name)
+
+		for _, action := range policy.Actions {
+			expected := false
+			for _, allowed := range tt.expected {
+				expected = expected || allowed == action
+			}
+
+			decision := engine.Evaluate(principal, action, tt.task)
+			assert.Equal(t, expected, decision.Allowed, "Incorrect decision for %s on %s", action, tt.name)
+			assert.NotEmpty(t, decision.Reason, "Missing reason for %s on %s", action, tt.name)
+			assert.Equal(t, expected, engine.Authorize(principal, action, tt.task))
+		}
+	}
+}
+
+func TestEvaluateDefaultRoles(t *testing.T) {
+	p := testPolicy
+	p.DefaultRoles = []policy.Role{policy.RoleViewer}
+
+	engine, err := policy.New(p)
+	require.NoError(t, err, "Failed to create engine")
+
+	principal := auth.Principal{Subject: "user-1", TenantID: "tenant-1"}
+	tsk := task.Task{ID: "task-1", TenantID: "tenant-1", OwnerID: "someone-else"}
+
+	decision := engine.Evaluate(principal, policy.ActionTaskRead, &tsk)
+	assert.True(t, decision.Allowed)
+	assert.Equal(t, "viewer role granted by default", decision.Reason)
+
+	decision = engine.Evaluate(principal, policy.ActionTaskUpdate, &tsk)
+	assert.False(t, decision.Allowed)
+}
+
+func TestEvaluateTenantActionsIgnoreTaskRoles(t *testing.T) {
+	p := testPolicy
+	p.OwnerRole = policy.RoleAdmin
+
+	engine, err := policy.New(p)
+	require.NoError(t, err, "Failed to create engine")
+
+	principal := auth.Principal{Subject: "user-1", TenantID: "tenant-1"}
+	tsk := task.Task{ID: "task-1", TenantID: "tenant-1", OwnerID: "user-1", Shares: map[string]string{"user-1": "admin"}}
+
+	decision := engine.Evaluate(principal, policy.ActionTaskShare, &tsk)
+	assert.True(t, decision.Allowed, "Owner role was not granted")
+
+	decision = engine.Evaluate(principal, policy.ActionTagManage, &tsk)
+	assert.False(t, decision.Allowed, "Task roles permitted managing tags")
+
+	decision = engine.Evaluate(principal, policy.ActionProjectArchive, &tsk)
+	assert.False(t, decision.Allowed, "Task roles permitted archiving projects")
+
+	decision = engine.Evaluate(principal, policy.ActionWebhookManage, &tsk)
+	assert.False(t, decision.Allowed, "Task roles permitted managing webhooks")
+
+	principal.Roles = []string{"admin"}
+	decision = engine.Evaluate(principal, policy.ActionTagManage, nil)
+	assert.True(t, decision.Allowed, "Principal role did not permit managing tags")
+}
+
+func TestAuthorizeEach(t *testing.T) {
+	var logs bytes.Buffer
+	originalLogger := log.Logger
+	log.Logger = zerolog.New(&logs)
+	defer func() { log.Logger = originalLogger }()
+
+	engine, err := policy.New(testPolicy)
+	require.NoError(t, err, "Failed to create engine")
+
+	principal := auth.Principal{Subject: "user-1", TenantID: "tenant-1"}
+	ts := []task.Task{
+		{ID: "task-1", TenantID: "tenant-1", OwnerID: "user-1"},
+		{ID: "task-2", TenantID: "tenant-1", OwnerID: "user-2"},
+		{ID: "task-3", TenantID: "tenant-1", OwnerID: "user-2", Shares: map[string]string{"user-1": "viewer"}},
+		{ID: "task-4", TenantID: "tenant-2", OwnerID: "user-1"},
+	}
+
+	allowed := engine.AuthorizeEach(principal, policy.ActionTaskRead, ts)
+	assert.Equal(t, []bool{true, false, true, false}, allowed)
+
+	// A single summary is logged instead of a record for every decision
+	assert.JSONEq(t, `{
+		"level": "info",
+		"audit": true,
+		"tenant": "tenant-1",
+		"principal": "user-1",
+		"action": "task:read",
+		"tasks": 4,
+		"denied": ["task-2", "task-4"],
+		"message": "Authorization decisions"
+	}`, logs.String())
+}
+
+func TestAuthorizeList(t *testing.T) {
+	var logs bytes.Buffer
+	originalLogger := log.Logger
+	log.Logger = zerolog.New(&logs)
+	defer func() { log.Logger = originalLogger }()
+
+	engine, err := policy.New(testPolicy)
+	require.NoError(t, err, "Failed to create engine")
+
+	principal := auth.Principal{Subject: "user-1", TenantID: "tenant-1"}
+
+	visibility := engine.AuthorizeList(principal, policy.ActionTaskRead)
+	assert.Equal(t, &task.Visibility{Subject: "user-1", Owned: true, SharedAs: []string{"viewer", "editor", "admin"}}, visibility)
+	assert.JSONEq(t, `{
+		"level": "info",
+		"audit": true,
+		"tenant": "tenant-1",
+		"principal": "user-1",
+		"action": "task:read",
+		"allTasks": false,
+		"owned": true,
+		"sharedAs": ["viewer", "editor", "admin"],
+		"message": "List authorization decision"
+	}`, logs.String())
+
+	visibility = engine.AuthorizeList(principal, policy.ActionTaskShare)
+	assert.Equal(t, &task.Visibility{Subject: "user-1", Owned: false, SharedAs: []string{"admin"}}, visibility)
+
+	visibility = engine.AuthorizeList(principal, policy.ActionProjectRead)
+	assert.Equal(t, &task.Visibility{Subject: "user-1", SharedAs: []string{}}, visibility, "Task roles permitted a tenant action")
+
+	principal.Roles = []string{"viewer"}
+	visibility = engine.AuthorizeList(principal, policy.ActionTaskRead)
+	assert.Nil(t, visibility, "Principal role did not cover every task")
+}
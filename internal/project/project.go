@@ -0,0 +1,36 @@
+// Package project groups tasks into projects.
+package project
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Project is a container for related tasks.
+type Project struct {
+	ID          string `json:"id"`
+	TenantID    string `json:"tenantId"`
+	OwnerID     string `json:"ownerId"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// TaskCount is the number of tasks in the project. It is maintained by the database in the same transaction that
+	// adds tasks to the project.
+	TaskCount int `json:"taskCount"`
+	// DateArchived is when the project was archived. Tasks in archived projects are hidden. Nil if the project is not
+	// archived.
+	DateArchived *time.Time `json:"dateArchived"`
+	DateCreated  time.Time  `json:"dateCreated"`
+	DateUpdated  time.Time  `json:"dateUpdated"`
+}
+
+// New creates a new project with default values. The returned pointer will never be nil.
+func New() *Project {
+	now := time.Now()
+	return &Project{ID: uuid.New().String(), DateCreated: now, DateUpdated: now}
+}
+
+// Archived indicates whether or not the project has been archived.
+func (p Project) Archived() bool {
+	return p.DateArchived != nil
+}
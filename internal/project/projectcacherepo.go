@@ -0,0 +1,58 @@
+package project
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/jaredpetersen/go-rest-template/internal/redis"
+)
+
+// CacheClient is a client for retrieving and manipulating projects in the cache
+type CacheClient interface {
+	Get(ctx context.Context, tenantID string, id string) (*Project, error)
+	Save(ctx context.Context, p Project) error
+	Delete(ctx context.Context, tenantID string, id string) error
+}
+
+// CacheRepo is a cache repository for projects.
+type CacheRepo struct {
+	Redis redis.Client
+}
+
+// Get retrieves a tenant's project from the cache using the project's ID. If a project cannot be found with that ID,
+// nil will be returned for both the project and error.
+func (cr CacheRepo) Get(ctx context.Context, tenantID string, id string) (*Project, error) {
+	val, err := cr.Redis.Get(ctx, getRedisKey(tenantID, id))
+	if err != nil {
+		return nil, err
+	}
+	if val == nil {
+		return nil, nil
+	}
+
+	var p Project
+	err = json.Unmarshal([]byte(*val), &p)
+
+	return &p, err
+}
+
+// Save stores a project in the cache.
+func (cr CacheRepo) Save(ctx context.Context, p Project) error {
+	value, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	return cr.Redis.Set(ctx, getRedisKey(p.TenantID, p.ID), value, 0)
+}
+
+// Delete removes a tenant's project from the cache.
+func (cr CacheRepo) Delete(ctx context.Context, tenantID string, id string) error {
+	return cr.Redis.Del(ctx, getRedisKey(tenantID, id))
+}
+
+// getRedisKey builds a redis key for the project in the cache. Keys are namespaced by tenant so that a project can
+// never be served to another tenant, even if the IDs collide.
+func getRedisKey(tenantID string, id string) string {
+	return "tenant." + tenantID + ".project." + id
+}
@@ -0,0 +1,102 @@
+package project_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jaredpetersen/go-rest-template/internal/project"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	redismock "github.com/jaredpetersen/go-rest-template/internal/redis/mocks"
+)
+
+func TestCacheRepoGet(t *testing.T) {
+	ctx := context.Background()
+
+	p := project.New()
+	p.TenantID = "tenant-1"
+	p.Name = "Home"
+	p.TaskCount = 3
+
+	value, err := json.Marshal(p)
+	require.NoError(t, err)
+	str := string(value)
+
+	rdb := redismock.Client{}
+	rdb.On("Get", mock.Anything, "tenant.tenant-1.project."+p.ID).Return(&str, nil)
+
+	pcr := project.CacheRepo{Redis: &rdb}
+
+	retrieved, err := pcr.Get(ctx, "tenant-1", p.ID)
+	assert.NoError(t, err, "Returned error")
+	require.NotNil(t, retrieved, "Did not return project")
+	assert.Equal(t, p.ID, retrieved.ID)
+	assert.Equal(t, "Home", retrieved.Name)
+	assert.Equal(t, 3, retrieved.TaskCount)
+
+	rdb.AssertExpectations(t)
+}
+
+func TestCacheRepoGetNonexistent(t *testing.T) {
+	ctx := context.Background()
+
+	rdb := redismock.Client{}
+	rdb.On("Get", mock.Anything, "tenant.tenant-1.project.someid").Return(nil, nil)
+
+	pcr := project.CacheRepo{Redis: &rdb}
+
+	retrieved, err := pcr.Get(ctx, "tenant-1", "someid")
+	assert.NoError(t, err, "Returned error")
+	assert.Nil(t, retrieved, "Returned project")
+}
+
+func TestCacheRepoGetReturnsRedisError(t *testing.T) {
+	ctx := context.Background()
+
+	expectedErr := errors.New("Failed")
+
+	rdb := redismock.Client{}
+	rdb.On("Get", mock.Anything, mock.Anything).Return(nil, expectedErr)
+
+	pcr := project.CacheRepo{Redis: &rdb}
+
+	retrieved, err := pcr.Get(ctx, "tenant-1", "someid")
+	assert.EqualError(t, err, expectedErr.Error(), "Did not return error")
+	assert.Nil(t, retrieved, "Returned project")
+}
+
+func TestCacheRepoSave(t *testing.T) {
+	ctx := context.Background()
+
+	p := project.New()
+	p.TenantID = "tenant-1"
+
+	rdb := redismock.Client{}
+	rdb.On("Set", mock.Anything, "tenant.tenant-1.project."+p.ID, mock.Anything, time.Duration(0)).Return(nil)
+
+	pcr := project.CacheRepo{Redis: &rdb}
+
+	err := pcr.Save(ctx, *p)
+	assert.NoError(t, err, "Returned error")
+
+	rdb.AssertExpectations(t)
+}
+
+func TestCacheRepoDelete(t *testing.T) {
+	ctx := context.Background()
+
+	rdb := redismock.Client{}
+	rdb.On("Del", mock.Anything, "tenant.tenant-1.project.someid").Return(nil)
+
+	pcr := project.CacheRepo{Redis: &rdb}
+
+	err := pcr.Delete(ctx, "tenant-1", "someid")
+	assert.NoError(t, err, "Returned error")
+
+	rdb.AssertExpectations(t)
+}
@@ -0,0 +1,95 @@
+package project
+
+import (
+	"context"
+	"database/sql"
+)
+
+// DBClient is a client for retrieving and manipulating projects in a SQL database
+type DBClient interface {
+	Get(ctx context.Context, tenantID string, id string) (*Project, error)
+	List(ctx context.Context, tenantID string, includeArchived bool) ([]Project, error)
+	Save(ctx context.Context, p Project) error
+	Update(ctx context.Context, p Project) error
+}
+
+// DBRepo is a database repository for projects.
+type DBRepo struct {
+	DB *sql.DB
+}
+
+// Get retrieves a tenant's project from the database using the project's ID. If a project cannot be found with that ID
+// for the tenant, nil will be returned for both the project and error.
+func (dbr DBRepo) Get(ctx context.Context, tenantID string, id string) (*Project, error) {
+	const query = `select owner_id, name, description, task_count, date_archived, date_created, date_updated
+		from project
+		where tenant_id = $1 and id = $2`
+	row := dbr.DB.QueryRowContext(ctx, query, tenantID, id)
+
+	p := Project{ID: id, TenantID: tenantID}
+	err := row.Scan(&p.OwnerID, &p.Name, &p.Description, &p.TaskCount, &p.DateArchived, &p.DateCreated, &p.DateUpdated)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+// List retrieves a tenant's projects from the database, ordered by name. Archived projects are left out unless
+// includeArchived is set.
+func (dbr DBRepo) List(ctx context.Context, tenantID string, includeArchived bool) ([]Project, error) {
+	const query = `select id, owner_id, name, description, task_count, date_archived, date_created, date_updated
+		from project
+		where tenant_id = $1 and ($2 or date_archived is null)
+		order by name, id`
+	rows, err := dbr.DB.QueryContext(ctx, query, tenantID, includeArchived)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ps := []Project{}
+	for rows.Next() {
+		p := Project{TenantID: tenantID}
+		err = rows.Scan(&p.ID, &p.OwnerID, &p.Name, &p.Description, &p.TaskCount, &p.DateArchived, &p.DateCreated, &p.DateUpdated)
+		if err != nil {
+			return nil, err
+		}
+
+		ps = append(ps, p)
+	}
+
+	return ps, rows.Err()
+}
+
+// Save stores a project in the database. New projects do not have any tasks so the task count is not stored.
+func (dbr DBRepo) Save(ctx context.Context, p Project) error {
+	const query = `insert into project (id, tenant_id, owner_id, name, description, date_archived, date_created, date_updated)
+		values ($1, $2, $3, $4, $5, $6, $7, $8)`
+	_, err := dbr.DB.ExecContext(ctx,
+		query,
+		p.ID,
+		p.TenantID,
+		p.OwnerID,
+		p.Name,
+		p.Description,
+		p.DateArchived,
+		p.DateCreated,
+		p.DateUpdated)
+
+	return err
+}
+
+// Update replaces a tenant's project in the database. The task count is left alone since it is only changed alongside
+// the tasks themselves.
+func (dbr DBRepo) Update(ctx context.Context, p Project) error {
+	const query = `update project
+		set name = $1, description = $2, date_archived = $3, date_updated = $4
+		where tenant_id = $5 and id = $6`
+	_, err := dbr.DB.ExecContext(ctx, query, p.Name, p.Description, p.DateArchived, p.DateUpdated, p.TenantID, p.ID)
+
+	return err
+}
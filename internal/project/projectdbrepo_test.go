@@ -0,0 +1,344 @@
+package project_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/jaredpetersen/go-rest-template/internal/migration"
+	"github.com/jaredpetersen/go-rest-template/internal/project"
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+	"testing"
+	"time"
+
+	_ "github.com/jackc/pgx/v4/stdlib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+type cockroachDBContainer struct {
+	testcontainers.Container
+	URI string
+}
+
+func setupCockroachDB(ctx context.Context) (*cockroachDBContainer, error) {
+	req := testcontainers.ContainerRequest{
+		Image:        "cockroachdb/cockroach:latest-v21.1",
+		ExposedPorts: []string{"26257/tcp", "8080/tcp"},
+		WaitingFor:   wait.ForHTTP("/health").WithPort("8080"),
+		Cmd:          []string{"start-single-node", "--insecure"},
+		SkipReaper:   true,
+	}
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	mappedPort, err := container.MappedPort(ctx, "26257")
+	if err != nil {
+		return nil, err
+	}
+
+	hostIP, err := container.Host(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	uri := fmt.Sprintf("postgres://root@%s:%s", hostIP, mappedPort.Port())
+
+	return &cockroachDBContainer{Container: container, URI: uri}, nil
+}
+
+func initCockroachDB(ctx context.Context, db *sql.DB) error {
+	const query = `CREATE DATABASE projectmanagement`
+	_, err := db.ExecContext(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return migration.Migrate(ctx, db)
+}
+
+func truncateCockroachDB(ctx context.Context, db *sql.DB) error {
+	const query = `truncate projectmanagement.task, projectmanagement.tag, projectmanagement.task_tag, projectmanagement.project,
+		projectmanagement.task_dependency, projectmanagement.outbox_message, projectmanagement.task_history, projectmanagement.task_comment,
+		projectmanagement.task_attachment`
+	_, err := db.ExecContext(ctx, query)
+	return err
+}
+
+func TestIntegrationDBRepoSaveGet(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+
+	cdbContainer, err := setupCockroachDB(ctx)
+	require.NoError(t, err, "Failed to start up CockroachDB container")
+	defer cdbContainer.Terminate(ctx)
+
+	db, err := sql.Open("pgx", cdbContainer.URI+"/projectmanagement")
+	require.NoError(t, err, "Failed to open connection to CockroachDB")
+	defer db.Close()
+
+	err = initCockroachDB(ctx, db)
+	require.NoError(t, err, "Failed to initialize CockroachDB")
+	defer truncateCockroachDB(ctx, db)
+
+	pdbr := project.DBRepo{DB: db}
+
+	p := project.New()
+	p.TenantID = "tenant-1"
+	p.OwnerID = "user-1"
+	p.Name = "Home"
+	p.Description = "Chores around the house"
+	p.DateCreated = p.DateCreated.Truncate(time.Microsecond)
+	p.DateUpdated = p.DateUpdated.Truncate(time.Microsecond)
+
+	err = pdbr.Save(ctx, *p)
+	require.NoError(t, err, "Save returned error")
+
+	savedProject, err := pdbr.Get(ctx, "tenant-1", p.ID)
+	require.NoError(t, err, "Get returned error")
+	require.NotNil(t, savedProject, "Get did not return a project")
+	assert.Equal(t, p.ID, savedProject.ID)
+	assert.Equal(t, p.TenantID, savedProject.TenantID)
+	assert.Equal(t, p.OwnerID, savedProject.OwnerID)
+	assert.Equal(t, p.Name, savedProject.Name)
+	assert.Equal(t, p.Description, savedProject.Description)
+	assert.Equal(t, 0, savedProject.TaskCount)
+	assert.Nil(t, savedProject.DateArchived)
+	assert.True(t, p.DateCreated.Equal(savedProject.DateCreated), "Incorrect date created")
+	assert.True(t, p.DateUpdated.Equal(savedProject.DateUpdated), "Incorrect date updated")
+
+	// Projects belong to a tenant
+	savedProject, err = pdbr.Get(ctx, "tenant-2", p.ID)
+	require.NoError(t, err, "Get returned error")
+	assert.Nil(t, savedProject, "Get returned another tenant's project")
+}
+
+func TestIntegrationDBRepoGetNotFound(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+
+	cdbContainer, err := setupCockroachDB(ctx)
+	require.NoError(t, err, "Failed to start up CockroachDB container")
+	defer cdbContainer.Terminate(ctx)
+
+	db, err := sql.Open("pgx", cdbContainer.URI+"/projectmanagement")
+	require.NoError(t, err, "Failed to open connection to CockroachDB")
+	defer db.Close()
+
+	err = initCockroachDB(ctx, db)
+	require.NoError(t, err, "Failed to initialize CockroachDB")
+
+	pdbr := project.DBRepo{DB: db}
+
+	p, err := pdbr.Get(ctx, "tenant-1", project.New().ID)
+	assert.NoError(t, err, "Get returned error")
+	assert.Nil(t, p, "Get returned a project")
+}
+
+func TestIntegrationDBRepoList(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+
+	cdbContainer, err := setupCockroachDB(ctx)
+	require.NoError(t, err, "Failed to start up CockroachDB container")
+	defer cdbContainer.Terminate(ctx)
+
+	db, err := sql.Open("pgx", cdbContainer.URI+"/projectmanagement")
+	require.NoError(t, err, "Failed to open connection to CockroachDB")
+	defer db.Close()
+
+	err = initCockroachDB(ctx, db)
+	require.NoError(t, err, "Failed to initialize CockroachDB")
+	defer truncateCockroachDB(ctx, db)
+
+	pdbr := project.DBRepo{DB: db}
+
+	archived := time.Now()
+	garden := project.New()
+	garden.TenantID = "tenant-1"
+	garden.Name = "Garden"
+	home := project.New()
+	home.TenantID = "tenant-1"
+	home.Name = "Home"
+	attic := project.New()
+	attic.TenantID = "tenant-1"
+	attic.Name = "Attic"
+	attic.DateArchived = &archived
+	other := project.New()
+	other.TenantID = "tenant-2"
+	other.Name = "Office"
+
+	for _, p := range []*project.Project{home, garden, attic, other} {
+		err = pdbr.Save(ctx, *p)
+		require.NoError(t, err, "Save returned error")
+	}
+
+	var tests = []struct {
+		name            string
+		includeArchived bool
+		expected        []string
+	}{
+		{name: "Unarchived", includeArchived: false, expected: []string{garden.ID, home.ID}},
+		{name: "IncludeArchived", includeArchived: true, expected: []string{attic.ID, garden.ID, home.ID}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ps, err := pdbr.List(ctx, "tenant-1", tt.includeArchived)
+			require.NoError(t, err, "List returned error")
+
+			ids := []string{}
+			for _, p := range ps {
+				ids = append(ids, p.ID)
+			}
+			assert.Equal(t, tt.expected, ids)
+		})
+	}
+}
+
+func TestIntegrationDBRepoUpdate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+
+	cdbContainer, err := setupCockroachDB(ctx)
+	require.NoError(t, err, "Failed to start up CockroachDB container")
+	defer cdbContainer.Terminate(ctx)
+
+	db, err := sql.Open("pgx", cdbContainer.URI+"/projectmanagement")
+	require.NoError(t, err, "Failed to open connection to CockroachDB")
+	defer db.Close()
+
+	err = initCockroachDB(ctx, db)
+	require.NoError(t, err, "Failed to initialize CockroachDB")
+	defer truncateCockroachDB(ctx, db)
+
+	pdbr := project.DBRepo{DB: db}
+	tdbr := task.DBRepo{DB: db}
+
+	p := project.New()
+	p.TenantID = "tenant-1"
+	p.Name = "Home"
+	err = pdbr.Save(ctx, *p)
+	require.NoError(t, err, "Save returned error")
+
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.ProjectID = p.ID
+	err = tdbr.Save(ctx, *tsk)
+	require.NoError(t, err, "Task save returned error")
+
+	// The project being updated is stale and does not know about the task, which must still be counted
+	archived := time.Now()
+	p.Name = "House"
+	p.Description = "Chores around the house"
+	p.DateArchived = &archived
+	p.DateUpdated = p.DateUpdated.Add(time.Minute)
+	err = pdbr.Update(ctx, *p)
+	require.NoError(t, err, "Update returned error")
+
+	savedProject, err := pdbr.Get(ctx, "tenant-1", p.ID)
+	require.NoError(t, err, "Get returned error")
+	require.NotNil(t, savedProject, "Get did not return a project")
+	assert.Equal(t, "House", savedProject.Name)
+	assert.Equal(t, "Chores around the house", savedProject.Description)
+	assert.True(t, savedProject.Archived(), "Project was not archived")
+	assert.Equal(t, 1, savedProject.TaskCount, "Update changed the task count")
+}
+
+func TestIntegrationDBRepoTaskCount(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+
+	cdbContainer, err := setupCockroachDB(ctx)
+	require.NoError(t, err, "Failed to start up CockroachDB container")
+	defer cdbContainer.Terminate(ctx)
+
+	db, err := sql.Open("pgx", cdbContainer.URI+"/projectmanagement")
+	require.NoError(t, err, "Failed to open connection to CockroachDB")
+	defer db.Close()
+
+	err = initCockroachDB(ctx, db)
+	require.NoError(t, err, "Failed to initialize CockroachDB")
+	defer truncateCockroachDB(ctx, db)
+
+	pdbr := project.DBRepo{DB: db}
+	tdbr := task.DBRepo{DB: db}
+
+	home := project.New()
+	home.TenantID = "tenant-1"
+	home.Name = "Home"
+	archived := time.Now()
+	attic := project.New()
+	attic.TenantID = "tenant-1"
+	attic.Name = "Attic"
+	attic.DateArchived = &archived
+	for _, p := range []*project.Project{home, attic} {
+		err = pdbr.Save(ctx, *p)
+		require.NoError(t, err, "Save returned error")
+	}
+
+	taskCount := func() int {
+		savedProject, err := pdbr.Get(ctx, "tenant-1", home.ID)
+		require.NoError(t, err, "Get returned error")
+		require.NotNil(t, savedProject, "Get did not return a project")
+		return savedProject.TaskCount
+	}
+
+	paint := task.New()
+	paint.TenantID = "tenant-1"
+	paint.ProjectID = home.ID
+	mow := task.New()
+	mow.TenantID = "tenant-1"
+	mow.ProjectID = home.ID
+	err = tdbr.SaveBatch(ctx, []task.Task{*paint, *mow})
+	require.NoError(t, err, "Task SaveBatch returned error")
+	assert.Equal(t, 2, taskCount(), "Saving tasks did not count them")
+
+	// The count is rolled back along with the tasks when any part of the transaction fails, even after the project was
+	// counted
+	rake := task.New()
+	rake.TenantID = "tenant-1"
+	rake.ProjectID = home.ID
+	dust := task.New()
+	dust.TenantID = "tenant-1"
+	dust.ProjectID = attic.ID
+	err = tdbr.SaveBatch(ctx, []task.Task{*rake, *dust})
+	assert.ErrorIs(t, err, task.ErrProjectUnavailable)
+	assert.Equal(t, 2, taskCount(), "Failed save changed the task count")
+
+	savedTsk, err := tdbr.Get(ctx, "tenant-1", rake.ID)
+	require.NoError(t, err, "Task get returned error")
+	assert.Nil(t, savedTsk, "Failed save stored a task")
+
+	err = tdbr.Delete(ctx, *paint)
+	require.NoError(t, err, "Task delete returned error")
+	assert.Equal(t, 1, taskCount(), "Deleting a task did not stop counting it")
+
+	// Deleting a stale version of a task fails without changing the count
+	staleMow := *mow
+	staleMow.Version++
+	err = tdbr.Delete(ctx, staleMow)
+	assert.ErrorIs(t, err, task.ErrVersionConflict)
+	assert.Equal(t, 1, taskCount(), "Failed delete changed the task count")
+}
@@ -0,0 +1,86 @@
+package projectmgr
+
+import (
+	"context"
+
+	"github.com/jaredpetersen/go-rest-template/internal/project"
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+	"github.com/rs/zerolog/log"
+)
+
+// Manager coordinates retrieving and storing projects across the cache and the database.
+type Manager struct {
+	ProjectCacheClient project.CacheClient
+	ProjectDBClient    project.DBClient
+	// TaskSearcher is told when projects are archived or restored if it maintains its own index. Optional.
+	TaskSearcher task.Searcher
+}
+
+// Get retrieves a tenant's project by ID, first looking to the cache and then falling back on the database. Projects
+// retrieved from the database are cached.
+func (mgr Manager) Get(ctx context.Context, tenantID string, id string) (*project.Project, error) {
+	p, err := mgr.ProjectCacheClient.Get(ctx, tenantID, id)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to retrieve project from cache")
+	}
+
+	if p != nil {
+		return p, nil
+	}
+
+	p, err = mgr.ProjectDBClient.Get(ctx, tenantID, id)
+	if err != nil || p == nil {
+		return p, err
+	}
+
+	err = mgr.ProjectCacheClient.Save(ctx, *p)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to store project in cache")
+	}
+
+	return p, nil
+}
+
+// List retrieves a tenant's projects from the database, ordered by name. Archived projects are left out unless
+// includeArchived is set. The cache is not used since it cannot be queried.
+func (mgr Manager) List(ctx context.Context, tenantID string, includeArchived bool) ([]project.Project, error) {
+	return mgr.ProjectDBClient.List(ctx, tenantID, includeArchived)
+}
+
+// Save stores a project in the database.
+func (mgr Manager) Save(ctx context.Context, p project.Project) error {
+	return mgr.ProjectDBClient.Save(ctx, p)
+}
+
+// Update replaces a project in the database and then removes it from the cache and updates the search index.
+//
+// The project is removed rather than replaced in the cache since the caller's copy of the task count may already be
+// outdated. If the removal or indexing fails, the error is logged and ignored.
+func (mgr Manager) Update(ctx context.Context, p project.Project) error {
+	err := mgr.ProjectDBClient.Update(ctx, p)
+	if err != nil {
+		return err
+	}
+
+	err = mgr.ProjectCacheClient.Delete(ctx, p.TenantID, p.ID)
+	if err != nil {
+		log.Error().Err(err).Str("project", p.ID).Msg("Failed to remove outdated project from cache")
+	}
+
+	mgr.index(ctx, p)
+
+	return nil
+}
+
+// index records whether or not the project is archived in the search index when the searcher maintains its own index.
+func (mgr Manager) index(ctx context.Context, p project.Project) {
+	indexer, ok := mgr.TaskSearcher.(task.ProjectIndexer)
+	if !ok {
+		return
+	}
+
+	err := indexer.IndexProject(ctx, p.TenantID, p.ID, p.Archived())
+	if err != nil {
+		log.Warn().Err(err).Str("project", p.ID).Msg("Failed to index project for search")
+	}
+}
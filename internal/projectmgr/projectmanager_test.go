@@ -0,0 +1,186 @@
+package projectmgr_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jaredpetersen/go-rest-template/internal/project"
+	projectmock "github.com/jaredpetersen/go-rest-template/internal/project/mocks"
+	"github.com/jaredpetersen/go-rest-template/internal/projectmgr"
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetReturnsCachedProject(t *testing.T) {
+	ctx := context.Background()
+
+	storedProject := project.Project{ID: "someid", TenantID: "sometenant"}
+
+	pcr := projectmock.CacheClient{}
+	pcr.On("Get", mock.Anything, storedProject.TenantID, storedProject.ID).Return(&storedProject, nil)
+
+	pdbr := projectmock.DBClient{}
+
+	mgr := projectmgr.Manager{ProjectCacheClient: &pcr, ProjectDBClient: &pdbr}
+
+	retrievedProject, err := mgr.Get(ctx, storedProject.TenantID, storedProject.ID)
+	assert.NoError(t, err, "Returned error")
+	assert.Equal(t, &storedProject, retrievedProject, "Returned incorrect project")
+
+	pcr.AssertExpectations(t)
+	pdbr.AssertExpectations(t)
+}
+
+func TestGetReturnsStoredProjectOnCacheMiss(t *testing.T) {
+	ctx := context.Background()
+
+	storedProject := project.Project{ID: "someid", TenantID: "sometenant"}
+
+	pcr := projectmock.CacheClient{}
+	pcr.On("Get", mock.Anything, storedProject.TenantID, storedProject.ID).Return(nil, nil)
+	pcr.On("Save", mock.Anything, storedProject).Return(nil)
+
+	pdbr := projectmock.DBClient{}
+	pdbr.On("Get", mock.Anything, storedProject.TenantID, storedProject.ID).Return(&storedProject, nil)
+
+	mgr := projectmgr.Manager{ProjectCacheClient: &pcr, ProjectDBClient: &pdbr}
+
+	retrievedProject, err := mgr.Get(ctx, storedProject.TenantID, storedProject.ID)
+	assert.NoError(t, err, "Returned error")
+	assert.Equal(t, &storedProject, retrievedProject, "Returned incorrect project")
+
+	pcr.AssertExpectations(t)
+	pdbr.AssertExpectations(t)
+}
+
+func TestGetReturnsStoredProjectOnCacheError(t *testing.T) {
+	ctx := context.Background()
+
+	storedProject := project.Project{ID: "someid", TenantID: "sometenant"}
+
+	pcr := projectmock.CacheClient{}
+	pcr.On("Get", mock.Anything, storedProject.TenantID, storedProject.ID).Return(nil, errors.New("Failed"))
+	pcr.On("Save", mock.Anything, storedProject).Return(errors.New("Failed"))
+
+	pdbr := projectmock.DBClient{}
+	pdbr.On("Get", mock.Anything, storedProject.TenantID, storedProject.ID).Return(&storedProject, nil)
+
+	mgr := projectmgr.Manager{ProjectCacheClient: &pcr, ProjectDBClient: &pdbr}
+
+	retrievedProject, err := mgr.Get(ctx, storedProject.TenantID, storedProject.ID)
+	assert.NoError(t, err, "Returned error")
+	assert.Equal(t, &storedProject, retrievedProject, "Returned incorrect project")
+
+	pcr.AssertExpectations(t)
+	pdbr.AssertExpectations(t)
+}
+
+func TestGetNonexistentProject(t *testing.T) {
+	ctx := context.Background()
+
+	pcr := projectmock.CacheClient{}
+	pcr.On("Get", mock.Anything, "sometenant", "someid").Return(nil, nil)
+
+	pdbr := projectmock.DBClient{}
+	pdbr.On("Get", mock.Anything, "sometenant", "someid").Return(nil, nil)
+
+	mgr := projectmgr.Manager{ProjectCacheClient: &pcr, ProjectDBClient: &pdbr}
+
+	retrievedProject, err := mgr.Get(ctx, "sometenant", "someid")
+	assert.NoError(t, err, "Returned error")
+	assert.Nil(t, retrievedProject, "Returned project")
+
+	pcr.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
+
+func TestUpdateRemovesProjectFromCache(t *testing.T) {
+	ctx := context.Background()
+
+	p := project.Project{ID: "someid", TenantID: "sometenant"}
+
+	pcr := projectmock.CacheClient{}
+	pcr.On("Delete", mock.Anything, p.TenantID, p.ID).Return(nil)
+
+	pdbr := projectmock.DBClient{}
+	pdbr.On("Update", mock.Anything, p).Return(nil)
+
+	mgr := projectmgr.Manager{ProjectCacheClient: &pcr, ProjectDBClient: &pdbr}
+
+	err := mgr.Update(ctx, p)
+	assert.NoError(t, err, "Returned error")
+
+	pcr.AssertExpectations(t)
+	pdbr.AssertExpectations(t)
+}
+
+func TestUpdateIndexesArchivedProject(t *testing.T) {
+	ctx := context.Background()
+
+	archived := time.Now()
+	p := project.Project{ID: "someid", TenantID: "sometenant", DateArchived: &archived}
+
+	tsk := task.New()
+	tsk.TenantID = p.TenantID
+	tsk.ProjectID = p.ID
+	tsk.Description = "Buy socks"
+
+	idx := task.NewMemoryIndex()
+	err := idx.Index(ctx, *tsk)
+	require.NoError(t, err, "Index returned error")
+
+	pcr := projectmock.CacheClient{}
+	pcr.On("Delete", mock.Anything, p.TenantID, p.ID).Return(nil)
+
+	pdbr := projectmock.DBClient{}
+	pdbr.On("Update", mock.Anything, p).Return(nil)
+
+	mgr := projectmgr.Manager{ProjectCacheClient: &pcr, ProjectDBClient: &pdbr, TaskSearcher: idx}
+
+	err = mgr.Update(ctx, p)
+	assert.NoError(t, err, "Returned error")
+
+	results, err := idx.Search(ctx, p.TenantID, "socks", 10)
+	require.NoError(t, err, "Search returned error")
+	assert.Empty(t, results, "Archived project's task was searchable")
+}
+
+func TestUpdateOnCacheError(t *testing.T) {
+	ctx := context.Background()
+
+	p := project.Project{ID: "someid", TenantID: "sometenant"}
+
+	pcr := projectmock.CacheClient{}
+	pcr.On("Delete", mock.Anything, p.TenantID, p.ID).Return(errors.New("Failed"))
+
+	pdbr := projectmock.DBClient{}
+	pdbr.On("Update", mock.Anything, p).Return(nil)
+
+	mgr := projectmgr.Manager{ProjectCacheClient: &pcr, ProjectDBClient: &pdbr}
+
+	err := mgr.Update(ctx, p)
+	assert.NoError(t, err, "Returned error")
+}
+
+func TestUpdateReturnsErrorOnDBError(t *testing.T) {
+	ctx := context.Background()
+
+	p := project.Project{ID: "someid", TenantID: "sometenant"}
+
+	expectedErr := errors.New("Failed")
+
+	pcr := projectmock.CacheClient{}
+
+	pdbr := projectmock.DBClient{}
+	pdbr.On("Update", mock.Anything, p).Return(expectedErr)
+
+	mgr := projectmgr.Manager{ProjectCacheClient: &pcr, ProjectDBClient: &pdbr}
+
+	err := mgr.Update(ctx, p)
+	assert.EqualError(t, err, expectedErr.Error(), "Did not return error")
+
+	pcr.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+}
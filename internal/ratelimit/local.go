@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// pruneInterval is the number of requests between removing keys that have fully reset.
+const pruneInterval = 1000
+
+// LocalLimiter is a limiter that only applies to the current process. Each replica of the service enforces the limit
+// independently so clients may exceed the limit when their requests are spread across replicas.
+type LocalLimiter struct {
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+
+	mtx      sync.Mutex
+	tats     map[string]time.Time
+	requests int
+}
+
+// NewLocalLimiter creates a limiter that only applies to the current process.
+func NewLocalLimiter() *LocalLimiter {
+	return &LocalLimiter{Now: time.Now, tats: make(map[string]time.Time)}
+}
+
+// Allow records a request for the key and decides whether or not it is permitted by the limit. Never returns an error.
+func (ll *LocalLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	emissionInterval := limit.emissionInterval()
+	burstOffset := emissionInterval * time.Duration(limit.burst())
+
+	ll.mtx.Lock()
+	defer ll.mtx.Unlock()
+
+	now := ll.Now()
+	ll.prune(now)
+
+	tat, ok := ll.tats[key]
+	if !ok || tat.Before(now) {
+		tat = now
+	}
+
+	newTAT := tat.Add(emissionInterval)
+	diff := now.Sub(newTAT.Add(-burstOffset))
+	if diff < 0 {
+		return Result{Allowed: false, Limit: limit.burst(), RetryAfter: -diff, ResetAfter: tat.Sub(now)}, nil
+	}
+
+	ll.tats[key] = newTAT
+
+	return Result{
+		Allowed:    true,
+		Limit:      limit.burst(),
+		Remaining:  int(diff / emissionInterval),
+		ResetAfter: newTAT.Sub(now),
+	}, nil
+}
+
+// prune periodically removes keys that have fully reset so that memory does not grow with every client ever seen.
+func (ll *LocalLimiter) prune(now time.Time) {
+	ll.requests++
+	if ll.requests < pruneInterval {
+		return
+	}
+	ll.requests = 0
+
+	for key, tat := range ll.tats {
+		if !tat.After(now) {
+			delete(ll.tats, key)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package ratelimit_test
+
+import (
+	"context"
+	"github.com/jaredpetersen/go-rest-template/internal/ratelimit"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildLocalLimiter creates a local limiter with a clock that only moves when the returned function is called
+func buildLocalLimiter() (*ratelimit.LocalLimiter, func(time.Duration)) {
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	limiter := ratelimit.NewLocalLimiter()
+	limiter.Now = func() time.Time { return now }
+
+	return limiter, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestLocalLimiterAllowBurst(t *testing.T) {
+	ctx := context.Background()
+	limiter, _ := buildLocalLimiter()
+	limit := ratelimit.Limit{Rate: 1, Period: time.Second, Burst: 3}
+
+	for i := 0; i < 3; i++ {
+		res, err := limiter.Allow(ctx, "key", limit)
+		require.NoError(t, err, "Returned error")
+		assert.True(t, res.Allowed, "Request %d was not allowed", i)
+		assert.Equal(t, 3, res.Limit)
+		assert.Equal(t, 2-i, res.Remaining)
+		assert.Equal(t, time.Duration(0), res.RetryAfter)
+		assert.Equal(t, time.Duration(i+1)*time.Second, res.ResetAfter)
+	}
+
+	res, err := limiter.Allow(ctx, "key", limit)
+	require.NoError(t, err, "Returned error")
+	assert.False(t, res.Allowed, "Request was allowed")
+	assert.Equal(t, 0, res.Remaining)
+	assert.Equal(t, time.Second, res.RetryAfter)
+	assert.Equal(t, 3*time.Second, res.ResetAfter)
+}
+
+func TestLocalLimiterAllowRefills(t *testing.T) {
+	ctx := context.Background()
+	limiter, advance := buildLocalLimiter()
+	limit := ratelimit.PerSecond(2)
+
+	for i := 0; i < 2; i++ {
+		res, _ := limiter.Allow(ctx, "key", limit)
+		assert.True(t, res.Allowed, "Request %d was not allowed", i)
+	}
+
+	res, _ := limiter.Allow(ctx, "key", limit)
+	assert.False(t, res.Allowed, "Request was allowed")
+	assert.Equal(t, 500*time.Millisecond, res.RetryAfter)
+
+	// A single request is permitted again after the emission interval
+	advance(500 * time.Millisecond)
+
+	res, _ = limiter.Allow(ctx, "key", limit)
+	assert.True(t, res.Allowed, "Request was not allowed")
+	assert.Equal(t, 0, res.Remaining)
+
+	// The full burst is permitted again after the reset
+	advance(res.ResetAfter)
+
+	res, _ = limiter.Allow(ctx, "key", limit)
+	assert.True(t, res.Allowed, "Request was not allowed")
+	assert.Equal(t, 1, res.Remaining)
+}
+
+func TestLocalLimiterAllowSeparateKeys(t *testing.T) {
+	ctx := context.Background()
+	limiter, _ := buildLocalLimiter()
+	limit := ratelimit.PerMinute(1)
+
+	res, _ := limiter.Allow(ctx, "key-1", limit)
+	assert.True(t, res.Allowed, "Request was not allowed")
+
+	res, _ = limiter.Allow(ctx, "key-1", limit)
+	assert.False(t, res.Allowed, "Request was allowed")
+
+	res, _ = limiter.Allow(ctx, "key-2", limit)
+	assert.True(t, res.Allowed, "Request for another key was not allowed")
+}
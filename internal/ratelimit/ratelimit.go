@@ -0,0 +1,95 @@
+// Package ratelimit limits how often clients may make requests using the generic cell rate algorithm (GCRA).
+//
+// GCRA is equivalent to a token bucket that refills continuously but only needs to store a single timestamp per key,
+// the theoretical arrival time (TAT) of the next request.
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Limit is the rate that requests are permitted at.
+type Limit struct {
+	// Rate is the number of requests permitted per period.
+	Rate int
+	// Period is the amount of time that the rate applies to.
+	Period time.Duration
+	// Burst is the number of requests that may be made at once. Defaults to the rate.
+	Burst int
+}
+
+// PerSecond creates a limit that permits the number of requests each second, with bursts up to that number.
+func PerSecond(rate int) Limit {
+	return Limit{Rate: rate, Period: time.Second}
+}
+
+// PerMinute creates a limit that permits the number of requests each minute, with bursts up to that number.
+func PerMinute(rate int) Limit {
+	return Limit{Rate: rate, Period: time.Minute}
+}
+
+// emissionInterval is the amount of time that it takes for a single request to be permitted again.
+func (l Limit) emissionInterval() time.Duration {
+	return l.Period / time.Duration(l.Rate)
+}
+
+// burst is the number of requests that may be made at once.
+func (l Limit) burst() int {
+	if l.Burst <= 0 {
+		return l.Rate
+	}
+
+	return l.Burst
+}
+
+// Result is the outcome of a rate limited request.
+type Result struct {
+	// Allowed indicates whether or not the request is permitted.
+	Allowed bool
+	// Limit is the maximum number of requests that may be made at once.
+	Limit int
+	// Remaining is the number of requests that may be made immediately after this one.
+	Remaining int
+	// RetryAfter is the amount of time until the request would be permitted. Zero if the request was permitted.
+	RetryAfter time.Duration
+	// ResetAfter is the amount of time until the full burst is available again.
+	ResetAfter time.Duration
+}
+
+// Limiter decides whether or not requests are permitted.
+type Limiter interface {
+	// Allow records a request for the key and decides whether or not it is permitted by the limit.
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// FallbackLimiter is a limiter that uses a fallback limiter when its primary limiter fails, e.g. when a shared store is
+// unavailable.
+type FallbackLimiter struct {
+	Primary  Limiter
+	Fallback Limiter
+	// Timeout bounds how long the primary limiter may take so that an unresponsive store does not stall every request.
+	// Not bounded if zero.
+	Timeout time.Duration
+}
+
+// Allow records a request for the key using the primary limiter, falling back to the fallback limiter if the primary
+// limiter fails.
+func (fl FallbackLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	primaryCtx := ctx
+	if fl.Timeout > 0 {
+		var cancel context.CancelFunc
+		primaryCtx, cancel = context.WithTimeout(ctx, fl.Timeout)
+		defer cancel()
+	}
+
+	res, err := fl.Primary.Allow(primaryCtx, key, limit)
+	if err == nil {
+		return res, nil
+	}
+
+	log.Warn().Err(err).Str("key", key).Msg("Primary rate limiter failed, using fallback")
+	return fl.Fallback.Allow(ctx, key, limit)
+}
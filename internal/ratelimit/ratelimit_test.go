@@ -0,0 +1,76 @@
+package ratelimit_test
+
+import (
+	"context"
+	"errors"
+	"github.com/jaredpetersen/go-rest-template/internal/ratelimit"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	redismock "github.com/jaredpetersen/go-rest-template/internal/redis/mocks"
+)
+
+func TestFallbackLimiterAllow(t *testing.T) {
+	ctx := context.Background()
+
+	rdb := redismock.Client{}
+	rdb.On("Eval", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return([]interface{}{int64(0), int64(0), int64(1000000), int64(1000000)}, nil)
+
+	limiter := ratelimit.FallbackLimiter{
+		Primary:  ratelimit.RedisLimiter{Redis: &rdb},
+		Fallback: ratelimit.NewLocalLimiter(),
+	}
+
+	// Primary decision is used even though the fallback would have allowed the request
+	res, err := limiter.Allow(ctx, "key", ratelimit.PerSecond(1))
+	assert.NoError(t, err, "Returned error")
+	assert.False(t, res.Allowed, "Request was allowed")
+}
+
+func TestFallbackLimiterAllowPrimaryError(t *testing.T) {
+	ctx := context.Background()
+
+	rdb := redismock.Client{}
+	rdb.On("Eval", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("Failed"))
+
+	limiter := ratelimit.FallbackLimiter{
+		Primary:  ratelimit.RedisLimiter{Redis: &rdb},
+		Fallback: ratelimit.NewLocalLimiter(),
+	}
+
+	res, err := limiter.Allow(ctx, "key", ratelimit.PerSecond(1))
+	assert.NoError(t, err, "Returned error")
+	assert.True(t, res.Allowed, "Request was not allowed")
+
+	res, err = limiter.Allow(ctx, "key", ratelimit.PerSecond(1))
+	assert.NoError(t, err, "Returned error")
+	assert.False(t, res.Allowed, "Fallback limiter did not limit request")
+}
+
+func TestFallbackLimiterAllowPrimaryTimeout(t *testing.T) {
+	ctx := context.Background()
+
+	// Primary limiter blocks until its context is done, like an unresponsive Redis
+	rdb := redismock.Client{}
+	rdb.On("Eval", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(func(ctx context.Context, script string, keys []string, args ...interface{}) interface{} {
+			<-ctx.Done()
+			return nil
+		}, func(ctx context.Context, script string, keys []string, args ...interface{}) error {
+			return ctx.Err()
+		})
+
+	limiter := ratelimit.FallbackLimiter{
+		Primary:  ratelimit.RedisLimiter{Redis: &rdb},
+		Fallback: ratelimit.NewLocalLimiter(),
+		Timeout:  10 * time.Millisecond,
+	}
+
+	res, err := limiter.Allow(ctx, "key", ratelimit.PerSecond(1))
+	assert.NoError(t, err, "Returned error")
+	assert.True(t, res.Allowed, "Request was not allowed")
+}
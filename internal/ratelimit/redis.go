@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jaredpetersen/go-rest-template/internal/redis"
+)
+
+// gcraScript implements GCRA atomically so that every replica shares the same limits.
+//
+// Redis' clock is used rather than the caller's so that clock skew between replicas does not affect the limit. Times
+// are in microseconds and stored as integer strings to avoid losing precision to Lua's number formatting.
+//
+// KEYS[1] - key to store the theoretical arrival time in
+// ARGV[1] - emission interval in microseconds
+// ARGV[2] - burst
+//
+// Returns {allowed, remaining, retry after in microseconds, reset after in microseconds}
+const gcraScript = `
+redis.replicate_commands()
+
+local emission_interval = tonumber(ARGV[1])
+local burst_offset = emission_interval * tonumber(ARGV[2])
+
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000000 + tonumber(time[2])
+
+local tat = tonumber(redis.call('GET', KEYS[1]))
+if not tat or tat < now then
+  tat = now
+end
+
+local new_tat = tat + emission_interval
+local diff = now - (new_tat - burst_offset)
+if diff < 0 then
+  return {0, 0, -diff, tat - now}
+end
+
+redis.call('SET', KEYS[1], string.format('%.0f', new_tat), 'PX', math.ceil((new_tat - now) / 1000))
+return {1, math.floor(diff / emission_interval), 0, new_tat - now}
+`
+
+// RedisLimiter is a limiter that is shared by every replica of the service through Redis.
+type RedisLimiter struct {
+	Redis redis.Client
+}
+
+// Allow records a request for the key and decides whether or not it is permitted by the limit.
+func (rl RedisLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	raw, err := rl.Redis.Eval(ctx, gcraScript, []string{key}, limit.emissionInterval().Microseconds(), limit.burst())
+	if err != nil {
+		return Result{}, err
+	}
+
+	values, ok := raw.([]interface{})
+	if !ok || len(values) != 4 {
+		return Result{}, fmt.Errorf("unexpected rate limit script result %v", raw)
+	}
+
+	var ints [4]int64
+	for i, value := range values {
+		ints[i], ok = value.(int64)
+		if !ok {
+			return Result{}, fmt.Errorf("unexpected rate limit script result %v", raw)
+		}
+	}
+
+	return Result{
+		Allowed:    ints[0] == 1,
+		Limit:      limit.burst(),
+		Remaining:  int(ints[1]),
+		RetryAfter: time.Duration(ints[2]) * time.Microsecond,
+		ResetAfter: time.Duration(ints[3]) * time.Microsecond,
+	}, nil
+}
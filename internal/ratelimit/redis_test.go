@@ -0,0 +1,174 @@
+package ratelimit_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jaredpetersen/go-rest-template/internal/ratelimit"
+	"testing"
+	"time"
+
+	"github.com/jaredpetersen/go-rest-template/internal/redis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	redismock "github.com/jaredpetersen/go-rest-template/internal/redis/mocks"
+)
+
+type redisContainer struct {
+	testcontainers.Container
+	URI string
+}
+
+// setupRedis starts up a Redis container
+//
+// Returned Redis container must be explicitly terminated
+func setupRedis(ctx context.Context) (*redisContainer, error) {
+	req := testcontainers.ContainerRequest{
+		Image:        "redis:6",
+		ExposedPorts: []string{"6379/tcp"},
+		WaitingFor:   wait.ForLog("* Ready to accept connections"),
+		SkipReaper:   true,
+	}
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	mappedPort, err := container.MappedPort(ctx, "6379")
+	if err != nil {
+		return nil, err
+	}
+
+	hostIP, err := container.Host(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	uri := fmt.Sprintf("redis://%s:%s", hostIP, mappedPort.Port())
+
+	return &redisContainer{Container: container, URI: uri}, nil
+}
+
+func TestRedisLimiterAllow(t *testing.T) {
+	ctx := context.Background()
+
+	rdb := redismock.Client{}
+	rdb.On("Eval", mock.Anything, mock.AnythingOfType("string"), []string{"key"}, int64(100000), 20).
+		Return([]interface{}{int64(1), int64(19), int64(0), int64(100000)}, nil)
+
+	limiter := ratelimit.RedisLimiter{Redis: &rdb}
+
+	res, err := limiter.Allow(ctx, "key", ratelimit.Limit{Rate: 10, Period: time.Second, Burst: 20})
+	assert.NoError(t, err, "Returned error")
+	assert.Equal(t, ratelimit.Result{
+		Allowed:    true,
+		Limit:      20,
+		Remaining:  19,
+		RetryAfter: 0,
+		ResetAfter: 100 * time.Millisecond,
+	}, res)
+
+	rdb.AssertExpectations(t)
+}
+
+func TestRedisLimiterAllowDenied(t *testing.T) {
+	ctx := context.Background()
+
+	rdb := redismock.Client{}
+	rdb.On("Eval", mock.Anything, mock.AnythingOfType("string"), []string{"key"}, int64(1000000), 1).
+		Return([]interface{}{int64(0), int64(0), int64(250000), int64(250000)}, nil)
+
+	limiter := ratelimit.RedisLimiter{Redis: &rdb}
+
+	res, err := limiter.Allow(ctx, "key", ratelimit.PerSecond(1))
+	assert.NoError(t, err, "Returned error")
+	assert.Equal(t, ratelimit.Result{
+		Allowed:    false,
+		Limit:      1,
+		Remaining:  0,
+		RetryAfter: 250 * time.Millisecond,
+		ResetAfter: 250 * time.Millisecond,
+	}, res)
+}
+
+func TestRedisLimiterAllowReturnsRedisError(t *testing.T) {
+	ctx := context.Background()
+
+	expectedErr := errors.New("Failed")
+
+	rdb := redismock.Client{}
+	rdb.On("Eval", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, expectedErr)
+
+	limiter := ratelimit.RedisLimiter{Redis: &rdb}
+
+	_, err := limiter.Allow(ctx, "key", ratelimit.PerSecond(1))
+	assert.EqualError(t, err, expectedErr.Error(), "Did not return error")
+}
+
+func TestRedisLimiterAllowUnexpectedResult(t *testing.T) {
+	ctx := context.Background()
+
+	var tests = []interface{}{
+		"OK",
+		[]interface{}{int64(1), int64(0)},
+		[]interface{}{int64(1), "0", int64(0), int64(0)},
+	}
+
+	for _, tt := range tests {
+		rdb := redismock.Client{}
+		rdb.On("Eval", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(tt, nil)
+
+		limiter := ratelimit.RedisLimiter{Redis: &rdb}
+
+		_, err := limiter.Allow(ctx, "key", ratelimit.PerSecond(1))
+		assert.Error(t, err, "Did not return error for %v", tt)
+	}
+}
+
+func TestIntegrationRedisLimiterAllow(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+	redisContainer, err := setupRedis(ctx)
+	require.NoError(t, err, "Failed to start up Redis container")
+	defer redisContainer.Terminate(ctx)
+
+	config := redis.Config{URI: redisContainer.URI}
+	rdb, err := redis.New(config)
+	require.NoError(t, err, "Client instantiation error")
+	defer rdb.Close()
+
+	limiter := ratelimit.RedisLimiter{Redis: rdb}
+	limit := ratelimit.Limit{Rate: 1, Period: time.Minute, Burst: 3}
+
+	for i := 0; i < 3; i++ {
+		res, err := limiter.Allow(ctx, "key", limit)
+		require.NoError(t, err, "Returned error")
+		assert.True(t, res.Allowed, "Request %d was not allowed", i)
+		assert.Equal(t, 2-i, res.Remaining)
+	}
+
+	res, err := limiter.Allow(ctx, "key", limit)
+	require.NoError(t, err, "Returned error")
+	assert.False(t, res.Allowed, "Request was allowed")
+	assert.InDelta(t, time.Minute, res.RetryAfter, float64(time.Second))
+	assert.InDelta(t, 3*time.Minute, res.ResetAfter, float64(time.Second))
+
+	// Key expires once the full burst is available again
+	ttl, err := rdb.TTL(ctx, "key")
+	require.NoError(t, err, "TTL error")
+	assert.InDelta(t, 3*time.Minute, ttl, float64(time.Second))
+
+	res, err = limiter.Allow(ctx, "other-key", limit)
+	require.NoError(t, err, "Returned error")
+	assert.True(t, res.Allowed, "Request for another key was not allowed")
+}
@@ -0,0 +1,344 @@
+// Package recurrence parses and expands RFC 5545 recurrence rules.
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency is how often a rule repeats.
+type Frequency int
+
+// Frequencies supported by rules. Rules that repeat more often than daily are not supported.
+const (
+	Daily Frequency = iota + 1
+	Weekly
+	Monthly
+	Yearly
+)
+
+var frequencyNames = map[Frequency]string{Daily: "DAILY", Weekly: "WEEKLY", Monthly: "MONTHLY", Yearly: "YEARLY"}
+
+// String returns the name of the frequency as it appears in a rule.
+func (f Frequency) String() string {
+	return frequencyNames[f]
+}
+
+var weekdayNames = map[time.Weekday]string{
+	time.Sunday:    "SU",
+	time.Monday:    "MO",
+	time.Tuesday:   "TU",
+	time.Wednesday: "WE",
+	time.Thursday:  "TH",
+	time.Friday:    "FR",
+	time.Saturday:  "SA",
+}
+
+// WeekdayNum is a day of the week, optionally limited to a single occurrence of that day within a month or year.
+type WeekdayNum struct {
+	Weekday time.Weekday
+	// N is the occurrence of the day within the month or year, such as 1 for the first Monday or -1 for the last
+	// Monday. Zero for every occurrence of the day.
+	N int
+}
+
+// String returns the day as it appears in a rule, such as "MO" or "-1FR".
+func (wn WeekdayNum) String() string {
+	if wn.N == 0 {
+		return weekdayNames[wn.Weekday]
+	}
+
+	return strconv.Itoa(wn.N) + weekdayNames[wn.Weekday]
+}
+
+// Rule is an RFC 5545 recurrence rule. Only rules that repeat daily or less often are supported, and the BYSETPOS,
+// BYWEEKNO, BYYEARDAY, BYHOUR, BYMINUTE, and BYSECOND parts are not supported.
+type Rule struct {
+	Freq Frequency
+	// Interval is the number of periods between repetitions, such as 2 for every other week. Defaults to 1.
+	Interval int
+	// Count is the number of occurrences that the rule is limited to. Zero if the rule is not limited by count.
+	Count int
+	// Until is the last moment that an occurrence may happen at. Zero if the rule is not limited by date.
+	Until time.Time
+	// UntilLocal indicates that Until is a date or local time without a time zone. The wall clock of Until is then
+	// interpreted in the time zone of the recurrence instead of as UTC.
+	UntilLocal bool
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByMonth    []time.Month
+	// WeekStart is the day that weeks start on, which matters for weekly rules with an interval. Defaults to Monday.
+	WeekStart time.Weekday
+}
+
+// ErrInvalidRule indicates that a recurrence rule could not be parsed. Errors returned by Parse wrap it.
+var ErrInvalidRule = errors.New("invalid recurrence rule")
+
+const (
+	untilDateLayout      = "20060102"
+	untilLocalTimeLayout = "20060102T150405"
+	untilUTCLayout       = "20060102T150405Z"
+)
+
+// Parse parses an RFC 5545 recurrence rule such as "FREQ=WEEKLY;BYDAY=MO,WE". The value may be prefixed with "RRULE:".
+// Names and values are case-insensitive.
+func Parse(s string) (*Rule, error) {
+	s = strings.TrimSpace(s)
+	if len(s) >= 6 && strings.EqualFold(s[:6], "RRULE:") {
+		s = s[6:]
+	}
+	if s == "" {
+		return nil, invalid("rule is empty")
+	}
+
+	r := Rule{Interval: 1, WeekStart: time.Monday}
+	seen := make(map[string]bool)
+
+	for _, part := range strings.Split(s, ";") {
+		pair := strings.SplitN(part, "=", 2)
+		if len(pair) != 2 {
+			return nil, invalid("part %q must be formatted as NAME=VALUE", part)
+		}
+
+		name := strings.ToUpper(strings.TrimSpace(pair[0]))
+		value := strings.ToUpper(strings.TrimSpace(pair[1]))
+		if name == "" || value == "" {
+			return nil, invalid("part %q must be formatted as NAME=VALUE", part)
+		}
+		if seen[name] {
+			return nil, invalid("part %s must not be repeated", name)
+		}
+		seen[name] = true
+
+		var err error
+		switch name {
+		case "FREQ":
+			r.Freq, err = parseFrequency(value)
+		case "INTERVAL":
+			r.Interval, err = parsePositive(name, value)
+		case "COUNT":
+			r.Count, err = parsePositive(name, value)
+		case "UNTIL":
+			r.Until, r.UntilLocal, err = parseUntil(value)
+		case "BYDAY":
+			r.ByDay, err = parseByDay(value)
+		case "BYMONTHDAY":
+			r.ByMonthDay, err = parseByMonthDay(value)
+		case "BYMONTH":
+			r.ByMonth, err = parseByMonth(value)
+		case "WKST":
+			r.WeekStart, err = parseWeekday(value)
+		case "BYSETPOS", "BYWEEKNO", "BYYEARDAY", "BYHOUR", "BYMINUTE", "BYSECOND":
+			err = invalid("part %s is not supported", name)
+		default:
+			err = invalid("part %s is not recognized", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := r.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+// validate checks the combination of parts, which cannot be checked while parsing them one by one.
+func (r Rule) validate() error {
+	if r.Freq == 0 {
+		return invalid("part FREQ is required")
+	}
+
+	if r.Count > 0 && !r.Until.IsZero() {
+		return invalid("parts COUNT and UNTIL must not both be set")
+	}
+
+	if r.Freq == Weekly && len(r.ByMonthDay) > 0 {
+		return invalid("part BYMONTHDAY must not be set for weekly rules")
+	}
+
+	for _, wn := range r.ByDay {
+		if wn.N == 0 {
+			continue
+		}
+		if r.Freq != Monthly && r.Freq != Yearly {
+			return invalid("part BYDAY must only number days for monthly or yearly rules")
+		}
+		if (r.Freq == Monthly || len(r.ByMonth) > 0) && (wn.N > 5 || wn.N < -5) {
+			return invalid("part BYDAY must not number days beyond the fifth in a month")
+		}
+	}
+
+	return nil
+}
+
+// String returns the rule in its canonical form, without the "RRULE:" prefix.
+func (r Rule) String() string {
+	parts := []string{"FREQ=" + r.Freq.String()}
+
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+
+	switch {
+	case r.Until.IsZero():
+	case !r.UntilLocal:
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format(untilUTCLayout))
+	case r.Until.Hour() == 23 && r.Until.Minute() == 59 && r.Until.Second() == 59:
+		parts = append(parts, "UNTIL="+r.Until.Format(untilDateLayout))
+	default:
+		parts = append(parts, "UNTIL="+r.Until.Format(untilLocalTimeLayout))
+	}
+
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, wn := range r.ByDay {
+			days[i] = wn.String()
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, day := range r.ByMonthDay {
+			days[i] = strconv.Itoa(day)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+
+	if len(r.ByMonth) > 0 {
+		months := make([]string, len(r.ByMonth))
+		for i, month := range r.ByMonth {
+			months[i] = strconv.Itoa(int(month))
+		}
+		parts = append(parts, "BYMONTH="+strings.Join(months, ","))
+	}
+
+	if r.WeekStart != time.Monday {
+		parts = append(parts, "WKST="+weekdayNames[r.WeekStart])
+	}
+
+	return strings.Join(parts, ";")
+}
+
+func parseFrequency(value string) (Frequency, error) {
+	for f, name := range frequencyNames {
+		if name == value {
+			return f, nil
+		}
+	}
+
+	switch value {
+	case "HOURLY", "MINUTELY", "SECONDLY":
+		return 0, invalid("frequency %s is not supported", value)
+	default:
+		return 0, invalid("frequency %s is not recognized", value)
+	}
+}
+
+func parsePositive(name string, value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, invalid("part %s must be a positive integer", name)
+	}
+
+	return n, nil
+}
+
+// parseUntil parses the end of a rule. Dates include the whole day.
+func parseUntil(value string) (time.Time, bool, error) {
+	if t, err := time.Parse(untilUTCLayout, value); err == nil {
+		return t, false, nil
+	}
+
+	if t, err := time.Parse(untilLocalTimeLayout, value); err == nil {
+		return t, true, nil
+	}
+
+	if t, err := time.Parse(untilDateLayout, value); err == nil {
+		return t.Add(24*time.Hour - time.Second), true, nil
+	}
+
+	return time.Time{}, false, invalid("part UNTIL must be a date or date-time such as 20060102T150405Z")
+}
+
+func parseByDay(value string) ([]WeekdayNum, error) {
+	var days []WeekdayNum
+	for _, item := range strings.Split(value, ",") {
+		if len(item) < 2 {
+			return nil, invalid("part BYDAY has invalid day %q", item)
+		}
+
+		weekday, err := parseWeekday(item[len(item)-2:])
+		if err != nil {
+			return nil, invalid("part BYDAY has invalid day %q", item)
+		}
+
+		wn := WeekdayNum{Weekday: weekday}
+		if ordinal := item[:len(item)-2]; ordinal != "" {
+			wn.N, err = strconv.Atoi(ordinal)
+			if err != nil || wn.N == 0 || wn.N > 53 || wn.N < -53 {
+				return nil, invalid("part BYDAY has invalid day %q", item)
+			}
+		}
+
+		days = append(days, wn)
+	}
+
+	return days, nil
+}
+
+func parseByMonthDay(value string) ([]int, error) {
+	var days []int
+	for _, item := range strings.Split(value, ",") {
+		day, err := strconv.Atoi(item)
+		if err != nil || day == 0 || day > 31 || day < -31 {
+			return nil, invalid("part BYMONTHDAY has invalid day %q", item)
+		}
+
+		days = append(days, day)
+	}
+
+	sort.Ints(days)
+	return days, nil
+}
+
+func parseByMonth(value string) ([]time.Month, error) {
+	var months []time.Month
+	for _, item := range strings.Split(value, ",") {
+		month, err := strconv.Atoi(item)
+		if err != nil || month < 1 || month > 12 {
+			return nil, invalid("part BYMONTH has invalid month %q", item)
+		}
+
+		months = append(months, time.Month(month))
+	}
+
+	sort.Slice(months, func(i, j int) bool { return months[i] < months[j] })
+	return months, nil
+}
+
+func parseWeekday(value string) (time.Weekday, error) {
+	for weekday, name := range weekdayNames {
+		if name == value {
+			return weekday, nil
+		}
+	}
+
+	return 0, invalid("weekday %s is not recognized", value)
+}
+
+func invalid(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidRule, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,189 @@
+package recurrence_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jaredpetersen/go-rest-template/internal/recurrence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name     string
+		rule     string
+		expected recurrence.Rule
+	}{
+		{
+			name:     "daily",
+			rule:     "FREQ=DAILY",
+			expected: recurrence.Rule{Freq: recurrence.Daily, Interval: 1, WeekStart: time.Monday},
+		},
+		{
+			name: "prefix and lowercase",
+			rule: "rrule:freq=weekly;byday=mo,we",
+			expected: recurrence.Rule{
+				Freq:      recurrence.Weekly,
+				Interval:  1,
+				ByDay:     []recurrence.WeekdayNum{{Weekday: time.Monday}, {Weekday: time.Wednesday}},
+				WeekStart: time.Monday,
+			},
+		},
+		{
+			name: "interval and count",
+			rule: "FREQ=WEEKLY;INTERVAL=2;COUNT=10;WKST=SU",
+			expected: recurrence.Rule{
+				Freq:      recurrence.Weekly,
+				Interval:  2,
+				Count:     10,
+				WeekStart: time.Sunday,
+			},
+		},
+		{
+			name: "until UTC",
+			rule: "FREQ=DAILY;UNTIL=20261231T235959Z",
+			expected: recurrence.Rule{
+				Freq:      recurrence.Daily,
+				Interval:  1,
+				Until:     time.Date(2026, time.December, 31, 23, 59, 59, 0, time.UTC),
+				WeekStart: time.Monday,
+			},
+		},
+		{
+			name: "until local time",
+			rule: "FREQ=DAILY;UNTIL=20261231T090000",
+			expected: recurrence.Rule{
+				Freq:       recurrence.Daily,
+				Interval:   1,
+				Until:      time.Date(2026, time.December, 31, 9, 0, 0, 0, time.UTC),
+				UntilLocal: true,
+				WeekStart:  time.Monday,
+			},
+		},
+		{
+			name: "until date includes the whole day",
+			rule: "FREQ=DAILY;UNTIL=20261231",
+			expected: recurrence.Rule{
+				Freq:       recurrence.Daily,
+				Interval:   1,
+				Until:      time.Date(2026, time.December, 31, 23, 59, 59, 0, time.UTC),
+				UntilLocal: true,
+				WeekStart:  time.Monday,
+			},
+		},
+		{
+			name: "numbered days",
+			rule: "FREQ=MONTHLY;BYDAY=1MO,-1FR,+2TU",
+			expected: recurrence.Rule{
+				Freq:     recurrence.Monthly,
+				Interval: 1,
+				ByDay: []recurrence.WeekdayNum{
+					{Weekday: time.Monday, N: 1},
+					{Weekday: time.Friday, N: -1},
+					{Weekday: time.Tuesday, N: 2},
+				},
+				WeekStart: time.Monday,
+			},
+		},
+		{
+			name: "month days and months are sorted",
+			rule: "FREQ=YEARLY;BYMONTHDAY=15,-1,1;BYMONTH=12,6",
+			expected: recurrence.Rule{
+				Freq:       recurrence.Yearly,
+				Interval:   1,
+				ByMonthDay: []int{-1, 1, 15},
+				ByMonth:    []time.Month{time.June, time.December},
+				WeekStart:  time.Monday,
+			},
+		},
+		{
+			name: "numbered days within the year",
+			rule: "FREQ=YEARLY;BYDAY=20MO",
+			expected: recurrence.Rule{
+				Freq:      recurrence.Yearly,
+				Interval:  1,
+				ByDay:     []recurrence.WeekdayNum{{Weekday: time.Monday, N: 20}},
+				WeekStart: time.Monday,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := recurrence.Parse(tc.rule)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, *r)
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	testCases := []struct {
+		name string
+		rule string
+	}{
+		{name: "empty", rule: ""},
+		{name: "prefix only", rule: "RRULE:"},
+		{name: "missing frequency", rule: "INTERVAL=2"},
+		{name: "unknown frequency", rule: "FREQ=FORTNIGHTLY"},
+		{name: "hourly", rule: "FREQ=HOURLY"},
+		{name: "missing value", rule: "FREQ="},
+		{name: "missing equals", rule: "FREQ=DAILY;COUNT"},
+		{name: "repeated part", rule: "FREQ=DAILY;FREQ=WEEKLY"},
+		{name: "zero interval", rule: "FREQ=DAILY;INTERVAL=0"},
+		{name: "negative count", rule: "FREQ=DAILY;COUNT=-1"},
+		{name: "count and until", rule: "FREQ=DAILY;COUNT=2;UNTIL=20261231T000000Z"},
+		{name: "malformed until", rule: "FREQ=DAILY;UNTIL=2026-12-31"},
+		{name: "unknown weekday", rule: "FREQ=WEEKLY;BYDAY=XX"},
+		{name: "zero numbered weekday", rule: "FREQ=MONTHLY;BYDAY=0MO"},
+		{name: "numbered weekday beyond year", rule: "FREQ=YEARLY;BYDAY=54MO"},
+		{name: "numbered weekday beyond month", rule: "FREQ=MONTHLY;BYDAY=6MO"},
+		{name: "numbered weekday beyond limited month", rule: "FREQ=YEARLY;BYMONTH=1;BYDAY=-6MO"},
+		{name: "numbered weekday for weekly rule", rule: "FREQ=WEEKLY;BYDAY=1MO"},
+		{name: "numbered weekday for daily rule", rule: "FREQ=DAILY;BYDAY=1MO"},
+		{name: "zero month day", rule: "FREQ=MONTHLY;BYMONTHDAY=0"},
+		{name: "month day beyond month", rule: "FREQ=MONTHLY;BYMONTHDAY=32"},
+		{name: "month day for weekly rule", rule: "FREQ=WEEKLY;BYMONTHDAY=1"},
+		{name: "month beyond year", rule: "FREQ=YEARLY;BYMONTH=13"},
+		{name: "unsupported part", rule: "FREQ=MONTHLY;BYSETPOS=-1"},
+		{name: "unknown part", rule: "FREQ=DAILY;X-NAME=1"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := recurrence.Parse(tc.rule)
+			assert.Nil(t, r)
+			assert.ErrorIs(t, err, recurrence.ErrInvalidRule)
+		})
+	}
+}
+
+func TestRuleString(t *testing.T) {
+	testCases := []struct {
+		rule     string
+		expected string
+	}{
+		{rule: "FREQ=DAILY", expected: "FREQ=DAILY"},
+		{rule: "rrule:freq=daily;interval=1", expected: "FREQ=DAILY"},
+		{rule: "FREQ=WEEKLY;WKST=SU;BYDAY=MO,FR;INTERVAL=2", expected: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR;WKST=SU"},
+		{rule: "FREQ=MONTHLY;BYDAY=+1MO,-1FR;COUNT=3", expected: "FREQ=MONTHLY;COUNT=3;BYDAY=1MO,-1FR"},
+		{rule: "FREQ=YEARLY;BYMONTH=3,1;BYMONTHDAY=-1", expected: "FREQ=YEARLY;BYMONTHDAY=-1;BYMONTH=1,3"},
+		{rule: "FREQ=DAILY;UNTIL=20261231T090000Z", expected: "FREQ=DAILY;UNTIL=20261231T090000Z"},
+		{rule: "FREQ=DAILY;UNTIL=20261231T090000", expected: "FREQ=DAILY;UNTIL=20261231T090000"},
+		{rule: "FREQ=DAILY;UNTIL=20261231", expected: "FREQ=DAILY;UNTIL=20261231"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.rule, func(t *testing.T) {
+			r, err := recurrence.Parse(tc.rule)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, r.String())
+
+			// The canonical form parses back to the same rule
+			reparsed, err := recurrence.Parse(r.String())
+			require.NoError(t, err)
+			assert.Equal(t, r, reparsed)
+		})
+	}
+}
@@ -0,0 +1,256 @@
+package recurrence
+
+import (
+	"time"
+)
+
+// horizon is how far past the start a set is expanded before giving up on finding another occurrence. Rules that can
+// never match, such as every February 30th, would otherwise be expanded forever.
+const horizon = 200
+
+// Set is an RFC 5545 recurrence set: the occurrences of a rule, starting from a date-time, minus any excluded
+// date-times.
+//
+// Occurrences happen at the same wall clock time as the start in the start's location, so they follow daylight saving
+// time transitions. Occurrences that would fall in a gap where the clocks spring forward are moved forward by the
+// length of the gap, and occurrences that would happen twice where the clocks fall back happen at the first of the
+// two, as required by RFC 5545.
+type Set struct {
+	Rule Rule
+	// Start is the first occurrence, also known as DTSTART. Its location is the time zone that the rule is expanded in.
+	// The start is only an occurrence if it matches the rule.
+	Start time.Time
+	// ExDates are occurrences to skip, also known as EXDATE. Skipped occurrences still count towards the rule's count.
+	ExDates []time.Time
+}
+
+// After returns the first occurrence strictly after a moment. False is returned if there are no more occurrences.
+func (s Set) After(t time.Time) (time.Time, bool) {
+	var next time.Time
+	found := false
+
+	s.each(func(occurrence time.Time) bool {
+		if !occurrence.After(t) {
+			return true
+		}
+
+		next = occurrence
+		found = true
+		return false
+	})
+
+	return next, found
+}
+
+// Occurrences returns up to limit occurrences at or after a moment, earliest first.
+func (s Set) Occurrences(from time.Time, limit int) []time.Time {
+	occurrences := []time.Time{}
+	if limit <= 0 {
+		return occurrences
+	}
+
+	s.each(func(occurrence time.Time) bool {
+		if occurrence.Before(from) {
+			return true
+		}
+
+		occurrences = append(occurrences, occurrence)
+		return len(occurrences) < limit
+	})
+
+	return occurrences
+}
+
+// each calls fn with every occurrence in order until fn returns false or the occurrences run out.
+func (s Set) each(fn func(time.Time) bool) {
+	r := s.Rule
+	if r.Interval < 1 {
+		r.Interval = 1
+	}
+
+	loc := s.Start.Location()
+	until := r.Until
+	if !until.IsZero() && r.UntilLocal {
+		until = localTime(until.Year(), until.Month(), until.Day(), until, loc)
+	}
+
+	startDate := dateOf(s.Start)
+	end := startDate.AddDate(horizon, 0, 0)
+	count := 0
+
+	for period := 0; ; period++ {
+		from, to := r.period(startDate, period)
+		if from.After(end) {
+			return
+		}
+
+		for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+			if !r.matches(day, s.Start) {
+				continue
+			}
+
+			occurrence := localTime(day.Year(), day.Month(), day.Day(), s.Start, loc)
+			if occurrence.Before(s.Start) {
+				continue
+			}
+			if !until.IsZero() && occurrence.After(until) {
+				return
+			}
+
+			count++
+			if !s.excluded(occurrence) && !fn(occurrence) {
+				return
+			}
+			if r.Count > 0 && count >= r.Count {
+				return
+			}
+		}
+	}
+}
+
+// excluded indicates whether or not an occurrence is one of the excluded date-times.
+func (s Set) excluded(occurrence time.Time) bool {
+	for _, exDate := range s.ExDates {
+		if exDate.Equal(occurrence) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// period returns the first and last day of one of the rule's periods, counting from the period containing the start.
+// Days are represented as midnight UTC.
+func (r Rule) period(start time.Time, n int) (time.Time, time.Time) {
+	switch r.Freq {
+	case Weekly:
+		offset := (int(start.Weekday()) - int(r.WeekStart) + 7) % 7
+		from := start.AddDate(0, 0, n*7*r.Interval-offset)
+		return from, from.AddDate(0, 0, 6)
+	case Monthly:
+		from := time.Date(start.Year(), start.Month()+time.Month(n*r.Interval), 1, 0, 0, 0, 0, time.UTC)
+		return from, from.AddDate(0, 1, -1)
+	case Yearly:
+		from := time.Date(start.Year()+n*r.Interval, time.January, 1, 0, 0, 0, 0, time.UTC)
+		return from, from.AddDate(1, 0, -1)
+	default:
+		day := start.AddDate(0, 0, n*r.Interval)
+		return day, day
+	}
+}
+
+// matches indicates whether or not a day within one of the rule's periods is an occurrence. Parts of the rule that are
+// not set fall back on the start, so that a monthly rule without any days repeats on the start's day of the month.
+func (r Rule) matches(day time.Time, start time.Time) bool {
+	if len(r.ByMonth) > 0 && !containsMonth(r.ByMonth, day.Month()) {
+		return false
+	}
+
+	if len(r.ByMonthDay) > 0 && !r.matchesMonthDay(day) {
+		return false
+	}
+
+	if len(r.ByDay) > 0 && !r.matchesDay(day) {
+		return false
+	}
+
+	byDate := len(r.ByDay) > 0 || len(r.ByMonthDay) > 0
+	switch r.Freq {
+	case Weekly:
+		return len(r.ByDay) > 0 || day.Weekday() == start.Weekday()
+	case Monthly:
+		return byDate || day.Day() == start.Day()
+	case Yearly:
+		if byDate {
+			return true
+		}
+		return day.Day() == start.Day() && (len(r.ByMonth) > 0 || day.Month() == start.Month())
+	default:
+		return true
+	}
+}
+
+// matchesMonthDay indicates whether or not the day is one of the rule's days of the month, where negative days count
+// back from the end of the month.
+func (r Rule) matchesMonthDay(day time.Time) bool {
+	daysInMonth := daysIn(day.Year(), day.Month())
+	for _, monthDay := range r.ByMonthDay {
+		if monthDay == day.Day() || (monthDay < 0 && daysInMonth+monthDay+1 == day.Day()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchesDay indicates whether or not the day is one of the rule's days of the week. Numbered days are counted within
+// the month for monthly rules and yearly rules limited to months, and within the year for other yearly rules.
+func (r Rule) matchesDay(day time.Time) bool {
+	scopeStart := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+	scopeEnd := scopeStart.AddDate(0, 1, -1)
+	if r.Freq == Yearly && len(r.ByMonth) == 0 {
+		scopeStart = time.Date(day.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+		scopeEnd = scopeStart.AddDate(1, 0, -1)
+	}
+
+	nth := int(day.Sub(scopeStart).Hours()/24)/7 + 1
+	nthLast := -(int(scopeEnd.Sub(day).Hours()/24)/7 + 1)
+
+	for _, wn := range r.ByDay {
+		if wn.Weekday == day.Weekday() && (wn.N == 0 || wn.N == nth || wn.N == nthLast) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// localTime finds the moment that a day has the wall clock time of the clock in a location.
+//
+// When the wall clock time does not exist because the clocks sprang forward, it is interpreted using the offset from
+// before the transition, which moves it forward by the length of the gap. When the wall clock time happens twice
+// because the clocks fell back, the first of the two is used.
+func localTime(year int, month time.Month, day int, clock time.Time, loc *time.Location) time.Time {
+	wall := time.Date(year, month, day, clock.Hour(), clock.Minute(), clock.Second(), clock.Nanosecond(), time.UTC)
+
+	// Transitions are far enough apart that only the offsets from a day on either side need to be considered
+	_, offsetBefore := wall.Add(-24 * time.Hour).In(loc).Zone()
+	_, offsetAfter := wall.Add(24 * time.Hour).In(loc).Zone()
+
+	var candidates []time.Time
+	for _, offset := range []int{offsetBefore, offsetAfter} {
+		candidate := wall.Add(-time.Duration(offset) * time.Second).In(loc)
+		if _, actual := candidate.Zone(); actual == offset {
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	switch {
+	case len(candidates) == 0:
+		return wall.Add(-time.Duration(offsetBefore) * time.Second).In(loc)
+	case len(candidates) == 2 && candidates[1].Before(candidates[0]):
+		return candidates[1]
+	default:
+		return candidates[0]
+	}
+}
+
+// dateOf returns the day of a moment in the moment's location, represented as midnight UTC.
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// daysIn returns the number of days in a month.
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func containsMonth(months []time.Month, month time.Month) bool {
+	for _, m := range months {
+		if m == month {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,534 @@
+package recurrence_test
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/jaredpetersen/go-rest-template/internal/recurrence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildSet creates a recurrence set from a rule that starts at a wall clock time in a time zone.
+func buildSet(t *testing.T, rule string, start string, timeZone string) recurrence.Set {
+	t.Helper()
+
+	r, err := recurrence.Parse(rule)
+	require.NoError(t, err, "Failed to parse rule")
+
+	loc, err := time.LoadLocation(timeZone)
+	require.NoError(t, err, "Failed to load time zone")
+
+	dtStart, err := time.ParseInLocation("2006-01-02T15:04:05", start, loc)
+	require.NoError(t, err, "Failed to parse start")
+
+	return recurrence.Set{Rule: *r, Start: dtStart}
+}
+
+// formatAll formats moments with their UTC offsets so that both the wall clock time and the instant are compared.
+func formatAll(ts []time.Time) []string {
+	formatted := make([]string, len(ts))
+	for i, t := range ts {
+		formatted[i] = t.Format(time.RFC3339)
+	}
+
+	return formatted
+}
+
+func TestSetOccurrencesAcrossDST(t *testing.T) {
+	testCases := []struct {
+		name     string
+		rule     string
+		start    string
+		timeZone string
+		expected []string
+	}{
+		{
+			name:     "keeps the wall clock time when the clocks spring forward",
+			rule:     "FREQ=DAILY;COUNT=4",
+			start:    "2026-03-07T09:00:00",
+			timeZone: "America/New_York",
+			expected: []string{
+				"2026-03-07T09:00:00-05:00",
+				"2026-03-08T09:00:00-04:00",
+				"2026-03-09T09:00:00-04:00",
+				"2026-03-10T09:00:00-04:00",
+			},
+		},
+		{
+			name:     "keeps the wall clock time when the clocks fall back",
+			rule:     "FREQ=DAILY;COUNT=3",
+			start:    "2026-10-31T09:00:00",
+			timeZone: "America/New_York",
+			expected: []string{
+				"2026-10-31T09:00:00-04:00",
+				"2026-11-01T09:00:00-05:00",
+				"2026-11-02T09:00:00-05:00",
+			},
+		},
+		{
+			name:     "moves times in the spring forward gap forward by the gap",
+			rule:     "FREQ=DAILY;COUNT=3",
+			start:    "2026-03-07T02:30:00",
+			timeZone: "America/New_York",
+			expected: []string{
+				"2026-03-07T02:30:00-05:00",
+				"2026-03-08T03:30:00-04:00",
+				"2026-03-09T02:30:00-04:00",
+			},
+		},
+		{
+			name:     "uses the first of repeated times when the clocks fall back",
+			rule:     "FREQ=DAILY;COUNT=3",
+			start:    "2026-10-31T01:30:00",
+			timeZone: "America/New_York",
+			expected: []string{
+				"2026-10-31T01:30:00-04:00",
+				"2026-11-01T01:30:00-04:00",
+				"2026-11-02T01:30:00-05:00",
+			},
+		},
+		{
+			name:     "moves times at the start of the gap",
+			rule:     "FREQ=WEEKLY;BYDAY=SU;COUNT=3",
+			start:    "2026-03-01T02:00:00",
+			timeZone: "America/New_York",
+			expected: []string{
+				"2026-03-01T02:00:00-05:00",
+				"2026-03-08T03:00:00-04:00",
+				"2026-03-15T02:00:00-04:00",
+			},
+		},
+		{
+			name:     "does not move times at the end of the gap",
+			rule:     "FREQ=DAILY;COUNT=2",
+			start:    "2026-03-07T03:00:00",
+			timeZone: "America/New_York",
+			expected: []string{
+				"2026-03-07T03:00:00-05:00",
+				"2026-03-08T03:00:00-04:00",
+			},
+		},
+		{
+			name:     "moves monthly occurrences in the gap",
+			rule:     "FREQ=MONTHLY;COUNT=3",
+			start:    "2026-02-08T02:30:00",
+			timeZone: "America/New_York",
+			expected: []string{
+				"2026-02-08T02:30:00-05:00",
+				"2026-03-08T03:30:00-04:00",
+				"2026-04-08T02:30:00-04:00",
+			},
+		},
+		{
+			name:     "moves yearly occurrences in the gap",
+			rule:     "FREQ=YEARLY;BYMONTH=3;BYDAY=2SU;COUNT=2",
+			start:    "2026-03-01T02:30:00",
+			timeZone: "America/New_York",
+			expected: []string{
+				"2026-03-08T03:30:00-04:00",
+				"2027-03-14T03:30:00-04:00",
+			},
+		},
+		{
+			name:     "spring forward in Europe",
+			rule:     "FREQ=WEEKLY;COUNT=3",
+			start:    "2026-03-22T01:30:00",
+			timeZone: "Europe/London",
+			expected: []string{
+				"2026-03-22T01:30:00Z",
+				"2026-03-29T02:30:00+01:00",
+				"2026-04-05T01:30:00+01:00",
+			},
+		},
+		{
+			name:     "fall back in Europe",
+			rule:     "FREQ=WEEKLY;COUNT=3",
+			start:    "2026-10-18T01:30:00",
+			timeZone: "Europe/London",
+			expected: []string{
+				"2026-10-18T01:30:00+01:00",
+				"2026-10-25T01:30:00+01:00",
+				"2026-11-01T01:30:00Z",
+			},
+		},
+		{
+			name:     "fall back in the southern hemisphere",
+			rule:     "FREQ=DAILY;COUNT=3",
+			start:    "2026-04-04T02:30:00",
+			timeZone: "Australia/Sydney",
+			expected: []string{
+				"2026-04-04T02:30:00+11:00",
+				"2026-04-05T02:30:00+11:00",
+				"2026-04-06T02:30:00+10:00",
+			},
+		},
+		{
+			name:     "spring forward in the southern hemisphere",
+			rule:     "FREQ=DAILY;COUNT=3",
+			start:    "2026-10-03T02:30:00",
+			timeZone: "Australia/Sydney",
+			expected: []string{
+				"2026-10-03T02:30:00+10:00",
+				"2026-10-04T03:30:00+11:00",
+				"2026-10-05T02:30:00+11:00",
+			},
+		},
+		{
+			name:     "half hour transitions",
+			rule:     "FREQ=DAILY;COUNT=3",
+			start:    "2026-10-03T02:15:00",
+			timeZone: "Australia/Lord_Howe",
+			expected: []string{
+				"2026-10-03T02:15:00+10:30",
+				"2026-10-04T02:45:00+11:00",
+				"2026-10-05T02:15:00+11:00",
+			},
+		},
+		{
+			name:     "half hour repeated times",
+			rule:     "FREQ=DAILY;COUNT=3",
+			start:    "2026-04-04T01:45:00",
+			timeZone: "Australia/Lord_Howe",
+			expected: []string{
+				"2026-04-04T01:45:00+11:00",
+				"2026-04-05T01:45:00+11:00",
+				"2026-04-06T01:45:00+10:30",
+			},
+		},
+		{
+			name:     "time zones without daylight saving time",
+			rule:     "FREQ=DAILY;COUNT=3",
+			start:    "2026-03-07T02:30:00",
+			timeZone: "UTC",
+			expected: []string{
+				"2026-03-07T02:30:00Z",
+				"2026-03-08T02:30:00Z",
+				"2026-03-09T02:30:00Z",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := buildSet(t, tc.rule, tc.start, tc.timeZone)
+
+			occurrences := s.Occurrences(s.Start, 100)
+			assert.Equal(t, tc.expected, formatAll(occurrences))
+		})
+	}
+}
+
+func TestSetOccurrencesAcrossDSTAreNotEvenlySpaced(t *testing.T) {
+	s := buildSet(t, "FREQ=DAILY;COUNT=3", "2026-03-07T09:00:00", "America/New_York")
+
+	occurrences := s.Occurrences(s.Start, 100)
+	require.Len(t, occurrences, 3)
+	assert.Equal(t, 23*time.Hour, occurrences[1].Sub(occurrences[0]), "Day with spring forward is not shorter")
+	assert.Equal(t, 24*time.Hour, occurrences[2].Sub(occurrences[1]), "Day after spring forward is not regular")
+}
+
+func TestSetOccurrencesUntilAcrossDST(t *testing.T) {
+	testCases := []struct {
+		name     string
+		rule     string
+		expected []string
+	}{
+		{
+			name: "until UTC is inclusive",
+			rule: "FREQ=DAILY;UNTIL=20260309T130000Z",
+			expected: []string{
+				"2026-03-07T09:00:00-05:00",
+				"2026-03-08T09:00:00-04:00",
+				"2026-03-09T09:00:00-04:00",
+			},
+		},
+		{
+			name: "until UTC uses the offset of the occurrence",
+			rule: "FREQ=DAILY;UNTIL=20260309T135959Z",
+			expected: []string{
+				"2026-03-07T09:00:00-05:00",
+				"2026-03-08T09:00:00-04:00",
+				"2026-03-09T09:00:00-04:00",
+			},
+		},
+		{
+			name: "until UTC excludes occurrences after it once the offset changes",
+			rule: "FREQ=DAILY;UNTIL=20260309T125959Z",
+			expected: []string{
+				"2026-03-07T09:00:00-05:00",
+				"2026-03-08T09:00:00-04:00",
+			},
+		},
+		{
+			name: "until local time is in the time zone of the set",
+			rule: "FREQ=DAILY;UNTIL=20260309T085959",
+			expected: []string{
+				"2026-03-07T09:00:00-05:00",
+				"2026-03-08T09:00:00-04:00",
+			},
+		},
+		{
+			name: "until date includes the whole day",
+			rule: "FREQ=DAILY;UNTIL=20260309",
+			expected: []string{
+				"2026-03-07T09:00:00-05:00",
+				"2026-03-08T09:00:00-04:00",
+				"2026-03-09T09:00:00-04:00",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := buildSet(t, tc.rule, "2026-03-07T09:00:00", "America/New_York")
+
+			occurrences := s.Occurrences(s.Start, 100)
+			assert.Equal(t, tc.expected, formatAll(occurrences))
+		})
+	}
+}
+
+func TestSetExDatesAcrossDST(t *testing.T) {
+	s := buildSet(t, "FREQ=DAILY;COUNT=4", "2026-03-07T02:30:00", "America/New_York")
+
+	// The excluded occurrence was moved forward by the gap. It is excluded no matter which time zone it is given in.
+	s.ExDates = []time.Time{time.Date(2026, time.March, 8, 7, 30, 0, 0, time.UTC)}
+
+	occurrences := s.Occurrences(s.Start, 100)
+	expected := []string{
+		"2026-03-07T02:30:00-05:00",
+		"2026-03-09T02:30:00-04:00",
+		"2026-03-10T02:30:00-04:00",
+	}
+	assert.Equal(t, expected, formatAll(occurrences), "Excluded occurrence did not count towards the count")
+}
+
+func TestSetOccurrences(t *testing.T) {
+	testCases := []struct {
+		name     string
+		rule     string
+		start    string
+		expected []string
+	}{
+		{
+			name:  "every tenth day",
+			rule:  "FREQ=DAILY;INTERVAL=10;COUNT=5",
+			start: "1997-09-02T09:00:00",
+			expected: []string{
+				"1997-09-02T09:00:00Z",
+				"1997-09-12T09:00:00Z",
+				"1997-09-22T09:00:00Z",
+				"1997-10-02T09:00:00Z",
+				"1997-10-12T09:00:00Z",
+			},
+		},
+		{
+			name:  "every day in January",
+			rule:  "FREQ=DAILY;BYMONTH=1;COUNT=3",
+			start: "1997-12-30T09:00:00",
+			expected: []string{
+				"1998-01-01T09:00:00Z",
+				"1998-01-02T09:00:00Z",
+				"1998-01-03T09:00:00Z",
+			},
+		},
+		{
+			name:  "every other week starting on Monday",
+			rule:  "FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,SU;WKST=MO",
+			start: "1997-08-05T09:00:00",
+			expected: []string{
+				"1997-08-05T09:00:00Z",
+				"1997-08-10T09:00:00Z",
+				"1997-08-19T09:00:00Z",
+				"1997-08-24T09:00:00Z",
+			},
+		},
+		{
+			name:  "every other week starting on Sunday",
+			rule:  "FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,SU;WKST=SU",
+			start: "1997-08-05T09:00:00",
+			expected: []string{
+				"1997-08-05T09:00:00Z",
+				"1997-08-17T09:00:00Z",
+				"1997-08-19T09:00:00Z",
+				"1997-08-31T09:00:00Z",
+			},
+		},
+		{
+			name:  "weekly on the day of the start",
+			rule:  "FREQ=WEEKLY;COUNT=3",
+			start: "2026-01-07T09:00:00",
+			expected: []string{
+				"2026-01-07T09:00:00Z",
+				"2026-01-14T09:00:00Z",
+				"2026-01-21T09:00:00Z",
+			},
+		},
+		{
+			name:  "start that does not match the rule is skipped",
+			rule:  "FREQ=WEEKLY;BYDAY=MO;COUNT=2",
+			start: "2026-01-07T09:00:00",
+			expected: []string{
+				"2026-01-12T09:00:00Z",
+				"2026-01-19T09:00:00Z",
+			},
+		},
+		{
+			name:  "last Friday of the month",
+			rule:  "FREQ=MONTHLY;BYDAY=-1FR;COUNT=3",
+			start: "2026-01-01T09:00:00",
+			expected: []string{
+				"2026-01-30T09:00:00Z",
+				"2026-02-27T09:00:00Z",
+				"2026-03-27T09:00:00Z",
+			},
+		},
+		{
+			name:  "first Monday and last Friday of the month",
+			rule:  "FREQ=MONTHLY;BYDAY=1MO,-1FR;COUNT=4",
+			start: "2026-01-01T09:00:00",
+			expected: []string{
+				"2026-01-05T09:00:00Z",
+				"2026-01-30T09:00:00Z",
+				"2026-02-02T09:00:00Z",
+				"2026-02-27T09:00:00Z",
+			},
+		},
+		{
+			name:  "Friday the 13th",
+			rule:  "FREQ=MONTHLY;BYDAY=FR;BYMONTHDAY=13;COUNT=3",
+			start: "2026-01-01T09:00:00",
+			expected: []string{
+				"2026-02-13T09:00:00Z",
+				"2026-03-13T09:00:00Z",
+				"2026-11-13T09:00:00Z",
+			},
+		},
+		{
+			name:  "months without the day of the start are skipped",
+			rule:  "FREQ=MONTHLY;COUNT=3",
+			start: "2026-01-31T09:00:00",
+			expected: []string{
+				"2026-01-31T09:00:00Z",
+				"2026-03-31T09:00:00Z",
+				"2026-05-31T09:00:00Z",
+			},
+		},
+		{
+			name:  "last day of the month",
+			rule:  "FREQ=MONTHLY;BYMONTHDAY=-1;COUNT=3",
+			start: "2026-01-31T09:00:00",
+			expected: []string{
+				"2026-01-31T09:00:00Z",
+				"2026-02-28T09:00:00Z",
+				"2026-03-31T09:00:00Z",
+			},
+		},
+		{
+			name:  "every other month",
+			rule:  "FREQ=MONTHLY;INTERVAL=2;COUNT=3",
+			start: "2026-11-15T09:00:00",
+			expected: []string{
+				"2026-11-15T09:00:00Z",
+				"2027-01-15T09:00:00Z",
+				"2027-03-15T09:00:00Z",
+			},
+		},
+		{
+			name:  "leap days",
+			rule:  "FREQ=YEARLY;COUNT=3",
+			start: "2028-02-29T09:00:00",
+			expected: []string{
+				"2028-02-29T09:00:00Z",
+				"2032-02-29T09:00:00Z",
+				"2036-02-29T09:00:00Z",
+			},
+		},
+		{
+			name:  "twentieth Monday of the year",
+			rule:  "FREQ=YEARLY;BYDAY=20MO;COUNT=3",
+			start: "1997-05-19T09:00:00",
+			expected: []string{
+				"1997-05-19T09:00:00Z",
+				"1998-05-18T09:00:00Z",
+				"1999-05-17T09:00:00Z",
+			},
+		},
+		{
+			name:  "every Thursday in March",
+			rule:  "FREQ=YEARLY;BYMONTH=3;BYDAY=TH;COUNT=6",
+			start: "1997-03-13T09:00:00",
+			expected: []string{
+				"1997-03-13T09:00:00Z",
+				"1997-03-20T09:00:00Z",
+				"1997-03-27T09:00:00Z",
+				"1998-03-05T09:00:00Z",
+				"1998-03-12T09:00:00Z",
+				"1998-03-19T09:00:00Z",
+			},
+		},
+		{
+			name:  "months of the year on the day of the start",
+			rule:  "FREQ=YEARLY;BYMONTH=6,7;COUNT=4",
+			start: "1997-06-10T09:00:00",
+			expected: []string{
+				"1997-06-10T09:00:00Z",
+				"1997-07-10T09:00:00Z",
+				"1998-06-10T09:00:00Z",
+				"1998-07-10T09:00:00Z",
+			},
+		},
+		{
+			name:  "first day of every month of the year",
+			rule:  "FREQ=YEARLY;BYMONTHDAY=1;COUNT=3",
+			start: "2026-11-01T09:00:00",
+			expected: []string{
+				"2026-11-01T09:00:00Z",
+				"2026-12-01T09:00:00Z",
+				"2027-01-01T09:00:00Z",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := buildSet(t, tc.rule, tc.start, "UTC")
+
+			occurrences := s.Occurrences(s.Start, 100)
+			assert.Equal(t, tc.expected, formatAll(occurrences))
+		})
+	}
+}
+
+func TestSetOccurrencesFrom(t *testing.T) {
+	s := buildSet(t, "FREQ=DAILY", "2026-01-01T09:00:00", "UTC")
+
+	occurrences := s.Occurrences(time.Date(2026, time.January, 10, 9, 0, 0, 0, time.UTC), 2)
+	assert.Equal(t, []string{"2026-01-10T09:00:00Z", "2026-01-11T09:00:00Z"}, formatAll(occurrences))
+
+	assert.Empty(t, s.Occurrences(s.Start, 0), "Returned occurrences without a limit")
+}
+
+func TestSetAfter(t *testing.T) {
+	s := buildSet(t, "FREQ=WEEKLY;BYDAY=MO,TH;COUNT=3", "2026-01-05T09:00:00", "America/New_York")
+
+	next, ok := s.After(s.Start)
+	require.True(t, ok, "Did not find next occurrence")
+	assert.Equal(t, "2026-01-08T09:00:00-05:00", next.Format(time.RFC3339), "Next occurrence is not strictly after")
+
+	next, ok = s.After(time.Date(2026, time.January, 8, 0, 0, 0, 0, time.UTC))
+	require.True(t, ok, "Did not find next occurrence")
+	assert.Equal(t, "2026-01-08T09:00:00-05:00", next.Format(time.RFC3339))
+
+	_, ok = s.After(time.Date(2026, time.January, 12, 9, 0, 0, 0, time.UTC).Add(5 * time.Hour))
+	assert.False(t, ok, "Found occurrence after the count ran out")
+}
+
+func TestSetAfterRuleThatNeverMatches(t *testing.T) {
+	s := buildSet(t, "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30", "2026-01-01T09:00:00", "UTC")
+
+	_, ok := s.After(s.Start)
+	assert.False(t, ok, "Found occurrence on a day that does not exist")
+}
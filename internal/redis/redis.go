@@ -3,6 +3,7 @@ package redis
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -12,8 +13,29 @@ import (
 type Client interface {
 	Ping(ctx context.Context) error
 	Get(ctx context.Context, key string) (*string, error)
+	MGet(ctx context.Context, keys ...string) ([]*string, error)
 	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
 	TTL(ctx context.Context, key string) (time.Duration, error)
+	Info(ctx context.Context, sections ...string) (string, error)
+	Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error)
+	Del(ctx context.Context, keys ...string) error
+	XAdd(ctx context.Context, stream string, maxLen int64, values map[string]interface{}) (string, error)
+	XRange(ctx context.Context, stream string, start string, stop string, count int64) ([]StreamEntry, error)
+	Publish(ctx context.Context, channel string, message string) error
+	Subscribe(ctx context.Context, channel string) (Subscription, error)
+	Close() error
+}
+
+// StreamEntry is an entry in a stream.
+type StreamEntry struct {
+	ID     string
+	Values map[string]interface{}
+}
+
+// Subscription receives the messages published to a channel.
+type Subscription interface {
+	// Messages returns the channel that the messages are received on. The channel is closed when the subscription is.
+	Messages() <-chan string
 	Close() error
 }
 
@@ -45,6 +67,24 @@ func (r *Redis) Get(ctx context.Context, key string) (*string, error) {
 	return &val, nil
 }
 
+// MGet retrieves multiple keys in a single round trip. The values are returned in the same order as the keys, with nil
+// for keys that do not exist.
+func (r *Redis) MGet(ctx context.Context, keys ...string) ([]*string, error) {
+	vals, err := r.c.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*string, len(vals))
+	for i, val := range vals {
+		if str, ok := val.(string); ok {
+			res[i] = &str
+		}
+	}
+
+	return res, nil
+}
+
 // Set sets a key with optional expiration.
 //
 // Expiration of 0 means that the key will not have an expiration.
@@ -59,6 +99,114 @@ func (r *Redis) TTL(ctx context.Context, key string) (time.Duration, error) {
 	return r.c.TTL(ctx, key).Result()
 }
 
+// Info returns information and statistics about the Redis server in the raw format documented by the INFO command.
+//
+// If no sections are provided, the default set of sections are returned.
+func (r *Redis) Info(ctx context.Context, sections ...string) (string, error) {
+	return r.c.Info(ctx, sections...).Result()
+}
+
+// Eval runs a Lua script atomically. The script is run by its SHA1 digest so that it is only sent to Redis when Redis
+// does not already have it cached.
+//
+// Integer replies are returned as int64, status and bulk string replies as string, array replies as []interface{}, and
+// nil replies as nil.
+func (r *Redis) Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+	val, err := redis.NewScript(script).Run(ctx, r.c, keys, args...).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+
+	return val, err
+}
+
+// Del removes keys. Keys that do not exist are ignored.
+func (r *Redis) Del(ctx context.Context, keys ...string) error {
+	return r.c.Del(ctx, keys...).Err()
+}
+
+// XAdd appends an entry with the values to a stream, returning the ID of the entry. The stream is created if it does
+// not exist.
+//
+// The oldest entries are trimmed so that the stream holds approximately maxLen entries. A maxLen of 0 means that the
+// stream is not trimmed.
+func (r *Redis) XAdd(ctx context.Context, stream string, maxLen int64, values map[string]interface{}) (string, error) {
+	return r.c.XAdd(ctx, &redis.XAddArgs{Stream: stream, MaxLen: maxLen, Approx: true, Values: values}).Result()
+}
+
+// XRange retrieves up to count entries of a stream with IDs between start and stop, inclusive, in order. The special
+// IDs "-" and "+" are the first and last possible IDs. A count of 0 retrieves every entry in the range.
+func (r *Redis) XRange(ctx context.Context, stream string, start string, stop string, count int64) ([]StreamEntry, error) {
+	var msgs []redis.XMessage
+	var err error
+	if count > 0 {
+		msgs, err = r.c.XRangeN(ctx, stream, start, stop, count).Result()
+	} else {
+		msgs, err = r.c.XRange(ctx, stream, start, stop).Result()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]StreamEntry, len(msgs))
+	for i, msg := range msgs {
+		res[i] = StreamEntry{ID: msg.ID, Values: msg.Values}
+	}
+
+	return res, nil
+}
+
+// Publish sends a message to every subscriber of a channel.
+func (r *Redis) Publish(ctx context.Context, channel string, message string) error {
+	return r.c.Publish(ctx, channel, message).Err()
+}
+
+// Subscribe listens for the messages published to a channel. The subscription holds a connection of its own until it
+// is closed.
+func (r *Redis) Subscribe(ctx context.Context, channel string) (Subscription, error) {
+	ps := r.c.Subscribe(ctx, channel)
+
+	// Wait for Redis to confirm the subscription so that messages published after returning are not missed
+	_, err := ps.Receive(ctx)
+	if err != nil {
+		ps.Close()
+		return nil, err
+	}
+
+	s := &subscription{ps: ps, msgs: make(chan string), done: make(chan struct{})}
+	go func() {
+		defer close(s.msgs)
+		for msg := range ps.Channel() {
+			select {
+			case s.msgs <- msg.Payload:
+			case <-s.done:
+				return
+			}
+		}
+	}()
+
+	return s, nil
+}
+
+// subscription is a Redis pub/sub subscription.
+type subscription struct {
+	ps        *redis.PubSub
+	msgs      chan string
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// Messages returns the channel that the messages are received on.
+func (s *subscription) Messages() <-chan string {
+	return s.msgs
+}
+
+// Close unsubscribes and closes the subscription's connection.
+func (s *subscription) Close() error {
+	s.closeOnce.Do(func() { close(s.done) })
+	return s.ps.Close()
+}
+
 // Close shuts down the connection to Redis.
 func (r *Redis) Close() error {
 	return r.c.Close()
@@ -250,6 +250,27 @@ func TestIntegrationTTLNoKey(t *testing.T) {
 	assert.Equal(t, time.Duration(-2), ttl)
 }
 
+func TestIntegrationInfo(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+	redisContainer, err := setupRedis(ctx)
+	require.NoError(t, err, "Failed to start up Redis container")
+	defer redisContainer.Terminate(ctx)
+
+	config := redis.Config{URI: redisContainer.URI}
+	rdb, err := redis.New(config)
+	require.NoError(t, err, "Client instantiation error")
+	defer rdb.Close()
+
+	info, err := rdb.Info(ctx, "memory", "replication")
+	assert.NoError(t, err, "Info error")
+	assert.Contains(t, info, "used_memory:")
+	assert.Contains(t, info, "role:master")
+}
+
 func TestIntegrationCloset(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
@@ -265,3 +286,210 @@ func TestIntegrationCloset(t *testing.T) {
 	require.NoError(t, err, "Client instantiation error")
 	defer rdb.Close()
 }
+
+func TestIntegrationEval(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+	redisContainer, err := setupRedis(ctx)
+	require.NoError(t, err, "Failed to start up Redis container")
+	defer redisContainer.Terminate(ctx)
+
+	config := redis.Config{URI: redisContainer.URI}
+	rdb, err := redis.New(config)
+	require.NoError(t, err, "Client instantiation error")
+	defer rdb.Close()
+
+	script := "return {redis.call('INCRBY', KEYS[1], ARGV[1]), ARGV[1]}"
+
+	// Run twice to make sure that the cached script is used
+	res, err := rdb.Eval(ctx, script, []string{"dummy"}, 2)
+	assert.NoError(t, err, "Eval error")
+	assert.Equal(t, []interface{}{int64(2), "2"}, res)
+
+	res, err = rdb.Eval(ctx, script, []string{"dummy"}, 3)
+	assert.NoError(t, err, "Eval error")
+	assert.Equal(t, []interface{}{int64(5), "3"}, res)
+}
+
+func TestIntegrationEvalNil(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+	redisContainer, err := setupRedis(ctx)
+	require.NoError(t, err, "Failed to start up Redis container")
+	defer redisContainer.Terminate(ctx)
+
+	config := redis.Config{URI: redisContainer.URI}
+	rdb, err := redis.New(config)
+	require.NoError(t, err, "Client instantiation error")
+	defer rdb.Close()
+
+	res, err := rdb.Eval(ctx, "return redis.call('GET', KEYS[1])", []string{"dummy"})
+	assert.NoError(t, err, "Eval error")
+	assert.Nil(t, res)
+}
+
+func TestIntegrationDel(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+	redisContainer, err := setupRedis(ctx)
+	require.NoError(t, err, "Failed to start up Redis container")
+	defer redisContainer.Terminate(ctx)
+
+	config := redis.Config{URI: redisContainer.URI}
+	rdb, err := redis.New(config)
+	require.NoError(t, err, "Client instantiation error")
+	defer rdb.Close()
+
+	err = rdb.Set(ctx, "dummy", "value", 0)
+	require.NoError(t, err, "Set error")
+
+	err = rdb.Del(ctx, "dummy", "missing")
+	assert.NoError(t, err, "Del error")
+
+	val, err := rdb.Get(ctx, "dummy")
+	assert.NoError(t, err, "Get error")
+	assert.Nil(t, val, "Key was not deleted")
+}
+
+func TestIntegrationMGet(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+	redisContainer, err := setupRedis(ctx)
+	require.NoError(t, err, "Failed to start up Redis container")
+	defer redisContainer.Terminate(ctx)
+
+	config := redis.Config{URI: redisContainer.URI}
+	rdb, err := redis.New(config)
+	require.NoError(t, err, "Client instantiation error")
+	defer rdb.Close()
+
+	err = rdb.Set(ctx, "first", "1", 0)
+	require.NoError(t, err, "Set error")
+	err = rdb.Set(ctx, "third", "3", 0)
+	require.NoError(t, err, "Set error")
+
+	vals, err := rdb.MGet(ctx, "first", "second", "third")
+	assert.NoError(t, err, "MGet error")
+	require.Len(t, vals, 3)
+	assert.Equal(t, "1", *vals[0])
+	assert.Nil(t, vals[1])
+	assert.Equal(t, "3", *vals[2])
+}
+
+func TestIntegrationXAdd(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+	redisContainer, err := setupRedis(ctx)
+	require.NoError(t, err, "Failed to start up Redis container")
+	defer redisContainer.Terminate(ctx)
+
+	config := redis.Config{URI: redisContainer.URI}
+	rdb, err := redis.New(config)
+	require.NoError(t, err, "Client instantiation error")
+	defer rdb.Close()
+
+	first, err := rdb.XAdd(ctx, "dummy", 0, map[string]interface{}{"name": "first"})
+	require.NoError(t, err, "XAdd error")
+	assert.NotEmpty(t, first, "XAdd did not return an ID")
+
+	second, err := rdb.XAdd(ctx, "dummy", 0, map[string]interface{}{"name": "second"})
+	require.NoError(t, err, "XAdd error")
+	assert.NotEqual(t, first, second, "XAdd returned the same ID twice")
+
+	length, err := rdb.Eval(ctx, "return redis.call('XLEN', KEYS[1])", []string{"dummy"})
+	require.NoError(t, err, "Eval error")
+	assert.Equal(t, int64(2), length)
+}
+
+func TestIntegrationXRange(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+	redisContainer, err := setupRedis(ctx)
+	require.NoError(t, err, "Failed to start up Redis container")
+	defer redisContainer.Terminate(ctx)
+
+	config := redis.Config{URI: redisContainer.URI}
+	rdb, err := redis.New(config)
+	require.NoError(t, err, "Client instantiation error")
+	defer rdb.Close()
+
+	first, err := rdb.XAdd(ctx, "dummy", 0, map[string]interface{}{"name": "first"})
+	require.NoError(t, err, "XAdd error")
+	second, err := rdb.XAdd(ctx, "dummy", 0, map[string]interface{}{"name": "second"})
+	require.NoError(t, err, "XAdd error")
+	_, err = rdb.XAdd(ctx, "dummy", 0, map[string]interface{}{"name": "third"})
+	require.NoError(t, err, "XAdd error")
+
+	entries, err := rdb.XRange(ctx, "dummy", "-", "+", 0)
+	require.NoError(t, err, "XRange error")
+	require.Len(t, entries, 3)
+	assert.Equal(t, first, entries[0].ID)
+	assert.Equal(t, map[string]interface{}{"name": "first"}, entries[0].Values)
+
+	entries, err = rdb.XRange(ctx, "dummy", second, "+", 1)
+	require.NoError(t, err, "XRange error")
+	require.Len(t, entries, 1)
+	assert.Equal(t, second, entries[0].ID)
+	assert.Equal(t, map[string]interface{}{"name": "second"}, entries[0].Values)
+
+	entries, err = rdb.XRange(ctx, "nonexistent", "-", "+", 0)
+	require.NoError(t, err, "XRange error")
+	assert.Empty(t, entries)
+}
+
+func TestIntegrationPublishSubscribe(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+	redisContainer, err := setupRedis(ctx)
+	require.NoError(t, err, "Failed to start up Redis container")
+	defer redisContainer.Terminate(ctx)
+
+	config := redis.Config{URI: redisContainer.URI}
+	rdb, err := redis.New(config)
+	require.NoError(t, err, "Client instantiation error")
+	defer rdb.Close()
+
+	sub, err := rdb.Subscribe(ctx, "dummy")
+	require.NoError(t, err, "Subscribe error")
+
+	err = rdb.Publish(ctx, "dummy", "hello")
+	require.NoError(t, err, "Publish error")
+
+	select {
+	case msg := <-sub.Messages():
+		assert.Equal(t, "hello", msg)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Message was not received")
+	}
+
+	err = sub.Close()
+	require.NoError(t, err, "Close error")
+
+	select {
+	case _, ok := <-sub.Messages():
+		assert.False(t, ok, "Messages were not closed")
+	case <-time.After(5 * time.Second):
+		t.Fatal("Messages were not closed")
+	}
+}
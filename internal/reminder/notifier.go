@@ -0,0 +1,134 @@
+package reminder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// LogNotifier writes reminders to the application log. Useful in development or as a record of the reminders sent.
+type LogNotifier struct{}
+
+// Notify logs the reminder. Never returns an error.
+func (LogNotifier) Notify(ctx context.Context, r Reminder) error {
+	log.Info().
+		Str("reminder", r.ID).
+		Str("tenant", r.TenantID).
+		Str("task", r.TaskID).
+		Str("owner", r.OwnerID).
+		Time("dateDue", r.DateDue).
+		Bool("overdue", r.Overdue()).
+		Msg("Task reminder")
+	return nil
+}
+
+// webhookPayload is the body of a reminder sent to a webhook.
+type webhookPayload struct {
+	Reminder
+	Overdue bool `json:"overdue"`
+}
+
+// WebhookNotifier posts reminders to a URL as JSON. The reminder's ID is also sent as the Idempotency-Key header so
+// that the receiver can ignore duplicates.
+type WebhookNotifier struct {
+	URL string
+	// Client sends the requests. Defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+// Notify posts the reminder to the webhook. Any response other than a 2xx is an error.
+func (wn WebhookNotifier) Notify(ctx context.Context, r Reminder) error {
+	body, err := json.Marshal(webhookPayload{Reminder: r, Overdue: r.Overdue()})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wn.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", r.ID)
+
+	client := wn.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %d", res.StatusCode)
+	}
+
+	return nil
+}
+
+// SendMailFunc sends an email, like smtp.SendMail.
+type SendMailFunc func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+
+// SMTPNotifier emails reminders. Task owners are identified by subjects rather than email addresses, so every reminder
+// is sent to the same recipients, such as a team's mailing list.
+type SMTPNotifier struct {
+	// Addr is the host and port of the SMTP server.
+	Addr string
+	// Auth authenticates with the SMTP server. Nil to send without authenticating.
+	Auth smtp.Auth
+	From string
+	To   []string
+	// SendMail sends the email. Defaults to smtp.SendMail.
+	SendMail SendMailFunc
+}
+
+// Notify emails the reminder. The reminder's ID is used as the message ID so that mail clients can ignore duplicates.
+//
+// The context is not used since smtp.SendMail does not support cancellation.
+func (sn SMTPNotifier) Notify(ctx context.Context, r Reminder) error {
+	sendMail := sn.SendMail
+	if sendMail == nil {
+		sendMail = smtp.SendMail
+	}
+
+	return sendMail(sn.Addr, sn.Auth, sn.From, sn.To, sn.message(r))
+}
+
+// message builds the email for the reminder.
+func (sn SMTPNotifier) message(r Reminder) []byte {
+	subject := "Task due soon"
+	if r.Overdue() {
+		subject = "Task overdue"
+	}
+
+	domain := "localhost"
+	if at := strings.LastIndex(sn.From, "@"); at >= 0 {
+		domain = sn.From[at+1:]
+	}
+
+	// Descriptions are provided by users so line breaks are removed to keep them from injecting headers
+	description := strings.NewReplacer("\r", " ", "\n", " ").Replace(r.Description)
+
+	var msg strings.Builder
+	msg.WriteString("From: " + sn.From + "\r\n")
+	msg.WriteString("To: " + strings.Join(sn.To, ", ") + "\r\n")
+	msg.WriteString("Subject: " + subject + "\r\n")
+	msg.WriteString("Message-ID: <" + r.ID + "@" + domain + ">\r\n")
+	msg.WriteString("Date: " + r.DateRemind.Format(time.RFC1123Z) + "\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(description + "\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString("Task " + r.TaskID + " is due " + r.DateDue.UTC().Format(time.RFC3339) + ".\r\n")
+
+	return []byte(msg.String())
+}
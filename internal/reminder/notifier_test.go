@@ -0,0 +1,121 @@
+package reminder_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"testing"
+	"time"
+
+	"github.com/jaredpetersen/go-rest-template/internal/reminder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func buildReminder() reminder.Reminder {
+	dateDue := time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+	return reminder.New(buildTask("sometask", dateDue, time.Hour), time.Hour)
+}
+
+func TestWebhookNotifierNotify(t *testing.T) {
+	r := buildReminder()
+
+	var received *http.Request
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		received = req
+		body, _ = io.ReadAll(req.Body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	wn := reminder.WebhookNotifier{URL: srv.URL}
+	err := wn.Notify(context.Background(), r)
+	require.NoError(t, err)
+
+	require.NotNil(t, received, "Webhook was not called")
+	assert.Equal(t, http.MethodPost, received.Method)
+	assert.Equal(t, "application/json", received.Header.Get("Content-Type"))
+	assert.Equal(t, r.ID, received.Header.Get("Idempotency-Key"))
+
+	expectedBody, err := json.Marshal(map[string]interface{}{
+		"id":          r.ID,
+		"tenantId":    "tenant-1",
+		"taskId":      "sometask",
+		"ownerId":     "user-1",
+		"description": "Buy milk",
+		"dateDue":     "2026-03-02T09:00:00Z",
+		"dateRemind":  "2026-03-02T08:00:00Z",
+		"overdue":     false,
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, string(expectedBody), string(body))
+}
+
+func TestWebhookNotifierNotifyErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	wn := reminder.WebhookNotifier{URL: srv.URL}
+	err := wn.Notify(context.Background(), buildReminder())
+	assert.EqualError(t, err, "webhook responded with status 503")
+}
+
+func TestSMTPNotifierNotify(t *testing.T) {
+	r := buildReminder()
+	r.Description = "Buy milk\r\nBcc: everyone@example.com"
+
+	var sentAddr, sentFrom string
+	var sentTo []string
+	var sentMsg []byte
+	sn := reminder.SMTPNotifier{
+		Addr: "smtp.example.com:587",
+		From: "reminders@example.com",
+		To:   []string{"team@example.com"},
+		SendMail: func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+			sentAddr, sentFrom, sentTo, sentMsg = addr, from, to, msg
+			return nil
+		},
+	}
+
+	err := sn.Notify(context.Background(), r)
+	require.NoError(t, err)
+
+	expectedMsg := "From: reminders@example.com\r\n" +
+		"To: team@example.com\r\n" +
+		"Subject: Task due soon\r\n" +
+		"Message-ID: <" + r.ID + "@example.com>\r\n" +
+		"Date: Mon, 02 Mar 2026 08:00:00 +0000\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"\r\n" +
+		"Buy milk  Bcc: everyone@example.com\r\n" +
+		"\r\n" +
+		"Task sometask is due 2026-03-02T09:00:00Z.\r\n"
+
+	assert.Equal(t, "smtp.example.com:587", sentAddr)
+	assert.Equal(t, "reminders@example.com", sentFrom)
+	assert.Equal(t, []string{"team@example.com"}, sentTo)
+	assert.Equal(t, expectedMsg, string(sentMsg))
+}
+
+func TestSMTPNotifierNotifyError(t *testing.T) {
+	sn := reminder.SMTPNotifier{
+		SendMail: func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+			return errors.New("connection refused")
+		},
+	}
+
+	err := sn.Notify(context.Background(), buildReminder())
+	assert.EqualError(t, err, "connection refused")
+}
+
+func TestLogNotifierNotify(t *testing.T) {
+	err := reminder.LogNotifier{}.Notify(context.Background(), buildReminder())
+	assert.NoError(t, err)
+}
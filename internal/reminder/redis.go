@@ -0,0 +1,118 @@
+package reminder
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jaredpetersen/go-rest-template/internal/redis"
+)
+
+// acquireScript takes the lease if it is free or renews it if the holder already has it.
+//
+// KEYS[1] - key to store the lease in
+// ARGV[1] - holder of the lease
+// ARGV[2] - lease TTL in milliseconds
+//
+// Returns 1 if the holder has the lease and 0 otherwise
+const acquireScript = `
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+  redis.call('PEXPIRE', KEYS[1], ARGV[2])
+  return 1
+end
+
+if redis.call('SET', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2]) then
+  return 1
+end
+
+return 0
+`
+
+// releaseScript gives up the lease if the holder still has it, so that a lease that has since been taken over by
+// another replica is left alone.
+//
+// KEYS[1] - key that the lease is stored in
+// ARGV[1] - holder of the lease
+const releaseScript = `
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+  redis.call('DEL', KEYS[1])
+end
+
+return 0
+`
+
+// reserveScript sets the key unless it already exists.
+//
+// KEYS[1] - key to reserve
+// ARGV[1] - reservation TTL in milliseconds
+//
+// Returns 1 if the key was reserved and 0 otherwise
+const reserveScript = `
+if redis.call('SET', KEYS[1], 'reserved', 'NX', 'PX', ARGV[1]) then
+  return 1
+end
+
+return 0
+`
+
+// RedisLease is a lease that is shared by every replica of the service through Redis. The lease expires if the holder
+// does not renew it within the TTL, e.g. because the replica crashed.
+type RedisLease struct {
+	Redis redis.Client
+	// Key is where the lease is stored.
+	Key string
+	// Holder uniquely identifies the replica, such as a hostname combined with a random ID.
+	Holder string
+	TTL    time.Duration
+}
+
+// Acquire takes the lease if it is free or renews it if the replica already holds it.
+func (rl RedisLease) Acquire(ctx context.Context) (bool, error) {
+	val, err := rl.Redis.Eval(ctx, acquireScript, []string{rl.Key}, rl.Holder, rl.TTL.Milliseconds())
+	if err != nil {
+		return false, err
+	}
+
+	held, ok := val.(int64)
+	if !ok {
+		return false, fmt.Errorf("unexpected lease script result %v", val)
+	}
+
+	return held == 1, nil
+}
+
+// Release gives up the lease if the replica still holds it.
+func (rl RedisLease) Release(ctx context.Context) error {
+	_, err := rl.Redis.Eval(ctx, releaseScript, []string{rl.Key}, rl.Holder)
+	return err
+}
+
+// RedisDeliveryStore records reminder deliveries in Redis.
+type RedisDeliveryStore struct {
+	Redis redis.Client
+}
+
+// Reserve atomically claims the key for a delivery that is in flight unless it has already been claimed or delivered.
+func (rds RedisDeliveryStore) Reserve(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	val, err := rds.Redis.Eval(ctx, reserveScript, []string{key}, ttl.Milliseconds())
+	if err != nil {
+		return false, err
+	}
+
+	reserved, ok := val.(int64)
+	if !ok {
+		return false, fmt.Errorf("unexpected reservation script result %v", val)
+	}
+
+	return reserved == 1, nil
+}
+
+// Complete records that the delivery was successful, replacing the reservation.
+func (rds RedisDeliveryStore) Complete(ctx context.Context, key string, ttl time.Duration) error {
+	return rds.Redis.Set(ctx, key, "delivered", ttl)
+}
+
+// Release removes the reservation for the key.
+func (rds RedisDeliveryStore) Release(ctx context.Context, key string) error {
+	return rds.Redis.Del(ctx, key)
+}
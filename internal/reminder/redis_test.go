@@ -0,0 +1,215 @@
+package reminder_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jaredpetersen/go-rest-template/internal/redis"
+	"github.com/jaredpetersen/go-rest-template/internal/reminder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	redismock "github.com/jaredpetersen/go-rest-template/internal/redis/mocks"
+)
+
+type redisContainer struct {
+	testcontainers.Container
+	URI string
+}
+
+// setupRedis starts up a Redis container
+//
+// Returned Redis container must be explicitly terminated
+func setupRedis(ctx context.Context) (*redisContainer, error) {
+	req := testcontainers.ContainerRequest{
+		Image:        "redis:6",
+		ExposedPorts: []string{"6379/tcp"},
+		WaitingFor:   wait.ForLog("* Ready to accept connections"),
+		SkipReaper:   true,
+	}
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	mappedPort, err := container.MappedPort(ctx, "6379")
+	if err != nil {
+		return nil, err
+	}
+
+	hostIP, err := container.Host(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	uri := fmt.Sprintf("redis://%s:%s", hostIP, mappedPort.Port())
+
+	return &redisContainer{Container: container, URI: uri}, nil
+}
+
+func TestRedisLeaseAcquire(t *testing.T) {
+	ctx := context.Background()
+
+	rdb := redismock.Client{}
+	rdb.On("Eval", mock.Anything, mock.AnythingOfType("string"), []string{"reminder.lease"}, "replica-1", int64(30000)).
+		Return(int64(1), nil)
+
+	lease := reminder.RedisLease{Redis: &rdb, Key: "reminder.lease", Holder: "replica-1", TTL: 30 * time.Second}
+
+	held, err := lease.Acquire(ctx)
+	assert.NoError(t, err, "Returned error")
+	assert.True(t, held, "Did not acquire lease")
+
+	rdb.AssertExpectations(t)
+}
+
+func TestRedisLeaseAcquireHeldElsewhere(t *testing.T) {
+	ctx := context.Background()
+
+	rdb := redismock.Client{}
+	rdb.On("Eval", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(int64(0), nil)
+
+	lease := reminder.RedisLease{Redis: &rdb, Key: "reminder.lease", Holder: "replica-1", TTL: 30 * time.Second}
+
+	held, err := lease.Acquire(ctx)
+	assert.NoError(t, err, "Returned error")
+	assert.False(t, held, "Acquired lease held by another replica")
+}
+
+func TestRedisLeaseAcquireReturnsRedisError(t *testing.T) {
+	ctx := context.Background()
+
+	expectedErr := errors.New("Failed")
+
+	rdb := redismock.Client{}
+	rdb.On("Eval", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, expectedErr)
+
+	lease := reminder.RedisLease{Redis: &rdb, Key: "reminder.lease", Holder: "replica-1", TTL: 30 * time.Second}
+
+	held, err := lease.Acquire(ctx)
+	assert.EqualError(t, err, expectedErr.Error(), "Did not return error")
+	assert.False(t, held)
+}
+
+func TestRedisDeliveryStoreReserve(t *testing.T) {
+	ctx := context.Background()
+
+	rdb := redismock.Client{}
+	rdb.On("Eval", mock.Anything, mock.AnythingOfType("string"), []string{"reminder.key"}, int64(60000)).
+		Return(int64(1), nil)
+
+	store := reminder.RedisDeliveryStore{Redis: &rdb}
+
+	reserved, err := store.Reserve(ctx, "reminder.key", time.Minute)
+	assert.NoError(t, err, "Returned error")
+	assert.True(t, reserved, "Did not reserve delivery")
+
+	rdb.AssertExpectations(t)
+}
+
+func TestRedisDeliveryStoreReserveUnexpectedResult(t *testing.T) {
+	ctx := context.Background()
+
+	rdb := redismock.Client{}
+	rdb.On("Eval", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return("OK", nil)
+
+	store := reminder.RedisDeliveryStore{Redis: &rdb}
+
+	_, err := store.Reserve(ctx, "reminder.key", time.Minute)
+	assert.Error(t, err, "Did not return error")
+}
+
+func TestIntegrationRedisLease(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+
+	redisContainer, err := setupRedis(ctx)
+	require.NoError(t, err, "Failed to start up Redis container")
+	defer redisContainer.Terminate(ctx)
+
+	rdb, err := redis.New(redis.Config{URI: redisContainer.URI})
+	require.NoError(t, err, "Failed to set up Redis client")
+	defer rdb.Close()
+
+	first := reminder.RedisLease{Redis: rdb, Key: "reminder.lease", Holder: "replica-1", TTL: time.Minute}
+	second := reminder.RedisLease{Redis: rdb, Key: "reminder.lease", Holder: "replica-2", TTL: time.Minute}
+
+	held, err := first.Acquire(ctx)
+	require.NoError(t, err)
+	assert.True(t, held, "First replica did not acquire free lease")
+
+	held, err = second.Acquire(ctx)
+	require.NoError(t, err)
+	assert.False(t, held, "Second replica acquired held lease")
+
+	held, err = first.Acquire(ctx)
+	require.NoError(t, err)
+	assert.True(t, held, "First replica did not renew its lease")
+
+	// Releasing a lease that is held by another replica does nothing
+	err = second.Release(ctx)
+	require.NoError(t, err)
+	held, err = second.Acquire(ctx)
+	require.NoError(t, err)
+	assert.False(t, held, "Second replica released the first replica's lease")
+
+	err = first.Release(ctx)
+	require.NoError(t, err)
+	held, err = second.Acquire(ctx)
+	require.NoError(t, err)
+	assert.True(t, held, "Second replica did not acquire released lease")
+}
+
+func TestIntegrationRedisDeliveryStore(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+
+	redisContainer, err := setupRedis(ctx)
+	require.NoError(t, err, "Failed to start up Redis container")
+	defer redisContainer.Terminate(ctx)
+
+	rdb, err := redis.New(redis.Config{URI: redisContainer.URI})
+	require.NoError(t, err, "Failed to set up Redis client")
+	defer rdb.Close()
+
+	store := reminder.RedisDeliveryStore{Redis: rdb}
+
+	reserved, err := store.Reserve(ctx, "reminder.key", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, reserved, "Did not reserve delivery")
+
+	reserved, err = store.Reserve(ctx, "reminder.key", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, reserved, "Reserved delivery that is in flight")
+
+	err = store.Release(ctx, "reminder.key")
+	require.NoError(t, err)
+	reserved, err = store.Reserve(ctx, "reminder.key", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, reserved, "Did not reserve released delivery")
+
+	err = store.Complete(ctx, "reminder.key", time.Hour)
+	require.NoError(t, err)
+	reserved, err = store.Reserve(ctx, "reminder.key", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, reserved, "Reserved delivered delivery")
+
+	ttl, err := rdb.TTL(ctx, "reminder.key")
+	require.NoError(t, err)
+	assert.Greater(t, ttl, time.Minute, "Delivery record did not replace the reservation's TTL")
+}
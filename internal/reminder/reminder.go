@@ -0,0 +1,82 @@
+// Package reminder reminds the owners of tasks that their tasks are due soon or overdue.
+package reminder
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+)
+
+// Reminder is a notice that a task is due soon or overdue.
+type Reminder struct {
+	// ID identifies the reminder. It is the same every time the reminder is built so that it can be used to deliver the
+	// reminder idempotently.
+	ID          string        `json:"id"`
+	TenantID    string        `json:"tenantId"`
+	TaskID      string        `json:"taskId"`
+	OwnerID     string        `json:"ownerId"`
+	Description string        `json:"description"`
+	DateDue     time.Time     `json:"dateDue"`
+	DateRemind  time.Time     `json:"dateRemind"`
+	Offset      time.Duration `json:"-"`
+}
+
+// New builds the reminder for a task that is sent offset before the task's due date. The task must have a due date.
+func New(t task.Task, offset time.Duration) Reminder {
+	dateDue := *t.DateDue
+	hash := sha256.Sum256([]byte(t.TenantID + "\x00" + t.ID + "\x00" +
+		strconv.FormatInt(dateDue.UnixNano(), 10) + "\x00" + strconv.FormatInt(int64(offset), 10)))
+
+	return Reminder{
+		ID:          hex.EncodeToString(hash[:16]),
+		TenantID:    t.TenantID,
+		TaskID:      t.ID,
+		OwnerID:     t.OwnerID,
+		Description: t.Description,
+		DateDue:     dateDue,
+		DateRemind:  dateDue.Add(-offset),
+		Offset:      offset,
+	}
+}
+
+// Overdue indicates whether or not the reminder is for a task that has passed its due date.
+func (r Reminder) Overdue() bool {
+	return r.Offset <= 0
+}
+
+// Notifier delivers reminders.
+type Notifier interface {
+	// Notify delivers the reminder. Reminders may be delivered more than once, so receivers should use the reminder's
+	// ID to ignore duplicates.
+	Notify(ctx context.Context, r Reminder) error
+}
+
+// TaskLister lists tasks that need reminders.
+type TaskLister interface {
+	// ListDue retrieves up to limit of the incomplete tasks of every tenant that are due no later than to, ordered by
+	// due date and then ID, starting after the cursor of a due date and task ID. An empty ID starts at the due date.
+	ListDue(ctx context.Context, afterDue time.Time, afterID string, to time.Time, limit int) ([]task.Task, error)
+}
+
+// Lease elects a single replica to send reminders.
+type Lease interface {
+	// Acquire takes or renews the lease. False is returned if another replica holds the lease.
+	Acquire(ctx context.Context) (bool, error)
+	// Release gives up the lease so that another replica can take it over without waiting for it to expire.
+	Release(ctx context.Context) error
+}
+
+// DeliveryStore records which reminders have been delivered.
+type DeliveryStore interface {
+	// Reserve atomically claims the key for a delivery that is in flight. False is returned if the key has already been
+	// claimed or delivered. The reservation expires after the TTL so that a crashed delivery is retried.
+	Reserve(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	// Complete records that the delivery was successful. The record expires after the TTL.
+	Complete(ctx context.Context, key string, ttl time.Duration) error
+	// Release removes the reservation for the key so that the delivery is retried.
+	Release(ctx context.Context, key string) error
+}
@@ -0,0 +1,173 @@
+package reminder
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+	"github.com/rs/zerolog/log"
+)
+
+// Scheduler periodically sends the reminders for tasks that are due soon or overdue.
+//
+// Every replica of the service may run a scheduler, but only the replica that holds the lease sends reminders. Each
+// delivery is also reserved in the delivery store before it is attempted, so a reminder is only delivered to each
+// notifier once even if two replicas briefly believe that they hold the lease. A delivery that fails is released and
+// retried on every run until the reminder is older than the catch-up window.
+type Scheduler struct {
+	Tasks      TaskLister
+	Deliveries DeliveryStore
+	Lease      Lease
+	// Notifiers deliver every reminder. The key names the notifier in logs and delivery records, so it must not change
+	// between releases.
+	Notifiers map[string]Notifier
+	// DefaultOffsets are how long before the due date to remind the owners of tasks that do not have reminder offsets
+	// of their own. Defaults to reminding them when the task becomes overdue.
+	DefaultOffsets []time.Duration
+	// CatchUp is how long after it was due that a reminder is still sent, e.g. when no replica held the lease at the
+	// time or a notifier was failing. Defaults to an hour.
+	CatchUp time.Duration
+	// Timeout is how long a notifier may take to deliver a reminder. Defaults to 30 seconds.
+	Timeout time.Duration
+	// BatchSize is the number of tasks retrieved at a time. Defaults to 500.
+	BatchSize int
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// NewScheduler creates a scheduler with default values. The returned pointer will never be nil.
+func NewScheduler() *Scheduler {
+	return &Scheduler{
+		Notifiers:      make(map[string]Notifier),
+		DefaultOffsets: []time.Duration{0},
+		CatchUp:        time.Hour,
+		Timeout:        30 * time.Second,
+		BatchSize:      500,
+		Now:            time.Now,
+	}
+}
+
+// Start runs the scheduler on an interval in a separate goroutine until the context is done, at which point the lease
+// is released. The lease should last longer than the interval so that it does not lapse between runs.
+func (s *Scheduler) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				// The context is already done so the lease needs a context of its own to be released
+				releaseCtx, cancel := context.WithTimeout(context.Background(), s.Timeout)
+				err := s.Lease.Release(releaseCtx)
+				cancel()
+				if err != nil {
+					log.Error().Err(err).Msg("Failed to release reminder lease")
+				}
+				return
+			case <-ticker.C:
+				err := s.Run(ctx)
+				if err != nil {
+					log.Error().Err(err).Msg("Failed to send reminders")
+				}
+			}
+		}
+	}()
+}
+
+// Run sends every reminder that has become due within the catch-up window and has not been delivered yet. Nothing is
+// sent if another replica holds the lease.
+//
+// Failed deliveries are logged rather than returned so that one failing notifier or task does not hold up the rest.
+func (s *Scheduler) Run(ctx context.Context) error {
+	held, err := s.Lease.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	if !held {
+		return nil
+	}
+
+	now := s.Now()
+	from := now.Add(-s.CatchUp)
+
+	// Reminders are sent ahead of the due date, so tasks that are due well after now may already need one
+	afterDue, afterID := from, ""
+	to := now.Add(task.MaxReminderOffset)
+	for {
+		ts, err := s.Tasks.ListDue(ctx, afterDue, afterID, to, s.BatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, t := range ts {
+			for _, offset := range s.offsets(t) {
+				r := New(t, offset)
+				if r.DateRemind.After(from) && !r.DateRemind.After(now) {
+					s.deliver(ctx, r)
+				}
+			}
+		}
+
+		if len(ts) == 0 || len(ts) < s.BatchSize {
+			return nil
+		}
+
+		last := ts[len(ts)-1]
+		afterDue, afterID = *last.DateDue, last.ID
+	}
+}
+
+// offsets returns how long before the task's due date to remind its owner about it.
+func (s *Scheduler) offsets(t task.Task) []time.Duration {
+	if len(t.ReminderOffsets) > 0 {
+		return t.ReminderOffsets
+	}
+
+	return s.DefaultOffsets
+}
+
+// deliver sends the reminder through every notifier that has not delivered it yet.
+func (s *Scheduler) deliver(ctx context.Context, r Reminder) {
+	// Sorted so that notifiers are always attempted in the same order
+	names := make([]string, 0, len(s.Notifiers))
+	for name := range s.Notifiers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		logger := log.With().Str("notifier", name).Str("reminder", r.ID).Str("task", r.TaskID).Logger()
+		key := "reminder." + name + "." + r.ID
+
+		// Reservations outlive the notifier's timeout so that a slow delivery is not attempted twice
+		reserved, err := s.Deliveries.Reserve(ctx, key, 2*s.Timeout)
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to reserve reminder delivery")
+			continue
+		}
+		if !reserved {
+			continue
+		}
+
+		notifyCtx, cancel := context.WithTimeout(ctx, s.Timeout)
+		err = s.Notifiers[name].Notify(notifyCtx, r)
+		cancel()
+		if err != nil {
+			logger.Warn().Err(err).Msg("Failed to deliver reminder, will retry")
+
+			err = s.Deliveries.Release(ctx, key)
+			if err != nil {
+				logger.Error().Err(err).Msg("Failed to release reminder delivery")
+			}
+			continue
+		}
+
+		// Delivery records only need to last as long as the reminder can be sent
+		err = s.Deliveries.Complete(ctx, key, 2*s.CatchUp)
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to record reminder delivery")
+		}
+	}
+}
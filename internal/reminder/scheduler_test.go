@@ -0,0 +1,395 @@
+package reminder_test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jaredpetersen/go-rest-template/internal/reminder"
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a clock that only moves when it is told to
+type fakeClock struct {
+	now time.Time
+}
+
+func (fc *fakeClock) Now() time.Time {
+	return fc.now
+}
+
+func (fc *fakeClock) Advance(d time.Duration) {
+	fc.now = fc.now.Add(d)
+}
+
+// fakeNotifier records the reminders that it is asked to deliver, failing while it has failures left
+type fakeNotifier struct {
+	mtx       sync.Mutex
+	failures  int
+	attempts  []reminder.Reminder
+	delivered []reminder.Reminder
+}
+
+func (fn *fakeNotifier) Notify(ctx context.Context, r reminder.Reminder) error {
+	fn.mtx.Lock()
+	defer fn.mtx.Unlock()
+
+	fn.attempts = append(fn.attempts, r)
+	if fn.failures > 0 {
+		fn.failures--
+		return errors.New("delivery failed")
+	}
+
+	fn.delivered = append(fn.delivered, r)
+	return nil
+}
+
+// fakeTaskLister pages through tasks in memory like task.DBRepo.ListDue
+type fakeTaskLister struct {
+	tasks []task.Task
+	calls int
+}
+
+func (ftl *fakeTaskLister) ListDue(ctx context.Context, afterDue time.Time, afterID string, to time.Time, limit int) ([]task.Task, error) {
+	ftl.calls++
+
+	sort.Slice(ftl.tasks, func(i, j int) bool {
+		if ftl.tasks[i].DateDue.Equal(*ftl.tasks[j].DateDue) {
+			return ftl.tasks[i].ID < ftl.tasks[j].ID
+		}
+		return ftl.tasks[i].DateDue.Before(*ftl.tasks[j].DateDue)
+	})
+
+	ts := []task.Task{}
+	for _, t := range ftl.tasks {
+		if t.Completed() || t.DateDue == nil || t.DateDue.After(to) {
+			continue
+		}
+		if t.DateDue.Before(afterDue) || (t.DateDue.Equal(afterDue) && t.ID <= afterID) {
+			continue
+		}
+		if len(ts) == limit {
+			break
+		}
+		ts = append(ts, t)
+	}
+
+	return ts, nil
+}
+
+// fakeLease is a lease that is either held or not
+type fakeLease struct {
+	held     bool
+	err      error
+	released bool
+}
+
+func (fl *fakeLease) Acquire(ctx context.Context) (bool, error) {
+	return fl.held, fl.err
+}
+
+func (fl *fakeLease) Release(ctx context.Context) error {
+	fl.released = true
+	return nil
+}
+
+// memoryDeliveryStore records deliveries in memory, ignoring expiration
+type memoryDeliveryStore struct {
+	mtx     sync.Mutex
+	records map[string]string
+}
+
+func newMemoryDeliveryStore() *memoryDeliveryStore {
+	return &memoryDeliveryStore{records: make(map[string]string)}
+}
+
+func (mds *memoryDeliveryStore) Reserve(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	mds.mtx.Lock()
+	defer mds.mtx.Unlock()
+
+	if _, ok := mds.records[key]; ok {
+		return false, nil
+	}
+	mds.records[key] = "reserved"
+	return true, nil
+}
+
+func (mds *memoryDeliveryStore) Complete(ctx context.Context, key string, ttl time.Duration) error {
+	mds.mtx.Lock()
+	defer mds.mtx.Unlock()
+
+	mds.records[key] = "delivered"
+	return nil
+}
+
+func (mds *memoryDeliveryStore) Release(ctx context.Context, key string) error {
+	mds.mtx.Lock()
+	defer mds.mtx.Unlock()
+
+	delete(mds.records, key)
+	return nil
+}
+
+func buildTask(id string, dateDue time.Time, offsets ...time.Duration) task.Task {
+	return task.Task{
+		ID:              id,
+		TenantID:        "tenant-1",
+		OwnerID:         "user-1",
+		Description:     "Buy milk",
+		DateDue:         &dateDue,
+		ReminderOffsets: offsets,
+	}
+}
+
+func buildScheduler(clock *fakeClock, tasks *fakeTaskLister, notifier *fakeNotifier) *reminder.Scheduler {
+	s := reminder.NewScheduler()
+	s.Now = clock.Now
+	s.Tasks = tasks
+	s.Lease = &fakeLease{held: true}
+	s.Deliveries = newMemoryDeliveryStore()
+	s.Notifiers["fake"] = notifier
+	return s
+}
+
+func TestSchedulerRunSendsOverdueReminder(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)}
+
+	tasks := &fakeTaskLister{tasks: []task.Task{
+		buildTask("overdue", clock.now.Add(-time.Minute)),
+		buildTask("later", clock.now.Add(time.Minute)),
+	}}
+	notifier := &fakeNotifier{}
+	s := buildScheduler(clock, tasks, notifier)
+
+	err := s.Run(ctx)
+	require.NoError(t, err)
+
+	require.Len(t, notifier.delivered, 1)
+	r := notifier.delivered[0]
+	assert.Equal(t, "overdue", r.TaskID)
+	assert.Equal(t, "tenant-1", r.TenantID)
+	assert.Equal(t, "user-1", r.OwnerID)
+	assert.Equal(t, "Buy milk", r.Description)
+	assert.True(t, r.Overdue())
+	assert.NotEmpty(t, r.ID)
+
+	// The other task becomes overdue once the clock passes its due date
+	clock.Advance(2 * time.Minute)
+	err = s.Run(ctx)
+	require.NoError(t, err)
+
+	require.Len(t, notifier.delivered, 2)
+	assert.Equal(t, "later", notifier.delivered[1].TaskID)
+}
+
+func TestSchedulerRunSendsReminderOffsets(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)}
+
+	dateDue := clock.now.Add(24 * time.Hour)
+	tasks := &fakeTaskLister{tasks: []task.Task{buildTask("sometask", dateDue, 24*time.Hour, time.Hour)}}
+	notifier := &fakeNotifier{}
+	s := buildScheduler(clock, tasks, notifier)
+
+	err := s.Run(ctx)
+	require.NoError(t, err)
+	require.Len(t, notifier.delivered, 1, "Did not send the day-ahead reminder")
+	assert.Equal(t, clock.now, notifier.delivered[0].DateRemind)
+	assert.False(t, notifier.delivered[0].Overdue())
+
+	clock.Advance(22 * time.Hour)
+	err = s.Run(ctx)
+	require.NoError(t, err)
+	assert.Len(t, notifier.delivered, 1, "Sent the hour-ahead reminder early")
+
+	clock.Advance(time.Hour)
+	err = s.Run(ctx)
+	require.NoError(t, err)
+	require.Len(t, notifier.delivered, 2, "Did not send the hour-ahead reminder")
+	assert.Equal(t, dateDue.Add(-time.Hour), notifier.delivered[1].DateRemind)
+
+	// Tasks with their own offsets are not reminded when they become overdue
+	clock.Advance(2 * time.Hour)
+	err = s.Run(ctx)
+	require.NoError(t, err)
+	assert.Len(t, notifier.delivered, 2)
+}
+
+func TestSchedulerRunIsIdempotent(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)}
+
+	tasks := &fakeTaskLister{tasks: []task.Task{buildTask("sometask", clock.now.Add(-time.Minute))}}
+	notifier := &fakeNotifier{}
+	s := buildScheduler(clock, tasks, notifier)
+
+	for i := 0; i < 3; i++ {
+		err := s.Run(ctx)
+		require.NoError(t, err)
+		clock.Advance(time.Minute)
+	}
+
+	assert.Len(t, notifier.attempts, 1, "Reminder was delivered more than once")
+}
+
+func TestSchedulerRunSharesDeliveriesAcrossReplicas(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)}
+
+	tasks := &fakeTaskLister{tasks: []task.Task{buildTask("sometask", clock.now.Add(-time.Minute))}}
+	notifier := &fakeNotifier{}
+
+	// Both replicas believe that they hold the lease, e.g. right after it changed hands
+	deliveries := newMemoryDeliveryStore()
+	first := buildScheduler(clock, tasks, notifier)
+	first.Deliveries = deliveries
+	second := buildScheduler(clock, tasks, notifier)
+	second.Deliveries = deliveries
+
+	require.NoError(t, first.Run(ctx))
+	require.NoError(t, second.Run(ctx))
+
+	assert.Len(t, notifier.attempts, 1, "Reminder was delivered by both replicas")
+}
+
+func TestSchedulerRunRetriesFailedDelivery(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)}
+
+	tasks := &fakeTaskLister{tasks: []task.Task{buildTask("sometask", clock.now.Add(-time.Minute))}}
+	notifier := &fakeNotifier{failures: 2}
+	s := buildScheduler(clock, tasks, notifier)
+
+	for i := 0; i < 4; i++ {
+		err := s.Run(ctx)
+		require.NoError(t, err)
+		clock.Advance(time.Minute)
+	}
+
+	assert.Len(t, notifier.attempts, 3, "Delivery was not retried until it succeeded")
+	assert.Len(t, notifier.delivered, 1)
+}
+
+func TestSchedulerRunGivesUpAfterCatchUp(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)}
+
+	tasks := &fakeTaskLister{tasks: []task.Task{buildTask("sometask", clock.now.Add(-time.Minute))}}
+	notifier := &fakeNotifier{failures: 100}
+	s := buildScheduler(clock, tasks, notifier)
+	s.CatchUp = 10 * time.Minute
+
+	for i := 0; i < 20; i++ {
+		err := s.Run(ctx)
+		require.NoError(t, err)
+		clock.Advance(time.Minute)
+	}
+
+	assert.Len(t, notifier.attempts, 9, "Delivery was retried after the catch-up window")
+	assert.Empty(t, notifier.delivered)
+}
+
+func TestSchedulerRunKeepsNotifiersIndependent(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)}
+
+	tasks := &fakeTaskLister{tasks: []task.Task{buildTask("sometask", clock.now.Add(-time.Minute))}}
+	healthy := &fakeNotifier{}
+	failing := &fakeNotifier{failures: 1}
+	s := buildScheduler(clock, tasks, healthy)
+	s.Notifiers["failing"] = failing
+
+	require.NoError(t, s.Run(ctx))
+	clock.Advance(time.Minute)
+	require.NoError(t, s.Run(ctx))
+
+	assert.Len(t, healthy.attempts, 1, "Healthy notifier was retried along with the failing notifier")
+	assert.Len(t, failing.attempts, 2)
+	assert.Len(t, failing.delivered, 1)
+}
+
+func TestSchedulerRunWithoutLease(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)}
+
+	tasks := &fakeTaskLister{tasks: []task.Task{buildTask("sometask", clock.now.Add(-time.Minute))}}
+	notifier := &fakeNotifier{}
+	s := buildScheduler(clock, tasks, notifier)
+	s.Lease = &fakeLease{held: false}
+
+	err := s.Run(ctx)
+	require.NoError(t, err)
+
+	assert.Empty(t, notifier.attempts)
+	assert.Zero(t, tasks.calls, "Listed tasks without holding the lease")
+}
+
+func TestSchedulerRunLeaseError(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)}
+
+	tasks := &fakeTaskLister{tasks: []task.Task{buildTask("sometask", clock.now.Add(-time.Minute))}}
+	notifier := &fakeNotifier{}
+	s := buildScheduler(clock, tasks, notifier)
+	s.Lease = &fakeLease{err: errors.New("redis unavailable")}
+
+	err := s.Run(ctx)
+	assert.Error(t, err)
+	assert.Empty(t, notifier.attempts, "Sent reminders without a lease")
+}
+
+func TestSchedulerRunPagesThroughTasks(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)}
+
+	// Tasks that are due at the same time must not be skipped between pages
+	dateDue := clock.now.Add(-time.Minute)
+	tasks := &fakeTaskLister{}
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		tasks.tasks = append(tasks.tasks, buildTask(id, dateDue))
+	}
+	notifier := &fakeNotifier{}
+	s := buildScheduler(clock, tasks, notifier)
+	s.BatchSize = 2
+
+	err := s.Run(ctx)
+	require.NoError(t, err)
+
+	assert.Len(t, notifier.delivered, 5)
+	assert.Equal(t, 3, tasks.calls)
+}
+
+func TestSchedulerRunSkipsCompletedTasks(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)}
+
+	completed := buildTask("sometask", clock.now.Add(-time.Minute))
+	dateCompleted := clock.now.Add(-time.Hour)
+	completed.DateCompleted = &dateCompleted
+	tasks := &fakeTaskLister{tasks: []task.Task{completed}}
+	notifier := &fakeNotifier{}
+	s := buildScheduler(clock, tasks, notifier)
+
+	err := s.Run(ctx)
+	require.NoError(t, err)
+
+	assert.Empty(t, notifier.attempts)
+}
+
+func TestNewReminderID(t *testing.T) {
+	dateDue := time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+
+	r := reminder.New(buildTask("sometask", dateDue), time.Hour)
+	assert.Equal(t, r.ID, reminder.New(buildTask("sometask", dateDue), time.Hour).ID, "ID is not stable")
+	assert.NotEqual(t, r.ID, reminder.New(buildTask("sometask", dateDue), 0).ID, "ID does not depend on the offset")
+	assert.NotEqual(t, r.ID, reminder.New(buildTask("sometask", dateDue.Add(time.Hour)), time.Hour).ID,
+		"ID does not depend on the due date")
+	assert.NotEqual(t, r.ID, reminder.New(buildTask("othertask", dateDue), time.Hour).ID, "ID does not depend on the task")
+	assert.Equal(t, dateDue.Add(-time.Hour), r.DateRemind)
+}
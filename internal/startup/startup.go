@@ -0,0 +1,89 @@
+// Package startup coordinates the initialization work that must be completed before the application can serve traffic.
+package startup
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// StepFunc is a function that performs a unit of initialization work.
+type StepFunc func(ctx context.Context) error
+
+// Step is a unit of initialization work. Steps are retried until they succeed or the gate times out.
+type Step struct {
+	// Name of the step. Used for logging.
+	Name string
+	// Func performs the initialization work. It is your responsibility to ensure that this function respects the
+	// provided context so that the logic may be terminated early.
+	Func StepFunc
+}
+
+// Gate runs the startup steps and keeps track of whether or not initialization has completed.
+type Gate struct {
+	// Timeout is the max time that all of the steps may take to complete before initialization is considered failed.
+	Timeout time.Duration
+	// RetryInterval is the time that should be waited on between attempts of a failed step.
+	RetryInterval time.Duration
+	// complete is set to 1 once all of the steps have succeeded. Accessed atomically.
+	complete int32
+}
+
+// New creates a startup gate with suitable default values. The returned pointer will never be nil.
+//
+// Timeout is set to a duration of 1 minute and RetryInterval is set to a duration of 1 second.
+func New() *Gate {
+	return &Gate{
+		Timeout:       time.Minute,
+		RetryInterval: time.Second,
+	}
+}
+
+// Run executes the steps in order, retrying each one until it succeeds. The gate is marked complete once all of the
+// steps have succeeded. An error is returned if the steps do not complete within the configured timeout.
+func (g *Gate) Run(ctx context.Context, steps ...Step) error {
+	if g.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, g.Timeout)
+		defer cancel()
+	}
+
+	for _, step := range steps {
+		err := g.runStep(ctx, step)
+		if err != nil {
+			return err
+		}
+	}
+
+	atomic.StoreInt32(&g.complete, 1)
+	log.Info().Msg("Startup complete")
+
+	return nil
+}
+
+// Complete indicates whether or not all of the startup steps have succeeded.
+func (g *Gate) Complete() bool {
+	return atomic.LoadInt32(&g.complete) == 1
+}
+
+// runStep executes a single step until it succeeds or the context is done.
+func (g *Gate) runStep(ctx context.Context, step Step) error {
+	for attempt := 1; ; attempt++ {
+		err := step.Func(ctx)
+		if err == nil {
+			log.Info().Str("step", step.Name).Int("attempt", attempt).Msg("Startup step complete")
+			return nil
+		}
+
+		log.Warn().Err(err).Str("step", step.Name).Int("attempt", attempt).Msg("Startup step failed")
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("startup step %s did not complete: %w", step.Name, err)
+		case <-time.After(g.RetryInterval):
+		}
+	}
+}
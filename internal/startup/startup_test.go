@@ -0,0 +1,76 @@
+package startup_test
+
+import (
+	"context"
+	"errors"
+	"github.com/jaredpetersen/go-rest-template/internal/startup"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	gate := startup.New()
+
+	assert.Equal(t, time.Minute, gate.Timeout, "Incorrect default timeout")
+	assert.Equal(t, time.Second, gate.RetryInterval, "Incorrect default retry interval")
+	assert.False(t, gate.Complete(), "Gate is complete before running")
+}
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+
+	var executed []string
+	buildStep := func(name string) startup.Step {
+		return startup.Step{Name: name, Func: func(ctx context.Context) error {
+			executed = append(executed, name)
+			return nil
+		}}
+	}
+
+	gate := startup.New()
+
+	err := gate.Run(ctx, buildStep("database"), buildStep("cache"))
+	assert.NoError(t, err, "Returned error")
+	assert.True(t, gate.Complete(), "Gate is not complete")
+	assert.Equal(t, []string{"database", "cache"}, executed, "Steps were not executed in order")
+}
+
+func TestRunRetriesFailedStep(t *testing.T) {
+	ctx := context.Background()
+
+	attempts := 0
+	step := startup.Step{Name: "database", Func: func(ctx context.Context) error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("not yet")
+		}
+		return nil
+	}}
+
+	gate := startup.New()
+	gate.RetryInterval = time.Millisecond
+
+	err := gate.Run(ctx, step)
+	assert.NoError(t, err, "Returned error")
+	assert.True(t, gate.Complete(), "Gate is not complete")
+	assert.Equal(t, 3, attempts, "Incorrect number of attempts")
+}
+
+func TestRunTimeout(t *testing.T) {
+	ctx := context.Background()
+
+	stepErr := errors.New("database unavailable")
+	step := startup.Step{Name: "database", Func: func(ctx context.Context) error {
+		return stepErr
+	}}
+
+	gate := startup.New()
+	gate.Timeout = time.Millisecond * 50
+	gate.RetryInterval = time.Millisecond * 10
+
+	err := gate.Run(ctx, step)
+	assert.ErrorIs(t, err, stepErr, "Incorrect error")
+	assert.False(t, gate.Complete(), "Gate is complete")
+}
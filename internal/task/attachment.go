@@ -0,0 +1,43 @@
+package task
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrTooManyAttachments indicates that a file could not be attached to a task because the task already has
+// MaxAttachments files attached to it.
+var ErrTooManyAttachments = errors.New("task has too many attachments")
+
+// MaxAttachments is the most files that may be attached to a task.
+const MaxAttachments = 100
+
+// Attachment describes a file that a principal attached to a task. The content of the file is kept in blob storage
+// under BlobKey rather than in the database.
+type Attachment struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenantId"`
+	TaskID   string `json:"taskId"`
+	// Name is the file name that the file was uploaded with, without any directories.
+	Name string `json:"name"`
+	// ContentType is sniffed from the content of the file rather than trusted from the upload.
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+	// Checksum is the hex encoded SHA-256 digest of the content.
+	Checksum    string    `json:"checksum"`
+	UploaderID  string    `json:"uploaderId"`
+	DateCreated time.Time `json:"dateCreated"`
+}
+
+// NewAttachment creates a new attachment with default values. The returned pointer will never be nil.
+func NewAttachment() *Attachment {
+	return &Attachment{ID: uuid.New().String(), DateCreated: time.Now()}
+}
+
+// BlobKey is the key that the content of the attachment is stored under in blob storage. Attachment IDs are unique
+// across tenants, so the key is based on the ID alone.
+func (a Attachment) BlobKey() string {
+	return "attachments/" + a.ID
+}
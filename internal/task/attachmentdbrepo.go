@@ -0,0 +1,125 @@
+package task
+
+import (
+	"context"
+	"database/sql"
+)
+
+// AttachmentDBClient is a client for retrieving and manipulating the metadata of the files attached to tasks in a SQL
+// database
+type AttachmentDBClient interface {
+	Get(ctx context.Context, tenantID string, taskID string, id string) (*Attachment, error)
+	List(ctx context.Context, tenantID string, taskID string) ([]Attachment, error)
+	Save(ctx context.Context, a Attachment) error
+	Delete(ctx context.Context, a Attachment) error
+}
+
+// AttachmentDBRepo is a database repository for the metadata of the files attached to tasks.
+type AttachmentDBRepo struct {
+	DB *sql.DB
+}
+
+// Get retrieves the metadata of a file attached to a tenant's task from the database using the attachment's ID. If an
+// attachment cannot be found with that ID on the task, nil will be returned for both the attachment and error.
+func (dbr AttachmentDBRepo) Get(ctx context.Context, tenantID string, taskID string, id string) (*Attachment, error) {
+	const query = `select name, content_type, size, checksum, uploader_id, date_created
+		from task_attachment
+		where tenant_id = $1 and task_id = $2 and id = $3`
+	row := dbr.DB.QueryRowContext(ctx, query, tenantID, taskID, id)
+
+	a := Attachment{ID: id, TenantID: tenantID, TaskID: taskID}
+	err := row.Scan(&a.Name, &a.ContentType, &a.Size, &a.Checksum, &a.UploaderID, &a.DateCreated)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &a, nil
+}
+
+// List retrieves the metadata of all of the files attached to a tenant's task from the database, oldest first. Tasks
+// have at most MaxAttachments attachments so they are not paged.
+func (dbr AttachmentDBRepo) List(ctx context.Context, tenantID string, taskID string) ([]Attachment, error) {
+	const query = `select id, name, content_type, size, checksum, uploader_id, date_created
+		from task_attachment
+		where tenant_id = $1 and task_id = $2
+		order by date_created, id`
+	rows, err := dbr.DB.QueryContext(ctx, query, tenantID, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	as := []Attachment{}
+	for rows.Next() {
+		a := Attachment{TenantID: tenantID, TaskID: taskID}
+		err = rows.Scan(&a.ID, &a.Name, &a.ContentType, &a.Size, &a.Checksum, &a.UploaderID, &a.DateCreated)
+		if err != nil {
+			return nil, err
+		}
+
+		as = append(as, a)
+	}
+
+	return as, rows.Err()
+}
+
+// Save stores the metadata of a file attached to a task in the database. ErrTaskUnavailable is returned if the task
+// does not exist and ErrTooManyAttachments if the task already has MaxAttachments attachments. Transactions are
+// serializable so concurrent uploads cannot exceed the limit together.
+func (dbr AttachmentDBRepo) Save(ctx context.Context, a Attachment) error {
+	tx, err := dbr.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	const taskQuery = `select exists (select 1 from task where tenant_id = $1 and id = $2)`
+	var taskExists bool
+	err = tx.QueryRowContext(ctx, taskQuery, a.TenantID, a.TaskID).Scan(&taskExists)
+	if err != nil {
+		return err
+	}
+	if !taskExists {
+		return ErrTaskUnavailable
+	}
+
+	const countQuery = `select count(*) from task_attachment where tenant_id = $1 and task_id = $2`
+	var count int
+	err = tx.QueryRowContext(ctx, countQuery, a.TenantID, a.TaskID).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count >= MaxAttachments {
+		return ErrTooManyAttachments
+	}
+
+	const query = `insert into task_attachment
+		(id, tenant_id, task_id, name, content_type, size, checksum, uploader_id, date_created)
+		values ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+	_, err = tx.ExecContext(ctx, query,
+		a.ID,
+		a.TenantID,
+		a.TaskID,
+		a.Name,
+		a.ContentType,
+		a.Size,
+		a.Checksum,
+		a.UploaderID,
+		a.DateCreated)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Delete removes the metadata of a file attached to a task from the database. Deleting an attachment that does not
+// exist does nothing.
+func (dbr AttachmentDBRepo) Delete(ctx context.Context, a Attachment) error {
+	const query = `delete from task_attachment where tenant_id = $1 and task_id = $2 and id = $3`
+	_, err := dbr.DB.ExecContext(ctx, query, a.TenantID, a.TaskID, a.ID)
+	return err
+}
@@ -0,0 +1,134 @@
+package task_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntegrationAttachmentDBRepo(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+
+	cdbContainer, err := setupCockroachDB(ctx)
+	require.NoError(t, err, "Failed to start up CockroachDB container")
+	defer cdbContainer.Terminate(ctx)
+
+	db, err := sql.Open("pgx", cdbContainer.URI+"/projectmanagement")
+	require.NoError(t, err, "Failed to open connection to CockroachDB")
+	defer db.Close()
+
+	err = initCockroachDB(ctx, db)
+	require.NoError(t, err, "Failed to initialize CockroachDB")
+	defer truncateCockroachDB(ctx, db)
+
+	adbr := task.AttachmentDBRepo{DB: db}
+	tdbr := task.DBRepo{DB: db}
+
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+	tsk.Description = "Plan picnic"
+	err = tdbr.Save(ctx, *tsk)
+	require.NoError(t, err, "Save returned error")
+
+	first := task.NewAttachment()
+	first.TenantID = "tenant-1"
+	first.TaskID = tsk.ID
+	first.Name = "map.png"
+	first.ContentType = "image/png"
+	first.Size = 2048
+	first.Checksum = "3f6cd0c2e0d6bf2b1e6f1bd4a8a2f3c09a1ee4f5d1bdf68e1e1c2c3d4e5f6a7b"
+	first.UploaderID = "user-1"
+	err = adbr.Save(ctx, *first)
+	require.NoError(t, err, "Save returned error")
+
+	second := task.NewAttachment()
+	second.TenantID = "tenant-1"
+	second.TaskID = tsk.ID
+	second.Name = "menu.txt"
+	second.ContentType = "text/plain; charset=utf-8"
+	second.Size = 12
+	second.Checksum = "9c2f4a1b0e3d5c7f8a6b4d2e0f1a3c5b7d9e8f6a4c2b0d1e3f5a7c9b8d6e4f2a"
+	second.UploaderID = "user-2"
+	second.DateCreated = first.DateCreated.Add(time.Second)
+	err = adbr.Save(ctx, *second)
+	require.NoError(t, err, "Save returned error")
+
+	a, err := adbr.Get(ctx, "tenant-1", tsk.ID, first.ID)
+	require.NoError(t, err, "Get returned error")
+	require.NotNil(t, a, "Did not return attachment")
+	assert.Equal(t, "map.png", a.Name, "Incorrect name")
+	assert.Equal(t, "image/png", a.ContentType, "Incorrect content type")
+	assert.Equal(t, int64(2048), a.Size, "Incorrect size")
+	assert.Equal(t, first.Checksum, a.Checksum, "Incorrect checksum")
+	assert.Equal(t, "user-1", a.UploaderID, "Incorrect uploader")
+
+	as, err := adbr.List(ctx, "tenant-1", tsk.ID)
+	require.NoError(t, err, "List returned error")
+	require.Len(t, as, 2, "Returned incorrect number of attachments")
+	assert.Equal(t, first.ID, as[0].ID, "Did not return oldest attachment first")
+	assert.Equal(t, second.ID, as[1].ID, "Did not return newest attachment last")
+
+	// Attachments are scoped to the tenant
+	a, err = adbr.Get(ctx, "tenant-2", tsk.ID, first.ID)
+	require.NoError(t, err, "Get returned error")
+	assert.Nil(t, a, "Returned another tenant's attachment")
+
+	err = adbr.Delete(ctx, *second)
+	require.NoError(t, err, "Delete returned error")
+
+	a, err = adbr.Get(ctx, "tenant-1", tsk.ID, second.ID)
+	require.NoError(t, err, "Get returned error")
+	assert.Nil(t, a, "Did not delete attachment")
+
+	// Files cannot be attached to tasks that do not exist
+	orphan := task.NewAttachment()
+	orphan.TenantID = "tenant-1"
+	orphan.TaskID = uuid.New().String()
+	orphan.Name = "orphan.txt"
+	orphan.ContentType = "text/plain; charset=utf-8"
+	orphan.UploaderID = "user-1"
+	err = adbr.Save(ctx, *orphan)
+	assert.ErrorIs(t, err, task.ErrTaskUnavailable)
+
+	// Tasks are limited in how many files may be attached to them
+	for i := 1; i < task.MaxAttachments; i++ {
+		extra := task.NewAttachment()
+		extra.TenantID = "tenant-1"
+		extra.TaskID = tsk.ID
+		extra.Name = "extra.txt"
+		extra.ContentType = "text/plain; charset=utf-8"
+		extra.UploaderID = "user-1"
+		err = adbr.Save(ctx, *extra)
+		require.NoError(t, err, "Save returned error")
+	}
+
+	tooMany := task.NewAttachment()
+	tooMany.TenantID = "tenant-1"
+	tooMany.TaskID = tsk.ID
+	tooMany.Name = "too-many.txt"
+	tooMany.ContentType = "text/plain; charset=utf-8"
+	tooMany.UploaderID = "user-1"
+	err = adbr.Save(ctx, *tooMany)
+	assert.ErrorIs(t, err, task.ErrTooManyAttachments)
+
+	// Attachments go away along with their task
+	storedTsk, err := tdbr.Get(ctx, "tenant-1", tsk.ID)
+	require.NoError(t, err, "Get returned error")
+	err = tdbr.Delete(ctx, *storedTsk)
+	require.NoError(t, err, "Delete returned error")
+
+	as, err = adbr.List(ctx, "tenant-1", tsk.ID)
+	require.NoError(t, err, "List returned error")
+	assert.Empty(t, as, "Did not delete attachments along with task")
+}
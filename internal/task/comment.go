@@ -0,0 +1,45 @@
+package task
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrCommentVersionConflict indicates that a comment could not be changed because it is no longer at the version that
+// the change was based on.
+var ErrCommentVersionConflict = errors.New("comment version conflict")
+
+// ErrTaskUnavailable indicates that a comment could not be stored because its task does not exist.
+var ErrTaskUnavailable = errors.New("task does not exist")
+
+// MaxCommentLength is the most characters that the body of a comment may have.
+const MaxCommentLength = 10000
+
+// Comment is a note that a principal left on a task. Bodies are Markdown and are stored as they were written, so they
+// must be sanitized when they are rendered.
+type Comment struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenantId"`
+	TaskID   string `json:"taskId"`
+	// AuthorID is the subject of the principal that wrote the comment. Only the author may edit it.
+	AuthorID    string    `json:"authorId"`
+	Body        string    `json:"body"`
+	DateCreated time.Time `json:"dateCreated"`
+	DateUpdated time.Time `json:"dateUpdated"`
+	Version     int       `json:"version"`
+}
+
+// NewComment creates a new comment with default values. The returned pointer will never be nil.
+func NewComment() *Comment {
+	now := time.Now()
+	return &Comment{ID: uuid.New().String(), DateCreated: now, DateUpdated: now, Version: 1}
+}
+
+// CommentPage is a page of a task's comments, oldest first.
+type CommentPage struct {
+	Comments []Comment `json:"comments"`
+	Limit    int       `json:"limit"`
+	Offset   int       `json:"offset"`
+}
@@ -0,0 +1,151 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jaredpetersen/go-rest-template/internal/redis"
+)
+
+// CommentCacheClient is a client for retrieving and manipulating pages of the comments on tasks in the cache
+type CommentCacheClient interface {
+	GetPage(ctx context.Context, tenantID string, taskID string, limit int, offset int) (*CommentPage, int64, error)
+	SavePage(ctx context.Context, tenantID string, taskID string, p CommentPage, generation int64) error
+	Forget(ctx context.Context, tenantID string, taskID string) error
+}
+
+// getPageScript retrieves a page of a task's comments along with the generation of the task's comments.
+//
+// The pages of each task's comments are kept together in a hash so that they can be forgotten together, along with a
+// generation that is incremented every time that they are forgotten.
+//
+// KEYS[1] - key of the hash
+// ARGV[1] - page field
+//
+// Returns the generation and the page JSON, or nil if the page is not cached
+const getPageScript = `
+local generation = redis.call('HGET', KEYS[1], 'generation') or '0'
+return {generation, redis.call('HGET', KEYS[1], ARGV[1])}
+`
+
+// savePageScript stores a page of a task's comments unless the comments have been forgotten since the page was read
+// from the database, so that a slow reader cannot store a page that is missing the latest changes.
+//
+// KEYS[1] - key of the hash
+// ARGV[1] - page field
+// ARGV[2] - page JSON
+// ARGV[3] - generation that the page was read at
+// ARGV[4] - TTL in milliseconds
+//
+// Returns 1 if the page was stored and 0 if it was outdated
+const savePageScript = `
+local generation = redis.call('HGET', KEYS[1], 'generation') or '0'
+if generation ~= ARGV[3] then
+  return 0
+end
+
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[2])
+redis.call('PEXPIRE', KEYS[1], ARGV[4])
+return 1
+`
+
+// forgetScript removes every page of a task's comments and increments the generation.
+//
+// KEYS[1] - key of the hash
+// ARGV[1] - TTL in milliseconds
+//
+// Returns the new generation
+const forgetScript = `
+local generation = redis.call('HINCRBY', KEYS[1], 'generation', 1)
+redis.call('DEL', KEYS[1])
+redis.call('HSET', KEYS[1], 'generation', generation)
+redis.call('PEXPIRE', KEYS[1], ARGV[1])
+return generation
+`
+
+// commentPageTTL is how long pages of comments are cached for. Pages are forgotten as soon as the comments change, so
+// this only bounds how long pages that are no longer read take up memory.
+const commentPageTTL = time.Hour
+
+// CommentCacheRepo is a cache repository for pages of the comments on tasks.
+type CommentCacheRepo struct {
+	Redis redis.Client
+}
+
+// GetPage retrieves a page of the comments on a tenant's task from the cache, along with the generation of the task's
+// comments that must be passed to SavePage when the page is not cached. If the page is not cached, nil will be returned
+// for the page.
+func (cr CommentCacheRepo) GetPage(ctx context.Context, tenantID string, taskID string, limit int, offset int) (*CommentPage, int64, error) {
+	raw, err := cr.Redis.Eval(ctx, getPageScript, []string{getCommentRedisKey(tenantID, taskID)}, pageField(limit, offset))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	values, ok := raw.([]interface{})
+	if !ok || len(values) == 0 {
+		return nil, 0, fmt.Errorf("unexpected comment page script result %v", raw)
+	}
+
+	rawGeneration, ok := values[0].(string)
+	if !ok {
+		return nil, 0, fmt.Errorf("unexpected comment page script result %v", raw)
+	}
+
+	generation, err := strconv.ParseInt(rawGeneration, 10, 64)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Redis drops trailing nils from the result
+	if len(values) < 2 || values[1] == nil {
+		return nil, generation, nil
+	}
+
+	value, ok := values[1].(string)
+	if !ok {
+		return nil, 0, fmt.Errorf("unexpected comment page script result %v", raw)
+	}
+
+	var p CommentPage
+	err = json.Unmarshal([]byte(value), &p)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return &p, generation, nil
+}
+
+// SavePage stores a page of the comments on a tenant's task in the cache. The page is not stored if the task's comments
+// have been forgotten since the generation was retrieved with GetPage.
+func (cr CommentCacheRepo) SavePage(ctx context.Context, tenantID string, taskID string, p CommentPage, generation int64) error {
+	value, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	key := getCommentRedisKey(tenantID, taskID)
+	args := []interface{}{pageField(p.Limit, p.Offset), value, strconv.FormatInt(generation, 10), commentPageTTL.Milliseconds()}
+	_, err = cr.Redis.Eval(ctx, savePageScript, []string{key}, args...)
+	return err
+}
+
+// Forget removes every page of the comments on a tenant's task from the cache. Pages that were read from the database
+// before the comments were forgotten are not stored afterwards.
+func (cr CommentCacheRepo) Forget(ctx context.Context, tenantID string, taskID string) error {
+	_, err := cr.Redis.Eval(ctx, forgetScript, []string{getCommentRedisKey(tenantID, taskID)}, commentPageTTL.Milliseconds())
+	return err
+}
+
+// getCommentRedisKey builds the redis key of the hash of pages of the comments on a task in the cache. Keys are
+// namespaced by tenant like tasks are.
+func getCommentRedisKey(tenantID string, taskID string) string {
+	return "tenant." + tenantID + ".task." + taskID + ".comments"
+}
+
+// pageField builds the hash field of a page of comments.
+func pageField(limit int, offset int) string {
+	return "page." + strconv.Itoa(limit) + "." + strconv.Itoa(offset)
+}
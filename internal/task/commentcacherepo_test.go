@@ -0,0 +1,157 @@
+package task_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/jaredpetersen/go-rest-template/internal/redis"
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	redismock "github.com/jaredpetersen/go-rest-template/internal/redis/mocks"
+)
+
+func TestCommentCacheRepoGetPage(t *testing.T) {
+	ctx := context.Background()
+
+	page := task.CommentPage{Comments: []task.Comment{{ID: "commentid", Body: "Bring snacks"}}, Limit: 20, Offset: 40}
+	pageJSON, err := json.Marshal(page)
+	require.NoError(t, err)
+
+	rdb := redismock.Client{}
+	rdb.On("Eval", mock.Anything, mock.AnythingOfType("string"), []string{"tenant.tenant-1.task.someid.comments"}, "page.20.40").
+		Return([]interface{}{"3", string(pageJSON)}, nil)
+
+	ccr := task.CommentCacheRepo{Redis: &rdb}
+
+	cachedPage, generation, err := ccr.GetPage(ctx, "tenant-1", "someid", 20, 40)
+	assert.NoError(t, err, "Returned error")
+	assert.Equal(t, &page, cachedPage, "Returned incorrect page")
+	assert.Equal(t, int64(3), generation, "Returned incorrect generation")
+}
+
+func TestCommentCacheRepoGetPageNotExists(t *testing.T) {
+	ctx := context.Background()
+
+	rdb := redismock.Client{}
+	rdb.On("Eval", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]interface{}{"3", nil}, nil)
+
+	ccr := task.CommentCacheRepo{Redis: &rdb}
+
+	cachedPage, generation, err := ccr.GetPage(ctx, "tenant-1", "someid", 20, 0)
+	assert.NoError(t, err, "Returned error")
+	assert.Nil(t, cachedPage, "Returned page")
+	assert.Equal(t, int64(3), generation, "Returned incorrect generation")
+}
+
+func TestCommentCacheRepoGetPageReturnsRedisError(t *testing.T) {
+	ctx := context.Background()
+
+	expectedErr := errors.New("Failed")
+
+	rdb := redismock.Client{}
+	rdb.On("Eval", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, expectedErr)
+
+	ccr := task.CommentCacheRepo{Redis: &rdb}
+
+	cachedPage, _, err := ccr.GetPage(ctx, "tenant-1", "someid", 20, 0)
+	assert.EqualError(t, err, expectedErr.Error(), "Did not return error")
+	assert.Nil(t, cachedPage, "Returned page")
+}
+
+func TestCommentCacheRepoSavePage(t *testing.T) {
+	ctx := context.Background()
+
+	page := task.CommentPage{Comments: []task.Comment{{ID: "commentid", Body: "Bring snacks"}}, Limit: 20, Offset: 40}
+	pageJSON, err := json.Marshal(page)
+	require.NoError(t, err)
+
+	rdb := redismock.Client{}
+	rdb.On("Eval",
+		mock.Anything,
+		mock.AnythingOfType("string"),
+		[]string{"tenant.tenant-1.task.someid.comments"},
+		"page.20.40",
+		pageJSON,
+		"3",
+		mock.AnythingOfType("int64")).
+		Return(int64(1), nil)
+
+	ccr := task.CommentCacheRepo{Redis: &rdb}
+
+	err = ccr.SavePage(ctx, "tenant-1", "someid", page, 3)
+	assert.NoError(t, err, "Returned error")
+
+	rdb.AssertExpectations(t)
+}
+
+func TestCommentCacheRepoForget(t *testing.T) {
+	ctx := context.Background()
+
+	rdb := redismock.Client{}
+	rdb.On("Eval", mock.Anything, mock.AnythingOfType("string"), []string{"tenant.tenant-1.task.someid.comments"}, mock.AnythingOfType("int64")).
+		Return(int64(4), nil)
+
+	ccr := task.CommentCacheRepo{Redis: &rdb}
+
+	err := ccr.Forget(ctx, "tenant-1", "someid")
+	assert.NoError(t, err, "Returned error")
+
+	rdb.AssertExpectations(t)
+}
+
+func TestIntegrationCommentCacheRepo(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+	redisContainer, err := setupRedis(ctx)
+	require.NoError(t, err, "Failed to start up Redis container")
+	defer redisContainer.Terminate(ctx)
+
+	rdb, err := redis.New(redis.Config{URI: redisContainer.URI})
+	require.NoError(t, err, "Client instantiation error")
+	defer rdb.Close()
+
+	ccr := task.CommentCacheRepo{Redis: rdb}
+
+	page := task.CommentPage{Comments: []task.Comment{{ID: "commentid", Body: "Bring snacks"}}, Limit: 20, Offset: 0}
+
+	cachedPage, generation, err := ccr.GetPage(ctx, "tenant-1", "someid", 20, 0)
+	require.NoError(t, err, "GetPage returned error")
+	assert.Nil(t, cachedPage, "Returned page that was never stored")
+	assert.Equal(t, int64(0), generation, "Returned incorrect generation")
+
+	err = ccr.SavePage(ctx, "tenant-1", "someid", page, generation)
+	require.NoError(t, err, "SavePage returned error")
+
+	cachedPage, _, err = ccr.GetPage(ctx, "tenant-1", "someid", 20, 0)
+	require.NoError(t, err, "GetPage returned error")
+	assert.Equal(t, &page, cachedPage, "Returned incorrect page")
+
+	// Other pages are cached separately
+	cachedPage, _, err = ccr.GetPage(ctx, "tenant-1", "someid", 20, 20)
+	require.NoError(t, err, "GetPage returned error")
+	assert.Nil(t, cachedPage, "Returned page that was never stored")
+
+	err = ccr.Forget(ctx, "tenant-1", "someid")
+	require.NoError(t, err, "Forget returned error")
+
+	cachedPage, newGeneration, err := ccr.GetPage(ctx, "tenant-1", "someid", 20, 0)
+	require.NoError(t, err, "GetPage returned error")
+	assert.Nil(t, cachedPage, "Returned forgotten page")
+	assert.Equal(t, generation+1, newGeneration, "Did not increment generation")
+
+	// Pages that were read before the comments were forgotten are not stored
+	err = ccr.SavePage(ctx, "tenant-1", "someid", page, generation)
+	require.NoError(t, err, "SavePage returned error")
+
+	cachedPage, _, err = ccr.GetPage(ctx, "tenant-1", "someid", 20, 0)
+	require.NoError(t, err, "GetPage returned error")
+	assert.Nil(t, cachedPage, "Stored outdated page")
+}
@@ -0,0 +1,174 @@
+package task
+
+import (
+	"context"
+	"database/sql"
+)
+
+// CommentDBClient is a client for retrieving and manipulating the comments on tasks in a SQL database
+type CommentDBClient interface {
+	Get(ctx context.Context, tenantID string, taskID string, id string) (*Comment, error)
+	List(ctx context.Context, tenantID string, taskID string, limit int, offset int) ([]Comment, error)
+	Save(ctx context.Context, c Comment) error
+	Update(ctx context.Context, c Comment) error
+	Delete(ctx context.Context, c Comment) error
+}
+
+// CommentDBRepo is a database repository for the comments on tasks.
+type CommentDBRepo struct {
+	DB *sql.DB
+}
+
+// Get retrieves a comment on a tenant's task from the database using the comment's ID. If a comment cannot be found
+// with that ID on the task, nil will be returned for both the comment and error.
+func (dbr CommentDBRepo) Get(ctx context.Context, tenantID string, taskID string, id string) (*Comment, error) {
+	const query = `select author_id, body, date_created, date_updated, version
+		from task_comment
+		where tenant_id = $1 and task_id = $2 and id = $3`
+	row := dbr.DB.QueryRowContext(ctx, query, tenantID, taskID, id)
+
+	c := Comment{ID: id, TenantID: tenantID, TaskID: taskID}
+	err := row.Scan(&c.AuthorID, &c.Body, &c.DateCreated, &c.DateUpdated, &c.Version)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// List retrieves a page of the comments on a tenant's task from the database, oldest first.
+func (dbr CommentDBRepo) List(ctx context.Context, tenantID string, taskID string, limit int, offset int) ([]Comment, error) {
+	const query = `select id, author_id, body, date_created, date_updated, version
+		from task_comment
+		where tenant_id = $1 and task_id = $2
+		order by date_created, id
+		limit $3 offset $4`
+	rows, err := dbr.DB.QueryContext(ctx, query, tenantID, taskID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cs := []Comment{}
+	for rows.Next() {
+		c := Comment{TenantID: tenantID, TaskID: taskID}
+		err = rows.Scan(&c.ID, &c.AuthorID, &c.Body, &c.DateCreated, &c.DateUpdated, &c.Version)
+		if err != nil {
+			return nil, err
+		}
+
+		cs = append(cs, c)
+	}
+
+	return cs, rows.Err()
+}
+
+// Save stores a comment in the database and counts it towards its task in the same transaction. ErrTaskUnavailable is
+// returned if the task does not exist.
+func (dbr CommentDBRepo) Save(ctx context.Context, c Comment) error {
+	tx, err := dbr.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Counting the comment first locks the task so that it cannot be deleted out from under the comment
+	err = countComments(ctx, tx, c.TenantID, c.TaskID, 1)
+	if err != nil {
+		return err
+	}
+
+	const query = `insert into task_comment (id, tenant_id, task_id, author_id, body, date_created, date_updated, version)
+		values ($1, $2, $3, $4, $5, $6, $7, $8)`
+	_, err = tx.ExecContext(ctx, query,
+		c.ID,
+		c.TenantID,
+		c.TaskID,
+		c.AuthorID,
+		c.Body,
+		c.DateCreated,
+		c.DateUpdated,
+		c.Version)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Update replaces the body of a comment in the database and increments its version. The comment's version must be the
+// version that is being replaced. ErrCommentVersionConflict is returned if the comment is no longer at that version or
+// no longer exists.
+func (dbr CommentDBRepo) Update(ctx context.Context, c Comment) error {
+	const query = `update task_comment
+		set body = $1, date_updated = $2, version = version + 1
+		where tenant_id = $3 and task_id = $4 and id = $5 and version = $6`
+	res, err := dbr.DB.ExecContext(ctx, query, c.Body, c.DateUpdated, c.TenantID, c.TaskID, c.ID, c.Version)
+	if err != nil {
+		return err
+	}
+
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return ErrCommentVersionConflict
+	}
+
+	return nil
+}
+
+// Delete removes a comment from the database and stops counting it towards its task in the same transaction. Deleting a
+// comment that does not exist does nothing.
+func (dbr CommentDBRepo) Delete(ctx context.Context, c Comment) error {
+	tx, err := dbr.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	const query = `delete from task_comment where tenant_id = $1 and task_id = $2 and id = $3`
+	res, err := tx.ExecContext(ctx, query, c.TenantID, c.TaskID, c.ID)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return nil
+	}
+
+	err = countComments(ctx, tx, c.TenantID, c.TaskID, -1)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// countComments changes the number of comments counted towards a task. ErrTaskUnavailable is returned if the task does
+// not exist.
+func countComments(ctx context.Context, tx *sql.Tx, tenantID string, taskID string, delta int) error {
+	const query = `update task set comment_count = comment_count + $1 where tenant_id = $2 and id = $3`
+	res, err := tx.ExecContext(ctx, query, delta, tenantID, taskID)
+	if err != nil {
+		return err
+	}
+
+	counted, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if counted == 0 {
+		return ErrTaskUnavailable
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package task_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntegrationCommentDBRepo(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+
+	cdbContainer, err := setupCockroachDB(ctx)
+	require.NoError(t, err, "Failed to start up CockroachDB container")
+	defer cdbContainer.Terminate(ctx)
+
+	db, err := sql.Open("pgx", cdbContainer.URI+"/projectmanagement")
+	require.NoError(t, err, "Failed to open connection to CockroachDB")
+	defer db.Close()
+
+	err = initCockroachDB(ctx, db)
+	require.NoError(t, err, "Failed to initialize CockroachDB")
+	defer truncateCockroachDB(ctx, db)
+
+	cdbr := task.CommentDBRepo{DB: db}
+	tdbr := task.DBRepo{DB: db}
+
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+	tsk.Description = "Plan picnic"
+	err = tdbr.Save(ctx, *tsk)
+	require.NoError(t, err, "Save returned error")
+
+	first := task.NewComment()
+	first.TenantID = "tenant-1"
+	first.TaskID = tsk.ID
+	first.AuthorID = "user-1"
+	first.Body = "Bring **snacks**"
+	err = cdbr.Save(ctx, *first)
+	require.NoError(t, err, "Save returned error")
+
+	second := task.NewComment()
+	second.TenantID = "tenant-1"
+	second.TaskID = tsk.ID
+	second.AuthorID = "user-2"
+	second.Body = "Bring a blanket"
+	second.DateCreated = first.DateCreated.Add(time.Second)
+	err = cdbr.Save(ctx, *second)
+	require.NoError(t, err, "Save returned error")
+
+	// Comments are counted towards the task without changing its version
+	storedTsk, err := tdbr.Get(ctx, "tenant-1", tsk.ID)
+	require.NoError(t, err, "Get returned error")
+	assert.Equal(t, 2, storedTsk.CommentCount, "Did not count comments")
+	assert.Equal(t, tsk.Version, storedTsk.Version, "Changed task version")
+
+	cs, err := cdbr.List(ctx, "tenant-1", tsk.ID, 20, 0)
+	require.NoError(t, err, "List returned error")
+	require.Len(t, cs, 2, "Returned incorrect number of comments")
+	assert.Equal(t, first.ID, cs[0].ID, "Did not return oldest comment first")
+	assert.Equal(t, "Bring **snacks**", cs[0].Body, "Did not store body as it was written")
+	assert.Equal(t, second.ID, cs[1].ID, "Did not return newest comment last")
+
+	cs, err = cdbr.List(ctx, "tenant-1", tsk.ID, 20, 1)
+	require.NoError(t, err, "List returned error")
+	require.Len(t, cs, 1, "Did not skip offset")
+	assert.Equal(t, second.ID, cs[0].ID, "Returned incorrect comment")
+
+	// Comments are scoped to the tenant
+	c, err := cdbr.Get(ctx, "tenant-2", tsk.ID, first.ID)
+	require.NoError(t, err, "Get returned error")
+	assert.Nil(t, c, "Returned another tenant's comment")
+
+	first.Body = "Bring snacks and drinks"
+	err = cdbr.Update(ctx, *first)
+	require.NoError(t, err, "Update returned error")
+
+	c, err = cdbr.Get(ctx, "tenant-1", tsk.ID, first.ID)
+	require.NoError(t, err, "Get returned error")
+	require.NotNil(t, c, "Did not return comment")
+	assert.Equal(t, "Bring snacks and drinks", c.Body, "Did not update body")
+	assert.Equal(t, 2, c.Version, "Did not increment version")
+
+	// The comment is no longer at the version that the change was based on
+	err = cdbr.Update(ctx, *first)
+	assert.ErrorIs(t, err, task.ErrCommentVersionConflict)
+
+	err = cdbr.Delete(ctx, *second)
+	require.NoError(t, err, "Delete returned error")
+
+	// Deleting a comment again does not count it twice
+	err = cdbr.Delete(ctx, *second)
+	require.NoError(t, err, "Delete returned error")
+
+	storedTsk, err = tdbr.Get(ctx, "tenant-1", tsk.ID)
+	require.NoError(t, err, "Get returned error")
+	assert.Equal(t, 1, storedTsk.CommentCount, "Did not stop counting deleted comment")
+
+	// Comments cannot be left on tasks that do not exist
+	orphan := task.NewComment()
+	orphan.TenantID = "tenant-1"
+	orphan.TaskID = "missing"
+	orphan.AuthorID = "user-1"
+	orphan.Body = "Hello?"
+	err = cdbr.Save(ctx, *orphan)
+	assert.ErrorIs(t, err, task.ErrTaskUnavailable)
+
+	// Comments go away along with their task
+	err = tdbr.Delete(ctx, *storedTsk)
+	require.NoError(t, err, "Delete returned error")
+
+	cs, err = cdbr.List(ctx, "tenant-1", tsk.ID, 20, 0)
+	require.NoError(t, err, "List returned error")
+	assert.Empty(t, cs, "Did not delete comments along with task")
+}
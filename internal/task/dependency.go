@@ -0,0 +1,140 @@
+package task
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// ErrDependencyCycle indicates that a dependency could not be stored because the blocked task already blocks the
+// blocker, directly or through other tasks, or because the tasks are the same.
+var ErrDependencyCycle = errors.New("dependency would create a cycle")
+
+// Dependency indicates that a task cannot be worked on until another task, its blocker, has been completed.
+type Dependency struct {
+	TenantID    string    `json:"tenantId"`
+	BlockerID   string    `json:"blockerId"`
+	BlockedID   string    `json:"blockedId"`
+	DateCreated time.Time `json:"dateCreated"`
+}
+
+// NewDependency creates a new dependency between two of a tenant's tasks. The returned pointer will never be nil.
+func NewDependency(tenantID string, blockerID string, blockedID string) *Dependency {
+	return &Dependency{TenantID: tenantID, BlockerID: blockerID, BlockedID: blockedID, DateCreated: time.Now()}
+}
+
+// Graph is a directed graph of the dependencies between tasks, where every edge points from a blocker to the task that
+// it blocks. Tasks without any dependencies are not part of the graph.
+type Graph struct {
+	blocking  map[string][]string
+	blockedBy map[string][]string
+}
+
+// NewGraph builds a graph from dependencies. Duplicate dependencies are only added once.
+func NewGraph(ds []Dependency) *Graph {
+	g := Graph{blocking: make(map[string][]string), blockedBy: make(map[string][]string)}
+	seen := make(map[Dependency]bool)
+	for _, d := range ds {
+		edge := Dependency{BlockerID: d.BlockerID, BlockedID: d.BlockedID}
+		if seen[edge] {
+			continue
+		}
+		seen[edge] = true
+
+		g.blocking[d.BlockerID] = append(g.blocking[d.BlockerID], d.BlockedID)
+		g.blockedBy[d.BlockedID] = append(g.blockedBy[d.BlockedID], d.BlockerID)
+	}
+
+	return &g
+}
+
+// Reachable indicates whether or not there is a path of dependencies leading from one task to another, meaning that the
+// first task blocks the second one directly or through other tasks. A task can only reach itself through a cycle.
+func (g *Graph) Reachable(fromID string, toID string) bool {
+	visited := make(map[string]bool)
+	queue := append([]string{}, g.blocking[fromID]...)
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+
+		if id == toID {
+			return true
+		}
+		if visited[id] {
+			continue
+		}
+		visited[id] = true
+
+		queue = append(queue, g.blocking[id]...)
+	}
+
+	return false
+}
+
+// WouldCycle indicates whether or not adding a dependency to the graph would create a cycle.
+func (g *Graph) WouldCycle(d Dependency) bool {
+	return d.BlockerID == d.BlockedID || g.Reachable(d.BlockedID, d.BlockerID)
+}
+
+// TopologicalSort orders tasks so that every task comes after all of the tasks that block it, including tasks that only
+// block it through tasks that are being left out of the order. Otherwise, tasks stay as close to the order that they
+// were provided in as possible. Duplicate IDs are only ordered once. ErrDependencyCycle is returned if the tasks depend
+// on each other in a cycle.
+func (g *Graph) TopologicalSort(ids []string) ([]string, error) {
+	// Tasks are ranked by where they were provided, with the tasks that are being left out ranked last
+	rank := make(map[string]int, len(ids))
+	for i := len(ids) - 1; i >= 0; i-- {
+		rank[ids[i]] = i
+	}
+	rankOf := func(id string) int {
+		r, ok := rank[id]
+		if !ok {
+			return len(ids)
+		}
+		return r
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int)
+	sorted := make([]string, 0, len(ids))
+
+	// Depth-first search through the blockers, ordering a task once everything blocking it has been ordered
+	var visit func(id string) error
+	visit = func(id string) error {
+		switch state[id] {
+		case visiting:
+			return ErrDependencyCycle
+		case visited:
+			return nil
+		}
+
+		state[id] = visiting
+		blockerIDs := append([]string{}, g.blockedBy[id]...)
+		sort.SliceStable(blockerIDs, func(i, j int) bool { return rankOf(blockerIDs[i]) < rankOf(blockerIDs[j]) })
+		for _, blockerID := range blockerIDs {
+			err := visit(blockerID)
+			if err != nil {
+				return err
+			}
+		}
+		state[id] = visited
+
+		if _, ok := rank[id]; ok {
+			sorted = append(sorted, id)
+		}
+
+		return nil
+	}
+
+	for _, id := range ids {
+		err := visit(id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return sorted, nil
+}
@@ -0,0 +1,191 @@
+package task_test
+
+import (
+	"testing"
+
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func dependency(blockerID string, blockedID string) task.Dependency {
+	return task.Dependency{TenantID: "tenant-1", BlockerID: blockerID, BlockedID: blockedID}
+}
+
+func TestNewDependency(t *testing.T) {
+	d := task.NewDependency("tenant-1", "task-1", "task-2")
+
+	assert.Equal(t, "tenant-1", d.TenantID, "Did not set TenantID")
+	assert.Equal(t, "task-1", d.BlockerID, "Did not set BlockerID")
+	assert.Equal(t, "task-2", d.BlockedID, "Did not set BlockedID")
+	assert.False(t, d.DateCreated.IsZero(), "Did not initialize DateCreated")
+}
+
+func TestGraphReachable(t *testing.T) {
+	// a -> b -> c, a -> d, e -> c
+	g := task.NewGraph([]task.Dependency{
+		dependency("a", "b"),
+		dependency("b", "c"),
+		dependency("a", "d"),
+		dependency("e", "c"),
+	})
+
+	assert.True(t, g.Reachable("a", "b"), "Direct dependency is not reachable")
+	assert.True(t, g.Reachable("a", "c"), "Transitive dependency is not reachable")
+	assert.True(t, g.Reachable("e", "c"), "Second blocker is not reachable")
+	assert.False(t, g.Reachable("c", "a"), "Dependency is reachable in reverse")
+	assert.False(t, g.Reachable("d", "c"), "Sibling is reachable")
+	assert.False(t, g.Reachable("a", "e"), "Unrelated task is reachable")
+	assert.False(t, g.Reachable("a", "a"), "Task reaches itself without a cycle")
+	assert.False(t, g.Reachable("x", "y"), "Unknown task is reachable")
+}
+
+func TestGraphReachableThroughCycle(t *testing.T) {
+	g := task.NewGraph([]task.Dependency{
+		dependency("a", "b"),
+		dependency("b", "c"),
+		dependency("c", "a"),
+	})
+
+	assert.True(t, g.Reachable("a", "a"), "Task does not reach itself through cycle")
+	assert.True(t, g.Reachable("c", "b"), "Task does not reach around cycle")
+	assert.False(t, g.Reachable("a", "d"), "Search through cycle did not terminate correctly")
+}
+
+func TestGraphWouldCycle(t *testing.T) {
+	// a -> b -> c -> d
+	g := task.NewGraph([]task.Dependency{
+		dependency("a", "b"),
+		dependency("b", "c"),
+		dependency("c", "d"),
+	})
+
+	testCases := []struct {
+		name     string
+		blocker  string
+		blocked  string
+		expected bool
+	}{
+		{name: "self", blocker: "a", blocked: "a", expected: true},
+		{name: "reverse of direct dependency", blocker: "b", blocked: "a", expected: true},
+		{name: "reverse of transitive dependency", blocker: "d", blocked: "a", expected: true},
+		{name: "existing dependency", blocker: "a", blocked: "b", expected: false},
+		{name: "shortcut", blocker: "a", blocked: "d", expected: false},
+		{name: "new task", blocker: "d", blocked: "e", expected: false},
+		{name: "unknown tasks", blocker: "x", blocked: "y", expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, g.WouldCycle(dependency(tc.blocker, tc.blocked)))
+		})
+	}
+}
+
+func TestGraphWouldCycleDiamond(t *testing.T) {
+	// a -> b -> d, a -> c -> d
+	g := task.NewGraph([]task.Dependency{
+		dependency("a", "b"),
+		dependency("a", "c"),
+		dependency("b", "d"),
+		dependency("c", "d"),
+	})
+
+	assert.False(t, g.WouldCycle(dependency("b", "c")), "Dependency between diamond sides detected as cycle")
+	assert.True(t, g.WouldCycle(dependency("d", "a")), "Dependency closing diamond not detected as cycle")
+	assert.True(t, g.WouldCycle(dependency("d", "c")), "Dependency closing diamond side not detected as cycle")
+}
+
+func TestGraphTopologicalSort(t *testing.T) {
+	// a -> b -> c, d -> c
+	g := task.NewGraph([]task.Dependency{
+		dependency("a", "b"),
+		dependency("b", "c"),
+		dependency("d", "c"),
+	})
+
+	sorted, err := g.TopologicalSort([]string{"c", "b", "a", "d"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "d", "c"}, sorted)
+}
+
+func TestGraphTopologicalSortKeepsOrderOfIndependentTasks(t *testing.T) {
+	g := task.NewGraph([]task.Dependency{dependency("a", "b")})
+
+	sorted, err := g.TopologicalSort([]string{"z", "b", "y", "a", "x"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"z", "a", "b", "y", "x"}, sorted)
+}
+
+func TestGraphTopologicalSortFollowsLeftOutTasks(t *testing.T) {
+	// a -> b -> c, where b is not being ordered
+	g := task.NewGraph([]task.Dependency{
+		dependency("a", "b"),
+		dependency("b", "c"),
+	})
+
+	sorted, err := g.TopologicalSort([]string{"c", "a"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "c"}, sorted)
+}
+
+func TestGraphTopologicalSortDiamond(t *testing.T) {
+	// a -> b -> d, a -> c -> d
+	g := task.NewGraph([]task.Dependency{
+		dependency("a", "b"),
+		dependency("a", "c"),
+		dependency("b", "d"),
+		dependency("c", "d"),
+	})
+
+	sorted, err := g.TopologicalSort([]string{"d", "c", "b", "a"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "c", "b", "d"}, sorted)
+}
+
+func TestGraphTopologicalSortDuplicates(t *testing.T) {
+	g := task.NewGraph([]task.Dependency{
+		dependency("a", "b"),
+		dependency("a", "b"),
+	})
+
+	sorted, err := g.TopologicalSort([]string{"b", "a", "b"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, sorted)
+}
+
+func TestGraphTopologicalSortEmpty(t *testing.T) {
+	g := task.NewGraph(nil)
+
+	sorted, err := g.TopologicalSort([]string{})
+	require.NoError(t, err)
+	assert.Empty(t, sorted)
+}
+
+func TestGraphTopologicalSortCycle(t *testing.T) {
+	g := task.NewGraph([]task.Dependency{
+		dependency("a", "b"),
+		dependency("b", "c"),
+		dependency("c", "a"),
+	})
+
+	sorted, err := g.TopologicalSort([]string{"a", "c"})
+	assert.Nil(t, sorted)
+	assert.ErrorIs(t, err, task.ErrDependencyCycle)
+}
+
+func TestGraphTopologicalSortCycleOutsideOfTasks(t *testing.T) {
+	// x <-> y both block a
+	g := task.NewGraph([]task.Dependency{
+		dependency("x", "y"),
+		dependency("y", "x"),
+		dependency("x", "a"),
+	})
+
+	_, err := g.TopologicalSort([]string{"a"})
+	assert.ErrorIs(t, err, task.ErrDependencyCycle)
+
+	sorted, err := g.TopologicalSort([]string{"b"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b"}, sorted)
+}
@@ -0,0 +1,104 @@
+package task
+
+import (
+	"context"
+	"database/sql"
+)
+
+// DependencyDBClient is a client for retrieving and manipulating dependencies between tasks in a SQL database
+type DependencyDBClient interface {
+	List(ctx context.Context, tenantID string) ([]Dependency, error)
+	BlockedIDs(ctx context.Context, tenantID string, blockerID string) ([]string, error)
+	Save(ctx context.Context, d Dependency) error
+	Delete(ctx context.Context, d Dependency) error
+}
+
+// DependencyDBRepo is a database repository for dependencies between tasks.
+type DependencyDBRepo struct {
+	DB *sql.DB
+}
+
+// List retrieves all of the dependencies between a tenant's tasks from the database, oldest first.
+func (dbr DependencyDBRepo) List(ctx context.Context, tenantID string) ([]Dependency, error) {
+	return listDependencies(ctx, dbr.DB, tenantID)
+}
+
+// BlockedIDs retrieves the IDs of a tenant's tasks that the task directly blocks.
+func (dbr DependencyDBRepo) BlockedIDs(ctx context.Context, tenantID string, blockerID string) ([]string, error) {
+	const query = `select blocked_id from task_dependency where tenant_id = $1 and blocker_id = $2`
+	rows, err := dbr.DB.QueryContext(ctx, query, tenantID, blockerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanIDs(rows)
+}
+
+// Save stores a dependency in the database. Storing a dependency that already exists does nothing.
+// ErrDependencyCycle is returned if the dependency would make the tasks depend on each other in a cycle. Transactions
+// are serializable so concurrent changes to the dependencies cannot combine into a cycle.
+func (dbr DependencyDBRepo) Save(ctx context.Context, d Dependency) error {
+	tx, err := dbr.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	ds, err := listDependencies(ctx, tx, d.TenantID)
+	if err != nil {
+		return err
+	}
+
+	if NewGraph(ds).WouldCycle(d) {
+		return ErrDependencyCycle
+	}
+
+	const query = `insert into task_dependency (blocked_id, blocker_id, tenant_id, date_created)
+		values ($1, $2, $3, $4)
+		on conflict (blocked_id, blocker_id) do nothing`
+	_, err = tx.ExecContext(ctx, query, d.BlockedID, d.BlockerID, d.TenantID, d.DateCreated)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Delete removes a dependency from the database. Removing a dependency that does not exist does nothing.
+func (dbr DependencyDBRepo) Delete(ctx context.Context, d Dependency) error {
+	const query = `delete from task_dependency where tenant_id = $1 and blocked_id = $2 and blocker_id = $3`
+	_, err := dbr.DB.ExecContext(ctx, query, d.TenantID, d.BlockedID, d.BlockerID)
+
+	return err
+}
+
+// queryer runs queries either directly against the database or within a transaction.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// listDependencies retrieves all of the dependencies between a tenant's tasks, oldest first.
+func listDependencies(ctx context.Context, q queryer, tenantID string) ([]Dependency, error) {
+	const query = `select blocker_id, blocked_id, date_created
+		from task_dependency
+		where tenant_id = $1
+		order by date_created, blocked_id, blocker_id`
+	rows, err := q.QueryContext(ctx, query, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ds := []Dependency{}
+	for rows.Next() {
+		d := Dependency{TenantID: tenantID}
+		err = rows.Scan(&d.BlockerID, &d.BlockedID, &d.DateCreated)
+		if err != nil {
+			return nil, err
+		}
+
+		ds = append(ds, d)
+	}
+
+	return ds, rows.Err()
+}
@@ -0,0 +1,105 @@
+package task_test
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntegrationDependencyDBRepo(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+
+	cdbContainer, err := setupCockroachDB(ctx)
+	require.NoError(t, err, "Failed to start up CockroachDB container")
+	defer cdbContainer.Terminate(ctx)
+
+	db, err := sql.Open("pgx", cdbContainer.URI+"/projectmanagement")
+	require.NoError(t, err, "Failed to open connection to CockroachDB")
+	defer db.Close()
+
+	err = initCockroachDB(ctx, db)
+	require.NoError(t, err, "Failed to initialize CockroachDB")
+	defer truncateCockroachDB(ctx, db)
+
+	ddbr := task.DependencyDBRepo{DB: db}
+	tdbr := task.DBRepo{DB: db}
+
+	// design -> build -> ship, test -> ship
+	var design, build, test, ship *task.Task
+	for _, tsk := range []**task.Task{&design, &build, &test, &ship} {
+		*tsk = task.New()
+		(*tsk).TenantID = "tenant-1"
+		err = tdbr.Save(ctx, **tsk)
+		require.NoError(t, err, "Task save returned error")
+	}
+
+	for _, d := range []*task.Dependency{
+		task.NewDependency("tenant-1", design.ID, build.ID),
+		task.NewDependency("tenant-1", build.ID, ship.ID),
+		task.NewDependency("tenant-1", test.ID, ship.ID),
+	} {
+		err = ddbr.Save(ctx, *d)
+		require.NoError(t, err, "Save returned error")
+	}
+
+	// Storing the same dependency again does nothing
+	err = ddbr.Save(ctx, *task.NewDependency("tenant-1", design.ID, build.ID))
+	require.NoError(t, err, "Save returned error for existing dependency")
+
+	err = ddbr.Save(ctx, *task.NewDependency("tenant-1", ship.ID, design.ID))
+	assert.ErrorIs(t, err, task.ErrDependencyCycle)
+
+	err = ddbr.Save(ctx, *task.NewDependency("tenant-1", build.ID, build.ID))
+	assert.ErrorIs(t, err, task.ErrDependencyCycle)
+
+	ds, err := ddbr.List(ctx, "tenant-1")
+	require.NoError(t, err, "List returned error")
+	assert.Len(t, ds, 3, "List returned incorrect number of dependencies")
+
+	ds, err = ddbr.List(ctx, "tenant-2")
+	require.NoError(t, err, "List returned error")
+	assert.Empty(t, ds, "List returned another tenant's dependencies")
+
+	ids, err := ddbr.BlockedIDs(ctx, "tenant-1", design.ID)
+	require.NoError(t, err, "BlockedIDs returned error")
+	assert.Equal(t, []string{build.ID}, ids)
+
+	savedTsk, err := tdbr.Get(ctx, "tenant-1", ship.ID)
+	require.NoError(t, err, "Task get returned error")
+	require.NotNil(t, savedTsk, "Task get did not return a task")
+	expectedBlockers := []string{build.ID, test.ID}
+	sort.Strings(expectedBlockers)
+	assert.Equal(t, expectedBlockers, savedTsk.BlockedBy)
+
+	// Completed tasks no longer block
+	now := time.Now()
+	completed := *test
+	completed.DateCompleted = &now
+	err = tdbr.Update(ctx, completed)
+	require.NoError(t, err, "Task update returned error")
+
+	savedTsk, err = tdbr.Get(ctx, "tenant-1", ship.ID)
+	require.NoError(t, err, "Task get returned error")
+	assert.Equal(t, []string{build.ID}, savedTsk.BlockedBy)
+
+	err = ddbr.Delete(ctx, task.Dependency{TenantID: "tenant-1", BlockerID: build.ID, BlockedID: ship.ID})
+	require.NoError(t, err, "Delete returned error")
+
+	savedTsk, err = tdbr.Get(ctx, "tenant-1", ship.ID)
+	require.NoError(t, err, "Task get returned error")
+	assert.False(t, savedTsk.Blocked(), "Task is still blocked")
+
+	// The removed dependency no longer prevents the reverse dependency
+	err = ddbr.Save(ctx, *task.NewDependency("tenant-1", ship.ID, build.ID))
+	assert.NoError(t, err, "Save returned error")
+}
@@ -0,0 +1,37 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/jaredpetersen/go-rest-template/internal/outbox"
+)
+
+// Types of domain events that are recorded in the outbox when tasks change.
+const (
+	EventCreated = "task.created"
+	EventUpdated = "task.updated"
+	EventDeleted = "task.deleted"
+)
+
+// ValidEventType determines whether or not the event type is one of the types of task events.
+func ValidEventType(eventType string) bool {
+	return eventType == EventCreated || eventType == EventUpdated || eventType == EventDeleted
+}
+
+// recordEvents adds an event of the given type about each of the tasks to the outbox within the transaction that
+// changes them. The payload of each event is the task after the change, or before it for deleted tasks. Events about
+// the same task are ordered by the task's version.
+func recordEvents(ctx context.Context, tx outbox.Execer, eventType string, ts ...Task) error {
+	ms := make([]outbox.Message, len(ts))
+	for i, t := range ts {
+		payload, err := json.Marshal(t)
+		if err != nil {
+			return err
+		}
+
+		ms[i] = *outbox.NewMessage(t.TenantID, t.ID, int64(t.Version), eventType, payload)
+	}
+
+	return outbox.Write(ctx, tx, ms...)
+}
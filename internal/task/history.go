@@ -0,0 +1,227 @@
+package task
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jaredpetersen/go-rest-template/internal/outbox"
+)
+
+// Actions that revisions record.
+const (
+	ActionCreated = "created"
+	ActionUpdated = "updated"
+	ActionDeleted = "deleted"
+)
+
+// Actor identifies who changed a task and the request that they changed it in.
+type Actor struct {
+	// Subject is the principal that made the change. Empty for changes that the service makes on its own.
+	Subject string `json:"subject"`
+	// RequestID identifies the request that the change was made in. Empty for changes made outside of a request.
+	RequestID string `json:"requestId"`
+}
+
+// actorKey is the context key for the actor.
+type actorKey struct{}
+
+// NewActorContext returns a new context that carries the actor, which is recorded with every change to a task that is
+// made with the context.
+func NewActorContext(ctx context.Context, a Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, a)
+}
+
+// ActorFromContext retrieves the actor from the context. The zero value is returned when the context does not carry an
+// actor.
+func ActorFromContext(ctx context.Context) Actor {
+	a, _ := ctx.Value(actorKey{}).(Actor)
+	return a
+}
+
+// Change is a change to one of a task's fields. Values are JSON encoded like the task, and null when the field is not
+// set.
+type Change struct {
+	Field  string          `json:"field"`
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+}
+
+// Revision is a change to a task, as recorded in the task's history.
+type Revision struct {
+	ID       string
+	TenantID string
+	TaskID   string
+	// Version is the version of the task after the change. Deleting a task counts as a change.
+	Version int
+	Action  string
+	Actor   Actor
+	Changes []Change
+	// Task is the task after the change, or before it for deleted tasks.
+	Task        Task
+	DateCreated time.Time
+}
+
+// auditedFields are the fields of a task, as named in its JSON encoding, whose changes are recorded. Fields that are
+// derived from other data or that change with every revision are left out.
+var auditedFields = []string{
+	"ownerId",
+	"projectId",
+	"parentId",
+	"description",
+	"dateDue",
+	"dateCompleted",
+	"recurrence",
+	"reminderOffsets",
+	"shares",
+	"tags",
+}
+
+// Diff determines how a task's fields changed, in a consistent order. Before is nil for tasks that were created and
+// after is nil for tasks that were deleted.
+func Diff(before *Task, after *Task) ([]Change, error) {
+	beforeValues, err := fieldValues(before)
+	if err != nil {
+		return nil, err
+	}
+
+	afterValues, err := fieldValues(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []Change{}
+	for _, field := range auditedFields {
+		b := beforeValues[field]
+		a := afterValues[field]
+		if bytes.Equal(b, a) {
+			continue
+		}
+
+		changes = append(changes, Change{Field: field, Before: b, After: a})
+	}
+
+	return changes, nil
+}
+
+// fieldValues encodes each of the task's fields as JSON. Fields that are not set are null.
+func fieldValues(t *Task) (map[string]json.RawMessage, error) {
+	values := make(map[string]json.RawMessage)
+	if t != nil {
+		raw, err := json.Marshal(normalize(*t))
+		if err != nil {
+			return nil, err
+		}
+
+		err = json.Unmarshal(raw, &values)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, field := range auditedFields {
+		if values[field] == nil {
+			values[field] = json.RawMessage("null")
+		}
+	}
+
+	return values, nil
+}
+
+// normalize stores the task's dates and tags the way that the database does, so that the same task is encoded the same
+// way whether or not it came from the database.
+func normalize(t Task) Task {
+	if t.DateDue != nil {
+		dateDue := t.DateDue.UTC().Truncate(time.Microsecond)
+		t.DateDue = &dateDue
+	}
+	if t.DateCompleted != nil {
+		dateCompleted := t.DateCompleted.UTC().Truncate(time.Microsecond)
+		t.DateCompleted = &dateCompleted
+	}
+	if t.Tags != nil {
+		t.Tags = append([]string{}, t.Tags...)
+		sort.Strings(t.Tags)
+	}
+
+	return t
+}
+
+// newRevision creates a revision of the task on behalf of the actor in the context. Before is nil for tasks that were
+// created and after is nil for tasks that were deleted. The task must be at the version after the change.
+func newRevision(ctx context.Context, action string, before *Task, after *Task) (*Revision, error) {
+	changes, err := Diff(before, after)
+	if err != nil {
+		return nil, err
+	}
+
+	t := after
+	if t == nil {
+		t = before
+	}
+
+	return &Revision{
+		ID:          uuid.NewString(),
+		TenantID:    t.TenantID,
+		TaskID:      t.ID,
+		Version:     t.Version,
+		Action:      action,
+		Actor:       ActorFromContext(ctx),
+		Changes:     changes,
+		Task:        normalize(*t),
+		DateCreated: time.Now(),
+	}, nil
+}
+
+// recordRevisions adds the revisions to the history of their tasks within the transaction that changes the tasks, so
+// that every change that is stored is recorded.
+func recordRevisions(ctx context.Context, tx outbox.Execer, rs ...Revision) error {
+	if len(rs) == 0 {
+		return nil
+	}
+
+	const columns = 10
+	args := make([]interface{}, 0, len(rs)*columns)
+	values := make([]string, len(rs))
+	for i, r := range rs {
+		changes, err := json.Marshal(r.Changes)
+		if err != nil {
+			return err
+		}
+
+		snapshot, err := json.Marshal(r.Task)
+		if err != nil {
+			return err
+		}
+
+		args = append(args,
+			r.ID,
+			r.TenantID,
+			r.TaskID,
+			r.Version,
+			r.Action,
+			r.Actor.Subject,
+			r.Actor.RequestID,
+			string(changes),
+			string(snapshot),
+			r.DateCreated)
+
+		placeholders := make([]string, columns)
+		for j := range placeholders {
+			placeholders[j] = "$" + strconv.Itoa(i*columns+j+1)
+		}
+		values[i] = "(" + strings.Join(placeholders, ", ") + ")"
+	}
+
+	query := `insert into task_history
+		(id, tenant_id, task_id, version, action, actor, request_id, changes, task, date_created)
+		values ` + strings.Join(values, ", ")
+	_, err := tx.ExecContext(ctx, query, args...)
+
+	return err
+}
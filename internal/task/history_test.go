@@ -0,0 +1,96 @@
+package task_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActorContext(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, task.Actor{}, task.ActorFromContext(ctx), "Returned actor for context without one")
+
+	a := task.Actor{Subject: "user-1", RequestID: "request-1"}
+	ctx = task.NewActorContext(ctx, a)
+	assert.Equal(t, a, task.ActorFromContext(ctx), "Returned incorrect actor")
+}
+
+func TestDiff(t *testing.T) {
+	dateDue := time.Date(2021, time.October, 1, 12, 0, 0, 0, time.UTC)
+
+	before := task.New()
+	before.TenantID = "tenant-1"
+	before.OwnerID = "user-1"
+	before.Description = "Call veterinarian"
+	before.Tags = []string{"pets", "calls"}
+
+	after := *before
+	after.Description = "Call veterinarian again"
+	after.DateDue = &dateDue
+	after.Version = before.Version + 1
+	after.DateUpdated = before.DateUpdated.Add(time.Minute)
+	// Tags in a different order are the same tags
+	after.Tags = []string{"calls", "pets"}
+
+	changes, err := task.Diff(before, &after)
+	require.NoError(t, err, "Returned error")
+
+	expected := []task.Change{
+		{
+			Field:  "description",
+			Before: json.RawMessage(`"Call veterinarian"`),
+			After:  json.RawMessage(`"Call veterinarian again"`),
+		},
+		{
+			Field:  "dateDue",
+			Before: json.RawMessage(`null`),
+			After:  json.RawMessage(`"2021-10-01T12:00:00Z"`),
+		},
+	}
+	assert.Equal(t, expected, changes, "Returned incorrect changes")
+}
+
+func TestDiffSameTask(t *testing.T) {
+	dateDue := time.Date(2021, time.October, 1, 12, 0, 0, 0, time.FixedZone("PDT", -7*60*60))
+
+	before := task.New()
+	before.OwnerID = "user-1"
+	before.DateDue = &dateDue
+
+	// The database returns dates in UTC
+	after := *before
+	utcDateDue := dateDue.UTC()
+	after.DateDue = &utcDateDue
+
+	changes, err := task.Diff(before, &after)
+	require.NoError(t, err, "Returned error")
+	assert.Empty(t, changes, "Returned changes for the same task")
+}
+
+func TestDiffCreatedAndDeleted(t *testing.T) {
+	tsk := task.New()
+	tsk.OwnerID = "user-1"
+	tsk.Description = "Update resumé"
+
+	created, err := task.Diff(nil, tsk)
+	require.NoError(t, err, "Returned error for created task")
+
+	deleted, err := task.Diff(tsk, nil)
+	require.NoError(t, err, "Returned error for deleted task")
+
+	expected := []task.Change{
+		{Field: "ownerId", Before: json.RawMessage(`null`), After: json.RawMessage(`"user-1"`)},
+		{Field: "description", Before: json.RawMessage(`null`), After: json.RawMessage(`"Update resumé"`)},
+	}
+	assert.Equal(t, expected, created, "Returned incorrect changes for created task")
+
+	for i := range expected {
+		expected[i].Before, expected[i].After = expected[i].After, expected[i].Before
+	}
+	assert.Equal(t, expected, deleted, "Returned incorrect changes for deleted task")
+}
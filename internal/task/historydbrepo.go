@@ -0,0 +1,133 @@
+package task
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// HistoryDBClient is a client for retrieving and pruning the history of tasks in a SQL database
+type HistoryDBClient interface {
+	GetRevision(ctx context.Context, tenantID string, taskID string, version int) (*Revision, error)
+	GetRevisionAsOf(ctx context.Context, tenantID string, taskID string, asOf time.Time) (*Revision, error)
+	ListRevisions(ctx context.Context, tenantID string, taskID string, limit int, offset int) ([]Revision, error)
+	PruneRevisions(ctx context.Context, before time.Time, limit int) (int, error)
+}
+
+// HistoryDBRepo is a database repository for the history of tasks. Revisions are recorded by DBRepo as tasks change.
+type HistoryDBRepo struct {
+	DB *sql.DB
+}
+
+// GetRevision retrieves the revision that brought a tenant's task to the version. If no such revision exists, nil will
+// be returned for both the revision and error.
+func (dbr HistoryDBRepo) GetRevision(ctx context.Context, tenantID string, taskID string, version int) (*Revision, error) {
+	const query = `select id, version, action, actor, request_id, changes, task, date_created
+		from task_history
+		where tenant_id = $1 and task_id = $2 and version = $3`
+	rows, err := dbr.DB.QueryContext(ctx, query, tenantID, taskID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	rs, err := scanRevisions(rows, tenantID, taskID)
+	if err != nil || len(rs) == 0 {
+		return nil, err
+	}
+
+	return &rs[0], nil
+}
+
+// GetRevisionAsOf retrieves the last revision of a tenant's task that was recorded at or before the time, which holds
+// the task as it was at that time. If no such revision exists, either because the task did not exist yet or because
+// the revision has been pruned, nil will be returned for both the revision and error.
+func (dbr HistoryDBRepo) GetRevisionAsOf(ctx context.Context, tenantID string, taskID string, asOf time.Time) (*Revision, error) {
+	const query = `select id, version, action, actor, request_id, changes, task, date_created
+		from task_history
+		where tenant_id = $1 and task_id = $2 and date_created <= $3
+		order by date_created desc, version desc
+		limit 1`
+	rows, err := dbr.DB.QueryContext(ctx, query, tenantID, taskID, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	rs, err := scanRevisions(rows, tenantID, taskID)
+	if err != nil || len(rs) == 0 {
+		return nil, err
+	}
+
+	return &rs[0], nil
+}
+
+// ListRevisions retrieves a page of the revisions in the history of a tenant's task, newest first. The history is kept
+// after the task is deleted.
+func (dbr HistoryDBRepo) ListRevisions(ctx context.Context, tenantID string, taskID string, limit int, offset int) ([]Revision, error) {
+	const query = `select id, version, action, actor, request_id, changes, task, date_created
+		from task_history
+		where tenant_id = $1 and task_id = $2
+		order by version desc, date_created desc
+		limit $3 offset $4`
+	rows, err := dbr.DB.QueryContext(ctx, query, tenantID, taskID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanRevisions(rows, tenantID, taskID)
+}
+
+// PruneRevisions removes up to limit revisions that were recorded before the given time, from the history of every
+// tenant's tasks. The number of revisions that were removed is returned.
+func (dbr HistoryDBRepo) PruneRevisions(ctx context.Context, before time.Time, limit int) (int, error) {
+	const query = `delete from task_history where date_created < $1 order by date_created limit $2`
+	res, err := dbr.DB.ExecContext(ctx, query, before, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	pruned, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(pruned), nil
+}
+
+// scanRevisions reads the revisions of a tenant's task from rows of id, version, action, actor, request_id, changes,
+// task, and date_created, closing the rows afterwards.
+func scanRevisions(rows *sql.Rows, tenantID string, taskID string) ([]Revision, error) {
+	defer rows.Close()
+
+	rs := []Revision{}
+	for rows.Next() {
+		r := Revision{TenantID: tenantID, TaskID: taskID}
+		var changes, snapshot []byte
+		err := rows.Scan(
+			&r.ID,
+			&r.Version,
+			&r.Action,
+			&r.Actor.Subject,
+			&r.Actor.RequestID,
+			&changes,
+			&snapshot,
+			&r.DateCreated)
+		if err != nil {
+			return nil, err
+		}
+
+		err = json.Unmarshal(changes, &r.Changes)
+		if err != nil {
+			return nil, err
+		}
+
+		err = json.Unmarshal(snapshot, &r.Task)
+		if err != nil {
+			return nil, err
+		}
+
+		rs = append(rs, r)
+	}
+
+	return rs, rows.Err()
+}
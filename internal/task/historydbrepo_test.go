@@ -0,0 +1,132 @@
+package task_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntegrationHistoryDBRepo(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+
+	cdbContainer, err := setupCockroachDB(ctx)
+	require.NoError(t, err, "Failed to start up CockroachDB container")
+	defer cdbContainer.Terminate(ctx)
+
+	db, err := sql.Open("pgx", cdbContainer.URI+"/projectmanagement")
+	require.NoError(t, err, "Failed to open connection to CockroachDB")
+	defer db.Close()
+
+	err = initCockroachDB(ctx, db)
+	require.NoError(t, err, "Failed to initialize CockroachDB")
+	defer truncateCockroachDB(ctx, db)
+
+	hdbr := task.HistoryDBRepo{DB: db}
+	tdbr := task.DBRepo{DB: db}
+
+	ctx = task.NewActorContext(ctx, task.Actor{Subject: "user-1", RequestID: "request-1"})
+
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+	tsk.Description = "Call veterinarian"
+	err = tdbr.Save(ctx, *tsk)
+	require.NoError(t, err, "Save returned error")
+
+	tsk.Description = "Call veterinarian again"
+	err = tdbr.Update(ctx, *tsk)
+	require.NoError(t, err, "Update returned error")
+	tsk.Version++
+
+	// Updates that do not change any of the recorded fields are still recorded
+	tsk.DateUpdated = time.Now()
+	err = tdbr.Update(ctx, *tsk)
+	require.NoError(t, err, "Update returned error")
+	tsk.Version++
+
+	r, err := hdbr.GetRevision(ctx, "tenant-1", tsk.ID, 1)
+	require.NoError(t, err, "GetRevision returned error")
+	require.NotNil(t, r, "Did not return revision")
+	assert.Equal(t, task.ActionCreated, r.Action, "Returned incorrect revision")
+	assert.Equal(t, "Call veterinarian", r.Task.Description, "Did not keep task as it was")
+
+	r, err = hdbr.GetRevision(ctx, "tenant-1", tsk.ID, 9)
+	require.NoError(t, err, "GetRevision returned error for unknown version")
+	assert.Nil(t, r, "Returned revision for unknown version")
+
+	r, err = hdbr.GetRevisionAsOf(ctx, "tenant-1", tsk.ID, time.Now())
+	require.NoError(t, err, "GetRevisionAsOf returned error")
+	require.NotNil(t, r, "Did not return revision as of now")
+	assert.Equal(t, tsk.Version, r.Version, "Did not return latest revision")
+	assert.Equal(t, "Call veterinarian again", r.Task.Description, "Did not return task as it is now")
+
+	r, err = hdbr.GetRevisionAsOf(ctx, "tenant-1", tsk.ID, tsk.DateCreated.Add(-time.Hour))
+	require.NoError(t, err, "GetRevisionAsOf returned error for time before task was created")
+	assert.Nil(t, r, "Returned revision from before task was created")
+
+	err = tdbr.Delete(ctx, *tsk)
+	require.NoError(t, err, "Delete returned error")
+
+	rs, err := hdbr.ListRevisions(ctx, "tenant-1", tsk.ID, 10, 0)
+	require.NoError(t, err, "ListRevisions returned error")
+	require.Len(t, rs, 4, "Returned incorrect number of revisions")
+
+	// Newest first
+	assert.Equal(t, task.ActionDeleted, rs[0].Action, "Returned incorrect action for deletion")
+	assert.Equal(t, tsk.Version+1, rs[0].Version, "Returned incorrect version for deletion")
+	assert.Equal(t, "Call veterinarian again", rs[0].Task.Description, "Did not keep task as it was before deletion")
+
+	assert.Equal(t, task.ActionUpdated, rs[1].Action, "Returned incorrect action for update")
+	assert.Empty(t, rs[1].Changes, "Returned changes for update that did not change anything")
+
+	assert.Equal(t, task.ActionUpdated, rs[2].Action, "Returned incorrect action for update")
+	assert.Equal(t, 2, rs[2].Version, "Returned incorrect version for update")
+	assert.Equal(t, []task.Change{
+		{
+			Field:  "description",
+			Before: json.RawMessage(`"Call veterinarian"`),
+			After:  json.RawMessage(`"Call veterinarian again"`),
+		},
+	}, rs[2].Changes, "Returned incorrect changes for update")
+
+	assert.Equal(t, task.ActionCreated, rs[3].Action, "Returned incorrect action for creation")
+	assert.Equal(t, 1, rs[3].Version, "Returned incorrect version for creation")
+
+	for _, r := range rs {
+		assert.Equal(t, "tenant-1", r.TenantID, "Returned incorrect tenant")
+		assert.Equal(t, tsk.ID, r.TaskID, "Returned incorrect task")
+		assert.Equal(t, task.Actor{Subject: "user-1", RequestID: "request-1"}, r.Actor, "Returned incorrect actor")
+	}
+
+	page, err := hdbr.ListRevisions(ctx, "tenant-1", tsk.ID, 2, 1)
+	require.NoError(t, err, "ListRevisions returned error for page")
+	assert.Equal(t, []string{rs[1].ID, rs[2].ID}, []string{page[0].ID, page[1].ID}, "Returned incorrect page")
+
+	other, err := hdbr.ListRevisions(ctx, "tenant-2", tsk.ID, 10, 0)
+	require.NoError(t, err, "ListRevisions returned error for other tenant")
+	assert.Empty(t, other, "Returned revisions of another tenant's task")
+
+	// Nothing is old enough to prune yet
+	pruned, err := hdbr.PruneRevisions(ctx, time.Now().Add(-time.Hour), 10)
+	require.NoError(t, err, "PruneRevisions returned error")
+	assert.Equal(t, 0, pruned, "Pruned revisions that are not old enough")
+
+	pruned, err = hdbr.PruneRevisions(ctx, time.Now().Add(time.Hour), 3)
+	require.NoError(t, err, "PruneRevisions returned error")
+	assert.Equal(t, 3, pruned, "Did not prune up to the limit")
+
+	rs, err = hdbr.ListRevisions(ctx, "tenant-1", tsk.ID, 10, 0)
+	require.NoError(t, err, "ListRevisions returned error after pruning")
+	require.Len(t, rs, 1, "Did not prune the oldest revisions")
+	assert.Equal(t, task.ActionDeleted, rs[0].Action, "Did not keep the newest revision")
+}
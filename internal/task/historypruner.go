@@ -0,0 +1,73 @@
+package task
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// HistoryPruner removes revisions from the history of tasks once they are older than the retention period.
+//
+// Pruning is idempotent, so every replica may run a pruner without coordinating.
+type HistoryPruner struct {
+	History HistoryDBClient
+	// Retention is how long revisions are kept. Defaults to a year.
+	Retention time.Duration
+	// BatchSize is the most revisions removed at a time, so that pruning does not hold up changes to tasks. Defaults to
+	// 1000.
+	BatchSize int
+	// Now reports the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// NewHistoryPruner creates a pruner with default values. The returned pointer will never be nil.
+func NewHistoryPruner() *HistoryPruner {
+	return &HistoryPruner{
+		Retention: 365 * 24 * time.Hour,
+		BatchSize: 1000,
+		Now:       time.Now,
+	}
+}
+
+// Start runs the pruner on an interval in a separate goroutine until the context is done.
+func (p *HistoryPruner) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				pruned, err := p.Run(ctx)
+				if err != nil {
+					log.Error().Err(err).Msg("Failed to prune task history")
+				}
+				if pruned > 0 {
+					log.Info().Int("revisions", pruned).Msg("Pruned task history")
+				}
+			}
+		}
+	}()
+}
+
+// Run removes every revision that is older than the retention period, a batch at a time. The number of revisions that
+// were removed is returned, even if some of them could not be.
+func (p *HistoryPruner) Run(ctx context.Context) (int, error) {
+	before := p.Now().Add(-p.Retention)
+
+	total := 0
+	for {
+		pruned, err := p.History.PruneRevisions(ctx, before, p.BatchSize)
+		total += pruned
+		if err != nil {
+			return total, err
+		}
+
+		if pruned < p.BatchSize {
+			return total, nil
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package task_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+	taskmock "github.com/jaredpetersen/go-rest-template/internal/task/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHistoryPrunerRun(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Date(2021, time.October, 1, 12, 0, 0, 0, time.UTC)
+	before := now.Add(-30 * 24 * time.Hour)
+
+	// Batches continue until one comes back short
+	hdbr := taskmock.HistoryDBClient{}
+	hdbr.On("PruneRevisions", mock.Anything, before, 10).Return(10, nil).Twice()
+	hdbr.On("PruneRevisions", mock.Anything, before, 10).Return(3, nil).Once()
+
+	p := task.NewHistoryPruner()
+	p.History = &hdbr
+	p.Retention = 30 * 24 * time.Hour
+	p.BatchSize = 10
+	p.Now = func() time.Time { return now }
+
+	pruned, err := p.Run(ctx)
+	assert.NoError(t, err, "Returned error")
+	assert.Equal(t, 23, pruned, "Returned incorrect number of pruned revisions")
+
+	hdbr.AssertExpectations(t)
+}
+
+func TestHistoryPrunerRunReturnsErrorOnDBError(t *testing.T) {
+	ctx := context.Background()
+
+	hdbr := taskmock.HistoryDBClient{}
+	hdbr.On("PruneRevisions", mock.Anything, mock.Anything, 10).Return(10, nil).Once()
+	hdbr.On("PruneRevisions", mock.Anything, mock.Anything, 10).Return(0, errors.New("failed")).Once()
+
+	p := task.NewHistoryPruner()
+	p.History = &hdbr
+	p.BatchSize = 10
+
+	pruned, err := p.Run(ctx)
+	assert.Error(t, err, "Did not return error")
+	assert.Equal(t, 10, pruned, "Returned incorrect number of pruned revisions")
+
+	hdbr.AssertExpectations(t)
+}
@@ -0,0 +1,146 @@
+package task
+
+import (
+	"context"
+	"html"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Searcher finds a tenant's tasks by keyword.
+type Searcher interface {
+	// Search returns up to limit of the tenant's tasks whose descriptions contain every term in the query, most
+	// relevant first.
+	Search(ctx context.Context, tenantID string, query string, limit int) ([]SearchResult, error)
+}
+
+// Indexer is implemented by searchers that maintain their own index instead of searching the task store directly. The
+// index must be updated every time a task is stored.
+type Indexer interface {
+	Index(ctx context.Context, ts ...Task) error
+}
+
+// ProjectIndexer is implemented by searchers that maintain their own index and so must be told which projects are
+// archived to leave out their tasks. The index must be updated every time a project is archived or restored.
+type ProjectIndexer interface {
+	IndexProject(ctx context.Context, tenantID string, projectID string, archived bool) error
+}
+
+// SearchResult is a task that matched a search query.
+type SearchResult struct {
+	Task Task
+	// Score is how relevant the task is to the query. Higher is more relevant.
+	Score float64
+	// Highlight is the task description with the matching terms wrapped in <mark> tags. The rest of the description is
+	// HTML escaped.
+	Highlight string
+}
+
+// Terms splits text into the unique, lowercase words that are used to index and search tasks. Words are made up of
+// letters and digits; everything else separates them.
+func Terms(text string) []string {
+	var terms []string
+	seen := make(map[string]bool)
+
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), isSeparator) {
+		if !seen[word] {
+			seen[word] = true
+			terms = append(terms, word)
+		}
+	}
+
+	return terms
+}
+
+// isSeparator determines whether or not the character separates words.
+func isSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// rank scores and highlights the tasks that match the query and returns up to limit of them, most relevant first. Tasks
+// that do not contain every term in the query are left out.
+//
+// Tasks are scored by how many of their words match the query, normalized by the length of the description so that
+// short, focused descriptions rank above long ones that mention the terms in passing. Descriptions that contain the
+// query as an exact phrase are boosted.
+func rank(ts []Task, query string, limit int) []SearchResult {
+	terms := Terms(query)
+	if len(terms) == 0 {
+		return []SearchResult{}
+	}
+
+	wanted := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		wanted[term] = true
+	}
+	phrase := strings.Join(terms, " ")
+
+	results := make([]SearchResult, 0, len(ts))
+	for _, t := range ts {
+		words := strings.FieldsFunc(strings.ToLower(t.Description), isSeparator)
+
+		matched := make(map[string]bool, len(terms))
+		matches := 0
+		for _, word := range words {
+			if wanted[word] {
+				matched[word] = true
+				matches++
+			}
+		}
+
+		if len(matched) != len(terms) {
+			continue
+		}
+
+		score := float64(matches) / math.Sqrt(float64(len(words)))
+		if strings.Contains(strings.Join(words, " "), phrase) {
+			score++
+		}
+
+		results = append(results, SearchResult{Task: t, Score: score, Highlight: highlight(t.Description, wanted)})
+	}
+
+	// Break ties with the newest task first and then the ID so that pages are stable
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		if !results[i].Task.DateCreated.Equal(results[j].Task.DateCreated) {
+			return results[i].Task.DateCreated.After(results[j].Task.DateCreated)
+		}
+		return results[i].Task.ID < results[j].Task.ID
+	})
+
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results
+}
+
+// highlight wraps the words in the text that are one of the terms in <mark> tags, escaping the rest of the text.
+func highlight(text string, terms map[string]bool) string {
+	var b strings.Builder
+	runes := []rune(text)
+
+	for start := 0; start < len(runes); {
+		end := start + 1
+		separator := isSeparator(runes[start])
+		for end < len(runes) && isSeparator(runes[end]) == separator {
+			end++
+		}
+
+		chunk := string(runes[start:end])
+		if !separator && terms[strings.ToLower(chunk)] {
+			b.WriteString("<mark>" + html.EscapeString(chunk) + "</mark>")
+		} else {
+			b.WriteString(html.EscapeString(chunk))
+		}
+
+		start = end
+	}
+
+	return b.String()
+}
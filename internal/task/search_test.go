@@ -0,0 +1,30 @@
+package task_test
+
+import (
+	"testing"
+
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTerms(t *testing.T) {
+	testCases := []struct {
+		name     string
+		text     string
+		expected []string
+	}{
+		{name: "Words", text: "buy socks", expected: []string{"buy", "socks"}},
+		{name: "Lowercase", text: "Buy SOCKS", expected: []string{"buy", "socks"}},
+		{name: "Punctuation", text: "buy: socks, shoes & hats!", expected: []string{"buy", "socks", "shoes", "hats"}},
+		{name: "Duplicates", text: "socks, more socks", expected: []string{"socks", "more"}},
+		{name: "Digits", text: "file 2021 taxes", expected: []string{"file", "2021", "taxes"}},
+		{name: "Unicode", text: "café über", expected: []string{"café", "über"}},
+		{name: "Empty", text: " -- ", expected: nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, task.Terms(tc.text))
+		})
+	}
+}
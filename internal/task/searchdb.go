@@ -0,0 +1,63 @@
+package task
+
+import (
+	"context"
+	"database/sql"
+)
+
+// searchCandidateLimit is the maximum number of matching tasks that are ranked for a single search. The most recently
+// created tasks are preferred when there are more matches than this.
+const searchCandidateLimit = 1000
+
+// DBSearcher searches tasks in the database using the inverted index on the terms that DBRepo stores alongside every
+// task. The database narrows the tasks down to the ones that contain every term in the query and they are then ranked
+// and highlighted in process.
+type DBSearcher struct {
+	DB *sql.DB
+}
+
+// Search returns up to limit of the tenant's tasks whose descriptions contain every term in the query, most relevant
+// first. Tasks in archived projects are left out.
+func (dbs DBSearcher) Search(ctx context.Context, tenantID string, query string, limit int) ([]SearchResult, error) {
+	terms := Terms(query)
+	if len(terms) == 0 {
+		return []SearchResult{}, nil
+	}
+
+	const sqlQuery = `select id, owner_id, project_id, parent_id, description, date_due, date_completed, date_created,
+			date_updated, shares, recurrence, reminder_offsets, version, comment_count
+		from task
+		where tenant_id = $1 and search_terms @> $2 and ` + unarchivedCondition + `
+		order by date_created desc
+		limit $3`
+	rows, err := dbs.DB.QueryContext(ctx, sqlQuery, tenantID, terms, searchCandidateLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := scanTasks(rows, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	results := rank(candidates, query, limit)
+
+	// Only look up the details of the tasks that made the cut
+	ts := make([]Task, len(results))
+	for i, result := range results {
+		ts[i] = result.Task
+	}
+
+	err = loadDetails(ctx, dbs.DB, tenantID, ts)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range results {
+		results[i].Task.Tags = ts[i].Tags
+		results[i].Task.Rollup = ts[i].Rollup
+		results[i].Task.BlockedBy = ts[i].BlockedBy
+	}
+
+	return results, nil
+}
@@ -0,0 +1,59 @@
+package task_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntegrationDBSearcherSearch(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+
+	cdbContainer, err := setupCockroachDB(ctx)
+	require.NoError(t, err, "Failed to start up CockroachDB container")
+	defer cdbContainer.Terminate(ctx)
+
+	db, err := sql.Open("pgx", cdbContainer.URI+"/projectmanagement")
+	require.NoError(t, err, "Failed to open connection to CockroachDB")
+	defer db.Close()
+
+	err = initCockroachDB(ctx, db)
+	require.NoError(t, err, "Failed to initialize CockroachDB")
+	defer truncateCockroachDB(ctx, db)
+
+	tdbr := task.DBRepo{DB: db}
+
+	socks := buildSearchTask("tenant-1", "Buy socks")
+	wool := buildSearchTask("tenant-1", "Buy wool socks and a sweater for the winter")
+	shoes := buildSearchTask("tenant-1", "Buy shoes")
+	otherTenant := buildSearchTask("tenant-2", "Buy socks")
+	err = tdbr.SaveBatch(ctx, []task.Task{socks, wool, shoes, otherTenant})
+	require.NoError(t, err, "SaveBatch returned error")
+
+	dbs := task.DBSearcher{DB: db}
+
+	results, err := dbs.Search(ctx, "tenant-1", "socks BUY", 10)
+	require.NoError(t, err, "Search returned error")
+	require.Len(t, results, 2, "Incorrect number of results")
+	assert.Equal(t, socks.ID, results[0].Task.ID)
+	assert.Equal(t, "<mark>Buy</mark> <mark>socks</mark>", results[0].Highlight)
+	assert.Equal(t, wool.ID, results[1].Task.ID)
+
+	// Updates must be reflected in the index
+	shoes.Description = "Buy socks for running"
+	err = tdbr.Update(ctx, shoes)
+	require.NoError(t, err, "Update returned error")
+
+	results, err = dbs.Search(ctx, "tenant-1", "running socks", 10)
+	require.NoError(t, err, "Search returned error")
+	require.Len(t, results, 1, "Incorrect number of results")
+	assert.Equal(t, shoes.ID, results[0].Task.ID)
+}
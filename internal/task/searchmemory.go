@@ -0,0 +1,137 @@
+package task
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryIndex is an in-process inverted index for searching tasks. It is suited to a single replica or to tests since
+// the index is not shared and is lost when the process exits.
+type MemoryIndex struct {
+	mu sync.RWMutex
+	// tasks holds the latest version of each indexed task by tenant and then ID
+	tasks map[string]map[string]Task
+	// postings holds the IDs of the tasks that contain each term by tenant and then term
+	postings map[string]map[string]map[string]bool
+	// archived holds the IDs of the archived projects by tenant
+	archived map[string]map[string]bool
+}
+
+// NewMemoryIndex creates an empty index. The returned pointer will never be nil.
+func NewMemoryIndex() *MemoryIndex {
+	return &MemoryIndex{
+		tasks:    make(map[string]map[string]Task),
+		postings: make(map[string]map[string]map[string]bool),
+		archived: make(map[string]map[string]bool),
+	}
+}
+
+// Index adds the tasks to the index, replacing any earlier versions of them.
+func (idx *MemoryIndex) Index(ctx context.Context, ts ...Task) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	for _, t := range ts {
+		idx.remove(t.TenantID, t.ID)
+
+		if idx.tasks[t.TenantID] == nil {
+			idx.tasks[t.TenantID] = make(map[string]Task)
+			idx.postings[t.TenantID] = make(map[string]map[string]bool)
+		}
+		idx.tasks[t.TenantID][t.ID] = t
+
+		postings := idx.postings[t.TenantID]
+		for _, term := range Terms(t.Description) {
+			if postings[term] == nil {
+				postings[term] = make(map[string]bool)
+			}
+			postings[term][t.ID] = true
+		}
+	}
+
+	return nil
+}
+
+// Remove takes a tenant's task out of the index.
+func (idx *MemoryIndex) Remove(ctx context.Context, tenantID string, id string) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(tenantID, id)
+	return nil
+}
+
+// remove takes a tenant's task out of the index. The caller must hold the write lock.
+func (idx *MemoryIndex) remove(tenantID string, id string) {
+	t, ok := idx.tasks[tenantID][id]
+	if !ok {
+		return
+	}
+
+	postings := idx.postings[tenantID]
+	for _, term := range Terms(t.Description) {
+		delete(postings[term], id)
+		if len(postings[term]) == 0 {
+			delete(postings, term)
+		}
+	}
+
+	delete(idx.tasks[tenantID], id)
+}
+
+// IndexProject records whether or not a tenant's project is archived so that its tasks are left out of searches.
+func (idx *MemoryIndex) IndexProject(ctx context.Context, tenantID string, projectID string, archived bool) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if !archived {
+		delete(idx.archived[tenantID], projectID)
+		return nil
+	}
+
+	if idx.archived[tenantID] == nil {
+		idx.archived[tenantID] = make(map[string]bool)
+	}
+	idx.archived[tenantID][projectID] = true
+
+	return nil
+}
+
+// Search returns up to limit of the tenant's tasks whose descriptions contain every term in the query, most relevant
+// first. Tasks in archived projects are left out.
+func (idx *MemoryIndex) Search(ctx context.Context, tenantID string, query string, limit int) ([]SearchResult, error) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	terms := Terms(query)
+	if len(terms) == 0 {
+		return []SearchResult{}, nil
+	}
+
+	// Intersect the postings starting with the rarest term so that as few tasks as possible are considered
+	postings := idx.postings[tenantID]
+	rarest := postings[terms[0]]
+	for _, term := range terms[1:] {
+		if len(postings[term]) < len(rarest) {
+			rarest = postings[term]
+		}
+	}
+
+	var candidates []Task
+	for id := range rarest {
+		candidate := true
+		for _, term := range terms {
+			if !postings[term][id] {
+				candidate = false
+				break
+			}
+		}
+
+		t := idx.tasks[tenantID][id]
+		if candidate && !idx.archived[tenantID][t.ProjectID] {
+			candidates = append(candidates, t)
+		}
+	}
+
+	return rank(candidates, query, limit), nil
+}
@@ -0,0 +1,177 @@
+package task_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func buildSearchTask(tenantID string, description string) task.Task {
+	tsk := task.New()
+	tsk.TenantID = tenantID
+	tsk.Description = description
+	return *tsk
+}
+
+func TestMemoryIndexSearch(t *testing.T) {
+	ctx := context.Background()
+
+	socks := buildSearchTask("tenant-1", "Buy socks")
+	wool := buildSearchTask("tenant-1", "Buy wool socks and a sweater for the winter")
+	shoes := buildSearchTask("tenant-1", "Buy shoes")
+	otherTenant := buildSearchTask("tenant-2", "Buy socks")
+
+	idx := task.NewMemoryIndex()
+	err := idx.Index(ctx, socks, wool, shoes, otherTenant)
+	require.NoError(t, err, "Index returned error")
+
+	results, err := idx.Search(ctx, "tenant-1", "SOCKS buy", 10)
+	require.NoError(t, err, "Search returned error")
+	require.Len(t, results, 2, "Incorrect number of results")
+
+	// Short descriptions rank above long ones that mention the terms in passing
+	assert.Equal(t, socks, results[0].Task)
+	assert.Equal(t, "<mark>Buy</mark> <mark>socks</mark>", results[0].Highlight)
+	assert.Equal(t, wool, results[1].Task)
+	assert.Equal(t, "<mark>Buy</mark> wool <mark>socks</mark> and a sweater for the winter", results[1].Highlight)
+	assert.Greater(t, results[0].Score, results[1].Score)
+}
+
+func TestMemoryIndexSearchLimit(t *testing.T) {
+	ctx := context.Background()
+
+	older := buildSearchTask("tenant-1", "Buy socks")
+	newer := buildSearchTask("tenant-1", "Buy socks")
+	newer.DateCreated = older.DateCreated.Add(time.Minute)
+
+	idx := task.NewMemoryIndex()
+	err := idx.Index(ctx, older, newer)
+	require.NoError(t, err, "Index returned error")
+
+	// Ties are broken with the newest task first
+	results, err := idx.Search(ctx, "tenant-1", "socks", 1)
+	require.NoError(t, err, "Search returned error")
+	require.Len(t, results, 1, "Incorrect number of results")
+	assert.Equal(t, newer.ID, results[0].Task.ID)
+}
+
+func TestMemoryIndexSearchPhraseBoost(t *testing.T) {
+	ctx := context.Background()
+
+	phrase := buildSearchTask("tenant-1", "Wool socks to buy")
+	scattered := buildSearchTask("tenant-1", "Socks made of wool")
+
+	idx := task.NewMemoryIndex()
+	err := idx.Index(ctx, scattered, phrase)
+	require.NoError(t, err, "Index returned error")
+
+	results, err := idx.Search(ctx, "tenant-1", "wool socks", 10)
+	require.NoError(t, err, "Search returned error")
+	require.Len(t, results, 2, "Incorrect number of results")
+	assert.Equal(t, phrase.ID, results[0].Task.ID)
+}
+
+func TestMemoryIndexSearchEscapesHighlight(t *testing.T) {
+	ctx := context.Background()
+
+	tsk := buildSearchTask("tenant-1", "<script>socks</script>")
+
+	idx := task.NewMemoryIndex()
+	err := idx.Index(ctx, tsk)
+	require.NoError(t, err, "Index returned error")
+
+	results, err := idx.Search(ctx, "tenant-1", "socks", 10)
+	require.NoError(t, err, "Search returned error")
+	require.Len(t, results, 1, "Incorrect number of results")
+	assert.Equal(t, "&lt;script&gt;<mark>socks</mark>&lt;/script&gt;", results[0].Highlight)
+}
+
+func TestMemoryIndexReindex(t *testing.T) {
+	ctx := context.Background()
+
+	tsk := buildSearchTask("tenant-1", "Buy socks")
+
+	idx := task.NewMemoryIndex()
+	err := idx.Index(ctx, tsk)
+	require.NoError(t, err, "Index returned error")
+
+	tsk.Description = "Buy shoes"
+	err = idx.Index(ctx, tsk)
+	require.NoError(t, err, "Index returned error")
+
+	results, err := idx.Search(ctx, "tenant-1", "socks", 10)
+	require.NoError(t, err, "Search returned error")
+	assert.Empty(t, results, "Returned outdated task")
+
+	results, err = idx.Search(ctx, "tenant-1", "shoes", 10)
+	require.NoError(t, err, "Search returned error")
+	require.Len(t, results, 1, "Incorrect number of results")
+	assert.Equal(t, "Buy shoes", results[0].Task.Description)
+}
+
+func TestMemoryIndexRemove(t *testing.T) {
+	ctx := context.Background()
+
+	tsk := buildSearchTask("tenant-1", "Buy socks")
+
+	idx := task.NewMemoryIndex()
+	err := idx.Index(ctx, tsk)
+	require.NoError(t, err, "Index returned error")
+
+	err = idx.Remove(ctx, "tenant-1", tsk.ID)
+	require.NoError(t, err, "Remove returned error")
+
+	results, err := idx.Search(ctx, "tenant-1", "socks", 10)
+	require.NoError(t, err, "Search returned error")
+	assert.Empty(t, results, "Returned removed task")
+}
+
+func TestMemoryIndexSearchArchivedProject(t *testing.T) {
+	ctx := context.Background()
+
+	loose := buildSearchTask("tenant-1", "Buy socks")
+	shelved := buildSearchTask("tenant-1", "Buy socks")
+	shelved.ProjectID = "project-1"
+	otherTenant := buildSearchTask("tenant-2", "Buy socks")
+	otherTenant.ProjectID = "project-1"
+
+	idx := task.NewMemoryIndex()
+	err := idx.Index(ctx, loose, shelved, otherTenant)
+	require.NoError(t, err, "Index returned error")
+
+	err = idx.IndexProject(ctx, "tenant-1", "project-1", true)
+	require.NoError(t, err, "IndexProject returned error")
+
+	results, err := idx.Search(ctx, "tenant-1", "socks", 10)
+	require.NoError(t, err, "Search returned error")
+	require.Len(t, results, 1, "Incorrect number of results")
+	assert.Equal(t, loose.ID, results[0].Task.ID)
+
+	results, err = idx.Search(ctx, "tenant-2", "socks", 10)
+	require.NoError(t, err, "Search returned error")
+	assert.Len(t, results, 1, "Archived another tenant's project")
+
+	// Restoring the project returns its tasks to searches
+	err = idx.IndexProject(ctx, "tenant-1", "project-1", false)
+	require.NoError(t, err, "IndexProject returned error")
+
+	results, err = idx.Search(ctx, "tenant-1", "socks", 10)
+	require.NoError(t, err, "Search returned error")
+	assert.Len(t, results, 2, "Restored project's tasks were not returned")
+}
+
+func TestMemoryIndexSearchNoTerms(t *testing.T) {
+	ctx := context.Background()
+
+	idx := task.NewMemoryIndex()
+	err := idx.Index(ctx, buildSearchTask("tenant-1", "Buy socks"))
+	require.NoError(t, err, "Index returned error")
+
+	results, err := idx.Search(ctx, "tenant-1", "!!", 10)
+	require.NoError(t, err, "Search returned error")
+	assert.Empty(t, results, "Returned results")
+}
@@ -0,0 +1,26 @@
+package task
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrTagExists indicates that a tag could not be stored because the tenant already has a tag with the same name.
+var ErrTagExists = errors.New("tag already exists")
+
+// Tag is a label used to categorize tasks. Tag names are unique within a tenant.
+type Tag struct {
+	ID          string    `json:"id"`
+	TenantID    string    `json:"tenantId"`
+	Name        string    `json:"name"`
+	DateCreated time.Time `json:"dateCreated"`
+	DateUpdated time.Time `json:"dateUpdated"`
+}
+
+// NewTag creates a new tag with default values. The returned pointer will never be nil.
+func NewTag() *Tag {
+	now := time.Now()
+	return &Tag{ID: uuid.New().String(), DateCreated: now, DateUpdated: now}
+}
@@ -0,0 +1,180 @@
+package task
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jackc/pgconn"
+)
+
+// uniqueViolationCode is the SQLSTATE returned when a statement violates a unique constraint.
+const uniqueViolationCode = "23505"
+
+// TagDBClient is a client for retrieving and manipulating tags in a SQL database
+type TagDBClient interface {
+	Get(ctx context.Context, tenantID string, id string) (*Tag, error)
+	GetByNames(ctx context.Context, tenantID string, names []string) ([]Tag, error)
+	List(ctx context.Context, tenantID string) ([]Tag, error)
+	Save(ctx context.Context, t Tag) error
+	Update(ctx context.Context, t Tag) error
+	Delete(ctx context.Context, tenantID string, id string) ([]string, error)
+	TaskIDs(ctx context.Context, tenantID string, id string) ([]string, error)
+}
+
+// TagDBRepo is a database repository for tags.
+type TagDBRepo struct {
+	DB *sql.DB
+}
+
+// Get retrieves a tenant's tag from the database using the tag's ID. If a tag cannot be found with that ID for the
+// tenant, nil will be returned for both the tag and error.
+func (dbr TagDBRepo) Get(ctx context.Context, tenantID string, id string) (*Tag, error) {
+	const query = `select name, date_created, date_updated from tag where tenant_id = $1 and id = $2`
+	row := dbr.DB.QueryRowContext(ctx, query, tenantID, id)
+
+	tg := Tag{ID: id, TenantID: tenantID}
+	err := row.Scan(&tg.Name, &tg.DateCreated, &tg.DateUpdated)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &tg, nil
+}
+
+// GetByNames retrieves a tenant's tags from the database using the tags' names. Tags that cannot be found for the
+// tenant are left out, so the returned tags may be fewer than the names.
+func (dbr TagDBRepo) GetByNames(ctx context.Context, tenantID string, names []string) ([]Tag, error) {
+	if len(names) == 0 {
+		return []Tag{}, nil
+	}
+
+	const query = `select id, tenant_id, name, date_created, date_updated
+		from tag
+		where tenant_id = $1 and name = any($2)
+		order by name`
+	rows, err := dbr.DB.QueryContext(ctx, query, tenantID, names)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanTags(rows)
+}
+
+// List retrieves all of a tenant's tags from the database, ordered by name.
+func (dbr TagDBRepo) List(ctx context.Context, tenantID string) ([]Tag, error) {
+	const query = `select id, tenant_id, name, date_created, date_updated
+		from tag
+		where tenant_id = $1
+		order by name`
+	rows, err := dbr.DB.QueryContext(ctx, query, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanTags(rows)
+}
+
+// Save stores a tag in the database. ErrTagExists is returned if the tenant already has a tag with the same name.
+func (dbr TagDBRepo) Save(ctx context.Context, t Tag) error {
+	const query = `insert into tag (id, tenant_id, name, date_created, date_updated) values ($1, $2, $3, $4, $5)`
+	_, err := dbr.DB.ExecContext(ctx, query, t.ID, t.TenantID, t.Name, t.DateCreated, t.DateUpdated)
+
+	return tagError(err)
+}
+
+// Update renames a tenant's tag in the database. ErrTagExists is returned if the tenant already has another tag with
+// the same name.
+func (dbr TagDBRepo) Update(ctx context.Context, t Tag) error {
+	const query = `update tag set name = $1, date_updated = $2 where tenant_id = $3 and id = $4`
+	_, err := dbr.DB.ExecContext(ctx, query, t.Name, t.DateUpdated, t.TenantID, t.ID)
+
+	return tagError(err)
+}
+
+// Delete removes a tenant's tag from the database along with its associations with tasks. The IDs of the tasks that had
+// the tag are returned.
+func (dbr TagDBRepo) Delete(ctx context.Context, tenantID string, id string) ([]string, error) {
+	tx, err := dbr.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `delete from task_tag where tenant_id = $1 and tag_id = $2 returning task_id`, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	taskIDs, err := scanIDs(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `delete from tag where tenant_id = $1 and id = $2`, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return taskIDs, tx.Commit()
+}
+
+// TaskIDs retrieves the IDs of a tenant's tasks that have the tag.
+func (dbr TagDBRepo) TaskIDs(ctx context.Context, tenantID string, id string) ([]string, error) {
+	const query = `select task_id from task_tag where tenant_id = $1 and tag_id = $2`
+	rows, err := dbr.DB.QueryContext(ctx, query, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanIDs(rows)
+}
+
+// scanIDs reads IDs from rows of a single column. The rows are closed.
+func scanIDs(rows *sql.Rows) ([]string, error) {
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		err := rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// scanTags reads tags from rows of id, tenant_id, name, date_created, and date_updated columns. The rows are closed.
+func scanTags(rows *sql.Rows) ([]Tag, error) {
+	defer rows.Close()
+
+	tgs := []Tag{}
+	for rows.Next() {
+		var tg Tag
+		err := rows.Scan(&tg.ID, &tg.TenantID, &tg.Name, &tg.DateCreated, &tg.DateUpdated)
+		if err != nil {
+			return nil, err
+		}
+
+		tgs = append(tgs, tg)
+	}
+
+	return tgs, rows.Err()
+}
+
+// tagError translates violations of the unique tag name constraint into ErrTagExists.
+func tagError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode {
+		return ErrTagExists
+	}
+
+	return err
+}
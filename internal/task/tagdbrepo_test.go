@@ -0,0 +1,104 @@
+package task_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntegrationTagDBRepo(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+
+	cdbContainer, err := setupCockroachDB(ctx)
+	require.NoError(t, err, "Failed to start up CockroachDB container")
+	defer cdbContainer.Terminate(ctx)
+
+	db, err := sql.Open("pgx", cdbContainer.URI+"/projectmanagement")
+	require.NoError(t, err, "Failed to open connection to CockroachDB")
+	defer db.Close()
+
+	err = initCockroachDB(ctx, db)
+	require.NoError(t, err, "Failed to initialize CockroachDB")
+	defer truncateCockroachDB(ctx, db)
+
+	tgdbr := task.TagDBRepo{DB: db}
+	tdbr := task.DBRepo{DB: db}
+
+	errand := task.NewTag()
+	errand.TenantID = "tenant-1"
+	errand.Name = "errand"
+	home := task.NewTag()
+	home.TenantID = "tenant-1"
+	home.Name = "home"
+	otherTenant := task.NewTag()
+	otherTenant.TenantID = "tenant-2"
+	otherTenant.Name = "errand"
+
+	for _, tg := range []*task.Tag{home, errand, otherTenant} {
+		err = tgdbr.Save(ctx, *tg)
+		require.NoError(t, err, "Save returned error")
+	}
+
+	// Names only need to be unique within a tenant
+	duplicate := task.NewTag()
+	duplicate.TenantID = "tenant-1"
+	duplicate.Name = "errand"
+	err = tgdbr.Save(ctx, *duplicate)
+	assert.ErrorIs(t, err, task.ErrTagExists)
+
+	savedTag, err := tgdbr.Get(ctx, "tenant-1", errand.ID)
+	require.NoError(t, err, "Get returned error")
+	require.NotNil(t, savedTag, "Get did not return a tag")
+	assert.Equal(t, "errand", savedTag.Name)
+
+	savedTag, err = tgdbr.Get(ctx, "tenant-2", errand.ID)
+	require.NoError(t, err, "Get returned error")
+	assert.Nil(t, savedTag, "Get returned another tenant's tag")
+
+	tgs, err := tgdbr.List(ctx, "tenant-1")
+	require.NoError(t, err, "List returned error")
+	require.Len(t, tgs, 2, "List returned incorrect number of tags")
+	assert.Equal(t, errand.ID, tgs[0].ID)
+	assert.Equal(t, home.ID, tgs[1].ID)
+
+	tgs, err = tgdbr.GetByNames(ctx, "tenant-1", []string{"home", "unknown"})
+	require.NoError(t, err, "GetByNames returned error")
+	require.Len(t, tgs, 1, "GetByNames returned incorrect number of tags")
+	assert.Equal(t, home.ID, tgs[0].ID)
+
+	renamed := *home
+	renamed.Name = "errand"
+	err = tgdbr.Update(ctx, renamed)
+	assert.ErrorIs(t, err, task.ErrTagExists)
+
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.Tags = []string{"errand", "home"}
+	err = tdbr.Save(ctx, *tsk)
+	require.NoError(t, err, "Task save returned error")
+
+	ids, err := tgdbr.TaskIDs(ctx, "tenant-1", errand.ID)
+	require.NoError(t, err, "TaskIDs returned error")
+	assert.Equal(t, []string{tsk.ID}, ids)
+
+	ids, err = tgdbr.Delete(ctx, "tenant-1", errand.ID)
+	require.NoError(t, err, "Delete returned error")
+	assert.Equal(t, []string{tsk.ID}, ids)
+
+	savedTag, err = tgdbr.Get(ctx, "tenant-1", errand.ID)
+	require.NoError(t, err, "Get returned error")
+	assert.Nil(t, savedTag, "Tag was not deleted")
+
+	savedTsk, err := tdbr.Get(ctx, "tenant-1", tsk.ID)
+	require.NoError(t, err, "Task get returned error")
+	require.NotNil(t, savedTsk, "Task get did not return a task")
+	assert.Equal(t, []string{"home"}, savedTsk.Tags)
+}
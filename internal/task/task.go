@@ -1,22 +1,189 @@
 package task
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jaredpetersen/go-rest-template/internal/recurrence"
 )
 
+// ErrVersionConflict indicates that a task could not be updated because it has been changed since it was retrieved.
+var ErrVersionConflict = errors.New("task version conflict")
+
+// ErrProjectUnavailable indicates that a task could not be added to a project because the project does not exist or has
+// been archived.
+var ErrProjectUnavailable = errors.New("project does not exist or is archived")
+
+// MaxDepth is the maximum number of levels that subtasks may be nested below a top-level task.
+const MaxDepth = 5
+
+// ErrParentUnavailable indicates that a task could not be made a subtask because the parent task does not exist.
+var ErrParentUnavailable = errors.New("parent task does not exist")
+
+// ErrHierarchyCycle indicates that a task could not be made a subtask because the parent task is the task itself or one
+// of its subtasks.
+var ErrHierarchyCycle = errors.New("task cannot be a subtask of itself or its subtasks")
+
+// ErrDepthExceeded indicates that a task could not be made a subtask because its subtasks would be nested more than
+// MaxDepth levels deep.
+var ErrDepthExceeded = fmt.Errorf("subtasks cannot be nested more than %d levels deep", MaxDepth)
+
+// BatchError indicates that a batch of tasks could not be stored because of one of the tasks in it.
+type BatchError struct {
+	// Index of the task in the batch.
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("task %d in batch: %s", e.Index, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// ErrHasSubtasks indicates that a task could not be deleted because it still has subtasks.
+var ErrHasSubtasks = errors.New("task has subtasks")
+
+// MaxReminders is the maximum number of reminders that a task may have.
+const MaxReminders = 5
+
+// MaxReminderOffset is the furthest ahead of its due date that a task may be reminded about.
+const MaxReminderOffset = 28 * 24 * time.Hour
+
 // Task represents something that must be done.
 type Task struct {
 	ID          string     `json:"id"`
+	TenantID    string     `json:"tenantId"`
+	OwnerID     string     `json:"ownerId"`
 	Description string     `json:"description"`
 	DateDue     *time.Time `json:"dateDue"`
 	DateCreated time.Time  `json:"dateCreated"`
 	DateUpdated time.Time  `json:"dateUpdated"`
+	// ProjectID is the project that contains the task. Empty if the task is not in a project.
+	ProjectID string `json:"projectId,omitempty"`
+	// ParentID is the task that the task is a subtask of. Empty for top-level tasks.
+	ParentID string `json:"parentId,omitempty"`
+	// DateCompleted is when the task was completed. Nil if the task has not been completed.
+	DateCompleted *time.Time `json:"dateCompleted"`
+	// Rollup summarizes the task's subtasks. It is derived from the subtasks rather than stored with the task.
+	Rollup Rollup `json:"rollup"`
+	// BlockedBy are the IDs of the incomplete tasks that block the task, ordered by ID. It is derived from the task's
+	// dependencies rather than stored with the task.
+	BlockedBy []string `json:"blockedBy,omitempty"`
+	// Recurrence repeats the task on a schedule. Nil if the task does not recur.
+	Recurrence *Recurrence `json:"recurrence,omitempty"`
+	// ReminderOffsets are how long before the due date to remind the task's owner about the task, such as zero for when
+	// the task becomes overdue. Empty to use the default reminders.
+	ReminderOffsets []time.Duration `json:"reminderOffsets,omitempty"`
+	// Shares grants principals within the tenant a role on the task. The key is the principal's subject.
+	Shares map[string]string `json:"shares,omitempty"`
+	// Tags are the names of the tenant's tags that categorize the task, ordered by name.
+	Tags []string `json:"tags,omitempty"`
+	// Version is incremented every time the task is updated. Used to detect concurrent updates.
+	Version int `json:"version"`
+	// CommentCount is the number of comments on the task. It is kept up to date as comments are stored and removed, so
+	// comments do not change the task's version.
+	CommentCount int `json:"commentCount"`
+}
+
+// Rollup summarizes all of a task's subtasks, including the subtasks of its subtasks.
+type Rollup struct {
+	Subtasks  int `json:"subtasks"`
+	Completed int `json:"completed"`
+}
+
+// PercentComplete is the percentage of the subtasks that have been completed, rounded down. Tasks without any subtasks
+// are 0% complete.
+func (r Rollup) PercentComplete() int {
+	if r.Subtasks == 0 {
+		return 0
+	}
+
+	return r.Completed * 100 / r.Subtasks
+}
+
+// Recurrence repeats a task on a schedule. Every occurrence is a separate task; completing an occurrence creates the
+// next one and moves the recurrence over to it.
+type Recurrence struct {
+	// Rule is an RFC 5545 recurrence rule, such as "FREQ=WEEKLY;BYDAY=MO".
+	Rule string `json:"rule"`
+	// TimeZone is the IANA time zone that the rule is expanded in, such as "America/New_York".
+	TimeZone string `json:"timeZone"`
+	// DateStart is the first occurrence, which the rule is expanded from.
+	DateStart time.Time `json:"dateStart"`
+	// ExDates are occurrences that are skipped.
+	ExDates []time.Time `json:"exDates,omitempty"`
+}
+
+// Set builds the recurrence set that the recurrence describes.
+func (r Recurrence) Set() (*recurrence.Set, error) {
+	rule, err := recurrence.Parse(r.Rule)
+	if err != nil {
+		return nil, err
+	}
+
+	loc, err := time.LoadLocation(r.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+
+	return &recurrence.Set{Rule: *rule, Start: r.DateStart.In(loc), ExDates: r.ExDates}, nil
 }
 
 // New creates a new task with default values. The returned pointer will never be nil.
 func New() *Task {
 	now := time.Now()
-	return &Task{ID: uuid.New().String(), DateCreated: now, DateUpdated: now}
+	return &Task{ID: uuid.New().String(), DateCreated: now, DateUpdated: now, Version: 1}
+}
+
+// Completed indicates whether or not the task has been completed.
+func (t Task) Completed() bool {
+	return t.DateCompleted != nil
+}
+
+// NextOccurrence creates the occurrence of a recurring task that comes after the task's due date. The next occurrence
+// is a copy of the task that takes over its recurrence. Nil is returned if the task does not recur or its recurrence
+// has ended.
+func (t Task) NextOccurrence() (*Task, error) {
+	if t.Recurrence == nil {
+		return nil, nil
+	}
+
+	s, err := t.Recurrence.Set()
+	if err != nil {
+		return nil, err
+	}
+
+	after := t.Recurrence.DateStart
+	if t.DateDue != nil {
+		after = *t.DateDue
+	}
+
+	dateDue, ok := s.After(after)
+	if !ok {
+		return nil, nil
+	}
+
+	next := New()
+	next.TenantID = t.TenantID
+	next.OwnerID = t.OwnerID
+	next.Description = t.Description
+	next.DateDue = &dateDue
+	next.ProjectID = t.ProjectID
+	next.ParentID = t.ParentID
+	next.Recurrence = t.Recurrence
+	next.ReminderOffsets = t.ReminderOffsets
+	next.Shares = t.Shares
+	next.Tags = t.Tags
+
+	return next, nil
+}
+
+// Blocked indicates whether or not the task is waiting on other tasks to be completed.
+func (t Task) Blocked() bool {
+	return len(t.BlockedBy) > 0
 }
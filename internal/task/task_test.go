@@ -4,10 +4,13 @@ import (
 	"encoding/json"
 	"github.com/jaredpetersen/go-rest-template/internal/task"
 	"testing"
+	"time"
+	_ "time/tzdata"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func taskMatcher(expectedTask task.Task) func(value []byte) bool {
@@ -40,6 +43,113 @@ func TestNew(t *testing.T) {
 
 	assert.Equal(t, tsk.DateCreated, tsk.DateUpdated, "DateCreated and DateUpdated are not equal")
 
-	expectedTask := task.Task{ID: tsk.ID, DateCreated: tsk.DateCreated, DateUpdated: tsk.DateUpdated}
+	assert.Equal(t, 1, tsk.Version, "Did not initialize Version")
+
+	expectedTask := task.Task{ID: tsk.ID, DateCreated: tsk.DateCreated, DateUpdated: tsk.DateUpdated, Version: 1}
 	assert.Equal(t, expectedTask, *tsk, "Task is setting more defaults than expected")
 }
+
+func TestRollupPercentComplete(t *testing.T) {
+	var tests = []struct {
+		name     string
+		rollup   task.Rollup
+		expected int
+	}{
+		{name: "NoSubtasks", rollup: task.Rollup{}, expected: 0},
+		{name: "NoneCompleted", rollup: task.Rollup{Subtasks: 4}, expected: 0},
+		{name: "SomeCompleted", rollup: task.Rollup{Subtasks: 3, Completed: 2}, expected: 66},
+		{name: "AllCompleted", rollup: task.Rollup{Subtasks: 3, Completed: 3}, expected: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.rollup.PercentComplete())
+		})
+	}
+}
+
+func TestNextOccurrence(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	dateStart := time.Date(2026, time.March, 2, 9, 0, 0, 0, loc)
+	dateDue := time.Date(2026, time.March, 4, 9, 0, 0, 0, loc)
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+	tsk.Description = "Water the plants"
+	tsk.DateDue = &dateDue
+	tsk.Tags = []string{"home"}
+	tsk.Recurrence = &task.Recurrence{Rule: "FREQ=WEEKLY;BYDAY=MO,WE", TimeZone: "America/New_York", DateStart: dateStart}
+
+	next, err := tsk.NextOccurrence()
+	require.NoError(t, err)
+	require.NotNil(t, next)
+
+	// The clocks spring forward between occurrences, which still happen at 9 AM
+	expectedDateDue := time.Date(2026, time.March, 9, 9, 0, 0, 0, loc)
+	assert.True(t, expectedDateDue.Equal(*next.DateDue), "Incorrect due date %s", next.DateDue)
+	assert.NotEqual(t, tsk.ID, next.ID, "Next occurrence reused the task's ID")
+	assert.Equal(t, tsk.TenantID, next.TenantID)
+	assert.Equal(t, tsk.OwnerID, next.OwnerID)
+	assert.Equal(t, tsk.Description, next.Description)
+	assert.Equal(t, tsk.Tags, next.Tags)
+	assert.Equal(t, tsk.Recurrence, next.Recurrence)
+	assert.Nil(t, next.DateCompleted)
+	assert.Equal(t, 1, next.Version)
+}
+
+func TestNextOccurrenceWithoutDueDate(t *testing.T) {
+	dateStart := time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+	tsk := task.New()
+	tsk.Recurrence = &task.Recurrence{Rule: "FREQ=DAILY", TimeZone: "UTC", DateStart: dateStart}
+
+	next, err := tsk.NextOccurrence()
+	require.NoError(t, err)
+	require.NotNil(t, next)
+	assert.Equal(t, dateStart.AddDate(0, 0, 1), *next.DateDue)
+}
+
+func TestNextOccurrenceNotRecurring(t *testing.T) {
+	next, err := task.New().NextOccurrence()
+	assert.NoError(t, err)
+	assert.Nil(t, next)
+}
+
+func TestNextOccurrenceEnded(t *testing.T) {
+	dateStart := time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+	dateDue := dateStart.AddDate(0, 0, 1)
+	tsk := task.New()
+	tsk.DateDue = &dateDue
+	tsk.Recurrence = &task.Recurrence{Rule: "FREQ=DAILY;COUNT=2", TimeZone: "UTC", DateStart: dateStart}
+
+	next, err := tsk.NextOccurrence()
+	assert.NoError(t, err)
+	assert.Nil(t, next)
+}
+
+func TestNextOccurrenceSkipsExDates(t *testing.T) {
+	dateStart := time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+	tsk := task.New()
+	tsk.DateDue = &dateStart
+	tsk.Recurrence = &task.Recurrence{
+		Rule:      "FREQ=DAILY",
+		TimeZone:  "UTC",
+		DateStart: dateStart,
+		ExDates:   []time.Time{dateStart.AddDate(0, 0, 1)},
+	}
+
+	next, err := tsk.NextOccurrence()
+	require.NoError(t, err)
+	require.NotNil(t, next)
+	assert.Equal(t, dateStart.AddDate(0, 0, 2), *next.DateDue)
+}
+
+func TestNextOccurrenceInvalidTimeZone(t *testing.T) {
+	tsk := task.New()
+	tsk.Recurrence = &task.Recurrence{Rule: "FREQ=DAILY", TimeZone: "Mars/Olympus_Mons", DateStart: time.Now()}
+
+	next, err := tsk.NextOccurrence()
+	assert.Error(t, err)
+	assert.Nil(t, next)
+}
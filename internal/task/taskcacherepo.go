@@ -9,19 +9,103 @@ import (
 
 // CacheClient is a client for retrieving and manipulating tasks in the cache
 type CacheClient interface {
-	Get(ctx context.Context, id string) (*Task, error)
+	Get(ctx context.Context, tenantID string, id string) (*Task, error)
+	GetBatch(ctx context.Context, tenantID string, ids []string) ([]*Task, error)
 	Save(ctx context.Context, t Task) error
+	SaveBatch(ctx context.Context, ts []Task) error
+	Delete(ctx context.Context, tenantID string, id string) error
+	RenameTag(ctx context.Context, tenantID string, ids []string, from string, to string) error
 }
 
+// saveScript stores each task unless the cache already has the same or a newer version of it, so that a slow writer
+// cannot replace a newer version with an older one.
+//
+// KEYS[n] - task key
+// ARGV[2n - 1] - task JSON
+// ARGV[2n] - task version
+//
+// Returns the number of tasks that were stored
+const saveScript = `
+local saved = 0
+for i, key in ipairs(KEYS) do
+  local version = tonumber(ARGV[i * 2])
+  local outdated = false
+
+  local current = redis.call('GET', key)
+  if current then
+    local ok, decoded = pcall(cjson.decode, current)
+    outdated = ok and type(decoded) == 'table' and tonumber(decoded.version) ~= nil and tonumber(decoded.version) >= version
+  end
+
+  if not outdated then
+    redis.call('SET', key, ARGV[i * 2 - 1])
+    saved = saved + 1
+  end
+end
+
+return saved
+`
+
+// renameTagScript replaces a tag name in each cached task that has it, removing the tag instead if the new name is
+// empty. Tasks that are not cached are skipped rather than stored.
+//
+// KEYS[n] - task key
+// ARGV[1] - current tag name
+// ARGV[2] - new tag name
+//
+// Returns the number of tasks that were changed
+const renameTagScript = `
+local renamed = 0
+for _, key in ipairs(KEYS) do
+  local current = redis.call('GET', key)
+  if current then
+    local ok, decoded = pcall(cjson.decode, current)
+    if ok and type(decoded) == 'table' and type(decoded.tags) == 'table' then
+      local tags = {}
+      local changed = false
+      for _, name in ipairs(decoded.tags) do
+        if name == ARGV[1] then
+          changed = true
+          if ARGV[2] ~= '' then
+            table.insert(tags, ARGV[2])
+          end
+        else
+          table.insert(tags, name)
+        end
+      end
+
+      if changed then
+        -- An empty table would be encoded as an object so leave the field out instead
+        if #tags == 0 then
+          decoded.tags = nil
+        else
+          table.sort(tags)
+          decoded.tags = tags
+        end
+
+        redis.call('SET', key, cjson.encode(decoded))
+        renamed = renamed + 1
+      end
+    end
+  end
+end
+
+return renamed
+`
+
+// renameTagBatchSize is the maximum number of tasks that are changed by a single script call so that Redis is not
+// blocked for long.
+const renameTagBatchSize = 100
+
 // CacheRepo is a cache repository for tasks.
 type CacheRepo struct {
 	Redis redis.Client
 }
 
-// Get retrieves a task from the cache using the task's ID. If a task cannot be found with that ID, nil will be
+// Get retrieves a tenant's task from the cache using the task's ID. If a task cannot be found with that ID, nil will be
 // returned for both the task and error.
-func (cr CacheRepo) Get(ctx context.Context, id string) (*Task, error) {
-	key := getRedisKey(id)
+func (cr CacheRepo) Get(ctx context.Context, tenantID string, id string) (*Task, error) {
+	key := getRedisKey(tenantID, id)
 	val, err := cr.Redis.Get(ctx, key)
 	if err != nil {
 		return nil, err
@@ -36,18 +120,99 @@ func (cr CacheRepo) Get(ctx context.Context, id string) (*Task, error) {
 	return &t, err
 }
 
-// Save stores a task in the cache.
-func (cr CacheRepo) Save(ctx context.Context, t Task) error {
-	key := getRedisKey(t.ID)
-	value, err := json.Marshal(t)
+// GetBatch retrieves a tenant's tasks from the cache in a single round trip. The tasks are returned in the same order
+// as the IDs, with nil for tasks that cannot be found.
+func (cr CacheRepo) GetBatch(ctx context.Context, tenantID string, ids []string) ([]*Task, error) {
+	if len(ids) == 0 {
+		return []*Task{}, nil
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = getRedisKey(tenantID, id)
+	}
+
+	vals, err := cr.Redis.MGet(ctx, keys...)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	ts := make([]*Task, len(vals))
+	for i, val := range vals {
+		if val == nil {
+			continue
+		}
+
+		var t Task
+		err = json.Unmarshal([]byte(*val), &t)
+		if err != nil {
+			return nil, err
+		}
+		ts[i] = &t
+	}
+
+	return ts, nil
+}
+
+// Save stores a task in the cache. The cache is left unchanged if it already has the same or a newer version of the
+// task.
+func (cr CacheRepo) Save(ctx context.Context, t Task) error {
+	return cr.SaveBatch(ctx, []Task{t})
+}
+
+// SaveBatch stores tasks in the cache in a single round trip. Tasks are left unchanged if the cache already has the
+// same or a newer version of them.
+func (cr CacheRepo) SaveBatch(ctx context.Context, ts []Task) error {
+	if len(ts) == 0 {
+		return nil
+	}
+
+	keys := make([]string, len(ts))
+	args := make([]interface{}, 0, len(ts)*2)
+	for i, t := range ts {
+		value, err := json.Marshal(t)
+		if err != nil {
+			return err
+		}
+
+		keys[i] = getRedisKey(t.TenantID, t.ID)
+		args = append(args, value, t.Version)
+	}
+
+	_, err := cr.Redis.Eval(ctx, saveScript, keys, args...)
+	return err
+}
+
+// Delete removes a tenant's task from the cache.
+func (cr CacheRepo) Delete(ctx context.Context, tenantID string, id string) error {
+	return cr.Redis.Del(ctx, getRedisKey(tenantID, id))
+}
+
+// RenameTag replaces the name of a tag in a tenant's cached tasks so that renames are reflected without waiting for the
+// tasks to be stored again. The tag is removed from the tasks instead if the new name is empty.
+func (cr CacheRepo) RenameTag(ctx context.Context, tenantID string, ids []string, from string, to string) error {
+	for start := 0; start < len(ids); start += renameTagBatchSize {
+		end := start + renameTagBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		keys := make([]string, 0, end-start)
+		for _, id := range ids[start:end] {
+			keys = append(keys, getRedisKey(tenantID, id))
+		}
+
+		_, err := cr.Redis.Eval(ctx, renameTagScript, keys, from, to)
+		if err != nil {
+			return err
+		}
 	}
 
-	return cr.Redis.Set(ctx, key, value, 0)
+	return nil
 }
 
-// getRedisKey builds a redis key for the task in the cache.
-func getRedisKey(id string) string {
-	return "task." + id
+// getRedisKey builds a redis key for the task in the cache. Keys are namespaced by tenant so that a task can never be
+// served to another tenant, even if the IDs collide.
+func getRedisKey(tenantID string, id string) string {
+	return "tenant." + tenantID + ".task." + id
 }
@@ -2,25 +2,70 @@ package task_test
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/jaredpetersen/go-rest-template/internal/redis"
 	"github.com/jaredpetersen/go-rest-template/internal/task"
 	"testing"
-	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
 
 	redismock "github.com/jaredpetersen/go-rest-template/internal/redis/mocks"
 )
 
+type redisContainer struct {
+	testcontainers.Container
+	URI string
+}
+
+// setupRedis starts up a Redis container
+//
+// Returned Redis container must be explicitly terminated
+func setupRedis(ctx context.Context) (*redisContainer, error) {
+	req := testcontainers.ContainerRequest{
+		Image:        "redis:6",
+		ExposedPorts: []string{"6379/tcp"},
+		WaitingFor:   wait.ForLog("* Ready to accept connections"),
+		SkipReaper:   true,
+	}
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	mappedPort, err := container.MappedPort(ctx, "6379")
+	if err != nil {
+		return nil, err
+	}
+
+	hostIP, err := container.Host(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	uri := fmt.Sprintf("redis://%s:%s", hostIP, mappedPort.Port())
+
+	return &redisContainer{Container: container, URI: uri}, nil
+}
+
 func TestCacheRepoSave(t *testing.T) {
 	ctx := context.Background()
 
 	tsk := task.New()
+	tsk.TenantID = "tenant-1"
 
 	rdb := redismock.Client{}
-	rdb.On("Set", mock.Anything, "task."+tsk.ID, mock.MatchedBy(taskMatcher(*tsk)), time.Duration(0)).Return(nil)
+	rdb.On("Eval", mock.Anything, mock.AnythingOfType("string"), []string{"tenant.tenant-1.task." + tsk.ID}, mock.MatchedBy(taskMatcher(*tsk)), 1).
+		Return(int64(1), nil)
 
 	tcr := task.CacheRepo{Redis: &rdb}
 
@@ -38,12 +83,117 @@ func TestCacheRepoSaveReturnsRedisError(t *testing.T) {
 	expectedErr := errors.New("Failed")
 
 	rdb := redismock.Client{}
-	rdb.On("Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(expectedErr)
+	rdb.On("Eval", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, expectedErr)
+
+	tcr := task.CacheRepo{Redis: &rdb}
+
+	err := tcr.Save(ctx, *tsk)
+	assert.EqualError(t, err, expectedErr.Error(), "Did not return error")
+}
+
+func TestCacheRepoSaveOutdatedVersion(t *testing.T) {
+	ctx := context.Background()
+
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+
+	// Cache already has a newer version so the script does not store the task
+	rdb := redismock.Client{}
+	rdb.On("Eval", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(int64(0), nil)
 
 	tcr := task.CacheRepo{Redis: &rdb}
 
 	err := tcr.Save(ctx, *tsk)
+	assert.NoError(t, err, "Returned error")
+}
+
+func TestCacheRepoSaveBatch(t *testing.T) {
+	ctx := context.Background()
+
+	first := task.New()
+	first.TenantID = "tenant-1"
+	second := task.New()
+	second.TenantID = "tenant-1"
+	second.Version = 2
+
+	rdb := redismock.Client{}
+	rdb.On("Eval",
+		mock.Anything,
+		mock.AnythingOfType("string"),
+		[]string{"tenant.tenant-1.task." + first.ID, "tenant.tenant-1.task." + second.ID},
+		mock.MatchedBy(taskMatcher(*first)),
+		1,
+		mock.MatchedBy(taskMatcher(*second)),
+		2).
+		Return(int64(2), nil)
+
+	tcr := task.CacheRepo{Redis: &rdb}
+
+	err := tcr.SaveBatch(ctx, []task.Task{*first, *second})
+	assert.NoError(t, err, "Returned error")
+
+	rdb.AssertExpectations(t)
+}
+
+func TestCacheRepoSaveBatchEmpty(t *testing.T) {
+	ctx := context.Background()
+
+	rdb := redismock.Client{}
+
+	tcr := task.CacheRepo{Redis: &rdb}
+
+	err := tcr.SaveBatch(ctx, []task.Task{})
+	assert.NoError(t, err, "Returned error")
+
+	rdb.AssertExpectations(t)
+}
+
+func TestCacheRepoGetBatch(t *testing.T) {
+	ctx := context.Background()
+
+	storedTask := "{\"id\":\"first\",\"description\":\"buy socks\",\"version\":3}"
+
+	rdb := redismock.Client{}
+	rdb.On("MGet", mock.Anything, "tenant.tenant-1.task.first", "tenant.tenant-1.task.second").Return([]*string{&storedTask, nil}, nil)
+
+	tcr := task.CacheRepo{Redis: &rdb}
+
+	tsks, err := tcr.GetBatch(ctx, "tenant-1", []string{"first", "second"})
+	assert.NoError(t, err, "Returned error")
+	assert.Equal(t, []*task.Task{{ID: "first", Description: "buy socks", Version: 3}, nil}, tsks)
+
+	rdb.AssertExpectations(t)
+}
+
+func TestCacheRepoGetBatchReturnsRedisError(t *testing.T) {
+	ctx := context.Background()
+
+	expectedErr := errors.New("Failed")
+
+	rdb := redismock.Client{}
+	rdb.On("MGet", mock.Anything, mock.Anything).Return(nil, expectedErr)
+
+	tcr := task.CacheRepo{Redis: &rdb}
+
+	tsks, err := tcr.GetBatch(ctx, "tenant-1", []string{"first"})
 	assert.EqualError(t, err, expectedErr.Error(), "Did not return error")
+	assert.Nil(t, tsks, "Returned tasks")
+}
+
+func TestCacheRepoDelete(t *testing.T) {
+	ctx := context.Background()
+
+	id := "2b7e1292-a831-4df5-b00e-3105a51111bb"
+
+	rdb := redismock.Client{}
+	rdb.On("Del", mock.Anything, "tenant.tenant-1.task."+id).Return(nil)
+
+	tcr := task.CacheRepo{Redis: &rdb}
+
+	err := tcr.Delete(ctx, "tenant-1", id)
+	assert.NoError(t, err, "Returned error")
+
+	rdb.AssertExpectations(t)
 }
 
 func TestCacheRepoGet(t *testing.T) {
@@ -54,11 +204,11 @@ func TestCacheRepoGet(t *testing.T) {
 	storedTask := fmt.Sprintf("{\"description\":\"%s\"}", description)
 
 	rdb := redismock.Client{}
-	rdb.On("Get", mock.Anything, "task."+id).Return(&storedTask, nil)
+	rdb.On("Get", mock.Anything, "tenant.tenant-1.task."+id).Return(&storedTask, nil)
 
 	tcr := task.CacheRepo{Redis: &rdb}
 
-	tsk, err := tcr.Get(ctx, id)
+	tsk, err := tcr.Get(ctx, "tenant-1", id)
 	assert.NoError(t, err, "Returned error")
 	assert.NotEqual(t, &tsk, task.Task{Description: description}, "Task is incorrect")
 
@@ -71,11 +221,11 @@ func TestCacheRepoGetNotExists(t *testing.T) {
 	id := "868e5655-660e-41f1-b271-b00172d7fa2d"
 
 	rdb := redismock.Client{}
-	rdb.On("Get", mock.Anything, "task."+id).Return(nil, nil)
+	rdb.On("Get", mock.Anything, "tenant.tenant-1.task."+id).Return(nil, nil)
 
 	tcr := task.CacheRepo{Redis: &rdb}
 
-	tsk, err := tcr.Get(ctx, id)
+	tsk, err := tcr.Get(ctx, "tenant-1", id)
 	assert.NoError(t, err, "Returned error")
 	assert.Nil(t, tsk, "Task should be nil")
 
@@ -94,7 +244,100 @@ func TestCacheRepoGetReturnsRedisError(t *testing.T) {
 
 	tcr := task.CacheRepo{Redis: &rdb}
 
-	tsk, err := tcr.Get(ctx, id)
+	tsk, err := tcr.Get(ctx, "tenant-1", id)
 	assert.Nil(t, tsk, "Task should be nil")
 	assert.EqualError(t, err, expectedError.Error(), "Did not return error")
 }
+
+func TestCacheRepoRenameTag(t *testing.T) {
+	ctx := context.Background()
+
+	// Enough tasks that the rename is split across multiple script calls
+	ids := make([]string, 150)
+	keys := make([]string, len(ids))
+	for i := range ids {
+		ids[i] = uuid.NewString()
+		keys[i] = "tenant.tenant-1.task." + ids[i]
+	}
+
+	rdb := redismock.Client{}
+	rdb.On("Eval", mock.Anything, mock.AnythingOfType("string"), keys[:100], "errand", "chore").Return(int64(100), nil).Once()
+	rdb.On("Eval", mock.Anything, mock.AnythingOfType("string"), keys[100:], "errand", "chore").Return(int64(50), nil).Once()
+
+	tcr := task.CacheRepo{Redis: &rdb}
+
+	err := tcr.RenameTag(ctx, "tenant-1", ids, "errand", "chore")
+	assert.NoError(t, err, "Returned error")
+
+	rdb.AssertExpectations(t)
+}
+
+func TestCacheRepoRenameTagReturnsRedisError(t *testing.T) {
+	ctx := context.Background()
+
+	expectedErr := errors.New("Failed")
+
+	rdb := redismock.Client{}
+	rdb.On("Eval", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, expectedErr)
+
+	tcr := task.CacheRepo{Redis: &rdb}
+
+	err := tcr.RenameTag(ctx, "tenant-1", []string{uuid.NewString()}, "errand", "chore")
+	assert.EqualError(t, err, expectedErr.Error(), "Did not return error")
+}
+
+func TestIntegrationCacheRepoRenameTag(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+	redisContainer, err := setupRedis(ctx)
+	require.NoError(t, err, "Failed to start up Redis container")
+	defer redisContainer.Terminate(ctx)
+
+	rdb, err := redis.New(redis.Config{URI: redisContainer.URI})
+	require.NoError(t, err, "Client instantiation error")
+	defer rdb.Close()
+
+	tcr := task.CacheRepo{Redis: rdb}
+
+	renamed := task.New()
+	renamed.TenantID = "tenant-1"
+	renamed.Tags = []string{"errand", "urgent"}
+	renamed.Shares = map[string]string{"user-2": "viewer"}
+	removed := task.New()
+	removed.TenantID = "tenant-1"
+	removed.Tags = []string{"errand"}
+	uncached := task.New()
+	uncached.TenantID = "tenant-1"
+
+	err = tcr.SaveBatch(ctx, []task.Task{*renamed, *removed})
+	require.NoError(t, err, "SaveBatch returned error")
+
+	err = tcr.RenameTag(ctx, "tenant-1", []string{renamed.ID, removed.ID, uncached.ID}, "errand", "zzz")
+	require.NoError(t, err, "RenameTag returned error")
+
+	cachedTsk, err := tcr.Get(ctx, "tenant-1", renamed.ID)
+	require.NoError(t, err, "Get returned error")
+	require.NotNil(t, cachedTsk, "Task is not cached")
+	assert.Equal(t, []string{"urgent", "zzz"}, cachedTsk.Tags)
+	assert.Equal(t, renamed.Shares, cachedTsk.Shares)
+	assert.Equal(t, renamed.Version, cachedTsk.Version)
+
+	// Removing the last tag leaves the field out rather than encoding an empty Lua table as an object
+	err = tcr.RenameTag(ctx, "tenant-1", []string{removed.ID}, "zzz", "")
+	require.NoError(t, err, "RenameTag returned error")
+
+	val, err := rdb.Get(ctx, "tenant.tenant-1.task."+removed.ID)
+	require.NoError(t, err, "Get returned error")
+	require.NotNil(t, val, "Task is not cached")
+	fields := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal([]byte(*val), &fields))
+	assert.NotContains(t, fields, "tags")
+
+	// Tasks that were not cached are not stored
+	cachedTsk, err = tcr.Get(ctx, "tenant-1", uncached.ID)
+	require.NoError(t, err, "Get returned error")
+	assert.Nil(t, cachedTsk, "Uncached task was stored")
+}
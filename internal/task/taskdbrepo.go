@@ -3,29 +3,128 @@ package task
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 // DBClient is a client for retrieving and manipulating tasks in a SQL database
 type DBClient interface {
-	Get(ctx context.Context, id string) (*Task, error)
+	Get(ctx context.Context, tenantID string, id string) (*Task, error)
+	GetBatch(ctx context.Context, tenantID string, ids []string) ([]Task, error)
+	List(ctx context.Context, tenantID string, f Filter) ([]Task, int, error)
+	ListSubtasks(ctx context.Context, tenantID string, id string, f SubtaskFilter) ([]Task, int, error)
+	GetAncestorIDs(ctx context.Context, tenantID string, ids []string) ([]string, error)
 	Save(ctx context.Context, t Task) error
+	SaveBatch(ctx context.Context, ts []Task) error
+	Update(ctx context.Context, t Task) error
+	CompleteOccurrence(ctx context.Context, t Task, next Task) error
+	Delete(ctx context.Context, t Task) error
+}
+
+// Filter narrows down the tasks that are listed.
+type Filter struct {
+	// Tags are the names of the tags that the tasks must have. Every tag is required unless AnyTag is set.
+	Tags []string
+	// AnyTag lists tasks that have at least one of the tags instead of all of them.
+	AnyTag bool
+	// ProjectID lists the tasks in the project. Tasks in archived projects are otherwise left out.
+	ProjectID string
+	// Visibility limits the tasks to the ones that a principal can see. Every task is listed if nil.
+	Visibility *Visibility
+	// Limit is the maximum number of tasks to list.
+	Limit int
+	// Offset is the number of matching tasks to skip before listing any.
+	Offset int
+}
+
+// SubtaskFilter narrows down the subtasks that are listed.
+type SubtaskFilter struct {
+	// Recursive lists the whole tree below the task instead of only its direct subtasks.
+	Recursive bool
+	// Visibility limits the subtasks to the ones that a principal can see. Every subtask is listed if nil.
+	Visibility *Visibility
+	// Limit is the maximum number of subtasks to list.
+	Limit int
+	// Offset is the number of matching subtasks to skip before listing any.
+	Offset int
+}
+
+// Visibility describes which tasks a principal can see when they cannot see every task within the tenant. A principal
+// that can see neither the tasks that they own nor any that are shared with them sees no tasks at all.
+type Visibility struct {
+	// Subject identifies the principal.
+	Subject string
+	// Owned indicates that the principal can see the tasks that they own.
+	Owned bool
+	// SharedAs are the roles that the principal can see tasks that have been shared with them as.
+	SharedAs []string
+}
+
+// condition builds a SQL condition that leaves out the tasks that cannot be seen, appending its query parameters to
+// args. The table is used to qualify the task's columns.
+func (v *Visibility) condition(table string, args []interface{}) (string, []interface{}) {
+	if v == nil {
+		return "true", args
+	}
+
+	var conditions []string
+	if v.Owned {
+		args = append(args, v.Subject)
+		conditions = append(conditions, table+`.owner_id = $`+strconv.Itoa(len(args)))
+	}
+	if len(v.SharedAs) > 0 {
+		args = append(args, v.Subject, v.SharedAs)
+		conditions = append(conditions,
+			table+`.shares->>$`+strconv.Itoa(len(args)-1)+`::text = any($`+strconv.Itoa(len(args))+`)`)
+	}
+	if len(conditions) == 0 {
+		return "false", args
+	}
+
+	return "(" + strings.Join(conditions, " or ") + ")", args
 }
 
+// unarchivedCondition is a SQL condition that leaves out tasks in archived projects. The tenant ID must be the first
+// query parameter.
+const unarchivedCondition = `(project_id is null or project_id not in (
+		select id from project where tenant_id = $1 and date_archived is not null))`
+
 // DBRepo is a database repository for tasks.
 type DBRepo struct {
 	DB *sql.DB
 }
 
-// Get retrieves a task from the database using the task's ID. If a task cannot be found with that ID, nil will be
-// returned for both the task and error.
-func (dbr DBRepo) Get(ctx context.Context, id string) (*Task, error) {
-	const query = `select description, date_due, date_created, date_updated
+// Get retrieves a tenant's task from the database using the task's ID. If a task cannot be found with that ID for the
+// tenant, nil will be returned for both the task and error. Tasks in archived projects are still retrieved.
+func (dbr DBRepo) Get(ctx context.Context, tenantID string, id string) (*Task, error) {
+	const query = `select owner_id, project_id, parent_id, description, date_due, date_completed, date_created,
+			date_updated, shares, recurrence, reminder_offsets, version, comment_count
 		from task
-		where id = $1`
-	row := dbr.DB.QueryRowContext(ctx, query, id)
+		where tenant_id = $1 and id = $2`
+	row := dbr.DB.QueryRowContext(ctx, query, tenantID, id)
 
-	tsk := Task{ID: id}
-	err := row.Scan(&tsk.Description, &tsk.DateDue, &tsk.DateCreated, &tsk.DateUpdated)
+	tsk := Task{ID: id, TenantID: tenantID}
+	var projectID, parentID sql.NullString
+	var shares, recurrence, reminderOffsets []byte
+	err := row.Scan(
+		&tsk.OwnerID,
+		&projectID,
+		&parentID,
+		&tsk.Description,
+		&tsk.DateDue,
+		&tsk.DateCompleted,
+		&tsk.DateCreated,
+		&tsk.DateUpdated,
+		&shares,
+		&recurrence,
+		&reminderOffsets,
+		&tsk.Version,
+		&tsk.CommentCount)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -33,20 +132,1062 @@ func (dbr DBRepo) Get(ctx context.Context, id string) (*Task, error) {
 		return nil, err
 	}
 
-	return &tsk, nil
+	tsk.ProjectID = projectID.String
+	tsk.ParentID = parentID.String
+	err = unmarshalShares(shares, &tsk)
+	if err != nil {
+		return nil, err
+	}
+
+	err = unmarshalRecurrence(recurrence, &tsk)
+	if err != nil {
+		return nil, err
+	}
+
+	err = unmarshalReminderOffsets(reminderOffsets, &tsk)
+	if err != nil {
+		return nil, err
+	}
+
+	ts := []Task{tsk}
+	err = loadDetails(ctx, dbr.DB, tenantID, ts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ts[0], nil
+}
+
+// GetBatch retrieves a tenant's tasks from the database in a single query. Tasks that cannot be found for the tenant
+// are left out, so the returned tasks may be fewer than the IDs and are in no particular order. Tasks in archived
+// projects are still retrieved.
+func (dbr DBRepo) GetBatch(ctx context.Context, tenantID string, ids []string) ([]Task, error) {
+	if len(ids) == 0 {
+		return []Task{}, nil
+	}
+
+	args := make([]interface{}, 0, len(ids)+1)
+	args = append(args, tenantID)
+	placeholders := make([]string, len(ids))
+	for i, id := range ids {
+		args = append(args, id)
+		placeholders[i] = "$" + strconv.Itoa(i+2)
+	}
+
+	query := `select id, owner_id, project_id, parent_id, description, date_due, date_completed, date_created,
+			date_updated, shares, recurrence, reminder_offsets, version, comment_count
+		from task
+		where tenant_id = $1 and id in (` + strings.Join(placeholders, ", ") + `)`
+	rows, err := dbr.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	ts, err := scanTasks(rows, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return ts, loadDetails(ctx, dbr.DB, tenantID, ts)
+}
+
+// List retrieves a page of a tenant's tasks from the database that match the filter, newest first, along with the total
+// number of tasks that match it. Details are only loaded for the tasks on the page.
+func (dbr DBRepo) List(ctx context.Context, tenantID string, f Filter) ([]Task, int, error) {
+	args := []interface{}{tenantID}
+
+	var projectCondition string
+	if f.ProjectID != "" {
+		args = append(args, f.ProjectID)
+		projectCondition = `and project_id = $` + strconv.Itoa(len(args))
+	} else {
+		projectCondition = `and ` + unarchivedCondition
+	}
+
+	var tagCondition string
+	if len(f.Tags) > 0 {
+		args = append(args, f.Tags)
+		tagCondition = `and id in (
+			select task_tag.task_id
+			from task_tag
+			join tag on tag.id = task_tag.tag_id
+			where task_tag.tenant_id = $1 and tag.name = any($` + strconv.Itoa(len(args)) + `)
+			group by task_tag.task_id`
+		if !f.AnyTag {
+			// Each of the task's tags is only associated with it once so a count of the matches is the number of tags
+			// that the task has in common with the filter
+			unique := make(map[string]bool, len(f.Tags))
+			for _, name := range f.Tags {
+				unique[name] = true
+			}
+			args = append(args, len(unique))
+			tagCondition += `
+			having count(*) = $` + strconv.Itoa(len(args))
+		}
+		tagCondition += `)`
+	}
+
+	var visibilityCondition string
+	visibilityCondition, args = f.Visibility.condition("task", args)
+
+	where := `where tenant_id = $1 ` + projectCondition + ` ` + tagCondition + ` and ` + visibilityCondition
+
+	var total int
+	err := dbr.DB.QueryRowContext(ctx, `select count(*) from task `+where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, f.Limit, f.Offset)
+	query := `select id, owner_id, project_id, parent_id, description, date_due, date_completed, date_created,
+			date_updated, shares, recurrence, reminder_offsets, version, comment_count
+		from task
+		` + where + `
+		order by date_created desc, id
+		limit $` + strconv.Itoa(len(args)-1) + ` offset $` + strconv.Itoa(len(args))
+	rows, err := dbr.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ts, err := scanTasks(rows, tenantID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return ts, total, loadDetails(ctx, dbr.DB, tenantID, ts)
+}
+
+// ListSubtasks retrieves a page of the subtasks of a tenant's task from the database that match the filter, along with
+// the total number of subtasks that match it. Only the direct subtasks are retrieved unless the filter is recursive, in
+// which case the whole tree below the task is walked in a single query. The subtasks are ordered by how deeply they are
+// nested and then newest first. Details are only loaded for the subtasks on the page.
+func (dbr DBRepo) ListSubtasks(ctx context.Context, tenantID string, id string, f SubtaskFilter) ([]Task, int, error) {
+	depth := 1
+	if f.Recursive {
+		depth = MaxDepth
+	}
+
+	args := []interface{}{tenantID, id, depth}
+
+	var visibilityCondition string
+	visibilityCondition, args = f.Visibility.condition("task", args)
+
+	subtasks := `with recursive subtask (id, depth) as (
+			select id, 1 from task where tenant_id = $1 and parent_id = $2
+			union all
+			select task.id, subtask.depth + 1
+			from task
+			join subtask on task.parent_id = subtask.id
+			where task.tenant_id = $1 and subtask.depth < $3
+		)`
+	where := `where task.tenant_id = $1 and ` + visibilityCondition
+
+	var total int
+	err := dbr.DB.QueryRowContext(ctx, subtasks+`
+		select count(*)
+		from subtask
+		join task on task.id = subtask.id
+		`+where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, f.Limit, f.Offset)
+	query := subtasks + `
+		select task.id, task.owner_id, task.project_id, task.parent_id, task.description, task.date_due,
+			task.date_completed, task.date_created, task.date_updated, task.shares,
+			task.recurrence, task.reminder_offsets, task.version, task.comment_count
+		from subtask
+		join task on task.id = subtask.id
+		` + where + `
+		order by subtask.depth, task.date_created desc, task.id
+		limit $` + strconv.Itoa(len(args)-1) + ` offset $` + strconv.Itoa(len(args))
+	rows, err := dbr.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ts, err := scanTasks(rows, tenantID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return ts, total, loadDetails(ctx, dbr.DB, tenantID, ts)
+}
+
+// ListDue retrieves up to limit of the incomplete tasks of every tenant that are due no later than to, ordered by due
+// date and then ID. Only tasks that come after the cursor, a due date and task ID, are retrieved so that the tasks can
+// be paged through without skipping tasks that are due at the same time. An empty ID starts at the due date. Tasks in
+// archived projects are left out so that their owners are not reminded about them.
+//
+// Only the fields needed to remind the tasks' owners about them are populated: the tenant, ID, owner, description, due
+// date, and reminder offsets.
+func (dbr DBRepo) ListDue(ctx context.Context, afterDue time.Time, afterID string, to time.Time, limit int) ([]Task, error) {
+	if afterID == "" {
+		afterID = uuid.Nil.String()
+	}
+
+	const query = `select tenant_id, id, owner_id, description, date_due, reminder_offsets
+		from task
+		where date_completed is null and (date_due, id) > ($1, $2) and date_due <= $3
+			and not exists (
+				select 1 from project
+				where project.tenant_id = task.tenant_id and project.id = task.project_id and project.date_archived is not null)
+		order by date_due, id
+		limit $4`
+	rows, err := dbr.DB.QueryContext(ctx, query, afterDue, afterID, to, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ts := []Task{}
+	for rows.Next() {
+		var tsk Task
+		var reminderOffsets []byte
+		err = rows.Scan(&tsk.TenantID, &tsk.ID, &tsk.OwnerID, &tsk.Description, &tsk.DateDue, &reminderOffsets)
+		if err != nil {
+			return nil, err
+		}
+
+		err = unmarshalReminderOffsets(reminderOffsets, &tsk)
+		if err != nil {
+			return nil, err
+		}
+
+		ts = append(ts, tsk)
+	}
+
+	return ts, rows.Err()
+}
+
+// GetAncestorIDs retrieves the IDs of every task that any of a tenant's tasks is a subtask of, directly or
+// indirectly, in a single query. The IDs are unique and in no particular order.
+func (dbr DBRepo) GetAncestorIDs(ctx context.Context, tenantID string, ids []string) ([]string, error) {
+	if len(ids) == 0 {
+		return []string{}, nil
+	}
+
+	args := make([]interface{}, 0, len(ids)+1)
+	args = append(args, tenantID)
+	placeholders := make([]string, len(ids))
+	for i, id := range ids {
+		args = append(args, id)
+		placeholders[i] = "$" + strconv.Itoa(i+2)
+	}
+
+	query := `with recursive ancestor (id, parent_id, depth) as (
+			select id, parent_id, 0 from task where tenant_id = $1 and id in (` + strings.Join(placeholders, ", ") + `)
+			union all
+			select task.id, task.parent_id, ancestor.depth + 1
+			from task
+			join ancestor on task.id = ancestor.parent_id
+			where task.tenant_id = $1 and ancestor.depth < ` + strconv.Itoa(MaxDepth) + `
+		)
+		select distinct id from ancestor where depth > 0`
+	rows, err := dbr.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ancestorIDs := []string{}
+	for rows.Next() {
+		var id string
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+
+		ancestorIDs = append(ancestorIDs, id)
+	}
+
+	return ancestorIDs, rows.Err()
 }
 
 // Save stores a task in the database.
 func (dbr DBRepo) Save(ctx context.Context, t Task) error {
-	const query = `insert into "task" (id, description, date_due, date_created, date_updated)
-		values ($1, $2, $3, $4, $5)`
-	_, err := dbr.DB.ExecContext(ctx,
+	return dbr.SaveBatch(ctx, []Task{t})
+}
+
+// SaveBatch stores tasks in the database in a single transaction. Either all of the tasks are stored or none of them
+// are. Tags that the tenant does not have are ignored. The task counts of the tasks' projects are incremented in the
+// same transaction; ErrProjectUnavailable is returned if any of the projects does not exist or is archived.
+// ErrParentUnavailable, ErrHierarchyCycle, or ErrDepthExceeded is returned in a BatchError that identifies the task if
+// any of the tasks cannot be a subtask of its parent. An EventCreated event is recorded in the outbox and a revision in
+// the history of each task in the same transaction.
+func (dbr DBRepo) SaveBatch(ctx context.Context, ts []Task) error {
+	if len(ts) == 0 {
+		return nil
+	}
+
+	tx, err := dbr.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = insertTasks(ctx, tx, ts)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Update replaces a tenant's task in the database and increments its version. The task's version must be the version
+// that is being replaced; the check and the update happen atomically so that concurrent updates cannot overwrite each
+// other. ErrVersionConflict is returned if the task is no longer at that version or no longer exists. Tags that the
+// tenant does not have are ignored. ErrParentUnavailable, ErrHierarchyCycle, or ErrDepthExceeded is returned if the
+// task cannot be a subtask of its parent. An EventUpdated event is recorded in the outbox and a revision with what
+// changed in the task's history in the same transaction.
+func (dbr DBRepo) Update(ctx context.Context, t Task) error {
+	tx, err := dbr.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = updateTask(ctx, tx, t)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CompleteOccurrence updates a completed occurrence of a recurring task like Update and stores the next occurrence like
+// Save in a single transaction, so that the next occurrence is created exactly once.
+func (dbr DBRepo) CompleteOccurrence(ctx context.Context, t Task, next Task) error {
+	tx, err := dbr.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = updateTask(ctx, tx, t)
+	if err != nil {
+		return err
+	}
+
+	err = insertTasks(ctx, tx, []Task{next})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Delete removes a tenant's task from the database along with its tags, dependencies, comments, and the metadata of its
+// attachments, and stops counting it towards its project. The task's version must be the version that is being deleted.
+// ErrVersionConflict is returned if the task is no longer at that version or no longer exists. ErrHasSubtasks is
+// returned if the task has subtasks, which must be deleted or moved first. An EventDeleted event is recorded in the
+// outbox and a revision in the task's history in the same transaction. The history is kept after the task is deleted.
+func (dbr DBRepo) Delete(ctx context.Context, t Task) error {
+	tx, err := dbr.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	const subtaskQuery = `select exists (select 1 from task where tenant_id = $1 and parent_id = $2)`
+	var hasSubtasks bool
+	err = tx.QueryRowContext(ctx, subtaskQuery, t.TenantID, t.ID).Scan(&hasSubtasks)
+	if err != nil {
+		return err
+	}
+	if hasSubtasks {
+		return ErrHasSubtasks
+	}
+
+	const deleteQuery = `delete from task where tenant_id = $1 and id = $2 and version = $3 returning project_id`
+	var projectID sql.NullString
+	err = tx.QueryRowContext(ctx, deleteQuery, t.TenantID, t.ID, t.Version).Scan(&projectID)
+	if err == sql.ErrNoRows {
+		return ErrVersionConflict
+	}
+	if err != nil {
+		return err
+	}
+
+	if projectID.Valid {
+		const projectQuery = `update project set task_count = task_count - 1 where tenant_id = $1 and id = $2`
+		_, err = tx.ExecContext(ctx, projectQuery, t.TenantID, projectID.String)
+		if err != nil {
+			return err
+		}
+	}
+
+	const tagQuery = `delete from task_tag where tenant_id = $1 and task_id = $2`
+	_, err = tx.ExecContext(ctx, tagQuery, t.TenantID, t.ID)
+	if err != nil {
+		return err
+	}
+
+	const dependencyQuery = `delete from task_dependency where tenant_id = $1 and (blocked_id = $2 or blocker_id = $2)`
+	_, err = tx.ExecContext(ctx, dependencyQuery, t.TenantID, t.ID)
+	if err != nil {
+		return err
+	}
+
+	const commentQuery = `delete from task_comment where tenant_id = $1 and task_id = $2`
+	_, err = tx.ExecContext(ctx, commentQuery, t.TenantID, t.ID)
+	if err != nil {
+		return err
+	}
+
+	const attachmentQuery = `delete from task_attachment where tenant_id = $1 and task_id = $2`
+	_, err = tx.ExecContext(ctx, attachmentQuery, t.TenantID, t.ID)
+	if err != nil {
+		return err
+	}
+
+	// The event comes after the task's last update
+	t.Version++
+	err = recordEvents(ctx, tx, EventDeleted, t)
+	if err != nil {
+		return err
+	}
+
+	r, err := newRevision(ctx, ActionDeleted, &t, nil)
+	if err != nil {
+		return err
+	}
+
+	err = recordRevisions(ctx, tx, *r)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// insertTasks stores tasks along with their tags, counts them towards their projects, and records that they were
+// created in both the outbox and their history.
+func insertTasks(ctx context.Context, tx *sql.Tx, ts []Task) error {
+	const columns = 15
+	args := make([]interface{}, 0, len(ts)*columns)
+	values := make([]string, len(ts))
+	for i, t := range ts {
+		shares, err := marshalShares(t)
+		if err != nil {
+			return err
+		}
+
+		recurrence, err := marshalRecurrence(t)
+		if err != nil {
+			return err
+		}
+
+		reminderOffsets, err := marshalReminderOffsets(t)
+		if err != nil {
+			return err
+		}
+
+		args = append(args,
+			t.ID,
+			t.TenantID,
+			t.OwnerID,
+			nullString(t.ProjectID),
+			nullString(t.ParentID),
+			t.Description,
+			t.DateDue,
+			t.DateCompleted,
+			t.DateCreated,
+			t.DateUpdated,
+			shares,
+			recurrence,
+			reminderOffsets,
+			t.Version,
+			searchTerms(t))
+
+		placeholders := make([]string, columns)
+		for j := range placeholders {
+			placeholders[j] = "$" + strconv.Itoa(i*columns+j+1)
+		}
+		values[i] = "(" + strings.Join(placeholders, ", ") + ")"
+	}
+
+	query := `insert into "task"
+		(id, tenant_id, owner_id, project_id, parent_id, description, date_due, date_completed, date_created, date_updated,
+			shares, recurrence, reminder_offsets, version, search_terms)
+		values ` + strings.Join(values, ", ")
+	_, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	for i, t := range ts {
+		err = saveTags(ctx, tx, t)
+		if err != nil {
+			return err
+		}
+
+		err = checkHierarchy(ctx, tx, t)
+		if err != nil {
+			return &BatchError{Index: i, Err: err}
+		}
+	}
+
+	err = countProjectTasks(ctx, tx, ts)
+	if err != nil {
+		return err
+	}
+
+	err = recordEvents(ctx, tx, EventCreated, ts...)
+	if err != nil {
+		return err
+	}
+
+	rs := make([]Revision, len(ts))
+	for i := range ts {
+		r, err := newRevision(ctx, ActionCreated, nil, &ts[i])
+		if err != nil {
+			return err
+		}
+
+		rs[i] = *r
+	}
+
+	return recordRevisions(ctx, tx, rs...)
+}
+
+// updateTask replaces a task along with its tags if it is still at the same version and records that it was updated,
+// along with what changed.
+func updateTask(ctx context.Context, tx *sql.Tx, t Task) error {
+	shares, err := marshalShares(t)
+	if err != nil {
+		return err
+	}
+
+	recurrence, err := marshalRecurrence(t)
+	if err != nil {
+		return err
+	}
+
+	reminderOffsets, err := marshalReminderOffsets(t)
+	if err != nil {
+		return err
+	}
+
+	// The task is retrieved as it was before the update so that the changes can be recorded
+	before, err := getVersion(ctx, tx, t.TenantID, t.ID, t.Version)
+	if err != nil {
+		return err
+	}
+	if before == nil {
+		return ErrVersionConflict
+	}
+
+	const query = `update task
+		set parent_id = $1, description = $2, date_due = $3, date_completed = $4, date_updated = $5, shares = $6,
+			recurrence = $7, reminder_offsets = $8, search_terms = $9, version = version + 1
+		where tenant_id = $10 and id = $11 and version = $12`
+	res, err := tx.ExecContext(ctx,
 		query,
-		t.ID,
+		nullString(t.ParentID),
 		t.Description,
 		t.DateDue,
-		t.DateCreated,
-		t.DateUpdated)
+		t.DateCompleted,
+		t.DateUpdated,
+		shares,
+		recurrence,
+		reminderOffsets,
+		searchTerms(t),
+		t.TenantID,
+		t.ID,
+		t.Version)
+	if err != nil {
+		return err
+	}
+
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return ErrVersionConflict
+	}
+
+	_, err = tx.ExecContext(ctx, `delete from task_tag where tenant_id = $1 and task_id = $2`, t.TenantID, t.ID)
+	if err != nil {
+		return err
+	}
+
+	err = saveTags(ctx, tx, t)
+	if err != nil {
+		return err
+	}
+
+	err = checkHierarchy(ctx, tx, t)
+	if err != nil {
+		return err
+	}
+
+	t.Version++
+	err = recordEvents(ctx, tx, EventUpdated, t)
+	if err != nil {
+		return err
+	}
+
+	r, err := newRevision(ctx, ActionUpdated, before, &t)
+	if err != nil {
+		return err
+	}
+
+	return recordRevisions(ctx, tx, *r)
+}
+
+// getVersion retrieves a tenant's task along with its tags within the transaction, provided that the task is still at
+// the version. If it is not, nil will be returned for both the task and error.
+func getVersion(ctx context.Context, tx *sql.Tx, tenantID string, id string, version int) (*Task, error) {
+	const query = `select id, owner_id, project_id, parent_id, description, date_due, date_completed, date_created,
+			date_updated, shares, recurrence, reminder_offsets, version, comment_count
+		from task
+		where tenant_id = $1 and id = $2 and version = $3`
+	rows, err := tx.QueryContext(ctx, query, tenantID, id, version)
+	if err != nil {
+		return nil, err
+	}
+
+	ts, err := scanTasks(rows, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if len(ts) == 0 {
+		return nil, nil
+	}
+
+	err = loadTags(ctx, tx, tenantID, ts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ts[0], nil
+}
+
+// scanTasks reads a tenant's tasks from rows of id, owner_id, project_id, parent_id, description, date_due,
+// date_completed, date_created, date_updated, shares, recurrence, reminder_offsets, version, and comment_count columns.
+// The rows are closed.
+func scanTasks(rows *sql.Rows, tenantID string) ([]Task, error) {
+	defer rows.Close()
+
+	ts := []Task{}
+	for rows.Next() {
+		tsk := Task{TenantID: tenantID}
+		var projectID, parentID sql.NullString
+		var shares, recurrence, reminderOffsets []byte
+		err := rows.Scan(
+			&tsk.ID,
+			&tsk.OwnerID,
+			&projectID,
+			&parentID,
+			&tsk.Description,
+			&tsk.DateDue,
+			&tsk.DateCompleted,
+			&tsk.DateCreated,
+			&tsk.DateUpdated,
+			&shares,
+			&recurrence,
+			&reminderOffsets,
+			&tsk.Version,
+			&tsk.CommentCount)
+		if err != nil {
+			return nil, err
+		}
+
+		tsk.ProjectID = projectID.String
+		tsk.ParentID = parentID.String
+
+		err = unmarshalShares(shares, &tsk)
+		if err != nil {
+			return nil, err
+		}
+
+		err = unmarshalRecurrence(recurrence, &tsk)
+		if err != nil {
+			return nil, err
+		}
+
+		err = unmarshalReminderOffsets(reminderOffsets, &tsk)
+		if err != nil {
+			return nil, err
+		}
+
+		ts = append(ts, tsk)
+	}
+
+	return ts, rows.Err()
+}
+
+// countProjectTasks increments the task count of each project that the tasks are added to. ErrProjectUnavailable is
+// returned if any of the projects does not exist or is archived.
+func countProjectTasks(ctx context.Context, tx *sql.Tx, ts []Task) error {
+	counts := make(map[string]int)
+	var projectIDs []string
+	for _, t := range ts {
+		if t.ProjectID == "" {
+			continue
+		}
+		if counts[t.ProjectID] == 0 {
+			projectIDs = append(projectIDs, t.ProjectID)
+		}
+		counts[t.ProjectID]++
+	}
+
+	const query = `update project
+		set task_count = task_count + $1
+		where tenant_id = $2 and id = $3 and date_archived is null`
+	for _, projectID := range projectIDs {
+		res, err := tx.ExecContext(ctx, query, counts[projectID], ts[0].TenantID, projectID)
+		if err != nil {
+			return err
+		}
+
+		updated, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if updated == 0 {
+			return ErrProjectUnavailable
+		}
+	}
+
+	return nil
+}
+
+// checkHierarchy ensures that the task can be a subtask of its parent: the parent must exist within the tenant, must
+// not be the task itself or one of its subtasks, and the task's subtasks must not end up nested more than MaxDepth
+// levels deep. Transactions are serializable so concurrent changes to the hierarchy cannot combine into a cycle.
+func checkHierarchy(ctx context.Context, tx *sql.Tx, t Task) error {
+	if t.ParentID == "" {
+		return nil
+	}
+
+	// Walk up from the parent, going one level past the limit so that exceeding it can be detected
+	const ancestorQuery = `with recursive ancestor (id, parent_id, depth) as (
+			select id, parent_id, 1 from task where tenant_id = $1 and id = $2
+			union all
+			select task.id, task.parent_id, ancestor.depth + 1
+			from task
+			join ancestor on task.id = ancestor.parent_id
+			where task.tenant_id = $1 and ancestor.depth <= $3
+		)
+		select id, depth from ancestor`
+	rows, err := tx.QueryContext(ctx, ancestorQuery, t.TenantID, t.ParentID, MaxDepth)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	found := false
+	levels := 0
+	for rows.Next() {
+		var id string
+		var depth int
+		err = rows.Scan(&id, &depth)
+		if err != nil {
+			return err
+		}
+
+		if id == t.ID {
+			return ErrHierarchyCycle
+		}
+
+		found = true
+		if depth > levels {
+			levels = depth
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	if !found {
+		return ErrParentUnavailable
+	}
+
+	// The task's own subtasks move down along with it
+	const heightQuery = `with recursive subtask (id, depth) as (
+			select id, 1 from task where tenant_id = $1 and parent_id = $2
+			union all
+			select task.id, subtask.depth + 1
+			from task
+			join subtask on task.parent_id = subtask.id
+			where task.tenant_id = $1 and subtask.depth <= $3
+		)
+		select coalesce(max(depth), 0) from subtask`
+	var height int
+	err = tx.QueryRowContext(ctx, heightQuery, t.TenantID, t.ID, MaxDepth).Scan(&height)
+	if err != nil {
+		return err
+	}
+
+	if levels+height > MaxDepth {
+		return ErrDepthExceeded
+	}
+
+	return nil
+}
+
+// saveTags associates the task with its tags. Tags that the tenant does not have are ignored.
+func saveTags(ctx context.Context, tx *sql.Tx, t Task) error {
+	if len(t.Tags) == 0 {
+		return nil
+	}
+
+	const query = `insert into task_tag (task_id, tag_id, tenant_id)
+		select $1, id, tenant_id from tag where tenant_id = $2 and name = any($3)`
+	_, err := tx.ExecContext(ctx, query, t.ID, t.TenantID, t.Tags)
 
 	return err
 }
+
+// loadDetails populates the tags, subtask rollups, and blockers of a tenant's tasks.
+func loadDetails(ctx context.Context, db *sql.DB, tenantID string, ts []Task) error {
+	err := loadTags(ctx, db, tenantID, ts)
+	if err != nil {
+		return err
+	}
+
+	err = loadRollups(ctx, db, tenantID, ts)
+	if err != nil {
+		return err
+	}
+
+	return loadBlockers(ctx, db, tenantID, ts)
+}
+
+// loadTags populates the names of the tags of a tenant's tasks in a single query.
+func loadTags(ctx context.Context, db queryer, tenantID string, ts []Task) error {
+	if len(ts) == 0 {
+		return nil
+	}
+
+	args := make([]interface{}, 0, len(ts)+1)
+	args = append(args, tenantID)
+	placeholders := make([]string, len(ts))
+	for i, t := range ts {
+		args = append(args, t.ID)
+		placeholders[i] = "$" + strconv.Itoa(i+2)
+	}
+
+	query := `select task_tag.task_id, tag.name
+		from task_tag
+		join tag on tag.id = task_tag.tag_id
+		where task_tag.tenant_id = $1 and task_tag.task_id in (` + strings.Join(placeholders, ", ") + `)`
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	tags := make(map[string][]string)
+	for rows.Next() {
+		var taskID, name string
+		err = rows.Scan(&taskID, &name)
+		if err != nil {
+			return err
+		}
+
+		tags[taskID] = append(tags[taskID], name)
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range ts {
+		ts[i].Tags = tags[ts[i].ID]
+		sort.Strings(ts[i].Tags)
+	}
+
+	return nil
+}
+
+// loadRollups populates the subtask rollups of a tenant's tasks by walking down every task's tree in a single query.
+func loadRollups(ctx context.Context, db *sql.DB, tenantID string, ts []Task) error {
+	if len(ts) == 0 {
+		return nil
+	}
+
+	args := make([]interface{}, 0, len(ts)+1)
+	args = append(args, tenantID)
+	placeholders := make([]string, len(ts))
+	for i, t := range ts {
+		args = append(args, t.ID)
+		placeholders[i] = "$" + strconv.Itoa(i+2)
+	}
+
+	query := `with recursive subtask (root_id, id, date_completed, depth) as (
+			select parent_id, id, date_completed, 1
+			from task
+			where tenant_id = $1 and parent_id in (` + strings.Join(placeholders, ", ") + `)
+			union all
+			select subtask.root_id, task.id, task.date_completed, subtask.depth + 1
+			from task
+			join subtask on task.parent_id = subtask.id
+			where task.tenant_id = $1 and subtask.depth < ` + strconv.Itoa(MaxDepth) + `
+		)
+		select root_id, count(*), count(date_completed) from subtask group by root_id`
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	rollups := make(map[string]Rollup)
+	for rows.Next() {
+		var taskID string
+		var rollup Rollup
+		err = rows.Scan(&taskID, &rollup.Subtasks, &rollup.Completed)
+		if err != nil {
+			return err
+		}
+
+		rollups[taskID] = rollup
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range ts {
+		ts[i].Rollup = rollups[ts[i].ID]
+	}
+
+	return nil
+}
+
+// loadBlockers populates the incomplete tasks that block a tenant's tasks in a single query.
+func loadBlockers(ctx context.Context, db *sql.DB, tenantID string, ts []Task) error {
+	if len(ts) == 0 {
+		return nil
+	}
+
+	args := make([]interface{}, 0, len(ts)+1)
+	args = append(args, tenantID)
+	placeholders := make([]string, len(ts))
+	for i, t := range ts {
+		args = append(args, t.ID)
+		placeholders[i] = "$" + strconv.Itoa(i+2)
+	}
+
+	query := `select task_dependency.blocked_id, task_dependency.blocker_id
+		from task_dependency
+		join task on task.id = task_dependency.blocker_id
+		where task_dependency.tenant_id = $1 and task.date_completed is null
+			and task_dependency.blocked_id in (` + strings.Join(placeholders, ", ") + `)`
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	blockers := make(map[string][]string)
+	for rows.Next() {
+		var taskID, blockerID string
+		err = rows.Scan(&taskID, &blockerID)
+		if err != nil {
+			return err
+		}
+
+		blockers[taskID] = append(blockers[taskID], blockerID)
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range ts {
+		ts[i].BlockedBy = blockers[ts[i].ID]
+		sort.Strings(ts[i].BlockedBy)
+	}
+
+	return nil
+}
+
+// searchTerms builds the terms stored alongside the task in the inverted index used by DBSearcher.
+func searchTerms(t Task) []string {
+	terms := Terms(t.Description)
+	if terms == nil {
+		return []string{}
+	}
+
+	return terms
+}
+
+// nullString converts an empty string into a SQL null.
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// marshalShares converts the task's shares into the JSON stored in the database.
+func marshalShares(t Task) (string, error) {
+	if t.Shares == nil {
+		return "{}", nil
+	}
+
+	shares, err := json.Marshal(t.Shares)
+	return string(shares), err
+}
+
+// marshalRecurrence converts the task's recurrence into the JSON stored in the database, which is null for tasks that
+// do not recur.
+func marshalRecurrence(t Task) (interface{}, error) {
+	if t.Recurrence == nil {
+		return nil, nil
+	}
+
+	recurrence, err := json.Marshal(t.Recurrence)
+	return string(recurrence), err
+}
+
+// unmarshalRecurrence populates the task's recurrence from the JSON stored in the database.
+func unmarshalRecurrence(raw []byte, t *Task) error {
+	if raw == nil {
+		return nil
+	}
+
+	var recurrence Recurrence
+	err := json.Unmarshal(raw, &recurrence)
+	if err != nil {
+		return err
+	}
+
+	t.Recurrence = &recurrence
+	return nil
+}
+
+// marshalReminderOffsets converts the task's reminder offsets into the JSON stored in the database, which is null for
+// tasks without reminders.
+func marshalReminderOffsets(t Task) (interface{}, error) {
+	if len(t.ReminderOffsets) == 0 {
+		return nil, nil
+	}
+
+	reminderOffsets, err := json.Marshal(t.ReminderOffsets)
+	return string(reminderOffsets), err
+}
+
+// unmarshalReminderOffsets populates the task's reminder offsets from the JSON stored in the database.
+func unmarshalReminderOffsets(raw []byte, t *Task) error {
+	if raw == nil {
+		return nil
+	}
+
+	return json.Unmarshal(raw, &t.ReminderOffsets)
+}
+
+// unmarshalShares populates the task's shares from the JSON stored in the database. Tasks without any shares are left
+// with nil shares.
+func unmarshalShares(raw []byte, t *Task) error {
+	var shares map[string]string
+	err := json.Unmarshal(raw, &shares)
+	if err != nil {
+		return err
+	}
+
+	if len(shares) > 0 {
+		t.Shares = shares
+	}
+
+	return nil
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"github.com/jaredpetersen/go-rest-template/internal/migration"
+	"github.com/jaredpetersen/go-rest-template/internal/project"
 	"github.com/jaredpetersen/go-rest-template/internal/task"
 	"testing"
 	"time"
@@ -53,20 +55,19 @@ func setupCockroachDB(ctx context.Context) (*cockroachDBContainer, error) {
 }
 
 func initCockroachDB(ctx context.Context, db *sql.DB) error {
-	const query = `CREATE DATABASE projectmanagement;
-		CREATE TABLE projectmanagement.task(
-			id uuid primary key not null,
-			description varchar(255) not null,
-			date_due timestamp with time zone,
-			date_created timestamp with time zone not null,
-			date_updated timestamp with time zone not null);`
+	const query = `CREATE DATABASE projectmanagement`
 	_, err := db.ExecContext(ctx, query)
+	if err != nil {
+		return err
+	}
 
-	return err
+	return migration.Migrate(ctx, db)
 }
 
 func truncateCockroachDB(ctx context.Context, db *sql.DB) error {
-	const query = `truncate projectmanagement.task`
+	const query = `truncate projectmanagement.task, projectmanagement.tag, projectmanagement.task_tag, projectmanagement.project,
+		projectmanagement.task_dependency, projectmanagement.outbox_message, projectmanagement.task_history, projectmanagement.task_comment,
+		projectmanagement.task_attachment`
 	_, err := db.ExecContext(ctx, query)
 	return err
 }
@@ -95,14 +96,24 @@ func TestIntegrationDBRepoSaveGet(t *testing.T) {
 	now := time.Now()
 
 	var tests = []*task.Task{
-		task.New(),
 		func() *task.Task {
 			tsk := task.New()
+			tsk.TenantID = "tenant-1"
+			tsk.OwnerID = "user-1"
+			return tsk
+		}(),
+		func() *task.Task {
+			tsk := task.New()
+			tsk.TenantID = "tenant-1"
+			tsk.OwnerID = "user-1"
 			tsk.Description = "Update resumé"
+			tsk.Shares = map[string]string{"user-2": "viewer"}
 			return tsk
 		}(),
 		func() *task.Task {
 			tsk := task.New()
+			tsk.TenantID = "tenant-2"
+			tsk.OwnerID = "user-2"
 			tsk.Description = "Call veterinarian"
 			tsk.DateDue = &now
 			return tsk
@@ -115,11 +126,15 @@ func TestIntegrationDBRepoSaveGet(t *testing.T) {
 		err = tdbr.Save(ctx, *tt)
 		require.NoError(t, err, "Save returned error")
 
-		savedTsk, err := tdbr.Get(ctx, tt.ID)
+		savedTsk, err := tdbr.Get(ctx, tt.TenantID, tt.ID)
 		require.NoError(t, err, "Get returned error")
 		require.NotNil(t, savedTsk, "Get did not return a task")
 		assert.Equal(t, tt.ID, savedTsk.ID)
+		assert.Equal(t, tt.TenantID, savedTsk.TenantID)
+		assert.Equal(t, tt.OwnerID, savedTsk.OwnerID)
 		assert.Equal(t, tt.Description, savedTsk.Description)
+		assert.Equal(t, tt.Shares, savedTsk.Shares)
+		assert.Equal(t, tt.Version, savedTsk.Version)
 
 		// Evaluate time using microseconds since that's as precise as CockroachDB goes
 
@@ -178,11 +193,190 @@ func TestIntegrationDBRepoGetNonexistent(t *testing.T) {
 
 	tdbr := task.DBRepo{DB: db}
 
-	tsk, err := tdbr.Get(ctx, uuid.NewString())
+	tsk, err := tdbr.Get(ctx, "tenant-1", uuid.NewString())
 	require.NoError(t, err, "Get returned error")
 	assert.Nil(t, tsk, "Get returned a task")
 }
 
+func TestIntegrationDBRepoGetOtherTenant(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+
+	cdbContainer, err := setupCockroachDB(ctx)
+	require.NoError(t, err, "Failed to start up CockroachDB container")
+	defer cdbContainer.Terminate(ctx)
+
+	db, err := sql.Open("pgx", cdbContainer.URI+"/projectmanagement")
+	require.NoError(t, err, "Failed to open connection to CockroachDB")
+	defer db.Close()
+
+	err = initCockroachDB(ctx, db)
+	require.NoError(t, err, "Failed to initialize CockroachDB")
+	defer truncateCockroachDB(ctx, db)
+
+	tdbr := task.DBRepo{DB: db}
+
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+	err = tdbr.Save(ctx, *tsk)
+	require.NoError(t, err, "Save returned error")
+
+	savedTsk, err := tdbr.Get(ctx, "tenant-2", tsk.ID)
+	require.NoError(t, err, "Get returned error")
+	assert.Nil(t, savedTsk, "Get returned another tenant's task")
+}
+
+func TestIntegrationDBRepoUpdate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+
+	cdbContainer, err := setupCockroachDB(ctx)
+	require.NoError(t, err, "Failed to start up CockroachDB container")
+	defer cdbContainer.Terminate(ctx)
+
+	db, err := sql.Open("pgx", cdbContainer.URI+"/projectmanagement")
+	require.NoError(t, err, "Failed to open connection to CockroachDB")
+	defer db.Close()
+
+	err = initCockroachDB(ctx, db)
+	require.NoError(t, err, "Failed to initialize CockroachDB")
+	defer truncateCockroachDB(ctx, db)
+
+	tdbr := task.DBRepo{DB: db}
+
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+	tsk.Description = "Buy milk"
+	err = tdbr.Save(ctx, *tsk)
+	require.NoError(t, err, "Save returned error")
+
+	tsk.Description = "Buy oat milk"
+	tsk.Shares = map[string]string{"user-2": "editor"}
+	err = tdbr.Update(ctx, *tsk)
+	require.NoError(t, err, "Update returned error")
+
+	updatedTsk, err := tdbr.Get(ctx, tsk.TenantID, tsk.ID)
+	require.NoError(t, err, "Get returned error")
+	require.NotNil(t, updatedTsk, "Get did not return a task")
+	assert.Equal(t, "Buy oat milk", updatedTsk.Description)
+	assert.Equal(t, tsk.Shares, updatedTsk.Shares)
+	assert.Equal(t, 2, updatedTsk.Version)
+
+	// Updating the replaced version again must fail
+	tsk.Description = "Buy almond milk"
+	err = tdbr.Update(ctx, *tsk)
+	assert.ErrorIs(t, err, task.ErrVersionConflict)
+
+	// Updating another tenant's task must fail
+	otherTsk := *updatedTsk
+	otherTsk.TenantID = "tenant-2"
+	err = tdbr.Update(ctx, otherTsk)
+	assert.ErrorIs(t, err, task.ErrVersionConflict)
+
+	updatedTsk, err = tdbr.Get(ctx, tsk.TenantID, tsk.ID)
+	require.NoError(t, err, "Get returned error")
+	assert.Equal(t, "Buy oat milk", updatedTsk.Description)
+	assert.Equal(t, 2, updatedTsk.Version)
+}
+
+func TestIntegrationDBRepoSaveBatchGetBatch(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+
+	cdbContainer, err := setupCockroachDB(ctx)
+	require.NoError(t, err, "Failed to start up CockroachDB container")
+	defer cdbContainer.Terminate(ctx)
+
+	db, err := sql.Open("pgx", cdbContainer.URI+"/projectmanagement")
+	require.NoError(t, err, "Failed to open connection to CockroachDB")
+	defer db.Close()
+
+	err = initCockroachDB(ctx, db)
+	require.NoError(t, err, "Failed to initialize CockroachDB")
+	defer truncateCockroachDB(ctx, db)
+
+	tdbr := task.DBRepo{DB: db}
+
+	var tsks []task.Task
+	for i := 0; i < 3; i++ {
+		tsk := task.New()
+		tsk.TenantID = "tenant-1"
+		tsk.OwnerID = "user-1"
+		tsk.Description = fmt.Sprintf("Task %d", i)
+		tsks = append(tsks, *tsk)
+	}
+	tsks[1].Shares = map[string]string{"user-2": "viewer"}
+
+	err = tdbr.SaveBatch(ctx, tsks)
+	require.NoError(t, err, "SaveBatch returned error")
+
+	otherTsk := task.New()
+	otherTsk.TenantID = "tenant-2"
+	err = tdbr.Save(ctx, *otherTsk)
+	require.NoError(t, err, "Save returned error")
+
+	savedTsks, err := tdbr.GetBatch(ctx, "tenant-1", []string{tsks[0].ID, tsks[1].ID, uuid.NewString(), otherTsk.ID})
+	require.NoError(t, err, "GetBatch returned error")
+	require.Len(t, savedTsks, 2, "GetBatch returned incorrect number of tasks")
+
+	saved := map[string]task.Task{}
+	for _, tsk := range savedTsks {
+		saved[tsk.ID] = tsk
+	}
+	assert.Equal(t, "Task 0", saved[tsks[0].ID].Description)
+	assert.Equal(t, "Task 1", saved[tsks[1].ID].Description)
+	assert.Equal(t, tsks[1].Shares, saved[tsks[1].ID].Shares)
+	assert.Equal(t, "tenant-1", saved[tsks[1].ID].TenantID)
+}
+
+func TestIntegrationDBRepoSaveBatchAllOrNothing(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+
+	cdbContainer, err := setupCockroachDB(ctx)
+	require.NoError(t, err, "Failed to start up CockroachDB container")
+	defer cdbContainer.Terminate(ctx)
+
+	db, err := sql.Open("pgx", cdbContainer.URI+"/projectmanagement")
+	require.NoError(t, err, "Failed to open connection to CockroachDB")
+	defer db.Close()
+
+	err = initCockroachDB(ctx, db)
+	require.NoError(t, err, "Failed to initialize CockroachDB")
+	defer truncateCockroachDB(ctx, db)
+
+	tdbr := task.DBRepo{DB: db}
+
+	existing := task.New()
+	existing.TenantID = "tenant-1"
+	err = tdbr.Save(ctx, *existing)
+	require.NoError(t, err, "Save returned error")
+
+	// Batch conflicts with the existing task so nothing should be stored
+	fresh := task.New()
+	fresh.TenantID = "tenant-1"
+	err = tdbr.SaveBatch(ctx, []task.Task{*fresh, *existing})
+	require.Error(t, err, "SaveBatch did not return error")
+
+	savedTsk, err := tdbr.Get(ctx, "tenant-1", fresh.ID)
+	require.NoError(t, err, "Get returned error")
+	assert.Nil(t, savedTsk, "SaveBatch partially stored the batch")
+}
+
 func TestIntegrationDBRepoGetDBError(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
@@ -202,7 +396,687 @@ func TestIntegrationDBRepoGetDBError(t *testing.T) {
 
 	tdbr := task.DBRepo{DB: db}
 
-	tsk, err := tdbr.Get(ctx, uuid.NewString())
+	tsk, err := tdbr.Get(ctx, "tenant-1", uuid.NewString())
 	require.Error(t, err, "Get did not return error")
 	assert.Nil(t, tsk, "Get returned a task")
 }
+
+func TestIntegrationDBRepoTags(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+
+	cdbContainer, err := setupCockroachDB(ctx)
+	require.NoError(t, err, "Failed to start up CockroachDB container")
+	defer cdbContainer.Terminate(ctx)
+
+	db, err := sql.Open("pgx", cdbContainer.URI+"/projectmanagement")
+	require.NoError(t, err, "Failed to open connection to CockroachDB")
+	defer db.Close()
+
+	err = initCockroachDB(ctx, db)
+	require.NoError(t, err, "Failed to initialize CockroachDB")
+	defer truncateCockroachDB(ctx, db)
+
+	tgdbr := task.TagDBRepo{DB: db}
+	tdbr := task.DBRepo{DB: db}
+
+	for _, name := range []string{"errand", "home", "urgent"} {
+		tg := task.NewTag()
+		tg.TenantID = "tenant-1"
+		tg.Name = name
+		err = tgdbr.Save(ctx, *tg)
+		require.NoError(t, err, "Tag save returned error")
+	}
+
+	milk := task.New()
+	milk.TenantID = "tenant-1"
+	milk.OwnerID = "user-1"
+	milk.Description = "Buy milk"
+	milk.Tags = []string{"errand", "urgent"}
+	milk.DateCreated = milk.DateCreated.Add(-time.Minute)
+	paint := task.New()
+	paint.TenantID = "tenant-1"
+	paint.OwnerID = "user-2"
+	paint.Description = "Paint fence"
+	paint.Tags = []string{"home"}
+	paint.Shares = map[string]string{"user-1": "viewer"}
+	other := task.New()
+	other.TenantID = "tenant-2"
+	other.Tags = []string{"errand"}
+
+	err = tdbr.SaveBatch(ctx, []task.Task{*milk, *paint})
+	require.NoError(t, err, "SaveBatch returned error")
+	err = tdbr.Save(ctx, *other)
+	require.NoError(t, err, "Save returned error")
+
+	savedTsk, err := tdbr.Get(ctx, "tenant-1", milk.ID)
+	require.NoError(t, err, "Get returned error")
+	require.NotNil(t, savedTsk, "Get did not return a task")
+	assert.Equal(t, []string{"errand", "urgent"}, savedTsk.Tags)
+
+	// Tags belong to a tenant so the other tenant's task cannot be tagged with them
+	savedTsk, err = tdbr.Get(ctx, "tenant-2", other.ID)
+	require.NoError(t, err, "Get returned error")
+	require.NotNil(t, savedTsk, "Get did not return a task")
+	assert.Empty(t, savedTsk.Tags)
+
+	var tests = []struct {
+		name          string
+		filter        task.Filter
+		expected      []string
+		expectedTotal int
+	}{
+		{name: "NoTags", filter: task.Filter{Limit: 10}, expected: []string{paint.ID, milk.ID}, expectedTotal: 2},
+		{name: "All", filter: task.Filter{Tags: []string{"errand", "urgent"}, Limit: 10}, expected: []string{milk.ID}, expectedTotal: 1},
+		{name: "AllMissing", filter: task.Filter{Tags: []string{"errand", "home"}, Limit: 10}, expected: []string{}, expectedTotal: 0},
+		{name: "Any", filter: task.Filter{Tags: []string{"errand", "home"}, AnyTag: true, Limit: 10}, expected: []string{paint.ID, milk.ID}, expectedTotal: 2},
+		{name: "Limit", filter: task.Filter{Limit: 1}, expected: []string{paint.ID}, expectedTotal: 2},
+		{name: "Offset", filter: task.Filter{Limit: 1, Offset: 1}, expected: []string{milk.ID}, expectedTotal: 2},
+		{
+			name:          "Owned",
+			filter:        task.Filter{Visibility: &task.Visibility{Subject: "user-1", Owned: true}, Limit: 10},
+			expected:      []string{milk.ID},
+			expectedTotal: 1,
+		},
+		{
+			name:          "Shared",
+			filter:        task.Filter{Visibility: &task.Visibility{Subject: "user-1", SharedAs: []string{"viewer"}}, Limit: 10},
+			expected:      []string{paint.ID},
+			expectedTotal: 1,
+		},
+		{
+			name:          "SharedAsOtherRole",
+			filter:        task.Filter{Visibility: &task.Visibility{Subject: "user-1", SharedAs: []string{"editor"}}, Limit: 10},
+			expected:      []string{},
+			expectedTotal: 0,
+		},
+		{
+			name: "OwnedOrShared",
+			filter: task.Filter{
+				Tags:       []string{"errand", "home"},
+				AnyTag:     true,
+				Visibility: &task.Visibility{Subject: "user-1", Owned: true, SharedAs: []string{"viewer"}},
+				Limit:      1,
+			},
+			expected:      []string{paint.ID},
+			expectedTotal: 2,
+		},
+		{
+			name:          "NotVisible",
+			filter:        task.Filter{Visibility: &task.Visibility{Subject: "user-1"}, Limit: 10},
+			expected:      []string{},
+			expectedTotal: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tsks, total, err := tdbr.List(ctx, "tenant-1", tt.filter)
+			require.NoError(t, err, "List returned error")
+
+			ids := []string{}
+			for _, tsk := range tsks {
+				ids = append(ids, tsk.ID)
+			}
+			assert.Equal(t, tt.expected, ids)
+			assert.Equal(t, tt.expectedTotal, total)
+		})
+	}
+
+	// Updating the task replaces its tags
+	milk.Tags = []string{"home"}
+	err = tdbr.Update(ctx, *milk)
+	require.NoError(t, err, "Update returned error")
+
+	savedTsk, err = tdbr.Get(ctx, "tenant-1", milk.ID)
+	require.NoError(t, err, "Get returned error")
+	require.NotNil(t, savedTsk, "Get did not return a task")
+	assert.Equal(t, []string{"home"}, savedTsk.Tags)
+}
+
+func TestIntegrationDBRepoProjects(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+
+	cdbContainer, err := setupCockroachDB(ctx)
+	require.NoError(t, err, "Failed to start up CockroachDB container")
+	defer cdbContainer.Terminate(ctx)
+
+	db, err := sql.Open("pgx", cdbContainer.URI+"/projectmanagement")
+	require.NoError(t, err, "Failed to open connection to CockroachDB")
+	defer db.Close()
+
+	err = initCockroachDB(ctx, db)
+	require.NoError(t, err, "Failed to initialize CockroachDB")
+	defer truncateCockroachDB(ctx, db)
+
+	pdbr := project.DBRepo{DB: db}
+	tdbr := task.DBRepo{DB: db}
+
+	home := project.New()
+	home.TenantID = "tenant-1"
+	home.Name = "Home"
+	err = pdbr.Save(ctx, *home)
+	require.NoError(t, err, "Project save returned error")
+
+	paint := task.New()
+	paint.TenantID = "tenant-1"
+	paint.ProjectID = home.ID
+	mow := task.New()
+	mow.TenantID = "tenant-1"
+	mow.ProjectID = home.ID
+	loose := task.New()
+	loose.TenantID = "tenant-1"
+	loose.DateCreated = loose.DateCreated.Add(-time.Minute)
+
+	err = tdbr.SaveBatch(ctx, []task.Task{*paint, *mow})
+	require.NoError(t, err, "SaveBatch returned error")
+	err = tdbr.Save(ctx, *loose)
+	require.NoError(t, err, "Save returned error")
+
+	savedTsk, err := tdbr.Get(ctx, "tenant-1", paint.ID)
+	require.NoError(t, err, "Get returned error")
+	require.NotNil(t, savedTsk, "Get did not return a task")
+	assert.Equal(t, home.ID, savedTsk.ProjectID)
+
+	// Task counts are maintained alongside the tasks
+	savedProject, err := pdbr.Get(ctx, "tenant-1", home.ID)
+	require.NoError(t, err, "Project get returned error")
+	require.NotNil(t, savedProject, "Project get did not return a project")
+	assert.Equal(t, 2, savedProject.TaskCount)
+
+	tsks, _, err := tdbr.List(ctx, "tenant-1", task.Filter{ProjectID: home.ID, Limit: 10})
+	require.NoError(t, err, "List returned error")
+	assert.Len(t, tsks, 2)
+
+	// Archiving the project hides its tasks from everything but the project itself
+	archived := time.Now()
+	home.DateArchived = &archived
+	err = pdbr.Update(ctx, *home)
+	require.NoError(t, err, "Project update returned error")
+
+	tsks, _, err = tdbr.List(ctx, "tenant-1", task.Filter{Limit: 10})
+	require.NoError(t, err, "List returned error")
+	require.Len(t, tsks, 1)
+	assert.Equal(t, loose.ID, tsks[0].ID)
+
+	tsks, _, err = tdbr.List(ctx, "tenant-1", task.Filter{ProjectID: home.ID, Limit: 10})
+	require.NoError(t, err, "List returned error")
+	assert.Len(t, tsks, 2)
+
+	// Tasks cannot be added to archived projects
+	late := task.New()
+	late.TenantID = "tenant-1"
+	late.ProjectID = home.ID
+	err = tdbr.Save(ctx, *late)
+	assert.ErrorIs(t, err, task.ErrProjectUnavailable)
+
+	savedTsk, err = tdbr.Get(ctx, "tenant-1", late.ID)
+	require.NoError(t, err, "Get returned error")
+	assert.Nil(t, savedTsk, "Task was stored in an archived project")
+}
+
+func TestIntegrationDBRepoSubtasks(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+
+	cdbContainer, err := setupCockroachDB(ctx)
+	require.NoError(t, err, "Failed to start up CockroachDB container")
+	defer cdbContainer.Terminate(ctx)
+
+	db, err := sql.Open("pgx", cdbContainer.URI+"/projectmanagement")
+	require.NoError(t, err, "Failed to open connection to CockroachDB")
+	defer db.Close()
+
+	err = initCockroachDB(ctx, db)
+	require.NoError(t, err, "Failed to initialize CockroachDB")
+	defer truncateCockroachDB(ctx, db)
+
+	tdbr := task.DBRepo{DB: db}
+
+	now := time.Now()
+
+	root := task.New()
+	root.TenantID = "tenant-1"
+	child := task.New()
+	child.TenantID = "tenant-1"
+	child.OwnerID = "user-1"
+	child.ParentID = root.ID
+	sibling := task.New()
+	sibling.TenantID = "tenant-1"
+	sibling.OwnerID = "user-2"
+	sibling.ParentID = root.ID
+	sibling.DateCreated = sibling.DateCreated.Add(-time.Minute)
+	grandchild := task.New()
+	grandchild.TenantID = "tenant-1"
+	grandchild.ParentID = child.ID
+	grandchild.DateCompleted = &now
+
+	err = tdbr.Save(ctx, *root)
+	require.NoError(t, err, "Save returned error")
+	err = tdbr.SaveBatch(ctx, []task.Task{*child, *sibling})
+	require.NoError(t, err, "SaveBatch returned error")
+	err = tdbr.Save(ctx, *grandchild)
+	require.NoError(t, err, "Save returned error")
+
+	// Rollups cover every level of subtasks
+	savedTsk, err := tdbr.Get(ctx, "tenant-1", root.ID)
+	require.NoError(t, err, "Get returned error")
+	require.NotNil(t, savedTsk, "Get did not return a task")
+	assert.Equal(t, task.Rollup{Subtasks: 3, Completed: 1}, savedTsk.Rollup)
+
+	savedTsks, err := tdbr.GetBatch(ctx, "tenant-1", []string{child.ID, grandchild.ID})
+	require.NoError(t, err, "GetBatch returned error")
+	for _, savedTsk := range savedTsks {
+		switch savedTsk.ID {
+		case child.ID:
+			assert.Equal(t, root.ID, savedTsk.ParentID)
+			assert.Equal(t, task.Rollup{Subtasks: 1, Completed: 1}, savedTsk.Rollup)
+		case grandchild.ID:
+			assert.True(t, savedTsk.Completed())
+			assert.Equal(t, task.Rollup{}, savedTsk.Rollup)
+		}
+	}
+
+	var tests = []struct {
+		name          string
+		filter        task.SubtaskFilter
+		expected      []string
+		expectedTotal int
+	}{
+		{name: "Direct", filter: task.SubtaskFilter{Limit: 10}, expected: []string{child.ID, sibling.ID}, expectedTotal: 2},
+		{
+			name:          "Recursive",
+			filter:        task.SubtaskFilter{Recursive: true, Limit: 10},
+			expected:      []string{child.ID, sibling.ID, grandchild.ID},
+			expectedTotal: 3,
+		},
+		{name: "Limit", filter: task.SubtaskFilter{Recursive: true, Limit: 1}, expected: []string{child.ID}, expectedTotal: 3},
+		{
+			name:          "Offset",
+			filter:        task.SubtaskFilter{Recursive: true, Limit: 1, Offset: 2},
+			expected:      []string{grandchild.ID},
+			expectedTotal: 3,
+		},
+		{
+			name: "Visibility",
+			filter: task.SubtaskFilter{
+				Recursive:  true,
+				Visibility: &task.Visibility{Subject: "user-1", Owned: true},
+				Limit:      10,
+			},
+			expected:      []string{child.ID},
+			expectedTotal: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tsks, total, err := tdbr.ListSubtasks(ctx, "tenant-1", root.ID, tt.filter)
+			require.NoError(t, err, "ListSubtasks returned error")
+
+			ids := []string{}
+			for _, tsk := range tsks {
+				ids = append(ids, tsk.ID)
+			}
+			assert.Equal(t, tt.expected, ids)
+			assert.Equal(t, tt.expectedTotal, total)
+		})
+	}
+
+	ancestorIDs, err := tdbr.GetAncestorIDs(ctx, "tenant-1", []string{grandchild.ID, sibling.ID})
+	require.NoError(t, err, "GetAncestorIDs returned error")
+	assert.ElementsMatch(t, []string{child.ID, root.ID}, ancestorIDs)
+
+	// Tasks cannot be moved underneath themselves
+	root.ParentID = grandchild.ID
+	err = tdbr.Update(ctx, *root)
+	assert.ErrorIs(t, err, task.ErrHierarchyCycle)
+
+	root.ParentID = root.ID
+	err = tdbr.Update(ctx, *root)
+	assert.ErrorIs(t, err, task.ErrHierarchyCycle)
+
+	orphan := task.New()
+	orphan.TenantID = "tenant-2"
+	orphan.ParentID = root.ID
+	err = tdbr.Save(ctx, *orphan)
+	assert.ErrorIs(t, err, task.ErrParentUnavailable, "Task was made a subtask of another tenant's task")
+
+	// Build a chain of subtasks down to the maximum depth below the sibling
+	parentID := sibling.ID
+	for depth := 2; depth <= task.MaxDepth; depth++ {
+		tsk := task.New()
+		tsk.TenantID = "tenant-1"
+		tsk.ParentID = parentID
+		err = tdbr.Save(ctx, *tsk)
+		require.NoError(t, err, "Save returned error at depth %d", depth)
+		parentID = tsk.ID
+	}
+
+	tooDeep := task.New()
+	tooDeep.TenantID = "tenant-1"
+	tooDeep.ParentID = parentID
+	err = tdbr.Save(ctx, *tooDeep)
+	assert.ErrorIs(t, err, task.ErrDepthExceeded)
+
+	// Batches identify the task that cannot be a subtask
+	shallow := task.New()
+	shallow.TenantID = "tenant-1"
+	shallow.ParentID = root.ID
+	err = tdbr.SaveBatch(ctx, []task.Task{*shallow, *tooDeep})
+	var batchErr *task.BatchError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Equal(t, 1, batchErr.Index)
+	assert.ErrorIs(t, err, task.ErrDepthExceeded)
+
+	// Moving a task moves its subtasks too
+	child.ParentID = sibling.ID
+	err = tdbr.Update(ctx, *child)
+	require.NoError(t, err, "Update returned error")
+
+	child.Version++
+	child.ParentID = parentID
+	err = tdbr.Update(ctx, *child)
+	assert.ErrorIs(t, err, task.ErrDepthExceeded)
+}
+
+func TestIntegrationDBRepoCompleteOccurrence(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+
+	cdbContainer, err := setupCockroachDB(ctx)
+	require.NoError(t, err, "Failed to start up CockroachDB container")
+	defer cdbContainer.Terminate(ctx)
+
+	db, err := sql.Open("pgx", cdbContainer.URI+"/projectmanagement")
+	require.NoError(t, err, "Failed to open connection to CockroachDB")
+	defer db.Close()
+
+	err = initCockroachDB(ctx, db)
+	require.NoError(t, err, "Failed to initialize CockroachDB")
+	defer truncateCockroachDB(ctx, db)
+
+	tdbr := task.DBRepo{DB: db}
+
+	dateDue := time.Date(2026, time.March, 2, 14, 0, 0, 0, time.UTC)
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+	tsk.Description = "Water the plants"
+	tsk.DateDue = &dateDue
+	tsk.Recurrence = &task.Recurrence{
+		Rule:      "FREQ=WEEKLY",
+		TimeZone:  "America/New_York",
+		DateStart: dateDue,
+		ExDates:   []time.Time{dateDue.AddDate(0, 0, 7)},
+	}
+	err = tdbr.Save(ctx, *tsk)
+	require.NoError(t, err, "Save returned error")
+
+	savedTsk, err := tdbr.Get(ctx, tsk.TenantID, tsk.ID)
+	require.NoError(t, err, "Get returned error")
+	require.NotNil(t, savedTsk.Recurrence, "Get did not return the recurrence")
+	assert.Equal(t, tsk.Recurrence.Rule, savedTsk.Recurrence.Rule)
+	assert.Equal(t, tsk.Recurrence.TimeZone, savedTsk.Recurrence.TimeZone)
+	assert.True(t, tsk.Recurrence.DateStart.Equal(savedTsk.Recurrence.DateStart), "Incorrect start")
+	assert.Len(t, savedTsk.Recurrence.ExDates, 1)
+
+	next, err := savedTsk.NextOccurrence()
+	require.NoError(t, err, "NextOccurrence returned error")
+	require.NotNil(t, next, "NextOccurrence did not return an occurrence")
+
+	now := time.Now()
+	completed := *savedTsk
+	completed.DateCompleted = &now
+	completed.Recurrence = nil
+	err = tdbr.CompleteOccurrence(ctx, completed, *next)
+	require.NoError(t, err, "CompleteOccurrence returned error")
+
+	completedTsk, err := tdbr.Get(ctx, tsk.TenantID, tsk.ID)
+	require.NoError(t, err, "Get returned error")
+	assert.True(t, completedTsk.Completed(), "Occurrence was not completed")
+	assert.Nil(t, completedTsk.Recurrence, "Completed occurrence kept the recurrence")
+
+	nextTsk, err := tdbr.Get(ctx, tsk.TenantID, next.ID)
+	require.NoError(t, err, "Get returned error")
+	require.NotNil(t, nextTsk, "Next occurrence was not stored")
+	assert.True(t, dateDue.AddDate(0, 0, 14).Equal(*nextTsk.DateDue), "Incorrect next due date")
+	assert.NotNil(t, nextTsk.Recurrence, "Next occurrence did not take over the recurrence")
+
+	// Completing the replaced version again must not create another occurrence
+	err = tdbr.CompleteOccurrence(ctx, completed, *next)
+	assert.ErrorIs(t, err, task.ErrVersionConflict)
+}
+
+func TestIntegrationDBRepoListDue(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+
+	cdbContainer, err := setupCockroachDB(ctx)
+	require.NoError(t, err, "Failed to start up CockroachDB container")
+	defer cdbContainer.Terminate(ctx)
+
+	db, err := sql.Open("pgx", cdbContainer.URI+"/projectmanagement")
+	require.NoError(t, err, "Failed to open connection to CockroachDB")
+	defer db.Close()
+
+	err = initCockroachDB(ctx, db)
+	require.NoError(t, err, "Failed to initialize CockroachDB")
+	defer truncateCockroachDB(ctx, db)
+
+	tdbr := task.DBRepo{DB: db}
+
+	dateDue := time.Date(2026, time.March, 2, 14, 0, 0, 0, time.UTC)
+	saveTask := func(description string, dateDue *time.Time, completed bool) *task.Task {
+		tsk := task.New()
+		tsk.TenantID = "tenant-1"
+		tsk.OwnerID = "user-1"
+		tsk.Description = description
+		tsk.DateDue = dateDue
+		tsk.ReminderOffsets = []time.Duration{time.Hour, 0}
+		if completed {
+			tsk.DateCompleted = dateDue
+		}
+		err := tdbr.Save(ctx, *tsk)
+		require.NoError(t, err, "Save returned error")
+		return tsk
+	}
+
+	// Tasks that share a due date are paged through by ID
+	first := saveTask("Buy milk", &dateDue, false)
+	second := saveTask("Buy eggs", &dateDue, false)
+	if second.ID < first.ID {
+		first, second = second, first
+	}
+	later := dateDue.Add(time.Hour)
+	third := saveTask("Buy bread", &later, false)
+	saveTask("Buy butter", &dateDue, true)
+	saveTask("Buy flour", nil, false)
+	tooLate := dateDue.Add(48 * time.Hour)
+	saveTask("Buy sugar", &tooLate, false)
+
+	// Tasks in archived projects are not due
+	pdbr := project.DBRepo{DB: db}
+	attic := project.New()
+	attic.TenantID = "tenant-1"
+	attic.Name = "Attic"
+	err = pdbr.Save(ctx, *attic)
+	require.NoError(t, err, "Project save returned error")
+	shelved := task.New()
+	shelved.TenantID = "tenant-1"
+	shelved.OwnerID = "user-1"
+	shelved.ProjectID = attic.ID
+	shelved.Description = "Buy paint"
+	shelved.DateDue = &dateDue
+	err = tdbr.Save(ctx, *shelved)
+	require.NoError(t, err, "Save returned error")
+	archived := time.Now()
+	attic.DateArchived = &archived
+	err = pdbr.Update(ctx, *attic)
+	require.NoError(t, err, "Project update returned error")
+
+	from := dateDue.Add(-time.Hour)
+	to := dateDue.Add(24 * time.Hour)
+
+	page, err := tdbr.ListDue(ctx, from, "", to, 2)
+	require.NoError(t, err, "ListDue returned error")
+	require.Len(t, page, 2)
+	assert.Equal(t, first.ID, page[0].ID)
+	assert.Equal(t, second.ID, page[1].ID)
+	assert.Equal(t, []time.Duration{time.Hour, 0}, page[0].ReminderOffsets)
+
+	page, err = tdbr.ListDue(ctx, *page[1].DateDue, page[1].ID, to, 2)
+	require.NoError(t, err, "ListDue returned error")
+	require.Len(t, page, 1)
+	assert.Equal(t, third.ID, page[0].ID)
+}
+
+func TestIntegrationDBRepoDelete(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+
+	cdbContainer, err := setupCockroachDB(ctx)
+	require.NoError(t, err, "Failed to start up CockroachDB container")
+	defer cdbContainer.Terminate(ctx)
+
+	db, err := sql.Open("pgx", cdbContainer.URI+"/projectmanagement")
+	require.NoError(t, err, "Failed to open connection to CockroachDB")
+	defer db.Close()
+
+	err = initCockroachDB(ctx, db)
+	require.NoError(t, err, "Failed to initialize CockroachDB")
+	defer truncateCockroachDB(ctx, db)
+
+	pdbr := project.DBRepo{DB: db}
+	tdbr := task.DBRepo{DB: db}
+	ddbr := task.DependencyDBRepo{DB: db}
+
+	home := project.New()
+	home.TenantID = "tenant-1"
+	home.Name = "Home"
+	err = pdbr.Save(ctx, *home)
+	require.NoError(t, err, "Project save returned error")
+
+	paint := task.New()
+	paint.TenantID = "tenant-1"
+	paint.ProjectID = home.ID
+	paint.Description = "Paint the fence"
+	buyPaint := task.New()
+	buyPaint.TenantID = "tenant-1"
+	buyPaint.ParentID = paint.ID
+	buyPaint.Description = "Buy paint"
+	err = tdbr.Save(ctx, *paint)
+	require.NoError(t, err, "Save returned error")
+	err = tdbr.Save(ctx, *buyPaint)
+	require.NoError(t, err, "Save returned error")
+
+	err = ddbr.Save(ctx, *task.NewDependency("tenant-1", paint.ID, buyPaint.ID))
+	require.NoError(t, err, "Dependency save returned error")
+
+	// Tasks with subtasks cannot be deleted
+	err = tdbr.Delete(ctx, *paint)
+	assert.ErrorIs(t, err, task.ErrHasSubtasks)
+
+	// Only the current version can be deleted
+	stale := *buyPaint
+	stale.Version = 0
+	err = tdbr.Delete(ctx, stale)
+	assert.ErrorIs(t, err, task.ErrVersionConflict)
+
+	err = tdbr.Delete(ctx, *buyPaint)
+	require.NoError(t, err, "Delete returned error")
+
+	err = tdbr.Delete(ctx, *paint)
+	require.NoError(t, err, "Delete returned error")
+
+	deletedTsk, err := tdbr.Get(ctx, "tenant-1", paint.ID)
+	require.NoError(t, err, "Get returned error")
+	assert.Nil(t, deletedTsk, "Task was not deleted")
+
+	dependencies, err := ddbr.List(ctx, "tenant-1")
+	require.NoError(t, err, "Dependency list returned error")
+	assert.Empty(t, dependencies, "Dependencies were not deleted with the task")
+
+	savedProject, err := pdbr.Get(ctx, "tenant-1", home.ID)
+	require.NoError(t, err, "Project get returned error")
+	assert.Equal(t, 0, savedProject.TaskCount, "Deleted task still counts towards its project")
+}
+
+func TestIntegrationDBRepoRecordsEvents(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+
+	cdbContainer, err := setupCockroachDB(ctx)
+	require.NoError(t, err, "Failed to start up CockroachDB container")
+	defer cdbContainer.Terminate(ctx)
+
+	db, err := sql.Open("pgx", cdbContainer.URI+"/projectmanagement")
+	require.NoError(t, err, "Failed to open connection to CockroachDB")
+	defer db.Close()
+
+	err = initCockroachDB(ctx, db)
+	require.NoError(t, err, "Failed to initialize CockroachDB")
+	defer truncateCockroachDB(ctx, db)
+
+	tdbr := task.DBRepo{DB: db}
+
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.Description = "Buy milk"
+	err = tdbr.Save(ctx, *tsk)
+	require.NoError(t, err, "Save returned error")
+
+	tsk.Description = "Buy oat milk"
+	err = tdbr.Update(ctx, *tsk)
+	require.NoError(t, err, "Update returned error")
+
+	// Failed changes do not record events
+	err = tdbr.Update(ctx, *tsk)
+	assert.ErrorIs(t, err, task.ErrVersionConflict)
+
+	updatedTsk, err := tdbr.Get(ctx, tsk.TenantID, tsk.ID)
+	require.NoError(t, err, "Get returned error")
+	err = tdbr.Delete(ctx, *updatedTsk)
+	require.NoError(t, err, "Delete returned error")
+
+	const query = `select tenant_id, ordering_key, sequence, type from outbox_message order by sequence`
+	rows, err := db.QueryContext(ctx, query)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var events []string
+	for rows.Next() {
+		var tenantID, key, eventType string
+		var sequence int
+		err = rows.Scan(&tenantID, &key, &sequence, &eventType)
+		require.NoError(t, err)
+		assert.Equal(t, "tenant-1", tenantID)
+		assert.Equal(t, tsk.ID, key)
+		events = append(events, fmt.Sprintf("%d %s", sequence, eventType))
+	}
+	require.NoError(t, rows.Err())
+
+	assert.Equal(t, []string{"1 task.created", "2 task.updated", "3 task.deleted"}, events)
+}
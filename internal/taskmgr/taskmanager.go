@@ -2,20 +2,33 @@ package taskmgr
 
 import (
 	"context"
+	"io"
+	"time"
 
+	"github.com/jaredpetersen/go-rest-template/internal/blob"
+	"github.com/jaredpetersen/go-rest-template/internal/project"
 	"github.com/jaredpetersen/go-rest-template/internal/task"
 	"github.com/rs/zerolog/log"
 )
 
 // DBRepo is a database repository for tasks.
 type Manager struct {
-	TaskCacheClient task.CacheClient
-	TaskDBClient    task.DBClient
+	AttachmentDBClient task.AttachmentDBClient
+	BlobStore          blob.Store
+	CommentCacheClient task.CommentCacheClient
+	CommentDBClient    task.CommentDBClient
+	DependencyDBClient task.DependencyDBClient
+	HistoryDBClient    task.HistoryDBClient
+	ProjectCacheClient project.CacheClient
+	TaskCacheClient    task.CacheClient
+	TaskDBClient       task.DBClient
+	TaskSearcher       task.Searcher
+	TagDBClient        task.TagDBClient
 }
 
-// Get retrieves a task by ID, first looking to the cache and then falling back on the database.
-func (mgr Manager) Get(ctx context.Context, id string) (*task.Task, error) {
-	task, err := mgr.TaskCacheClient.Get(ctx, id)
+// Get retrieves a tenant's task by ID, first looking to the cache and then falling back on the database.
+func (mgr Manager) Get(ctx context.Context, tenantID string, id string) (*task.Task, error) {
+	task, err := mgr.TaskCacheClient.Get(ctx, tenantID, id)
 	if err != nil {
 		log.Warn().Err(err).Msg("Failed to retrieve task from cache")
 	}
@@ -24,18 +37,576 @@ func (mgr Manager) Get(ctx context.Context, id string) (*task.Task, error) {
 		return task, nil
 	}
 
-	return mgr.TaskDBClient.Get(ctx, id)
+	return mgr.TaskDBClient.Get(ctx, tenantID, id)
 }
 
-// Save stores a task to both cache and database.
+// GetBatch retrieves a tenant's tasks by ID, first looking to the cache and then falling back on the database for any
+// tasks that were not cached. The tasks are returned in the same order as the IDs, with tasks that cannot be found left
+// out.
+func (mgr Manager) GetBatch(ctx context.Context, tenantID string, ids []string) ([]task.Task, error) {
+	cached, err := mgr.TaskCacheClient.GetBatch(ctx, tenantID, ids)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to retrieve tasks from cache")
+		cached = make([]*task.Task, len(ids))
+	}
+
+	found := make(map[string]task.Task, len(ids))
+	var misses []string
+	for i, id := range ids {
+		if cached[i] != nil {
+			found[id] = *cached[i]
+		} else {
+			misses = append(misses, id)
+		}
+	}
+
+	if len(misses) > 0 {
+		stored, err := mgr.TaskDBClient.GetBatch(ctx, tenantID, misses)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, t := range stored {
+			found[t.ID] = t
+		}
+	}
+
+	ts := make([]task.Task, 0, len(found))
+	for _, id := range ids {
+		if t, ok := found[id]; ok {
+			ts = append(ts, t)
+			// Only return each task once if the ID was requested multiple times
+			delete(found, id)
+		}
+	}
+
+	return ts, nil
+}
+
+// List retrieves a page of a tenant's tasks that match the filter from the database, newest first, along with the total
+// number of tasks that match it. The cache is not used since it cannot be queried.
+func (mgr Manager) List(ctx context.Context, tenantID string, f task.Filter) ([]task.Task, int, error) {
+	return mgr.TaskDBClient.List(ctx, tenantID, f)
+}
+
+// ListSubtasks retrieves a page of the subtasks of a tenant's task that match the filter from the database, optionally
+// including the whole tree below the task, along with the total number of subtasks that match it. The cache is not used
+// since it cannot be queried.
+func (mgr Manager) ListSubtasks(ctx context.Context, tenantID string, id string, f task.SubtaskFilter) ([]task.Task, int, error) {
+	return mgr.TaskDBClient.ListSubtasks(ctx, tenantID, id, f)
+}
+
+// Save stores a task in the database and then the cache.
 //
 // If the save to the cache fails, the error is logged and ignored so that we are resilient to fleeting cache
 // dependency issues.
 func (mgr Manager) Save(ctx context.Context, t task.Task) error {
-	err := mgr.TaskCacheClient.Save(ctx, t)
+	err := mgr.TaskDBClient.Save(ctx, t)
+	if err != nil {
+		return err
+	}
+
+	// Only cached once stored so that a task the database rejects is never served
+	err = mgr.TaskCacheClient.Save(ctx, t)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to store task in cache")
+	}
+
+	mgr.forgetProjects(ctx, t)
+	mgr.forgetAncestors(ctx, t.TenantID, subtaskIDs(t))
+	mgr.index(ctx, t)
+	return nil
+}
+
+// SaveBatch stores tasks in the database and then the cache. Either all of the tasks are stored or none of them are.
+//
+// If the save to the cache fails, the error is logged and ignored so that we are resilient to fleeting cache
+// dependency issues.
+func (mgr Manager) SaveBatch(ctx context.Context, ts []task.Task) error {
+	err := mgr.TaskDBClient.SaveBatch(ctx, ts)
+	if err != nil {
+		return err
+	}
+
+	// Only cached once stored so that tasks the database rejects are never served
+	err = mgr.TaskCacheClient.SaveBatch(ctx, ts)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to store tasks in cache")
+	}
+
+	mgr.forgetProjects(ctx, ts...)
+	if len(ts) > 0 {
+		mgr.forgetAncestors(ctx, ts[0].TenantID, subtaskIDs(ts...))
+	}
+	mgr.index(ctx, ts...)
+	return nil
+}
+
+// Update replaces a task in the database and then the cache. The task's version must be the version that is being
+// replaced. The updated task is returned.
+//
+// task.ErrVersionConflict is returned if the task has been changed since it was retrieved. If the update to the cache
+// fails, the task is removed from the cache so that the previous version is not served. The task's ancestors, both
+// before and after the update in case it was moved, are removed from the cache since their rollups may have changed.
+//
+// Completing an occurrence of a recurring task creates the next occurrence in the same transaction and moves the
+// recurrence over to it.
+func (mgr Manager) Update(ctx context.Context, t task.Task) (*task.Task, error) {
+	var next *task.Task
+	if t.Completed() && t.Recurrence != nil {
+		var err error
+		next, err = t.NextOccurrence()
+		if err != nil {
+			return nil, err
+		}
+
+		t.Recurrence = nil
+	}
+
+	previousAncestorIDs, err := mgr.TaskDBClient.GetAncestorIDs(ctx, t.TenantID, []string{t.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	if next != nil {
+		err = mgr.TaskDBClient.CompleteOccurrence(ctx, t, *next)
+	} else {
+		err = mgr.TaskDBClient.Update(ctx, t)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	t.Version++
+
+	err = mgr.TaskCacheClient.Save(ctx, t)
 	if err != nil {
 		log.Warn().Err(err).Msg("Failed to store task in cache")
+
+		err = mgr.TaskCacheClient.Delete(ctx, t.TenantID, t.ID)
+		if err != nil {
+			log.Error().Err(err).Str("task", t.ID).Msg("Failed to remove outdated task from cache")
+		}
+	}
+
+	mgr.forgetTasks(ctx, t.TenantID, previousAncestorIDs)
+	mgr.forgetAncestors(ctx, t.TenantID, subtaskIDs(t))
+	mgr.forgetBlocked(ctx, t)
+	mgr.index(ctx, t)
+
+	// The next occurrence shares the task's parent, whose ancestors have already been forgotten
+	if next != nil {
+		mgr.forgetProjects(ctx, *next)
+		mgr.index(ctx, *next)
 	}
 
-	return mgr.TaskDBClient.Save(ctx, t)
+	return &t, nil
+}
+
+// Delete removes a task from the database and then the cache. The task's version must be the version that is being
+// deleted.
+//
+// task.ErrVersionConflict is returned if the task has been changed since it was retrieved and task.ErrHasSubtasks if it
+// still has subtasks. The task's ancestors, project, and the tasks that it blocked are removed from the cache since
+// their rollups, task counts, and blockers have changed. The task's comments and attachments go away along with it.
+func (mgr Manager) Delete(ctx context.Context, t task.Task) error {
+	ancestorIDs, err := mgr.TaskDBClient.GetAncestorIDs(ctx, t.TenantID, []string{t.ID})
+	if err != nil {
+		return err
+	}
+
+	// The dependencies go away along with the task so the tasks that it blocked must be found first
+	blockedIDs, err := mgr.DependencyDBClient.BlockedIDs(ctx, t.TenantID, t.ID)
+	if err != nil {
+		return err
+	}
+
+	// The attachments' metadata goes away along with the task so the attachments must be found first
+	attachments, err := mgr.AttachmentDBClient.List(ctx, t.TenantID, t.ID)
+	if err != nil {
+		return err
+	}
+
+	err = mgr.TaskDBClient.Delete(ctx, t)
+	if err != nil {
+		return err
+	}
+
+	mgr.forgetTasks(ctx, t.TenantID, append([]string{t.ID}, ancestorIDs...))
+	mgr.forgetTasks(ctx, t.TenantID, blockedIDs)
+	mgr.forgetProjects(ctx, t)
+	mgr.forgetComments(ctx, t.TenantID, t.ID)
+	mgr.deleteBlobs(ctx, attachments...)
+	return nil
+}
+
+// Search finds up to limit of a tenant's tasks by keyword, most relevant first.
+func (mgr Manager) Search(ctx context.Context, tenantID string, query string, limit int) ([]task.SearchResult, error) {
+	return mgr.TaskSearcher.Search(ctx, tenantID, query, limit)
+}
+
+// forgetProjects removes the projects that stored tasks were added to from the cache, since their task counts have
+// changed.
+//
+// If the removal fails, the error is logged and ignored since the tasks have already been stored.
+func (mgr Manager) forgetProjects(ctx context.Context, ts ...task.Task) {
+	forgotten := make(map[string]bool)
+	for _, t := range ts {
+		if t.ProjectID == "" || forgotten[t.ProjectID] {
+			continue
+		}
+		forgotten[t.ProjectID] = true
+
+		err := mgr.ProjectCacheClient.Delete(ctx, t.TenantID, t.ProjectID)
+		if err != nil {
+			log.Error().Err(err).Str("project", t.ProjectID).Msg("Failed to remove outdated project from cache")
+		}
+	}
+}
+
+// forgetAncestors removes every task that the subtasks are nested under from the cache, since their rollups have
+// changed.
+//
+// If the removal fails, the error is logged and ignored since the subtasks have already been stored.
+func (mgr Manager) forgetAncestors(ctx context.Context, tenantID string, ids []string) {
+	if len(ids) == 0 {
+		return
+	}
+
+	ancestorIDs, err := mgr.TaskDBClient.GetAncestorIDs(ctx, tenantID, ids)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to find tasks with outdated rollups")
+		return
+	}
+
+	mgr.forgetTasks(ctx, tenantID, ancestorIDs)
+}
+
+// forgetBlocked removes the tasks that the task blocks from the cache, since the task may have been completed.
+//
+// If the removal fails, the error is logged and ignored since the task has already been stored.
+func (mgr Manager) forgetBlocked(ctx context.Context, t task.Task) {
+	blockedIDs, err := mgr.DependencyDBClient.BlockedIDs(ctx, t.TenantID, t.ID)
+	if err != nil {
+		log.Error().Err(err).Str("task", t.ID).Msg("Failed to find tasks with outdated blockers")
+		return
+	}
+
+	mgr.forgetTasks(ctx, t.TenantID, blockedIDs)
+}
+
+// forgetTasks removes tasks from the cache. If the removal fails, the error is logged and ignored.
+func (mgr Manager) forgetTasks(ctx context.Context, tenantID string, ids []string) {
+	for _, id := range ids {
+		err := mgr.TaskCacheClient.Delete(ctx, tenantID, id)
+		if err != nil {
+			log.Error().Err(err).Str("task", id).Msg("Failed to remove outdated task from cache")
+		}
+	}
+}
+
+// subtaskIDs finds the IDs of the tasks that are subtasks of another task.
+func subtaskIDs(ts ...task.Task) []string {
+	var ids []string
+	for _, t := range ts {
+		if t.ParentID != "" {
+			ids = append(ids, t.ID)
+		}
+	}
+
+	return ids
+}
+
+// index adds stored tasks to the search index when the searcher maintains its own index.
+//
+// If indexing fails, the error is logged and ignored since the tasks have already been stored.
+func (mgr Manager) index(ctx context.Context, ts ...task.Task) {
+	indexer, ok := mgr.TaskSearcher.(task.Indexer)
+	if !ok {
+		return
+	}
+
+	err := indexer.Index(ctx, ts...)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to index tasks for search")
+	}
+}
+
+// GetTag retrieves a tenant's tag by ID from the database.
+func (mgr Manager) GetTag(ctx context.Context, tenantID string, id string) (*task.Tag, error) {
+	return mgr.TagDBClient.Get(ctx, tenantID, id)
+}
+
+// GetTagsByName retrieves a tenant's tags by name from the database. Tags that cannot be found are left out.
+func (mgr Manager) GetTagsByName(ctx context.Context, tenantID string, names []string) ([]task.Tag, error) {
+	return mgr.TagDBClient.GetByNames(ctx, tenantID, names)
+}
+
+// ListTags retrieves all of a tenant's tags from the database, ordered by name.
+func (mgr Manager) ListTags(ctx context.Context, tenantID string) ([]task.Tag, error) {
+	return mgr.TagDBClient.List(ctx, tenantID)
+}
+
+// SaveTag stores a tag in the database. task.ErrTagExists is returned if the tenant already has a tag with the same
+// name.
+func (mgr Manager) SaveTag(ctx context.Context, t task.Tag) error {
+	return mgr.TagDBClient.Save(ctx, t)
+}
+
+// RenameTag renames a tag in the database and then in every cached task that has the tag. The renamed tag is returned.
+// task.ErrTagExists is returned if the tenant already has another tag with the same name.
+//
+// If the cached tasks cannot be changed, they are removed from the cache so that the old name is not served.
+func (mgr Manager) RenameTag(ctx context.Context, t task.Tag, name string) (*task.Tag, error) {
+	from := t.Name
+	t.Name = name
+	t.DateUpdated = time.Now()
+
+	err := mgr.TagDBClient.Update(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+
+	taskIDs, err := mgr.TagDBClient.TaskIDs(ctx, t.TenantID, t.ID)
+	if err != nil {
+		log.Error().Err(err).Str("tag", t.ID).Msg("Failed to find tasks with renamed tag")
+		return &t, nil
+	}
+
+	mgr.renameCachedTag(ctx, t.TenantID, taskIDs, from, name)
+	return &t, nil
+}
+
+// DeleteTag removes a tag from the database and then from every cached task that had the tag.
+//
+// If the cached tasks cannot be changed, they are removed from the cache so that the deleted tag is not served.
+func (mgr Manager) DeleteTag(ctx context.Context, t task.Tag) error {
+	taskIDs, err := mgr.TagDBClient.Delete(ctx, t.TenantID, t.ID)
+	if err != nil {
+		return err
+	}
+
+	mgr.renameCachedTag(ctx, t.TenantID, taskIDs, t.Name, "")
+	return nil
+}
+
+// renameCachedTag renames a tag in the cached tasks, falling back on removing the tasks from the cache.
+func (mgr Manager) renameCachedTag(ctx context.Context, tenantID string, taskIDs []string, from string, to string) {
+	err := mgr.TaskCacheClient.RenameTag(ctx, tenantID, taskIDs, from, to)
+	if err == nil {
+		return
+	}
+
+	log.Warn().Err(err).Msg("Failed to rename tag in cached tasks")
+
+	for _, id := range taskIDs {
+		err = mgr.TaskCacheClient.Delete(ctx, tenantID, id)
+		if err != nil {
+			log.Error().Err(err).Str("task", id).Msg("Failed to remove outdated task from cache")
+		}
+	}
+}
+
+// SaveDependency stores a dependency in the database and then removes the blocked task from the cache, since its
+// blockers have changed. task.ErrDependencyCycle is returned if the dependency would make the tasks depend on each
+// other in a cycle.
+func (mgr Manager) SaveDependency(ctx context.Context, d task.Dependency) error {
+	err := mgr.DependencyDBClient.Save(ctx, d)
+	if err != nil {
+		return err
+	}
+
+	mgr.forgetTasks(ctx, d.TenantID, []string{d.BlockedID})
+	return nil
+}
+
+// DeleteDependency removes a dependency from the database and then removes the blocked task from the cache, since its
+// blockers have changed.
+func (mgr Manager) DeleteDependency(ctx context.Context, d task.Dependency) error {
+	err := mgr.DependencyDBClient.Delete(ctx, d)
+	if err != nil {
+		return err
+	}
+
+	mgr.forgetTasks(ctx, d.TenantID, []string{d.BlockedID})
+	return nil
+}
+
+// SortTasks orders a tenant's tasks so that every task comes after all of the tasks that block it.
+// task.ErrDependencyCycle is returned if the tasks depend on each other in a cycle.
+func (mgr Manager) SortTasks(ctx context.Context, tenantID string, ids []string) ([]string, error) {
+	ds, err := mgr.DependencyDBClient.List(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return task.NewGraph(ds).TopologicalSort(ids)
+}
+
+// GetRevision retrieves the revision that brought a tenant's task to the version from the database.
+func (mgr Manager) GetRevision(ctx context.Context, tenantID string, id string, version int) (*task.Revision, error) {
+	return mgr.HistoryDBClient.GetRevision(ctx, tenantID, id, version)
+}
+
+// GetRevisionAsOf retrieves the revision that holds a tenant's task as it was at the time from the database.
+func (mgr Manager) GetRevisionAsOf(ctx context.Context, tenantID string, id string, asOf time.Time) (*task.Revision, error) {
+	return mgr.HistoryDBClient.GetRevisionAsOf(ctx, tenantID, id, asOf)
+}
+
+// ListHistory retrieves a page of the revisions in the history of a tenant's task from the database, newest first.
+// History is not cached since it is rarely read and grows with every change.
+func (mgr Manager) ListHistory(ctx context.Context, tenantID string, id string, limit int, offset int) ([]task.Revision, error) {
+	return mgr.HistoryDBClient.ListRevisions(ctx, tenantID, id, limit, offset)
+}
+
+// GetComment retrieves a comment on a tenant's task by ID from the database. Individual comments are not cached since
+// they are only retrieved to be changed.
+func (mgr Manager) GetComment(ctx context.Context, tenantID string, taskID string, id string) (*task.Comment, error) {
+	return mgr.CommentDBClient.Get(ctx, tenantID, taskID, id)
+}
+
+// ListComments retrieves a page of the comments on a tenant's task, oldest first, first looking to the cache and then
+// falling back on the database. Pages that are retrieved from the database are stored in the cache.
+//
+// If the cache fails, the error is logged and ignored so that we are resilient to fleeting cache dependency issues.
+func (mgr Manager) ListComments(ctx context.Context, tenantID string, taskID string, limit int, offset int) (*task.CommentPage, error) {
+	page, generation, cacheErr := mgr.CommentCacheClient.GetPage(ctx, tenantID, taskID, limit, offset)
+	if cacheErr != nil {
+		log.Warn().Err(cacheErr).Msg("Failed to retrieve comments from cache")
+	}
+
+	if page != nil {
+		return page, nil
+	}
+
+	cs, err := mgr.CommentDBClient.List(ctx, tenantID, taskID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	page = &task.CommentPage{Comments: cs, Limit: limit, Offset: offset}
+
+	// A page is only stored when the generation is known, otherwise it could replace a newer page
+	if cacheErr == nil {
+		err = mgr.CommentCacheClient.SavePage(ctx, tenantID, taskID, *page, generation)
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to store comments in cache")
+		}
+	}
+
+	return page, nil
+}
+
+// SaveComment stores a comment in the database and then removes its task and the task's pages of comments from the
+// cache, since the task's comment count has changed. task.ErrTaskUnavailable is returned if the task does not exist.
+func (mgr Manager) SaveComment(ctx context.Context, c task.Comment) error {
+	err := mgr.CommentDBClient.Save(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	mgr.forgetTasks(ctx, c.TenantID, []string{c.TaskID})
+	mgr.forgetComments(ctx, c.TenantID, c.TaskID)
+	return nil
+}
+
+// UpdateComment replaces the body of a comment in the database and then removes the task's pages of comments from the
+// cache. The comment's version must be the version that is being replaced. The updated comment is returned.
+//
+// task.ErrCommentVersionConflict is returned if the comment has been changed since it was retrieved.
+func (mgr Manager) UpdateComment(ctx context.Context, c task.Comment, body string) (*task.Comment, error) {
+	c.Body = body
+	c.DateUpdated = time.Now()
+
+	err := mgr.CommentDBClient.Update(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Version++
+
+	mgr.forgetComments(ctx, c.TenantID, c.TaskID)
+	return &c, nil
+}
+
+// DeleteComment removes a comment from the database and then removes its task and the task's pages of comments from
+// the cache, since the task's comment count has changed.
+func (mgr Manager) DeleteComment(ctx context.Context, c task.Comment) error {
+	err := mgr.CommentDBClient.Delete(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	mgr.forgetTasks(ctx, c.TenantID, []string{c.TaskID})
+	mgr.forgetComments(ctx, c.TenantID, c.TaskID)
+	return nil
+}
+
+// forgetComments removes the pages of the comments on a task from the cache. If the removal fails, the error is logged
+// and ignored.
+func (mgr Manager) forgetComments(ctx context.Context, tenantID string, taskID string) {
+	err := mgr.CommentCacheClient.Forget(ctx, tenantID, taskID)
+	if err != nil {
+		log.Error().Err(err).Str("task", taskID).Msg("Failed to remove outdated comments from cache")
+	}
+}
+
+// GetAttachment retrieves the metadata of a file attached to a tenant's task by ID from the database.
+func (mgr Manager) GetAttachment(ctx context.Context, tenantID string, taskID string, id string) (*task.Attachment, error) {
+	return mgr.AttachmentDBClient.Get(ctx, tenantID, taskID, id)
+}
+
+// ListAttachments retrieves the metadata of all of the files attached to a tenant's task from the database, oldest
+// first.
+func (mgr Manager) ListAttachments(ctx context.Context, tenantID string, taskID string) ([]task.Attachment, error) {
+	return mgr.AttachmentDBClient.List(ctx, tenantID, taskID)
+}
+
+// SaveAttachment stores the content of a file attached to a task in blob storage and then its metadata in the database.
+// The content must match the attachment's size and checksum; blob.ErrChecksumMismatch is returned if it does not.
+//
+// task.ErrTaskUnavailable is returned if the task does not exist and task.ErrTooManyAttachments if the task already has
+// too many attachments, in which case the content is removed from blob storage again.
+func (mgr Manager) SaveAttachment(ctx context.Context, a task.Attachment, r io.Reader) error {
+	err := mgr.BlobStore.Put(ctx, a.BlobKey(), r, a.Size, a.Checksum)
+	if err != nil {
+		return err
+	}
+
+	err = mgr.AttachmentDBClient.Save(ctx, a)
+	if err != nil {
+		mgr.deleteBlobs(ctx, a)
+		return err
+	}
+
+	return nil
+}
+
+// OpenAttachment opens the content of a file attached to a task for reading. The content is not retrieved from blob
+// storage until it is read, so seeking to the requested range first only retrieves that range.
+func (mgr Manager) OpenAttachment(ctx context.Context, a task.Attachment) io.ReadSeekCloser {
+	return blob.NewReader(ctx, mgr.BlobStore, a.BlobKey(), a.Size)
+}
+
+// DeleteAttachment removes the metadata of a file attached to a task from the database and then its content from blob
+// storage.
+func (mgr Manager) DeleteAttachment(ctx context.Context, a task.Attachment) error {
+	err := mgr.AttachmentDBClient.Delete(ctx, a)
+	if err != nil {
+		return err
+	}
+
+	mgr.deleteBlobs(ctx, a)
+	return nil
+}
+
+// deleteBlobs removes the content of attachments from blob storage. If the removal fails, the error is logged and
+// ignored since the attachments can no longer be retrieved without their metadata.
+func (mgr Manager) deleteBlobs(ctx context.Context, as ...task.Attachment) {
+	for _, a := range as {
+		err := mgr.BlobStore.Delete(ctx, a.BlobKey())
+		if err != nil {
+			log.Error().Err(err).Str("attachment", a.ID).Msg("Failed to remove attachment content from blob storage")
+		}
+	}
 }
@@ -4,8 +4,14 @@ import (
 	"context"
 	"errors"
 	"github.com/jaredpetersen/go-rest-template/internal/taskmgr"
+	"io"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/jaredpetersen/go-rest-template/internal/blob"
+	blobmock "github.com/jaredpetersen/go-rest-template/internal/blob/mocks"
+	projectmock "github.com/jaredpetersen/go-rest-template/internal/project/mocks"
 	"github.com/jaredpetersen/go-rest-template/internal/task"
 	taskmock "github.com/jaredpetersen/go-rest-template/internal/task/mocks"
 	"github.com/stretchr/testify/assert"
@@ -15,16 +21,16 @@ import (
 func TestGetReturnsCachedTask(t *testing.T) {
 	ctx := context.Background()
 
-	storedTask := task.Task{ID: "someid"}
+	storedTask := task.Task{ID: "someid", TenantID: "sometenant"}
 
 	tcr := taskmock.CacheClient{}
-	tcr.On("Get", mock.Anything, storedTask.ID).Return(&storedTask, nil)
+	tcr.On("Get", mock.Anything, storedTask.TenantID, storedTask.ID).Return(&storedTask, nil)
 
 	tdbr := taskmock.DBClient{}
 
 	mgr := taskmgr.Manager{TaskCacheClient: &tcr, TaskDBClient: &tdbr}
 
-	retrievedTask, err := mgr.Get(ctx, storedTask.ID)
+	retrievedTask, err := mgr.Get(ctx, storedTask.TenantID, storedTask.ID)
 	assert.NoError(t, err, "Returned error")
 	assert.Equal(t, &storedTask, retrievedTask, "Returned incorrect task")
 
@@ -35,17 +41,17 @@ func TestGetReturnsCachedTask(t *testing.T) {
 func TestGetReturnsStoredTaskOnCacheMiss(t *testing.T) {
 	ctx := context.Background()
 
-	storedTask := task.Task{ID: "someid"}
+	storedTask := task.Task{ID: "someid", TenantID: "sometenant"}
 
 	tcr := taskmock.CacheClient{}
-	tcr.On("Get", mock.Anything, storedTask.ID).Return(nil, nil)
+	tcr.On("Get", mock.Anything, storedTask.TenantID, storedTask.ID).Return(nil, nil)
 
 	tdbr := taskmock.DBClient{}
-	tdbr.On("Get", mock.Anything, storedTask.ID).Return(&storedTask, nil)
+	tdbr.On("Get", mock.Anything, storedTask.TenantID, storedTask.ID).Return(&storedTask, nil)
 
 	mgr := taskmgr.Manager{TaskCacheClient: &tcr, TaskDBClient: &tdbr}
 
-	retrievedTask, err := mgr.Get(ctx, storedTask.ID)
+	retrievedTask, err := mgr.Get(ctx, storedTask.TenantID, storedTask.ID)
 	assert.NoError(t, err, "Returned error")
 	assert.Equal(t, &storedTask, retrievedTask, "Returned incorrect task")
 
@@ -56,17 +62,17 @@ func TestGetReturnsStoredTaskOnCacheMiss(t *testing.T) {
 func TestGetReturnsStoredTaskOnCacheError(t *testing.T) {
 	ctx := context.Background()
 
-	storedTask := task.Task{ID: "someid"}
+	storedTask := task.Task{ID: "someid", TenantID: "sometenant"}
 
 	tcr := taskmock.CacheClient{}
-	tcr.On("Get", mock.Anything, storedTask.ID).Return(nil, errors.New("Failed"))
+	tcr.On("Get", mock.Anything, storedTask.TenantID, storedTask.ID).Return(nil, errors.New("Failed"))
 
 	tdbr := taskmock.DBClient{}
-	tdbr.On("Get", mock.Anything, storedTask.ID).Return(&storedTask, nil)
+	tdbr.On("Get", mock.Anything, storedTask.TenantID, storedTask.ID).Return(&storedTask, nil)
 
 	mgr := taskmgr.Manager{TaskCacheClient: &tcr, TaskDBClient: &tdbr}
 
-	retrievedTask, err := mgr.Get(ctx, storedTask.ID)
+	retrievedTask, err := mgr.Get(ctx, storedTask.TenantID, storedTask.ID)
 	assert.NoError(t, err, "Returned error")
 	assert.Equal(t, &storedTask, retrievedTask, "Returned incorrect task")
 
@@ -77,18 +83,18 @@ func TestGetReturnsStoredTaskOnCacheError(t *testing.T) {
 func TestGetReturnsErrorOnDBError(t *testing.T) {
 	ctx := context.Background()
 
-	storedTask := task.Task{ID: "someid"}
+	storedTask := task.Task{ID: "someid", TenantID: "sometenant"}
 	dbErr := errors.New("Fail")
 
 	tcr := taskmock.CacheClient{}
-	tcr.On("Get", mock.Anything, storedTask.ID).Return(nil, nil)
+	tcr.On("Get", mock.Anything, storedTask.TenantID, storedTask.ID).Return(nil, nil)
 
 	tdbr := taskmock.DBClient{}
-	tdbr.On("Get", mock.Anything, storedTask.ID).Return(nil, dbErr)
+	tdbr.On("Get", mock.Anything, storedTask.TenantID, storedTask.ID).Return(nil, dbErr)
 
 	mgr := taskmgr.Manager{TaskCacheClient: &tcr, TaskDBClient: &tdbr}
 
-	retrievedTask, err := mgr.Get(ctx, storedTask.ID)
+	retrievedTask, err := mgr.Get(ctx, storedTask.TenantID, storedTask.ID)
 	assert.ErrorIs(t, dbErr, err, "Incorrect error")
 	assert.Nil(t, retrievedTask, "Task must be nil")
 
@@ -143,7 +149,6 @@ func TestSaveReturnsErrorOnDBError(t *testing.T) {
 	dbErr := errors.New("Failed")
 
 	tcr := taskmock.CacheClient{}
-	tcr.On("Save", mock.Anything, tsk).Return(nil)
 
 	tdbr := taskmock.DBClient{}
 	tdbr.On("Save", mock.Anything, tsk).Return(dbErr)
@@ -154,5 +159,1140 @@ func TestSaveReturnsErrorOnDBError(t *testing.T) {
 	assert.ErrorIs(t, dbErr, err, "Incorrect error")
 
 	tdbr.AssertExpectations(t)
+
+	// A task that was not stored must not be served from the cache
+	tcr.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
+
+func TestUpdate(t *testing.T) {
+	ctx := context.Background()
+
+	tsk := task.Task{ID: "someid", TenantID: "sometenant", Version: 1}
+	updatedTsk := task.Task{ID: "someid", TenantID: "sometenant", Version: 2}
+
+	tcr := taskmock.CacheClient{}
+	tcr.On("Save", mock.Anything, updatedTsk).Return(nil)
+
+	tdbr := taskmock.DBClient{}
+	tdbr.On("GetAncestorIDs", mock.Anything, tsk.TenantID, []string{tsk.ID}).Return([]string{}, nil)
+	tdbr.On("Update", mock.Anything, tsk).Return(nil)
+
+	ddbr := taskmock.DependencyDBClient{}
+	ddbr.On("BlockedIDs", mock.Anything, tsk.TenantID, tsk.ID).Return([]string{}, nil)
+
+	mgr := taskmgr.Manager{DependencyDBClient: &ddbr, TaskCacheClient: &tcr, TaskDBClient: &tdbr}
+
+	res, err := mgr.Update(ctx, tsk)
+	assert.NoError(t, err, "Returned error")
+	assert.Equal(t, &updatedTsk, res, "Returned incorrect task")
+
+	tdbr.AssertExpectations(t)
+	tcr.AssertExpectations(t)
+}
+
+func TestUpdateRemovesTaskFromCacheOnCacheError(t *testing.T) {
+	ctx := context.Background()
+
+	tsk := task.Task{ID: "someid", TenantID: "sometenant", Version: 1}
+
+	tcr := taskmock.CacheClient{}
+	tcr.On("Save", mock.Anything, mock.Anything).Return(errors.New("Failed"))
+	tcr.On("Delete", mock.Anything, tsk.TenantID, tsk.ID).Return(nil)
+
+	tdbr := taskmock.DBClient{}
+	tdbr.On("GetAncestorIDs", mock.Anything, tsk.TenantID, []string{tsk.ID}).Return([]string{}, nil)
+	tdbr.On("Update", mock.Anything, tsk).Return(nil)
+
+	ddbr := taskmock.DependencyDBClient{}
+	ddbr.On("BlockedIDs", mock.Anything, tsk.TenantID, tsk.ID).Return([]string{}, nil)
+
+	mgr := taskmgr.Manager{DependencyDBClient: &ddbr, TaskCacheClient: &tcr, TaskDBClient: &tdbr}
+
+	res, err := mgr.Update(ctx, tsk)
+	assert.NoError(t, err, "Returned error")
+	assert.Equal(t, 2, res.Version, "Did not increment version")
+
+	tdbr.AssertExpectations(t)
+	tcr.AssertExpectations(t)
+}
+
+func TestUpdateReturnsErrorOnDBError(t *testing.T) {
+	ctx := context.Background()
+
+	tsk := task.Task{ID: "someid", TenantID: "sometenant", Version: 1}
+
+	tcr := taskmock.CacheClient{}
+
+	tdbr := taskmock.DBClient{}
+	tdbr.On("GetAncestorIDs", mock.Anything, tsk.TenantID, []string{tsk.ID}).Return([]string{}, nil)
+	tdbr.On("Update", mock.Anything, tsk).Return(task.ErrVersionConflict)
+
+	mgr := taskmgr.Manager{TaskCacheClient: &tcr, TaskDBClient: &tdbr}
+
+	res, err := mgr.Update(ctx, tsk)
+	assert.ErrorIs(t, err, task.ErrVersionConflict, "Incorrect error")
+	assert.Nil(t, res, "Task must be nil")
+
+	// Cache must not be touched when the update fails
+	tdbr.AssertExpectations(t)
+	tcr.AssertExpectations(t)
+}
+
+func TestGetBatchCombinesCacheAndDB(t *testing.T) {
+	ctx := context.Background()
+
+	cachedTask := task.Task{ID: "first", TenantID: "sometenant"}
+	storedTask := task.Task{ID: "second", TenantID: "sometenant"}
+
+	tcr := taskmock.CacheClient{}
+	tcr.On("GetBatch", mock.Anything, "sometenant", []string{"first", "second", "third"}).Return([]*task.Task{&cachedTask, nil, nil}, nil)
+
+	tdbr := taskmock.DBClient{}
+	tdbr.On("GetBatch", mock.Anything, "sometenant", []string{"second", "third"}).Return([]task.Task{storedTask}, nil)
+
+	mgr := taskmgr.Manager{TaskCacheClient: &tcr, TaskDBClient: &tdbr}
+
+	tsks, err := mgr.GetBatch(ctx, "sometenant", []string{"first", "second", "third"})
+	assert.NoError(t, err, "Returned error")
+	assert.Equal(t, []task.Task{cachedTask, storedTask}, tsks, "Returned incorrect tasks")
+
+	tcr.AssertExpectations(t)
+	tdbr.AssertExpectations(t)
+}
+
+func TestGetBatchAllCached(t *testing.T) {
+	ctx := context.Background()
+
+	cachedTask := task.Task{ID: "first", TenantID: "sometenant"}
+
+	tcr := taskmock.CacheClient{}
+	tcr.On("GetBatch", mock.Anything, "sometenant", []string{"first"}).Return([]*task.Task{&cachedTask}, nil)
+
+	tdbr := taskmock.DBClient{}
+
+	mgr := taskmgr.Manager{TaskCacheClient: &tcr, TaskDBClient: &tdbr}
+
+	tsks, err := mgr.GetBatch(ctx, "sometenant", []string{"first"})
+	assert.NoError(t, err, "Returned error")
+	assert.Equal(t, []task.Task{cachedTask}, tsks, "Returned incorrect tasks")
+
+	// Database must not be queried when every task is cached
+	tdbr.AssertExpectations(t)
+}
+
+func TestGetBatchReturnsStoredTasksOnCacheError(t *testing.T) {
+	ctx := context.Background()
+
+	storedTask := task.Task{ID: "first", TenantID: "sometenant"}
+
+	tcr := taskmock.CacheClient{}
+	tcr.On("GetBatch", mock.Anything, "sometenant", []string{"first", "first"}).Return(nil, errors.New("Failed"))
+
+	tdbr := taskmock.DBClient{}
+	tdbr.On("GetBatch", mock.Anything, "sometenant", []string{"first", "first"}).Return([]task.Task{storedTask}, nil)
+
+	mgr := taskmgr.Manager{TaskCacheClient: &tcr, TaskDBClient: &tdbr}
+
+	tsks, err := mgr.GetBatch(ctx, "sometenant", []string{"first", "first"})
+	assert.NoError(t, err, "Returned error")
+	assert.Equal(t, []task.Task{storedTask}, tsks, "Returned incorrect tasks")
+}
+
+func TestGetBatchReturnsErrorOnDBError(t *testing.T) {
+	ctx := context.Background()
+
+	dbErr := errors.New("Failed")
+
+	tcr := taskmock.CacheClient{}
+	tcr.On("GetBatch", mock.Anything, mock.Anything, mock.Anything).Return([]*task.Task{nil}, nil)
+
+	tdbr := taskmock.DBClient{}
+	tdbr.On("GetBatch", mock.Anything, mock.Anything, mock.Anything).Return(nil, dbErr)
+
+	mgr := taskmgr.Manager{TaskCacheClient: &tcr, TaskDBClient: &tdbr}
+
+	tsks, err := mgr.GetBatch(ctx, "sometenant", []string{"first"})
+	assert.ErrorIs(t, err, dbErr, "Incorrect error")
+	assert.Nil(t, tsks, "Tasks must be nil")
+}
+
+func TestSaveBatch(t *testing.T) {
+	ctx := context.Background()
+
+	tsks := []task.Task{{ID: "first"}, {ID: "second"}}
+
+	tcr := taskmock.CacheClient{}
+	tcr.On("SaveBatch", mock.Anything, tsks).Return(errors.New("Failed"))
+
+	tdbr := taskmock.DBClient{}
+	tdbr.On("SaveBatch", mock.Anything, tsks).Return(nil)
+
+	mgr := taskmgr.Manager{TaskCacheClient: &tcr, TaskDBClient: &tdbr}
+
+	// Cache errors are ignored
+	err := mgr.SaveBatch(ctx, tsks)
+	assert.NoError(t, err, "Returned error")
+
+	tdbr.AssertExpectations(t)
+	tcr.AssertExpectations(t)
+}
+
+func TestSaveBatchRemovesProjectsFromCache(t *testing.T) {
+	ctx := context.Background()
+
+	tsks := []task.Task{
+		{ID: "first", TenantID: "sometenant", ProjectID: "someproject"},
+		{ID: "second", TenantID: "sometenant", ProjectID: "someproject"},
+		{ID: "third", TenantID: "sometenant"},
+	}
+
+	tcr := taskmock.CacheClient{}
+	tcr.On("SaveBatch", mock.Anything, tsks).Return(nil)
+
+	tdbr := taskmock.DBClient{}
+	tdbr.On("SaveBatch", mock.Anything, tsks).Return(nil)
+
+	// The project's task count changed so it is removed from the cache once
+	pcr := projectmock.CacheClient{}
+	pcr.On("Delete", mock.Anything, "sometenant", "someproject").Return(nil).Once()
+
+	mgr := taskmgr.Manager{TaskCacheClient: &tcr, TaskDBClient: &tdbr, ProjectCacheClient: &pcr}
+
+	err := mgr.SaveBatch(ctx, tsks)
+	assert.NoError(t, err, "Returned error")
+
+	pcr.AssertExpectations(t)
+}
+
+func TestSaveDoesNotRemoveProjectFromCacheOnDBError(t *testing.T) {
+	ctx := context.Background()
+
+	tsk := task.Task{ID: "someid", TenantID: "sometenant", ProjectID: "someproject"}
+
+	tcr := taskmock.CacheClient{}
+	tcr.On("Save", mock.Anything, tsk).Return(nil)
+
+	tdbr := taskmock.DBClient{}
+	tdbr.On("Save", mock.Anything, tsk).Return(task.ErrProjectUnavailable)
+
+	pcr := projectmock.CacheClient{}
+
+	mgr := taskmgr.Manager{TaskCacheClient: &tcr, TaskDBClient: &tdbr, ProjectCacheClient: &pcr}
+
+	err := mgr.Save(ctx, tsk)
+	assert.ErrorIs(t, err, task.ErrProjectUnavailable, "Incorrect error")
+
+	pcr.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSaveBatchReturnsErrorOnDBError(t *testing.T) {
+	ctx := context.Background()
+
+	tsks := []task.Task{{ID: "first"}}
+	dbErr := errors.New("Failed")
+
+	tcr := taskmock.CacheClient{}
+
+	tdbr := taskmock.DBClient{}
+	tdbr.On("SaveBatch", mock.Anything, tsks).Return(dbErr)
+
+	mgr := taskmgr.Manager{TaskCacheClient: &tcr, TaskDBClient: &tdbr}
+
+	err := mgr.SaveBatch(ctx, tsks)
+	assert.ErrorIs(t, err, dbErr, "Incorrect error")
+
+	// Tasks that were not stored must not be served from the cache
+	tcr.AssertNotCalled(t, "SaveBatch", mock.Anything, mock.Anything)
+}
+
+func TestSearch(t *testing.T) {
+	ctx := context.Background()
+
+	results := []task.SearchResult{{Task: task.Task{ID: "first"}, Score: 1, Highlight: "<mark>socks</mark>"}}
+
+	searcher := taskmock.Searcher{}
+	searcher.On("Search", mock.Anything, "sometenant", "socks", 10).Return(results, nil)
+
+	mgr := taskmgr.Manager{TaskSearcher: &searcher}
+
+	res, err := mgr.Search(ctx, "sometenant", "socks", 10)
+	assert.NoError(t, err, "Returned error")
+	assert.Equal(t, results, res, "Returned incorrect results")
+}
+
+func TestSaveIndexesTask(t *testing.T) {
+	ctx := context.Background()
+
+	tsk := task.Task{ID: "first", TenantID: "sometenant", Description: "Buy socks"}
+
+	tcr := taskmock.CacheClient{}
+	tcr.On("Save", mock.Anything, tsk).Return(nil)
+
+	tdbr := taskmock.DBClient{}
+	tdbr.On("Save", mock.Anything, tsk).Return(nil)
+
+	idx := task.NewMemoryIndex()
+
+	mgr := taskmgr.Manager{TaskCacheClient: &tcr, TaskDBClient: &tdbr, TaskSearcher: idx}
+
+	err := mgr.Save(ctx, tsk)
+	assert.NoError(t, err, "Returned error")
+
+	res, err := mgr.Search(ctx, "sometenant", "socks", 10)
+	assert.NoError(t, err, "Returned error")
+	assert.Len(t, res, 1, "Task was not indexed")
+}
+
+func TestSaveDoesNotIndexTaskOnDBError(t *testing.T) {
+	ctx := context.Background()
+
+	tsk := task.Task{ID: "first", TenantID: "sometenant", Description: "Buy socks"}
+
+	tcr := taskmock.CacheClient{}
+	tcr.On("Save", mock.Anything, tsk).Return(nil)
+
+	tdbr := taskmock.DBClient{}
+	tdbr.On("Save", mock.Anything, tsk).Return(errors.New("Failed"))
+
+	mgr := taskmgr.Manager{TaskCacheClient: &tcr, TaskDBClient: &tdbr, TaskSearcher: task.NewMemoryIndex()}
+
+	err := mgr.Save(ctx, tsk)
+	assert.Error(t, err, "Did not return error")
+
+	res, err := mgr.Search(ctx, "sometenant", "socks", 10)
+	assert.NoError(t, err, "Returned error")
+	assert.Empty(t, res, "Task was indexed")
+}
+
+func TestUpdateIndexesTask(t *testing.T) {
+	ctx := context.Background()
+
+	tsk := task.Task{ID: "first", TenantID: "sometenant", Description: "Buy shoes", Version: 1}
+
+	tcr := taskmock.CacheClient{}
+	tcr.On("Save", mock.Anything, mock.Anything).Return(nil)
+
+	tdbr := taskmock.DBClient{}
+	tdbr.On("GetAncestorIDs", mock.Anything, tsk.TenantID, []string{tsk.ID}).Return([]string{}, nil)
+	tdbr.On("Update", mock.Anything, tsk).Return(nil)
+
+	idx := task.NewMemoryIndex()
+	idx.Index(ctx, task.Task{ID: "first", TenantID: "sometenant", Description: "Buy socks"})
+
+	ddbr := taskmock.DependencyDBClient{}
+	ddbr.On("BlockedIDs", mock.Anything, tsk.TenantID, tsk.ID).Return([]string{}, nil)
+
+	mgr := taskmgr.Manager{DependencyDBClient: &ddbr, TaskCacheClient: &tcr, TaskDBClient: &tdbr, TaskSearcher: idx}
+
+	_, err := mgr.Update(ctx, tsk)
+	assert.NoError(t, err, "Returned error")
+
+	res, err := mgr.Search(ctx, "sometenant", "socks", 10)
+	assert.NoError(t, err, "Returned error")
+	assert.Empty(t, res, "Returned outdated task")
+
+	res, err = mgr.Search(ctx, "sometenant", "shoes", 10)
+	assert.NoError(t, err, "Returned error")
+	assert.Len(t, res, 1, "Task was not indexed")
+	assert.Equal(t, 2, res[0].Task.Version, "Indexed incorrect version")
+}
+
+func TestList(t *testing.T) {
+	ctx := context.Background()
+
+	filter := task.Filter{Tags: []string{"errand"}, Limit: 10, Offset: 10}
+	storedTasks := []task.Task{{ID: "someid", TenantID: "sometenant", Tags: []string{"errand"}}}
+
+	tdbr := taskmock.DBClient{}
+	tdbr.On("List", mock.Anything, "sometenant", filter).Return(storedTasks, 11, nil)
+
+	mgr := taskmgr.Manager{TaskDBClient: &tdbr}
+
+	tsks, total, err := mgr.List(ctx, "sometenant", filter)
+	assert.NoError(t, err, "Returned error")
+	assert.Equal(t, storedTasks, tsks, "Returned incorrect tasks")
+	assert.Equal(t, 11, total, "Returned incorrect total")
+
+	tdbr.AssertExpectations(t)
+}
+
+func TestRenameTag(t *testing.T) {
+	ctx := context.Background()
+
+	tg := task.Tag{ID: "tagid", TenantID: "sometenant", Name: "errand"}
+	taskIDs := []string{"someid", "otherid"}
+
+	tgdbr := taskmock.TagDBClient{}
+	tgdbr.On("Update", mock.Anything, mock.MatchedBy(func(updated task.Tag) bool {
+		return updated.ID == tg.ID && updated.Name == "chore"
+	})).Return(nil)
+	tgdbr.On("TaskIDs", mock.Anything, tg.TenantID, tg.ID).Return(taskIDs, nil)
+
+	tcr := taskmock.CacheClient{}
+	tcr.On("RenameTag", mock.Anything, tg.TenantID, taskIDs, "errand", "chore").Return(nil)
+
+	mgr := taskmgr.Manager{TaskCacheClient: &tcr, TagDBClient: &tgdbr}
+
+	renamed, err := mgr.RenameTag(ctx, tg, "chore")
+	assert.NoError(t, err, "Returned error")
+	assert.Equal(t, "chore", renamed.Name, "Returned incorrect tag")
+
+	tgdbr.AssertExpectations(t)
+	tcr.AssertExpectations(t)
+}
+
+func TestRenameTagRemovesTasksFromCacheOnCacheError(t *testing.T) {
+	ctx := context.Background()
+
+	tg := task.Tag{ID: "tagid", TenantID: "sometenant", Name: "errand"}
+	taskIDs := []string{"someid", "otherid"}
+
+	tgdbr := taskmock.TagDBClient{}
+	tgdbr.On("Update", mock.Anything, mock.Anything).Return(nil)
+	tgdbr.On("TaskIDs", mock.Anything, tg.TenantID, tg.ID).Return(taskIDs, nil)
+
+	tcr := taskmock.CacheClient{}
+	tcr.On("RenameTag", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(errors.New("failed to rename"))
+	tcr.On("Delete", mock.Anything, tg.TenantID, "someid").Return(nil)
+	tcr.On("Delete", mock.Anything, tg.TenantID, "otherid").Return(nil)
+
+	mgr := taskmgr.Manager{TaskCacheClient: &tcr, TagDBClient: &tgdbr}
+
+	_, err := mgr.RenameTag(ctx, tg, "chore")
+	assert.NoError(t, err, "Returned error")
+
 	tcr.AssertExpectations(t)
 }
+
+func TestRenameTagReturnsErrorOnDBError(t *testing.T) {
+	ctx := context.Background()
+
+	tg := task.Tag{ID: "tagid", TenantID: "sometenant", Name: "errand"}
+
+	tgdbr := taskmock.TagDBClient{}
+	tgdbr.On("Update", mock.Anything, mock.Anything).Return(task.ErrTagExists)
+
+	tcr := taskmock.CacheClient{}
+
+	mgr := taskmgr.Manager{TaskCacheClient: &tcr, TagDBClient: &tgdbr}
+
+	renamed, err := mgr.RenameTag(ctx, tg, "chore")
+	assert.ErrorIs(t, err, task.ErrTagExists)
+	assert.Nil(t, renamed)
+
+	tcr.AssertNotCalled(t, "RenameTag", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestDeleteTag(t *testing.T) {
+	ctx := context.Background()
+
+	tg := task.Tag{ID: "tagid", TenantID: "sometenant", Name: "errand"}
+	taskIDs := []string{"someid"}
+
+	tgdbr := taskmock.TagDBClient{}
+	tgdbr.On("Delete", mock.Anything, tg.TenantID, tg.ID).Return(taskIDs, nil)
+
+	// Deleting a tag removes it from the cached tasks
+	tcr := taskmock.CacheClient{}
+	tcr.On("RenameTag", mock.Anything, tg.TenantID, taskIDs, "errand", "").Return(nil)
+
+	mgr := taskmgr.Manager{TaskCacheClient: &tcr, TagDBClient: &tgdbr}
+
+	err := mgr.DeleteTag(ctx, tg)
+	assert.NoError(t, err, "Returned error")
+
+	tgdbr.AssertExpectations(t)
+	tcr.AssertExpectations(t)
+}
+
+func TestSaveRemovesAncestorsFromCache(t *testing.T) {
+	ctx := context.Background()
+
+	tsk := task.Task{ID: "someid", TenantID: "sometenant", ParentID: "parent"}
+
+	tcr := taskmock.CacheClient{}
+	tcr.On("Save", mock.Anything, tsk).Return(nil)
+	tcr.On("Delete", mock.Anything, "sometenant", "parent").Return(nil)
+	tcr.On("Delete", mock.Anything, "sometenant", "grandparent").Return(errors.New("Failed"))
+
+	// The rollups of every task above the subtask have changed
+	tdbr := taskmock.DBClient{}
+	tdbr.On("Save", mock.Anything, tsk).Return(nil)
+	tdbr.On("GetAncestorIDs", mock.Anything, "sometenant", []string{"someid"}).Return([]string{"parent", "grandparent"}, nil)
+
+	mgr := taskmgr.Manager{TaskCacheClient: &tcr, TaskDBClient: &tdbr}
+
+	// Cache errors are ignored
+	err := mgr.Save(ctx, tsk)
+	assert.NoError(t, err, "Returned error")
+
+	tdbr.AssertExpectations(t)
+	tcr.AssertExpectations(t)
+}
+
+func TestSaveTopLevelTaskDoesNotRemoveAncestorsFromCache(t *testing.T) {
+	ctx := context.Background()
+
+	tsk := task.Task{ID: "someid", TenantID: "sometenant"}
+
+	tcr := taskmock.CacheClient{}
+	tcr.On("Save", mock.Anything, tsk).Return(nil)
+
+	tdbr := taskmock.DBClient{}
+	tdbr.On("Save", mock.Anything, tsk).Return(nil)
+
+	mgr := taskmgr.Manager{TaskCacheClient: &tcr, TaskDBClient: &tdbr}
+
+	err := mgr.Save(ctx, tsk)
+	assert.NoError(t, err, "Returned error")
+
+	tdbr.AssertNotCalled(t, "GetAncestorIDs", mock.Anything, mock.Anything, mock.Anything)
+	tcr.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdateRemovesPreviousAndCurrentAncestorsFromCache(t *testing.T) {
+	ctx := context.Background()
+
+	// The task is being moved from one parent to another
+	tsk := task.Task{ID: "someid", TenantID: "sometenant", ParentID: "newparent", Version: 1}
+
+	tcr := taskmock.CacheClient{}
+	tcr.On("Save", mock.Anything, mock.Anything).Return(nil)
+	tcr.On("Delete", mock.Anything, "sometenant", "oldparent").Return(nil)
+	tcr.On("Delete", mock.Anything, "sometenant", "newparent").Return(nil)
+
+	tdbr := taskmock.DBClient{}
+	tdbr.On("GetAncestorIDs", mock.Anything, "sometenant", []string{"someid"}).Return([]string{"oldparent"}, nil).Once()
+	tdbr.On("Update", mock.Anything, tsk).Return(nil)
+	tdbr.On("GetAncestorIDs", mock.Anything, "sometenant", []string{"someid"}).Return([]string{"newparent"}, nil).Once()
+
+	ddbr := taskmock.DependencyDBClient{}
+	ddbr.On("BlockedIDs", mock.Anything, "sometenant", "someid").Return([]string{}, nil)
+
+	mgr := taskmgr.Manager{DependencyDBClient: &ddbr, TaskCacheClient: &tcr, TaskDBClient: &tdbr}
+
+	_, err := mgr.Update(ctx, tsk)
+	assert.NoError(t, err, "Returned error")
+
+	tdbr.AssertExpectations(t)
+	tcr.AssertExpectations(t)
+}
+
+func TestListSubtasks(t *testing.T) {
+	ctx := context.Background()
+
+	subtasks := []task.Task{{ID: "child", ParentID: "someid"}, {ID: "grandchild", ParentID: "child"}}
+
+	tdbr := taskmock.DBClient{}
+	filter := task.SubtaskFilter{Recursive: true, Limit: 10}
+	tdbr.On("ListSubtasks", mock.Anything, "sometenant", "someid", filter).Return(subtasks, 2, nil)
+
+	mgr := taskmgr.Manager{TaskDBClient: &tdbr}
+
+	res, total, err := mgr.ListSubtasks(ctx, "sometenant", "someid", filter)
+	assert.NoError(t, err, "Returned error")
+	assert.Equal(t, subtasks, res, "Returned incorrect subtasks")
+	assert.Equal(t, 2, total, "Returned incorrect total")
+}
+
+func TestUpdateRemovesBlockedTasksFromCache(t *testing.T) {
+	ctx := context.Background()
+
+	tsk := task.Task{ID: "someid", TenantID: "sometenant", Version: 1}
+
+	tcr := taskmock.CacheClient{}
+	tcr.On("Save", mock.Anything, mock.Anything).Return(nil)
+	tcr.On("Delete", mock.Anything, "sometenant", "blocked1").Return(nil)
+	tcr.On("Delete", mock.Anything, "sometenant", "blocked2").Return(nil)
+
+	tdbr := taskmock.DBClient{}
+	tdbr.On("GetAncestorIDs", mock.Anything, "sometenant", []string{"someid"}).Return([]string{}, nil)
+	tdbr.On("Update", mock.Anything, tsk).Return(nil)
+
+	ddbr := taskmock.DependencyDBClient{}
+	ddbr.On("BlockedIDs", mock.Anything, "sometenant", "someid").Return([]string{"blocked1", "blocked2"}, nil)
+
+	mgr := taskmgr.Manager{DependencyDBClient: &ddbr, TaskCacheClient: &tcr, TaskDBClient: &tdbr}
+
+	_, err := mgr.Update(ctx, tsk)
+	assert.NoError(t, err, "Returned error")
+
+	ddbr.AssertExpectations(t)
+	tcr.AssertExpectations(t)
+}
+
+func TestUpdateCompletesOccurrence(t *testing.T) {
+	ctx := context.Background()
+
+	dateStart := time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+	dateCompleted := dateStart.Add(time.Hour)
+	recurrence := task.Recurrence{Rule: "FREQ=DAILY", TimeZone: "UTC", DateStart: dateStart}
+	tsk := task.Task{
+		ID:            "someid",
+		TenantID:      "sometenant",
+		Description:   "Water the plants",
+		DateDue:       &dateStart,
+		DateCompleted: &dateCompleted,
+		Recurrence:    &recurrence,
+		Version:       1,
+	}
+
+	completed := tsk
+	completed.Recurrence = nil
+	nextOccurrence := mock.MatchedBy(func(next task.Task) bool {
+		return next.ID != tsk.ID && next.DateDue.Equal(dateStart.AddDate(0, 0, 1)) &&
+			next.DateCompleted == nil && next.Recurrence == &recurrence
+	})
+
+	tcr := taskmock.CacheClient{}
+	tcr.On("Save", mock.Anything, mock.Anything).Return(nil)
+
+	tdbr := taskmock.DBClient{}
+	tdbr.On("GetAncestorIDs", mock.Anything, "sometenant", []string{"someid"}).Return([]string{}, nil)
+	tdbr.On("CompleteOccurrence", mock.Anything, completed, nextOccurrence).Return(nil)
+
+	ddbr := taskmock.DependencyDBClient{}
+	ddbr.On("BlockedIDs", mock.Anything, "sometenant", "someid").Return([]string{}, nil)
+
+	mgr := taskmgr.Manager{DependencyDBClient: &ddbr, TaskCacheClient: &tcr, TaskDBClient: &tdbr}
+
+	updatedTsk, err := mgr.Update(ctx, tsk)
+	assert.NoError(t, err, "Returned error")
+	assert.Nil(t, updatedTsk.Recurrence, "Completed occurrence kept the recurrence")
+
+	tdbr.AssertExpectations(t)
+	tdbr.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestUpdateCompletesLastOccurrence(t *testing.T) {
+	ctx := context.Background()
+
+	dateStart := time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+	dateCompleted := dateStart.Add(time.Hour)
+	tsk := task.Task{
+		ID:            "someid",
+		TenantID:      "sometenant",
+		DateDue:       &dateStart,
+		DateCompleted: &dateCompleted,
+		Recurrence:    &task.Recurrence{Rule: "FREQ=DAILY;COUNT=1", TimeZone: "UTC", DateStart: dateStart},
+		Version:       1,
+	}
+
+	completed := tsk
+	completed.Recurrence = nil
+
+	tcr := taskmock.CacheClient{}
+	tcr.On("Save", mock.Anything, mock.Anything).Return(nil)
+
+	tdbr := taskmock.DBClient{}
+	tdbr.On("GetAncestorIDs", mock.Anything, "sometenant", []string{"someid"}).Return([]string{}, nil)
+	tdbr.On("Update", mock.Anything, completed).Return(nil)
+
+	ddbr := taskmock.DependencyDBClient{}
+	ddbr.On("BlockedIDs", mock.Anything, "sometenant", "someid").Return([]string{}, nil)
+
+	mgr := taskmgr.Manager{DependencyDBClient: &ddbr, TaskCacheClient: &tcr, TaskDBClient: &tdbr}
+
+	_, err := mgr.Update(ctx, tsk)
+	assert.NoError(t, err, "Returned error")
+
+	tdbr.AssertExpectations(t)
+	tdbr.AssertNotCalled(t, "CompleteOccurrence", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSaveDependencyRemovesBlockedTaskFromCache(t *testing.T) {
+	ctx := context.Background()
+
+	d := task.Dependency{TenantID: "sometenant", BlockerID: "blocker", BlockedID: "blocked"}
+
+	tcr := taskmock.CacheClient{}
+	tcr.On("Delete", mock.Anything, "sometenant", "blocked").Return(nil)
+
+	ddbr := taskmock.DependencyDBClient{}
+	ddbr.On("Save", mock.Anything, d).Return(nil)
+
+	mgr := taskmgr.Manager{DependencyDBClient: &ddbr, TaskCacheClient: &tcr}
+
+	err := mgr.SaveDependency(ctx, d)
+	assert.NoError(t, err, "Returned error")
+
+	ddbr.AssertExpectations(t)
+	tcr.AssertExpectations(t)
+}
+
+func TestSaveDependencyReturnsErrorOnDBError(t *testing.T) {
+	ctx := context.Background()
+
+	d := task.Dependency{TenantID: "sometenant", BlockerID: "blocker", BlockedID: "blocked"}
+
+	tcr := taskmock.CacheClient{}
+
+	ddbr := taskmock.DependencyDBClient{}
+	ddbr.On("Save", mock.Anything, d).Return(task.ErrDependencyCycle)
+
+	mgr := taskmgr.Manager{DependencyDBClient: &ddbr, TaskCacheClient: &tcr}
+
+	err := mgr.SaveDependency(ctx, d)
+	assert.ErrorIs(t, err, task.ErrDependencyCycle, "Incorrect error")
+
+	tcr.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestDeleteDependencyRemovesBlockedTaskFromCache(t *testing.T) {
+	ctx := context.Background()
+
+	d := task.Dependency{TenantID: "sometenant", BlockerID: "blocker", BlockedID: "blocked"}
+
+	tcr := taskmock.CacheClient{}
+	tcr.On("Delete", mock.Anything, "sometenant", "blocked").Return(nil)
+
+	ddbr := taskmock.DependencyDBClient{}
+	ddbr.On("Delete", mock.Anything, d).Return(nil)
+
+	mgr := taskmgr.Manager{DependencyDBClient: &ddbr, TaskCacheClient: &tcr}
+
+	err := mgr.DeleteDependency(ctx, d)
+	assert.NoError(t, err, "Returned error")
+
+	ddbr.AssertExpectations(t)
+	tcr.AssertExpectations(t)
+}
+
+func TestSortTasks(t *testing.T) {
+	ctx := context.Background()
+
+	ds := []task.Dependency{
+		{TenantID: "sometenant", BlockerID: "first", BlockedID: "second"},
+		{TenantID: "sometenant", BlockerID: "second", BlockedID: "third"},
+	}
+
+	ddbr := taskmock.DependencyDBClient{}
+	ddbr.On("List", mock.Anything, "sometenant").Return(ds, nil)
+
+	mgr := taskmgr.Manager{DependencyDBClient: &ddbr}
+
+	res, err := mgr.SortTasks(ctx, "sometenant", []string{"third", "unrelated", "first", "second"})
+	assert.NoError(t, err, "Returned error")
+	assert.Equal(t, []string{"first", "second", "third", "unrelated"}, res, "Returned incorrect order")
+}
+
+func TestDelete(t *testing.T) {
+	ctx := context.Background()
+
+	tsk := task.Task{ID: "someid", TenantID: "sometenant", ProjectID: "someproject", ParentID: "parentid", Version: 2}
+
+	tcr := taskmock.CacheClient{}
+	tcr.On("Delete", mock.Anything, "sometenant", "someid").Return(nil)
+	tcr.On("Delete", mock.Anything, "sometenant", "parentid").Return(nil)
+	tcr.On("Delete", mock.Anything, "sometenant", "blockedid").Return(nil)
+
+	tdbr := taskmock.DBClient{}
+	tdbr.On("GetAncestorIDs", mock.Anything, "sometenant", []string{"someid"}).Return([]string{"parentid"}, nil)
+	tdbr.On("Delete", mock.Anything, tsk).Return(nil)
+
+	ddbr := taskmock.DependencyDBClient{}
+	ddbr.On("BlockedIDs", mock.Anything, "sometenant", "someid").Return([]string{"blockedid"}, nil)
+
+	pcr := projectmock.CacheClient{}
+	pcr.On("Delete", mock.Anything, "sometenant", "someproject").Return(nil)
+
+	ccr := taskmock.CommentCacheClient{}
+	ccr.On("Forget", mock.Anything, "sometenant", "someid").Return(nil)
+
+	attachment := task.Attachment{ID: "attachmentid", TenantID: "sometenant", TaskID: "someid"}
+
+	adbr := taskmock.AttachmentDBClient{}
+	adbr.On("List", mock.Anything, "sometenant", "someid").Return([]task.Attachment{attachment}, nil)
+
+	bs := blobmock.Store{}
+	bs.On("Delete", mock.Anything, "attachments/attachmentid").Return(nil)
+
+	mgr := taskmgr.Manager{
+		AttachmentDBClient: &adbr,
+		BlobStore:          &bs,
+		CommentCacheClient: &ccr,
+		DependencyDBClient: &ddbr,
+		ProjectCacheClient: &pcr,
+		TaskCacheClient:    &tcr,
+		TaskDBClient:       &tdbr,
+	}
+
+	err := mgr.Delete(ctx, tsk)
+	assert.NoError(t, err, "Returned error")
+
+	tdbr.AssertExpectations(t)
+	tcr.AssertExpectations(t)
+	pcr.AssertExpectations(t)
+	ccr.AssertExpectations(t)
+	bs.AssertExpectations(t)
+}
+
+func TestDeleteReturnsErrorOnDBError(t *testing.T) {
+	ctx := context.Background()
+
+	tsk := task.Task{ID: "someid", TenantID: "sometenant", Version: 2}
+
+	tcr := taskmock.CacheClient{}
+
+	tdbr := taskmock.DBClient{}
+	tdbr.On("GetAncestorIDs", mock.Anything, "sometenant", []string{"someid"}).Return([]string{}, nil)
+	tdbr.On("Delete", mock.Anything, tsk).Return(task.ErrHasSubtasks)
+
+	ddbr := taskmock.DependencyDBClient{}
+	ddbr.On("BlockedIDs", mock.Anything, "sometenant", "someid").Return([]string{}, nil)
+
+	adbr := taskmock.AttachmentDBClient{}
+	adbr.On("List", mock.Anything, "sometenant", "someid").Return([]task.Attachment{{ID: "attachmentid"}}, nil)
+
+	bs := blobmock.Store{}
+
+	mgr := taskmgr.Manager{
+		AttachmentDBClient: &adbr,
+		BlobStore:          &bs,
+		DependencyDBClient: &ddbr,
+		TaskCacheClient:    &tcr,
+		TaskDBClient:       &tdbr,
+	}
+
+	err := mgr.Delete(ctx, tsk)
+	assert.ErrorIs(t, err, task.ErrHasSubtasks)
+
+	tcr.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+	bs.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
+
+func TestListHistory(t *testing.T) {
+	ctx := context.Background()
+
+	rs := []task.Revision{
+		{ID: "second", TenantID: "sometenant", TaskID: "someid", Version: 2, Action: task.ActionUpdated},
+		{ID: "first", TenantID: "sometenant", TaskID: "someid", Version: 1, Action: task.ActionCreated},
+	}
+
+	hdbr := taskmock.HistoryDBClient{}
+	hdbr.On("ListRevisions", mock.Anything, "sometenant", "someid", 20, 0).Return(rs, nil)
+
+	mgr := taskmgr.Manager{HistoryDBClient: &hdbr}
+
+	res, err := mgr.ListHistory(ctx, "sometenant", "someid", 20, 0)
+	assert.NoError(t, err, "Returned error")
+	assert.Equal(t, rs, res, "Returned incorrect revisions")
+}
+
+func TestGetRevision(t *testing.T) {
+	ctx := context.Background()
+
+	r := task.Revision{ID: "first", TenantID: "sometenant", TaskID: "someid", Version: 1, Action: task.ActionCreated}
+
+	hdbr := taskmock.HistoryDBClient{}
+	hdbr.On("GetRevision", mock.Anything, "sometenant", "someid", 1).Return(&r, nil)
+
+	mgr := taskmgr.Manager{HistoryDBClient: &hdbr}
+
+	res, err := mgr.GetRevision(ctx, "sometenant", "someid", 1)
+	assert.NoError(t, err, "Returned error")
+	assert.Equal(t, &r, res, "Returned incorrect revision")
+}
+
+func TestGetRevisionAsOf(t *testing.T) {
+	ctx := context.Background()
+
+	asOf := time.Now()
+	r := task.Revision{ID: "first", TenantID: "sometenant", TaskID: "someid", Version: 1, Action: task.ActionCreated}
+
+	hdbr := taskmock.HistoryDBClient{}
+	hdbr.On("GetRevisionAsOf", mock.Anything, "sometenant", "someid", asOf).Return(&r, nil)
+
+	mgr := taskmgr.Manager{HistoryDBClient: &hdbr}
+
+	res, err := mgr.GetRevisionAsOf(ctx, "sometenant", "someid", asOf)
+	assert.NoError(t, err, "Returned error")
+	assert.Equal(t, &r, res, "Returned incorrect revision")
+}
+
+func TestListCommentsReturnsCachedPage(t *testing.T) {
+	ctx := context.Background()
+
+	page := task.CommentPage{Comments: []task.Comment{{ID: "commentid", Body: "Bring snacks"}}, Limit: 20, Offset: 0}
+
+	ccr := taskmock.CommentCacheClient{}
+	ccr.On("GetPage", mock.Anything, "sometenant", "someid", 20, 0).Return(&page, int64(3), nil)
+
+	cdbr := taskmock.CommentDBClient{}
+
+	mgr := taskmgr.Manager{CommentCacheClient: &ccr, CommentDBClient: &cdbr}
+
+	retrievedPage, err := mgr.ListComments(ctx, "sometenant", "someid", 20, 0)
+	assert.NoError(t, err, "Returned error")
+	assert.Equal(t, &page, retrievedPage, "Returned incorrect page")
+
+	cdbr.AssertNotCalled(t, "List", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestListCommentsStoresPageOnCacheMiss(t *testing.T) {
+	ctx := context.Background()
+
+	cs := []task.Comment{{ID: "commentid", Body: "Bring snacks"}}
+	page := task.CommentPage{Comments: cs, Limit: 20, Offset: 40}
+
+	// The page is stored at the generation that it was missed at
+	ccr := taskmock.CommentCacheClient{}
+	ccr.On("GetPage", mock.Anything, "sometenant", "someid", 20, 40).Return(nil, int64(3), nil)
+	ccr.On("SavePage", mock.Anything, "sometenant", "someid", page, int64(3)).Return(errors.New("Failed"))
+
+	cdbr := taskmock.CommentDBClient{}
+	cdbr.On("List", mock.Anything, "sometenant", "someid", 20, 40).Return(cs, nil)
+
+	mgr := taskmgr.Manager{CommentCacheClient: &ccr, CommentDBClient: &cdbr}
+
+	// Cache errors are ignored
+	retrievedPage, err := mgr.ListComments(ctx, "sometenant", "someid", 20, 40)
+	assert.NoError(t, err, "Returned error")
+	assert.Equal(t, &page, retrievedPage, "Returned incorrect page")
+
+	ccr.AssertExpectations(t)
+	cdbr.AssertExpectations(t)
+}
+
+func TestListCommentsDoesNotStorePageOnCacheError(t *testing.T) {
+	ctx := context.Background()
+
+	cs := []task.Comment{{ID: "commentid", Body: "Bring snacks"}}
+
+	ccr := taskmock.CommentCacheClient{}
+	ccr.On("GetPage", mock.Anything, "sometenant", "someid", 20, 0).Return(nil, int64(0), errors.New("Failed"))
+
+	cdbr := taskmock.CommentDBClient{}
+	cdbr.On("List", mock.Anything, "sometenant", "someid", 20, 0).Return(cs, nil)
+
+	mgr := taskmgr.Manager{CommentCacheClient: &ccr, CommentDBClient: &cdbr}
+
+	retrievedPage, err := mgr.ListComments(ctx, "sometenant", "someid", 20, 0)
+	assert.NoError(t, err, "Returned error")
+	assert.Equal(t, cs, retrievedPage.Comments, "Returned incorrect comments")
+
+	ccr.AssertNotCalled(t, "SavePage", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSaveCommentRemovesTaskAndCommentsFromCache(t *testing.T) {
+	ctx := context.Background()
+
+	c := task.Comment{ID: "commentid", TenantID: "sometenant", TaskID: "someid", Body: "Bring snacks"}
+
+	cdbr := taskmock.CommentDBClient{}
+	cdbr.On("Save", mock.Anything, c).Return(nil)
+
+	// The task's comment count has changed
+	tcr := taskmock.CacheClient{}
+	tcr.On("Delete", mock.Anything, "sometenant", "someid").Return(nil)
+
+	ccr := taskmock.CommentCacheClient{}
+	ccr.On("Forget", mock.Anything, "sometenant", "someid").Return(errors.New("Failed"))
+
+	mgr := taskmgr.Manager{CommentCacheClient: &ccr, CommentDBClient: &cdbr, TaskCacheClient: &tcr}
+
+	// Cache errors are ignored
+	err := mgr.SaveComment(ctx, c)
+	assert.NoError(t, err, "Returned error")
+
+	cdbr.AssertExpectations(t)
+	tcr.AssertExpectations(t)
+	ccr.AssertExpectations(t)
+}
+
+func TestSaveCommentReturnsErrorOnDBError(t *testing.T) {
+	ctx := context.Background()
+
+	c := task.Comment{ID: "commentid", TenantID: "sometenant", TaskID: "someid", Body: "Bring snacks"}
+
+	cdbr := taskmock.CommentDBClient{}
+	cdbr.On("Save", mock.Anything, c).Return(task.ErrTaskUnavailable)
+
+	tcr := taskmock.CacheClient{}
+	ccr := taskmock.CommentCacheClient{}
+
+	mgr := taskmgr.Manager{CommentCacheClient: &ccr, CommentDBClient: &cdbr, TaskCacheClient: &tcr}
+
+	err := mgr.SaveComment(ctx, c)
+	assert.ErrorIs(t, err, task.ErrTaskUnavailable)
+
+	tcr.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+	ccr.AssertNotCalled(t, "Forget", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdateComment(t *testing.T) {
+	ctx := context.Background()
+
+	c := task.Comment{ID: "commentid", TenantID: "sometenant", TaskID: "someid", Body: "Bring snacks", Version: 2}
+
+	cdbr := taskmock.CommentDBClient{}
+	cdbr.On("Update", mock.Anything, mock.MatchedBy(func(updated task.Comment) bool {
+		return updated.Body == "Bring more snacks" && updated.Version == 2
+	})).Return(nil)
+
+	// The comment count has not changed so the task stays cached
+	tcr := taskmock.CacheClient{}
+
+	ccr := taskmock.CommentCacheClient{}
+	ccr.On("Forget", mock.Anything, "sometenant", "someid").Return(nil)
+
+	mgr := taskmgr.Manager{CommentCacheClient: &ccr, CommentDBClient: &cdbr, TaskCacheClient: &tcr}
+
+	updated, err := mgr.UpdateComment(ctx, c, "Bring more snacks")
+	assert.NoError(t, err, "Returned error")
+	assert.Equal(t, "Bring more snacks", updated.Body, "Returned incorrect body")
+	assert.Equal(t, 3, updated.Version, "Returned incorrect version")
+
+	cdbr.AssertExpectations(t)
+	ccr.AssertExpectations(t)
+	tcr.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdateCommentReturnsErrorOnVersionConflict(t *testing.T) {
+	ctx := context.Background()
+
+	c := task.Comment{ID: "commentid", TenantID: "sometenant", TaskID: "someid", Body: "Bring snacks", Version: 2}
+
+	cdbr := taskmock.CommentDBClient{}
+	cdbr.On("Update", mock.Anything, mock.Anything).Return(task.ErrCommentVersionConflict)
+
+	ccr := taskmock.CommentCacheClient{}
+
+	mgr := taskmgr.Manager{CommentCacheClient: &ccr, CommentDBClient: &cdbr}
+
+	updated, err := mgr.UpdateComment(ctx, c, "Bring more snacks")
+	assert.ErrorIs(t, err, task.ErrCommentVersionConflict)
+	assert.Nil(t, updated, "Returned comment")
+
+	ccr.AssertNotCalled(t, "Forget", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestDeleteCommentRemovesTaskAndCommentsFromCache(t *testing.T) {
+	ctx := context.Background()
+
+	c := task.Comment{ID: "commentid", TenantID: "sometenant", TaskID: "someid", Version: 1}
+
+	cdbr := taskmock.CommentDBClient{}
+	cdbr.On("Delete", mock.Anything, c).Return(nil)
+
+	tcr := taskmock.CacheClient{}
+	tcr.On("Delete", mock.Anything, "sometenant", "someid").Return(nil)
+
+	ccr := taskmock.CommentCacheClient{}
+	ccr.On("Forget", mock.Anything, "sometenant", "someid").Return(nil)
+
+	mgr := taskmgr.Manager{CommentCacheClient: &ccr, CommentDBClient: &cdbr, TaskCacheClient: &tcr}
+
+	err := mgr.DeleteComment(ctx, c)
+	assert.NoError(t, err, "Returned error")
+
+	cdbr.AssertExpectations(t)
+	tcr.AssertExpectations(t)
+	ccr.AssertExpectations(t)
+}
+
+func TestSaveAttachment(t *testing.T) {
+	ctx := context.Background()
+
+	a := task.Attachment{ID: "attachmentid", TenantID: "sometenant", TaskID: "someid", Size: 3, Checksum: "somechecksum"}
+	content := strings.NewReader("abc")
+
+	bs := blobmock.Store{}
+	bs.On("Put", mock.Anything, "attachments/attachmentid", content, int64(3), "somechecksum").Return(nil)
+
+	adbr := taskmock.AttachmentDBClient{}
+	adbr.On("Save", mock.Anything, a).Return(nil)
+
+	mgr := taskmgr.Manager{AttachmentDBClient: &adbr, BlobStore: &bs}
+
+	err := mgr.SaveAttachment(ctx, a, content)
+	assert.NoError(t, err, "Returned error")
+
+	bs.AssertExpectations(t)
+	adbr.AssertExpectations(t)
+}
+
+func TestSaveAttachmentDoesNotSaveMetadataOnBlobError(t *testing.T) {
+	ctx := context.Background()
+
+	a := task.Attachment{ID: "attachmentid", TenantID: "sometenant", TaskID: "someid", Size: 3, Checksum: "somechecksum"}
+
+	bs := blobmock.Store{}
+	bs.On("Put", mock.Anything, "attachments/attachmentid", mock.Anything, int64(3), "somechecksum").
+		Return(blob.ErrChecksumMismatch)
+
+	adbr := taskmock.AttachmentDBClient{}
+
+	mgr := taskmgr.Manager{AttachmentDBClient: &adbr, BlobStore: &bs}
+
+	err := mgr.SaveAttachment(ctx, a, strings.NewReader("abd"))
+	assert.ErrorIs(t, err, blob.ErrChecksumMismatch)
+
+	adbr.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
+
+func TestSaveAttachmentRemovesContentOnDBError(t *testing.T) {
+	ctx := context.Background()
+
+	a := task.Attachment{ID: "attachmentid", TenantID: "sometenant", TaskID: "someid", Size: 3, Checksum: "somechecksum"}
+
+	bs := blobmock.Store{}
+	bs.On("Put", mock.Anything, "attachments/attachmentid", mock.Anything, int64(3), "somechecksum").Return(nil)
+	bs.On("Delete", mock.Anything, "attachments/attachmentid").Return(nil)
+
+	adbr := taskmock.AttachmentDBClient{}
+	adbr.On("Save", mock.Anything, a).Return(task.ErrTooManyAttachments)
+
+	mgr := taskmgr.Manager{AttachmentDBClient: &adbr, BlobStore: &bs}
+
+	err := mgr.SaveAttachment(ctx, a, strings.NewReader("abc"))
+	assert.ErrorIs(t, err, task.ErrTooManyAttachments)
+
+	bs.AssertExpectations(t)
+}
+
+func TestOpenAttachment(t *testing.T) {
+	ctx := context.Background()
+
+	a := task.Attachment{ID: "attachmentid", TenantID: "sometenant", TaskID: "someid", Size: 11}
+
+	bs := blobmock.Store{}
+	bs.On("Get", mock.Anything, "attachments/attachmentid", int64(6), int64(-1)).
+		Return(io.NopCloser(strings.NewReader("world")), nil)
+
+	mgr := taskmgr.Manager{BlobStore: &bs}
+
+	rs := mgr.OpenAttachment(ctx, a)
+	defer rs.Close()
+
+	_, err := rs.Seek(6, io.SeekStart)
+	assert.NoError(t, err, "Seek returned error")
+
+	content, err := io.ReadAll(rs)
+	assert.NoError(t, err, "Read returned error")
+	assert.Equal(t, "world", string(content), "Returned incorrect content")
+
+	bs.AssertExpectations(t)
+}
+
+func TestDeleteAttachment(t *testing.T) {
+	ctx := context.Background()
+
+	a := task.Attachment{ID: "attachmentid", TenantID: "sometenant", TaskID: "someid"}
+
+	adbr := taskmock.AttachmentDBClient{}
+	adbr.On("Delete", mock.Anything, a).Return(nil)
+
+	bs := blobmock.Store{}
+	bs.On("Delete", mock.Anything, "attachments/attachmentid").Return(errors.New("unavailable"))
+
+	mgr := taskmgr.Manager{AttachmentDBClient: &adbr, BlobStore: &bs}
+
+	// Content that cannot be removed is only logged since the metadata is already gone
+	err := mgr.DeleteAttachment(ctx, a)
+	assert.NoError(t, err, "Returned error")
+
+	adbr.AssertExpectations(t)
+	bs.AssertExpectations(t)
+}
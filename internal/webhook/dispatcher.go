@@ -0,0 +1,197 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// DeliveryStore is where the dispatcher claims deliveries and records how they went.
+type DeliveryStore interface {
+	GetSubscription(ctx context.Context, tenantID string, id string) (*Subscription, error)
+	ClaimDeliveries(ctx context.Context, now time.Time, until time.Time, limit int) ([]Delivery, error)
+	UpdateDelivery(ctx context.Context, d Delivery) error
+}
+
+// Dispatcher periodically sends pending deliveries to their subscriptions.
+//
+// Every replica of the service may run a dispatcher. Deliveries are claimed before they are attempted so that only one
+// replica attempts each of them at a time. A delivery that fails is retried with exponential backoff until it has been
+// attempted MaxAttempts times, at which point it is dead-lettered: it is kept in the delivery log with its last error
+// but not attempted again unless it is redelivered.
+//
+// Deliveries are sent at least once, so a subscriber may receive the same delivery more than once, e.g. when a replica
+// goes away after sending it but before recording that it succeeded. The delivery ID is sent in the Webhook-Id header
+// so that subscribers can ignore duplicates.
+type Dispatcher struct {
+	Deliveries DeliveryStore
+	// Client sends the requests. Defaults to http.DefaultClient.
+	Client *http.Client
+	// MaxAttempts is how many times a delivery is attempted before it is dead-lettered. Defaults to 8.
+	MaxAttempts int
+	// Backoff is how long to wait before the first retry. The wait doubles with every retry after that. Defaults to 30
+	// seconds.
+	Backoff time.Duration
+	// MaxBackoff is the longest to wait between retries. Defaults to an hour.
+	MaxBackoff time.Duration
+	// Timeout is how long a subscription may take to respond. Deliveries are claimed for twice as long. Defaults to 10
+	// seconds.
+	Timeout time.Duration
+	// BatchSize is the number of deliveries claimed at a time. Defaults to 100.
+	BatchSize int
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// NewDispatcher creates a dispatcher with default values. The returned pointer will never be nil.
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{
+		MaxAttempts: 8,
+		Backoff:     30 * time.Second,
+		MaxBackoff:  time.Hour,
+		Timeout:     10 * time.Second,
+		BatchSize:   100,
+		Now:         time.Now,
+	}
+}
+
+// Start runs the dispatcher on an interval in a separate goroutine until the context is done.
+func (dsp *Dispatcher) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				err := dsp.Run(ctx)
+				if err != nil {
+					log.Error().Err(err).Msg("Failed to dispatch webhook deliveries")
+				}
+			}
+		}
+	}()
+}
+
+// Run attempts every pending delivery that is due, a batch at a time.
+//
+// Failed deliveries are recorded rather than returned so that one failing subscription does not hold up the rest.
+func (dsp *Dispatcher) Run(ctx context.Context) error {
+	for {
+		now := dsp.Now()
+		ds, err := dsp.Deliveries.ClaimDeliveries(ctx, now, now.Add(2*dsp.Timeout), dsp.BatchSize)
+		if err != nil {
+			return err
+		}
+
+		// Deliveries tend to go to the same few subscriptions
+		subscriptions := make(map[string]*Subscription)
+		for _, d := range ds {
+			s, ok := subscriptions[d.SubscriptionID]
+			if !ok {
+				s, err = dsp.Deliveries.GetSubscription(ctx, d.TenantID, d.SubscriptionID)
+				if err != nil {
+					return err
+				}
+
+				subscriptions[d.SubscriptionID] = s
+			}
+
+			// The subscription was deleted after the delivery was claimed, which also deleted the delivery
+			if s == nil {
+				continue
+			}
+
+			err = dsp.attempt(ctx, *s, d)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(ds) < dsp.BatchSize {
+			return nil
+		}
+	}
+}
+
+// attempt sends the delivery to the subscription and records how it went.
+func (dsp *Dispatcher) attempt(ctx context.Context, s Subscription, d Delivery) error {
+	now := dsp.Now()
+	responseStatus, err := dsp.send(ctx, s, d, now)
+
+	d.Attempts++
+	d.ResponseStatus = responseStatus
+	d.DateUpdated = now
+
+	switch {
+	case err == nil:
+		d.Status = StatusSucceeded
+		d.Error = ""
+	case d.Attempts >= dsp.MaxAttempts:
+		d.Status = StatusDead
+		d.Error = err.Error()
+		log.Warn().
+			Err(err).
+			Str("subscription", s.ID).
+			Str("delivery", d.ID).
+			Int("attempts", d.Attempts).
+			Msg("Dead-lettered webhook delivery")
+	default:
+		d.Error = err.Error()
+		d.DateNextAttempt = now.Add(dsp.backoff(d.Attempts))
+	}
+
+	return dsp.Deliveries.UpdateDelivery(ctx, d)
+}
+
+// send posts the delivery's payload to the subscription, returning the status code of the response. Any response other
+// than a 2xx is an error.
+func (dsp *Dispatcher) send(ctx context.Context, s Subscription, d Delivery, now time.Time) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, dsp.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Webhook-Id", d.ID)
+	req.Header.Set("Webhook-Event", d.EventType)
+	req.Header.Set(SignatureHeader, Sign(s.Secret, now, d.Payload))
+
+	client := dsp.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("subscription responded with status %d", res.StatusCode)
+	}
+
+	return res.StatusCode, nil
+}
+
+// backoff determines how long to wait before the next attempt after the given number of attempts.
+func (dsp *Dispatcher) backoff(attempts int) time.Duration {
+	wait := dsp.Backoff
+	for i := 1; i < attempts; i++ {
+		wait *= 2
+		if wait >= dsp.MaxBackoff {
+			return dsp.MaxBackoff
+		}
+	}
+
+	return wait
+}
@@ -0,0 +1,384 @@
+package webhook_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jaredpetersen/go-rest-template/internal/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (fc *fakeClock) Now() time.Time {
+	return fc.now
+}
+
+func (fc *fakeClock) Advance(d time.Duration) {
+	fc.now = fc.now.Add(d)
+}
+
+// memoryDeliveryStore is an in-memory delivery store with the same claiming semantics as the database.
+type memoryDeliveryStore struct {
+	mu            sync.Mutex
+	subscriptions map[string]webhook.Subscription
+	deliveries    map[string]webhook.Delivery
+	claimErr      error
+}
+
+func newMemoryDeliveryStore() *memoryDeliveryStore {
+	return &memoryDeliveryStore{
+		subscriptions: make(map[string]webhook.Subscription),
+		deliveries:    make(map[string]webhook.Delivery),
+	}
+}
+
+func (mds *memoryDeliveryStore) GetSubscription(ctx context.Context, tenantID string, id string) (*webhook.Subscription, error) {
+	mds.mu.Lock()
+	defer mds.mu.Unlock()
+
+	s, ok := mds.subscriptions[id]
+	if !ok || s.TenantID != tenantID {
+		return nil, nil
+	}
+
+	return &s, nil
+}
+
+func (mds *memoryDeliveryStore) ClaimDeliveries(ctx context.Context, now time.Time, until time.Time, limit int) ([]webhook.Delivery, error) {
+	mds.mu.Lock()
+	defer mds.mu.Unlock()
+
+	if mds.claimErr != nil {
+		return nil, mds.claimErr
+	}
+
+	var due []webhook.Delivery
+	for _, d := range mds.deliveries {
+		if d.Status == webhook.StatusPending && !d.DateNextAttempt.After(now) {
+			due = append(due, d)
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool { return due[i].DateNextAttempt.Before(due[j].DateNextAttempt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	for i := range due {
+		due[i].DateNextAttempt = until
+		mds.deliveries[due[i].ID] = due[i]
+	}
+
+	return due, nil
+}
+
+func (mds *memoryDeliveryStore) UpdateDelivery(ctx context.Context, d webhook.Delivery) error {
+	mds.mu.Lock()
+	defer mds.mu.Unlock()
+
+	mds.deliveries[d.ID] = d
+	return nil
+}
+
+func (mds *memoryDeliveryStore) delivery(id string) webhook.Delivery {
+	mds.mu.Lock()
+	defer mds.mu.Unlock()
+
+	return mds.deliveries[id]
+}
+
+// receiver is a local webhook subscriber that records what it receives and responds with a configurable status.
+type receiver struct {
+	mu       sync.Mutex
+	status   int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (rcv *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+
+	rcv.requests = append(rcv.requests, req)
+	rcv.bodies = append(rcv.bodies, body)
+	w.WriteHeader(rcv.status)
+}
+
+func (rcv *receiver) setStatus(status int) {
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+
+	rcv.status = status
+}
+
+func (rcv *receiver) received() int {
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+
+	return len(rcv.requests)
+}
+
+func buildDispatcher(clock *fakeClock, store *memoryDeliveryStore) *webhook.Dispatcher {
+	dsp := webhook.NewDispatcher()
+	dsp.Deliveries = store
+	dsp.Now = clock.Now
+	dsp.MaxAttempts = 3
+	dsp.Backoff = time.Minute
+	dsp.MaxBackoff = 90 * time.Second
+	return dsp
+}
+
+// addDelivery adds a subscription for the URL and a pending delivery to it that is due now.
+func addDelivery(store *memoryDeliveryStore, clock *fakeClock, url string) webhook.Delivery {
+	s := webhook.Subscription{
+		ID:       "subscription-1",
+		TenantID: "tenant-1",
+		URL:      url,
+		Events:   []string{webhook.EventTaskCreated},
+		Secret:   "whsec_secret",
+	}
+	store.subscriptions[s.ID] = s
+
+	d := webhook.Delivery{
+		ID:              "delivery-1",
+		TenantID:        "tenant-1",
+		SubscriptionID:  s.ID,
+		EventID:         "event-1",
+		EventType:       webhook.EventTaskCreated,
+		Payload:         []byte(`{"id":"event-1","type":"task.created"}`),
+		Status:          webhook.StatusPending,
+		DateNextAttempt: clock.Now(),
+		DateCreated:     clock.Now(),
+		DateUpdated:     clock.Now(),
+	}
+	store.deliveries[d.ID] = d
+
+	return d
+}
+
+func TestDispatcherRunDelivers(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)}
+
+	rcv := &receiver{status: http.StatusNoContent}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+
+	store := newMemoryDeliveryStore()
+	d := addDelivery(store, clock, srv.URL)
+
+	dsp := buildDispatcher(clock, store)
+	err := dsp.Run(ctx)
+	require.NoError(t, err)
+
+	require.Equal(t, 1, rcv.received(), "Delivery was not sent")
+	req := rcv.requests[0]
+	assert.Equal(t, http.MethodPost, req.Method)
+	assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+	assert.Equal(t, d.ID, req.Header.Get("Webhook-Id"))
+	assert.Equal(t, webhook.EventTaskCreated, req.Header.Get("Webhook-Event"))
+	assert.Equal(t, d.Payload, rcv.bodies[0])
+
+	// Subscribers verify the signature with the secret that they were given
+	err = webhook.Verify("whsec_secret", req.Header.Get(webhook.SignatureHeader), rcv.bodies[0], time.Minute, clock.Now())
+	assert.NoError(t, err, "Signature does not verify")
+
+	delivered := store.delivery(d.ID)
+	assert.Equal(t, webhook.StatusSucceeded, delivered.Status)
+	assert.Equal(t, 1, delivered.Attempts)
+	assert.Equal(t, http.StatusNoContent, delivered.ResponseStatus)
+	assert.Empty(t, delivered.Error)
+
+	// Delivered deliveries are not sent again
+	clock.Advance(time.Hour)
+	err = dsp.Run(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, rcv.received(), "Delivery was sent again")
+}
+
+func TestDispatcherRunRetriesWithBackoff(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)}
+
+	rcv := &receiver{status: http.StatusServiceUnavailable}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+
+	store := newMemoryDeliveryStore()
+	d := addDelivery(store, clock, srv.URL)
+
+	dsp := buildDispatcher(clock, store)
+	err := dsp.Run(ctx)
+	require.NoError(t, err)
+
+	failed := store.delivery(d.ID)
+	assert.Equal(t, webhook.StatusPending, failed.Status)
+	assert.Equal(t, 1, failed.Attempts)
+	assert.Equal(t, http.StatusServiceUnavailable, failed.ResponseStatus)
+	assert.Equal(t, "subscription responded with status 503", failed.Error)
+	assert.Equal(t, clock.Now().Add(time.Minute), failed.DateNextAttempt, "Incorrect first backoff")
+
+	// Not retried until the backoff has passed
+	clock.Advance(30 * time.Second)
+	err = dsp.Run(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, rcv.received(), "Delivery was retried before the backoff passed")
+
+	// The backoff doubles but is capped
+	clock.Advance(30 * time.Second)
+	err = dsp.Run(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, rcv.received(), "Delivery was not retried")
+	assert.Equal(t, clock.Now().Add(90*time.Second), store.delivery(d.ID).DateNextAttempt, "Incorrect capped backoff")
+
+	// Succeeds once the subscriber recovers
+	rcv.setStatus(http.StatusOK)
+	clock.Advance(90 * time.Second)
+	err = dsp.Run(ctx)
+	require.NoError(t, err)
+
+	delivered := store.delivery(d.ID)
+	assert.Equal(t, webhook.StatusSucceeded, delivered.Status)
+	assert.Equal(t, 3, delivered.Attempts)
+	assert.Empty(t, delivered.Error, "Error from earlier attempt was kept")
+}
+
+func TestDispatcherRunDeadLetters(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)}
+
+	rcv := &receiver{status: http.StatusInternalServerError}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+
+	store := newMemoryDeliveryStore()
+	d := addDelivery(store, clock, srv.URL)
+
+	dsp := buildDispatcher(clock, store)
+	for i := 0; i < 5; i++ {
+		err := dsp.Run(ctx)
+		require.NoError(t, err)
+		clock.Advance(time.Hour)
+	}
+
+	assert.Equal(t, 3, rcv.received(), "Delivery was not attempted MaxAttempts times")
+
+	dead := store.delivery(d.ID)
+	assert.Equal(t, webhook.StatusDead, dead.Status)
+	assert.Equal(t, 3, dead.Attempts)
+	assert.Equal(t, "subscription responded with status 500", dead.Error)
+}
+
+func TestDispatcherRunUnreachable(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)}
+
+	// Close the receiver straight away so that nothing is listening
+	srv := httptest.NewServer(&receiver{status: http.StatusOK})
+	srv.Close()
+
+	store := newMemoryDeliveryStore()
+	d := addDelivery(store, clock, srv.URL)
+
+	dsp := buildDispatcher(clock, store)
+	err := dsp.Run(ctx)
+	require.NoError(t, err)
+
+	failed := store.delivery(d.ID)
+	assert.Equal(t, webhook.StatusPending, failed.Status)
+	assert.Equal(t, 1, failed.Attempts)
+	assert.Zero(t, failed.ResponseStatus)
+	assert.NotEmpty(t, failed.Error)
+}
+
+func TestDispatcherRunTimesOut(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)}
+
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	store := newMemoryDeliveryStore()
+	d := addDelivery(store, clock, srv.URL)
+
+	dsp := buildDispatcher(clock, store)
+	dsp.Timeout = 50 * time.Millisecond
+	err := dsp.Run(ctx)
+	require.NoError(t, err)
+
+	failed := store.delivery(d.ID)
+	assert.Equal(t, webhook.StatusPending, failed.Status)
+	assert.Equal(t, 1, failed.Attempts)
+	assert.Contains(t, failed.Error, "context deadline exceeded")
+}
+
+func TestDispatcherRunSkipsDeletedSubscription(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)}
+
+	rcv := &receiver{status: http.StatusOK}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+
+	store := newMemoryDeliveryStore()
+	d := addDelivery(store, clock, srv.URL)
+	delete(store.subscriptions, d.SubscriptionID)
+
+	dsp := buildDispatcher(clock, store)
+	err := dsp.Run(ctx)
+	require.NoError(t, err)
+
+	assert.Zero(t, rcv.received(), "Delivery was sent to deleted subscription")
+}
+
+func TestDispatcherRunPages(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)}
+
+	rcv := &receiver{status: http.StatusOK}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+
+	store := newMemoryDeliveryStore()
+	d := addDelivery(store, clock, srv.URL)
+	for _, id := range []string{"delivery-2", "delivery-3", "delivery-4", "delivery-5"} {
+		other := d
+		other.ID = id
+		store.deliveries[id] = other
+	}
+
+	dsp := buildDispatcher(clock, store)
+	dsp.BatchSize = 2
+	err := dsp.Run(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, 5, rcv.received(), "Not every delivery was sent")
+}
+
+func TestDispatcherRunReturnsClaimError(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)}
+
+	store := newMemoryDeliveryStore()
+	store.claimErr = errors.New("failed")
+
+	dsp := buildDispatcher(clock, store)
+	err := dsp.Run(context.Background())
+	assert.EqualError(t, err, "failed")
+}
@@ -0,0 +1,32 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/jaredpetersen/go-rest-template/internal/outbox"
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+)
+
+// OutboxSink publishes the task events relayed from the outbox to the subscriptions that want them. Each event keeps
+// the ID of its outbox message so that relaying the same message again does not queue duplicate deliveries. Messages of
+// any other type are ignored.
+type OutboxSink struct {
+	Publisher Publisher
+}
+
+// Publish publishes the message as an event.
+func (os OutboxSink) Publish(ctx context.Context, m outbox.Message) error {
+	if !ValidEventType(m.Type) {
+		return nil
+	}
+
+	var t task.Task
+	err := json.Unmarshal(m.Payload, &t)
+	if err != nil {
+		return err
+	}
+
+	e := Event{ID: m.ID, Type: m.Type, TenantID: m.TenantID, Date: m.DateCreated, Data: t}
+	return os.Publisher.Publish(ctx, e)
+}
@@ -0,0 +1,75 @@
+package webhook_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jaredpetersen/go-rest-template/internal/outbox"
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+	"github.com/jaredpetersen/go-rest-template/internal/webhook"
+	webhookmock "github.com/jaredpetersen/go-rest-template/internal/webhook/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutboxSinkPublish(t *testing.T) {
+	ctx := context.Background()
+
+	m := outbox.NewMessage("tenant-1", "task-1", 2, task.EventUpdated, []byte(`{"id":"task-1","description":"Write tests"}`))
+	m.DateCreated = time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+
+	// Set up relevant dependencies
+	wp := webhookmock.Publisher{}
+	expectedEvent := webhook.Event{
+		ID:       m.ID,
+		Type:     webhook.EventTaskUpdated,
+		TenantID: "tenant-1",
+		Date:     m.DateCreated,
+		Data:     task.Task{ID: "task-1", Description: "Write tests"},
+	}
+	wp.On("Publish", ctx, expectedEvent).Return(nil)
+
+	sink := webhook.OutboxSink{Publisher: &wp}
+
+	err := sink.Publish(ctx, *m)
+	require.NoError(t, err)
+
+	wp.AssertExpectations(t)
+}
+
+func TestOutboxSinkPublishIgnoresUnknownTypes(t *testing.T) {
+	ctx := context.Background()
+
+	m := outbox.NewMessage("tenant-1", "project-1", 1, "project.created", []byte(`{"id":"project-1"}`))
+
+	// Set up relevant dependencies
+	wp := webhookmock.Publisher{}
+
+	sink := webhook.OutboxSink{Publisher: &wp}
+
+	err := sink.Publish(ctx, *m)
+	require.NoError(t, err)
+
+	wp.AssertNotCalled(t, "Publish")
+}
+
+func TestOutboxSinkPublishReturnsError(t *testing.T) {
+	ctx := context.Background()
+
+	m := outbox.NewMessage("tenant-1", "task-1", 1, task.EventCreated, []byte(`{"id":"task-1"}`))
+
+	// Set up relevant dependencies
+	wp := webhookmock.Publisher{}
+	wpErr := errors.New("failed")
+	wp.On("Publish", ctx, mock.AnythingOfType("webhook.Event")).Return(wpErr)
+
+	sink := webhook.OutboxSink{Publisher: &wp}
+
+	err := sink.Publish(ctx, *m)
+	assert.Equal(t, wpErr, err)
+
+	wp.AssertExpectations(t)
+}
@@ -0,0 +1,177 @@
+// Package webhook notifies HTTP endpoints that have subscribed to a tenant's task lifecycle events.
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+)
+
+// Types of events that may be subscribed to.
+const (
+	EventTaskCreated = task.EventCreated
+	EventTaskUpdated = task.EventUpdated
+	EventTaskDeleted = task.EventDeleted
+)
+
+// EventTypes lists every type of event.
+var EventTypes = []string{EventTaskCreated, EventTaskUpdated, EventTaskDeleted}
+
+// ValidEventType determines whether or not the event type is one of the known types of events.
+func ValidEventType(eventType string) bool {
+	for _, known := range EventTypes {
+		if eventType == known {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Statuses of a delivery.
+const (
+	// StatusPending deliveries have not been delivered yet and will be attempted again.
+	StatusPending = "pending"
+	// StatusSucceeded deliveries were accepted by the subscriber.
+	StatusSucceeded = "succeeded"
+	// StatusDead deliveries failed too many times and will not be attempted again unless they are redelivered.
+	StatusDead = "dead"
+)
+
+// SignatureHeader is the header that carries the signature of a delivery.
+const SignatureHeader = "Webhook-Signature"
+
+// ErrInvalidSignature indicates that a delivery's signature does not match its payload.
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// Event is something that happened to a task.
+type Event struct {
+	ID       string    `json:"id"`
+	Type     string    `json:"type"`
+	TenantID string    `json:"tenantId"`
+	Date     time.Time `json:"date"`
+	// Data is the task after the change, or before it for deleted tasks.
+	Data task.Task `json:"data"`
+}
+
+// NewEvent creates a new event about the task.
+func NewEvent(eventType string, t task.Task) *Event {
+	return &Event{ID: uuid.New().String(), Type: eventType, TenantID: t.TenantID, Date: time.Now(), Data: t}
+}
+
+// Publisher publishes events to the subscriptions that want them.
+type Publisher interface {
+	Publish(ctx context.Context, es ...Event) error
+}
+
+// Subscription is an HTTP endpoint that wants to be notified about some of a tenant's events.
+type Subscription struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenantId"`
+	OwnerID  string `json:"ownerId"`
+	URL      string `json:"url"`
+	// Events are the types of events that the subscription wants.
+	Events []string `json:"events"`
+	// Secret signs the payloads sent to the subscription so that the subscriber can verify where they came from.
+	Secret      string    `json:"-"`
+	DateCreated time.Time `json:"dateCreated"`
+	DateUpdated time.Time `json:"dateUpdated"`
+}
+
+// NewSubscription creates a new subscription with default values and a random secret. The returned pointer will never
+// be nil.
+func NewSubscription() (*Subscription, error) {
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return &Subscription{
+		ID:          uuid.New().String(),
+		Secret:      "whsec_" + hex.EncodeToString(secret),
+		DateCreated: now,
+		DateUpdated: now,
+	}, nil
+}
+
+// Delivery is an attempt to send an event to a subscription.
+type Delivery struct {
+	ID             string `json:"id"`
+	TenantID       string `json:"tenantId"`
+	SubscriptionID string `json:"subscriptionId"`
+	EventID        string `json:"eventId"`
+	EventType      string `json:"eventType"`
+	// Payload is the body that is sent to the subscription.
+	Payload []byte `json:"payload"`
+	Status  string `json:"status"`
+	// Attempts is the number of times that delivery has been attempted.
+	Attempts int `json:"attempts"`
+	// DateNextAttempt is when delivery will be attempted again. Only meaningful for pending deliveries.
+	DateNextAttempt time.Time `json:"dateNextAttempt"`
+	// ResponseStatus is the HTTP status code of the last response from the subscription. Zero if there was no response.
+	ResponseStatus int `json:"responseStatus"`
+	// Error describes why the last attempt failed. Empty if it succeeded or has not been attempted.
+	Error       string    `json:"error"`
+	DateCreated time.Time `json:"dateCreated"`
+	DateUpdated time.Time `json:"dateUpdated"`
+}
+
+// Sign signs the payload with the secret, returning the value of the signature header. The signature is an HMAC-SHA256
+// of the Unix timestamp and the payload joined by a period, which lets subscribers reject replayed deliveries.
+func Sign(secret string, timestamp time.Time, payload []byte) string {
+	unix := strconv.FormatInt(timestamp.Unix(), 10)
+	return "t=" + unix + ",v1=" + signature(secret, unix, payload)
+}
+
+// Verify checks the value of a signature header against the payload. ErrInvalidSignature is returned if the signature
+// does not match or its timestamp is further than the tolerance from now.
+func Verify(secret string, header string, payload []byte, tolerance time.Duration, now time.Time) error {
+	var unix string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		if strings.HasPrefix(part, "t=") {
+			unix = strings.TrimPrefix(part, "t=")
+		} else if strings.HasPrefix(part, "v1=") {
+			signatures = append(signatures, strings.TrimPrefix(part, "v1="))
+		}
+	}
+
+	seconds, err := strconv.ParseInt(unix, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	age := now.Sub(time.Unix(seconds, 0))
+	if age > tolerance || age < -tolerance {
+		return ErrInvalidSignature
+	}
+
+	expected := signature(secret, unix, payload)
+	for _, sig := range signatures {
+		if hmac.Equal([]byte(sig), []byte(expected)) {
+			return nil
+		}
+	}
+
+	return ErrInvalidSignature
+}
+
+// signature computes the hex encoded HMAC-SHA256 of the timestamp and payload.
+func signature(secret string, unix string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unix))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
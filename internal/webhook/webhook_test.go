@@ -0,0 +1,103 @@
+package webhook_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaredpetersen/go-rest-template/internal/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSign(t *testing.T) {
+	timestamp := time.Unix(1772442000, 0)
+	payload := []byte(`{"id":"someevent"}`)
+
+	header := webhook.Sign("secret", timestamp, payload)
+	assert.Equal(t, "t=1772442000,v1=7768a24942637ce40d9ff4c1842c88725c0e835a8d6e8d077698a65df51bc557", header)
+}
+
+func TestVerify(t *testing.T) {
+	timestamp := time.Unix(1772442000, 0)
+	payload := []byte(`{"id":"someevent"}`)
+	header := webhook.Sign("secret", timestamp, payload)
+
+	err := webhook.Verify("secret", header, payload, 5*time.Minute, timestamp.Add(time.Minute))
+	assert.NoError(t, err)
+}
+
+func TestVerifyInvalid(t *testing.T) {
+	timestamp := time.Unix(1772442000, 0)
+	payload := []byte(`{"id":"someevent"}`)
+	header := webhook.Sign("secret", timestamp, payload)
+
+	testCases := []struct {
+		name    string
+		secret  string
+		header  string
+		payload []byte
+		now     time.Time
+	}{
+		{
+			name:    "WrongSecret",
+			secret:  "othersecret",
+			header:  header,
+			payload: payload,
+			now:     timestamp,
+		},
+		{
+			name:    "ChangedPayload",
+			secret:  "secret",
+			header:  header,
+			payload: []byte(`{"id":"otherevent"}`),
+			now:     timestamp,
+		},
+		{
+			name:    "Replayed",
+			secret:  "secret",
+			header:  header,
+			payload: payload,
+			now:     timestamp.Add(10 * time.Minute),
+		},
+		{
+			name:    "MissingTimestamp",
+			secret:  "secret",
+			header:  header[strings.Index(header, ",")+1:],
+			payload: payload,
+			now:     timestamp,
+		},
+		{
+			name:    "MissingSignature",
+			secret:  "secret",
+			header:  "t=1772442000",
+			payload: payload,
+			now:     timestamp,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := webhook.Verify(tc.secret, tc.header, tc.payload, 5*time.Minute, tc.now)
+			assert.ErrorIs(t, err, webhook.ErrInvalidSignature)
+		})
+	}
+}
+
+func TestNewSubscription(t *testing.T) {
+	s, err := webhook.NewSubscription()
+	require.NoError(t, err)
+	assert.NotEmpty(t, s.ID)
+	assert.True(t, strings.HasPrefix(s.Secret, "whsec_"), "Secret is missing its prefix")
+
+	other, err := webhook.NewSubscription()
+	require.NoError(t, err)
+	assert.NotEqual(t, s.Secret, other.Secret, "Secrets are not random")
+}
+
+func TestValidEventType(t *testing.T) {
+	assert.True(t, webhook.ValidEventType(webhook.EventTaskCreated))
+	assert.True(t, webhook.ValidEventType(webhook.EventTaskUpdated))
+	assert.True(t, webhook.ValidEventType(webhook.EventTaskDeleted))
+	assert.False(t, webhook.ValidEventType("task.archived"))
+}
@@ -0,0 +1,317 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// DBClient is a client for retrieving and manipulating webhook subscriptions and their deliveries in a SQL database
+type DBClient interface {
+	GetSubscription(ctx context.Context, tenantID string, id string) (*Subscription, error)
+	ListSubscriptions(ctx context.Context, tenantID string) ([]Subscription, error)
+	SaveSubscription(ctx context.Context, s Subscription) error
+	UpdateSubscription(ctx context.Context, s Subscription) error
+	DeleteSubscription(ctx context.Context, tenantID string, id string) error
+	Publish(ctx context.Context, es ...Event) error
+	GetDelivery(ctx context.Context, tenantID string, id string) (*Delivery, error)
+	ListDeliveries(ctx context.Context, tenantID string, subscriptionID string, f DeliveryFilter) ([]Delivery, error)
+	ClaimDeliveries(ctx context.Context, now time.Time, until time.Time, limit int) ([]Delivery, error)
+	UpdateDelivery(ctx context.Context, d Delivery) error
+}
+
+// DeliveryFilter narrows down the deliveries that are listed.
+type DeliveryFilter struct {
+	// Status lists the deliveries with the status. Every delivery is listed if empty.
+	Status string
+	Limit  int
+	Offset int
+}
+
+// deliveryColumns are the columns that are scanned by scanDelivery, in order.
+const deliveryColumns = `id, tenant_id, subscription_id, event_id, event_type, payload, status, attempts,
+	date_next_attempt, response_status, error, date_created, date_updated`
+
+// DBRepo is a database repository for webhook subscriptions and their deliveries.
+type DBRepo struct {
+	DB *sql.DB
+}
+
+// GetSubscription retrieves a tenant's subscription from the database using the subscription's ID. If a subscription
+// cannot be found with that ID for the tenant, nil will be returned for both the subscription and error.
+func (dbr DBRepo) GetSubscription(ctx context.Context, tenantID string, id string) (*Subscription, error) {
+	const query = `select owner_id, url, events, secret, date_created, date_updated
+		from webhook_subscription
+		where tenant_id = $1 and id = $2`
+	row := dbr.DB.QueryRowContext(ctx, query, tenantID, id)
+
+	s := Subscription{ID: id, TenantID: tenantID}
+	var events []byte
+	err := row.Scan(&s.OwnerID, &s.URL, &events, &s.Secret, &s.DateCreated, &s.DateUpdated)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(events, &s.Events)
+	if err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+// ListSubscriptions retrieves a tenant's subscriptions from the database, oldest first.
+func (dbr DBRepo) ListSubscriptions(ctx context.Context, tenantID string) ([]Subscription, error) {
+	const query = `select id, owner_id, url, events, secret, date_created, date_updated
+		from webhook_subscription
+		where tenant_id = $1
+		order by date_created, id`
+	rows, err := dbr.DB.QueryContext(ctx, query, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ss := []Subscription{}
+	for rows.Next() {
+		s := Subscription{TenantID: tenantID}
+		var events []byte
+		err = rows.Scan(&s.ID, &s.OwnerID, &s.URL, &events, &s.Secret, &s.DateCreated, &s.DateUpdated)
+		if err != nil {
+			return nil, err
+		}
+
+		err = json.Unmarshal(events, &s.Events)
+		if err != nil {
+			return nil, err
+		}
+
+		ss = append(ss, s)
+	}
+
+	return ss, rows.Err()
+}
+
+// SaveSubscription stores a subscription in the database.
+func (dbr DBRepo) SaveSubscription(ctx context.Context, s Subscription) error {
+	events, err := json.Marshal(s.Events)
+	if err != nil {
+		return err
+	}
+
+	const query = `insert into webhook_subscription (id, tenant_id, owner_id, url, events, secret, date_created, date_updated)
+		values ($1, $2, $3, $4, $5, $6, $7, $8)`
+	_, err = dbr.DB.ExecContext(ctx,
+		query,
+		s.ID,
+		s.TenantID,
+		s.OwnerID,
+		s.URL,
+		string(events),
+		s.Secret,
+		s.DateCreated,
+		s.DateUpdated)
+
+	return err
+}
+
+// UpdateSubscription replaces the URL and events of a tenant's subscription in the database. The secret is left alone.
+func (dbr DBRepo) UpdateSubscription(ctx context.Context, s Subscription) error {
+	events, err := json.Marshal(s.Events)
+	if err != nil {
+		return err
+	}
+
+	const query = `update webhook_subscription
+		set url = $1, events = $2, date_updated = $3
+		where tenant_id = $4 and id = $5`
+	_, err = dbr.DB.ExecContext(ctx, query, s.URL, string(events), s.DateUpdated, s.TenantID, s.ID)
+
+	return err
+}
+
+// DeleteSubscription removes a tenant's subscription and its deliveries from the database, so that any pending
+// deliveries are abandoned.
+func (dbr DBRepo) DeleteSubscription(ctx context.Context, tenantID string, id string) error {
+	tx, err := dbr.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	const deliveryQuery = `delete from webhook_delivery where tenant_id = $1 and subscription_id = $2`
+	_, err = tx.ExecContext(ctx, deliveryQuery, tenantID, id)
+	if err != nil {
+		return err
+	}
+
+	const subscriptionQuery = `delete from webhook_subscription where tenant_id = $1 and id = $2`
+	_, err = tx.ExecContext(ctx, subscriptionQuery, tenantID, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Publish queues a pending delivery of each event for every one of the event's tenant's subscriptions that wants it.
+// Either all of the deliveries are queued or none of them are. Events that have already been published are ignored so
+// that publishing an event again does not queue duplicate deliveries.
+func (dbr DBRepo) Publish(ctx context.Context, es ...Event) error {
+	tx, err := dbr.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	const query = `insert into webhook_delivery (id, tenant_id, subscription_id, event_id, event_type, payload, status,
+			attempts, date_next_attempt, response_status, error, date_created, date_updated)
+		select gen_random_uuid(), tenant_id, id, $2, $3, $4, $5, 0, $6, 0, '', $6, $6
+		from webhook_subscription
+		where tenant_id = $1 and events @> $7
+		on conflict (subscription_id, event_id) do nothing`
+	for _, e := range es {
+		payload, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+
+		eventType, err := json.Marshal([]string{e.Type})
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, query, e.TenantID, e.ID, e.Type, payload, StatusPending, e.Date, string(eventType))
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetDelivery retrieves a tenant's delivery from the database using the delivery's ID. If a delivery cannot be found
+// with that ID for the tenant, nil will be returned for both the delivery and error.
+func (dbr DBRepo) GetDelivery(ctx context.Context, tenantID string, id string) (*Delivery, error) {
+	const query = `select ` + deliveryColumns + `
+		from webhook_delivery
+		where tenant_id = $1 and id = $2`
+	row := dbr.DB.QueryRowContext(ctx, query, tenantID, id)
+
+	d, err := scanDelivery(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// ListDeliveries retrieves the deliveries to a tenant's subscription from the database, newest first.
+func (dbr DBRepo) ListDeliveries(ctx context.Context, tenantID string, subscriptionID string, f DeliveryFilter) ([]Delivery, error) {
+	const query = `select ` + deliveryColumns + `
+		from webhook_delivery
+		where tenant_id = $1 and subscription_id = $2 and ($3 = '' or status = $3)
+		order by date_created desc, id desc
+		limit $4 offset $5`
+	rows, err := dbr.DB.QueryContext(ctx, query, tenantID, subscriptionID, f.Status, f.Limit, f.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ds := []Delivery{}
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		ds = append(ds, *d)
+	}
+
+	return ds, rows.Err()
+}
+
+// ClaimDeliveries retrieves up to limit pending deliveries, across every tenant, that are due to be attempted by now,
+// soonest first. The claimed deliveries are not due again until the given time so that other replicas leave them alone
+// while they are attempted, and are picked up again if the replica goes away before recording the attempt.
+func (dbr DBRepo) ClaimDeliveries(ctx context.Context, now time.Time, until time.Time, limit int) ([]Delivery, error) {
+	const query = `update webhook_delivery
+		set date_next_attempt = $3
+		where status = $1 and date_next_attempt <= $2
+		order by date_next_attempt
+		limit $4
+		returning ` + deliveryColumns
+	rows, err := dbr.DB.QueryContext(ctx, query, StatusPending, now, until, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ds := []Delivery{}
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		ds = append(ds, *d)
+	}
+
+	return ds, rows.Err()
+}
+
+// UpdateDelivery replaces the outcome of a tenant's delivery in the database.
+func (dbr DBRepo) UpdateDelivery(ctx context.Context, d Delivery) error {
+	const query = `update webhook_delivery
+		set status = $1, attempts = $2, date_next_attempt = $3, response_status = $4, error = $5, date_updated = $6
+		where tenant_id = $7 and id = $8`
+	_, err := dbr.DB.ExecContext(ctx,
+		query,
+		d.Status,
+		d.Attempts,
+		d.DateNextAttempt,
+		d.ResponseStatus,
+		d.Error,
+		d.DateUpdated,
+		d.TenantID,
+		d.ID)
+
+	return err
+}
+
+// scanner is implemented by both sql.Row and sql.Rows.
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanDelivery scans the deliveryColumns of a row into a delivery.
+func scanDelivery(row scanner) (*Delivery, error) {
+	var d Delivery
+	err := row.Scan(
+		&d.ID,
+		&d.TenantID,
+		&d.SubscriptionID,
+		&d.EventID,
+		&d.EventType,
+		&d.Payload,
+		&d.Status,
+		&d.Attempts,
+		&d.DateNextAttempt,
+		&d.ResponseStatus,
+		&d.Error,
+		&d.DateCreated,
+		&d.DateUpdated)
+	if err != nil {
+		return nil, err
+	}
+
+	return &d, nil
+}
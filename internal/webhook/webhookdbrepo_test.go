@@ -0,0 +1,226 @@
+package webhook_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	_ "github.com/jackc/pgx/v4/stdlib"
+	"github.com/jaredpetersen/go-rest-template/internal/migration"
+	"github.com/jaredpetersen/go-rest-template/internal/task"
+	"github.com/jaredpetersen/go-rest-template/internal/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+type cockroachDBContainer struct {
+	testcontainers.Container
+	URI string
+}
+
+func setupCockroachDB(ctx context.Context) (*cockroachDBContainer, error) {
+	req := testcontainers.ContainerRequest{
+		Image:        "cockroachdb/cockroach:latest-v21.1",
+		ExposedPorts: []string{"26257/tcp", "8080/tcp"},
+		WaitingFor:   wait.ForHTTP("/health").WithPort("8080"),
+		Cmd:          []string{"start-single-node", "--insecure"},
+		SkipReaper:   true,
+	}
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	mappedPort, err := container.MappedPort(ctx, "26257")
+	if err != nil {
+		return nil, err
+	}
+
+	hostIP, err := container.Host(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	uri := fmt.Sprintf("postgres://root@%s:%s", hostIP, mappedPort.Port())
+
+	return &cockroachDBContainer{Container: container, URI: uri}, nil
+}
+
+func initCockroachDB(ctx context.Context, db *sql.DB) error {
+	const query = `CREATE DATABASE projectmanagement`
+	_, err := db.ExecContext(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return migration.Migrate(ctx, db)
+}
+
+func saveSubscription(t *testing.T, ctx context.Context, dbr webhook.DBRepo, events ...string) webhook.Subscription {
+	s, err := webhook.NewSubscription()
+	require.NoError(t, err)
+	s.TenantID = "tenant-1"
+	s.OwnerID = "user-1"
+	s.URL = "https://example.com/webhook"
+	s.Events = events
+
+	err = dbr.SaveSubscription(ctx, *s)
+	require.NoError(t, err, "SaveSubscription returned error")
+
+	return *s
+}
+
+func TestIntegrationDBRepoSubscriptions(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+
+	cdbContainer, err := setupCockroachDB(ctx)
+	require.NoError(t, err, "Failed to start up CockroachDB container")
+	defer cdbContainer.Terminate(ctx)
+
+	db, err := sql.Open("pgx", cdbContainer.URI+"/projectmanagement")
+	require.NoError(t, err, "Failed to open connection to CockroachDB")
+	defer db.Close()
+
+	err = initCockroachDB(ctx, db)
+	require.NoError(t, err, "Failed to initialize CockroachDB")
+
+	dbr := webhook.DBRepo{DB: db}
+
+	s := saveSubscription(t, ctx, dbr, webhook.EventTaskCreated, webhook.EventTaskDeleted)
+
+	saved, err := dbr.GetSubscription(ctx, s.TenantID, s.ID)
+	require.NoError(t, err, "GetSubscription returned error")
+	require.NotNil(t, saved, "GetSubscription did not return the subscription")
+	assert.Equal(t, s.URL, saved.URL)
+	assert.Equal(t, s.Events, saved.Events)
+	assert.Equal(t, s.Secret, saved.Secret)
+
+	other, err := dbr.GetSubscription(ctx, "tenant-2", s.ID)
+	require.NoError(t, err, "GetSubscription returned error")
+	assert.Nil(t, other, "GetSubscription returned another tenant's subscription")
+
+	s.URL = "https://example.com/other"
+	s.Events = []string{webhook.EventTaskUpdated}
+	err = dbr.UpdateSubscription(ctx, s)
+	require.NoError(t, err, "UpdateSubscription returned error")
+
+	ss, err := dbr.ListSubscriptions(ctx, s.TenantID)
+	require.NoError(t, err, "ListSubscriptions returned error")
+	require.Len(t, ss, 1)
+	assert.Equal(t, "https://example.com/other", ss[0].URL)
+	assert.Equal(t, []string{webhook.EventTaskUpdated}, ss[0].Events)
+
+	err = dbr.DeleteSubscription(ctx, s.TenantID, s.ID)
+	require.NoError(t, err, "DeleteSubscription returned error")
+
+	deleted, err := dbr.GetSubscription(ctx, s.TenantID, s.ID)
+	require.NoError(t, err, "GetSubscription returned error")
+	assert.Nil(t, deleted, "Subscription was not deleted")
+}
+
+func TestIntegrationDBRepoDeliveries(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+
+	cdbContainer, err := setupCockroachDB(ctx)
+	require.NoError(t, err, "Failed to start up CockroachDB container")
+	defer cdbContainer.Terminate(ctx)
+
+	db, err := sql.Open("pgx", cdbContainer.URI+"/projectmanagement")
+	require.NoError(t, err, "Failed to open connection to CockroachDB")
+	defer db.Close()
+
+	err = initCockroachDB(ctx, db)
+	require.NoError(t, err, "Failed to initialize CockroachDB")
+
+	dbr := webhook.DBRepo{DB: db}
+
+	created := saveSubscription(t, ctx, dbr, webhook.EventTaskCreated)
+	updated := saveSubscription(t, ctx, dbr, webhook.EventTaskUpdated)
+
+	tsk := task.New()
+	tsk.TenantID = "tenant-1"
+	tsk.OwnerID = "user-1"
+	tsk.Description = "Buy milk"
+
+	// Only the subscription that wants the event gets a delivery
+	e := webhook.NewEvent(webhook.EventTaskCreated, *tsk)
+	err = dbr.Publish(ctx, *e)
+	require.NoError(t, err, "Publish returned error")
+
+	// Publishing the same event again does not queue another delivery
+	err = dbr.Publish(ctx, *e)
+	require.NoError(t, err, "Publish returned error")
+
+	ds, err := dbr.ListDeliveries(ctx, "tenant-1", created.ID, webhook.DeliveryFilter{Limit: 10})
+	require.NoError(t, err, "ListDeliveries returned error")
+	require.Len(t, ds, 1)
+	assert.Equal(t, e.ID, ds[0].EventID)
+	assert.Equal(t, webhook.EventTaskCreated, ds[0].EventType)
+	assert.Equal(t, webhook.StatusPending, ds[0].Status)
+
+	var payload webhook.Event
+	err = json.Unmarshal(ds[0].Payload, &payload)
+	require.NoError(t, err, "Payload is not an event")
+	assert.Equal(t, tsk.ID, payload.Data.ID)
+
+	ds, err = dbr.ListDeliveries(ctx, "tenant-1", updated.ID, webhook.DeliveryFilter{Limit: 10})
+	require.NoError(t, err, "ListDeliveries returned error")
+	assert.Empty(t, ds, "Subscription received an event that it did not want")
+
+	// Claimed deliveries are not claimed again until the claim expires
+	now := time.Now().Add(time.Second)
+	claimed, err := dbr.ClaimDeliveries(ctx, now, now.Add(time.Minute), 10)
+	require.NoError(t, err, "ClaimDeliveries returned error")
+	require.Len(t, claimed, 1)
+
+	claimed, err = dbr.ClaimDeliveries(ctx, now, now.Add(time.Minute), 10)
+	require.NoError(t, err, "ClaimDeliveries returned error")
+	assert.Empty(t, claimed, "Claimed delivery was claimed again")
+
+	claimed, err = dbr.ClaimDeliveries(ctx, now.Add(time.Minute), now.Add(2*time.Minute), 10)
+	require.NoError(t, err, "ClaimDeliveries returned error")
+	require.Len(t, claimed, 1, "Expired claim was not claimed again")
+
+	d := claimed[0]
+	d.Status = webhook.StatusDead
+	d.Attempts = 8
+	d.ResponseStatus = 500
+	d.Error = "subscription responded with status 500"
+	err = dbr.UpdateDelivery(ctx, d)
+	require.NoError(t, err, "UpdateDelivery returned error")
+
+	dead, err := dbr.ListDeliveries(ctx, "tenant-1", created.ID, webhook.DeliveryFilter{Status: webhook.StatusDead, Limit: 10})
+	require.NoError(t, err, "ListDeliveries returned error")
+	require.Len(t, dead, 1)
+	assert.Equal(t, 8, dead[0].Attempts)
+	assert.Equal(t, d.Error, dead[0].Error)
+
+	got, err := dbr.GetDelivery(ctx, "tenant-1", d.ID)
+	require.NoError(t, err, "GetDelivery returned error")
+	require.NotNil(t, got)
+	assert.Equal(t, webhook.StatusDead, got.Status)
+
+	// Deleting the subscription deletes its deliveries
+	err = dbr.DeleteSubscription(ctx, "tenant-1", created.ID)
+	require.NoError(t, err, "DeleteSubscription returned error")
+
+	got, err = dbr.GetDelivery(ctx, "tenant-1", d.ID)
+	require.NoError(t, err, "GetDelivery returned error")
+	assert.Nil(t, got, "Delivery was not deleted with its subscription")
+}
@@ -6,14 +6,34 @@ import (
 	"fmt"
 	"github.com/jaredpetersen/go-rest-template/internal/healthcheck"
 	"net/http"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
 	"time"
+	// Recurring tasks are expanded in any time zone, even where the system has no time zone database
+	_ "time/tzdata"
 
+	"github.com/google/uuid"
 	_ "github.com/jackc/pgx/v4/stdlib"
 	"github.com/jaredpetersen/go-health/health"
 	"github.com/jaredpetersen/go-rest-template/internal/app"
+	"github.com/jaredpetersen/go-rest-template/internal/auth"
+	"github.com/jaredpetersen/go-rest-template/internal/blob"
+	"github.com/jaredpetersen/go-rest-template/internal/collab"
+	"github.com/jaredpetersen/go-rest-template/internal/idempotency"
+	"github.com/jaredpetersen/go-rest-template/internal/migration"
+	"github.com/jaredpetersen/go-rest-template/internal/outbox"
+	"github.com/jaredpetersen/go-rest-template/internal/policy"
+	"github.com/jaredpetersen/go-rest-template/internal/project"
+	"github.com/jaredpetersen/go-rest-template/internal/projectmgr"
+	"github.com/jaredpetersen/go-rest-template/internal/ratelimit"
 	"github.com/jaredpetersen/go-rest-template/internal/redis"
+	"github.com/jaredpetersen/go-rest-template/internal/reminder"
+	"github.com/jaredpetersen/go-rest-template/internal/startup"
 	"github.com/jaredpetersen/go-rest-template/internal/task"
 	"github.com/jaredpetersen/go-rest-template/internal/taskmgr"
+	"github.com/jaredpetersen/go-rest-template/internal/webhook"
 	"github.com/rs/zerolog/log"
 )
 
@@ -52,14 +72,412 @@ func main() {
 	redisHealthCheck.TTL = healthCheckTTL
 	redisHealthCheck.Timeout = healthCheckTimeout
 
+	// Slower moving resources don't need to be checked as often
+	infrequentHealthCheckTTL := time.Second * 30
+	dbLatencyWarnThreshold := time.Millisecond * 500
+	dbPoolWarnSaturation := 0.9
+	dbClockSkewWarnThreshold := time.Second
+	redisMemoryWarnUsage := 0.9
+	redisReplicationWarnLag := time.Second * 10
+	diskWarnFreeBytes := uint64(1 << 30)
+	diskDownFreeBytes := uint64(100 << 20)
+
+	dbLatencyHealthCheck := health.NewCheck("database-latency",
+		healthcheck.BuildDBLatencyHealthCheckFunc(db, dbLatencyWarnThreshold))
+	dbLatencyHealthCheck.TTL = healthCheckTTL
+	dbLatencyHealthCheck.Timeout = healthCheckTimeout
+
+	dbPoolHealthCheck := health.NewCheck("database-pool", healthcheck.BuildDBPoolHealthCheckFunc(db, dbPoolWarnSaturation))
+	dbPoolHealthCheck.TTL = healthCheckTTL
+
+	dbMigrationHealthCheck := health.NewCheck("database-migration", healthcheck.BuildMigrationHealthCheckFunc(db))
+	dbMigrationHealthCheck.TTL = infrequentHealthCheckTTL
+	dbMigrationHealthCheck.Timeout = healthCheckTimeout
+
+	dbClockSkewHealthCheck := health.NewCheck("database-clock-skew",
+		healthcheck.BuildDBClockSkewHealthCheckFunc(db, dbClockSkewWarnThreshold))
+	dbClockSkewHealthCheck.TTL = infrequentHealthCheckTTL
+	dbClockSkewHealthCheck.Timeout = healthCheckTimeout
+
+	redisMemoryHealthCheck := health.NewCheck("redis-memory",
+		healthcheck.BuildRedisMemoryHealthCheckFunc(rdb, redisMemoryWarnUsage))
+	redisMemoryHealthCheck.TTL = infrequentHealthCheckTTL
+	redisMemoryHealthCheck.Timeout = healthCheckTimeout
+
+	redisReplicationHealthCheck := health.NewCheck("redis-replication",
+		healthcheck.BuildRedisReplicationHealthCheckFunc(rdb, redisReplicationWarnLag))
+	redisReplicationHealthCheck.TTL = infrequentHealthCheckTTL
+	redisReplicationHealthCheck.Timeout = healthCheckTimeout
+
+	diskHealthCheck := health.NewCheck("disk",
+		healthcheck.BuildDiskHealthCheckFunc(os.TempDir(), diskWarnFreeBytes, diskDownFreeBytes))
+	diskHealthCheck.TTL = infrequentHealthCheckTTL
+
 	healthMonitor := health.New()
-	healthMonitor.Monitor(ctx, redisHealthCheck, dbHealthCheck)
+	healthMonitor.Monitor(ctx,
+		redisHealthCheck,
+		dbHealthCheck,
+		dbLatencyHealthCheck,
+		dbPoolHealthCheck,
+		dbMigrationHealthCheck,
+		dbClockSkewHealthCheck,
+		redisMemoryHealthCheck,
+		redisReplicationHealthCheck,
+		diskHealthCheck)
 	a.HealthMonitor = healthMonitor
 
+	// Set up liveness
+	// These checks must only look at the internal state of the process so that dependency outages don't cause restarts
+	heartbeatInterval := time.Second
+	heartbeatMaxAge := time.Second * 10
+	// Must comfortably exceed the longest health check TTL plus its timeout
+	watchdogMaxAge := time.Minute * 2
+	maxGoroutines := 10000
+	maxHeapBytes := uint64(1 << 30)
+
+	heartbeat := healthcheck.NewHeartbeat()
+	heartbeat.Start(ctx, heartbeatInterval)
+
+	a.LivenessChecks = []health.Check{
+		health.NewCheck("heartbeat", healthcheck.BuildHeartbeatHealthCheckFunc(heartbeat, heartbeatMaxAge)),
+		health.NewCheck("watchdog", healthcheck.BuildMonitorWatchdogHealthCheckFunc(healthMonitor, watchdogMaxAge)),
+		health.NewCheck("goroutines", healthcheck.BuildGoroutineHealthCheckFunc(maxGoroutines)),
+		health.NewCheck("memory", healthcheck.BuildMemoryHealthCheckFunc(maxHeapBytes)),
+	}
+
+	// Set up authentication
+	// Each mechanism is only enabled when its file is provided
+	authenticator := auth.Authenticator{}
+
+	if jwksPath := os.Getenv("AUTH_JWKS_FILE"); jwksPath != "" {
+		keySet, err := auth.LoadKeySet(jwksPath)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to load JWKS file")
+		}
+		// Pick up rotated keys without requiring a restart
+		keySet.Watch(ctx, time.Minute)
+
+		authenticator.JWT = &auth.JWTValidator{
+			Keys:     keySet,
+			Issuer:   os.Getenv("AUTH_JWT_ISSUER"),
+			Audience: os.Getenv("AUTH_JWT_AUDIENCE"),
+		}
+	}
+
+	if apiKeysPath := os.Getenv("AUTH_API_KEYS_FILE"); apiKeysPath != "" {
+		apiKeys, err := auth.LoadAPIKeyStore(apiKeysPath)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to load API keys file")
+		}
+
+		authenticator.APIKeys = apiKeys
+	}
+
+	a.Authenticator = authenticator
+
+	// Set up authorization
+	policyPath := os.Getenv("POLICY_FILE")
+	if policyPath == "" {
+		policyPath = "config/policy.json"
+	}
+	authorizer, err := policy.Load(policyPath)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load policy file")
+	}
+	a.Authorizer = authorizer
+
+	// Set up rate limiting
+	// Limits are shared across replicas through Redis, falling back to per-replica limits when Redis is unavailable
+	a.RateLimiter = ratelimit.FallbackLimiter{
+		Primary:  ratelimit.RedisLimiter{Redis: rdb},
+		Fallback: ratelimit.NewLocalLimiter(),
+		Timeout:  100 * time.Millisecond,
+	}
+	a.RateLimits = map[string]ratelimit.Limit{
+		app.RouteAuthenticate:       {Rate: 100, Period: time.Second, Burst: 200},
+		app.RouteTasksGet:           ratelimit.PerSecond(50),
+		app.RouteTasksPermissions:   ratelimit.PerSecond(50),
+		app.RouteTasksSave:          {Rate: 10, Period: time.Second, Burst: 20},
+		app.RouteTasksUpdate:        {Rate: 10, Period: time.Second, Burst: 20},
+		app.RouteTasksDelete:        {Rate: 10, Period: time.Second, Burst: 20},
+		app.RouteTasksBatchGet:      ratelimit.PerSecond(10),
+		app.RouteTasksBatchCreate:   ratelimit.PerSecond(2),
+		app.RouteTasksSearch:        ratelimit.PerSecond(10),
+		app.RouteTasksList:          ratelimit.PerSecond(10),
+		app.RouteTasksSubtasks:      ratelimit.PerSecond(10),
+		app.RouteTasksOccurrences:   ratelimit.PerSecond(10),
+		app.RouteTasksHistory:       ratelimit.PerSecond(10),
+		app.RouteTasksRevert:        ratelimit.PerSecond(2),
+		app.RouteTasksBlockers:      ratelimit.PerSecond(10),
+		app.RouteTasksSort:          ratelimit.PerSecond(10),
+		app.RouteTasksEvents:        ratelimit.PerSecond(2),
+		app.RouteTasksCollaboration: ratelimit.PerSecond(2),
+		app.RouteCommentsGet:        ratelimit.PerSecond(50),
+		app.RouteCommentsList:       ratelimit.PerSecond(10),
+		app.RouteCommentsSave:       ratelimit.PerSecond(2),
+		app.RouteCommentsUpdate:     ratelimit.PerSecond(2),
+		app.RouteCommentsDelete:     ratelimit.PerSecond(2),
+		app.RouteAttachmentsGet:     ratelimit.PerSecond(50),
+		app.RouteAttachmentsList:    ratelimit.PerSecond(10),
+		app.RouteAttachmentsContent: ratelimit.PerSecond(10),
+		app.RouteAttachmentsSave:    ratelimit.PerSecond(2),
+		app.RouteAttachmentsDelete:  ratelimit.PerSecond(2),
+		app.RouteTagsGet:            ratelimit.PerSecond(50),
+		app.RouteTagsList:           ratelimit.PerSecond(10),
+		app.RouteTagsSave:           ratelimit.PerSecond(2),
+		app.RouteTagsUpdate:         ratelimit.PerSecond(2),
+		app.RouteTagsDelete:         ratelimit.PerSecond(2),
+		app.RouteProjectsGet:        ratelimit.PerSecond(50),
+		app.RouteProjectsList:       ratelimit.PerSecond(10),
+		app.RouteProjectsSave:       ratelimit.PerSecond(2),
+		app.RouteProjectsUpdate:     ratelimit.PerSecond(2),
+		app.RouteProjectsArchive:    ratelimit.PerSecond(2),
+		app.RouteProjectTasksList:   ratelimit.PerSecond(10),
+		app.RouteProjectTasksSave:   {Rate: 10, Period: time.Second, Burst: 20},
+		app.RouteWebhooksGet:        ratelimit.PerSecond(50),
+		app.RouteWebhooksList:       ratelimit.PerSecond(10),
+		app.RouteWebhooksSave:       ratelimit.PerSecond(2),
+		app.RouteWebhooksUpdate:     ratelimit.PerSecond(2),
+		app.RouteWebhooksDelete:     ratelimit.PerSecond(2),
+		app.RouteWebhooksDeliveries: ratelimit.PerSecond(10),
+	}
+
+	// Set up idempotency
+	// Responses are stored in Redis, falling back to the database when Redis is unavailable
+	idempotencyDBStore := idempotency.DBStore{DB: db}
+	a.IdempotencyStore = idempotency.FallbackStore{
+		Primary:  idempotency.RedisStore{Redis: rdb},
+		Fallback: idempotencyDBStore,
+	}
+	a.IdempotencyWindow = 24 * time.Hour
+
+	// Records in Redis expire on their own but every replica prunes the ones in the database
+	idempotencyPruneInterval := 10 * time.Minute
+	idempotencyPruner := idempotency.NewPruner()
+	idempotencyPruner.Store = idempotencyDBStore
+
+	// Set up task search, which is shared by the task and project managers. Swap in task.NewMemoryIndex() to search in
+	// process instead of in the database
+	taskSearcher := task.DBSearcher{DB: db}
+
+	// Set up project manager
+	projectCacheClient := project.CacheRepo{Redis: rdb}
+	a.ProjectManager = projectmgr.Manager{
+		ProjectCacheClient: projectCacheClient,
+		ProjectDBClient:    project.DBRepo{DB: db},
+		TaskSearcher:       taskSearcher,
+	}
+
+	// Set up webhooks
+	webhookDBClient := webhook.DBRepo{DB: db}
+	a.WebhookManager = webhookDBClient
+
+	// Every replica runs a dispatcher, claiming deliveries so that each is only attempted by one replica at a time
+	webhookInterval := 5 * time.Second
+	webhookDispatcher := webhook.NewDispatcher()
+	webhookDispatcher.Deliveries = webhookDBClient
+
+	// Set up blob storage
+	// Attachments are kept on the local filesystem unless an S3-compatible bucket is configured
+	var blobStore blob.Store = blob.FSStore{Dir: "data/attachments"}
+	if blobDir := os.Getenv("BLOB_DIR"); blobDir != "" {
+		blobStore = blob.FSStore{Dir: blobDir}
+	}
+	if bucket := os.Getenv("S3_BUCKET"); bucket != "" {
+		if os.Getenv("S3_ENDPOINT") == "" {
+			log.Fatal().Msg("S3_ENDPOINT must be set along with S3_BUCKET")
+		}
+
+		blobStore = blob.S3Store{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Bucket:          bucket,
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+			Region:          os.Getenv("S3_REGION"),
+		}
+	}
+
+	a.AttachmentMaxSize = 25 << 20
+	if attachmentMaxSize := os.Getenv("ATTACHMENT_MAX_SIZE"); attachmentMaxSize != "" {
+		maxSize, err := strconv.ParseInt(attachmentMaxSize, 10, 64)
+		if err != nil || maxSize <= 0 {
+			log.Fatal().Err(err).Msg("Failed to parse attachment max size")
+		}
+
+		a.AttachmentMaxSize = maxSize
+	}
+
 	// Set up task manager
 	taskCacheClient := task.CacheRepo{Redis: rdb}
 	taskDBClient := task.DBRepo{DB: db}
-	a.TaskManager = taskmgr.Manager{TaskDBClient: taskDBClient, TaskCacheClient: taskCacheClient}
+	tagDBClient := task.TagDBRepo{DB: db}
+	dependencyDBClient := task.DependencyDBRepo{DB: db}
+	historyDBClient := task.HistoryDBRepo{DB: db}
+	commentDBClient := task.CommentDBRepo{DB: db}
+	commentCacheClient := task.CommentCacheRepo{Redis: rdb}
+	taskManager := taskmgr.Manager{
+		TaskDBClient:       taskDBClient,
+		TaskCacheClient:    taskCacheClient,
+		TaskSearcher:       taskSearcher,
+		TagDBClient:        tagDBClient,
+		DependencyDBClient: dependencyDBClient,
+		HistoryDBClient:    historyDBClient,
+		CommentDBClient:    commentDBClient,
+		CommentCacheClient: commentCacheClient,
+		AttachmentDBClient: task.AttachmentDBRepo{DB: db},
+		BlobStore:          blobStore,
+		// Tasks change the task counts of their projects
+		ProjectCacheClient: projectCacheClient,
+	}
+	a.TaskManager = taskManager
+	a.TagManager = taskManager
+	a.DependencyManager = taskManager
+	a.HistoryManager = taskManager
+	a.CommentManager = taskManager
+	a.AttachmentManager = taskManager
+
+	// Every replica prunes the history of tasks, which is safe to do concurrently
+	historyPruneInterval := time.Hour
+	historyPruner := task.NewHistoryPruner()
+	historyPruner.History = historyDBClient
+
+	if historyRetention := os.Getenv("TASK_HISTORY_RETENTION"); historyRetention != "" {
+		retention, err := time.ParseDuration(historyRetention)
+		if err != nil || retention <= 0 {
+			log.Fatal().Err(err).Msg("Failed to parse task history retention")
+		}
+
+		historyPruner.Retention = retention
+	}
+
+	// Set up reminders
+	// Every replica runs the scheduler but only the replica that holds the lease sends reminders
+	reminderInterval := 30 * time.Second
+	hostname, _ := os.Hostname()
+
+	reminderScheduler := reminder.NewScheduler()
+	reminderScheduler.Tasks = taskDBClient
+	reminderScheduler.Deliveries = reminder.RedisDeliveryStore{Redis: rdb}
+	reminderScheduler.Lease = reminder.RedisLease{
+		Redis:  rdb,
+		Key:    "reminder.lease",
+		Holder: hostname + "." + uuid.NewString(),
+		// Long enough to survive a missed renewal without handing off to another replica
+		TTL: 3 * reminderInterval,
+	}
+	reminderScheduler.Notifiers["log"] = reminder.LogNotifier{}
+
+	if webhookURL := os.Getenv("REMINDER_WEBHOOK_URL"); webhookURL != "" {
+		reminderScheduler.Notifiers["webhook"] = reminder.WebhookNotifier{
+			URL:    webhookURL,
+			Client: &http.Client{Timeout: reminderScheduler.Timeout},
+		}
+	}
+
+	if smtpAddr := os.Getenv("REMINDER_SMTP_ADDR"); smtpAddr != "" {
+		smtpNotifier := reminder.SMTPNotifier{
+			Addr: smtpAddr,
+			From: os.Getenv("REMINDER_SMTP_FROM"),
+			To:   strings.Split(os.Getenv("REMINDER_SMTP_TO"), ","),
+		}
+		if username := os.Getenv("REMINDER_SMTP_USERNAME"); username != "" {
+			smtpHost := strings.Split(smtpAddr, ":")[0]
+			smtpNotifier.Auth = smtp.PlainAuth("", username, os.Getenv("REMINDER_SMTP_PASSWORD"), smtpHost)
+		}
+
+		reminderScheduler.Notifiers["smtp"] = smtpNotifier
+	}
+
+	// Set up the outbox relay
+	// Task changes record their events in the outbox and only the replica that holds the lease relays them, in order
+	outboxInterval := time.Second
+	outboxStream := "outbox.events"
+
+	outboxRelay := outbox.NewRelay()
+	outboxRelay.Messages = outbox.DBRepo{DB: db}
+	outboxRelay.Lease = reminder.RedisLease{
+		Redis:  rdb,
+		Key:    "outbox.lease",
+		Holder: hostname + "." + uuid.NewString(),
+		TTL:    10 * outboxInterval,
+	}
+	outboxRelay.Sinks["redis"] = outbox.RedisStreamSink{
+		Redis:   rdb,
+		Stream:  outboxStream,
+		MaxLen:  100000,
+		Channel: outboxStream,
+	}
+	outboxRelay.Sinks["webhook"] = webhook.OutboxSink{Publisher: webhookDBClient}
+	if os.Getenv("OUTBOX_STDOUT") == "true" {
+		outboxRelay.Sinks["stdout"] = outbox.WriterSink{Writer: os.Stdout}
+	}
+
+	// Set up task event streams
+	// Every replica follows the events that the relay publishes and catches clients up from the stream when they resume
+	taskEventFeed := outbox.NewFeed()
+	taskEventFeed.Redis = rdb
+	taskEventFeed.Stream = outboxStream
+	taskEventFeed.Channel = outboxStream
+	taskEventFeed.Start(ctx)
+	a.TaskEventFeed = taskEventFeed
+	a.TaskEventHeartbeat = 15 * time.Second
+
+	// Set up collaboration
+	// Sessions refresh their presence on every ping, so presence outlives a few missed refreshes before it expires
+	collabPingInterval := 30 * time.Second
+	taskPresence := collab.NewPresence()
+	taskPresence.Redis = rdb
+	taskPresence.Channel = "presence"
+	taskPresence.TTL = 3 * collabPingInterval
+	taskPresence.Start(ctx)
+	a.TaskPresence = taskPresence
+	a.CollabPingInterval = collabPingInterval
+	a.CollabMaxConnections = 1000
+	a.CollabMaxConnectionsPerPrincipal = 5
+
+	// Set up startup
+	runMigrations := true
+	startupTimeout := time.Minute
+
+	startupSteps := []startup.Step{
+		{Name: "database", Func: db.PingContext},
+	}
+	if runMigrations {
+		startupSteps = append(startupSteps, startup.Step{
+			Name: "migration",
+			Func: func(ctx context.Context) error { return migration.Migrate(ctx, db) },
+		})
+	}
+	startupSteps = append(startupSteps,
+		startup.Step{Name: "cache", Func: rdb.Ping},
+		startup.Step{Name: "health", Func: func(ctx context.Context) error {
+			// Wait for every health check to report at least once so that readiness reflects reality
+			for name, checkStatus := range healthMonitor.Check().CheckStatuses {
+				if checkStatus.Timestamp.IsZero() {
+					return fmt.Errorf("health check %s has not run", name)
+				}
+			}
+			return nil
+		}},
+	)
+
+	startupGate := startup.New()
+	startupGate.Timeout = startupTimeout
+	a.StartupGate = startupGate
+
+	go func() {
+		err := startupGate.Run(ctx, startupSteps...)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to start up")
+		}
+
+		// Reminders, events, webhooks, and pruning need the migrated database
+		reminderScheduler.Start(ctx, reminderInterval)
+		outboxRelay.Start(ctx, outboxInterval)
+		webhookDispatcher.Start(ctx, webhookInterval)
+		historyPruner.Start(ctx, historyPruneInterval)
+		idempotencyPruner.Start(ctx, idempotencyPruneInterval)
+	}()
 
 	addr := 8080
 	srv := &http.Server{
@@ -67,6 +485,8 @@ func main() {
 		Handler:      a,
 		ReadTimeout:  5 * time.Second,
 		WriteTimeout: 10 * time.Second,
+		// Lets long-lived routes such as task event streams extend the WriteTimeout
+		ConnContext: app.ConnContext,
 	}
 
 	log.Info().Int("port", addr).Msg("Started")